@@ -0,0 +1,29 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package cash implements the "cash" command group.
+package cash
+
+import (
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/cash/cashhistory"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/cash/cashinterest"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/cash/cashmargin"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/cash/cashsettlement"
+)
+
+// NewCommand returns a new cash command group.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Display cash balance information",
+		SubCommands: []*appcmd.Command{
+			cashhistory.NewCommand("history", builder),
+			cashinterest.NewCommand("interest", builder),
+			cashmargin.NewCommand("margin", builder),
+			cashsettlement.NewCommand("settlement", builder),
+		},
+	}
+}
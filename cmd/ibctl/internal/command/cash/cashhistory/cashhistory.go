@@ -0,0 +1,150 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package cashhistory implements the "cash history" command.
+package cashhistory
+
+import (
+	"context"
+	"os"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlcash"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// formatFlagName is the flag name for the output format.
+	formatFlagName = "format"
+	// downloadFlagName is the flag name for downloading fresh data before displaying.
+	downloadFlagName = "download"
+	// currencyFlagName is the flag name for filtering by currency.
+	currencyFlagName = "currency"
+)
+
+// NewCommand returns a new cash history command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Reconstruct cash balances over time from transaction history",
+		Long: `Reconstruct cash balances over time from transaction history.
+
+Replays deposits, withdrawals, trades, FX conversions, dividends, withholding
+tax, interest, and fees from all merged data sources, and lists the balance of
+each account and currency at the end of every date with cash movements.
+Negative balances are margin loans.
+
+Balances are only as complete as the history. Use data reconcile --cash to
+compare the reconstructed balances with the IBKR Cash Report.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Format is the output format (table, csv, json).
+	Format string
+	// Download fetches fresh data before displaying.
+	Download bool
+	// Group restricts the output to the accounts in a configured account group.
+	Group string
+	// Currency restricts the output to a single currency.
+	Currency string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+	flagSet.BoolVar(&f.Download, downloadFlagName, false, "Download fresh data before displaying")
+	flagSet.StringVar(&f.Group, ibctlcmd.GroupFlagName, "", "Only include accounts in this account group from ibctl.yaml")
+	flagSet.StringVar(&f.Currency, currencyFlagName, "", "Only include balances in this currency (e.g., USD)")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) error {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	// Download fresh data if --download is set.
+	if flags.Download {
+		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir, "")
+		if err != nil {
+			return err
+		}
+		if err := downloader.Download(ctx); err != nil {
+			return err
+		}
+	}
+	// Merge data from all sources.
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+	)
+	if err != nil {
+		return err
+	}
+	// Restrict to the accounts in --group if set.
+	_, mergedData, err = ibctlcmd.ApplyGroup(config, mergedData, flags.Group)
+	if err != nil {
+		return err
+	}
+	var balances []*ibctlcash.Balance
+	for _, balance := range ibctlcash.GetBalances(mergedData.Trades, mergedData.CashTransactions) {
+		if flags.Currency != "" && balance.Currency != flags.Currency {
+			continue
+		}
+		balances = append(balances, balance)
+	}
+	// Write output in the requested format.
+	writer := os.Stdout
+	switch format {
+	case cliio.FormatTable:
+		rows := make([][]string, 0, len(balances))
+		for _, b := range balances {
+			rows = append(rows, ibctlcash.BalanceToRow(b))
+		}
+		return cliio.WriteTable(writer, ibctlcash.BalanceHeaders(), rows)
+	case cliio.FormatCSV:
+		records := make([][]string, 0, len(balances)+1)
+		records = append(records, ibctlcash.BalanceHeaders())
+		for _, b := range balances {
+			records = append(records, ibctlcash.BalanceToRow(b))
+		}
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, balances...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package cashinterest implements the "cash interest" command.
+package cashinterest
+
+import (
+	"context"
+	"os"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlcash"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// formatFlagName is the flag name for the output format.
+	formatFlagName = "format"
+	// downloadFlagName is the flag name for downloading fresh data before displaying.
+	downloadFlagName = "download"
+	// currencyFlagName is the flag name for filtering by currency.
+	currencyFlagName = "currency"
+	// monthlyFlagName is the flag name for listing the interest rates of each month.
+	monthlyFlagName = "monthly"
+)
+
+// NewCommand returns a new cash interest command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Display the effective interest rates earned and paid on cash",
+		Long: `Display the effective interest rates earned and paid on cash.
+
+IBKR pays interest on credit cash balances and charges interest on debit
+balances monthly, as Broker Interest Received and Paid cash transactions
+described as "<currency> CREDIT INT FOR <MON-YYYY>" and "<currency> DEBIT INT
+FOR <MON-YYYY>". This command lists the interest earned and paid by each
+account in each currency, with the average credit and debit balances and the
+effective annualized rates they imply:
+
+  interest / average balance * 365 / days
+
+By default, the period is every month from the first to the last month with
+interest, so months where a balance earned no interest, such as balances below
+IBKR's minimum for earning interest, lower the effective rate. With --monthly,
+there is one row per month with interest instead.
+
+The balances are reconstructed from transaction history as in cash history,
+so the rates are estimates: IBKR pays and charges tiered rates on settled
+balances. Periods without a credit or debit in the reconstructed balances have
+no earned or paid rate. Use cash margin for margin interest alone.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Format is the output format (table, csv, json).
+	Format string
+	// Download fetches fresh data before displaying.
+	Download bool
+	// Group restricts the output to the accounts in a configured account group.
+	Group string
+	// Currency restricts the output to a single currency.
+	Currency string
+	// Monthly lists the interest rates of each month instead of the whole period.
+	Monthly bool
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+	flagSet.BoolVar(&f.Download, downloadFlagName, false, "Download fresh data before displaying")
+	flagSet.StringVar(&f.Group, ibctlcmd.GroupFlagName, "", "Only include accounts in this account group from ibctl.yaml")
+	flagSet.StringVar(&f.Currency, currencyFlagName, "", "Only include interest in this currency (e.g., USD)")
+	flagSet.BoolVar(&f.Monthly, monthlyFlagName, false, "List the interest rates of each month with interest")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) error {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	// Download fresh data if --download is set.
+	if flags.Download {
+		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir, "")
+		if err != nil {
+			return err
+		}
+		if err := downloader.Download(ctx); err != nil {
+			return err
+		}
+	}
+	// Merge data from all sources.
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+		ibctlmerge.MergeWithTrades(),
+		ibctlmerge.MergeWithCashTransactions(),
+	)
+	if err != nil {
+		return err
+	}
+	// Restrict to the accounts in --group if set.
+	_, mergedData, err = ibctlcmd.ApplyGroup(config, mergedData, flags.Group)
+	if err != nil {
+		return err
+	}
+	var interestRates []*ibctlcash.InterestRate
+	for _, interestRate := range ibctlcash.GetInterestRates(mergedData.Trades, mergedData.CashTransactions, flags.Monthly) {
+		if flags.Currency != "" && interestRate.Currency != flags.Currency {
+			continue
+		}
+		interestRates = append(interestRates, interestRate)
+	}
+	// Write output in the requested format.
+	writer := os.Stdout
+	switch format {
+	case cliio.FormatTable:
+		rows := make([][]string, 0, len(interestRates))
+		for _, r := range interestRates {
+			rows = append(rows, ibctlcash.InterestRateToRow(r))
+		}
+		return cliio.WriteTable(writer, ibctlcash.InterestRateHeaders(), rows)
+	case cliio.FormatCSV:
+		records := make([][]string, 0, len(interestRates)+1)
+		records = append(records, ibctlcash.InterestRateHeaders())
+		for _, r := range interestRates {
+			records = append(records, ibctlcash.InterestRateToRow(r))
+		}
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, interestRates...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
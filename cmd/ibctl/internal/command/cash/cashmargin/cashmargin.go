@@ -0,0 +1,153 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package cashmargin implements the "cash margin" command.
+package cashmargin
+
+import (
+	"context"
+	"os"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlcash"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// formatFlagName is the flag name for the output format.
+	formatFlagName = "format"
+	// downloadFlagName is the flag name for downloading fresh data before displaying.
+	downloadFlagName = "download"
+	// currencyFlagName is the flag name for filtering by currency.
+	currencyFlagName = "currency"
+)
+
+// NewCommand returns a new cash margin command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Display margin interest paid and the annualized rate it implies",
+		Long: `Display margin interest paid and the annualized rate it implies.
+
+IBKR charges interest on negative cash balances monthly, as Broker Interest
+Paid cash transactions described as "<currency> DEBIT INT FOR <MON-YYYY>".
+This command lists the margin interest of each account, currency, and month,
+with the average debit balance of the month and the annualized rate:
+
+  interest / average debit * 365 / days in month
+
+The debit balance is reconstructed from transaction history as in cash history,
+so the rate is an estimate: IBKR charges tiered rates on settled balances.
+Months without a debit in the reconstructed balances have no rate.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Format is the output format (table, csv, json).
+	Format string
+	// Download fetches fresh data before displaying.
+	Download bool
+	// Group restricts the output to the accounts in a configured account group.
+	Group string
+	// Currency restricts the output to a single currency.
+	Currency string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+	flagSet.BoolVar(&f.Download, downloadFlagName, false, "Download fresh data before displaying")
+	flagSet.StringVar(&f.Group, ibctlcmd.GroupFlagName, "", "Only include accounts in this account group from ibctl.yaml")
+	flagSet.StringVar(&f.Currency, currencyFlagName, "", "Only include margin interest in this currency (e.g., USD)")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) error {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	// Download fresh data if --download is set.
+	if flags.Download {
+		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir, "")
+		if err != nil {
+			return err
+		}
+		if err := downloader.Download(ctx); err != nil {
+			return err
+		}
+	}
+	// Merge data from all sources.
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+	)
+	if err != nil {
+		return err
+	}
+	// Restrict to the accounts in --group if set.
+	_, mergedData, err = ibctlcmd.ApplyGroup(config, mergedData, flags.Group)
+	if err != nil {
+		return err
+	}
+	var marginCosts []*ibctlcash.MarginCost
+	for _, marginCost := range ibctlcash.GetMarginCosts(mergedData.Trades, mergedData.CashTransactions) {
+		if flags.Currency != "" && marginCost.Currency != flags.Currency {
+			continue
+		}
+		marginCosts = append(marginCosts, marginCost)
+	}
+	// Write output in the requested format.
+	writer := os.Stdout
+	switch format {
+	case cliio.FormatTable:
+		rows := make([][]string, 0, len(marginCosts))
+		for _, m := range marginCosts {
+			rows = append(rows, ibctlcash.MarginCostToRow(m))
+		}
+		return cliio.WriteTable(writer, ibctlcash.MarginCostHeaders(), rows)
+	case cliio.FormatCSV:
+		records := make([][]string, 0, len(marginCosts)+1)
+		records = append(records, ibctlcash.MarginCostHeaders())
+		for _, m := range marginCosts {
+			records = append(records, ibctlcash.MarginCostToRow(m))
+		}
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, marginCosts...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package cashsettlement implements the "cash settlement" command.
+package cashsettlement
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlcash"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// formatFlagName is the flag name for the output format.
+	formatFlagName = "format"
+	// downloadFlagName is the flag name for downloading fresh data before displaying.
+	downloadFlagName = "download"
+	// currenciesFlagName is the flag name for listing unsettled cash per account and currency.
+	currenciesFlagName = "currencies"
+)
+
+// NewCommand returns a new cash settlement command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Display unsettled trades and reconcile them against the Cash Report",
+		Long: `Display unsettled trades and reconcile them against the Cash Report.
+
+Trades settle after their trade date, typically one business day later for
+stocks. This command lists the trades with a settle date after today and the
+cash each moves when it settles, positive for cash received. FX conversions
+move cash in both currencies of the pair, so they are listed once for each.
+
+With --currencies, the unsettled cash is listed per account and currency
+instead: the number of unsettled trades and their total cash, and the Cash
+Report ending cash, ending settled cash, and unsettled cash (ending cash minus
+ending settled cash). DIFFERENCE is the unsettled cash not explained by the
+unsettled trades, such as unsettled cash transactions or trades missing from
+the data.
+
+The Cash Report is as of the last download, so use --download for a
+reconciliation that matches today's unsettled trades. Ending settled cash
+requires the Ending Settled Cash field in the Cash Report section of the Flex
+Query; accounts downloaded without it only have the unsettled trade cash.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Format is the output format (table, csv, json).
+	Format string
+	// Download fetches fresh data before displaying.
+	Download bool
+	// Group restricts the output to the accounts in a configured account group.
+	Group string
+	// Currencies lists the unsettled cash per account and currency instead of the trades.
+	Currencies bool
+	// MaxAge fails if the position snapshot is older than this, or is 0 for no limit.
+	MaxAge time.Duration
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+	flagSet.BoolVar(&f.Download, downloadFlagName, false, "Download fresh data before displaying")
+	flagSet.StringVar(&f.Group, ibctlcmd.GroupFlagName, "", "Only include accounts in this account group from ibctl.yaml")
+	flagSet.BoolVar(&f.Currencies, currenciesFlagName, false, "List the unsettled cash per account and currency, reconciled against the Cash Report")
+	flagSet.DurationVar(&f.MaxAge, ibctlcmd.MaxAgeFlagName, 0, "Fail if the position snapshot was downloaded longer ago than this (e.g., 24h)")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) error {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	// Download fresh data if --download is set.
+	if flags.Download {
+		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir, "")
+		if err != nil {
+			return err
+		}
+		if err := downloader.Download(ctx); err != nil {
+			return err
+		}
+	}
+	// Merge data from all sources.
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+		ibctlmerge.MergeWithTrades(),
+		ibctlmerge.MergeWithCashPositions(),
+	)
+	if err != nil {
+		return err
+	}
+	// Restrict to the accounts in --group if set.
+	config, mergedData, err = ibctlcmd.ApplyGroup(config, mergedData, flags.Group)
+	if err != nil {
+		return err
+	}
+	// Warn about, or with --max-age fail on, a stale Cash Report.
+	if err := ibctlcmd.CheckSnapshotAge(container, config, flags.Group, flags.MaxAge); err != nil {
+		return err
+	}
+	unsettledTrades := ibctlcash.GetUnsettledTrades(mergedData.Trades, xtime.TimeToDate(time.Now()))
+	writer := os.Stdout
+	if flags.Currencies {
+		settlements := ibctlcash.GetSettlements(unsettledTrades, mergedData.CashPositions)
+		switch format {
+		case cliio.FormatTable:
+			rows := make([][]string, 0, len(settlements))
+			for _, s := range settlements {
+				rows = append(rows, ibctlcash.SettlementToRow(s))
+			}
+			return cliio.WriteTable(writer, ibctlcash.SettlementHeaders(), rows)
+		case cliio.FormatCSV:
+			records := make([][]string, 0, len(settlements)+1)
+			records = append(records, ibctlcash.SettlementHeaders())
+			for _, s := range settlements {
+				records = append(records, ibctlcash.SettlementToRow(s))
+			}
+			return cliio.WriteCSVRecords(writer, records)
+		case cliio.FormatJSON:
+			return cliio.WriteJSON(writer, settlements...)
+		default:
+			return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+		}
+	}
+	switch format {
+	case cliio.FormatTable:
+		rows := make([][]string, 0, len(unsettledTrades))
+		for _, u := range unsettledTrades {
+			rows = append(rows, ibctlcash.UnsettledTradeToRow(u))
+		}
+		return cliio.WriteTable(writer, ibctlcash.UnsettledTradeHeaders(), rows)
+	case cliio.FormatCSV:
+		records := make([][]string, 0, len(unsettledTrades)+1)
+		records = append(records, ibctlcash.UnsettledTradeHeaders())
+		for _, u := range unsettledTrades {
+			records = append(records, ibctlcash.UnsettledTradeToRow(u))
+		}
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, unsettledTrades...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
@@ -0,0 +1,25 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package account implements the "config account" command group.
+package account
+
+import (
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/config/account/accountadd"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/config/account/accountlist"
+)
+
+// NewCommand returns a new account command group.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Manage account alias mappings",
+		SubCommands: []*appcmd.Command{
+			accountadd.NewCommand("add", builder),
+			accountlist.NewCommand("list", builder),
+		},
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package accountadd implements the "config account add" command.
+package accountadd
+
+import (
+	"context"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/spf13/pflag"
+)
+
+// NewCommand returns a new config account add command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <alias> <account-id>",
+		Short: "Add an account alias mapping to the configuration file",
+		Long: `Add an account alias mapping to the accounts block of ibctl.yaml.
+
+The alias must be lowercase alphanumeric with hyphens (e.g., "rrsp", "hold-co"),
+and the account ID is the IBKR account number (e.g., "U1234567"). Comments and
+existing entries in the configuration file are preserved.`,
+		Args: appcmd.ExactArgs(2),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the ibctl directory containing ibctl.yaml.
+	Dir string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+}
+
+func run(_ context.Context, container appext.Container, flags *flags) error {
+	alias, accountID := container.Arg(0), container.Arg(1)
+	if err := ibctlconfig.AddAccount(flags.Dir, alias, accountID); err != nil {
+		return err
+	}
+	container.Logger().Info("account added", "alias", alias)
+	return nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package accountlist implements the "config account list" command.
+package accountlist
+
+import (
+	"context"
+	"sort"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/spf13/pflag"
+)
+
+// formatFlagName is the flag name for the output format.
+const formatFlagName = "format"
+
+// NewCommand returns a new config account list command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "List account alias mappings from the configuration file",
+		Args:  appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the ibctl directory containing ibctl.yaml.
+	Dir string
+	// Format is the output format (table, csv, json).
+	Format string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+}
+
+// account is a single account alias mapping for output.
+type account struct {
+	// Alias is the account alias.
+	Alias string `json:"alias"`
+	// AccountID is the IBKR account ID.
+	AccountID string `json:"account_id"`
+}
+
+func run(_ context.Context, container appext.Container, flags *flags) error {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	aliasToAccountID, err := ibctlconfig.ListAccounts(flags.Dir)
+	if err != nil {
+		return err
+	}
+	// Sort by alias for deterministic output.
+	accounts := make([]*account, 0, len(aliasToAccountID))
+	for alias, accountID := range aliasToAccountID {
+		accounts = append(accounts, &account{Alias: alias, AccountID: accountID})
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Alias < accounts[j].Alias
+	})
+	headers := []string{"ALIAS", "ACCOUNT ID"}
+	rows := make([][]string, 0, len(accounts))
+	for _, a := range accounts {
+		rows = append(rows, []string{a.Alias, a.AccountID})
+	}
+	writer := container.Stdout()
+	switch format {
+	case cliio.FormatTable:
+		return cliio.WriteTable(writer, headers, rows)
+	case cliio.FormatCSV:
+		return cliio.WriteCSVRecords(writer, append([][]string{headers}, rows...))
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, accounts...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
@@ -8,12 +8,15 @@ package config
 import (
 	"buf.build/go/app/appcmd"
 	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/config/account"
 	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/config/configedit"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/config/configflexqueryspec"
 	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/config/configinit"
 	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/config/configvalidate"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/config/symbol"
 )
 
-// NewCommand returns a new config command group with init, edit, and validate sub-commands.
+// NewCommand returns a new config command group with init, edit, validate, flexquery-spec, account, and symbol sub-commands.
 func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
 	return &appcmd.Command{
 		Use:   name,
@@ -22,6 +25,9 @@ func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
 			configinit.NewCommand("init", builder),
 			configedit.NewCommand("edit", builder),
 			configvalidate.NewCommand("validate", builder),
+			configflexqueryspec.NewCommand("flexquery-spec", builder),
+			account.NewCommand("account", builder),
+			symbol.NewCommand("symbol", builder),
 		},
 	}
 }
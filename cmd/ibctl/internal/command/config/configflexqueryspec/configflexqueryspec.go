@@ -0,0 +1,169 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package configflexqueryspec implements the "config flexquery-spec" command.
+package configflexqueryspec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctldownload"
+	"github.com/bufdev/ibctl/internal/pkg/ibkrflexquery"
+	"github.com/bufdev/ibctl/internal/pkg/protoio"
+	"github.com/spf13/pflag"
+)
+
+// checkFlagName is the flag name for checking a saved Flex Query response against the spec.
+const checkFlagName = "check"
+
+// sectionOptions maps sections to the optional settings of the section in the IBKR portal.
+var sectionOptions = map[string]string{
+	ibkrflexquery.SectionTrades: `Options: check "Closed Lots" for ibctl data reconcile --lots (optional)`,
+}
+
+// NewCommand returns a new config flexquery-spec command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Print the Flex Query sections, fields, and settings that ibctl requires",
+		Long: `Print the Flex Query sections, fields, and settings that ibctl requires.
+
+The output is a checklist to follow in the IBKR portal under Performance &
+Reports > Flex Queries when creating the Activity Flex Query. Select all
+fields of each section; the fields listed are the ones ibctl reads.
+
+With --check, the checklist is checked against a saved Flex Query response,
+such as one saved with "ibctl probe --save-raw" or archived in cache/raw/:
+each section is marked [x] if it is included with all fields, or [!] with
+what is missing, and the command fails if anything is missing. Settings
+cannot be checked from a response and stay unchecked.
+
+Downloads run the same check and warn about missing sections and fields.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Check is the path of a saved Flex Query response to check against the spec.
+	Check string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Check, checkFlagName, "", "Check a saved Flex Query XML response against the spec")
+}
+
+func run(_ context.Context, container appext.Container, flags *flags) error {
+	var check *check
+	if flags.Check != "" {
+		var err error
+		check, err = readCheck(container, flags.Check)
+		if err != nil {
+			return err
+		}
+	}
+	if err := writeSpec(container.Stdout(), check); err != nil {
+		return err
+	}
+	if check != nil && (len(check.missingSections) > 0 || len(check.sectionToMissingFields) > 0) {
+		return errors.New("flex query response does not match the spec, update the query in the IBKR portal")
+	}
+	return nil
+}
+
+// check is the result of checking a saved Flex Query response.
+type check struct {
+	// missingSections are the sections that at least one statement does not include.
+	missingSections []string
+	// sectionToMissingFields maps sections to the fields their rows do not include.
+	sectionToMissingFields map[string][]string
+}
+
+// readCheck reads a saved Flex Query response and checks it against the spec.
+func readCheck(container appext.Container, filePath string) (*check, error) {
+	// Read through protoio so encrypted raw archives can be checked.
+	if err := ibctlcmd.ConfigureEncryption(container, false); err != nil {
+		return nil, err
+	}
+	xmlData, err := protoio.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("reading flex query response: %w", err)
+	}
+	if _, err := ibkrflexquery.ParseResponse(xmlData); err != nil {
+		return nil, err
+	}
+	missingSections, err := ibkrflexquery.MissingSections(xmlData)
+	if err != nil {
+		return nil, err
+	}
+	sectionToMissingFields, err := ibkrflexquery.MissingFields(xmlData)
+	if err != nil {
+		return nil, err
+	}
+	return &check{
+		missingSections:        missingSections,
+		sectionToMissingFields: sectionToMissingFields,
+	}, nil
+}
+
+// writeSpec writes the checklist, marking sections with the check if it is non-nil.
+func writeSpec(writer io.Writer, check *check) error {
+	var builder strings.Builder
+	builder.WriteString("Activity Flex Query sections (select all fields of each):\n")
+	for _, sectionSpec := range ibkrflexquery.SectionSpecs {
+		box := "[ ]"
+		var problem string
+		if check != nil {
+			switch {
+			case slices.Contains(check.missingSections, sectionSpec.Section):
+				box, problem = "[!]", "Section not included"
+			case len(check.sectionToMissingFields[sectionSpec.Section]) > 0:
+				box, problem = "[!]", "Missing fields: "+strings.Join(check.sectionToMissingFields[sectionSpec.Section], ", ")
+			default:
+				box = "[x]"
+			}
+		}
+		requirement := "recommended"
+		if slices.Contains(ibctldownload.RequiredSections, sectionSpec.Section) {
+			requirement = "required"
+		}
+		fmt.Fprintf(&builder, "  %s %s (%s)\n", box, sectionSpec.PortalName, requirement)
+		if problem != "" {
+			fmt.Fprintf(&builder, "      %s\n", problem)
+		}
+		fmt.Fprintf(&builder, "      Fields read: %s\n", strings.Join(sectionSpec.Fields, ", "))
+		if option, ok := sectionOptions[sectionSpec.Section]; ok {
+			fmt.Fprintf(&builder, "      %s\n", option)
+		}
+	}
+	var group string
+	for _, setting := range ibkrflexquery.Settings {
+		if setting.Group != group {
+			group = setting.Group
+			fmt.Fprintf(&builder, "%s:\n", group)
+		}
+		fmt.Fprintf(&builder, "  [ ] %s: %s\n", setting.Name, setting.Value)
+	}
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
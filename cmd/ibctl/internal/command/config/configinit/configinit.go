@@ -43,7 +43,7 @@ func newFlags() *flags {
 
 // Bind registers the flag definitions with the given flag set.
 func (f *flags) Bind(flagSet *pflag.FlagSet) {
-	flagSet.StringVar(&f.Dir, ibctlcmd.DirFlagName, ".", "The ibctl directory containing ibctl.yaml")
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
 }
 
 func run(_ context.Context, container appext.Container, flags *flags) error {
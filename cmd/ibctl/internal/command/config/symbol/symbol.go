@@ -0,0 +1,23 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package symbol implements the "config symbol" command group.
+package symbol
+
+import (
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/config/symbol/symbolset"
+)
+
+// NewCommand returns a new symbol command group.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Manage symbol classifications",
+		SubCommands: []*appcmd.Command{
+			symbolset.NewCommand("set", builder),
+		},
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package symbolset implements the "config symbol set" command.
+package symbolset
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// categoryFlagName is the flag name for the asset category.
+	categoryFlagName = "category"
+	// typeFlagName is the flag name for the asset type.
+	typeFlagName = "type"
+	// sectorFlagName is the flag name for the sector.
+	sectorFlagName = "sector"
+	// geoFlagName is the flag name for the geography.
+	geoFlagName = "geo"
+	// forceFlagName is the flag name for skipping validation against holdings.
+	forceFlagName = "force"
+)
+
+// NewCommand returns a new config symbol set command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <symbol>",
+		Short: "Add or update the classification of a symbol in the configuration file",
+		Long: `Add or update the classification of a symbol in the symbols list of ibctl.yaml.
+
+Only the given classifications are changed; others are left as they are.
+Comments and existing entries in the configuration file are preserved.
+
+The symbol is validated against the symbols in your trades and positions to
+catch typos in ticker names. Use --force to set a symbol that is not held.
+
+Example:
+
+  ibctl config symbol set NET --category EQUITY --type STOCK --sector TECH --geo US`,
+		Args: appcmd.ExactArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the ibctl directory containing ibctl.yaml.
+	Dir string
+	// Category is the asset category (e.g., "EQUITY").
+	Category string
+	// Type is the asset type (e.g., "STOCK", "ETF").
+	Type string
+	// Sector is the sector classification (e.g., "TECH").
+	Sector string
+	// Geo is the geographic classification (e.g., "US", "INTL").
+	Geo string
+	// Force skips validation of the symbol against holdings.
+	Force bool
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Category, categoryFlagName, "", "The asset category (e.g., EQUITY)")
+	flagSet.StringVar(&f.Type, typeFlagName, "", "The asset type (e.g., STOCK, ETF)")
+	flagSet.StringVar(&f.Sector, sectorFlagName, "", "The sector classification (e.g., TECH)")
+	flagSet.StringVar(&f.Geo, geoFlagName, "", "The geographic classification (e.g., US, INTL)")
+	flagSet.BoolVar(&f.Force, forceFlagName, false, "Set the symbol even if it is not in any trades or positions")
+}
+
+func run(_ context.Context, container appext.Container, flags *flags) error {
+	symbol := container.Arg(0)
+	if flags.Category == "" && flags.Type == "" && flags.Sector == "" && flags.Geo == "" {
+		return appcmd.NewInvalidArgumentErrorf(
+			"at least one of --%s, --%s, --%s, or --%s is required",
+			categoryFlagName, typeFlagName, sectorFlagName, geoFlagName,
+		)
+	}
+	if !flags.Force {
+		if err := validateSymbol(container, flags.Dir, symbol); err != nil {
+			return err
+		}
+	}
+	added, err := ibctlconfig.SetSymbol(flags.Dir, symbol, ibctlconfig.SymbolConfig{
+		Category: flags.Category,
+		Type:     flags.Type,
+		Sector:   flags.Sector,
+		Geo:      flags.Geo,
+	})
+	if err != nil {
+		return err
+	}
+	if added {
+		container.Logger().Info("symbol added", "symbol", symbol)
+	} else {
+		container.Logger().Info("symbol updated", "symbol", symbol)
+	}
+	return nil
+}
+
+// validateSymbol returns an error if the symbol does not appear in any trades or positions.
+func validateSymbol(container appext.Container, dirPath string, symbol string) error {
+	config, err := ibctlcmd.ReadConfig(container, dirPath)
+	if err != nil {
+		return fmt.Errorf("reading configuration to validate symbol (use --%s to skip): %w", forceFlagName, err)
+	}
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+	)
+	if err != nil {
+		return err
+	}
+	symbols := make(map[string]struct{})
+	for _, trade := range mergedData.Trades {
+		symbols[trade.GetSymbol()] = struct{}{}
+	}
+	for _, position := range mergedData.Positions {
+		symbols[position.GetSymbol()] = struct{}{}
+	}
+	if _, ok := symbols[symbol]; ok {
+		return nil
+	}
+	// Suggest a symbol that only differs in case, the most common typo.
+	for existingSymbol := range symbols {
+		if strings.EqualFold(existingSymbol, symbol) {
+			return fmt.Errorf("symbol %q not found in trades or positions, did you mean %q? (use --%s to set it anyway)", symbol, existingSymbol, forceFlagName)
+		}
+	}
+	return fmt.Errorf("symbol %q not found in trades or positions (use --%s to set it anyway)", symbol, forceFlagName)
+}
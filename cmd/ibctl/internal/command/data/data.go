@@ -8,7 +8,21 @@ package data
 import (
 	"buf.build/go/app/appcmd"
 	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/data/dataaudit"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/data/databackup"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/data/dataduplicates"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/data/datalog"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/data/datamigrate"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/data/dataprune"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/data/datareconcile"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/data/datarestore"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/data/datastatus"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/data/dataunzip"
 	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/data/datazip"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/data/encryption"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/data/statement"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/data/trade"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/data/transferbasis"
 )
 
 // NewCommand returns a new data command group with data management sub-commands.
@@ -17,7 +31,21 @@ func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
 		Use:   name,
 		Short: "Manage ibctl data",
 		SubCommands: []*appcmd.Command{
+			dataaudit.NewCommand("audit", builder),
+			databackup.NewCommand("backup", builder),
+			dataduplicates.NewCommand("duplicates", builder),
+			datalog.NewCommand("log", builder),
+			datamigrate.NewCommand("migrate", builder),
+			dataprune.NewCommand("prune", builder),
+			datareconcile.NewCommand("reconcile", builder),
+			datarestore.NewCommand("restore", builder),
+			datastatus.NewCommand("status", builder),
+			dataunzip.NewCommand("unzip", builder),
 			datazip.NewCommand("zip", builder),
+			encryption.NewCommand("encryption", builder),
+			statement.NewCommand("statement", builder),
+			trade.NewCommand("trade", builder),
+			transferbasis.NewCommand("transfer-basis", builder),
 		},
 	}
 }
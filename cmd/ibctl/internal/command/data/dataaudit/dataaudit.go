@@ -0,0 +1,129 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package dataaudit implements the "data audit" command.
+package dataaudit
+
+import (
+	"context"
+	"os"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlaudit"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// formatFlagName is the flag name for the output format.
+	formatFlagName = "format"
+)
+
+// NewCommand returns a new data audit command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Flag suspicious trade records",
+		Long: `Flag suspicious trade records in the merged data.
+
+The audit applies these checks to every trade across all sources:
+
+  price_deviation     warning  The trade price deviates more than 50% from the
+                               mean price of the same symbol on every other
+                               trading day within 7 days. Options are skipped.
+                               Stock splits can also trigger it.
+  duplicate_trade_id  error    Trades from different sources share a trade ID
+                               but differ in content.
+  settle_date         error    The settle date is before the trade date.
+  quantity_side       error    A buy has a negative quantity, or a sell has a
+                               positive quantity.
+  low_confidence      warning  The trade was extracted from a statement PDF with
+                               "ibctl data statement import", and should be
+                               verified against the original statement.
+
+Findings are sorted by date. Use --format json for machine-readable output.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Format is the output format (table, csv, json).
+	Format string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+}
+
+func run(_ context.Context, container appext.Container, flags *flags) error {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	// Merge trade data from all sources.
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+		ibctlmerge.MergeWithTrades(),
+	)
+	if err != nil {
+		return err
+	}
+	findings, err := ibctlaudit.AuditTrades(mergedData.Trades)
+	if err != nil {
+		return err
+	}
+	// Write output in the requested format.
+	writer := os.Stdout
+	switch format {
+	case cliio.FormatTable:
+		rows := make([][]string, 0, len(findings))
+		for _, finding := range findings {
+			rows = append(rows, ibctlaudit.FindingToRow(finding))
+		}
+		return cliio.WriteTable(writer, ibctlaudit.FindingHeaders(), rows)
+	case cliio.FormatCSV:
+		records := make([][]string, 0, len(findings)+1)
+		records = append(records, ibctlaudit.FindingHeaders())
+		for _, finding := range findings {
+			records = append(records, ibctlaudit.FindingToRow(finding))
+		}
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, findings...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package databackup implements the "data backup" command.
+package databackup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlarchive"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlremote"
+	"github.com/bufdev/ibctl/internal/pkg/cryptobox"
+	"github.com/spf13/pflag"
+)
+
+// targetFlagName is the flag name for selecting backup targets.
+const targetFlagName = "target"
+
+// NewCommand returns a new data backup command that uploads an encrypted archive
+// of the ibctl directory to the configured remote targets.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Upload an encrypted archive of the ibctl directory to remote targets",
+		Long: `Upload an encrypted archive of the ibctl directory to the backup targets
+configured in ibctl.yaml.
+
+The archive has the same layout as "data zip" (excluding data/backups/) and is
+encrypted with AES-256-GCM using the key in the ` + ibctlcmd.EncryptionKeyEnvVar + `
+environment variable. After each upload, only the newest archives are kept,
+per the configured retention.
+
+Decrypt and extract an archive with "ibctl data unzip <archive.zip.enc>".`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Targets restricts the backup to the named targets. Empty means all targets.
+	Targets []string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringSliceVar(&f.Targets, targetFlagName, nil, "Only back up to the named targets (default all)")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) error {
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	targetConfigs, err := selectTargetConfigs(config.BackupTargets, flags.Targets)
+	if err != nil {
+		return err
+	}
+	key, err := ibctlcmd.ReadEncryptionKey(container)
+	if err != nil {
+		return err
+	}
+	httpClient, err := ibctlcmd.NewHTTPClient(container, config)
+	if err != nil {
+		return err
+	}
+	// Construct all targets before building the archive so credential errors surface early.
+	targets := make([]ibctlremote.Target, 0, len(targetConfigs))
+	for _, targetConfig := range targetConfigs {
+		target, err := ibctlremote.NewTarget(targetConfig, httpClient, container.Env)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, target)
+	}
+	// Build the archive in memory, excluding local backup generations and the lock file.
+	var buffer bytes.Buffer
+	if err := ibctlarchive.WriteZip(&buffer, config.DirPath, ibctlpath.DataBackupsDirPath(config.DirPath), ibctlpath.LockFilePath(config.DirPath)); err != nil {
+		return fmt.Errorf("creating zip archive: %w", err)
+	}
+	sealed, err := cryptobox.Seal(key, buffer.Bytes())
+	if err != nil {
+		return fmt.Errorf("encrypting archive: %w", err)
+	}
+	archiveName := ibctlremote.NewArchiveName(time.Now())
+	logger := container.Logger()
+	for _, target := range targets {
+		deleted, err := ibctlremote.Upload(ctx, target, archiveName, sealed, config.BackupRetention)
+		if err != nil {
+			return err
+		}
+		logger.Info("backup uploaded", "target", target.Name(), "archive", archiveName, "bytes", len(sealed), "pruned", len(deleted))
+	}
+	return nil
+}
+
+// selectTargetConfigs returns the configured targets matching the names, or all
+// targets if names is empty.
+func selectTargetConfigs(targetConfigs []ibctlconfig.BackupTargetConfig, names []string) ([]ibctlconfig.BackupTargetConfig, error) {
+	if len(targetConfigs) == 0 {
+		return nil, fmt.Errorf("no backup targets configured, add a backup section to %s", ibctlpath.ConfigFileName)
+	}
+	if len(names) == 0 {
+		return targetConfigs, nil
+	}
+	byName := make(map[string]ibctlconfig.BackupTargetConfig, len(targetConfigs))
+	for _, targetConfig := range targetConfigs {
+		byName[targetConfig.Name] = targetConfig
+	}
+	selected := make([]ibctlconfig.BackupTargetConfig, 0, len(names))
+	for _, name := range names {
+		targetConfig, ok := byName[name]
+		if !ok {
+			return nil, appcmd.NewInvalidArgumentErrorf("unknown backup target %q", name)
+		}
+		selected = append(selected, targetConfig)
+	}
+	return selected, nil
+}
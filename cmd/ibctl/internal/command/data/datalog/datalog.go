@@ -0,0 +1,110 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package datalog implements the "data log" command.
+package datalog
+
+import (
+	"context"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlgit"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// formatFlagName is the flag name for the output format.
+	formatFlagName = "format"
+	// limitFlagName is the flag name for the maximum number of commits to list.
+	limitFlagName = "limit"
+)
+
+// NewCommand returns a new data log command that lists the git history of the data.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "List the recent git commits that changed the data",
+		Long: `List the recent git commits that changed the data, newest first.
+
+Each row has the abbreviated commit hash, the author time, the number of data
+files changed, and the commit message. Commits are made after each download
+with git.auto_commit: true in ibctl.yaml, but commits made by hand that touch
+data/ are listed too. data/backups/ is not tracked.
+
+To see the data as of a commit, e.g. to compare reports over time, check it
+out into a copy of the ibctl directory:
+
+  git -C <copy> checkout <commit> -- data`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Format is the output format (table, csv, json).
+	Format string
+	// Limit is the maximum number of commits to list, or 0 for all commits.
+	Limit int
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+	flagSet.IntVar(&f.Limit, limitFlagName, 20, "Maximum number of commits to list (0 for all)")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) error {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	if flags.Limit < 0 {
+		return appcmd.NewInvalidArgumentErrorf("--%s must not be negative", limitFlagName)
+	}
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	commits, err := ibctlgit.Log(ctx, config.DirPath, flags.Limit)
+	if err != nil {
+		return err
+	}
+	writer := container.Stdout()
+	switch format {
+	case cliio.FormatTable:
+		rows := make([][]string, 0, len(commits))
+		for _, commit := range commits {
+			rows = append(rows, ibctlgit.CommitToRow(commit))
+		}
+		return cliio.WriteTable(writer, ibctlgit.CommitHeaders(), rows)
+	case cliio.FormatCSV:
+		records := make([][]string, 0, len(commits)+1)
+		records = append(records, ibctlgit.CommitHeaders())
+		for _, commit := range commits {
+			records = append(records, ibctlgit.CommitToRow(commit))
+		}
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, commits...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package datamigrate implements the "data migrate" command.
+package datamigrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmigrate"
+	"github.com/bufdev/ibctl/internal/pkg/filemode"
+	"github.com/spf13/pflag"
+)
+
+// dryRunFlagName is the flag name for listing pending migrations without applying them.
+const dryRunFlagName = "dry-run"
+
+// NewCommand returns a new data migrate command that upgrades on-disk data to
+// the current data format version.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Upgrade on-disk data to the current data format version",
+		Long: `Upgrade on-disk data to the current data format version.
+
+The data format version is stored in data/version. When a new version of
+ibctl changes the meaning of on-disk data, commands refuse to read older
+data until it is migrated with this command, rather than misreading it.
+
+Persistent data is backed up before migrating, so a migration can be rolled
+back with "ibctl data restore". Use --dry-run to list pending migrations.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// DryRun lists pending migrations without applying them.
+	DryRun bool
+	// Wait waits for other commands modifying the ibctl directory to finish instead of failing.
+	Wait bool
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.BoolVar(&f.DryRun, dryRunFlagName, false, "List pending migrations without applying them")
+	flagSet.BoolVar(&f.Wait, ibctlcmd.WaitFlagName, false, "Wait for other ibctl commands modifying the ibctl directory to finish instead of failing")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) (retErr error) {
+	// Read the config directly, since ibctlcmd.ReadConfig refuses data that needs migrating.
+	config, err := ibctlconfig.ReadConfig(flags.Dir)
+	if err != nil {
+		return err
+	}
+	// Migrations may rewrite data files, which must honor at-rest encryption
+	// and the configured permissions.
+	if err := ibctlcmd.ConfigureEncryption(container, config.Encrypt); err != nil {
+		return err
+	}
+	filemode.Set(config.FileMode, config.DirMode)
+	// Hold the lock on the ibctl directory, unless only listing migrations.
+	if !flags.DryRun {
+		release, err := ibctlcmd.LockDir(ctx, container, config.DirPath, flags.Wait)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			retErr = errors.Join(retErr, release())
+		}()
+	}
+	var migrations []ibctlmigrate.Migration
+	if flags.DryRun {
+		migrations, err = ibctlmigrate.Pending(config.DirPath)
+	} else {
+		migrations, err = ibctlmigrate.Migrate(config.DirPath)
+	}
+	if err != nil {
+		return err
+	}
+	for _, migration := range migrations {
+		if _, err := fmt.Fprintf(container.Stdout(), "v%d -> v%d: %s\n", migration.FromVersion, migration.FromVersion+1, migration.Description); err != nil {
+			return err
+		}
+	}
+	if len(migrations) == 0 {
+		container.Logger().Info("data is up to date", "version", ibctlmigrate.CurrentVersion)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package dataprune implements the "data prune" command.
+package dataprune
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlprune"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// olderThanFlagName is the flag name for the age after which files are stale.
+	olderThanFlagName = "older-than"
+	// dryRunFlagName is the flag name for reporting without removing.
+	dryRunFlagName = "dry-run"
+	// snapshotsFlagName is the flag name for also pruning position snapshots.
+	snapshotsFlagName = "snapshots"
+	// formatFlagName is the flag name for the output format.
+	formatFlagName = "format"
+)
+
+// NewCommand returns a new data prune command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Remove stale files from the cache",
+		Long: `Remove stale files from the cache, and report the size of each cache directory.
+
+cache/ grows without bound, but deleting it discards data that is slow or
+impossible to re-download. Each cache directory is instead pruned by its own
+rule, where files not modified within --older-than (e.g., 180d or 72h) are old:
+
+  cache/raw/                  Raw Flex Query XML archives that are old, except
+                              the newest archive of each account.
+  cache/debug/                HTTP trace files that are old.
+  cache/activity_statements/  Parsed results of Activity Statement CSVs that
+                              were since edited, replaced, or removed,
+                              regardless of age.
+  cache/fx/                   FX rate pairs with a currency no longer in any
+                              trade, position, or cash record, if old.
+  cache/prices/               Closing prices of symbols no longer held, if old.
+
+The cache directories of accounts no longer in ibctl.yaml are also pruned.
+Other files, such as cache/merged_data.json, are never pruned.
+
+Position snapshots (data/accounts/<alias>/snapshots/) are persistent data and
+are only pruned with --snapshots: old snapshots are removed, except the newest
+snapshot of each account.
+
+The report lists the files and size of each directory and what can be pruned
+from it. Sizes are in bytes in CSV output. With --dry-run, nothing is removed,
+and each file or directory that would be removed is logged.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// OlderThan is the age after which files are stale (e.g., 180d or 72h).
+	OlderThan string
+	// DryRun reports what would be pruned without removing anything.
+	DryRun bool
+	// Snapshots also prunes position snapshots.
+	Snapshots bool
+	// Format is the output format (table, csv, json).
+	Format string
+	// Wait waits for other commands modifying the ibctl directory to finish instead of failing.
+	Wait bool
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.OlderThan, olderThanFlagName, "180d", "The age after which files are stale, in days (e.g., 180d) or as a duration (e.g., 72h)")
+	flagSet.BoolVar(&f.DryRun, dryRunFlagName, false, "Report what would be pruned without removing anything")
+	flagSet.BoolVar(&f.Snapshots, snapshotsFlagName, false, "Also prune position snapshots, keeping the newest of each account")
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+	flagSet.BoolVar(&f.Wait, ibctlcmd.WaitFlagName, false, "Wait for other ibctl commands modifying the ibctl directory to finish instead of failing")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) (retErr error) {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	olderThan, err := ibctlprune.ParseAge(flags.OlderThan)
+	if err != nil {
+		return appcmd.NewInvalidArgumentErrorf("--%s: %v", olderThanFlagName, err)
+	}
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	if !flags.DryRun {
+		// Hold the lock on the ibctl directory while removing files.
+		release, err := ibctlcmd.LockDir(ctx, container, config.DirPath, flags.Wait)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			retErr = errors.Join(retErr, release())
+		}()
+	}
+	// Merge all data, which determines the currencies and symbols still in use.
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+	)
+	if err != nil {
+		return err
+	}
+	result, err := ibctlprune.Scan(
+		config.DirPath,
+		config.AccountAliases,
+		mergedData,
+		config.TaxBaseCurrency,
+		time.Now().Add(-olderThan),
+		flags.Snapshots,
+	)
+	if err != nil {
+		return err
+	}
+	var prunedFiles int
+	var prunedBytes int64
+	for _, candidate := range result.Candidates {
+		prunedFiles += candidate.Files
+		prunedBytes += candidate.Bytes
+		if flags.DryRun {
+			container.Logger().Info(
+				"would prune",
+				"path", candidate.Path,
+				"files", candidate.Files,
+				"size", ibctlprune.FormatSize(candidate.Bytes),
+				"reason", candidate.Reason,
+			)
+		}
+	}
+	if !flags.DryRun {
+		if err := ibctlprune.Prune(config.DirPath, result.Candidates); err != nil {
+			return err
+		}
+		container.Logger().Info("cache pruned", "files", prunedFiles, "size", ibctlprune.FormatSize(prunedBytes))
+	}
+	// Write the size report in the requested format.
+	writer := os.Stdout
+	switch format {
+	case cliio.FormatTable:
+		rows := make([][]string, 0, len(result.Usages))
+		for _, usage := range result.Usages {
+			rows = append(rows, ibctlprune.UsageToTableRow(usage))
+		}
+		return cliio.WriteTable(writer, ibctlprune.UsageHeaders(), rows)
+	case cliio.FormatCSV:
+		records := make([][]string, 0, len(result.Usages)+1)
+		records = append(records, ibctlprune.UsageHeaders())
+		for _, usage := range result.Usages {
+			records = append(records, ibctlprune.UsageToRow(usage))
+		}
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, result.Usages...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
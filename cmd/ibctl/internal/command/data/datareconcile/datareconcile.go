@@ -0,0 +1,443 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package datareconcile implements the "data reconcile" command.
+package datareconcile
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlcash"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlreconcile"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctltaxlot"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/bufdev/ibctl/internal/pkg/ibkractivitycsv"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// formatFlagName is the flag name for the output format.
+	formatFlagName = "format"
+	// lotsFlagName is the flag name for reconciling IBKR's closed lots against FIFO.
+	lotsFlagName = "lots"
+	// mtmFlagName is the flag name for reconciling Activity Statement mark-to-market P/L.
+	mtmFlagName = "mtm"
+	// cashFlagName is the flag name for reconciling reconstructed cash balances against the Cash Report.
+	cashFlagName = "cash"
+	// realizedFlagName is the flag name for reconciling realized P/L against IBKR's FIFO realized P/L.
+	realizedFlagName = "realized"
+	// codesFlagName is the flag name for listing Activity Statement trades with unknown trade codes.
+	codesFlagName = "codes"
+)
+
+// assetCategoryCash is the IBKR asset category for cash/FX trades, which have no lots.
+const assetCategoryCash = "CASH"
+
+// NewCommand returns a new data reconcile command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Reconcile consecutive position snapshots against trades, transfers, and corporate actions",
+		Long: `Reconcile consecutive position snapshots against trades, transfers, and corporate actions.
+
+Every download stores a dated position snapshot per account. For each pair of
+consecutive snapshots, this command checks that:
+
+  previous position + trades + transfers + corporate actions = current position
+
+per account and symbol, and lists any unexplained quantity changes. These
+usually indicate a missing data window.
+
+With --lots, instead compare the lots IBKR closed for each trade against the
+lots ibctl's FIFO computation closed, and list every account, symbol, and
+closing date where they diverge. This needs the Flex Query Trades section to
+include the "Closed Lots" level of detail.
+
+With --mtm, instead compare the Mark-to-Market Performance Summary of each
+Activity Statement CSV against the quantities and P/L ibctl computes for the
+statement period, and list every account, symbol, and period where they
+diverge. P/L is computed from IBKR's prior and current prices as
+
+  prior quantity * (current price - prior price)
+  + sum over trades of (quantity * current price + proceeds) + commissions
+
+and compared with IBKR's position, transaction, and commissions P/L, only for
+stocks in the account base currency. Differences of up to one currency unit
+are ignored.
+
+With --cash, instead reconstruct each account's cash balances by replaying
+deposits, withdrawals, trades, FX conversions, dividends, withholding tax,
+interest, and fees, and list every account and currency whose balance differs
+from the IBKR Cash Report by more than one cent. A difference usually means
+cash history is missing, such as before the first Activity Statement.
+
+With --realized, instead compare the realized P/L IBKR reports for each
+closing trade with the realized gains ibctl's FIFO computation attributes to
+the trade, and list every trade where they differ by more than one cent. Both
+include the commissions of the opening and closing trades. Only trades
+downloaded through the Flex Query carry IBKR's realized P/L.
+
+With --codes, instead list the Activity Statement trades with IBKR trade codes
+ibctl does not know. Cancellations (Ca) and corrections (Co) are applied during
+the merge, and other known codes do not change the trades, but an unknown code
+may mean a trade needs manual review.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Format is the output format (table, csv, json).
+	Format string
+	// Lots reconciles IBKR's closed lots against FIFO instead of position snapshots.
+	Lots bool
+	// MTM reconciles Activity Statement mark-to-market P/L instead of position snapshots.
+	MTM bool
+	// Cash reconciles reconstructed cash balances against the Cash Report instead of position snapshots.
+	Cash bool
+	// Realized reconciles realized P/L against IBKR's FIFO realized P/L instead of position snapshots.
+	Realized bool
+	// Codes lists Activity Statement trades with unknown trade codes instead of reconciling position snapshots.
+	Codes bool
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+	flagSet.BoolVar(&f.Lots, lotsFlagName, false, "Compare IBKR's closed lots against ibctl's FIFO lot matching")
+	flagSet.BoolVar(&f.MTM, mtmFlagName, false, "Compare the Activity Statement Mark-to-Market Performance Summary against ibctl's quantities and P/L")
+	flagSet.BoolVar(&f.Cash, cashFlagName, false, "Compare cash balances reconstructed from transaction history against the IBKR Cash Report")
+	flagSet.BoolVar(&f.Realized, realizedFlagName, false, "Compare IBKR's FIFO realized P/L of each closing trade against ibctl's realized gains")
+	flagSet.BoolVar(&f.Codes, codesFlagName, false, "List Activity Statement trades with trade codes ibctl does not know")
+}
+
+func run(_ context.Context, container appext.Container, flags *flags) error {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	if countTrue(flags.Lots, flags.MTM, flags.Cash, flags.Realized, flags.Codes) > 1 {
+		return appcmd.NewInvalidArgumentErrorf("only one of --%s, --%s, --%s, --%s, and --%s can be used", lotsFlagName, mtmFlagName, cashFlagName, realizedFlagName, codesFlagName)
+	}
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	aliases := make([]string, 0, len(config.AccountAliases))
+	for alias := range config.AccountAliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	// Trade codes are read directly from the Activity Statement CSVs, so no merge is needed.
+	if flags.Codes {
+		return runCodes(container, config, aliases, format)
+	}
+	// Merge trade data from all sources.
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+	)
+	if err != nil {
+		return err
+	}
+	if flags.Lots {
+		return runLots(container, config, mergedData, aliases, format)
+	}
+	if flags.MTM {
+		return runMTM(container, config, mergedData, aliases, format)
+	}
+	if flags.Cash {
+		return runCash(mergedData, format)
+	}
+	if flags.Realized {
+		return runRealized(config, mergedData, format)
+	}
+	// Reconcile each account's snapshots in alias order for deterministic output.
+	logger := container.Logger()
+	var discrepancies []*ibctlreconcile.Discrepancy
+	for _, alias := range aliases {
+		snapshots, err := ibctlreconcile.ReadPositionSnapshots(ibctlpath.DataAccountSnapshotsDirPath(config.DirPath, alias), alias)
+		if err != nil {
+			return err
+		}
+		if len(snapshots) < 2 {
+			logger.Info("not enough position snapshots to reconcile", "account", alias, "snapshots", len(snapshots))
+			continue
+		}
+		discrepancies = append(discrepancies, ibctlreconcile.Reconcile(snapshots, mergedData.Trades, mergedData.Transfers, mergedData.CorporateActions)...)
+	}
+	// Write output in the requested format.
+	writer := os.Stdout
+	switch format {
+	case cliio.FormatTable:
+		rows := make([][]string, 0, len(discrepancies))
+		for _, d := range discrepancies {
+			rows = append(rows, ibctlreconcile.DiscrepancyToRow(d))
+		}
+		return cliio.WriteTable(writer, ibctlreconcile.DiscrepancyHeaders(), rows)
+	case cliio.FormatCSV:
+		records := make([][]string, 0, len(discrepancies)+1)
+		records = append(records, ibctlreconcile.DiscrepancyHeaders())
+		for _, d := range discrepancies {
+			records = append(records, ibctlreconcile.DiscrepancyToRow(d))
+		}
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, discrepancies...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
+
+// runLots reconciles IBKR's closed lots against ibctl's FIFO lot matching.
+func runLots(
+	container appext.Container,
+	config *ibctlconfig.Config,
+	mergedData *ibctlmerge.MergedData,
+	aliases []string,
+	format cliio.Format,
+) error {
+	var closedLots []*datav1.ClosedLot
+	for _, alias := range aliases {
+		accountClosedLots, err := ibctlreconcile.ReadClosedLots(ibctlpath.DataAccountDirPath(config.DirPath, alias))
+		if err != nil {
+			return err
+		}
+		if len(accountClosedLots) == 0 {
+			container.Logger().Info("no IBKR closed lots to reconcile", "account", alias)
+		}
+		closedLots = append(closedLots, accountClosedLots...)
+	}
+	var securityTrades []*datav1.Trade
+	for _, trade := range mergedData.Trades {
+		if trade.GetAssetCategory() != assetCategoryCash {
+			securityTrades = append(securityTrades, trade)
+		}
+	}
+	taxLotResult, err := ibctltaxlot.ComputeTaxLotsCached(config.CacheTaxLotsDirPath(), securityTrades, config.AverageCostSymbols())
+	if err != nil {
+		return err
+	}
+	lotDivergences := ibctlreconcile.ReconcileLots(closedLots, taxLotResult.RealizedGains)
+	// Write output in the requested format.
+	writer := os.Stdout
+	switch format {
+	case cliio.FormatTable:
+		rows := make([][]string, 0, len(lotDivergences))
+		for _, d := range lotDivergences {
+			rows = append(rows, ibctlreconcile.LotDivergenceToRow(d))
+		}
+		return cliio.WriteTable(writer, ibctlreconcile.LotDivergenceHeaders(), rows)
+	case cliio.FormatCSV:
+		records := make([][]string, 0, len(lotDivergences)+1)
+		records = append(records, ibctlreconcile.LotDivergenceHeaders())
+		for _, d := range lotDivergences {
+			records = append(records, ibctlreconcile.LotDivergenceToRow(d))
+		}
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, lotDivergences...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
+
+// runMTM reconciles the Activity Statement Mark-to-Market Performance Summary
+// against ibctl's quantities and P/L.
+func runMTM(
+	container appext.Container,
+	config *ibctlconfig.Config,
+	mergedData *ibctlmerge.MergedData,
+	aliases []string,
+	format cliio.Format,
+) error {
+	var mtmDivergences []*ibctlreconcile.MTMDivergence
+	for _, alias := range aliases {
+		statements, err := ibkractivitycsv.ParseDirectoryWithCache(
+			filepath.Join(ibctlpath.ActivityStatementsDirPath(config.DirPath), alias),
+			filepath.Join(ibctlpath.CacheActivityStatementsDirPath(config.DirPath), alias),
+		)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				container.Logger().Info("no activity statements to reconcile", "account", alias)
+				continue
+			}
+			return err
+		}
+		mtmDivergences = append(mtmDivergences, ibctlreconcile.ReconcileMTM(alias, statements, mergedData.Trades, mergedData.Transfers, mergedData.CorporateActions)...)
+	}
+	// Write output in the requested format.
+	writer := os.Stdout
+	switch format {
+	case cliio.FormatTable:
+		rows := make([][]string, 0, len(mtmDivergences))
+		for _, d := range mtmDivergences {
+			rows = append(rows, ibctlreconcile.MTMDivergenceToRow(d))
+		}
+		return cliio.WriteTable(writer, ibctlreconcile.MTMDivergenceHeaders(), rows)
+	case cliio.FormatCSV:
+		records := make([][]string, 0, len(mtmDivergences)+1)
+		records = append(records, ibctlreconcile.MTMDivergenceHeaders())
+		for _, d := range mtmDivergences {
+			records = append(records, ibctlreconcile.MTMDivergenceToRow(d))
+		}
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, mtmDivergences...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
+
+// runRealized reconciles ibctl's realized gains against IBKR's FIFO realized P/L.
+func runRealized(config *ibctlconfig.Config, mergedData *ibctlmerge.MergedData, format cliio.Format) error {
+	var securityTrades []*datav1.Trade
+	for _, trade := range mergedData.Trades {
+		if trade.GetAssetCategory() != assetCategoryCash {
+			securityTrades = append(securityTrades, trade)
+		}
+	}
+	taxLotResult, err := ibctltaxlot.ComputeTaxLotsCached(config.CacheTaxLotsDirPath(), securityTrades, config.AverageCostSymbols())
+	if err != nil {
+		return err
+	}
+	realizedDivergences := ibctlreconcile.ReconcileRealized(mergedData.Trades, taxLotResult.RealizedGains)
+	// Write output in the requested format.
+	writer := os.Stdout
+	switch format {
+	case cliio.FormatTable:
+		rows := make([][]string, 0, len(realizedDivergences))
+		for _, d := range realizedDivergences {
+			rows = append(rows, ibctlreconcile.RealizedDivergenceToRow(d))
+		}
+		return cliio.WriteTable(writer, ibctlreconcile.RealizedDivergenceHeaders(), rows)
+	case cliio.FormatCSV:
+		records := make([][]string, 0, len(realizedDivergences)+1)
+		records = append(records, ibctlreconcile.RealizedDivergenceHeaders())
+		for _, d := range realizedDivergences {
+			records = append(records, ibctlreconcile.RealizedDivergenceToRow(d))
+		}
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, realizedDivergences...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
+
+// runCodes lists the Activity Statement trades with unknown trade codes.
+func runCodes(
+	container appext.Container,
+	config *ibctlconfig.Config,
+	aliases []string,
+	format cliio.Format,
+) error {
+	var unknownTradeCodes []*ibctlreconcile.UnknownTradeCode
+	for _, alias := range aliases {
+		statements, err := ibkractivitycsv.ParseDirectoryWithCache(
+			filepath.Join(ibctlpath.ActivityStatementsDirPath(config.DirPath), alias),
+			filepath.Join(ibctlpath.CacheActivityStatementsDirPath(config.DirPath), alias),
+		)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				container.Logger().Info("no activity statements to check", "account", alias)
+				continue
+			}
+			return err
+		}
+		unknownTradeCodes = append(unknownTradeCodes, ibctlreconcile.FindUnknownTradeCodes(alias, statements)...)
+	}
+	// Write output in the requested format.
+	writer := os.Stdout
+	switch format {
+	case cliio.FormatTable:
+		rows := make([][]string, 0, len(unknownTradeCodes))
+		for _, u := range unknownTradeCodes {
+			rows = append(rows, ibctlreconcile.UnknownTradeCodeToRow(u))
+		}
+		return cliio.WriteTable(writer, ibctlreconcile.UnknownTradeCodeHeaders(), rows)
+	case cliio.FormatCSV:
+		records := make([][]string, 0, len(unknownTradeCodes)+1)
+		records = append(records, ibctlreconcile.UnknownTradeCodeHeaders())
+		for _, u := range unknownTradeCodes {
+			records = append(records, ibctlreconcile.UnknownTradeCodeToRow(u))
+		}
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, unknownTradeCodes...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
+
+// runCash reconciles cash balances reconstructed from transaction history
+// against the Cash Report.
+func runCash(mergedData *ibctlmerge.MergedData, format cliio.Format) error {
+	divergences := ibctlcash.Reconcile(mergedData.Trades, mergedData.CashTransactions, mergedData.CashPositions)
+	// Write output in the requested format.
+	writer := os.Stdout
+	switch format {
+	case cliio.FormatTable:
+		rows := make([][]string, 0, len(divergences))
+		for _, d := range divergences {
+			rows = append(rows, ibctlcash.DivergenceToRow(d))
+		}
+		return cliio.WriteTable(writer, ibctlcash.DivergenceHeaders(), rows)
+	case cliio.FormatCSV:
+		records := make([][]string, 0, len(divergences)+1)
+		records = append(records, ibctlcash.DivergenceHeaders())
+		for _, d := range divergences {
+			records = append(records, ibctlcash.DivergenceToRow(d))
+		}
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, divergences...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
+
+// countTrue returns the number of true values.
+func countTrue(values ...bool) int {
+	var count int
+	for _, value := range values {
+		if value {
+			count++
+		}
+	}
+	return count
+}
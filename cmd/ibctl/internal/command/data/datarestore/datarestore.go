@@ -0,0 +1,110 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package datarestore implements the "data restore" command.
+package datarestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlbackup"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/spf13/pflag"
+)
+
+// listFlagName is the flag name for listing backup generations.
+const listFlagName = "list"
+
+// NewCommand returns a new data restore command that rolls back persistent data
+// to a backup generation.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " [generation]",
+		Short: "Restore persistent data from a backup",
+		Long: `Restore data/accounts/ from a backup generation under data/backups/.
+
+A backup generation is created automatically before each download modifies
+persistent data. If no generation is given, the most recent one is restored.
+The current data is backed up before restoring, so a restore can be undone.
+
+Use --list to show available generations, newest first.`,
+		Args: appcmd.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// List lists backup generations instead of restoring.
+	List bool
+	// Wait waits for other commands modifying the ibctl directory to finish instead of failing.
+	Wait bool
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.BoolVar(&f.List, listFlagName, false, "List backup generations, newest first")
+	flagSet.BoolVar(&f.Wait, ibctlcmd.WaitFlagName, false, "Wait for other ibctl commands modifying the ibctl directory to finish instead of failing")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) (retErr error) {
+	// Hold the lock on the ibctl directory, unless only listing generations.
+	if !flags.List {
+		release, err := ibctlcmd.LockDir(ctx, container, flags.Dir, flags.Wait)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			retErr = errors.Join(retErr, release())
+		}()
+	}
+	backupsDirPath := ibctlpath.DataBackupsDirPath(flags.Dir)
+	generations, err := ibctlbackup.ListGenerations(backupsDirPath)
+	if err != nil {
+		return fmt.Errorf("listing backups: %w", err)
+	}
+	if flags.List {
+		for _, generation := range generations {
+			if _, err := fmt.Fprintln(container.Stdout(), generation); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(generations) == 0 {
+		return fmt.Errorf("no backups found in %s", backupsDirPath)
+	}
+	// Default to the most recent generation.
+	generation := generations[0]
+	if container.NumArgs() > 0 {
+		generation = container.Arg(0)
+	}
+	if err := ibctlbackup.Restore(
+		ibctlpath.DataAccountsDirPath(flags.Dir),
+		backupsDirPath,
+		generation,
+		ibctlbackup.DefaultRetention,
+	); err != nil {
+		return err
+	}
+	container.Logger().Info("data restored", "generation", generation)
+	return nil
+}
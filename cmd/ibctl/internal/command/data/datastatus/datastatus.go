@@ -0,0 +1,128 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package datastatus implements the "data status" command.
+package datastatus
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlstatus"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// formatFlagName is the flag name for the output format.
+	formatFlagName = "format"
+)
+
+// NewCommand returns a new data status command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Summarize the coverage, size, and health of the ibctl directory",
+		Long: `Summarize the coverage, size, and health of the ibctl directory in one table.
+
+Each row is in one of the sections:
+
+  account     The trades of an account (COUNT), their date range, the size
+              of its persistent data, and when its position snapshot was
+              last downloaded, flagged as stale after snapshot_max_age.
+  statements  The months covered by the Activity Statement CSVs of an
+              account (COUNT), and the months missing between the first and
+              last statement.
+  fx          The rates of an FX pair (COUNT) and their date range, flagged
+              if the pair starts after the first trade in its currency, is
+              missing, or is not used by any trade.
+  cache       The files of an entry of cache/ (COUNT) and their size, with
+              what "ibctl data prune" would remove by default.
+
+STATUS is "ok" for rows without problems. Sizes are in bytes in CSV output.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Format is the output format (table, csv, json).
+	Format string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+}
+
+func run(_ context.Context, container appext.Container, flags *flags) error {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	// Merge all data, which determines the trade coverage and the FX pairs in use.
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := ibctlstatus.GetStatus(config, mergedData, time.Now())
+	if err != nil {
+		return err
+	}
+	// Write output in the requested format.
+	writer := os.Stdout
+	switch format {
+	case cliio.FormatTable:
+		tableRows := make([][]string, 0, len(rows))
+		for _, row := range rows {
+			tableRows = append(tableRows, ibctlstatus.ToTableRow(row))
+		}
+		return cliio.WriteTable(writer, ibctlstatus.Headers(), tableRows)
+	case cliio.FormatCSV:
+		records := make([][]string, 0, len(rows)+1)
+		records = append(records, ibctlstatus.Headers())
+		for _, row := range rows {
+			records = append(records, ibctlstatus.ToRow(row))
+		}
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, rows...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
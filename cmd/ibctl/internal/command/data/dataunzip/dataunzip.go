@@ -0,0 +1,158 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package dataunzip implements the "data unzip" command.
+package dataunzip
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlarchive"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/pkg/cryptobox"
+	"github.com/bufdev/ibctl/internal/pkg/filemode"
+	"github.com/spf13/pflag"
+)
+
+// forceFlagName is the flag name for overwriting an existing ibctl directory.
+const forceFlagName = "force"
+
+// NewCommand returns a new data unzip command that extracts a data zip archive.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <archive.zip>",
+		Short: "Extract an ibctl directory archive created by data zip or data backup",
+		Long: `Extract an ibctl directory archive created by data zip into --dir.
+
+Encrypted archives created by data backup (.zip.enc) are decrypted with the
+key in the ` + ibctlcmd.EncryptionKeyEnvVar + ` environment variable.
+
+The archive is validated before anything is written: it must contain
+ibctl.yaml at its root, only the expected top-level entries (ibctl.yaml,
+data/, cache/, activity_statements/, trade_confirmations/, seed/), and no paths that escape the
+target directory. Extraction is refused if the target directory already
+contains ibctl.yaml, unless --force is given.`,
+		Args: appcmd.ExactArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the target directory to extract into.
+	Dir string
+	// Force allows extracting over an existing ibctl directory.
+	Force bool
+	// Wait waits for other commands modifying the ibctl directory to finish instead of failing.
+	Wait bool
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Dir, ibctlcmd.DirFlagName, ".", "The ibctl directory to extract into")
+	flagSet.BoolVar(&f.Force, forceFlagName, false, "Overwrite files in an existing ibctl directory")
+	flagSet.BoolVar(&f.Wait, ibctlcmd.WaitFlagName, false, "Wait for other ibctl commands modifying the ibctl directory to finish instead of failing")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) (retErr error) {
+	archivePath := container.Arg(0)
+	if !strings.HasSuffix(archivePath, ".zip") && !strings.HasSuffix(archivePath, ".zip.enc") {
+		return appcmd.NewInvalidArgumentError("archive must have a .zip or .zip.enc extension")
+	}
+	data, err := os.ReadFile(archivePath)
+	if err != nil {
+		return fmt.Errorf("reading archive: %w", err)
+	}
+	// Decrypt archives created by data backup.
+	if cryptobox.IsSealed(data) {
+		key, err := ibctlcmd.ReadEncryptionKey(container)
+		if err != nil {
+			return err
+		}
+		if data, err = cryptobox.Open(key, data); err != nil {
+			return fmt.Errorf("decrypting archive: %w", err)
+		}
+	}
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("opening archive: %w", err)
+	}
+	// Validate the whole archive before writing anything.
+	if err := ibctlarchive.Validate(zipReader.File); err != nil {
+		return fmt.Errorf("invalid archive %s: %w", archivePath, err)
+	}
+	// Hold the lock on the ibctl directory while extracting into it.
+	if err := filemode.MkdirAll(flags.Dir); err != nil {
+		return err
+	}
+	release, err := ibctlcmd.LockDir(ctx, container, flags.Dir, flags.Wait)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = errors.Join(retErr, release())
+	}()
+	// Refuse to clobber an existing ibctl directory unless forced.
+	if _, err := os.Stat(ibctlpath.ConfigFilePath(flags.Dir)); err == nil {
+		if !flags.Force {
+			return fmt.Errorf("%s already contains %s, use --%s to overwrite", flags.Dir, ibctlpath.ConfigFileName, forceFlagName)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for _, zipFile := range zipReader.File {
+		if err := extractFile(zipFile, flags.Dir); err != nil {
+			return fmt.Errorf("extracting %s: %w", zipFile.Name, err)
+		}
+	}
+	container.Logger().Info("zip archive extracted", "path", archivePath, "dir", flags.Dir, "files", len(zipReader.File))
+	return nil
+}
+
+// extractFile writes a single validated archive entry under dirPath.
+func extractFile(zipFile *zip.File, dirPath string) error {
+	targetPath := filepath.Join(dirPath, filepath.FromSlash(path.Clean(zipFile.Name)))
+	if zipFile.FileInfo().IsDir() {
+		return filemode.MkdirAll(targetPath)
+	}
+	if err := filemode.MkdirAll(filepath.Dir(targetPath)); err != nil {
+		return err
+	}
+	reader, err := zipFile.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	file, err := filemode.OpenFile(targetPath, os.O_WRONLY|os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, reader); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -6,10 +6,8 @@
 package datazip
 
 import (
-	"archive/zip"
 	"context"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -17,6 +15,10 @@ import (
 	"buf.build/go/app/appcmd"
 	"buf.build/go/app/appext"
 	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlarchive"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlredact"
 	"github.com/spf13/pflag"
 )
 
@@ -29,7 +31,13 @@ func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
 	return &appcmd.Command{
 		Use:   name,
 		Short: "Archive the ibctl directory to a zip file",
-		Args:  appcmd.NoArgs,
+		Long: `Archive the ibctl directory to a zip file.
+
+With --redact (or redact: true in ibctl.yaml), account aliases and IBKR
+account IDs are replaced with stable pseudonyms (account-1, ...) in every file
+name and file, so the archive can be shared. Encrypted files are decrypted
+before they are redacted.`,
+		Args: appcmd.NoArgs,
 		Run: builder.NewRunFunc(
 			func(ctx context.Context, container appext.Container) error {
 				return run(ctx, container, flags)
@@ -44,6 +52,8 @@ type flags struct {
 	Dir string
 	// Output is the path to the output zip file.
 	Output string
+	// Redact replaces account identifiers with pseudonyms.
+	Redact ibctlcmd.RedactFlag
 }
 
 func newFlags() *flags {
@@ -52,8 +62,9 @@ func newFlags() *flags {
 
 // Bind registers the flag definitions with the given flag set.
 func (f *flags) Bind(flagSet *pflag.FlagSet) {
-	flagSet.StringVar(&f.Dir, ibctlcmd.DirFlagName, ".", "The ibctl directory containing ibctl.yaml")
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
 	flagSet.StringVarP(&f.Output, outputFlagName, "o", "", "Output zip file path (required)")
+	f.Redact.Bind(flagSet)
 }
 
 func run(_ context.Context, container appext.Container, flags *flags) error {
@@ -77,55 +88,43 @@ func run(_ context.Context, container appext.Container, flags *flags) error {
 	if !info.IsDir() {
 		return fmt.Errorf("%s is not a directory", absDirPath)
 	}
-	// Create the output zip file.
-	outputFile, err := os.Create(flags.Output)
+	// Resolve the output path so it can be excluded if it's inside the base directory.
+	absOutput, err := filepath.Abs(flags.Output)
 	if err != nil {
-		return fmt.Errorf("creating output file: %w", err)
+		return fmt.Errorf("resolving output path: %w", err)
 	}
-	defer outputFile.Close()
-	zipWriter := zip.NewWriter(outputFile)
-	defer zipWriter.Close()
-	// Walk the base directory and add all files to the zip archive.
-	if err := filepath.Walk(absDirPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		// Skip the output zip file itself if it's inside the base directory.
-		absOutput, _ := filepath.Abs(flags.Output)
-		if path == absOutput {
-			return nil
-		}
-		// Compute the relative path for the zip entry.
-		relPath, err := filepath.Rel(absDirPath, path)
-		if err != nil {
-			return err
-		}
-		// Skip the root directory entry.
-		if relPath == "." {
-			return nil
-		}
-		// For directories, add a trailing slash entry.
-		if info.IsDir() {
-			_, err := zipWriter.Create(relPath + "/")
-			return err
-		}
-		// Add the file to the zip archive.
-		writer, err := zipWriter.Create(relPath)
-		if err != nil {
+	// Redact if --redact is set or redact is the default in the config. The
+	// config is otherwise not needed, so archiving a directory with a missing
+	// or invalid config still works without --redact.
+	var redactor *ibctlredact.Redactor
+	config, err := ibctlconfig.ReadConfig(absDirPath)
+	if err != nil {
+		if flags.Redact.Enabled(&ibctlconfig.Config{}) {
 			return err
 		}
-		file, err := os.Open(path)
-		if err != nil {
+	} else if flags.Redact.Enabled(config) {
+		// Sealed files are decrypted before they are redacted.
+		if err := ibctlcmd.ConfigureEncryption(container, false); err != nil {
 			return err
 		}
-		defer file.Close()
-		_, err = io.Copy(writer, file)
-		return err
-	}); err != nil {
+		redactor = ibctlredact.NewRedactor(config.AccountAliases)
+	}
+	// Create the output zip file.
+	outputFile, err := os.Create(flags.Output)
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	defer outputFile.Close()
+	// Walk the base directory and add all files but the lock file to the zip archive.
+	if redactor != nil {
+		err = ibctlarchive.WriteRedactedZip(outputFile, absDirPath, redactor, absOutput, ibctlpath.LockFilePath(absDirPath))
+	} else {
+		err = ibctlarchive.WriteZip(outputFile, absDirPath, absOutput, ibctlpath.LockFilePath(absDirPath))
+	}
+	if err != nil {
 		return fmt.Errorf("creating zip archive: %w", err)
 	}
-	// Close the zip writer to flush the archive.
-	if err := zipWriter.Close(); err != nil {
+	if err := outputFile.Close(); err != nil {
 		return fmt.Errorf("finalizing zip archive: %w", err)
 	}
 	logger := container.Logger()
@@ -0,0 +1,23 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package encryption implements the "data encryption" command group.
+package encryption
+
+import (
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/data/encryption/encryptionmigrate"
+)
+
+// NewCommand returns a new encryption command group.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Manage at-rest encryption of data files",
+		SubCommands: []*appcmd.Command{
+			encryptionmigrate.NewCommand("migrate", builder),
+		},
+	}
+}
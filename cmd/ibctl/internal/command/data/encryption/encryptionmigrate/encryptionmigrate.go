@@ -0,0 +1,144 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package encryptionmigrate implements the "data encryption migrate" command.
+package encryptionmigrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/pkg/cryptobox"
+	"github.com/bufdev/ibctl/internal/pkg/filemode"
+	"github.com/bufdev/ibctl/internal/pkg/protoio"
+	"github.com/spf13/pflag"
+)
+
+// NewCommand returns a new encryption migrate command that rewrites data files
+// to match the configured encryption setting.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Rewrite data and cache files to match the encrypt setting in ibctl.yaml",
+		Long: `Rewrite every file under data/ and cache/ to match the encrypt setting in ibctl.yaml.
+
+The data version marker (data/version) and the price overrides file
+(data/prices/overrides.yaml), which is edited by hand, are always plaintext,
+and are decrypted if they were encrypted.
+
+If encrypt is true, plaintext files are encrypted. If encrypt is false, encrypted
+files are decrypted. Both directions need the key from the ` + ibctlcmd.EncryptionKeyEnvVar + `
+environment variable (or the macOS keychain). Files already in the desired
+state are left untouched, so the command is safe to re-run.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Wait waits for other commands modifying the ibctl directory to finish instead of failing.
+	Wait bool
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.BoolVar(&f.Wait, ibctlcmd.WaitFlagName, false, "Wait for other ibctl commands modifying the ibctl directory to finish instead of failing")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) (retErr error) {
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	// Decrypting also needs the key, which ReadConfig only requires when encrypting.
+	key, err := ibctlcmd.ReadEncryptionKey(container)
+	if err != nil {
+		return err
+	}
+	protoio.SetEncryption(key, config.Encrypt)
+	// Hold the lock on the ibctl directory while rewriting files.
+	release, err := ibctlcmd.LockDir(ctx, container, config.DirPath, flags.Wait)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = errors.Join(retErr, release())
+	}()
+	var numRewritten int
+	for _, dirName := range []string{"data", "cache"} {
+		err := filepath.WalkDir(filepath.Join(config.DirPath, dirName), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			// The data version marker and the hand-edited price overrides
+			// file are always plaintext.
+			encrypt := config.Encrypt
+			if path == ibctlpath.DataVersionFilePath(config.DirPath) || path == ibctlpath.DataPriceOverridesFilePath(config.DirPath) {
+				encrypt = false
+			}
+			rewritten, err := migrateFile(path, encrypt)
+			if err != nil {
+				return fmt.Errorf("migrating %s: %w", path, err)
+			}
+			if rewritten {
+				numRewritten++
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	container.Logger().Info("encryption migration complete", "encrypt", config.Encrypt, "files_rewritten", numRewritten)
+	return nil
+}
+
+// migrateFile rewrites the file if its encryption state does not match encrypt.
+// Returns true if the file was rewritten.
+func migrateFile(filePath string, encrypt bool) (bool, error) {
+	raw, err := os.ReadFile(filePath)
+	if err != nil {
+		return false, err
+	}
+	if cryptobox.IsSealed(raw) == encrypt {
+		return false, nil
+	}
+	// protoio decrypts sealed files on read.
+	data, err := protoio.ReadFile(filePath)
+	if err != nil {
+		return false, err
+	}
+	if !encrypt {
+		return true, filemode.WriteFile(filePath, data)
+	}
+	// protoio seals on write per the configured mode.
+	if err := protoio.WriteFile(filePath, data); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,23 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package statement implements the "data statement" command group.
+package statement
+
+import (
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/data/statement/statementimport"
+)
+
+// NewCommand returns a new statement command group.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Manage trades extracted from statements without structured data",
+		SubCommands: []*appcmd.Command{
+			statementimport.NewCommand("import", builder),
+		},
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package statementimport implements the "data statement import" command.
+package statementimport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlstatement"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// accountFlagName is the flag name for the account alias.
+	accountFlagName = "account"
+	// currencyFlagName is the flag name for the currency of trades before the first currency heading.
+	currencyFlagName = "currency"
+)
+
+// NewCommand returns a new data statement import command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <file>",
+		Short: "Import trades from an Activity Statement PDF, best-effort",
+		Long: `Import trades from an Activity Statement PDF, best-effort.
+
+Activity Statements for old years are sometimes only available as PDFs, which
+have no structured data. The stock trades in the Trades section of the PDF are
+extracted from its text into the seed data of the account
+(seed/<alias>/transactions.json), and are merged like transactions imported
+from previous brokers.
+
+Extraction is best-effort, so every extracted transaction is tagged as low
+confidence: its lots have the "statement_extract" source in holding lot list,
+and "ibctl data audit" warns about its trades. Verify them against the
+original statement.
+
+Text in fonts with custom encodings cannot be extracted from PDFs. If no
+trades are found, convert the PDF to CSV with a PDF table extraction tool and
+import the CSV file instead. Files that do not end in .pdf are read as CSV.
+Rows are read positionally: the symbol, the date and optional time, the
+quantity, the trade price, and the proceeds and commission if present.
+
+Only stock trades with whole share quantities are extracted. Skipped trade
+rows, such as options and Forex trades, are logged. --currency is the
+currency of trades before the first currency heading. Transactions that are
+already in the seed data are not added again, so a statement can be imported
+again safely.`,
+		Args: appcmd.ExactArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Account is the alias of the account the statement is for.
+	Account string
+	// Currency is the currency of trades before the first currency heading.
+	Currency string
+	// Wait waits for other commands modifying the ibctl directory to finish instead of failing.
+	Wait bool
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Account, accountFlagName, "", "The alias of the account the statement is for (required)")
+	flagSet.StringVar(&f.Currency, currencyFlagName, "USD", "The currency of trades before the first currency heading")
+	flagSet.BoolVar(&f.Wait, ibctlcmd.WaitFlagName, false, "Wait for other ibctl commands modifying the ibctl directory to finish instead of failing")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) (retErr error) {
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	if _, ok := config.AccountAliases[flags.Account]; !ok {
+		return appcmd.NewInvalidArgumentErrorf("--%s must be a configured account alias, got %q", accountFlagName, flags.Account)
+	}
+	filePath := container.Arg(0)
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	isPDF := strings.EqualFold(filepath.Ext(filePath), ".pdf")
+	var result *ibctlstatement.Result
+	if isPDF {
+		result, err = ibctlstatement.ParsePDF(data, flags.Account, flags.Currency)
+	} else {
+		result, err = ibctlstatement.ParseCSV(bytes.NewReader(data), flags.Account, flags.Currency)
+	}
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	for _, skipped := range result.Skipped {
+		container.Logger().Warn("statement row skipped", "row", skipped.Row, "reason", skipped.Reason)
+	}
+	if len(result.Transactions) == 0 {
+		if isPDF {
+			return appcmd.NewInvalidArgumentErrorf("no trades found in %s, convert it to CSV with a PDF table extraction tool and import the CSV file", filePath)
+		}
+		return appcmd.NewInvalidArgumentErrorf("no trades found in %s", filePath)
+	}
+	// Hold the lock on the ibctl directory while writing the transactions.
+	release, err := ibctlcmd.LockDir(ctx, container, config.DirPath, flags.Wait)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = errors.Join(retErr, release())
+	}()
+	added, err := ibctlstatement.WriteTransactions(ibctlpath.SeedDirPath(config.DirPath), flags.Account, result.Transactions)
+	if err != nil {
+		return err
+	}
+	container.Logger().Info(
+		"statement imported",
+		"account", flags.Account,
+		"trades", added,
+		"already_imported", len(result.Transactions)-added,
+		"skipped", len(result.Skipped),
+	)
+	return nil
+}
@@ -0,0 +1,23 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package trade implements the "data trade" command group.
+package trade
+
+import (
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/data/trade/tradeadd"
+)
+
+// NewCommand returns a new trade command group.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Manage manually entered trades",
+		SubCommands: []*appcmd.Command{
+			tradeadd.NewCommand("add", builder),
+		},
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package tradeadd implements the "data trade add" command.
+package tradeadd
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmanual"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// accountFlagName is the flag name for the account alias.
+	accountFlagName = "account"
+	// symbolFlagName is the flag name for the symbol.
+	symbolFlagName = "symbol"
+	// dateFlagName is the flag name for the trade date.
+	dateFlagName = "date"
+	// sideFlagName is the flag name for the trade side.
+	sideFlagName = "side"
+	// quantityFlagName is the flag name for the quantity.
+	quantityFlagName = "quantity"
+	// priceFlagName is the flag name for the price per share.
+	priceFlagName = "price"
+	// currencyFlagName is the flag name for the currency code.
+	currencyFlagName = "currency"
+	// commissionFlagName is the flag name for the commission.
+	commissionFlagName = "commission"
+	// assetCategoryFlagName is the flag name for the asset category.
+	assetCategoryFlagName = "asset-category"
+	// descriptionFlagName is the flag name for the description.
+	descriptionFlagName = "description"
+	// stdinFlagName is the flag name for reading trades as JSON from stdin.
+	stdinFlagName = "stdin"
+)
+
+// NewCommand returns a new data trade add command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Add a manually entered trade",
+		Long: `Add a manually entered trade for a position held outside IBKR (e.g., a private placement).
+
+The trade is given with flags, or with --stdin as a JSON object or array of objects:
+
+  {"account": "individual", "symbol": "ACME", "date": "2024-03-01", "side": "buy",
+   "quantity": "1000", "price": "2.50", "currency": "USD", "commission": "0"}
+
+Quantity and commission are positive amounts; the side determines the sign.
+Manual trades are validated and appended to data/manual/<alias>/trades.json,
+and are merged with downloaded and imported trades by all commands. Adding
+the same trade twice is an error.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Stdin reads trades as JSON from stdin instead of flags.
+	Stdin bool
+	// TradeInput is the trade given with flags.
+	TradeInput ibctlmanual.TradeInput
+	// Wait waits for other commands modifying the ibctl directory to finish instead of failing.
+	Wait bool
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.BoolVar(&f.Stdin, stdinFlagName, false, "Read trades as a JSON object or array from stdin instead of flags")
+	flagSet.StringVar(&f.TradeInput.Account, accountFlagName, "", "The account alias")
+	flagSet.StringVar(&f.TradeInput.Symbol, symbolFlagName, "", "The symbol")
+	flagSet.StringVar(&f.TradeInput.Date, dateFlagName, "", "The trade date (YYYY-MM-DD)")
+	flagSet.StringVar(&f.TradeInput.Side, sideFlagName, "", "The trade side (buy or sell)")
+	flagSet.StringVar(&f.TradeInput.Quantity, quantityFlagName, "", "The positive quantity")
+	flagSet.StringVar(&f.TradeInput.Price, priceFlagName, "", "The price per share")
+	flagSet.StringVar(&f.TradeInput.Currency, currencyFlagName, "USD", "The currency code")
+	flagSet.StringVar(&f.TradeInput.Commission, commissionFlagName, "", "The positive commission paid")
+	flagSet.StringVar(&f.TradeInput.AssetCategory, assetCategoryFlagName, "STK", "The IBKR asset category")
+	flagSet.StringVar(&f.TradeInput.Description, descriptionFlagName, "", "The security description")
+	flagSet.BoolVar(&f.Wait, ibctlcmd.WaitFlagName, false, "Wait for other ibctl commands modifying the ibctl directory to finish instead of failing")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) (retErr error) {
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	tradeInputs := []ibctlmanual.TradeInput{flags.TradeInput}
+	if flags.Stdin {
+		data, err := io.ReadAll(container.Stdin())
+		if err != nil {
+			return err
+		}
+		tradeInputs, err = ibctlmanual.ParseTradeInputs(data)
+		if err != nil {
+			return appcmd.NewInvalidArgumentError(err.Error())
+		}
+	}
+	trades := make([]*datav1.Trade, 0, len(tradeInputs))
+	for _, tradeInput := range tradeInputs {
+		trade, err := ibctlmanual.NewTrade(tradeInput, config.AccountAliases)
+		if err != nil {
+			return appcmd.NewInvalidArgumentError(err.Error())
+		}
+		trades = append(trades, trade)
+	}
+	// Hold the lock on the ibctl directory while writing the trades.
+	release, err := ibctlcmd.LockDir(ctx, container, config.DirPath, flags.Wait)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = errors.Join(retErr, release())
+	}()
+	if err := ibctlmanual.AddTrades(ibctlpath.DataManualDirPath(config.DirPath), trades); err != nil {
+		return err
+	}
+	for _, trade := range trades {
+		container.Logger().Info("added manual trade", "account", trade.GetAccountId(), "symbol", trade.GetSymbol(), "trade_id", trade.GetTradeId())
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package transferbasis implements the "data transfer-basis" command group.
+package transferbasis
+
+import (
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/data/transferbasis/transferbasisimport"
+)
+
+// NewCommand returns a new transfer-basis command group.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Manage cost basis for positions transferred into IBKR",
+		SubCommands: []*appcmd.Command{
+			transferbasisimport.NewCommand("import", builder),
+		},
+	}
+}
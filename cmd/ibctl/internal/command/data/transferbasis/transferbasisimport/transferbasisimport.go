@@ -0,0 +1,103 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package transferbasisimport implements the "data transfer-basis import" command.
+package transferbasisimport
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctltransferbasis"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// accountFlagName is the flag name for the account alias.
+	accountFlagName = "account"
+)
+
+// NewCommand returns a new data transfer-basis import command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <file>",
+		Short: "Import per-lot cost basis from an IBKR Position Transfer Basis CSV export",
+		Long: `Import per-lot cost basis from an IBKR Position Transfer Basis CSV export.
+
+Positions transferred into IBKR free of payment (FOP) have no transfer price,
+so FIFO has no lots for them. Each row of the export becomes a lot with its
+acquisition date and cost basis, shown with the "transfer_basis" source in
+holding lot list.
+
+The CSV header must have Symbol, Quantity, and Acquisition Date (or Date
+Acquired) columns, and either a Unit Cost or a Cost Basis (total) column.
+Currency defaults to USD. Importing replaces all previously imported lots
+for the account.`,
+		Args: appcmd.ExactArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Account is the alias of the account the positions were transferred into.
+	Account string
+	// Wait waits for other commands modifying the ibctl directory to finish instead of failing.
+	Wait bool
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Account, accountFlagName, "", "The alias of the account the positions were transferred into (required)")
+	flagSet.BoolVar(&f.Wait, ibctlcmd.WaitFlagName, false, "Wait for other ibctl commands modifying the ibctl directory to finish instead of failing")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) (retErr error) {
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	if _, ok := config.AccountAliases[flags.Account]; !ok {
+		return appcmd.NewInvalidArgumentErrorf("--%s must be a configured account alias, got %q", accountFlagName, flags.Account)
+	}
+	file, err := os.Open(container.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	trades, err := ibctltransferbasis.ParseCSV(file, flags.Account)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	// Hold the lock on the ibctl directory while writing the trades.
+	release, err := ibctlcmd.LockDir(ctx, container, config.DirPath, flags.Wait)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = errors.Join(retErr, release())
+	}()
+	if err := ibctltransferbasis.WriteTrades(ibctlpath.DataManualDirPath(config.DirPath), flags.Account, trades); err != nil {
+		return err
+	}
+	container.Logger().Info("transfer basis imported", "account", flags.Account, "lots", len(trades))
+	return nil
+}
@@ -6,21 +6,100 @@
 package download
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
 
 	"buf.build/go/app/appcmd"
 	"buf.build/go/app/appext"
 	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctldownload"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/bufdev/ibctl/internal/pkg/protoio"
 	"github.com/spf13/pflag"
 )
 
+const (
+	// formatFlagName is the flag name for the output format.
+	formatFlagName = "format"
+	// dryRunFlagName is the flag name for reporting changes without writing.
+	dryRunFlagName = "dry-run"
+	// strictFlagName is the flag name for failing on unconvertible records.
+	strictFlagName = "strict"
+	// noWriteIfUnchangedFlagName is the flag name for skipping rewrites of unchanged files.
+	noWriteIfUnchangedFlagName = "no-write-if-unchanged"
+)
+
 // NewCommand returns a new download command that pre-caches IBKR data.
 func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
 	flags := newFlags()
 	return &appcmd.Command{
 		Use:   name,
 		Short: "Pre-cache IBKR data via Flex Query API",
-		Args:  appcmd.NoArgs,
+		Long: `Pre-cache IBKR data via Flex Query API.
+
+With --replay (or the ` + ibctlcmd.FlexReplayEnvVar + ` environment variable), the Flex Query
+response is read from a saved XML file instead of the API, e.g. one captured
+with "ibctl probe --save-raw".
+
+With --dry-run, the Flex Query is fetched but nothing is written. Instead, the
+changes the download would make are printed: new trades per account, position
+quantities that differ from the cached positions, and FX date ranges that
+would be fetched.
+
+After a download, a summary is printed per account: new trades, updated
+positions, and warnings, followed by the FX pairs whose rates were refreshed
+and the symbols whose prices were refreshed. Use --format json for a
+machine-readable summary.
+
+The daily closing prices of traded stocks, ETFs, and funds are downloaded
+from Yahoo Finance into cache/prices/, over the dates each symbol was held,
+for "ibctl holding list --as-of". Only dates not already cached are fetched.
+Set quote_symbol in ibctl.yaml for symbols whose Yahoo Finance symbol differs,
+such as non-US listings (e.g., SHOP.TO). Symbols whose prices cannot be
+fetched are warnings.
+
+The positions are also compared with the previous download (or the latest
+dated snapshot if the cache was cleared), and the positions that were opened,
+closed, or changed quantity are listed after the summary. With notify.command
+in ibctl.yaml, the command is run with this report on stdin whenever positions
+changed, such as to send an email, so that unexpected activity is noticed.
+
+Flex Query records that cannot be converted are skipped with a warning and
+saved to data/quarantine/<alias>/<timestamp>.xml for inspection. With --strict
+(or "strict: true" in ibctl.yaml), the download fails instead.
+
+With --period (or flex_query_period in ibctl.yaml), the period configured in
+the Flex Query is overridden with a preset: LastBusinessDay, LastBusinessWeek,
+Last30CalendarDays, MonthToDate, LastMonth, LastQuarter, YearToDate, LastYear,
+or Last365CalendarDays. A short period makes frequent downloads faster, since
+trades are merged into the existing data.
+
+With --debug-http, the redacted URL, status, and timing of every API request
+are traced to cache/debug/http-<timestamp>.log, to diagnose IBKR-side
+failures. With --debug-http-body, the start of every response body is traced
+too, which can include account data.
+
+Data files are written in a stable format, so re-downloading the same data
+produces the same bytes. With --no-write-if-unchanged, files whose content
+would not change are not rewritten at all, which keeps their modification
+times, and keeps encrypted files from being re-encrypted with a new nonce,
+for users who track the ibctl directory in git.
+
+With git.auto_commit: true in ibctl.yaml, data/ (without data/backups/) is
+committed to git after each successful download, with a message built from
+git.commit_message. A repository is initialized in the ibctl directory if it
+is not already in one. Use "ibctl data log" to list the data history.
+
+A download holds a lock on the ibctl directory (ibctl.lock), so that two
+downloads, such as a scheduled one and a manual one, cannot interleave their
+writes. If another command holds the lock, the download fails with exit code
+7, or with --wait, waits for the other command to finish.`,
+		Args: appcmd.NoArgs,
 		Run: builder.NewRunFunc(
 			func(ctx context.Context, container appext.Container) error {
 				return run(ctx, container, flags)
@@ -33,6 +112,22 @@ func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
 type flags struct {
 	// Dir is the ibctl directory containing ibctl.yaml.
 	Dir string
+	// Format is the output format (table, csv, json).
+	Format string
+	// Replay is the path to a saved Flex Query XML response to use instead of the API.
+	Replay string
+	// DryRun reports the changes a download would make without writing anything.
+	DryRun bool
+	// Strict fails the download on records that cannot be converted.
+	Strict bool
+	// Period is the period preset that overrides the Flex Query's configured period.
+	Period string
+	// NoWriteIfUnchanged skips rewriting files whose content would not change.
+	NoWriteIfUnchanged bool
+	// Wait waits for other commands modifying the ibctl directory to finish instead of failing.
+	Wait bool
+	// DebugHTTP traces every API request to a file under cache/debug/.
+	DebugHTTP ibctlcmd.DebugHTTPFlags
 }
 
 func newFlags() *flags {
@@ -41,15 +136,199 @@ func newFlags() *flags {
 
 // Bind registers the flag definitions with the given flag set.
 func (f *flags) Bind(flagSet *pflag.FlagSet) {
-	flagSet.StringVar(&f.Dir, ibctlcmd.DirFlagName, ".", "The ibctl directory containing ibctl.yaml")
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+	flagSet.StringVar(&f.Replay, ibctlcmd.ReplayFlagName, "", "Read the Flex Query response from a saved XML file instead of the API")
+	flagSet.BoolVar(&f.DryRun, dryRunFlagName, false, "Print the changes the download would make without writing anything")
+	flagSet.BoolVar(&f.Strict, strictFlagName, false, "Fail on records that cannot be converted instead of quarantining them")
+	flagSet.StringVar(&f.Period, ibctlcmd.PeriodFlagName, "", "Period preset that overrides the Flex Query period (e.g., LastBusinessDay, Last30CalendarDays, YearToDate)")
+	flagSet.BoolVar(&f.NoWriteIfUnchanged, noWriteIfUnchangedFlagName, false, "Do not rewrite files whose content would not change")
+	flagSet.BoolVar(&f.Wait, ibctlcmd.WaitFlagName, false, "Wait for other ibctl commands modifying the ibctl directory to finish instead of failing")
+	f.DebugHTTP.Bind(flagSet)
 }
 
-func run(ctx context.Context, container appext.Container, flags *flags) error {
+func run(ctx context.Context, container appext.Container, flags *flags) (retErr error) {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	period, err := ibctlcmd.ParsePeriod(flags.Period)
+	if err != nil {
+		return err
+	}
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	if period != "" {
+		config.FlexQueryPeriod = period
+	}
+	if flags.Strict {
+		config.Strict = true
+	}
+	if flags.NoWriteIfUnchanged {
+		protoio.SetSkipUnchangedWrites(true)
+	}
+	closeTrace, err := flags.DebugHTTP.Open(container, config)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = errors.Join(retErr, closeTrace())
+	}()
 	// Construct the downloader using shared command wiring.
-	downloader, err := ibctlcmd.NewDownloader(container, flags.Dir)
+	downloader, err := ibctlcmd.NewDownloaderForConfig(container, config, flags.Replay)
 	if err != nil {
 		return err
 	}
+	if flags.DryRun {
+		changes, err := downloader.DryRun(ctx)
+		if err != nil {
+			return err
+		}
+		return writeChanges(container.Stdout(), format, changes)
+	}
+	// Hold the lock on the ibctl directory for the whole download.
+	release, err := ibctlcmd.LockDir(ctx, container, config.DirPath, flags.Wait)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = errors.Join(retErr, release())
+	}()
 	// Download full history.
-	return downloader.Download(ctx)
+	summary, err := downloader.DownloadWithSummary(ctx)
+	if err != nil {
+		return err
+	}
+	if err := writeSummary(container.Stdout(), format, summary); err != nil {
+		return err
+	}
+	// A failed notification does not fail the download, since the data was written.
+	if positionChanges := summary.PositionChanges(); config.NotifyCommand != "" && len(positionChanges) > 0 {
+		if err := notify(ctx, container, config.NotifyCommand, positionChanges); err != nil {
+			container.Logger().Warn("failed to run notify command", "error", err)
+		}
+	}
+	return nil
+}
+
+// notify runs the notify command with the position change report on stdin.
+func notify(ctx context.Context, container appext.Container, command string, positionChanges []*ibctldownload.PositionChange) error {
+	var report bytes.Buffer
+	if _, err := fmt.Fprintf(&report, "ibctl download found %d position change(s):\n\n", len(positionChanges)); err != nil {
+		return err
+	}
+	if err := writePositionChanges(&report, positionChanges); err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = &report
+	cmd.Stdout = container.Stderr()
+	cmd.Stderr = container.Stderr()
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running %q: %w", command, err)
+	}
+	return nil
+}
+
+// writePositionChanges writes the position changes as a table.
+func writePositionChanges(writer io.Writer, positionChanges []*ibctldownload.PositionChange) error {
+	rows := make([][]string, 0, len(positionChanges))
+	for _, positionChange := range positionChanges {
+		rows = append(rows, ibctldownload.PositionChangeToRow(positionChange))
+	}
+	return cliio.WriteTable(writer, ibctldownload.PositionChangeHeaders(), rows)
+}
+
+// writeChanges writes the changes a dry run would make in the given format.
+func writeChanges(writer io.Writer, format cliio.Format, changes []*ibctldownload.Change) error {
+	switch format {
+	case cliio.FormatTable:
+		rows := make([][]string, 0, len(changes))
+		for _, change := range changes {
+			rows = append(rows, ibctldownload.ChangeToRow(change))
+		}
+		return cliio.WriteTable(writer, ibctldownload.ChangeHeaders(), rows)
+	case cliio.FormatCSV:
+		records := make([][]string, 0, len(changes)+1)
+		records = append(records, ibctldownload.ChangeHeaders())
+		for _, change := range changes {
+			records = append(records, ibctldownload.ChangeToRow(change))
+		}
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, changes...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
+
+// writeSummary writes the download summary in the given format. The table
+// format lists the position changes, the refreshed FX pairs, the data commit,
+// and all warnings after the account table. The CSV format has the account
+// rows only.
+func writeSummary(writer io.Writer, format cliio.Format, summary *ibctldownload.Summary) error {
+	switch format {
+	case cliio.FormatTable:
+		rows := make([][]string, 0, len(summary.Accounts))
+		for _, accountSummary := range summary.Accounts {
+			rows = append(rows, ibctldownload.AccountSummaryToRow(accountSummary))
+		}
+		if err := cliio.WriteTable(writer, ibctldownload.AccountSummaryHeaders(), rows); err != nil {
+			return err
+		}
+		if positionChanges := summary.PositionChanges(); len(positionChanges) > 0 {
+			if _, err := fmt.Fprintf(writer, "\nPosition changes:\n"); err != nil {
+				return err
+			}
+			if err := writePositionChanges(writer, positionChanges); err != nil {
+				return err
+			}
+		}
+		if len(summary.FXPairsRefreshed) > 0 {
+			if _, err := fmt.Fprintf(writer, "\nFX pairs refreshed: %s\n", strings.Join(summary.FXPairsRefreshed, ", ")); err != nil {
+				return err
+			}
+		}
+		if len(summary.PricesRefreshed) > 0 {
+			if _, err := fmt.Fprintf(writer, "\nPrices refreshed: %s\n", strings.Join(summary.PricesRefreshed, ", ")); err != nil {
+				return err
+			}
+		}
+		if summary.Commit != "" {
+			if _, err := fmt.Fprintf(writer, "\nCommitted data: %s\n", summary.Commit); err != nil {
+				return err
+			}
+		}
+		var warnings []string
+		for _, accountSummary := range summary.Accounts {
+			for _, warning := range accountSummary.Warnings {
+				warnings = append(warnings, accountSummary.Account+": "+warning)
+			}
+		}
+		warnings = append(warnings, summary.Warnings...)
+		if len(warnings) > 0 {
+			if _, err := fmt.Fprintln(writer); err != nil {
+				return err
+			}
+		}
+		for _, warning := range warnings {
+			if _, err := fmt.Fprintf(writer, "warning: %s\n", warning); err != nil {
+				return err
+			}
+		}
+		return nil
+	case cliio.FormatCSV:
+		records := make([][]string, 0, len(summary.Accounts)+1)
+		records = append(records, ibctldownload.AccountSummaryHeaders())
+		for _, accountSummary := range summary.Accounts {
+			records = append(records, ibctldownload.AccountSummaryToRow(accountSummary))
+		}
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, summary)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
 }
@@ -0,0 +1,29 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package export implements the "export" command group.
+package export
+
+import (
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/export/exportbeancount"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/export/exportghostfolio"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/export/exportlots"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/export/exportportfolioperformance"
+)
+
+// NewCommand returns a new export command group.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Export IBKR activity to other tools",
+		SubCommands: []*appcmd.Command{
+			exportbeancount.NewCommand("beancount", builder),
+			exportghostfolio.NewCommand("ghostfolio", builder),
+			exportlots.NewCommand("lots", builder),
+			exportportfolioperformance.NewCommand("portfolio-performance", builder),
+		},
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package exportbeancount implements the "export beancount" command.
+package exportbeancount
+
+import (
+	"bytes"
+	"context"
+	"os"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlbeancount"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlredact"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// outputFlagName is the flag name for the output ledger file path.
+	outputFlagName = "output"
+	// downloadFlagName is the flag name for downloading fresh data before exporting.
+	downloadFlagName = "download"
+)
+
+// NewCommand returns a new export beancount command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Export trades, income, fees, and transfers as a Beancount ledger",
+		Long: `Export trades, income, fees, and transfers as a Beancount ledger.
+
+Each account alias gets Assets, Income, Expenses, and Equity accounts under
+IBKR:<Alias>. Securities are held at cost in a FIFO-booked Securities account,
+so Beancount books realized gains to the CapitalGains account the same way
+ibctl computes them. FX conversions are booked as currency exchanges in the
+Cash account. Deposits, withdrawals, and position transfers are booked
+against the Transfers equity account; transfers in without a transfer price
+are flagged with "!" and booked at zero cost.
+
+Each transaction has the IBKR trade_id or transaction_id as metadata. The
+ledger is written to stdout unless --output is set.
+
+With --redact (or redact: true in ibctl.yaml), account aliases are replaced
+with stable pseudonyms (IBKR:Account-1, ...), and descriptions that mention
+an account are dropped, so the ledger can be shared.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Output is the ledger file path, or empty for stdout.
+	Output string
+	// Download fetches fresh data before exporting.
+	Download bool
+	// Group restricts the export to the accounts in a configured account group.
+	Group string
+	// Redact replaces account identifiers with pseudonyms.
+	Redact ibctlcmd.RedactFlag
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVarP(&f.Output, outputFlagName, "o", "", "Output ledger file path (defaults to stdout)")
+	flagSet.BoolVar(&f.Download, downloadFlagName, false, "Download fresh data before exporting")
+	flagSet.StringVar(&f.Group, ibctlcmd.GroupFlagName, "", "Only include accounts in this account group from ibctl.yaml")
+	f.Redact.Bind(flagSet)
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) error {
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	// Download fresh data if --download is set.
+	if flags.Download {
+		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir, "")
+		if err != nil {
+			return err
+		}
+		if err := downloader.Download(ctx); err != nil {
+			return err
+		}
+	}
+	// Merge trade data from all sources.
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+	)
+	if err != nil {
+		return err
+	}
+	// Restrict to the accounts in --group if set.
+	_, mergedData, err = ibctlcmd.ApplyGroup(config, mergedData, flags.Group)
+	if err != nil {
+		return err
+	}
+	if flags.Redact.Enabled(config) {
+		mergedData = ibctlredact.NewRedactor(config.AccountAliases).MergedData(mergedData)
+	}
+	if flags.Output == "" {
+		return ibctlbeancount.Write(os.Stdout, mergedData)
+	}
+	var buffer bytes.Buffer
+	if err := ibctlbeancount.Write(&buffer, mergedData); err != nil {
+		return err
+	}
+	return os.WriteFile(flags.Output, buffer.Bytes(), 0o600)
+}
@@ -0,0 +1,173 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package exportghostfolio implements the "export ghostfolio" command.
+package exportghostfolio
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlghostfolio"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlredact"
+	"github.com/bufdev/ibctl/internal/pkg/ghostfolio"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// outputFlagName is the flag name for the output JSON file path.
+	outputFlagName = "output"
+	// pushFlagName is the flag name for importing directly into the configured Ghostfolio instance.
+	pushFlagName = "push"
+	// downloadFlagName is the flag name for downloading fresh data before exporting.
+	downloadFlagName = "download"
+	// ghostfolioAccessTokenEnvVar is the environment variable for the Ghostfolio security token.
+	ghostfolioAccessTokenEnvVar = "GHOSTFOLIO_ACCESS_TOKEN"
+)
+
+// NewCommand returns a new export ghostfolio command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Export trades and income as Ghostfolio activities",
+		Long: `Export trades and income as Ghostfolio activities.
+
+Stock and ETF trades, dividends, and interest are exported, with withholding
+tax, fees, and interest paid as fees. Options, futures, bonds, FX conversions,
+deposits, and withdrawals are not exported, since Ghostfolio cannot price or
+represent them. Symbols are exported as-is with the YAHOO data source, so
+non-US listings may need their Yahoo suffix (e.g., SHOP.TO) fixed in Ghostfolio.
+
+By default, the activities are written as a Ghostfolio JSON import file to
+stdout or --output. With --push, they are imported directly into the
+Ghostfolio instance configured in the ghostfolio section of ibctl.yaml, using
+the security token in the ` + ghostfolioAccessTokenEnvVar + ` environment variable.
+Ghostfolio rejects an import that contains activities it already has, so use
+--group or a fresh Ghostfolio account when pushing repeatedly.
+
+With --redact (or redact: true in ibctl.yaml), the import file has no
+Ghostfolio account IDs and descriptions that mention an account are dropped,
+so it can be shared. Activities imported with --push are never redacted.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Output is the JSON file path, or empty for stdout.
+	Output string
+	// Push imports the activities into the configured Ghostfolio instance.
+	Push bool
+	// Download fetches fresh data before exporting.
+	Download bool
+	// Group restricts the export to the accounts in a configured account group.
+	Group string
+	// Redact replaces account identifiers with pseudonyms in the import file.
+	Redact ibctlcmd.RedactFlag
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVarP(&f.Output, outputFlagName, "o", "", "Output JSON file path (defaults to stdout)")
+	flagSet.BoolVar(&f.Push, pushFlagName, false, "Import the activities into the Ghostfolio instance configured in ibctl.yaml")
+	flagSet.BoolVar(&f.Download, downloadFlagName, false, "Download fresh data before exporting")
+	flagSet.StringVar(&f.Group, ibctlcmd.GroupFlagName, "", "Only include accounts in this account group from ibctl.yaml")
+	f.Redact.Bind(flagSet)
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) error {
+	if flags.Push && flags.Output != "" {
+		return appcmd.NewInvalidArgumentErrorf("--%s and --%s cannot be used together", pushFlagName, outputFlagName)
+	}
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	var accessToken string
+	if flags.Push {
+		if config.GhostfolioURL == "" {
+			return appcmd.NewInvalidArgumentErrorf("--%s requires a ghostfolio url in %s", pushFlagName, ibctlpath.ConfigFileName)
+		}
+		accessToken = container.Env(ghostfolioAccessTokenEnvVar)
+		if accessToken == "" {
+			return appcmd.NewInvalidArgumentErrorf("--%s requires the %s environment variable", pushFlagName, ghostfolioAccessTokenEnvVar)
+		}
+	}
+	// Download fresh data if --download is set.
+	if flags.Download {
+		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir, "")
+		if err != nil {
+			return err
+		}
+		if err := downloader.Download(ctx); err != nil {
+			return err
+		}
+	}
+	// Merge trade data from all sources.
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+	)
+	if err != nil {
+		return err
+	}
+	// Restrict to the accounts in --group if set.
+	_, mergedData, err = ibctlcmd.ApplyGroup(config, mergedData, flags.Group)
+	if err != nil {
+		return err
+	}
+	if !flags.Push && flags.Redact.Enabled(config) {
+		mergedData = ibctlredact.NewRedactor(config.AccountAliases).MergedData(mergedData)
+	}
+	activities := ibctlghostfolio.GetActivities(mergedData, config.GhostfolioAccountIDs)
+	if flags.Push {
+		httpClient, err := ibctlcmd.NewHTTPClient(container, config)
+		if err != nil {
+			return err
+		}
+		if err := ghostfolio.NewClient(httpClient, config.GhostfolioURL, accessToken).Import(ctx, activities); err != nil {
+			return err
+		}
+		container.Logger().Info("activities imported into ghostfolio", "url", config.GhostfolioURL, "activities", len(activities))
+		return nil
+	}
+	data, err := json.MarshalIndent(ibctlghostfolio.NewExportFile(activities, time.Now()), "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if flags.Output == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(flags.Output, data, 0o600)
+}
@@ -0,0 +1,174 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package exportlots implements the "export lots" command.
+package exportlots
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlholdings"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlredact"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctltransferbasis"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// formatFlagName is the flag name for the output format.
+	formatFlagName = "format"
+	// outputFlagName is the flag name for the output file path.
+	outputFlagName = "output"
+	// downloadFlagName is the flag name for downloading fresh data before exporting.
+	downloadFlagName = "download"
+	// symbolFlagName is the flag name for filtering by symbol.
+	symbolFlagName = "symbol"
+)
+
+// NewCommand returns a new export lots command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Export open tax lots with their cost basis for a transfer to another broker",
+		Long: `Export open tax lots with their cost basis for a transfer to another broker.
+
+When transferring positions out of IBKR, the receiving broker needs the
+acquisition date and cost basis of each lot. Each row has the account alias,
+symbol, quantity, acquisition date (MM/DD/YYYY), unit cost, total cost basis,
+and currency of a lot, with column names accepted by the cost basis upload
+tools of most brokers. Costs are in the lot's native currency. Export one
+account or --group at a time to upload each account's lots separately.
+
+The CSV can also be imported into another ibctl directory with
+"ibctl data transfer-basis import". --format json writes one object per lot
+instead. The output is written to stdout unless --output is set.
+
+With --redact (or redact: true in ibctl.yaml), account aliases are replaced
+with stable pseudonyms (account-1, ...), so the export can be shared.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Format is the output format (csv, json).
+	Format string
+	// Output is the output file path, or empty for stdout.
+	Output string
+	// Download fetches fresh data before exporting.
+	Download bool
+	// Group restricts the export to the accounts in a configured account group.
+	Group string
+	// Symbol filters lots to a specific symbol. Empty means all symbols.
+	Symbol string
+	// Redact replaces account identifiers with pseudonyms.
+	Redact ibctlcmd.RedactFlag
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "csv", "Output format (csv, json)")
+	flagSet.StringVarP(&f.Output, outputFlagName, "o", "", "Output file path (defaults to stdout)")
+	flagSet.BoolVar(&f.Download, downloadFlagName, false, "Download fresh data before exporting")
+	flagSet.StringVar(&f.Group, ibctlcmd.GroupFlagName, "", "Only include accounts in this account group from ibctl.yaml")
+	flagSet.StringVar(&f.Symbol, symbolFlagName, "", "Filter by symbol (omit for all symbols)")
+	f.Redact.Bind(flagSet)
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) error {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	if format != cliio.FormatCSV && format != cliio.FormatJSON {
+		return appcmd.NewInvalidArgumentErrorf("--%s must be csv or json", formatFlagName)
+	}
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	// Download fresh data if --download is set.
+	if flags.Download {
+		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir, "")
+		if err != nil {
+			return err
+		}
+		if err := downloader.Download(ctx); err != nil {
+			return err
+		}
+	}
+	// Merge trade data from all sources.
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+	)
+	if err != nil {
+		return err
+	}
+	// Restrict to the accounts in --group if set.
+	groupConfig, mergedData, err := ibctlcmd.ApplyGroup(config, mergedData, flags.Group)
+	if err != nil {
+		return err
+	}
+	// Costs are exported in native currency, so no FX rates are needed.
+	result, err := ibctlholdings.GetLotList(flags.Symbol, mergedData.Trades, mergedData.Positions, groupConfig, nil)
+	if err != nil {
+		return err
+	}
+	lots, err := ibctltransferbasis.NewLots(result.Lots)
+	if err != nil {
+		return err
+	}
+	if flags.Redact.Enabled(config) {
+		redactor := ibctlredact.NewRedactor(config.AccountAliases)
+		for _, l := range lots {
+			l.Account = redactor.Pseudonym(l.Account)
+		}
+	}
+	if flags.Output == "" {
+		return write(os.Stdout, format, lots)
+	}
+	var buffer bytes.Buffer
+	if err := write(&buffer, format, lots); err != nil {
+		return err
+	}
+	return os.WriteFile(flags.Output, buffer.Bytes(), 0o600)
+}
+
+// write writes the lots in the format.
+func write(writer io.Writer, format cliio.Format, lots []*ibctltransferbasis.Lot) error {
+	if format == cliio.FormatJSON {
+		return cliio.WriteJSON(writer, lots...)
+	}
+	return ibctltransferbasis.WriteCSV(writer, lots)
+}
@@ -0,0 +1,134 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package exportportfolioperformance implements the "export portfolio-performance" command.
+package exportportfolioperformance
+
+import (
+	"bytes"
+	"context"
+	"os"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlportfolioperformance"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlredact"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// outputFlagName is the flag name for the output CSV file path.
+	outputFlagName = "output"
+	// downloadFlagName is the flag name for downloading fresh data before exporting.
+	downloadFlagName = "download"
+)
+
+// NewCommand returns a new export portfolio-performance command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Export trades, income, and cash flows as a Portfolio Performance CSV",
+		Long: `Export trades, income, and cash flows as a Portfolio Performance CSV.
+
+Import the file in Portfolio Performance with File > Import > CSV files, using
+the "Account Transactions" type, which books buys and sells against both the
+cash and securities accounts chosen in the import wizard. Export one account
+or --group at a time to import each IBKR account into its own Portfolio
+Performance accounts.
+
+FX conversions and position transfers are not exported. The Note column has
+the account alias and IBKR ID of each row. The CSV is written to stdout
+unless --output is set.
+
+With --redact (or redact: true in ibctl.yaml), account aliases are replaced
+with stable pseudonyms (account-1, ...), and descriptions that mention an
+account are dropped, so the CSV can be shared.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Output is the CSV file path, or empty for stdout.
+	Output string
+	// Download fetches fresh data before exporting.
+	Download bool
+	// Group restricts the export to the accounts in a configured account group.
+	Group string
+	// Redact replaces account identifiers with pseudonyms.
+	Redact ibctlcmd.RedactFlag
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVarP(&f.Output, outputFlagName, "o", "", "Output CSV file path (defaults to stdout)")
+	flagSet.BoolVar(&f.Download, downloadFlagName, false, "Download fresh data before exporting")
+	flagSet.StringVar(&f.Group, ibctlcmd.GroupFlagName, "", "Only include accounts in this account group from ibctl.yaml")
+	f.Redact.Bind(flagSet)
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) error {
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	// Download fresh data if --download is set.
+	if flags.Download {
+		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir, "")
+		if err != nil {
+			return err
+		}
+		if err := downloader.Download(ctx); err != nil {
+			return err
+		}
+	}
+	// Merge trade data from all sources.
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+	)
+	if err != nil {
+		return err
+	}
+	// Restrict to the accounts in --group if set.
+	_, mergedData, err = ibctlcmd.ApplyGroup(config, mergedData, flags.Group)
+	if err != nil {
+		return err
+	}
+	if flags.Redact.Enabled(config) {
+		mergedData = ibctlredact.NewRedactor(config.AccountAliases).MergedData(mergedData)
+	}
+	if flags.Output == "" {
+		return ibctlportfolioperformance.Write(os.Stdout, mergedData)
+	}
+	var buffer bytes.Buffer
+	if err := ibctlportfolioperformance.Write(&buffer, mergedData); err != nil {
+		return err
+	}
+	return os.WriteFile(flags.Output, buffer.Bytes(), 0o600)
+}
@@ -7,17 +7,22 @@ package categorylist
 
 import (
 	"context"
+	"io"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"buf.build/go/app/appcmd"
 	"buf.build/go/app/appext"
 	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
-	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctlholdings"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
 	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
 	"github.com/spf13/pflag"
 )
 
@@ -27,13 +32,21 @@ const formatFlagName = "format"
 // downloadFlagName is the flag name for downloading fresh data before displaying.
 const downloadFlagName = "download"
 
+// byFlagName is the flag name for the classification to aggregate by.
+const byFlagName = "by"
+
 // NewCommand returns a new category list command.
 func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
 	flags := newFlags()
 	return &appcmd.Command{
 		Use:   name,
 		Short: "List holdings aggregated by category",
-		Args:  appcmd.NoArgs,
+		Long: `List holdings aggregated by category.
+
+Use --by to aggregate by another symbol classification (type, sector, or geo)
+instead. Use --format chart to render the allocation as a bar chart in the
+terminal, largest first.`,
+		Args: appcmd.NoArgs,
 		Run: builder.NewRunFunc(
 			func(ctx context.Context, container appext.Container) error {
 				return run(ctx, container, flags)
@@ -46,10 +59,16 @@ func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
 type flags struct {
 	// Dir is the base directory containing ibctl.yaml and data subdirectories.
 	Dir string
-	// Format is the output format (table, csv, json).
+	// Format is the output format (table, csv, json, chart).
 	Format string
+	// By is the classification to aggregate by (category, type, sector, geo).
+	By string
 	// Download fetches fresh data before displaying.
 	Download bool
+	// Group restricts holdings to the accounts in a configured account group.
+	Group string
+	// MaxAge fails if the position snapshot is older than this, or is 0 for no limit.
+	MaxAge time.Duration
 }
 
 func newFlags() *flags {
@@ -58,24 +77,31 @@ func newFlags() *flags {
 
 // Bind registers the flag definitions with the given flag set.
 func (f *flags) Bind(flagSet *pflag.FlagSet) {
-	flagSet.StringVar(&f.Dir, ibctlcmd.DirFlagName, ".", "The ibctl directory containing ibctl.yaml")
-	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json, chart)")
+	flagSet.StringVar(&f.By, byFlagName, string(ibctlholdings.ClassificationCategory), "Classification to aggregate by (category, type, sector, geo)")
 	flagSet.BoolVar(&f.Download, downloadFlagName, false, "Download fresh data before displaying")
+	flagSet.StringVar(&f.Group, ibctlcmd.GroupFlagName, "", "Only include accounts in this account group from ibctl.yaml")
+	flagSet.DurationVar(&f.MaxAge, ibctlcmd.MaxAgeFlagName, 0, "Fail if the position snapshot was downloaded longer ago than this (e.g., 24h)")
 }
 
 func run(ctx context.Context, container appext.Container, flags *flags) error {
-	format, err := cliio.ParseFormat(flags.Format)
+	format, err := cliio.ParseChartableFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	classification, err := ibctlholdings.ParseClassification(flags.By)
 	if err != nil {
 		return appcmd.NewInvalidArgumentError(err.Error())
 	}
 	// Read and validate the configuration file from the base directory.
-	config, err := ibctlconfig.ReadConfig(flags.Dir)
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
 	if err != nil {
 		return err
 	}
 	// Download fresh data if --download is set.
 	if flags.Download {
-		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir)
+		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir, "")
 		if err != nil {
 			return err
 		}
@@ -88,12 +114,25 @@ func run(ctx context.Context, container appext.Container, flags *flags) error {
 		ibctlpath.DataAccountsDirPath(config.DirPath),
 		ibctlpath.CacheAccountsDirPath(config.DirPath),
 		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
 		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
 		config.AccountAliases,
 	)
 	if err != nil {
 		return err
 	}
+	// Restrict to the accounts in --group if set.
+	config, mergedData, err = ibctlcmd.ApplyGroup(config, mergedData, flags.Group)
+	if err != nil {
+		return err
+	}
+	// Warn about, or with --max-age fail on, a stale position snapshot.
+	if err := ibctlcmd.CheckSnapshotAge(container, config, flags.Group, flags.MaxAge); err != nil {
+		return err
+	}
 	// Load FX rates for USD conversion.
 	fxStore := ibctlfxrates.NewStore(ibctlpath.CacheFXDirPath(config.DirPath))
 	// Compute holdings via FIFO from all trade data.
@@ -101,29 +140,50 @@ func run(ctx context.Context, container appext.Container, flags *flags) error {
 	if err != nil {
 		return err
 	}
-	// Aggregate holdings by category.
-	categories := ibctlholdings.GetCategoryList(result.Holdings)
+	// Aggregate holdings by the classification.
+	categories := ibctlholdings.GetClassificationList(result.Holdings, classification, config.Lookthroughs)
+	totals := ibctlholdings.ComputeCategoryTotals(categories)
+	// Name the first column after the classification.
+	headers := ibctlholdings.CategoryListHeaders()
+	headers[0] = strings.ToUpper(string(classification))
 	// Write output in the requested format.
 	writer := os.Stdout
 	switch format {
 	case cliio.FormatTable:
-		headers := ibctlholdings.CategoryListHeaders()
 		rows := make([][]string, 0, len(categories))
 		for _, c := range categories {
 			rows = append(rows, ibctlholdings.CategoryOverviewToTableRow(c))
 		}
-		return cliio.WriteTable(writer, headers, rows)
+		return cliio.WriteTableWithTotals(writer, headers, rows, ibctlholdings.CategoryOverviewToTableRow(totals))
 	case cliio.FormatCSV:
-		headers := ibctlholdings.CategoryListHeaders()
-		records := make([][]string, 0, len(categories)+1)
+		records := make([][]string, 0, len(categories)+2)
 		records = append(records, headers)
 		for _, c := range categories {
 			records = append(records, ibctlholdings.CategoryOverviewToRow(c))
 		}
+		records = append(records, ibctlholdings.CategoryOverviewToRow(totals))
 		return cliio.WriteCSVRecords(writer, records)
 	case cliio.FormatJSON:
-		return cliio.WriteJSON(writer, categories...)
+		return cliio.WriteJSONWithSummary(writer, xtime.TimeToDate(time.Now()).String(), totals, categories...)
+	case cliio.FormatChart:
+		return writeChart(writer, categories)
 	default:
 		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
 	}
 }
+
+// writeChart writes the allocation as a bar chart, largest market value first.
+func writeChart(writer io.Writer, categories []*ibctlholdings.CategoryOverview) error {
+	entries := make([]cliio.BarChartEntry, 0, len(categories))
+	for _, c := range categories {
+		entries = append(entries, cliio.BarChartEntry{
+			Label:      c.Category,
+			Value:      float64(mathpb.ParseMicros(c.MarketValueUSD)),
+			Annotation: c.NetLiqPct + "  " + cliio.FormatUSD(c.MarketValueUSD),
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Value > entries[j].Value
+	})
+	return cliio.WriteBarChart(writer, entries)
+}
@@ -0,0 +1,23 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package currency implements the "holding currency" command group.
+package currency
+
+import (
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/holding/currency/currencylist"
+)
+
+// NewCommand returns a new currency command group.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Display holdings by currency",
+		SubCommands: []*appcmd.Command{
+			currencylist.NewCommand("list", builder),
+		},
+	}
+}
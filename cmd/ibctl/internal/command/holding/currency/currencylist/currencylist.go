@@ -0,0 +1,153 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package currencylist implements the "holding currency list" command.
+package currencylist
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlholdings"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/spf13/pflag"
+)
+
+// formatFlagName is the flag name for the output format.
+const formatFlagName = "format"
+
+// downloadFlagName is the flag name for downloading fresh data before displaying.
+const downloadFlagName = "download"
+
+// NewCommand returns a new currency list command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "List currency exposure across securities and cash",
+		Long: `List currency exposure across securities and cash.
+
+Securities are assigned to their trading currency, or split across currencies
+by the currencies look-through of the symbol in ibctl.yaml (e.g., for ADRs and
+global ETFs). Cash balances are assigned to their own currency. Each currency's
+exposure is shown in USD, in the currency itself, and in the base_currency from
+the taxes section of ibctl.yaml.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Format is the output format (table, csv, json).
+	Format string
+	// Download fetches fresh data before displaying.
+	Download bool
+	// Group restricts holdings to the accounts in a configured account group.
+	Group string
+	// MaxAge fails if the position snapshot is older than this, or is 0 for no limit.
+	MaxAge time.Duration
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+	flagSet.BoolVar(&f.Download, downloadFlagName, false, "Download fresh data before displaying")
+	flagSet.StringVar(&f.Group, ibctlcmd.GroupFlagName, "", "Only include accounts in this account group from ibctl.yaml")
+	flagSet.DurationVar(&f.MaxAge, ibctlcmd.MaxAgeFlagName, 0, "Fail if the position snapshot was downloaded longer ago than this (e.g., 24h)")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) error {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	// Download fresh data if --download is set.
+	if flags.Download {
+		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir, "")
+		if err != nil {
+			return err
+		}
+		if err := downloader.Download(ctx); err != nil {
+			return err
+		}
+	}
+	// Merge trade data from all sources.
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+	)
+	if err != nil {
+		return err
+	}
+	// Restrict to the accounts in --group if set.
+	config, mergedData, err = ibctlcmd.ApplyGroup(config, mergedData, flags.Group)
+	if err != nil {
+		return err
+	}
+	// Warn about, or with --max-age fail on, a stale position snapshot.
+	if err := ibctlcmd.CheckSnapshotAge(container, config, flags.Group, flags.MaxAge); err != nil {
+		return err
+	}
+	// Load FX rates for USD and base currency conversion.
+	fxStore := ibctlfxrates.NewStore(ibctlpath.CacheFXDirPath(config.DirPath))
+	// Compute holdings via FIFO from all trade data.
+	result, err := ibctlholdings.GetHoldingsOverview(mergedData.Trades, mergedData.Positions, mergedData.CashPositions, config, fxStore)
+	if err != nil {
+		return err
+	}
+	exposures := ibctlholdings.GetCurrencyExposure(result.Holdings, config, fxStore)
+	headers := ibctlholdings.CurrencyExposureHeaders(config.TaxBaseCurrency)
+	// Write output in the requested format.
+	writer := os.Stdout
+	switch format {
+	case cliio.FormatTable:
+		rows := make([][]string, 0, len(exposures))
+		for _, exposure := range exposures {
+			rows = append(rows, ibctlholdings.CurrencyExposureToTableRow(exposure))
+		}
+		return cliio.WriteTable(writer, headers, rows)
+	case cliio.FormatCSV:
+		records := make([][]string, 0, len(exposures)+1)
+		records = append(records, headers)
+		for _, exposure := range exposures {
+			records = append(records, ibctlholdings.CurrencyExposureToRow(exposure))
+		}
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, exposures...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
@@ -9,7 +9,12 @@ import (
 	"buf.build/go/app/appcmd"
 	"buf.build/go/app/appext"
 	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/holding/category"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/holding/currency"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/holding/holdingestimatedtax"
 	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/holding/holdinglist"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/holding/holdingstats"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/holding/holdingstress"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/holding/holdingtaxprojection"
 	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/holding/holdingvalue"
 	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/holding/lot"
 )
@@ -21,8 +26,13 @@ func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
 		Short: "Display holding information",
 		SubCommands: []*appcmd.Command{
 			category.NewCommand("category", builder),
+			currency.NewCommand("currency", builder),
+			holdingestimatedtax.NewCommand("estimated-tax", builder),
 			holdinglist.NewCommand("list", builder),
 			lot.NewCommand("lot", builder),
+			holdingstats.NewCommand("stats", builder),
+			holdingstress.NewCommand("stress", builder),
+			holdingtaxprojection.NewCommand("tax-projection", builder),
 			holdingvalue.NewCommand("value", builder),
 		},
 	}
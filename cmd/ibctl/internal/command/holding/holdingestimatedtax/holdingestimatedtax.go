@@ -0,0 +1,190 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package holdingestimatedtax implements the "holding estimated-tax" command.
+package holdingestimatedtax
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctltax"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// formatFlagName is the flag name for the output format.
+	formatFlagName = "format"
+	// downloadFlagName is the flag name for downloading fresh data before displaying.
+	downloadFlagName = "download"
+	// yearFlagName is the flag name for the tax year.
+	yearFlagName = "year"
+	// priorYearTaxFlagName is the flag name for overriding the prior-year tax.
+	priorYearTaxFlagName = "prior-year-tax"
+)
+
+// NewCommand returns a new holding estimated-tax command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Compute safe-harbor quarterly estimated tax payments",
+		Long: `Compute safe-harbor quarterly estimated tax payments.
+
+The required annual amount is the lower of 90% of the tax projected for the
+year by "holding tax-projection" and taxes.prior_year_pct (default 100) percent
+of taxes.prior_year_tax from ibctl.yaml. It is split evenly across the four
+due dates: April 15, June 15, September 15, and January 15 of the next year.
+
+The projection only includes realized gains and income to date, so rerun this
+before each due date. Due dates are not adjusted for weekends or holidays.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Format is the output format (table, csv, json).
+	Format string
+	// Download fetches fresh data before displaying.
+	Download bool
+	// Group restricts the projection to the accounts in a configured account group.
+	Group string
+	// Year is the tax year.
+	Year int
+	// PriorYearTax overrides taxes.prior_year_tax from the config.
+	PriorYearTax string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+	flagSet.BoolVar(&f.Download, downloadFlagName, false, "Download fresh data before displaying")
+	flagSet.StringVar(&f.Group, ibctlcmd.GroupFlagName, "", "Only include accounts in this account group from ibctl.yaml")
+	flagSet.IntVar(&f.Year, yearFlagName, time.Now().Year(), "The tax year")
+	flagSet.StringVar(&f.PriorYearTax, priorYearTaxFlagName, "", "The prior year's total tax in USD, overriding taxes.prior_year_tax")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) error {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	var flagPriorYearTaxMicros int64
+	if flags.PriorYearTax != "" {
+		units, micros, err := mathpb.ParseToUnitsMicros(flags.PriorYearTax)
+		if err != nil {
+			return appcmd.NewInvalidArgumentErrorf("invalid --%s %q: %v", priorYearTaxFlagName, flags.PriorYearTax, err)
+		}
+		flagPriorYearTaxMicros = units*1_000_000 + micros
+	}
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	// Download fresh data if --download is set.
+	if flags.Download {
+		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir, "")
+		if err != nil {
+			return err
+		}
+		if err := downloader.Download(ctx); err != nil {
+			return err
+		}
+	}
+	// Merge trade data from all sources.
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+	)
+	if err != nil {
+		return err
+	}
+	// Restrict to the accounts in --group if set.
+	config, mergedData, err = ibctlcmd.ApplyGroup(config, mergedData, flags.Group)
+	if err != nil {
+		return err
+	}
+	// Load FX rates for USD and base currency conversion.
+	fxStore := ibctlfxrates.NewStore(ibctlpath.CacheFXDirPath(config.DirPath))
+	// Project the tax for the year to date.
+	projection, err := ibctltax.GetProjection(flags.Year, mergedData.Trades, mergedData.CashTransactions, config, fxStore)
+	if err != nil {
+		return err
+	}
+	if len(projection.UnconvertedCurrencies) > 0 {
+		container.Logger().Warn(
+			"activity excluded from tax projection, no USD exchange rate available",
+			"currencies", strings.Join(projection.UnconvertedCurrencies, ","),
+		)
+	}
+	priorYearTaxMicros := config.TaxPriorYearMicros
+	if flags.PriorYearTax != "" {
+		priorYearTaxMicros = flagPriorYearTaxMicros
+	}
+	estimatedPayments := ibctltax.GetEstimatedPayments(projection, priorYearTaxMicros, config.TaxPriorYearPct, fxStore)
+	// Write output in the requested format.
+	writer := os.Stdout
+	headers := ibctltax.EstimatedPaymentHeaders(estimatedPayments.BaseCurrency)
+	switch format {
+	case cliio.FormatTable:
+		rows := make([][]string, 0, len(estimatedPayments.Payments))
+		for _, p := range estimatedPayments.Payments {
+			rows = append(rows, ibctltax.EstimatedPaymentToTableRow(p))
+		}
+		if err := cliio.WriteTable(writer, headers, rows); err != nil {
+			return err
+		}
+		fmt.Fprintf(writer, "\n")
+		fmt.Fprintf(writer, "Projected Tax:            %s\n", cliio.FormatUSD(estimatedPayments.CurrentYearTaxUSD))
+		fmt.Fprintf(writer, "Current-Year Safe Harbor: %s (90%% of projected tax)\n", cliio.FormatUSD(estimatedPayments.CurrentYearSafeHarborUSD))
+		if estimatedPayments.PriorYearSafeHarborUSD != "" {
+			fmt.Fprintf(writer, "Prior-Year Safe Harbor:   %s (%.0f%% of prior-year tax)\n", cliio.FormatUSD(estimatedPayments.PriorYearSafeHarborUSD), config.TaxPriorYearPct)
+		}
+		fmt.Fprintf(writer, "Required:                 %s\n", cliio.FormatUSD(estimatedPayments.RequiredUSD))
+		return nil
+	case cliio.FormatCSV:
+		records := make([][]string, 0, len(estimatedPayments.Payments)+1)
+		records = append(records, headers)
+		for _, p := range estimatedPayments.Payments {
+			records = append(records, ibctltax.EstimatedPaymentToRow(p))
+		}
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, estimatedPayments)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
@@ -7,7 +7,12 @@ package holdinglist
 
 import (
 	"context"
+	"fmt"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
+	"time"
 
 	"buf.build/go/app/appcmd"
 	"buf.build/go/app/appext"
@@ -15,11 +20,16 @@ import (
 	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctlholdings"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlincome"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlprices"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctltaxlot"
 	"github.com/bufdev/ibctl/internal/pkg/cliio"
 	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/pricestore"
+	"github.com/bufdev/ibctl/internal/pkg/yahoofinance"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
 	"github.com/spf13/pflag"
 )
 
@@ -29,13 +39,83 @@ const formatFlagName = "format"
 // downloadFlagName is the flag name for downloading fresh data before displaying.
 const downloadFlagName = "download"
 
+// watchFlagName is the flag name for re-rendering the table in place on an interval.
+const watchFlagName = "watch"
+
+// refreshFlagName is the flag name for the --watch refresh interval.
+const refreshFlagName = "refresh"
+
+// byAccountFlagName is the flag name for grouping holdings by account with subtotals.
+const byAccountFlagName = "by-account"
+
+// holdingsDropOrder is the order in which holdings table columns are dropped
+// to fit the terminal width: classifications, then yield and projected
+// income, then native currency prices, then the STCG/LTCG split and the
+// currency.
+var holdingsDropOrder = []int{14, 12, 13, 11, 17, 18, 3, 2, 5, 8, 9, 1}
+
 // NewCommand returns a new holdings overview command.
 func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
 	flags := newFlags()
 	return &appcmd.Command{
 		Use:   name,
 		Short: "List holdings with prices, positions, and classifications",
-		Args:  appcmd.NoArgs,
+		Long: `List holdings with prices, positions, and classifications.
+
+With --as-of YYYY-MM-DD, holdings are computed from the trades on or before
+the date, valued at the closing prices in the price cache (cache/prices/) and
+converted at the FX rates in effect on the date, with ST/LT classification as
+of the date. Closing prices of stocks and funds that are not cached are
+fetched from Yahoo Finance. A symbol held on the date without a closing price
+in the week up to it, or a price override dated on or before it, is an error.
+Cash balances are only known for the present, so they are omitted.
+
+YIELD and PROJ INCOME USD are computed from the dividends per share paid in
+the trailing twelve months, from the dividend records in statement data:
+YIELD is the dividends per share divided by the last price, and PROJ INCOME
+USD is the dividends per share times the position, the annual income if the
+dividends continue. Holdings without dividends in the trailing twelve months
+have no yield. The TOTAL row has the total projected income and its yield on
+the total market value.
+
+With --watch, the table stays on screen and is re-rendered in place every
+--refresh interval (default 1m) until interrupted, with a CHANGE USD column
+with the change in market value since the previous refresh. Each refresh
+revalues stocks and funds at their latest quotes from Yahoo Finance, which
+are delayed by up to the exchange's delay, without downloading Flex Query
+data, which is end-of-day. Other holdings keep the prices of the last
+download. With --download, data is downloaded once before the first render.
+
+In a terminal, P&L, STCG, LTCG, and changes are colored green for gains and
+red for losses, symbols with a position discrepancy or no market value are
+yellow, and totals are cyan. --color always or never overrides the terminal
+detection, and the NO_COLOR environment variable disables auto coloring.
+
+Tables wider than the terminal (or COLUMNS) are narrowed by dropping the
+classification columns, then the yield and projected income, then the
+native currency prices, then the STCG/LTCG split. Use --pager to view every
+column in $PAGER instead.
+
+With --fx-audit (requires --format json), each holding not in USD has an
+fx_rate with the currency pair, rate, rate date, and provider its USD values
+were converted with, to verify conversions against broker statements.
+
+With --by-account, holdings are listed per account instead of aggregated
+across accounts, and each account is followed by a subtotal row with the
+market value, P&L, STCG, and LTCG in USD. The TOTAL row is the combined total
+of all accounts, which also includes the cash adjustments from ibctl.yaml.
+Subtotal rows are in table and CSV output; JSON output is not grouped.
+
+--dir can be repeated to combine the holdings of separate ibctl directories,
+such as personal and corporate directories, into one report. Each directory
+is read with its own ibctl.yaml, data, and caches, and holdings of the same
+symbol are combined, with positions and USD values summed and average prices
+weighted by position. --group applies to every directory. With --by-account,
+subtotal rows are labeled with the directory name and account alias.
+
+CSV output ends with a TOTAL row, and JSON output with a summary object
+{"summary":{"as_of":...,"count":...,"totals":{...}}} after the holdings.`,
+		Args: appcmd.NoArgs,
 		Run: builder.NewRunFunc(
 			func(ctx context.Context, container appext.Container) error {
 				return run(ctx, container, flags)
@@ -46,12 +126,31 @@ func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
 }
 
 type flags struct {
-	// Dir is the base directory containing ibctl.yaml and data subdirectories.
-	Dir string
+	// Dirs is the base directories containing ibctl.yaml and data
+	// subdirectories, whose holdings are combined.
+	Dirs []string
 	// Format is the output format (table, csv, json).
 	Format string
 	// Download fetches fresh data before displaying.
 	Download bool
+	// Group restricts holdings to the accounts in a configured account group.
+	Group string
+	// MaxAge fails if the position snapshot is older than this, or is 0 for no limit.
+	MaxAge time.Duration
+	// AsOf computes holdings as of a historical date (YYYY-MM-DD).
+	AsOf string
+	// Watch re-quotes and re-renders the table in place every Refresh interval.
+	Watch bool
+	// Refresh is the --watch refresh interval.
+	Refresh time.Duration
+	// Color is when to color table output (auto, always, never).
+	Color string
+	// Pager pipes table output into the PAGER command.
+	Pager bool
+	// FXAudit annotates each holding with the FX rate its USD values were converted with.
+	FXAudit bool
+	// ByAccount lists holdings per account with subtotal rows.
+	ByAccount bool
 }
 
 func newFlags() *flags {
@@ -60,9 +159,18 @@ func newFlags() *flags {
 
 // Bind registers the flag definitions with the given flag set.
 func (f *flags) Bind(flagSet *pflag.FlagSet) {
-	flagSet.StringVar(&f.Dir, ibctlcmd.DirFlagName, ".", "The ibctl directory containing ibctl.yaml")
+	ibctlcmd.BindDirsFlags(flagSet, &f.Dirs)
 	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
 	flagSet.BoolVar(&f.Download, downloadFlagName, false, "Download fresh data before displaying")
+	flagSet.StringVar(&f.Group, ibctlcmd.GroupFlagName, "", "Only include accounts in this account group from ibctl.yaml")
+	flagSet.DurationVar(&f.MaxAge, ibctlcmd.MaxAgeFlagName, 0, "Fail if the position snapshot was downloaded longer ago than this (e.g., 24h)")
+	flagSet.StringVar(&f.AsOf, ibctlcmd.AsOfFlagName, "", "Compute holdings as of a historical date (YYYY-MM-DD)")
+	flagSet.BoolVar(&f.Watch, watchFlagName, false, "Re-quote and re-render the table in place every --refresh interval")
+	flagSet.DurationVar(&f.Refresh, refreshFlagName, time.Minute, "The --watch refresh interval")
+	flagSet.StringVar(&f.Color, ibctlcmd.ColorFlagName, string(cliio.ColorModeAuto), "When to color table output (auto, always, never)")
+	flagSet.BoolVar(&f.Pager, ibctlcmd.PagerFlagName, false, "Pipe table output into $PAGER (default less -RS)")
+	flagSet.BoolVar(&f.FXAudit, ibctlcmd.FXAuditFlagName, false, "Annotate each holding with the FX rate, rate date, and provider its USD values were converted with (requires --format json)")
+	flagSet.BoolVar(&f.ByAccount, byAccountFlagName, false, "List holdings per account with subtotal rows")
 }
 
 func run(ctx context.Context, container appext.Container, flags *flags) error {
@@ -70,20 +178,227 @@ func run(ctx context.Context, container appext.Container, flags *flags) error {
 	if err != nil {
 		return appcmd.NewInvalidArgumentError(err.Error())
 	}
-	// Read and validate the configuration file from the base directory.
-	config, err := ibctlconfig.ReadConfig(flags.Dir)
+	colorMode, err := cliio.ParseColorMode(flags.Color)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	asOf, err := ibctlcmd.ParseAsOf(flags.AsOf)
 	if err != nil {
 		return err
 	}
-	// Download fresh data if --download is set.
-	if flags.Download {
-		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir)
+	if flags.FXAudit && format != cliio.FormatJSON {
+		return appcmd.NewInvalidArgumentErrorf("--%s requires --%s json", ibctlcmd.FXAuditFlagName, formatFlagName)
+	}
+	// Colored output is for terminals, so it only applies to tables.
+	color := format == cliio.FormatTable && cliio.ColorEnabled(colorMode, os.Stdout)
+	if flags.Watch {
+		if format != cliio.FormatTable {
+			return appcmd.NewInvalidArgumentErrorf("--%s requires --%s table", watchFlagName, formatFlagName)
+		}
+		if !asOf.IsZero() {
+			return appcmd.NewInvalidArgumentErrorf("--%s cannot be used with --%s", watchFlagName, ibctlcmd.AsOfFlagName)
+		}
+		if flags.Pager {
+			return appcmd.NewInvalidArgumentErrorf("--%s cannot be used with --%s", watchFlagName, ibctlcmd.PagerFlagName)
+		}
+		if flags.ByAccount {
+			return appcmd.NewInvalidArgumentErrorf("--%s cannot be used with --%s", watchFlagName, byAccountFlagName)
+		}
+		if flags.Refresh <= 0 {
+			return appcmd.NewInvalidArgumentErrorf("--%s must be positive", refreshFlagName)
+		}
+		return watch(ctx, container, flags, color)
+	}
+	result, accounts, err := getHoldings(ctx, container, flags, asOf, flags.Download, false)
+	if err != nil {
+		return err
+	}
+	// Write output in the requested format.
+	writer := os.Stdout
+	switch format {
+	case cliio.FormatTable:
+		headers, rows, rowHoldings, totalsRow := holdingsTable(result)
+		subtotalRowIndexes := make(map[int]struct{})
+		if flags.ByAccount {
+			rows, rowHoldings, subtotalRowIndexes = accountHoldingsTable(accounts)
+		}
+		var cellColor cliio.CellColorFunc
+		if color {
+			warningSymbols := holdingWarningSymbols(result)
+			cellColor = func(rowIndex int, columnIndex int) cliio.Color {
+				if _, ok := subtotalRowIndexes[rowIndex]; ok {
+					return cliio.ColorCyan
+				}
+				return holdingCellColor(rowHoldings[rowIndex], columnIndex, warningSymbols)
+			}
+		}
+		return ibctlcmd.WriteTable(ctx, container, flags.Pager, headers, rows, totalsRow, holdingsDropOrder, cellColor)
+	case cliio.FormatCSV:
+		headers := ibctlholdings.HoldingsOverviewHeaders()
+		records := make([][]string, 0, len(result.Holdings)+2)
+		records = append(records, headers)
+		if flags.ByAccount {
+			for _, account := range accounts {
+				for _, h := range account.holdings {
+					records = append(records, ibctlholdings.HoldingOverviewToRow(h))
+				}
+				records = append(records, ibctlholdings.AccountTotalsToRow(account.alias, ibctlholdings.ComputeTotals(account.holdings)))
+			}
+		} else {
+			for _, h := range result.Holdings {
+				records = append(records, ibctlholdings.HoldingOverviewToRow(h))
+			}
+		}
+		records = append(records, ibctlholdings.TotalsToRow(ibctlholdings.ComputeTotals(result.Holdings)))
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSONWithSummary(writer, ibctlcmd.AsOfDate(asOf).String(), ibctlholdings.ComputeTotals(result.Holdings), result.Holdings...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
+
+// watch re-quotes and re-renders the holdings table in place every refresh
+// interval until interrupted, with a CHANGE USD column of the change in each
+// holding's market value since the previous refresh. Data is only downloaded
+// before the first render, and only if --download is set, since Flex Query
+// data is end-of-day and each download writes the data directory. If color is
+// set, changes are colored green or red.
+func watch(ctx context.Context, container appext.Container, flags *flags, color bool) error {
+	writer := os.Stdout
+	var previousMarketValues map[string]int64
+	download := flags.Download
+	for {
+		result, _, err := getHoldings(ctx, container, flags, xtime.Date{}, download, true)
 		if err != nil {
 			return err
 		}
-		if err := downloader.Download(ctx); err != nil {
+		download = false
+		headers, rows, rowHoldings, totalsRow := holdingsTable(result)
+		headers = append(headers, "CHANGE USD")
+		totalsRow = append(totalsRow, "")
+		changeColumnIndex := len(headers) - 1
+		marketValues := make(map[string]int64, len(result.Holdings))
+		changes := make([]int64, len(rows))
+		var totalChangeMicros int64
+		for i, h := range rowHoldings {
+			change := ""
+			// Holdings without a market price or FX rate have no market value to compare.
+			if h != nil && h.MarketValueUSD != "" {
+				marketValueMicros := mathpb.ParseMicros(h.MarketValueUSD)
+				marketValues[h.Symbol] = marketValueMicros
+				if previousMarketValue, ok := previousMarketValues[h.Symbol]; ok {
+					changes[i] = marketValueMicros - previousMarketValue
+					totalChangeMicros += changes[i]
+					change = cliio.FormatUSDMicros(changes[i])
+				}
+			}
+			rows[i] = append(rows[i], change)
+		}
+		if previousMarketValues != nil {
+			totalsRow[changeColumnIndex] = cliio.FormatUSDMicros(totalChangeMicros)
+		}
+		previousMarketValues = marketValues
+		if _, err := fmt.Fprint(writer, cliio.ClearScreen); err != nil {
 			return err
 		}
+		var cellColor cliio.CellColorFunc
+		if color {
+			warningSymbols := holdingWarningSymbols(result)
+			cellColor = func(rowIndex int, columnIndex int) cliio.Color {
+				if columnIndex == changeColumnIndex {
+					switch {
+					case changes[rowIndex] > 0:
+						return cliio.ColorGreen
+					case changes[rowIndex] < 0:
+						return cliio.ColorRed
+					}
+				}
+				return holdingCellColor(rowHoldings[rowIndex], columnIndex, warningSymbols)
+			}
+		}
+		if err := ibctlcmd.WriteTable(ctx, container, false, headers, rows, totalsRow, holdingsDropOrder, cellColor); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(writer, "\nUpdated %s, refreshing every %s (Ctrl-C to stop)\n", time.Now().Format(time.TimeOnly), flags.Refresh); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(flags.Refresh):
+		}
+	}
+}
+
+// accountHoldings is the holdings of a single account, for --by-account.
+type accountHoldings struct {
+	// alias is the account alias.
+	alias string
+	// holdings is the account's holdings.
+	holdings []*ibctlholdings.HoldingOverview
+}
+
+// getHoldings computes the holdings of each --dir directory and combines
+// them. If quote is set, priced positions are revalued at their latest quotes. If --by-account is set, the holdings of each account with any are also
+// returned, sorted by alias within each directory. With multiple directories,
+// account aliases are prefixed with the directory name.
+func getHoldings(
+	ctx context.Context,
+	container appext.Container,
+	flags *flags,
+	asOf xtime.Date,
+	download bool,
+	quote bool,
+) (*ibctlholdings.HoldingsResult, []*accountHoldings, error) {
+	if len(flags.Dirs) == 1 {
+		return getDirHoldings(ctx, container, flags, flags.Dirs[0], asOf, download, quote)
+	}
+	results := make([]*ibctlholdings.HoldingsResult, 0, len(flags.Dirs))
+	var accounts []*accountHoldings
+	for _, dirPath := range flags.Dirs {
+		result, dirAccounts, err := getDirHoldings(ctx, container, flags, dirPath, asOf, download, quote)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", dirPath, err)
+		}
+		results = append(results, result)
+		for _, account := range dirAccounts {
+			account.alias = filepath.Base(filepath.Clean(dirPath)) + "/" + account.alias
+			accounts = append(accounts, account)
+		}
+	}
+	return ibctlholdings.CombineHoldingsResults(results), accounts, nil
+}
+
+// getDirHoldings reads the config of the directory, optionally downloads
+// fresh data, and computes the holdings, logging any data inconsistencies. If
+// asOf is non-zero, the holdings are computed as of that date. If quote is
+// set, priced positions are revalued at their latest quotes. If --by-account
+// is set, the holdings of each account with any are also returned, sorted by
+// alias.
+func getDirHoldings(
+	ctx context.Context,
+	container appext.Container,
+	flags *flags,
+	dirPath string,
+	asOf xtime.Date,
+	download bool,
+	quote bool,
+) (*ibctlholdings.HoldingsResult, []*accountHoldings, error) {
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, dirPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Download fresh data if --download is set.
+	if download {
+		downloader, err := ibctlcmd.NewDownloader(container, dirPath, "")
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := downloader.Download(ctx); err != nil {
+			return nil, nil, err
+		}
 	}
 	// Merge seed lots + Activity Statement CSVs + Flex Query cached data across all accounts.
 	// Trades come from data/ (persistent), snapshots from cache/ (blow-away safe).
@@ -91,18 +406,59 @@ func run(ctx context.Context, container appext.Container, flags *flags) error {
 		ibctlpath.DataAccountsDirPath(config.DirPath),
 		ibctlpath.CacheAccountsDirPath(config.DirPath),
 		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
 		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
 		config.AccountAliases,
+		ibctlmerge.MergeWithTrades(),
+		ibctlmerge.MergeWithPositions(),
+		ibctlmerge.MergeWithCashPositions(),
+		ibctlmerge.MergeWithCashTransactions(),
 	)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	// Load FX rates for USD price conversion. Returns an empty store if no data available.
-	fxStore := ibctlfxrates.NewStore(ibctlpath.CacheFXDirPath(config.DirPath))
-	// Compute holdings via FIFO from all trade data, verified against IBKR positions.
-	result, err := ibctlholdings.GetHoldingsOverview(mergedData.Trades, mergedData.Positions, mergedData.CashPositions, config, fxStore)
+	// Restrict to the accounts in --group if set.
+	config, mergedData, err = ibctlcmd.ApplyGroup(config, mergedData, flags.Group)
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+	// Warn about, or with --max-age fail on, a stale position snapshot. Past
+	// dates do not use the snapshot.
+	if asOf.IsZero() {
+		if err := ibctlcmd.CheckSnapshotAge(container, config, flags.Group, flags.MaxAge); err != nil {
+			return nil, nil, err
+		}
+	}
+	// Revalue positions at their latest quotes. Positions that cannot be
+	// quoted keep the prices of the last download, with a warning.
+	if quote {
+		httpClient, err := ibctlcmd.NewHTTPClient(container, config)
+		if err != nil {
+			return nil, nil, err
+		}
+		positions, err := ibctlprices.ApplyQuotes(ctx, yahoofinance.NewClient(httpClient), config, mergedData.Positions)
+		if positions == nil {
+			return nil, nil, err
+		}
+		if err != nil {
+			container.Logger().Warn("failed to fetch quotes", "error", err)
+		}
+		mergedData.Positions = positions
+	}
+	// Past dates are valued at closing prices, fetching those not cached.
+	var priceStore *pricestore.Store
+	if !asOf.IsZero() {
+		priceStore, err = ibctlcmd.NewPriceStore(container, config, mergedData.Trades)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	result, err := computeHoldings(ctx, config, mergedData, asOf, priceStore, flags.FXAudit)
+	if err != nil {
+		return nil, nil, err
 	}
 	// Log any data inconsistencies detected during computation.
 	logger := container.Logger()
@@ -116,51 +472,168 @@ func run(ctx context.Context, container appext.Container, flags *flags) error {
 	for _, d := range result.PositionDiscrepancies {
 		logPositionDiscrepancy(container, d)
 	}
-	// Write output in the requested format.
-	writer := os.Stdout
-	switch format {
-	case cliio.FormatTable:
-		headers := ibctlholdings.HoldingsOverviewHeaders()
-		// Split holdings into securities and cash for separate display sections.
-		var securityRows, cashRows [][]string
-		for _, h := range result.Holdings {
-			row := ibctlholdings.HoldingOverviewToTableRow(h)
-			if h.Category == "CASH" {
-				cashRows = append(cashRows, row)
-			} else {
-				securityRows = append(securityRows, row)
-			}
+	if !flags.ByAccount {
+		return result, nil, nil
+	}
+	accountAliases := slices.Sorted(maps.Keys(config.AccountAliases))
+	if flags.Group != "" {
+		accountAliases = config.Groups[flags.Group]
+	}
+	var accounts []*accountHoldings
+	for _, accountAlias := range accountAliases {
+		// Cash adjustments are not for any one account, so they are only in the total.
+		accountConfig := *config
+		accountConfig.CashAdjustments = nil
+		accountResult, err := computeHoldings(ctx, &accountConfig, ibctlmerge.FilterAccounts(mergedData, []string{accountAlias}), asOf, priceStore, false)
+		if err != nil {
+			return nil, nil, err
 		}
-		// Build the row sections: securities, then cash, then totals.
-		var sections [][]string
-		sections = append(sections, securityRows...)
-		if len(cashRows) > 0 {
-			// Blank separator row between securities and cash.
-			sections = append(sections, make([]string, len(headers)))
-			sections = append(sections, cashRows...)
-		}
-		// Build the totals row aligned to the same columns as the data.
-		totals := ibctlholdings.ComputeTotals(result.Holdings)
-		totalsRow := make([]string, len(headers))
-		totalsRow[0] = "TOTAL"
-		totalsRow[6] = totals.MarketValueUSD
-		totalsRow[7] = totals.UnrealizedPnLUSD
-		totalsRow[8] = totals.STCGUSD
-		totalsRow[9] = totals.LTCGUSD
-		return cliio.WriteTableWithTotals(writer, headers, sections, totalsRow)
-	case cliio.FormatCSV:
-		headers := ibctlholdings.HoldingsOverviewHeaders()
-		records := make([][]string, 0, len(result.Holdings)+1)
-		records = append(records, headers)
-		for _, h := range result.Holdings {
-			records = append(records, ibctlholdings.HoldingOverviewToRow(h))
+		if len(accountResult.Holdings) == 0 {
+			continue
 		}
-		return cliio.WriteCSVRecords(writer, records)
-	case cliio.FormatJSON:
-		return cliio.WriteJSON(writer, result.Holdings...)
-	default:
-		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+		accounts = append(accounts, &accountHoldings{
+			alias:    accountAlias,
+			holdings: accountResult.Holdings,
+		})
+	}
+	return result, accounts, nil
+}
+
+// computeHoldings computes the holdings from the merged data, as of asOf if
+// non-zero, valued at the closing prices in priceStore. If fxAudit is set,
+// each holding is annotated with its FX rate.
+func computeHoldings(
+	ctx context.Context,
+	config *ibctlconfig.Config,
+	mergedData *ibctlmerge.MergedData,
+	asOf xtime.Date,
+	priceStore *pricestore.Store,
+	fxAudit bool,
+) (*ibctlholdings.HoldingsResult, error) {
+	var result *ibctlholdings.HoldingsResult
+	var err error
+	var fxStore *ibctlfxrates.Store
+	if asOf.IsZero() {
+		// Load FX rates for USD price conversion. Returns an empty store if no data available.
+		fxStore = ibctlfxrates.NewStore(ibctlpath.CacheFXDirPath(config.DirPath))
+		// Compute holdings via FIFO from all trade data, verified against IBKR positions.
+		result, err = ibctlholdings.GetHoldingsOverview(mergedData.Trades, mergedData.Positions, mergedData.CashPositions, config, fxStore)
+	} else {
+		// Use the FX rates and closing prices in effect on the date.
+		fxStore = ibctlfxrates.NewStoreAsOf(ibctlpath.CacheFXDirPath(config.DirPath), asOf)
+		result, err = ibctlholdings.GetHoldingsOverviewAsOf(ctx, asOf, mergedData.Trades, config, fxStore, priceStore)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Add yields and projected income from the trailing twelve months of dividends.
+	symbolToDividendsPerShare, err := ibctlincome.GetTrailingDividendsPerShare(mergedData.CashTransactions, ibctlcmd.AsOfDate(asOf))
+	if err != nil {
+		return nil, err
+	}
+	ibctlholdings.AddIncomeYields(result.Holdings, symbolToDividendsPerShare, fxStore)
+	if fxAudit {
+		ibctlholdings.AddFXRates(result.Holdings, fxStore)
+	}
+	return result, nil
+}
+
+// holdingsTable returns the headers, rows, and totals row of the holdings
+// table, along with the holding of each row. Rows are the securities, then a
+// blank separator row with a nil holding and the cash holdings if there are any.
+func holdingsTable(result *ibctlholdings.HoldingsResult) ([]string, [][]string, []*ibctlholdings.HoldingOverview, []string) {
+	headers := ibctlholdings.HoldingsOverviewHeaders()
+	// Split holdings into securities and cash for separate display sections.
+	var securityHoldings, cashHoldings []*ibctlholdings.HoldingOverview
+	for _, h := range result.Holdings {
+		if h.Category == "CASH" {
+			cashHoldings = append(cashHoldings, h)
+		} else {
+			securityHoldings = append(securityHoldings, h)
+		}
+	}
+	// Build the row sections: securities, then cash, then totals.
+	rowHoldings := securityHoldings
+	if len(cashHoldings) > 0 {
+		// Blank separator row between securities and cash.
+		rowHoldings = append(rowHoldings, nil)
+		rowHoldings = append(rowHoldings, cashHoldings...)
+	}
+	rows := make([][]string, 0, len(rowHoldings))
+	for _, h := range rowHoldings {
+		if h == nil {
+			rows = append(rows, make([]string, len(headers)))
+			continue
+		}
+		rows = append(rows, ibctlholdings.HoldingOverviewToTableRow(h))
+	}
+	// Build the totals row aligned to the same columns as the data.
+	totalsRow := ibctlholdings.TotalsToTableRow(ibctlholdings.ComputeTotals(result.Holdings))
+	return headers, rows, rowHoldings, totalsRow
+}
+
+// accountHoldingsTable returns the rows of the holdings table for
+// --by-account, along with the holding of each row and the indexes of the
+// subtotal rows. Each account's holdings are followed by its subtotal row, and
+// accounts are separated by a blank row with a nil holding. Subtotal rows also
+// have a nil holding.
+func accountHoldingsTable(accounts []*accountHoldings) ([][]string, []*ibctlholdings.HoldingOverview, map[int]struct{}) {
+	headers := ibctlholdings.HoldingsOverviewHeaders()
+	var rows [][]string
+	var rowHoldings []*ibctlholdings.HoldingOverview
+	subtotalRowIndexes := make(map[int]struct{})
+	for i, account := range accounts {
+		// Separate accounts with a blank row; the totals row adds its own.
+		if i > 0 {
+			rows = append(rows, make([]string, len(headers)))
+			rowHoldings = append(rowHoldings, nil)
+		}
+		for _, h := range account.holdings {
+			rows = append(rows, ibctlholdings.HoldingOverviewToTableRow(h))
+			rowHoldings = append(rowHoldings, h)
+		}
+		subtotalRowIndexes[len(rows)] = struct{}{}
+		rows = append(rows, ibctlholdings.AccountTotalsToTableRow(account.alias, ibctlholdings.ComputeTotals(account.holdings)))
+		rowHoldings = append(rowHoldings, nil)
+	}
+	return rows, rowHoldings, subtotalRowIndexes
+}
+
+// holdingWarningSymbols returns the symbols to flag in colored output: those
+// with a position discrepancy and those without a market value.
+func holdingWarningSymbols(result *ibctlholdings.HoldingsResult) map[string]struct{} {
+	warningSymbols := make(map[string]struct{})
+	for _, d := range result.PositionDiscrepancies {
+		warningSymbols[d.Symbol] = struct{}{}
+	}
+	for _, h := range result.Holdings {
+		if h.MarketValueUSD == "" {
+			warningSymbols[h.Symbol] = struct{}{}
+		}
+	}
+	return warningSymbols
+}
+
+// holdingCellColor returns the color of a cell of the holdings table: the
+// symbol of a warning symbol in yellow, and P&L, STCG, and LTCG green for
+// gains and red for losses. The holding is nil for the separator row.
+func holdingCellColor(h *ibctlholdings.HoldingOverview, columnIndex int, warningSymbols map[string]struct{}) cliio.Color {
+	if h == nil {
+		return cliio.ColorNone
+	}
+	switch columnIndex {
+	case 0:
+		if _, ok := warningSymbols[h.Symbol]; ok {
+			return cliio.ColorYellow
+		}
+	case 7:
+		return cliio.SignColor(h.UnrealizedPnLUSD)
+	case 8:
+		return cliio.SignColor(h.STCGUSD)
+	case 9:
+		return cliio.SignColor(h.LTCGUSD)
 	}
+	return cliio.ColorNone
 }
 
 // logPositionDiscrepancy logs a structured position discrepancy as a warning.
@@ -0,0 +1,163 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package holdingstats implements the "holding stats" command.
+package holdingstats
+
+import (
+	"context"
+	"os"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctltrade"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// formatFlagName is the flag name for the output format.
+	formatFlagName = "format"
+	// downloadFlagName is the flag name for downloading fresh data before displaying.
+	downloadFlagName = "download"
+	// symbolFlagName is the flag name for restricting the stats to a symbol.
+	symbolFlagName = "symbol"
+	// yearFlagName is the flag name for restricting the stats to lots closed in a year.
+	yearFlagName = "year"
+)
+
+// NewCommand returns a new holding stats command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Display realized trading statistics per symbol",
+		Long: `Display realized trading statistics per symbol.
+
+Statistics are computed from the lots closed by FIFO matching of all trades:
+the number of closed lots, the win rate (lots closed at a gain), the average
+holding period in days, the average return on cost basis, the best and worst
+closed lots, and the total realized P&L. A lot closed by several trades counts
+once per closing trade, and short lots count when they are bought back.
+
+Gains exclude commissions and are converted to USD at the latest rate. Use
+--year to only include lots closed in a year, and --symbol to only include a
+symbol.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Format is the output format (table, csv, json).
+	Format string
+	// Download fetches fresh data before displaying.
+	Download bool
+	// Group restricts trades to the accounts in a configured account group.
+	Group string
+	// Symbol restricts the stats to a symbol.
+	Symbol string
+	// Year restricts the stats to lots closed in a year, or 0 for all years.
+	Year int
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+	flagSet.BoolVar(&f.Download, downloadFlagName, false, "Download fresh data before displaying")
+	flagSet.StringVar(&f.Group, ibctlcmd.GroupFlagName, "", "Only include accounts in this account group from ibctl.yaml")
+	flagSet.StringVar(&f.Symbol, symbolFlagName, "", "Only include this symbol")
+	flagSet.IntVar(&f.Year, yearFlagName, 0, "Only include lots closed in this year")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) error {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	if flags.Year < 0 {
+		return appcmd.NewInvalidArgumentErrorf("--%s must be positive", yearFlagName)
+	}
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	// Download fresh data if --download is set.
+	if flags.Download {
+		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir, "")
+		if err != nil {
+			return err
+		}
+		if err := downloader.Download(ctx); err != nil {
+			return err
+		}
+	}
+	// Merge trade data from all sources.
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+		ibctlmerge.MergeWithTrades(),
+	)
+	if err != nil {
+		return err
+	}
+	// Restrict to the accounts in --group if set.
+	config, mergedData, err = ibctlcmd.ApplyGroup(config, mergedData, flags.Group)
+	if err != nil {
+		return err
+	}
+	// Load FX rates for USD conversion.
+	fxStore := ibctlfxrates.NewStore(ibctlpath.CacheFXDirPath(config.DirPath))
+	stats, err := ibctltrade.GetSymbolStats(mergedData.Trades, flags.Year, flags.Symbol, config.AverageCostSymbols(), config.CacheTaxLotsDirPath(), fxStore)
+	if err != nil {
+		return err
+	}
+	// The last entry is the totals across all symbols.
+	symbolStats, totals := stats[:len(stats)-1], stats[len(stats)-1]
+	// Write output in the requested format.
+	writer := os.Stdout
+	switch format {
+	case cliio.FormatTable:
+		rows := make([][]string, 0, len(symbolStats))
+		for _, s := range symbolStats {
+			rows = append(rows, ibctltrade.SymbolStatsToTableRow(s))
+		}
+		return cliio.WriteTableWithTotals(writer, ibctltrade.SymbolStatsHeaders(), rows, ibctltrade.SymbolStatsToTableRow(totals))
+	case cliio.FormatCSV:
+		records := make([][]string, 0, len(stats)+1)
+		records = append(records, ibctltrade.SymbolStatsHeaders())
+		for _, s := range stats {
+			records = append(records, ibctltrade.SymbolStatsToRow(s))
+		}
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, symbolStats...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
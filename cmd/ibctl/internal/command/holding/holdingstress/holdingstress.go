@@ -0,0 +1,192 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package holdingstress implements the "holding stress" command.
+package holdingstress
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlholdings"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlstress"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// formatFlagName is the flag name for the output format.
+	formatFlagName = "format"
+	// downloadFlagName is the flag name for downloading fresh data before displaying.
+	downloadFlagName = "download"
+	// shockFlagName is the flag name for the shocks to apply.
+	shockFlagName = "shock"
+	// byFlagName is the flag name for the classification to report the allocation by.
+	byFlagName = "by"
+)
+
+// NewCommand returns a new holding stress command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Apply scenario shocks to holdings and report the impact",
+		Long: `Apply scenario shocks to holdings and report the impact.
+
+--shock is a comma-separated list of KEY:PERCENT shocks, such as
+'EQUITY:-20%,BOND:-5%,USD.CAD:+10%'. A key is a category, sector, or trading
+currency, which shocks the price of every matching holding, or a currency
+pair against USD, which shocks the USD value of holdings and cash in the
+currency. "USD.CAD:+10%" means USD rises 10% against CAD, so CAD holdings
+lose 1/1.1 of their USD value. A holding matched by several keys is shocked by
+each, compounded. Sector and currency pair shocks follow the lookthrough and
+currencies sections of ibctl.yaml, so a TECH shock hits only the TECH part of
+an ETF.
+
+Reports the portfolio value, P&L change, and unrealized P&L before and after
+the shocks, and the allocation shift by category (or --by type, sector, or
+geo). Keys that match no holding are reported as warnings.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Format is the output format (table, csv, json).
+	Format string
+	// Download fetches fresh data before displaying.
+	Download bool
+	// Group restricts holdings to the accounts in a configured account group.
+	Group string
+	// Shock is the comma-separated list of KEY:PERCENT shocks.
+	Shock string
+	// By is the classification to report the allocation by (category, type, sector, geo).
+	By string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+	flagSet.BoolVar(&f.Download, downloadFlagName, false, "Download fresh data before displaying")
+	flagSet.StringVar(&f.Group, ibctlcmd.GroupFlagName, "", "Only include accounts in this account group from ibctl.yaml")
+	flagSet.StringVar(&f.Shock, shockFlagName, "", "Comma-separated KEY:PERCENT shocks (e.g., 'EQUITY:-20%,USD.CAD:+10%')")
+	flagSet.StringVar(&f.By, byFlagName, string(ibctlholdings.ClassificationCategory), "Classification to report the allocation by (category, type, sector, geo)")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) error {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	classification, err := ibctlholdings.ParseClassification(flags.By)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	if flags.Shock == "" {
+		return appcmd.NewInvalidArgumentErrorf("--%s is required", shockFlagName)
+	}
+	shocks, err := ibctlstress.ParseShocks(flags.Shock)
+	if err != nil {
+		return appcmd.NewInvalidArgumentErrorf("invalid --%s: %v", shockFlagName, err)
+	}
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	// Download fresh data if --download is set.
+	if flags.Download {
+		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir, "")
+		if err != nil {
+			return err
+		}
+		if err := downloader.Download(ctx); err != nil {
+			return err
+		}
+	}
+	// Merge trade data from all sources.
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+	)
+	if err != nil {
+		return err
+	}
+	// Restrict to the accounts in --group if set.
+	config, mergedData, err = ibctlcmd.ApplyGroup(config, mergedData, flags.Group)
+	if err != nil {
+		return err
+	}
+	// Load FX rates for USD conversion.
+	fxStore := ibctlfxrates.NewStore(ibctlpath.CacheFXDirPath(config.DirPath))
+	// Compute holdings via FIFO from all trade data.
+	holdingsResult, err := ibctlholdings.GetHoldingsOverview(mergedData.Trades, mergedData.Positions, mergedData.CashPositions, config, fxStore)
+	if err != nil {
+		return err
+	}
+	result, err := ibctlstress.Apply(holdingsResult.Holdings, shocks, classification, config)
+	if err != nil {
+		return err
+	}
+	for _, key := range result.UnmatchedKeys {
+		container.Logger().Warn("shock matched no holding", "key", key)
+	}
+	// Write output in the requested format.
+	writer := os.Stdout
+	headers := ibctlstress.AllocationShiftHeaders(classification)
+	switch format {
+	case cliio.FormatTable:
+		rows := make([][]string, 0, len(result.Allocations))
+		for _, a := range result.Allocations {
+			rows = append(rows, ibctlstress.AllocationShiftToTableRow(a))
+		}
+		totalsRow := []string{"TOTAL", cliio.FormatUSD(result.ValueUSD), cliio.FormatUSD(result.StressedValueUSD), cliio.FormatUSD(result.ChangeUSD), "", "", ""}
+		if err := cliio.WriteTableWithTotals(writer, headers, rows, totalsRow); err != nil {
+			return err
+		}
+		fmt.Fprintf(writer, "\n")
+		fmt.Fprintf(writer, "Portfolio Value:  %s -> %s\n", cliio.FormatUSD(result.ValueUSD), cliio.FormatUSD(result.StressedValueUSD))
+		fmt.Fprintf(writer, "P&L Change:       %s (%s)\n", cliio.FormatUSD(result.ChangeUSD), result.ChangePct)
+		fmt.Fprintf(writer, "Unrealized P&L:   %s -> %s\n", cliio.FormatUSD(result.UnrealizedPnLUSD), cliio.FormatUSD(result.StressedUnrealizedPnLUSD))
+		return nil
+	case cliio.FormatCSV:
+		records := make([][]string, 0, len(result.Allocations)+2)
+		records = append(records, headers)
+		for _, a := range result.Allocations {
+			records = append(records, ibctlstress.AllocationShiftToRow(a))
+		}
+		records = append(records, []string{"TOTAL", result.ValueUSD, result.StressedValueUSD, result.ChangeUSD, "", "", ""})
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, result)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
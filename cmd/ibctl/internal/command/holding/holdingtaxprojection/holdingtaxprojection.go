@@ -0,0 +1,162 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package holdingtaxprojection implements the "holding tax-projection" command.
+package holdingtaxprojection
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctltax"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// formatFlagName is the flag name for the output format.
+	formatFlagName = "format"
+	// downloadFlagName is the flag name for downloading fresh data before displaying.
+	downloadFlagName = "download"
+	// yearFlagName is the flag name for the tax year.
+	yearFlagName = "year"
+)
+
+// NewCommand returns a new holding tax-projection command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Project the tax liability for a year per account type",
+		Long: `Project the tax liability for a year per account type.
+
+Combines realized short-term and long-term capital gains (FIFO), dividends,
+and interest for the year with the rates in the taxes section of ibctl.yaml,
+less withholding tax credits. Only taxable accounts have projected tax;
+deferred and exempt accounts (see account_types) are shown for reference.
+
+Amounts are converted to USD at the FX rate on their date: the cost of a
+realized gain at the rate on the open date, its proceeds at the rate on the
+close date, and income at the rate on the payment date. The projected tax is
+also shown in taxes.base_currency, at the most recent FX rate.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Format is the output format (table, csv, json).
+	Format string
+	// Download fetches fresh data before displaying.
+	Download bool
+	// Group restricts the projection to the accounts in a configured account group.
+	Group string
+	// Year is the tax year.
+	Year int
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+	flagSet.BoolVar(&f.Download, downloadFlagName, false, "Download fresh data before displaying")
+	flagSet.StringVar(&f.Group, ibctlcmd.GroupFlagName, "", "Only include accounts in this account group from ibctl.yaml")
+	flagSet.IntVar(&f.Year, yearFlagName, time.Now().Year(), "The tax year")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) error {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	// Download fresh data if --download is set.
+	if flags.Download {
+		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir, "")
+		if err != nil {
+			return err
+		}
+		if err := downloader.Download(ctx); err != nil {
+			return err
+		}
+	}
+	// Merge trade data from all sources.
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+	)
+	if err != nil {
+		return err
+	}
+	// Restrict to the accounts in --group if set.
+	config, mergedData, err = ibctlcmd.ApplyGroup(config, mergedData, flags.Group)
+	if err != nil {
+		return err
+	}
+	// Load FX rates for USD and base currency conversion.
+	fxStore := ibctlfxrates.NewStore(ibctlpath.CacheFXDirPath(config.DirPath))
+	projection, err := ibctltax.GetProjection(flags.Year, mergedData.Trades, mergedData.CashTransactions, config, fxStore)
+	if err != nil {
+		return err
+	}
+	if len(projection.UnconvertedCurrencies) > 0 {
+		container.Logger().Warn(
+			"activity excluded from tax projection, no USD exchange rate available",
+			"currencies", strings.Join(projection.UnconvertedCurrencies, ","),
+		)
+	}
+	// Write output in the requested format.
+	writer := os.Stdout
+	headers := ibctltax.ProjectionHeaders(projection.BaseCurrency)
+	switch format {
+	case cliio.FormatTable:
+		rows := make([][]string, 0, len(projection.AccountTypes))
+		for _, p := range projection.AccountTypes {
+			rows = append(rows, ibctltax.AccountTypeProjectionToTableRow(p))
+		}
+		return cliio.WriteTableWithTotals(writer, headers, rows, ibctltax.AccountTypeProjectionToTableRow(projection.Total))
+	case cliio.FormatCSV:
+		records := make([][]string, 0, len(projection.AccountTypes)+2)
+		records = append(records, headers)
+		for _, p := range projection.AccountTypes {
+			records = append(records, ibctltax.AccountTypeProjectionToRow(p))
+		}
+		records = append(records, ibctltax.AccountTypeProjectionToRow(projection.Total))
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, projection)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
@@ -8,24 +8,60 @@ package holdingvalue
 import (
 	"context"
 	"fmt"
+	"io"
+	"maps"
 	"math"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"buf.build/go/app"
 	"buf.build/go/app/appcmd"
 	"buf.build/go/app/appext"
 	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlalert"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctlholdings"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlincome"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
 	"github.com/bufdev/ibctl/internal/pkg/cliio"
 	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
 	"github.com/spf13/pflag"
 )
 
-// downloadFlagName is the flag name for downloading fresh data before displaying.
-const downloadFlagName = "download"
+const (
+	// byFlagName is the flag name for breaking the value down by account or group.
+	byFlagName = "by"
+	// downloadFlagName is the flag name for downloading fresh data before displaying.
+	downloadFlagName = "download"
+	// formatFlagName is the flag name for the output format.
+	formatFlagName = "format"
+)
+
+const (
+	// breakdownByAccount breaks the value down by account.
+	breakdownByAccount = "account"
+	// breakdownByGroup breaks the value down by configured account group.
+	breakdownByGroup = "group"
+)
+
+// alertsExitCode is the exit code when any alert rule is triggered, distinct
+// from the exit code 1 used for errors.
+const alertsExitCode = 2
+
+// accountTypeLabels are the display labels for account types.
+var accountTypeLabels = map[string]string{
+	ibctlconfig.AccountTypeTaxable:  "Taxable",
+	ibctlconfig.AccountTypeDeferred: "Tax-Deferred",
+	ibctlconfig.AccountTypeExempt:   "Tax-Exempt",
+}
 
 // NewCommand returns a new holding value command.
 func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
@@ -33,7 +69,38 @@ func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
 	return &appcmd.Command{
 		Use:   name,
 		Short: "Display portfolio value with estimated tax impact",
-		Args:  appcmd.NoArgs,
+		Long: `Display portfolio value with estimated tax impact.
+
+Capital gains tax is only estimated for taxable accounts. If account_types in
+ibctl.yaml marks any accounts as deferred or exempt, the portfolio value is
+broken down by account type and those accounts are excluded from STCG and LTCG.
+
+Dividends that have gone ex-dividend but are not yet paid are receivable, so
+they are included in the portfolio value and shown on an Accrued Dividends
+line. They come from the Change in Dividend Accruals section of the Flex Query.
+
+Projected Income is the annual dividend income if the dividends per share paid
+in the trailing twelve months continue, as in the PROJ INCOME USD column of
+"ibctl holding list", and Income Yield is the projected income divided by the
+market value of the holdings.
+
+Alert rules from the alerts section of ibctl.yaml are checked against the
+holdings. Each triggered rule prints a WARN line, and the command exits with
+code 2 so it can be used for scripted monitoring.
+
+--by account adds a table of the value, STCG, LTCG, estimated tax, and
+after-tax value of each account, and --by group does the same for each group
+in the groups section of ibctl.yaml (or only the --group group if set). Gains
+are only counted for taxable accounts, as for the total. Manual cash
+adjustments are not attributed to any account, so the rows may not add up to
+the total. Groups can share accounts, so their rows may overlap.
+
+--format csv writes one METRIC,VALUE row per line of the summary, or the
+--by table if set, and --format json writes the summary as a single object,
+with the --by rows in its breakdown field, USD values as raw decimals, and tax
+rates as fractions. With csv or json, WARN lines are written
+to stderr so the output stays machine-readable.`,
+		Args: appcmd.NoArgs,
 		Run: builder.NewRunFunc(
 			func(ctx context.Context, container appext.Container) error {
 				return run(ctx, container, flags)
@@ -46,8 +113,16 @@ func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
 type flags struct {
 	// Dir is the base directory containing ibctl.yaml and data subdirectories.
 	Dir string
+	// Format is the output format (table, csv, json).
+	Format string
+	// By breaks the value down by account or group, or is empty for no breakdown.
+	By string
 	// Download fetches fresh data before displaying.
 	Download bool
+	// Group restricts holdings to the accounts in a configured account group.
+	Group string
+	// MaxAge fails if the position snapshot is older than this, or is 0 for no limit.
+	MaxAge time.Duration
 }
 
 func newFlags() *flags {
@@ -56,19 +131,30 @@ func newFlags() *flags {
 
 // Bind registers the flag definitions with the given flag set.
 func (f *flags) Bind(flagSet *pflag.FlagSet) {
-	flagSet.StringVar(&f.Dir, ibctlcmd.DirFlagName, ".", "The ibctl directory containing ibctl.yaml")
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+	flagSet.StringVar(&f.By, byFlagName, "", "Also break the value down by account or group")
 	flagSet.BoolVar(&f.Download, downloadFlagName, false, "Download fresh data before displaying")
+	flagSet.StringVar(&f.Group, ibctlcmd.GroupFlagName, "", "Only include accounts in this account group from ibctl.yaml")
+	flagSet.DurationVar(&f.MaxAge, ibctlcmd.MaxAgeFlagName, 0, "Fail if the position snapshot was downloaded longer ago than this (e.g., 24h)")
 }
 
 func run(ctx context.Context, container appext.Container, flags *flags) error {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	if flags.By != "" && flags.By != breakdownByAccount && flags.By != breakdownByGroup {
+		return appcmd.NewInvalidArgumentErrorf("--%s must be %s or %s", byFlagName, breakdownByAccount, breakdownByGroup)
+	}
 	// Read and validate the configuration file from the base directory.
-	config, err := ibctlconfig.ReadConfig(flags.Dir)
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
 	if err != nil {
 		return err
 	}
 	// Download fresh data if --download is set.
 	if flags.Download {
-		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir)
+		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir, "")
 		if err != nil {
 			return err
 		}
@@ -81,12 +167,25 @@ func run(ctx context.Context, container appext.Container, flags *flags) error {
 		ibctlpath.DataAccountsDirPath(config.DirPath),
 		ibctlpath.CacheAccountsDirPath(config.DirPath),
 		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
 		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
 		config.AccountAliases,
 	)
 	if err != nil {
 		return err
 	}
+	// Restrict to the accounts in --group if set.
+	config, mergedData, err = ibctlcmd.ApplyGroup(config, mergedData, flags.Group)
+	if err != nil {
+		return err
+	}
+	// Warn about, or with --max-age fail on, a stale position snapshot.
+	if err := ibctlcmd.CheckSnapshotAge(container, config, flags.Group, flags.MaxAge); err != nil {
+		return err
+	}
 	// Load FX rates for USD conversion.
 	fxStore := ibctlfxrates.NewStore(ibctlpath.CacheFXDirPath(config.DirPath))
 	// Compute holdings via FIFO from all trade data.
@@ -94,10 +193,32 @@ func run(ctx context.Context, container appext.Container, flags *flags) error {
 	if err != nil {
 		return err
 	}
-	// Sum up portfolio value, STCG, and LTCG from all holdings.
-	var totalValueMicros, totalSTCGMicros, totalLTCGMicros int64
+	// Add projected income from the trailing twelve months of dividends.
+	symbolToDividendsPerShare, err := ibctlincome.GetTrailingDividendsPerShare(mergedData.CashTransactions, xtime.TimeToDate(time.Now()))
+	if err != nil {
+		return err
+	}
+	ibctlholdings.AddIncomeYields(result.Holdings, symbolToDividendsPerShare, fxStore)
+	totals := ibctlholdings.ComputeTotals(result.Holdings)
+	// Read the dividends declared but not yet paid, which are part of the portfolio value.
+	dividendAccruals, err := ibctlcmd.ReadDividendAccruals(config, flags.Group)
+	if err != nil {
+		return err
+	}
+	// Compute the value of each account type and the holdings of taxable accounts.
+	accountTypeValues, taxableHoldings, err := getAccountTypeValues(config, mergedData, fxStore, result.Holdings, dividendAccruals)
+	if err != nil {
+		return err
+	}
+	// Sum up portfolio value from all holdings and accrued dividends, and STCG
+	// and LTCG from taxable holdings.
+	accruedDividendsMicros := accruedDividendsUSDMicros(dividendAccruals, fxStore)
+	totalValueMicros := accruedDividendsMicros
+	var totalSTCGMicros, totalLTCGMicros int64
 	for _, h := range result.Holdings {
 		totalValueMicros += mathpb.ParseMicros(h.MarketValueUSD)
+	}
+	for _, h := range taxableHoldings {
 		totalSTCGMicros += mathpb.ParseMicros(h.STCGUSD)
 		totalLTCGMicros += mathpb.ParseMicros(h.LTCGUSD)
 	}
@@ -106,17 +227,403 @@ func run(ctx context.Context, container appext.Container, flags *flags) error {
 	ltcgTaxMicros := int64(math.Round(float64(totalLTCGMicros) * config.TaxRateLTCG))
 	totalTaxMicros := stcgTaxMicros + ltcgTaxMicros
 	// After-tax value = portfolio value - total taxes.
-	afterTaxMicros := totalValueMicros - totalTaxMicros
-	// Print the summary.
+	summary := &valueSummary{
+		ValueUSD:            usdString(totalValueMicros),
+		AccruedDividendsUSD: usdString(accruedDividendsMicros),
+		ProjectedIncomeUSD:  totals.ProjectedIncomeUSD,
+		IncomeYield:         incomeYield(totals),
+		STCGUSD:             usdString(totalSTCGMicros),
+		STCGTaxRate:         config.TaxRateSTCG,
+		STCGTaxUSD:          usdString(stcgTaxMicros),
+		LTCGUSD:             usdString(totalLTCGMicros),
+		LTCGTaxRate:         config.TaxRateLTCG,
+		LTCGTaxUSD:          usdString(ltcgTaxMicros),
+		TotalTaxUSD:         usdString(totalTaxMicros),
+		AfterTaxValueUSD:    usdString(totalValueMicros - totalTaxMicros),
+	}
+	for _, accountTypeValue := range accountTypeValues {
+		summary.AccountTypes = append(summary.AccountTypes, &accountTypeValueSummary{
+			AccountType: accountTypeValue.accountType,
+			ValueUSD:    usdString(accountTypeValue.valueMicros),
+		})
+	}
+	// Break the value down by account or group if --by is set.
+	if flags.By != "" {
+		summary.Breakdown, err = getValueBreakdowns(config, mergedData, fxStore, dividendAccruals, flags.By, flags.Group)
+		if err != nil {
+			return err
+		}
+	}
+	// Write the summary in the requested format. Alerts go to stderr for
+	// machine-readable formats.
 	writer := os.Stdout
-	fmt.Fprintf(writer, "Portfolio Value:  %s\n", cliio.FormatUSDMicros(totalValueMicros))
+	alertWriter := os.Stderr
+	switch format {
+	case cliio.FormatTable:
+		writeValueSummaryText(writer, summary)
+		if flags.By != "" {
+			fmt.Fprintf(writer, "\n")
+			if err := cliio.WriteTable(writer, valueBreakdownHeaders(flags.By), valueBreakdownsToTableRows(summary.Breakdown)); err != nil {
+				return err
+			}
+		}
+		alertWriter = writer
+	case cliio.FormatCSV:
+		records := valueSummaryToRecords(summary)
+		if flags.By != "" {
+			records = append([][]string{valueBreakdownHeaders(flags.By)}, valueBreakdownsToRows(summary.Breakdown)...)
+		}
+		if err := cliio.WriteCSVRecords(writer, records); err != nil {
+			return err
+		}
+	case cliio.FormatJSON:
+		if err := cliio.WriteJSON(writer, summary); err != nil {
+			return err
+		}
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+	// Check alert rules, exiting with a distinct code if any triggered.
+	alerts, err := ibctlalert.Evaluate(result.Holdings, taxableHoldings, config.Alerts, config.Lookthroughs)
+	if err != nil {
+		return err
+	}
+	if len(alerts) == 0 {
+		return nil
+	}
+	if format == cliio.FormatTable {
+		fmt.Fprintf(alertWriter, "\n")
+	}
+	for _, alert := range alerts {
+		fmt.Fprintf(alertWriter, "WARN %s: %s\n", alert.Name, alert.Message)
+	}
+	return app.NewErrorf(alertsExitCode, "%d alert(s) triggered", len(alerts))
+}
+
+// valueSummary is the portfolio value with estimated tax impact. USD values
+// are raw decimal strings, and tax rates are fractions.
+type valueSummary struct {
+	// ValueUSD is the portfolio value, including accrued dividends.
+	ValueUSD string `json:"value_usd"`
+	// AccountTypes is the value of each account type, only set if any account is not taxable.
+	AccountTypes []*accountTypeValueSummary `json:"account_types,omitempty"`
+	// AccruedDividendsUSD is the value of dividends declared but not yet paid.
+	AccruedDividendsUSD string `json:"accrued_dividends_usd"`
+	// ProjectedIncomeUSD is the annual dividend income projected from the
+	// trailing twelve months of dividends per share.
+	ProjectedIncomeUSD string `json:"projected_income_usd"`
+	// IncomeYield is ProjectedIncomeUSD divided by the market value of the holdings.
+	IncomeYield float64 `json:"income_yield"`
+	// STCGUSD is the unrealized short-term gain of taxable accounts.
+	STCGUSD string `json:"stcg_usd"`
+	// STCGTaxRate is the short-term capital gains tax rate.
+	STCGTaxRate float64 `json:"stcg_tax_rate"`
+	// STCGTaxUSD is the estimated tax on STCGUSD.
+	STCGTaxUSD string `json:"stcg_tax_usd"`
+	// LTCGUSD is the unrealized long-term gain of taxable accounts.
+	LTCGUSD string `json:"ltcg_usd"`
+	// LTCGTaxRate is the long-term capital gains tax rate.
+	LTCGTaxRate float64 `json:"ltcg_tax_rate"`
+	// LTCGTaxUSD is the estimated tax on LTCGUSD.
+	LTCGTaxUSD string `json:"ltcg_tax_usd"`
+	// TotalTaxUSD is STCGTaxUSD + LTCGTaxUSD.
+	TotalTaxUSD string `json:"total_tax_usd"`
+	// AfterTaxValueUSD is ValueUSD - TotalTaxUSD.
+	AfterTaxValueUSD string `json:"after_tax_value_usd"`
+	// Breakdown is the value of each account or group, only set with --by.
+	Breakdown []*valueBreakdown `json:"breakdown,omitempty"`
+}
+
+// valueBreakdown is the value with estimated tax impact of one account or group.
+type valueBreakdown struct {
+	// Name is the account alias or group name.
+	Name string `json:"name"`
+	// ValueUSD is the value of the accounts, including accrued dividends.
+	ValueUSD string `json:"value_usd"`
+	// STCGUSD is the unrealized short-term gain of the taxable accounts.
+	STCGUSD string `json:"stcg_usd"`
+	// LTCGUSD is the unrealized long-term gain of the taxable accounts.
+	LTCGUSD string `json:"ltcg_usd"`
+	// TotalTaxUSD is the estimated tax on STCGUSD and LTCGUSD.
+	TotalTaxUSD string `json:"total_tax_usd"`
+	// AfterTaxValueUSD is ValueUSD - TotalTaxUSD.
+	AfterTaxValueUSD string `json:"after_tax_value_usd"`
+}
+
+// accountTypeValueSummary is the value of the accounts of one account type.
+type accountTypeValueSummary struct {
+	// AccountType is the account type (taxable, deferred, or exempt).
+	AccountType string `json:"account_type"`
+	// ValueUSD is the value of the accounts, including accrued dividends.
+	ValueUSD string `json:"value_usd"`
+}
+
+// writeValueSummaryText writes the summary as formatted text.
+func writeValueSummaryText(writer io.Writer, summary *valueSummary) {
+	fmt.Fprintf(writer, "Portfolio Value:  %s\n", cliio.FormatUSD(summary.ValueUSD))
+	for _, accountType := range summary.AccountTypes {
+		fmt.Fprintf(writer, "  %-15s %s\n", accountTypeLabels[accountType.AccountType]+":", cliio.FormatUSD(accountType.ValueUSD))
+	}
+	if mathpb.ParseMicros(summary.AccruedDividendsUSD) != 0 {
+		fmt.Fprintf(writer, "  Accrued Dividends: %s\n", cliio.FormatUSD(summary.AccruedDividendsUSD))
+	}
+	if mathpb.ParseMicros(summary.ProjectedIncomeUSD) != 0 {
+		fmt.Fprintf(writer, "\n")
+		fmt.Fprintf(writer, "Projected Income: %s (%.2f%% yield)\n", cliio.FormatUSD(summary.ProjectedIncomeUSD), summary.IncomeYield*100)
+	}
 	fmt.Fprintf(writer, "\n")
-	fmt.Fprintf(writer, "STCG:            %s\n", cliio.FormatUSDMicros(totalSTCGMicros))
-	fmt.Fprintf(writer, "STCG Tax (%.1f%%):  %s\n", config.TaxRateSTCG*100, cliio.FormatUSDMicros(stcgTaxMicros))
-	fmt.Fprintf(writer, "LTCG:            %s\n", cliio.FormatUSDMicros(totalLTCGMicros))
-	fmt.Fprintf(writer, "LTCG Tax (%.1f%%):  %s\n", config.TaxRateLTCG*100, cliio.FormatUSDMicros(ltcgTaxMicros))
-	fmt.Fprintf(writer, "Total Tax:       %s\n", cliio.FormatUSDMicros(totalTaxMicros))
+	fmt.Fprintf(writer, "STCG:            %s\n", cliio.FormatUSD(summary.STCGUSD))
+	fmt.Fprintf(writer, "STCG Tax (%.1f%%):  %s\n", summary.STCGTaxRate*100, cliio.FormatUSD(summary.STCGTaxUSD))
+	fmt.Fprintf(writer, "LTCG:            %s\n", cliio.FormatUSD(summary.LTCGUSD))
+	fmt.Fprintf(writer, "LTCG Tax (%.1f%%):  %s\n", summary.LTCGTaxRate*100, cliio.FormatUSD(summary.LTCGTaxUSD))
+	fmt.Fprintf(writer, "Total Tax:       %s\n", cliio.FormatUSD(summary.TotalTaxUSD))
 	fmt.Fprintf(writer, "\n")
-	fmt.Fprintf(writer, "After-Tax Value: %s\n", cliio.FormatUSDMicros(afterTaxMicros))
-	return nil
+	fmt.Fprintf(writer, "After-Tax Value: %s\n", cliio.FormatUSD(summary.AfterTaxValueUSD))
+}
+
+// valueSummaryToRecords converts the summary to CSV records with a header,
+// one METRIC,VALUE record per line of the text summary.
+func valueSummaryToRecords(summary *valueSummary) [][]string {
+	records := [][]string{
+		{"METRIC", "VALUE"},
+		{"VALUE_USD", summary.ValueUSD},
+	}
+	for _, accountType := range summary.AccountTypes {
+		records = append(records, []string{"VALUE_USD_" + strings.ToUpper(accountType.AccountType), accountType.ValueUSD})
+	}
+	return append(
+		records,
+		[]string{"ACCRUED_DIVIDENDS_USD", summary.AccruedDividendsUSD},
+		[]string{"PROJECTED_INCOME_USD", summary.ProjectedIncomeUSD},
+		[]string{"INCOME_YIELD", strconv.FormatFloat(summary.IncomeYield, 'f', -1, 64)},
+		[]string{"STCG_USD", summary.STCGUSD},
+		[]string{"STCG_TAX_RATE", strconv.FormatFloat(summary.STCGTaxRate, 'f', -1, 64)},
+		[]string{"STCG_TAX_USD", summary.STCGTaxUSD},
+		[]string{"LTCG_USD", summary.LTCGUSD},
+		[]string{"LTCG_TAX_RATE", strconv.FormatFloat(summary.LTCGTaxRate, 'f', -1, 64)},
+		[]string{"LTCG_TAX_USD", summary.LTCGTaxUSD},
+		[]string{"TOTAL_TAX_USD", summary.TotalTaxUSD},
+		[]string{"AFTER_TAX_VALUE_USD", summary.AfterTaxValueUSD},
+	)
+}
+
+// valueBreakdownHeaders returns the table headers for a breakdown by account or group.
+func valueBreakdownHeaders(by string) []string {
+	return []string{strings.ToUpper(by), "VALUE USD", "STCG USD", "LTCG USD", "TAX USD", "AFTER-TAX USD"}
+}
+
+// valueBreakdownsToRows converts the breakdowns to table rows with raw decimal values.
+func valueBreakdownsToRows(breakdowns []*valueBreakdown) [][]string {
+	rows := make([][]string, 0, len(breakdowns))
+	for _, breakdown := range breakdowns {
+		rows = append(rows, []string{
+			breakdown.Name,
+			breakdown.ValueUSD,
+			breakdown.STCGUSD,
+			breakdown.LTCGUSD,
+			breakdown.TotalTaxUSD,
+			breakdown.AfterTaxValueUSD,
+		})
+	}
+	return rows
+}
+
+// valueBreakdownsToTableRows converts the breakdowns to table rows with USD values formatted.
+func valueBreakdownsToTableRows(breakdowns []*valueBreakdown) [][]string {
+	rows := valueBreakdownsToRows(breakdowns)
+	for _, row := range rows {
+		for i := 1; i < len(row); i++ {
+			row[i] = cliio.FormatUSD(row[i])
+		}
+	}
+	return rows
+}
+
+// incomeYield returns the projected income of the totals as a fraction of
+// their market value, or 0 if the market value is not positive.
+func incomeYield(totals *ibctlholdings.Totals) float64 {
+	marketValueMicros := mathpb.ParseMicros(totals.MarketValueUSD)
+	if marketValueMicros <= 0 {
+		return 0
+	}
+	return float64(mathpb.ParseMicros(totals.ProjectedIncomeUSD)) / float64(marketValueMicros)
+}
+
+// usdString returns USD micros as a raw decimal string.
+func usdString(micros int64) string {
+	return moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", micros))
+}
+
+// accountTypeValue is the total market value of the accounts of one account type.
+type accountTypeValue struct {
+	accountType string
+	valueMicros int64
+}
+
+// getAccountTypeValues returns the market value of each account type with
+// accounts in the merged data, including accrued dividends, and the holdings
+// of taxable accounts.
+//
+// If every account is taxable, no values are returned and the taxable holdings
+// are the given holdings. Manual cash adjustments are counted as taxable.
+func getAccountTypeValues(
+	config *ibctlconfig.Config,
+	mergedData *ibctlmerge.MergedData,
+	fxStore *ibctlfxrates.Store,
+	holdings []*ibctlholdings.HoldingOverview,
+	dividendAccruals []*datav1.CashTransaction,
+) ([]accountTypeValue, []*ibctlholdings.HoldingOverview, error) {
+	if len(config.AccountAliasesForType(ibctlconfig.AccountTypeTaxable)) == len(config.AccountTypes) {
+		return nil, holdings, nil
+	}
+	var accountTypeValues []accountTypeValue
+	var taxableHoldings []*ibctlholdings.HoldingOverview
+	for _, accountType := range ibctlconfig.AccountTypes {
+		accountAliases := config.AccountAliasesForType(accountType)
+		accountTypeData := ibctlmerge.FilterAccounts(mergedData, accountAliases)
+		accountTypeConfig := *config
+		if accountType != ibctlconfig.AccountTypeTaxable {
+			accountTypeConfig.CashAdjustments = nil
+		}
+		result, err := ibctlholdings.GetHoldingsOverview(accountTypeData.Trades, accountTypeData.Positions, accountTypeData.CashPositions, &accountTypeConfig, fxStore)
+		if err != nil {
+			return nil, nil, fmt.Errorf("computing %s holdings: %w", accountType, err)
+		}
+		if accountType == ibctlconfig.AccountTypeTaxable {
+			taxableHoldings = result.Holdings
+		}
+		var accountTypeDividendAccruals []*datav1.CashTransaction
+		for _, dividendAccrual := range dividendAccruals {
+			if slices.Contains(accountAliases, dividendAccrual.GetAccountId()) {
+				accountTypeDividendAccruals = append(accountTypeDividendAccruals, dividendAccrual)
+			}
+		}
+		valueMicros := accruedDividendsUSDMicros(accountTypeDividendAccruals, fxStore)
+		if len(result.Holdings) == 0 && valueMicros == 0 {
+			continue
+		}
+		for _, h := range result.Holdings {
+			valueMicros += mathpb.ParseMicros(h.MarketValueUSD)
+		}
+		accountTypeValues = append(accountTypeValues, accountTypeValue{accountType: accountType, valueMicros: valueMicros})
+	}
+	return accountTypeValues, taxableHoldings, nil
+}
+
+// accruedDividendsUSDMicros returns the total of the dividend accruals in USD
+// micros. Accruals in currencies without an FX rate are skipped.
+func accruedDividendsUSDMicros(dividendAccruals []*datav1.CashTransaction, fxStore *ibctlfxrates.Store) int64 {
+	var totalMicros int64
+	for _, dividendAccrual := range dividendAccruals {
+		if usdMoney, ok := fxStore.ConvertToUSD(dividendAccrual.GetAmount()); ok {
+			totalMicros += moneypb.MoneyToMicros(usdMoney)
+		}
+	}
+	return totalMicros
+}
+
+// accountValue is the value and unrealized gains of one account in USD micros.
+type accountValue struct {
+	valueMicros int64
+	stcgMicros  int64
+	ltcgMicros  int64
+}
+
+// getValueBreakdowns returns the value with estimated tax impact of each
+// account, or of each configured group, sorted by name. If group is set, only
+// its accounts, or only the group itself, are included.
+func getValueBreakdowns(
+	config *ibctlconfig.Config,
+	mergedData *ibctlmerge.MergedData,
+	fxStore *ibctlfxrates.Store,
+	dividendAccruals []*datav1.CashTransaction,
+	by string,
+	group string,
+) ([]*valueBreakdown, error) {
+	accountAliases := slices.Sorted(maps.Keys(config.AccountAliases))
+	if group != "" {
+		accountAliases = config.Groups[group]
+	}
+	accountValues := make(map[string]accountValue, len(accountAliases))
+	for _, alias := range accountAliases {
+		value, err := getAccountValue(config, mergedData, fxStore, dividendAccruals, alias)
+		if err != nil {
+			return nil, err
+		}
+		accountValues[alias] = value
+	}
+	var breakdowns []*valueBreakdown
+	switch by {
+	case breakdownByAccount:
+		for _, alias := range accountAliases {
+			breakdowns = append(breakdowns, newValueBreakdown(config, alias, accountValues[alias]))
+		}
+	case breakdownByGroup:
+		groupNames := slices.Sorted(maps.Keys(config.Groups))
+		if group != "" {
+			groupNames = []string{group}
+		}
+		for _, groupName := range groupNames {
+			var groupValue accountValue
+			for _, alias := range config.Groups[groupName] {
+				groupValue.valueMicros += accountValues[alias].valueMicros
+				groupValue.stcgMicros += accountValues[alias].stcgMicros
+				groupValue.ltcgMicros += accountValues[alias].ltcgMicros
+			}
+			breakdowns = append(breakdowns, newValueBreakdown(config, groupName, groupValue))
+		}
+	default:
+		return nil, fmt.Errorf("unknown breakdown: %q", by)
+	}
+	return breakdowns, nil
+}
+
+// getAccountValue returns the value of an account, including accrued
+// dividends, and its unrealized gains if it is taxable. Manual cash
+// adjustments are not attributed to any account.
+func getAccountValue(
+	config *ibctlconfig.Config,
+	mergedData *ibctlmerge.MergedData,
+	fxStore *ibctlfxrates.Store,
+	dividendAccruals []*datav1.CashTransaction,
+	alias string,
+) (accountValue, error) {
+	accountData := ibctlmerge.FilterAccounts(mergedData, []string{alias})
+	accountConfig := *config
+	accountConfig.CashAdjustments = nil
+	result, err := ibctlholdings.GetHoldingsOverview(accountData.Trades, accountData.Positions, accountData.CashPositions, &accountConfig, fxStore)
+	if err != nil {
+		return accountValue{}, fmt.Errorf("computing %s holdings: %w", alias, err)
+	}
+	var accountDividendAccruals []*datav1.CashTransaction
+	for _, dividendAccrual := range dividendAccruals {
+		if dividendAccrual.GetAccountId() == alias {
+			accountDividendAccruals = append(accountDividendAccruals, dividendAccrual)
+		}
+	}
+	value := accountValue{valueMicros: accruedDividendsUSDMicros(accountDividendAccruals, fxStore)}
+	isTaxable := config.AccountTypes[alias] == ibctlconfig.AccountTypeTaxable
+	for _, h := range result.Holdings {
+		value.valueMicros += mathpb.ParseMicros(h.MarketValueUSD)
+		if isTaxable {
+			value.stcgMicros += mathpb.ParseMicros(h.STCGUSD)
+			value.ltcgMicros += mathpb.ParseMicros(h.LTCGUSD)
+		}
+	}
+	return value, nil
+}
+
+// newValueBreakdown returns the breakdown row for the value, estimating tax
+// with the same rates as the total.
+func newValueBreakdown(config *ibctlconfig.Config, name string, value accountValue) *valueBreakdown {
+	totalTaxMicros := int64(math.Round(float64(value.stcgMicros)*config.TaxRateSTCG)) +
+		int64(math.Round(float64(value.ltcgMicros)*config.TaxRateLTCG))
+	return &valueBreakdown{
+		Name:             name,
+		ValueUSD:         usdString(value.valueMicros),
+		STCGUSD:          usdString(value.stcgMicros),
+		LTCGUSD:          usdString(value.ltcgMicros),
+		TotalTaxUSD:      usdString(totalTaxMicros),
+		AfterTaxValueUSD: usdString(value.valueMicros - totalTaxMicros),
+	}
 }
@@ -8,6 +8,7 @@ package lot
 import (
 	"buf.build/go/app/appcmd"
 	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/holding/lot/lotaging"
 	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/holding/lot/lotlist"
 )
 
@@ -17,6 +18,7 @@ func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
 		Use:   name,
 		Short: "Display individual tax lots",
 		SubCommands: []*appcmd.Command{
+			lotaging.NewCommand("aging", builder),
 			lotlist.NewCommand("list", builder),
 		},
 	}
@@ -0,0 +1,163 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package lotaging implements the "holding lot aging" command.
+package lotaging
+
+import (
+	"context"
+	"os"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlholdings"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// formatFlagName is the flag name for the output format.
+	formatFlagName = "format"
+	// downloadFlagName is the flag name for downloading fresh data before displaying.
+	downloadFlagName = "download"
+	// daysFlagName is the flag name for the aging window in days.
+	daysFlagName = "days"
+)
+
+// NewCommand returns a new lot aging command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "List short-term lots that become long-term within a number of days",
+		Long: `List short-term lots that become long-term within a number of days.
+
+Lots become long-term after the holding period of the jurisdiction in the
+taxes section of ibctl.yaml, which is more than one year by default. For each
+lot in a taxable account that crosses that threshold within --days, the
+unrealized gain that would shift from STCG to LTCG is shown with the estimated
+tax saved by waiting until the long-term date to sell, using the stcg and ltcg
+rates from the taxes section of ibctl.yaml. The tax delta is negative for
+losses, whose deduction is worth less once long-term. Jurisdictions without
+long-term gains, such as ca, have no lots to show.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Format is the output format (table, csv, json).
+	Format string
+	// Download fetches fresh data before displaying.
+	Download bool
+	// Group restricts holdings to the accounts in a configured account group.
+	Group string
+	// Days is the aging window: lots that become long-term within this many days are listed.
+	Days int
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+	flagSet.BoolVar(&f.Download, downloadFlagName, false, "Download fresh data before displaying")
+	flagSet.StringVar(&f.Group, ibctlcmd.GroupFlagName, "", "Only include accounts in this account group from ibctl.yaml")
+	flagSet.IntVar(&f.Days, daysFlagName, 30, "List lots that become long-term within this many days")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) error {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	if flags.Days <= 0 {
+		return appcmd.NewInvalidArgumentErrorf("--%s must be positive", daysFlagName)
+	}
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	// Download fresh data if --download is set.
+	if flags.Download {
+		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir, "")
+		if err != nil {
+			return err
+		}
+		if err := downloader.Download(ctx); err != nil {
+			return err
+		}
+	}
+	// Merge trade data from all sources.
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+	)
+	if err != nil {
+		return err
+	}
+	// Restrict to the accounts in --group if set.
+	config, mergedData, err = ibctlcmd.ApplyGroup(config, mergedData, flags.Group)
+	if err != nil {
+		return err
+	}
+	// Load FX rates for USD conversion.
+	fxStore := ibctlfxrates.NewStore(ibctlpath.CacheFXDirPath(config.DirPath))
+	agings, err := ibctlholdings.GetLotAging(flags.Days, mergedData.Trades, mergedData.Positions, config, fxStore)
+	if err != nil {
+		return err
+	}
+	// Write output in the requested format.
+	writer := os.Stdout
+	switch format {
+	case cliio.FormatTable:
+		headers := ibctlholdings.LotAgingHeaders()
+		rows := make([][]string, 0, len(agings))
+		var gainMicros, taxDeltaMicros int64
+		for _, aging := range agings {
+			rows = append(rows, ibctlholdings.LotAgingToTableRow(aging))
+			gainMicros += mathpb.ParseMicros(aging.GainUSD)
+			taxDeltaMicros += mathpb.ParseMicros(aging.TaxDeltaUSD)
+		}
+		totalsRow := make([]string, len(headers))
+		totalsRow[0] = "TOTAL"
+		totalsRow[6] = cliio.FormatUSDMicros(gainMicros)
+		totalsRow[7] = cliio.FormatUSDMicros(taxDeltaMicros)
+		return cliio.WriteTableWithTotals(writer, headers, rows, totalsRow)
+	case cliio.FormatCSV:
+		records := make([][]string, 0, len(agings)+1)
+		records = append(records, ibctlholdings.LotAgingHeaders())
+		for _, aging := range agings {
+			records = append(records, ibctlholdings.LotAgingToRow(aging))
+		}
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, agings...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
@@ -8,14 +8,15 @@ package lotlist
 import (
 	"context"
 	"os"
+	"time"
 
 	"buf.build/go/app/appcmd"
 	"buf.build/go/app/appext"
 	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
-	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctlholdings"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlnotes"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
 	"github.com/bufdev/ibctl/internal/pkg/cliio"
 	"github.com/spf13/pflag"
@@ -28,15 +29,60 @@ const (
 	downloadFlagName = "download"
 	// symbolFlagName is the flag name for filtering by symbol.
 	symbolFlagName = "symbol"
+	// groupByFlagName is the flag name for grouping lots with subtotals.
+	groupByFlagName = "group-by"
+	// tagFlagName is the flag name for filtering by tag.
+	tagFlagName = "tag"
+	// tableNoteMaxWidth is the maximum width of a note in table output. CSV
+	// and JSON output have the full note.
+	tableNoteMaxWidth = 32
 )
 
+// lotsDropOrder is the order in which lot table columns are dropped to fit the
+// terminal width: the source, lot ID, and classifications, then the native
+// currency values, then the notes and tags.
+var lotsDropOrder = []int{17, 18, 16, 14, 15, 13, 5, 6, 7, 4, 8, 20, 19}
+
 // NewCommand returns a new lot list command.
 func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
 	flags := newFlags()
 	return &appcmd.Command{
 		Use:   name,
 		Short: "List individual tax lots, optionally filtered by symbol",
-		Args:  appcmd.NoArgs,
+		Long: `List individual tax lots, optionally filtered by symbol.
+
+With --group-by symbol, account, year (the year the lot was opened), or tag,
+lots are grouped and each group is followed by a subtotal row with the P&L,
+STCG, LTCG, and value in USD, and, when grouping by symbol, the total quantity.
+A lot with several tags is in the group of each. Subtotal rows are in table and
+CSV output; JSON output is not grouped.
+
+Tags and notes come from data/notes/<alias>/notes.yaml, where lots are keyed
+by lot ID and symbols by symbol. --tag keeps the lots with a matching tag,
+where "thesis" also matches "thesis:ai".
+
+With --as-of YYYY-MM-DD, lots are computed from the trades on or before the
+date, valued at the closing prices in the price cache (cache/prices/) and
+converted at the FX rates in effect on the date, with ST/LT classification as
+of the date. Closing prices are fetched and required as in "ibctl holding list
+--as-of".
+
+In a terminal, P&L, STCG, and LTCG are colored green for gains and red for
+losses, symbols of lots without a USD P&L are yellow, and subtotals and totals
+are cyan. --color always or never overrides the terminal detection.
+
+Notes are truncated in table output. Tables wider than the terminal (or
+COLUMNS) are narrowed by dropping the source, lot ID, and classification
+columns, then the native currency values, then the notes and tags. Use
+--pager to view every column in $PAGER instead.
+
+With --fx-audit (requires --format json), each lot not in USD has an fx_rate
+with the currency pair, rate, rate date, and provider its USD values were
+converted with, to verify conversions against broker statements.
+
+CSV output ends with a TOTAL row, and JSON output with a summary object
+{"summary":{"as_of":...,"count":...,"totals":{...}}} after the lots.`,
+		Args: appcmd.NoArgs,
 		Run: builder.NewRunFunc(
 			func(ctx context.Context, container appext.Container) error {
 				return run(ctx, container, flags)
@@ -53,8 +99,24 @@ type flags struct {
 	Format string
 	// Download fetches fresh data before displaying.
 	Download bool
+	// Group restricts holdings to the accounts in a configured account group.
+	Group string
+	// MaxAge fails if the position snapshot is older than this, or is 0 for no limit.
+	MaxAge time.Duration
 	// Symbol filters lots to a specific symbol. Empty means all symbols.
 	Symbol string
+	// GroupBy groups lots by symbol, account, year, or tag with subtotal rows. Empty means no grouping.
+	GroupBy string
+	// Tag restricts the list to lots with a matching tag.
+	Tag string
+	// AsOf computes lots as of a historical date (YYYY-MM-DD).
+	AsOf string
+	// Color is when to color table output (auto, always, never).
+	Color string
+	// Pager pipes table output into the PAGER command.
+	Pager bool
+	// FXAudit annotates each lot with the FX rate its USD values were converted with.
+	FXAudit bool
 }
 
 func newFlags() *flags {
@@ -63,10 +125,18 @@ func newFlags() *flags {
 
 // Bind registers the flag definitions with the given flag set.
 func (f *flags) Bind(flagSet *pflag.FlagSet) {
-	flagSet.StringVar(&f.Dir, ibctlcmd.DirFlagName, ".", "The ibctl directory containing ibctl.yaml")
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
 	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
 	flagSet.BoolVar(&f.Download, downloadFlagName, false, "Download fresh data before displaying")
+	flagSet.StringVar(&f.Group, ibctlcmd.GroupFlagName, "", "Only include accounts in this account group from ibctl.yaml")
+	flagSet.DurationVar(&f.MaxAge, ibctlcmd.MaxAgeFlagName, 0, "Fail if the position snapshot was downloaded longer ago than this (e.g., 24h)")
 	flagSet.StringVar(&f.Symbol, symbolFlagName, "", "Filter by symbol (omit for all symbols)")
+	flagSet.StringVar(&f.GroupBy, groupByFlagName, "", "Group lots with subtotal rows (symbol, account, year, tag)")
+	flagSet.StringVar(&f.Tag, tagFlagName, "", "Only list lots with this tag from the notes")
+	flagSet.StringVar(&f.AsOf, ibctlcmd.AsOfFlagName, "", "Compute lots as of a historical date (YYYY-MM-DD)")
+	flagSet.StringVar(&f.Color, ibctlcmd.ColorFlagName, string(cliio.ColorModeAuto), "When to color table output (auto, always, never)")
+	flagSet.BoolVar(&f.Pager, ibctlcmd.PagerFlagName, false, "Pipe table output into $PAGER (default less -RS)")
+	flagSet.BoolVar(&f.FXAudit, ibctlcmd.FXAuditFlagName, false, "Annotate each lot with the FX rate, rate date, and provider its USD values were converted with (requires --format json)")
 }
 
 func run(ctx context.Context, container appext.Container, flags *flags) error {
@@ -74,14 +144,30 @@ func run(ctx context.Context, container appext.Container, flags *flags) error {
 	if err != nil {
 		return appcmd.NewInvalidArgumentError(err.Error())
 	}
+	colorMode, err := cliio.ParseColorMode(flags.Color)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	switch flags.GroupBy {
+	case "", ibctlholdings.LotGroupBySymbol, ibctlholdings.LotGroupByAccount, ibctlholdings.LotGroupByYear, ibctlholdings.LotGroupByTag:
+	default:
+		return appcmd.NewInvalidArgumentErrorf("--%s must be one of %s, %s, %s, %s", groupByFlagName, ibctlholdings.LotGroupBySymbol, ibctlholdings.LotGroupByAccount, ibctlholdings.LotGroupByYear, ibctlholdings.LotGroupByTag)
+	}
+	asOf, err := ibctlcmd.ParseAsOf(flags.AsOf)
+	if err != nil {
+		return err
+	}
+	if flags.FXAudit && format != cliio.FormatJSON {
+		return appcmd.NewInvalidArgumentErrorf("--%s requires --%s json", ibctlcmd.FXAuditFlagName, formatFlagName)
+	}
 	// Read and validate the configuration file from the base directory.
-	config, err := ibctlconfig.ReadConfig(flags.Dir)
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
 	if err != nil {
 		return err
 	}
 	// Download fresh data if --download is set.
 	if flags.Download {
-		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir)
+		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir, "")
 		if err != nil {
 			return err
 		}
@@ -94,48 +180,160 @@ func run(ctx context.Context, container appext.Container, flags *flags) error {
 		ibctlpath.DataAccountsDirPath(config.DirPath),
 		ibctlpath.CacheAccountsDirPath(config.DirPath),
 		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
 		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
 		config.AccountAliases,
 	)
 	if err != nil {
 		return err
 	}
-	// Load FX rates for USD conversion.
-	fxStore := ibctlfxrates.NewStore(ibctlpath.CacheFXDirPath(config.DirPath))
+	// Restrict to the accounts in --group if set.
+	config, mergedData, err = ibctlcmd.ApplyGroup(config, mergedData, flags.Group)
+	if err != nil {
+		return err
+	}
+	// Warn about, or with --max-age fail on, a stale position snapshot. Past
+	// dates do not use the snapshot.
+	if asOf.IsZero() {
+		if err := ibctlcmd.CheckSnapshotAge(container, config, flags.Group, flags.MaxAge); err != nil {
+			return err
+		}
+	}
 	// Get the lot list, optionally filtered by symbol.
-	result, err := ibctlholdings.GetLotList(flags.Symbol, mergedData.Trades, mergedData.Positions, config, fxStore)
+	var result *ibctlholdings.LotListResult
+	var fxStore *ibctlfxrates.Store
+	if asOf.IsZero() {
+		// Load FX rates for USD conversion.
+		fxStore = ibctlfxrates.NewStore(ibctlpath.CacheFXDirPath(config.DirPath))
+		result, err = ibctlholdings.GetLotList(flags.Symbol, mergedData.Trades, mergedData.Positions, config, fxStore)
+	} else {
+		// Use the FX rates and closing prices in effect on the date, fetching
+		// closing prices that are not cached.
+		fxStore = ibctlfxrates.NewStoreAsOf(ibctlpath.CacheFXDirPath(config.DirPath), asOf)
+		priceStore, err := ibctlcmd.NewPriceStore(container, config, mergedData.Trades)
+		if err != nil {
+			return err
+		}
+		result, err = ibctlholdings.GetLotListAsOf(ctx, asOf, flags.Symbol, mergedData.Trades, config, fxStore, priceStore)
+	}
 	if err != nil {
 		return err
 	}
+	if flags.FXAudit {
+		ibctlholdings.AddLotFXRates(result.Lots, fxStore)
+	}
+	// Read the lot and symbol notes, and restrict to --tag if set.
+	notes, err := ibctlnotes.ReadNotes(ibctlpath.DataNotesDirPath(config.DirPath), config.AccountAliases)
+	if err != nil {
+		return err
+	}
+	ibctlholdings.AnnotateLots(result.Lots, notes)
+	if flags.Tag != "" {
+		result.Lots = ibctlholdings.FilterLotsByTag(result.Lots, flags.Tag)
+	}
 	// Write output in the requested format.
 	writer := os.Stdout
 	switch format {
 	case cliio.FormatTable:
 		headers := ibctlholdings.LotListHeaders()
 		rows := make([][]string, 0, len(result.Lots))
-		for _, l := range result.Lots {
-			rows = append(rows, ibctlholdings.LotOverviewToTableRow(l))
+		// The lot of each row, nil for separator and subtotal rows, for coloring.
+		rowLots := make([]*ibctlholdings.LotOverview, 0, len(result.Lots))
+		subtotalRowIndexes := make(map[int]struct{})
+		if flags.GroupBy != "" {
+			groups, err := ibctlholdings.GroupLots(result.Lots, flags.GroupBy)
+			if err != nil {
+				return err
+			}
+			for i, group := range groups {
+				// Separate groups with a blank row; the totals row adds its own.
+				if i > 0 {
+					rows = append(rows, make([]string, len(headers)))
+					rowLots = append(rowLots, nil)
+				}
+				for _, l := range group.Lots {
+					rows = append(rows, ibctlholdings.LotOverviewToTableRow(l))
+					rowLots = append(rowLots, l)
+				}
+				subtotalRowIndexes[len(rows)] = struct{}{}
+				rows = append(rows, ibctlholdings.LotGroupToTableRow(group))
+				rowLots = append(rowLots, nil)
+			}
+		} else {
+			for _, l := range result.Lots {
+				rows = append(rows, ibctlholdings.LotOverviewToTableRow(l))
+				rowLots = append(rowLots, l)
+			}
 		}
 		// Build totals row.
-		totals := ibctlholdings.ComputeLotTotals(result.Lots)
-		totalsRow := make([]string, len(headers))
-		totalsRow[0] = "TOTAL"
-		totalsRow[9] = totals.PnLUSD
-		totalsRow[10] = totals.STCGUSD
-		totalsRow[11] = totals.LTCGUSD
-		totalsRow[12] = totals.ValueUSD
-		return cliio.WriteTableWithTotals(writer, headers, rows, totalsRow)
+		totalsRow := ibctlholdings.LotTotalsToTableRow(ibctlholdings.ComputeLotTotals(result.Lots))
+		// Truncate notes, which are free text of any length.
+		for _, row := range rows {
+			row[20] = cliio.Truncate(row[20], tableNoteMaxWidth)
+		}
+		var cellColor cliio.CellColorFunc
+		if cliio.ColorEnabled(colorMode, os.Stdout) {
+			cellColor = func(rowIndex int, columnIndex int) cliio.Color {
+				if _, ok := subtotalRowIndexes[rowIndex]; ok {
+					return cliio.ColorCyan
+				}
+				return lotCellColor(rowLots[rowIndex], columnIndex)
+			}
+		}
+		return ibctlcmd.WriteTable(ctx, container, flags.Pager, headers, rows, totalsRow, lotsDropOrder, cellColor)
 	case cliio.FormatCSV:
 		headers := ibctlholdings.LotListHeaders()
-		records := make([][]string, 0, len(result.Lots)+1)
+		records := make([][]string, 0, len(result.Lots)+2)
 		records = append(records, headers)
-		for _, l := range result.Lots {
-			records = append(records, ibctlholdings.LotOverviewToRow(l))
+		if flags.GroupBy != "" {
+			groups, err := ibctlholdings.GroupLots(result.Lots, flags.GroupBy)
+			if err != nil {
+				return err
+			}
+			for _, group := range groups {
+				for _, l := range group.Lots {
+					records = append(records, ibctlholdings.LotOverviewToRow(l))
+				}
+				records = append(records, ibctlholdings.LotGroupToRow(group))
+			}
+		} else {
+			for _, l := range result.Lots {
+				records = append(records, ibctlholdings.LotOverviewToRow(l))
+			}
 		}
+		records = append(records, ibctlholdings.LotTotalsToRow(ibctlholdings.ComputeLotTotals(result.Lots)))
 		return cliio.WriteCSVRecords(writer, records)
 	case cliio.FormatJSON:
-		return cliio.WriteJSON(writer, result.Lots...)
+		return cliio.WriteJSONWithSummary(writer, ibctlcmd.AsOfDate(asOf).String(), ibctlholdings.ComputeLotTotals(result.Lots), result.Lots...)
 	default:
 		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
 	}
 }
+
+// lotCellColor returns the color of a cell of the lot table: the symbol of a
+// lot without a USD P&L (no market price or FX rate) in yellow, and P&L, STCG,
+// and LTCG green for gains and red for losses. The lot is nil for separator
+// rows.
+func lotCellColor(l *ibctlholdings.LotOverview, columnIndex int) cliio.Color {
+	if l == nil {
+		return cliio.ColorNone
+	}
+	switch columnIndex {
+	case 0:
+		if l.PnLUSD == "" {
+			return cliio.ColorYellow
+		}
+	case 6:
+		return cliio.SignColor(l.PnL)
+	case 9:
+		return cliio.SignColor(l.PnLUSD)
+	case 10:
+		return cliio.SignColor(l.STCGUSD)
+	case 11:
+		return cliio.SignColor(l.LTCGUSD)
+	}
+	return cliio.ColorNone
+}
@@ -0,0 +1,27 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package income implements the "income" command group.
+package income
+
+import (
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/income/incomecalendar"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/income/incomelist"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/income/incomewithholding"
+)
+
+// NewCommand returns a new income command group.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Display income and cash flow information",
+		SubCommands: []*appcmd.Command{
+			incomecalendar.NewCommand("calendar", builder),
+			incomelist.NewCommand("list", builder),
+			incomewithholding.NewCommand("withholding", builder),
+		},
+	}
+}
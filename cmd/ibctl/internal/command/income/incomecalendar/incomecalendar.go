@@ -0,0 +1,170 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package incomecalendar implements the "income calendar" command.
+package incomecalendar
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	mathv1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/math/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlholdings"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlincome"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// formatFlagName is the flag name for the output format.
+	formatFlagName = "format"
+	// downloadFlagName is the flag name for downloading fresh data before displaying.
+	downloadFlagName = "download"
+	// monthsFlagName is the flag name for the number of months to project.
+	monthsFlagName = "months"
+)
+
+// NewCommand returns a new income calendar command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Project upcoming dividend payments of current holdings",
+		Long: `Project upcoming dividend payments of current holdings.
+
+For each symbol currently held, the payment cadence (monthly, quarterly,
+semiannual, or annual) is inferred from the intervals between its past
+dividend payments, and payments are projected from the most recent payment
+date over the next --months months. The projected amount is the most recent
+per-share rate from the IBKR dividend description times the current share
+count across all accounts, or the most recent payment if the description has
+no per-share rate.
+
+Amounts are gross of withholding tax, and dates are estimated payment dates,
+since the Flex Query does not report ex-dividend dates. Symbols with fewer
+than two past payments are skipped.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Format is the output format (table, csv, json).
+	Format string
+	// Download fetches fresh data before displaying.
+	Download bool
+	// Months is the number of months to project.
+	Months int
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+	flagSet.BoolVar(&f.Download, downloadFlagName, false, "Download fresh data before displaying")
+	flagSet.IntVar(&f.Months, monthsFlagName, 12, "The number of months to project")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) error {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	if flags.Months <= 0 {
+		return appcmd.NewInvalidArgumentErrorf("--%s must be positive", monthsFlagName)
+	}
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	// Download fresh data if --download is set.
+	if flags.Download {
+		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir, "")
+		if err != nil {
+			return err
+		}
+		if err := downloader.Download(ctx); err != nil {
+			return err
+		}
+	}
+	// Merge data from all sources.
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+	)
+	if err != nil {
+		return err
+	}
+	// Load FX rates for USD conversion.
+	fxStore := ibctlfxrates.NewStore(ibctlpath.CacheFXDirPath(config.DirPath))
+	// Compute current share counts from holdings.
+	holdingsResult, err := ibctlholdings.GetHoldingsOverview(mergedData.Trades, mergedData.Positions, mergedData.CashPositions, config, fxStore)
+	if err != nil {
+		return err
+	}
+	shares := make(map[string]*mathv1.Decimal, len(holdingsResult.Holdings))
+	for _, h := range holdingsResult.Holdings {
+		shares[h.Symbol] = h.Position
+	}
+	entries, err := ibctlincome.GetDividendCalendar(mergedData.CashTransactions, shares, xtime.TimeToDate(time.Now()), flags.Months, fxStore)
+	if err != nil {
+		return err
+	}
+	// Write output in the requested format.
+	writer := os.Stdout
+	switch format {
+	case cliio.FormatTable:
+		headers := ibctlincome.DividendCalendarHeaders()
+		rows := make([][]string, 0, len(entries))
+		for _, e := range entries {
+			rows = append(rows, ibctlincome.DividendCalendarEntryToTableRow(e))
+		}
+		// Build totals row.
+		totals := ibctlincome.ComputeDividendCalendarTotals(entries)
+		if totals.MissingUSD > 0 {
+			container.Logger().Warn("total excludes amounts without a USD conversion, run \"ibctl download\" to fetch FX rates",
+				"count", totals.MissingUSD)
+		}
+		return cliio.WriteTableWithTotals(writer, headers, rows, ibctlincome.DividendCalendarTotalsToTableRow(totals))
+	case cliio.FormatCSV:
+		headers := ibctlincome.DividendCalendarHeaders()
+		records := make([][]string, 0, len(entries)+1)
+		records = append(records, headers)
+		for _, e := range entries {
+			records = append(records, ibctlincome.DividendCalendarEntryToRow(e))
+		}
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, entries...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package incomewithholding implements the "income withholding" command.
+package incomewithholding
+
+import (
+	"context"
+	"os"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlincome"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// formatFlagName is the flag name for the output format.
+	formatFlagName = "format"
+	// downloadFlagName is the flag name for downloading fresh data before displaying.
+	downloadFlagName = "download"
+	// candidatesFlagName is the flag name for listing the over-withheld payments.
+	candidatesFlagName = "candidates"
+)
+
+// NewCommand returns a new income withholding command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Summarize dividend withholding tax by country and flag reclaim candidates",
+		Long: `Summarize dividend withholding tax by country and flag reclaim candidates.
+
+Withholding tax is matched to its dividend by account, symbol, and date, and
+summarized per source country and year with the effective withholding rate.
+The source country is parsed from the IBKR withholding description (e.g.,
+"- US Tax"), or the ISIN of the security if the description has none.
+
+If taxes.treaty_rates in ibctl.yaml sets the treaty rate for a country, each
+payment withheld more than half a percentage point above it is flagged as a
+reclaim candidate, and the excess is summed per country and year. Use
+--candidates to list the flagged payments instead of the summary.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Format is the output format (table, csv, json).
+	Format string
+	// Download fetches fresh data before displaying.
+	Download bool
+	// Candidates lists the over-withheld payments instead of the summary.
+	Candidates bool
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+	flagSet.BoolVar(&f.Download, downloadFlagName, false, "Download fresh data before displaying")
+	flagSet.BoolVar(&f.Candidates, candidatesFlagName, false, "List the payments withheld above the treaty rate instead of the summary")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) error {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	// Download fresh data if --download is set.
+	if flags.Download {
+		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir, "")
+		if err != nil {
+			return err
+		}
+		if err := downloader.Download(ctx); err != nil {
+			return err
+		}
+	}
+	// Merge data from all sources.
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+		ibctlmerge.MergeWithCashTransactions(),
+	)
+	if err != nil {
+		return err
+	}
+	// Load FX rates for USD conversion.
+	fxStore := ibctlfxrates.NewStore(ibctlpath.CacheFXDirPath(config.DirPath))
+	result, err := ibctlincome.GetWithholdingReport(mergedData.CashTransactions, config.TaxTreatyRates, fxStore)
+	if err != nil {
+		return err
+	}
+	if len(config.TaxTreatyRates) == 0 {
+		container.Logger().Warn("no taxes.treaty_rates configured in ibctl.yaml, reclaim candidates cannot be flagged")
+	}
+	// Build the header and rows for either the summary or the candidates.
+	var headers []string
+	var rows [][]string
+	var tableRows [][]string
+	var objects []any
+	if flags.Candidates {
+		headers = ibctlincome.WithholdingPaymentHeaders()
+		for _, p := range result.ReclaimCandidates {
+			rows = append(rows, ibctlincome.WithholdingPaymentToRow(p))
+			tableRows = append(tableRows, ibctlincome.WithholdingPaymentToTableRow(p))
+			objects = append(objects, p)
+		}
+	} else {
+		headers = ibctlincome.WithholdingSummaryHeaders()
+		for _, s := range result.Summaries {
+			rows = append(rows, ibctlincome.WithholdingSummaryToRow(s))
+			tableRows = append(tableRows, ibctlincome.WithholdingSummaryToTableRow(s))
+			objects = append(objects, s)
+		}
+	}
+	// Write output in the requested format.
+	writer := os.Stdout
+	switch format {
+	case cliio.FormatTable:
+		return cliio.WriteTable(writer, headers, tableRows)
+	case cliio.FormatCSV:
+		return cliio.WriteCSVRecords(writer, append([][]string{headers}, rows...))
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, objects...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
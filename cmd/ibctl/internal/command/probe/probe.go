@@ -9,12 +9,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	"buf.build/go/app/appcmd"
 	"buf.build/go/app/appext"
 	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
-	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
 	"github.com/bufdev/ibctl/internal/pkg/ibkrflexquery"
 	"github.com/bufdev/ibctl/internal/standard/xtime"
 	"github.com/spf13/pflag"
@@ -25,6 +26,8 @@ const (
 	fromFlagName = "from"
 	// toFlagName is the flag name for the end date.
 	toFlagName = "to"
+	// saveRawFlagName is the flag name for saving the raw XML response.
+	saveRawFlagName = "save-raw"
 )
 
 // NewCommand returns a new probe command for testing API connectivity and date ranges.
@@ -35,11 +38,27 @@ func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
 		Short: "Probe the IBKR Flex Query API with a specific date range",
 		Long: `Probe the IBKR Flex Query API with a specific date range.
 
-Makes a single API call and prints the number of trades, positions, and cash
-transactions returned. Does not write to the data cache.
+Makes a single API call and prints, per account, the number of rows returned
+in every section ibctl reads (trades, positions, cash transactions, transfers,
+trade transfers, corporate actions, and cash report). Sections that the
+configured Flex Query does not include are flagged as MISSING, so the query
+setup can be verified. Does not write to the data cache.
 
-Without --from/--to, uses the query's configured period.
-With --from/--to (YYYYMMDD format), overrides the period to test specific date ranges.`,
+Without --from/--to, uses the query's configured period, or the
+flex_query_period preset in ibctl.yaml if set.
+With --from/--to (YYYYMMDD format), overrides the period to test specific date ranges.
+With --period, overrides the period with a preset such as LastBusinessDay,
+Last30CalendarDays, or YearToDate.
+
+With --save-raw, the raw XML response is written to the given file. Saved
+responses can be replayed with --replay (or the ` + ibctlcmd.FlexReplayEnvVar + ` environment
+variable) on probe and download, which is useful for debugging parsing issues
+without hitting the API.
+
+With --debug-http, the redacted URL, status, and timing of every API request
+are traced to cache/debug/http-<timestamp>.log. With --debug-http-body, the
+start of every response body is traced too, which shows the error codes IBKR
+returns in otherwise successful responses.`,
 		Args: appcmd.NoArgs,
 		Run: builder.NewRunFunc(
 			func(ctx context.Context, container appext.Container) error {
@@ -57,6 +76,14 @@ type flags struct {
 	From string
 	// To is the end date (YYYYMMDD).
 	To string
+	// Period is the period preset that overrides the query's configured period.
+	Period string
+	// SaveRaw is the file path to write the raw XML response to.
+	SaveRaw string
+	// Replay is the path to a saved Flex Query XML response to use instead of the API.
+	Replay string
+	// DebugHTTP traces every API request to a file under cache/debug/.
+	DebugHTTP ibctlcmd.DebugHTTPFlags
 }
 
 func newFlags() *flags {
@@ -65,20 +92,30 @@ func newFlags() *flags {
 
 // Bind registers the flag definitions with the given flag set.
 func (f *flags) Bind(flagSet *pflag.FlagSet) {
-	flagSet.StringVar(&f.Dir, ibctlcmd.DirFlagName, ".", "The ibctl directory containing ibctl.yaml")
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
 	flagSet.StringVar(&f.From, fromFlagName, "", "Start date (YYYYMMDD)")
 	flagSet.StringVar(&f.To, toFlagName, "", "End date (YYYYMMDD)")
+	flagSet.StringVar(&f.Period, ibctlcmd.PeriodFlagName, "", "Period preset (e.g., LastBusinessDay, Last30CalendarDays, YearToDate) instead of --from/--to")
+	flagSet.StringVar(&f.SaveRaw, saveRawFlagName, "", "Write the raw XML response to this file")
+	flagSet.StringVar(&f.Replay, ibctlcmd.ReplayFlagName, "", "Read the Flex Query response from a saved XML file instead of the API")
+	f.DebugHTTP.Bind(flagSet)
 }
 
-func run(ctx context.Context, container appext.Container, flags *flags) error {
+func run(ctx context.Context, container appext.Container, flags *flags) (retErr error) {
 	// Validate: either both --from/--to are set, or neither.
 	if (flags.From == "") != (flags.To == "") {
 		return appcmd.NewInvalidArgumentError("--from and --to must both be specified or both be omitted")
 	}
+	if flags.Period != "" && flags.From != "" {
+		return appcmd.NewInvalidArgumentErrorf("--%s cannot be used with --from and --to", ibctlcmd.PeriodFlagName)
+	}
+	period, err := ibctlcmd.ParsePeriod(flags.Period)
+	if err != nil {
+		return err
+	}
 	// Parse date flags if provided (YYYYMMDD format). Zero values mean use query defaults.
 	var fromDate, toDate xtime.Date
 	if flags.From != "" {
-		var err error
 		fromDate, err = parseYYYYMMDD(flags.From)
 		if err != nil {
 			return appcmd.NewInvalidArgumentErrorf("invalid --from date %q, expected YYYYMMDD format: %v", flags.From, err)
@@ -89,49 +126,125 @@ func run(ctx context.Context, container appext.Container, flags *flags) error {
 		}
 	}
 	// Read config for the query ID.
-	config, err := ibctlconfig.ReadConfig(flags.Dir)
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
 	if err != nil {
 		return err
 	}
-	// Read the IBKR token from the environment.
-	const ibkrFlexWebServiceTokenEnvVar = "IBKR_FLEX_WEB_SERVICE_TOKEN"
-	ibkrToken := container.Env(ibkrFlexWebServiceTokenEnvVar)
-	if ibkrToken == "" {
-		return errors.New(ibkrFlexWebServiceTokenEnvVar + " environment variable is required, set it to your IBKR Flex Web Service token (see \"ibctl --help\" for details)")
+	// Use the configured period preset unless the period is set by flags.
+	if period == "" && flags.From == "" {
+		period = config.FlexQueryPeriod
+	}
+	// Construct the Flex Query client, which reads the IBKR token from the environment unless replaying.
+	closeTrace, err := flags.DebugHTTP.Open(container, config)
+	if err != nil {
+		return err
 	}
-	// Make a single API call with the specified date range.
+	defer func() {
+		retErr = errors.Join(retErr, closeTrace())
+	}()
+	httpClient, err := ibctlcmd.NewHTTPClient(container, config)
+	if err != nil {
+		return err
+	}
+	client, ibkrToken, err := ibctlcmd.NewFlexQueryClient(container, httpClient, flags.Replay)
+	if err != nil {
+		return err
+	}
+	// Make a single API call with the specified period or date range.
 	logger := container.Logger()
-	client := ibkrflexquery.NewClient(logger)
-	logger.Info("probing API", "from", fromDate.String(), "to", toDate.String(), "query_id", config.IBKRFlexQueryID)
-	statements, err := client.Download(ctx, ibkrToken, config.IBKRFlexQueryID, fromDate, toDate)
+	logger.Info("probing API", "period", string(period), "from", fromDate.String(), "to", toDate.String(), "query_id", config.IBKRFlexQueryID)
+	xmlData, err := client.DownloadRaw(ctx, ibkrToken, config.IBKRFlexQueryID, period, fromDate, toDate)
+	if err != nil {
+		return fmt.Errorf("probe failed: %w", err)
+	}
+	// Save the raw response before parsing so parsing issues can be debugged.
+	if flags.SaveRaw != "" {
+		if err := os.WriteFile(flags.SaveRaw, xmlData, 0o600); err != nil {
+			return fmt.Errorf("saving raw response: %w", err)
+		}
+		logger.Info("raw response saved", "path", flags.SaveRaw)
+	}
+	statements, err := ibkrflexquery.ParseResponse(xmlData)
+	if err != nil {
+		return fmt.Errorf("probe failed: %w", err)
+	}
+	// Determine which sections the Flex Query includes, to distinguish empty sections from missing ones.
+	accountIDToSections, err := ibkrflexquery.GetSections(xmlData)
 	if err != nil {
 		return fmt.Errorf("probe failed: %w", err)
 	}
 	// Print per-account results to stdout.
+	missingSections := make(map[string]struct{})
 	for _, statement := range statements {
 		// Look up the account alias if available.
 		alias := statement.AccountId
 		if configAlias, ok := config.AccountIDToAlias[statement.AccountId]; ok {
 			alias = configAlias
 		}
-		_, err = fmt.Fprintf(
-			container.Stdout(),
-			"account: %s\n  trades: %d\n  positions: %d\n  cash_transactions: %d\n  transfers: %d\n  trade_transfers: %d\n  corporate_actions: %d\n",
-			alias,
-			len(statement.Trades),
-			len(statement.OpenPositions),
-			len(statement.CashTransactions),
-			len(statement.Transfers),
-			len(statement.TradeTransfers),
-			len(statement.CorporateActions),
-		)
-		if err != nil {
+		if _, err := fmt.Fprintf(container.Stdout(), "account: %s\n", alias); err != nil {
 			return err
 		}
+		sections := accountIDToSections[statement.AccountId]
+		for _, section := range ibkrflexquery.Sections {
+			count := fmt.Sprintf("%d", sectionCount(&statement, section))
+			if _, ok := sections[section]; !ok {
+				count = "MISSING (not included in the flex query)"
+				missingSections[section] = struct{}{}
+			}
+			if _, err := fmt.Fprintf(container.Stdout(), "  %s: %s\n", sectionDisplayNames[section], count); err != nil {
+				return err
+			}
+		}
+	}
+	// Flag sections the configured Flex Query does not include.
+	if len(missingSections) > 0 {
+		var missing []string
+		for _, section := range ibkrflexquery.Sections {
+			if _, ok := missingSections[section]; ok {
+				missing = append(missing, section)
+			}
+		}
+		logger.Warn(
+			"flex query is missing sections that ibctl needs, add them to the query in the IBKR portal",
+			"sections", strings.Join(missing, ", "),
+		)
 	}
 	return nil
 }
 
+// sectionDisplayNames maps Flex Query section names to the names printed by probe.
+var sectionDisplayNames = map[string]string{
+	ibkrflexquery.SectionTrades:           "trades",
+	ibkrflexquery.SectionOpenPositions:    "positions",
+	ibkrflexquery.SectionCashTransactions: "cash_transactions",
+	ibkrflexquery.SectionTransfers:        "transfers",
+	ibkrflexquery.SectionTradeTransfers:   "trade_transfers",
+	ibkrflexquery.SectionCorporateActions: "corporate_actions",
+	ibkrflexquery.SectionCashReport:       "cash_report",
+}
+
+// sectionCount returns the number of rows in the section of the statement.
+func sectionCount(statement *ibkrflexquery.FlexStatement, section string) int {
+	switch section {
+	case ibkrflexquery.SectionTrades:
+		return len(statement.Trades)
+	case ibkrflexquery.SectionOpenPositions:
+		return len(statement.OpenPositions)
+	case ibkrflexquery.SectionCashTransactions:
+		return len(statement.CashTransactions)
+	case ibkrflexquery.SectionTransfers:
+		return len(statement.Transfers)
+	case ibkrflexquery.SectionTradeTransfers:
+		return len(statement.TradeTransfers)
+	case ibkrflexquery.SectionCorporateActions:
+		return len(statement.CorporateActions)
+	case ibkrflexquery.SectionCashReport:
+		return len(statement.CashReport)
+	default:
+		return 0
+	}
+}
+
 // parseYYYYMMDD parses a date string in YYYYMMDD format into an xtime.Date.
 func parseYYYYMMDD(s string) (xtime.Date, error) {
 	t, err := time.Parse("20060102", s)
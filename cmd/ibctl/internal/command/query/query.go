@@ -0,0 +1,251 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package query implements the "query" command.
+package query
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlholdings"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctltrade"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/query"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// formatFlagName is the flag name for the output format.
+	formatFlagName = "format"
+	// downloadFlagName is the flag name for downloading fresh data before querying.
+	downloadFlagName = "download"
+)
+
+const (
+	// sourceHoldings is the source name for holdings aggregated across accounts.
+	sourceHoldings = "holdings"
+	// sourceLots is the source name for individual tax lots.
+	sourceLots = "lots"
+	// sourceTrades is the source name for individual trades.
+	sourceTrades = "trades"
+)
+
+// NewCommand returns a new query command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <expression>",
+		Short: "Evaluate an ad-hoc query over holdings, lots, or trades",
+		Long: `Evaluate an ad-hoc query over holdings, lots, or trades.
+
+A query is a source followed by stages separated by "|". The source is one of
+holdings, lots, or trades, whose fields are the JSON fields of "holding list",
+"holding lot list", and "trade list". Lots also have an account_type field
+(taxable, deferred, or exempt). The stages are:
+
+  where <field> <op> <value> [and|or ...]   filter records (ops: == != < <= > >= ~)
+  select <field>, ...                       keep only the fields
+  sort <field> [asc|desc]                   sort records
+  limit <n>                                 keep the first n records
+  group <field>                             group for the following aggregation
+  sum|avg|min|max <field>, count, ...       aggregate records
+
+Comparisons are numeric if both sides are numbers, and otherwise compare
+strings case-insensitively. ~ matches a case-insensitive substring. Quote
+values with spaces. Sums are exact to six decimal places.
+
+Examples:
+
+  ibctl query 'lots | where sector == TECH and account_type == taxable | sum value_usd'
+  ibctl query 'holdings | sort market_value_usd desc | limit 5 | select symbol, market_value_usd'
+  ibctl query 'trades | where date >= 2025-01-01 and side == SELL | group symbol | sum proceeds, count'
+
+A single value (e.g., a sum without group) is printed bare in table format,
+so the result can be used directly in shell scripts.`,
+		Args: appcmd.ExactArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Format is the output format (table, csv, json).
+	Format string
+	// Download fetches fresh data before querying.
+	Download bool
+	// Group restricts the data to the accounts in a configured account group.
+	Group string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+	flagSet.BoolVar(&f.Download, downloadFlagName, false, "Download fresh data before querying")
+	flagSet.StringVar(&f.Group, ibctlcmd.GroupFlagName, "", "Only include accounts in this account group from ibctl.yaml")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) error {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	// Parse the query before reading any data so syntax errors fail fast.
+	q, err := query.Parse(container.Arg(0))
+	if err != nil {
+		return appcmd.NewInvalidArgumentErrorf("invalid query: %v", err)
+	}
+	switch q.Source() {
+	case sourceHoldings, sourceLots, sourceTrades:
+	default:
+		return appcmd.NewInvalidArgumentErrorf("invalid query: unknown source %q, must be one of %s, %s, %s", q.Source(), sourceHoldings, sourceLots, sourceTrades)
+	}
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	// Download fresh data if --download is set.
+	if flags.Download {
+		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir, "")
+		if err != nil {
+			return err
+		}
+		if err := downloader.Download(ctx); err != nil {
+			return err
+		}
+	}
+	// Merge trade data from all sources.
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+	)
+	if err != nil {
+		return err
+	}
+	// Restrict to the accounts in --group if set.
+	config, mergedData, err = ibctlcmd.ApplyGroup(config, mergedData, flags.Group)
+	if err != nil {
+		return err
+	}
+	records, err := getRecords(q.Source(), mergedData, config)
+	if err != nil {
+		return err
+	}
+	result, err := q.Eval(records)
+	if err != nil {
+		return appcmd.NewInvalidArgumentErrorf("invalid query: %v", err)
+	}
+	var names []string
+	if len(result) > 0 {
+		names = result[0].Names()
+	}
+	rows := make([][]string, 0, len(result))
+	for _, record := range result {
+		rows = append(rows, record.Values())
+	}
+	// Write output in the requested format.
+	writer := os.Stdout
+	switch format {
+	case cliio.FormatTable:
+		// Print a single value bare so it can be captured in shell scripts.
+		if len(rows) == 1 && len(names) == 1 {
+			_, err := fmt.Fprintln(writer, rows[0][0])
+			return err
+		}
+		headers := make([]string, len(names))
+		for i, name := range names {
+			headers[i] = strings.ToUpper(name)
+		}
+		return cliio.WriteTable(writer, headers, rows)
+	case cliio.FormatCSV:
+		return cliio.WriteCSVRecords(writer, append([][]string{names}, rows...))
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, result...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
+
+// getRecords returns the records for the query source.
+func getRecords(source string, mergedData *ibctlmerge.MergedData, config *ibctlconfig.Config) ([]*query.Record, error) {
+	var records []*query.Record
+	switch source {
+	case sourceHoldings:
+		fxStore := ibctlfxrates.NewStore(ibctlpath.CacheFXDirPath(config.DirPath))
+		result, err := ibctlholdings.GetHoldingsOverview(mergedData.Trades, mergedData.Positions, mergedData.CashPositions, config, fxStore)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range result.Holdings {
+			record, err := query.NewRecordFromStruct(h)
+			if err != nil {
+				return nil, err
+			}
+			// The position is a decimal message, so flatten it to its decimal string.
+			record.Set("position", mathpb.ToString(h.Position))
+			records = append(records, record)
+		}
+	case sourceLots:
+		fxStore := ibctlfxrates.NewStore(ibctlpath.CacheFXDirPath(config.DirPath))
+		result, err := ibctlholdings.GetLotList("", mergedData.Trades, mergedData.Positions, config, fxStore)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range result.Lots {
+			record, err := query.NewRecordFromStruct(l)
+			if err != nil {
+				return nil, err
+			}
+			// The quantity is a decimal message, so flatten it to its decimal string.
+			record.Set("quantity", mathpb.ToString(l.Quantity))
+			// Accounts without a configured type are taxable.
+			accountType := config.AccountTypes[l.Account]
+			if accountType == "" {
+				accountType = ibctlconfig.AccountTypeTaxable
+			}
+			record.Set("account_type", accountType)
+			records = append(records, record)
+		}
+	case sourceTrades:
+		for _, t := range ibctltrade.GetTradeList(mergedData.Trades, "", "") {
+			record, err := query.NewRecordFromStruct(t)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, record)
+		}
+	default:
+		return nil, fmt.Errorf("unknown source %q", source)
+	}
+	return records, nil
+}
@@ -0,0 +1,25 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package report implements the "report" command group.
+package report
+
+import (
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/report/reportactivity"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/report/reportbenchmark"
+)
+
+// NewCommand returns a new report command group.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Display reports on the data",
+		SubCommands: []*appcmd.Command{
+			reportactivity.NewCommand("activity", builder),
+			reportbenchmark.NewCommand("benchmark", builder),
+		},
+	}
+}
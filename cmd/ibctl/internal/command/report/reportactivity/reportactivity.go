@@ -0,0 +1,281 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package reportactivity implements the "report activity" command.
+package reportactivity
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlactivity"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// formatFlagName is the flag name for the output format.
+	formatFlagName = "format"
+	// downloadFlagName is the flag name for downloading fresh data before displaying.
+	downloadFlagName = "download"
+	// yearFlagName is the flag name for the year to report on.
+	yearFlagName = "year"
+	// dailyFlagName is the flag name for the GitHub-style daily heatmap.
+	dailyFlagName = "daily"
+	// metricFlagName is the flag name for the metric the heatmap is shaded by.
+	metricFlagName = "metric"
+)
+
+// NewCommand returns a new report activity command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Display a heatmap of trading activity in a year",
+		Long: `Display a heatmap of trading activity in a year.
+
+By default, there is one row per month of --year (defaulting to the current
+year), with one cell per day of the month, followed by the number of trades
+and the traded notional of the month. With --daily, the heatmap is GitHub-style
+instead, with one row per weekday and one column per week.
+
+Cells are shaded relative to the busiest day of the year, by the number of
+trades, or with --metric notional, by the traded notional: the absolute value
+of the trade proceeds in USD. Trades in currencies without an FX rate are
+counted, but not included in the notional.
+
+A heatmap helps to review activity patterns, and to confirm that the data is
+complete: a month without trades where trades were expected usually means a
+missing statement.
+
+The csv and json formats have one record per month, or with --daily, one
+record per day with trades.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Format is the output format (table, csv, json).
+	Format string
+	// Download fetches fresh data before displaying.
+	Download bool
+	// Group restricts trades to the accounts in a configured account group.
+	Group string
+	// Year is the year to report on, or 0 for the current year.
+	Year int
+	// Daily displays a GitHub-style heatmap with one cell per day.
+	Daily bool
+	// Metric is the metric the heatmap is shaded by (trades, notional).
+	Metric string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+	flagSet.BoolVar(&f.Download, downloadFlagName, false, "Download fresh data before displaying")
+	flagSet.StringVar(&f.Group, ibctlcmd.GroupFlagName, "", "Only include accounts in this account group from ibctl.yaml")
+	flagSet.IntVar(&f.Year, yearFlagName, 0, "The year to report on (defaults to the current year)")
+	flagSet.BoolVar(&f.Daily, dailyFlagName, false, "Display a GitHub-style heatmap with one row per weekday")
+	flagSet.StringVar(&f.Metric, metricFlagName, ibctlactivity.MetricTrades, "The metric to shade the heatmap by (trades, notional)")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) error {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	if flags.Year < 0 {
+		return appcmd.NewInvalidArgumentErrorf("--%s must be positive", yearFlagName)
+	}
+	if !slices.Contains(ibctlactivity.Metrics, flags.Metric) {
+		return appcmd.NewInvalidArgumentErrorf("--%s must be one of: %s", metricFlagName, strings.Join(ibctlactivity.Metrics, ", "))
+	}
+	year := flags.Year
+	if year == 0 {
+		year = time.Now().Year()
+	}
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	// Download fresh data if --download is set.
+	if flags.Download {
+		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir, "")
+		if err != nil {
+			return err
+		}
+		if err := downloader.Download(ctx); err != nil {
+			return err
+		}
+	}
+	// Merge trade data from all sources.
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+		ibctlmerge.MergeWithTrades(),
+	)
+	if err != nil {
+		return err
+	}
+	// Restrict to the accounts in --group if set.
+	_, mergedData, err = ibctlcmd.ApplyGroup(config, mergedData, flags.Group)
+	if err != nil {
+		return err
+	}
+	fxStore := ibctlfxrates.NewStore(ibctlpath.CacheFXDirPath(config.DirPath))
+	activity, err := ibctlactivity.GetActivity(mergedData.Trades, year, fxStore)
+	if err != nil {
+		return err
+	}
+	periodActivities := activity.Months
+	if flags.Daily {
+		periodActivities = nil
+		for _, day := range activity.Days {
+			if day.Trades > 0 {
+				periodActivities = append(periodActivities, day)
+			}
+		}
+	}
+	writer := container.Stdout()
+	switch format {
+	case cliio.FormatTable:
+		if flags.Daily {
+			return writeDailyHeatmap(writer, activity, flags.Metric)
+		}
+		return writeMonthlyHeatmap(writer, activity, flags.Metric)
+	case cliio.FormatCSV:
+		records := make([][]string, 0, len(periodActivities)+1)
+		records = append(records, ibctlactivity.PeriodActivityHeaders())
+		for _, periodActivity := range periodActivities {
+			records = append(records, ibctlactivity.PeriodActivityToRow(periodActivity))
+		}
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, periodActivities...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
+
+// writeMonthlyHeatmap writes a heatmap with one row per month and one cell per
+// day of the month, annotated with the trades and notional of the month.
+func writeMonthlyHeatmap(writer io.Writer, activity *ibctlactivity.Activity, metric string) error {
+	var tradesWidth int
+	var notionalWidth int
+	for _, month := range activity.Months {
+		tradesWidth = max(tradesWidth, len(strconv.Itoa(month.Trades)))
+		notionalWidth = max(notionalWidth, len(cliio.FormatUSDMicros(month.NotionalUSDMicros())))
+	}
+	rows := make([]cliio.HeatmapRow, 0, len(activity.Months))
+	var dayIndex int
+	for i, month := range activity.Months {
+		daysInMonth := time.Date(activity.Year, time.Month(i+2), 0, 0, 0, 0, 0, time.UTC).Day()
+		cells := make([]float64, 0, daysInMonth)
+		for _, day := range activity.Days[dayIndex : dayIndex+daysInMonth] {
+			cells = append(cells, day.Value(metric))
+		}
+		dayIndex += daysInMonth
+		rows = append(rows, cliio.HeatmapRow{
+			Label: time.Month(i + 1).String()[:3],
+			Cells: cells,
+			Annotation: fmt.Sprintf(
+				"%*d %-6s  %*s",
+				tradesWidth,
+				month.Trades,
+				pluralTrades(month.Trades),
+				notionalWidth,
+				cliio.FormatUSDMicros(month.NotionalUSDMicros()),
+			),
+		})
+	}
+	// Label the days of the month every five days.
+	header := []rune(strings.Repeat(" ", 31))
+	for _, day := range []int{1, 5, 10, 15, 20, 25, 30} {
+		copy(header[day-1:], []rune(strconv.Itoa(day)))
+	}
+	if err := cliio.WriteHeatmap(writer, string(header), rows); err != nil {
+		return err
+	}
+	return writeTotal(writer, activity)
+}
+
+// writeDailyHeatmap writes a GitHub-style heatmap with one row per weekday,
+// starting on Monday, and one column per week.
+func writeDailyHeatmap(writer io.Writer, activity *ibctlactivity.Activity, metric string) error {
+	firstDay := time.Date(activity.Year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	// The number of days of the first week before January 1.
+	offset := (int(firstDay.Weekday()) + 6) % 7
+	weeks := (offset + len(activity.Days) + 6) / 7
+	rows := make([]cliio.HeatmapRow, 7)
+	for weekday := range rows {
+		rows[weekday].Label = time.Weekday((weekday + 1) % 7).String()[:3]
+		rows[weekday].Cells = make([]float64, weeks)
+		for week := range weeks {
+			rows[weekday].Cells[week] = -1
+		}
+	}
+	header := []rune(strings.Repeat(" ", weeks+3))
+	lastLabelEnd := 0
+	for i, day := range activity.Days {
+		week := (offset + i) / 7
+		rows[(offset+i)%7].Cells[week] = day.Value(metric)
+		// Label each month above the week of its first day, if there is room.
+		date := firstDay.AddDate(0, 0, i)
+		if date.Day() == 1 && week >= lastLabelEnd {
+			label := date.Month().String()[:3]
+			copy(header[week:], []rune(label))
+			lastLabelEnd = week + len(label) + 1
+		}
+	}
+	if err := cliio.WriteHeatmap(writer, string(header), rows); err != nil {
+		return err
+	}
+	return writeTotal(writer, activity)
+}
+
+// pluralTrades returns "trade" or "trades" for the number of trades.
+func pluralTrades(trades int) string {
+	if trades == 1 {
+		return "trade"
+	}
+	return "trades"
+}
+
+// writeTotal writes the trades and notional of the year.
+func writeTotal(writer io.Writer, activity *ibctlactivity.Activity) error {
+	_, err := fmt.Fprintf(writer, "\n%d: %d %s, %s notional\n", activity.Year, activity.Trades, pluralTrades(activity.Trades), cliio.FormatUSD(activity.NotionalUSD))
+	return err
+}
@@ -0,0 +1,220 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package reportbenchmark implements the "report benchmark" command.
+package reportbenchmark
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlholdings"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// formatFlagName is the flag name for the output format.
+	formatFlagName = "format"
+	// downloadFlagName is the flag name for downloading fresh data before displaying.
+	downloadFlagName = "download"
+	// benchmarkFlagName is the flag name for the benchmark to compare to.
+	benchmarkFlagName = "benchmark"
+	// byFlagName is the flag name for the classification to compare by.
+	byFlagName = "by"
+)
+
+// NewCommand returns a new report benchmark command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Compare portfolio weights to a benchmark",
+		Long: `Compare portfolio weights to a benchmark.
+
+Holdings are aggregated by sector as in "ibctl holding category list --by sector",
+including look-through weights, and each sector's percentage of the portfolio
+is compared to its percentage of the benchmark from the benchmarks section of
+ibctl.yaml. Use --by to compare by another classification (category, type, or
+geo) that the benchmark has weights for.
+
+OVER/UNDER % is the portfolio percentage minus the benchmark percentage, so
+positive values are overweight and negative values are underweight. OVER/UNDER
+USD is the market value above or below the benchmark weight of the portfolio
+value, which is the amount to sell or buy to match the benchmark.
+
+Classification values only in the portfolio, such as cash, have a benchmark
+weight of 0. Use --group to compare only the accounts in an account group.
+
+--benchmark selects the benchmark by name, and can be omitted if only one
+benchmark is configured.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Format is the output format (table, csv, json).
+	Format string
+	// Benchmark is the name of the benchmark in ibctl.yaml to compare to.
+	Benchmark string
+	// By is the classification to compare by (category, type, sector, geo).
+	By string
+	// Download fetches fresh data before displaying.
+	Download bool
+	// Group restricts holdings to the accounts in a configured account group.
+	Group string
+	// MaxAge fails if the position snapshot is older than this, or is 0 for no limit.
+	MaxAge time.Duration
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+	flagSet.StringVar(&f.Benchmark, benchmarkFlagName, "", "The benchmark from ibctl.yaml to compare to (defaults to the only benchmark)")
+	flagSet.StringVar(&f.By, byFlagName, string(ibctlholdings.ClassificationSector), "Classification to compare by (category, type, sector, geo)")
+	flagSet.BoolVar(&f.Download, downloadFlagName, false, "Download fresh data before displaying")
+	flagSet.StringVar(&f.Group, ibctlcmd.GroupFlagName, "", "Only include accounts in this account group from ibctl.yaml")
+	flagSet.DurationVar(&f.MaxAge, ibctlcmd.MaxAgeFlagName, 0, "Fail if the position snapshot was downloaded longer ago than this (e.g., 24h)")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) error {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	classification, err := ibctlholdings.ParseClassification(flags.By)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	benchmarkName, err := getBenchmarkName(config, flags.Benchmark)
+	if err != nil {
+		return err
+	}
+	benchmarkWeights := classification.BenchmarkWeights(config.Benchmarks[benchmarkName])
+	if benchmarkWeights == nil {
+		return appcmd.NewInvalidArgumentErrorf("benchmark %q has no %s weights in ibctl.yaml", benchmarkName, classification)
+	}
+	// Download fresh data if --download is set.
+	if flags.Download {
+		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir, "")
+		if err != nil {
+			return err
+		}
+		if err := downloader.Download(ctx); err != nil {
+			return err
+		}
+	}
+	// Merge trade data from all sources.
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+	)
+	if err != nil {
+		return err
+	}
+	// Restrict to the accounts in --group if set.
+	config, mergedData, err = ibctlcmd.ApplyGroup(config, mergedData, flags.Group)
+	if err != nil {
+		return err
+	}
+	// Warn about, or with --max-age fail on, a stale position snapshot.
+	if err := ibctlcmd.CheckSnapshotAge(container, config, flags.Group, flags.MaxAge); err != nil {
+		return err
+	}
+	// Load FX rates for USD conversion.
+	fxStore := ibctlfxrates.NewStore(ibctlpath.CacheFXDirPath(config.DirPath))
+	// Compute holdings via FIFO from all trade data.
+	result, err := ibctlholdings.GetHoldingsOverview(mergedData.Trades, mergedData.Positions, mergedData.CashPositions, config, fxStore)
+	if err != nil {
+		return err
+	}
+	// Aggregate holdings by the classification and compare to the benchmark.
+	categories := ibctlholdings.GetClassificationList(result.Holdings, classification, config.Lookthroughs)
+	comparisons, totals := ibctlholdings.GetBenchmarkComparison(categories, benchmarkWeights)
+	// Name the first column after the classification.
+	headers := ibctlholdings.BenchmarkComparisonHeaders()
+	headers[0] = strings.ToUpper(string(classification))
+	// Write output in the requested format.
+	writer := container.Stdout()
+	switch format {
+	case cliio.FormatTable:
+		rows := make([][]string, 0, len(comparisons))
+		for _, c := range comparisons {
+			rows = append(rows, ibctlholdings.BenchmarkComparisonToTableRow(c))
+		}
+		return cliio.WriteTableWithTotals(writer, headers, rows, ibctlholdings.BenchmarkComparisonToTableRow(totals))
+	case cliio.FormatCSV:
+		records := make([][]string, 0, len(comparisons)+2)
+		records = append(records, headers)
+		for _, c := range comparisons {
+			records = append(records, ibctlholdings.BenchmarkComparisonToRow(c))
+		}
+		records = append(records, ibctlholdings.BenchmarkComparisonToRow(totals))
+		return cliio.WriteCSVRecords(writer, records)
+	case cliio.FormatJSON:
+		return cliio.WriteJSONWithSummary(writer, xtime.TimeToDate(time.Now()).String(), totals, comparisons...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
+
+// getBenchmarkName returns the name of the benchmark to compare to, which is
+// the only configured benchmark if name is empty.
+func getBenchmarkName(config *ibctlconfig.Config, name string) (string, error) {
+	if name != "" {
+		if _, ok := config.Benchmarks[name]; !ok {
+			return "", appcmd.NewInvalidArgumentErrorf("unknown benchmark %q, add it to the benchmarks section of ibctl.yaml", name)
+		}
+		return name, nil
+	}
+	names := make([]string, 0, len(config.Benchmarks))
+	for benchmarkName := range config.Benchmarks {
+		names = append(names, benchmarkName)
+	}
+	slices.Sort(names)
+	switch len(names) {
+	case 0:
+		return "", fmt.Errorf("no benchmarks configured, add one to the benchmarks section of ibctl.yaml")
+	case 1:
+		return names[0], nil
+	default:
+		return "", appcmd.NewInvalidArgumentErrorf("--%s is required with multiple benchmarks, one of: %s", benchmarkFlagName, strings.Join(names, ", "))
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package selfupdate implements the "self-update" command.
+package selfupdate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlversion"
+	"github.com/bufdev/ibctl/internal/pkg/githubrelease"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// checkFlagName is the flag name for only checking whether an update is available.
+	checkFlagName = "check"
+	// versionFlagName is the flag name for the release version to install.
+	versionFlagName = "version"
+)
+
+const (
+	// releaseOwner is the GitHub owner of the ibctl repository.
+	releaseOwner = "bufdev"
+	// releaseRepo is the GitHub name of the ibctl repository.
+	releaseRepo = "ibctl"
+	// checksumsAssetName is the name of the sha256sum-format checksums file attached to each release.
+	checksumsAssetName = "sha256sums.txt"
+)
+
+// NewCommand returns a new self-update command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Update ibctl to the latest GitHub release",
+		Long: `Update ibctl to the latest GitHub release.
+
+Downloads the ibctl-<os>-<arch> binary from the latest release of
+github.com/bufdev/ibctl, verifies its SHA-256 checksum against the release's
+sha256sums.txt, and atomically replaces the running binary. The data format
+and converters change frequently, so keeping ibctl current avoids reading
+data written by a newer version.
+
+Use --check to only report whether a newer release is available, and
+--version to install a specific release (including an older one). Builds of
+a version other than vMAJOR.MINOR.PATCH (such as "dev" or a Go pseudo-version
+from "go install") are always updated.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Check only reports whether an update is available.
+	Check bool
+	// Version is the release version to install, or empty for the latest.
+	Version string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	flagSet.BoolVar(&f.Check, checkFlagName, false, "Only check whether a newer release is available")
+	flagSet.StringVar(&f.Version, versionFlagName, "", "The release version to install (e.g., v1.2.3), defaults to the latest")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) error {
+	info := ibctlversion.Get()
+	httpClient, err := ibctlcmd.NewHTTPClient(container, nil)
+	if err != nil {
+		return err
+	}
+	client := githubrelease.NewClient(httpClient)
+	var release *githubrelease.Release
+	if flags.Version != "" {
+		if !ibctlversion.IsReleaseVersion(flags.Version) {
+			return appcmd.NewInvalidArgumentErrorf("invalid --%s %q, must be vMAJOR.MINOR.PATCH", versionFlagName, flags.Version)
+		}
+		release, err = client.GetRelease(ctx, releaseOwner, releaseRepo, flags.Version)
+	} else {
+		release, err = client.GetLatestRelease(ctx, releaseOwner, releaseRepo)
+	}
+	if err != nil {
+		return fmt.Errorf("fetching release: %w", err)
+	}
+	// Without --version, only update to a newer release.
+	if comparison, ok := ibctlversion.CompareVersions(info.Version, release.TagName); ok {
+		if comparison == 0 || (comparison > 0 && flags.Version == "") {
+			_, err := fmt.Fprintf(container.Stdout(), "ibctl %s is up to date\n", info.Version)
+			return err
+		}
+	}
+	if flags.Check {
+		_, err := fmt.Fprintf(container.Stdout(), "ibctl %s is available (current: %s)\n", release.TagName, info.Version)
+		return err
+	}
+	assetName := fmt.Sprintf("ibctl-%s-%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		assetName += ".exe"
+	}
+	binaryAsset := release.Asset(assetName)
+	if binaryAsset == nil {
+		return fmt.Errorf("release %s has no %s binary", release.TagName, assetName)
+	}
+	checksumsAsset := release.Asset(checksumsAssetName)
+	if checksumsAsset == nil {
+		return fmt.Errorf("release %s has no %s, refusing to install an unverified binary", release.TagName, checksumsAssetName)
+	}
+	checksumsData, err := client.DownloadAsset(ctx, checksumsAsset)
+	if err != nil {
+		return fmt.Errorf("downloading %s: %w", checksumsAssetName, err)
+	}
+	checksums, err := githubrelease.ParseChecksums(checksumsData)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", checksumsAssetName, err)
+	}
+	container.Logger().Info("downloading release", "version", release.TagName, "asset", assetName)
+	binaryData, err := client.DownloadAsset(ctx, binaryAsset)
+	if err != nil {
+		return fmt.Errorf("downloading %s: %w", assetName, err)
+	}
+	if err := githubrelease.VerifyChecksum(checksums, assetName, binaryData); err != nil {
+		return err
+	}
+	executablePath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locating the running binary: %w", err)
+	}
+	if executablePath, err = filepath.EvalSymlinks(executablePath); err != nil {
+		return fmt.Errorf("locating the running binary: %w", err)
+	}
+	if err := replaceFile(executablePath, binaryData); err != nil {
+		return fmt.Errorf("replacing %s: %w", executablePath, err)
+	}
+	_, err = fmt.Fprintf(container.Stdout(), "updated ibctl from %s to %s\n", info.Version, release.TagName)
+	return err
+}
+
+// replaceFile atomically replaces the file with an executable file with the
+// data, by writing a temporary file in the same directory and renaming it over
+// the file. The running binary keeps executing from the replaced file.
+func replaceFile(filePath string, data []byte) (retErr error) {
+	file, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tempFilePath := file.Name()
+	defer func() {
+		// Clean up the temporary file if anything failed before the rename.
+		if retErr != nil {
+			_ = os.Remove(tempFilePath)
+		}
+	}()
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tempFilePath, 0o755); err != nil {
+		return err
+	}
+	return os.Rename(tempFilePath, filePath)
+}
@@ -0,0 +1,177 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package serve implements the "serve" command.
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlserve"
+	"github.com/bufdev/ibctl/internal/pkg/connecthttp"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// addressFlagName is the flag name for the listen address.
+	addressFlagName = "address"
+	// grpcFlagName is the flag name for also accepting gRPC clients.
+	grpcFlagName = "grpc"
+	// shutdownTimeout is how long in-flight requests have to finish on shutdown.
+	shutdownTimeout = 10 * time.Second
+)
+
+// NewCommand returns a new serve command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Serve the ibctl API over HTTP",
+		Long: `Serve the ibctl API over HTTP.
+
+The ibctl.service.v1.IbctlService API (proto/ibctl/service/v1/service.proto)
+is served with the Connect protocol, which any Connect client, or curl, can
+call with JSON or binary protobuf:
+
+  curl -H 'Content-Type: application/json' -d '{}' \
+    http://localhost:8080/ibctl.service.v1.IbctlService/GetHoldings
+
+With --grpc, gRPC clients are also accepted, using unencrypted HTTP/2.
+
+Prometheus metrics are served at /metrics: API request and download counts,
+download durations, FX rate cache lookups, merged trade counts per account,
+and the total market value and unrealized P&L of all holdings in USD. The
+portfolio gauges are computed from the data on disk on every scrape.
+
+Every request reads the data on disk, so the output of a Download RPC or a
+separate "ibctl download" is reflected in the next request. The server has no
+authentication and listens on localhost by default.`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the ibctl directory containing ibctl.yaml.
+	Dir string
+	// Address is the address to listen on.
+	Address string
+	// GRPC accepts gRPC clients over unencrypted HTTP/2.
+	GRPC bool
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Address, addressFlagName, "localhost:8080", "The address to listen on")
+	flagSet.BoolVar(&f.GRPC, grpcFlagName, false, "Also accept gRPC clients over unencrypted HTTP/2")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) error {
+	// Read the config up front so that configuration errors fail fast and
+	// encryption is configured once for the process.
+	if _, err := ibctlcmd.ReadConfig(container, flags.Dir); err != nil {
+		return err
+	}
+	// Downloads write the data directory and merges write the merged data
+	// cache, so requests use the data directory one at a time.
+	var lock sync.Mutex
+	download := func(ctx context.Context) error {
+		lock.Lock()
+		defer lock.Unlock()
+		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir, "")
+		if err != nil {
+			return err
+		}
+		return downloader.Download(ctx)
+	}
+	loadFunc := func(_ context.Context, group string) (*ibctlconfig.Config, *ibctlmerge.MergedData, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		return load(flags.Dir, group)
+	}
+	server := &http.Server{
+		Handler:           ibctlserve.NewHandler(download, loadFunc),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if flags.GRPC {
+		server.Protocols = new(http.Protocols)
+		server.Protocols.SetHTTP1(true)
+		server.Protocols.SetUnencryptedHTTP2(true)
+	}
+	listener, err := net.Listen("tcp", flags.Address)
+	if err != nil {
+		return err
+	}
+	container.Logger().Info("serving", "address", listener.Addr().String(), "grpc", flags.GRPC)
+	// Shut down gracefully when the command is interrupted.
+	serveErrC := make(chan error, 1)
+	go func() {
+		serveErrC <- server.Serve(listener)
+	}()
+	select {
+	case err := <-serveErrC:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-serveErrC; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// load reads the config and merged data, restricted to the accounts in group if set.
+func load(dirPath string, group string) (*ibctlconfig.Config, *ibctlmerge.MergedData, error) {
+	config, err := ibctlconfig.ReadConfig(dirPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, ok := config.Groups[group]; group != "" && !ok {
+		return nil, nil, connecthttp.NewError(
+			connecthttp.CodeInvalidArgument,
+			fmt.Errorf("unknown group %q, groups are defined in the groups section of %s", group, ibctlpath.ConfigFileName),
+		)
+	}
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ibctlcmd.ApplyGroup(config, mergedData, group)
+}
@@ -0,0 +1,183 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package tradelist implements the "trade list" command.
+package tradelist
+
+import (
+	"context"
+	"os"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlnotes"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctltrade"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// formatFlagName is the flag name for the output format.
+	formatFlagName = "format"
+	// downloadFlagName is the flag name for downloading fresh data before displaying.
+	downloadFlagName = "download"
+	// orderFlagName is the flag name for filtering by order ID.
+	orderFlagName = "order"
+	// symbolFlagName is the flag name for filtering by symbol.
+	symbolFlagName = "symbol"
+	// byOrderFlagName is the flag name for combining the legs of each order into one row.
+	byOrderFlagName = "by-order"
+	// tagFlagName is the flag name for filtering by tag.
+	tagFlagName = "tag"
+)
+
+// NewCommand returns a new trade list command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "List trades, grouping the legs of multi-leg and combo orders",
+		Long: `List trades, grouping the legs of multi-leg and combo orders.
+
+IBKR reports each leg of an option spread or combo order as a separate trade.
+Legs that share an IBKR order ID are listed together, and --by-order combines
+them into a single row per order with net proceeds. Filtering with --symbol
+keeps every leg of the matching orders.
+
+Order IDs come from the Flex Query ibOrderID field; trades from Activity
+Statement CSVs and other sources have no order ID and are listed on their own.
+
+Tags and notes come from data/notes/<alias>/notes.yaml, where trades are keyed
+by trade ID and symbols by symbol. --tag keeps the trades with a matching tag,
+where "thesis" also matches "thesis:ai".`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// Dir is the base directory containing ibctl.yaml and data subdirectories.
+	Dir string
+	// Format is the output format (table, csv, json).
+	Format string
+	// Download fetches fresh data before displaying.
+	Download bool
+	// Group restricts the list to the accounts in a configured account group.
+	Group string
+	// Order restricts the list to the legs of an order.
+	Order string
+	// Symbol restricts the list to orders with a leg in the symbol.
+	Symbol string
+	// ByOrder combines the legs of each order into one row.
+	ByOrder bool
+	// Tag restricts the list to trades with a matching tag.
+	Tag string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	ibctlcmd.BindDirFlags(flagSet, &f.Dir)
+	flagSet.StringVar(&f.Format, formatFlagName, "table", "Output format (table, csv, json)")
+	flagSet.BoolVar(&f.Download, downloadFlagName, false, "Download fresh data before displaying")
+	flagSet.StringVar(&f.Group, ibctlcmd.GroupFlagName, "", "Only include accounts in this account group from ibctl.yaml")
+	flagSet.StringVar(&f.Order, orderFlagName, "", "Only list the legs of this IBKR order ID")
+	flagSet.StringVar(&f.Symbol, symbolFlagName, "", "Only list orders with a leg in this symbol")
+	flagSet.BoolVar(&f.ByOrder, byOrderFlagName, false, "Combine the legs of each order into one row")
+	flagSet.StringVar(&f.Tag, tagFlagName, "", "Only list trades with this tag from the notes")
+}
+
+func run(ctx context.Context, container appext.Container, flags *flags) error {
+	format, err := cliio.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	// Read and validate the configuration file from the base directory.
+	config, err := ibctlcmd.ReadConfig(container, flags.Dir)
+	if err != nil {
+		return err
+	}
+	// Download fresh data if --download is set.
+	if flags.Download {
+		downloader, err := ibctlcmd.NewDownloader(container, flags.Dir, "")
+		if err != nil {
+			return err
+		}
+		if err := downloader.Download(ctx); err != nil {
+			return err
+		}
+	}
+	// Merge trade data from all sources.
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+		ibctlmerge.MergeWithTrades(),
+	)
+	if err != nil {
+		return err
+	}
+	// Restrict to the accounts in --group if set.
+	_, mergedData, err = ibctlcmd.ApplyGroup(config, mergedData, flags.Group)
+	if err != nil {
+		return err
+	}
+	// Read the trade and symbol notes, and restrict to --tag if set.
+	notes, err := ibctlnotes.ReadNotes(ibctlpath.DataNotesDirPath(config.DirPath), config.AccountAliases)
+	if err != nil {
+		return err
+	}
+	trades := mergedData.Trades
+	if flags.Tag != "" {
+		trades = notes.FilterTrades(trades, flags.Tag)
+	}
+	// Build the header and rows for either trades or orders.
+	var headers []string
+	var rows [][]string
+	var objects []any
+	if flags.ByOrder {
+		headers = ibctltrade.OrderListHeaders()
+		for _, o := range ibctltrade.GetOrderList(trades, flags.Order, flags.Symbol) {
+			rows = append(rows, ibctltrade.OrderOverviewToRow(o))
+			objects = append(objects, o)
+		}
+	} else {
+		headers = ibctltrade.TradeListHeaders()
+		tradeOverviews := ibctltrade.GetTradeList(trades, flags.Order, flags.Symbol)
+		ibctltrade.AnnotateTrades(tradeOverviews, notes)
+		for _, t := range tradeOverviews {
+			rows = append(rows, ibctltrade.TradeOverviewToRow(t))
+			objects = append(objects, t)
+		}
+	}
+	// Write output in the requested format.
+	writer := os.Stdout
+	switch format {
+	case cliio.FormatTable:
+		return cliio.WriteTable(writer, headers, rows)
+	case cliio.FormatCSV:
+		return cliio.WriteCSVRecords(writer, append([][]string{headers}, rows...))
+	case cliio.FormatJSON:
+		return cliio.WriteJSON(writer, objects...)
+	default:
+		return appcmd.NewInvalidArgumentErrorf("unsupported format: %s", format)
+	}
+}
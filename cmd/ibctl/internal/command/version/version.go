@@ -0,0 +1,77 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package version implements the "version" command.
+package version
+
+import (
+	"context"
+	"fmt"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlversion"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/spf13/pflag"
+)
+
+// jsonFlagName is the flag name for JSON output.
+const jsonFlagName = "json"
+
+// NewCommand returns a new version command.
+func NewCommand(name string, builder appext.SubCommandBuilder) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Print the version and build metadata",
+		Long: `Print the version and build metadata.
+
+Prints the release version, the git commit and date the binary was built
+from, and the Go version. Builds without release metadata print the version,
+commit, and date embedded by the Go toolchain if available, or "dev".`,
+		Args: appcmd.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	// JSON prints the build metadata as JSON.
+	JSON bool
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+// Bind registers the flag definitions with the given flag set.
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	flagSet.BoolVar(&f.JSON, jsonFlagName, false, "Print the build metadata as JSON")
+}
+
+func run(container appext.Container, flags *flags) error {
+	info := ibctlversion.Get()
+	if flags.JSON {
+		return cliio.WriteJSON(container.Stdout(), info)
+	}
+	if _, err := fmt.Fprintf(container.Stdout(), "version: %s\n", info.Version); err != nil {
+		return err
+	}
+	if info.Commit != "" {
+		if _, err := fmt.Fprintf(container.Stdout(), "commit: %s\n", info.Commit); err != nil {
+			return err
+		}
+	}
+	if info.Date != "" {
+		if _, err := fmt.Fprintf(container.Stdout(), "date: %s\n", info.Date); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(container.Stdout(), "go: %s\n", info.GoVersion)
+	return err
+}
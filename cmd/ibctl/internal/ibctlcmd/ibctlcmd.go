@@ -6,41 +6,992 @@
 package ibctlcmd
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
+	"buf.build/go/app"
+	"buf.build/go/app/appcmd"
 	"buf.build/go/app/appext"
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctldownload"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmigrate"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlprices"
 	"github.com/bufdev/ibctl/internal/pkg/bankofcanada"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/bufdev/ibctl/internal/pkg/cryptobox"
+	"github.com/bufdev/ibctl/internal/pkg/filelock"
+	"github.com/bufdev/ibctl/internal/pkg/filemode"
 	"github.com/bufdev/ibctl/internal/pkg/frankfurter"
+	"github.com/bufdev/ibctl/internal/pkg/httpclient"
 	"github.com/bufdev/ibctl/internal/pkg/ibkrflexquery"
+	"github.com/bufdev/ibctl/internal/pkg/pricestore"
+	"github.com/bufdev/ibctl/internal/pkg/protoio"
+	"github.com/bufdev/ibctl/internal/pkg/timing"
+	"github.com/bufdev/ibctl/internal/pkg/yahoofinance"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+	"github.com/spf13/pflag"
 )
 
 const (
 	// DirFlagName is the flag name for the base directory path.
 	DirFlagName = "dir"
+	// WorkspaceFlagName is the flag name for selecting the base directory by workspace name.
+	WorkspaceFlagName = "workspace"
+	// GroupFlagName is the flag name for restricting holdings to an account group.
+	GroupFlagName = "group"
+	// ColorFlagName is the flag name for when to color table output.
+	ColorFlagName = "color"
+	// PagerFlagName is the flag name for piping table output into the pager.
+	PagerFlagName = "pager"
+	// RedactFlagName is the flag name for replacing account identifiers in exports.
+	RedactFlagName = "redact"
+	// AsOfFlagName is the flag name for computing holdings as of a historical date.
+	AsOfFlagName = "as-of"
+	// FXAuditFlagName is the flag name for annotating converted values with the FX rate used.
+	FXAuditFlagName = "fx-audit"
+	// PeriodFlagName is the flag name for overriding the Flex Query period with a preset.
+	PeriodFlagName = "period"
+	// DebugHTTPFlagName is the flag name for tracing all API requests to a file under cache/debug/.
+	DebugHTTPFlagName = "debug-http"
+	// DebugHTTPBodyFlagName is the flag name for including response body snippets in HTTP traces.
+	DebugHTTPBodyFlagName = "debug-http-body"
+	// ReplayFlagName is the flag name for replaying a saved Flex Query XML response.
+	ReplayFlagName = "replay"
+	// MaxAgeFlagName is the flag name for failing when the position snapshot is older than a duration.
+	MaxAgeFlagName = "max-age"
+	// ProfileFlagName is the flag name for reporting the time spent in each phase of a command.
+	ProfileFlagName = "profile"
+	// CPUProfileFlagName is the flag name for writing a pprof CPU profile of a command.
+	CPUProfileFlagName = "cpu-profile"
+	// MemProfileFlagName is the flag name for writing a pprof heap profile of a command.
+	MemProfileFlagName = "mem-profile"
+	// ReadOnlyFlagName is the flag name for refusing all writes to the ibctl directory.
+	ReadOnlyFlagName = "readonly"
+	// WaitFlagName is the flag name for waiting for the lock on the ibctl directory instead of failing.
+	WaitFlagName = "wait"
+	// DirEnvVar is the environment variable name for the default base directory path.
+	DirEnvVar = "IBCTL_DIR"
+	// FlexReplayEnvVar is the environment variable name for replaying a saved Flex Query XML response.
+	FlexReplayEnvVar = "IBCTL_FLEX_REPLAY"
+	// EncryptionKeyEnvVar is the environment variable name for the base64-encoded encryption key.
+	EncryptionKeyEnvVar = "IBCTL_ENCRYPTION_KEY"
+	// ProxyURLEnvVar is the environment variable name for the proxy URL of all API clients.
+	ProxyURLEnvVar = "IBCTL_PROXY_URL"
+	// CABundleEnvVar is the environment variable name for the CA bundle of all API clients.
+	CABundleEnvVar = "IBCTL_CA_BUNDLE"
+	// InsecureSkipVerifyEnvVar is the environment variable name for disabling TLS certificate verification.
+	InsecureSkipVerifyEnvVar = "IBCTL_INSECURE_SKIP_VERIFY"
+	// encryptionKeyKeychainService is the macOS keychain service name for the encryption key.
+	encryptionKeyKeychainService = "ibctl-encryption-key"
+	// pagerEnvVar is the environment variable name for the pager command.
+	pagerEnvVar = "PAGER"
+	// defaultPager is the pager command if PAGER is not set. -R passes colors
+	// through and -S scrolls wide tables horizontally instead of wrapping them.
+	defaultPager = "less -RS"
+	// httpTraceBodyBytes is the number of response body bytes included in HTTP traces.
+	httpTraceBodyBytes = 2048
 	// ibkrFlexWebServiceTokenEnvVar is the environment variable name for the IBKR Flex Web Service token.
 	ibkrFlexWebServiceTokenEnvVar = "IBKR_FLEX_WEB_SERVICE_TOKEN"
 )
 
+// Exit codes for errors that scripts need to distinguish. Other errors exit with 1.
+const (
+	// ExitCodeTokenInvalid is the exit code when IBKR rejects the Flex Web Service token.
+	ExitCodeTokenInvalid = 3
+	// ExitCodeQueryMissingSection is the exit code when the Flex Query does not include a required section.
+	ExitCodeQueryMissingSection = 4
+	// ExitCodeRateLimited is the exit code when IBKR rate limits the Flex Web Service token.
+	ExitCodeRateLimited = 5
+	// ExitCodeStaleCache is the exit code when the data must be migrated before it is read.
+	ExitCodeStaleCache = 6
+	// ExitCodeLocked is the exit code when another ibctl process holds the lock on the ibctl directory.
+	ExitCodeLocked = 7
+)
+
+// readOnly is the value of the --readonly flag.
+var readOnly bool
+
+// BindReadOnlyFlag registers the --readonly flag with the given flag set. If
+// set, every ibctl directory read with ReadConfig is read-only, as if readonly
+// were set in its ibctl.yaml.
+func BindReadOnlyFlag(flagSet *pflag.FlagSet) {
+	flagSet.BoolVar(&readOnly, ReadOnlyFlagName, false, "Do not write anything to the ibctl directory (downloads and data changes fail)")
+}
+
+// ErrorInterceptor is an appext.Interceptor that maps the errors that scripts
+// need to distinguish to their exit codes, and appends a hint on how to fix
+// the problem to the error message.
+func ErrorInterceptor(next func(context.Context, appext.Container) error) func(context.Context, appext.Container) error {
+	return func(ctx context.Context, container appext.Container) error {
+		err := next(ctx, container)
+		if err == nil {
+			return nil
+		}
+		for _, errorHint := range errorHints {
+			if errors.Is(err, errorHint.err) {
+				return app.WrapError(errorHint.exitCode, fmt.Errorf("%w\nhint: %s", err, errorHint.hint))
+			}
+		}
+		return err
+	}
+}
+
+// ProfileFlags are the root flags for profiling a command.
+type ProfileFlags struct {
+	// Profile reports the time spent in each phase of the command to stderr.
+	Profile bool
+	// CPUProfile is the file to write a pprof CPU profile to, or empty for none.
+	CPUProfile string
+	// MemProfile is the file to write a pprof heap profile to, or empty for none.
+	MemProfile string
+}
+
+// NewProfileFlags returns new ProfileFlags.
+func NewProfileFlags() *ProfileFlags {
+	return &ProfileFlags{}
+}
+
+// Bind registers the profiling flags with the given flag set.
+func (f *ProfileFlags) Bind(flagSet *pflag.FlagSet) {
+	flagSet.BoolVar(&f.Profile, ProfileFlagName, false, "Report the time spent in download, CSV parsing, merge, FIFO, FX loading, and rendering to stderr")
+	flagSet.StringVar(&f.CPUProfile, CPUProfileFlagName, "", "Write a pprof CPU profile of the command to this file")
+	flagSet.StringVar(&f.MemProfile, MemProfileFlagName, "", "Write a pprof heap profile to this file after the command completes")
+}
+
+// Interceptor is an appext.Interceptor that profiles the command according to
+// the flags. The phase report and profiles are written even if the command
+// fails, so slow failures can be diagnosed too.
+func (f *ProfileFlags) Interceptor(next func(context.Context, appext.Container) error) func(context.Context, appext.Container) error {
+	return func(ctx context.Context, container appext.Container) (retErr error) {
+		if f.CPUProfile != "" {
+			file, err := os.Create(f.CPUProfile)
+			if err != nil {
+				return err
+			}
+			if err := pprof.StartCPUProfile(file); err != nil {
+				return errors.Join(err, file.Close())
+			}
+			defer func() {
+				pprof.StopCPUProfile()
+				retErr = errors.Join(retErr, file.Close())
+			}()
+		}
+		if f.Profile {
+			timing.Enable()
+		}
+		start := time.Now()
+		err := next(ctx, container)
+		total := time.Since(start)
+		if f.Profile {
+			err = errors.Join(err, writePhases(container.Stderr(), timing.Phases(), total))
+		}
+		if f.MemProfile != "" {
+			err = errors.Join(err, writeMemProfile(f.MemProfile))
+		}
+		return err
+	}
+}
+
+// writePhases writes a table of the time spent in each phase and the total
+// time of the command.
+func writePhases(writer io.Writer, phases []*timing.Phase, total time.Duration) error {
+	rows := make([][]string, 0, len(phases))
+	for _, phase := range phases {
+		var pct float64
+		if total > 0 {
+			pct = float64(phase.Duration) / float64(total) * 100
+		}
+		rows = append(rows, []string{
+			phase.Name,
+			strconv.Itoa(phase.Calls),
+			phase.Duration.Round(time.Millisecond).String(),
+			fmt.Sprintf("%.1f%%", pct),
+		})
+	}
+	if _, err := fmt.Fprintf(writer, "\n"); err != nil {
+		return err
+	}
+	return cliio.WriteTableWithTotals(
+		writer,
+		[]string{"PHASE", "CALLS", "TIME", "PCT"},
+		rows,
+		[]string{"TOTAL", "", total.Round(time.Millisecond).String(), "100.0%"},
+	)
+}
+
+// writeMemProfile writes a pprof heap profile to the file.
+func writeMemProfile(filePath string) (retErr error) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = errors.Join(retErr, file.Close())
+	}()
+	// Collect garbage first so the profile reflects live memory.
+	runtime.GC()
+	return pprof.WriteHeapProfile(file)
+}
+
+// ReadConfig reads and validates the configuration file from the base directory,
+// checks that the data format version is supported, and configures at-rest
+// encryption and the permissions of data files.
+//
+// If readonly is set in the config or --readonly is set, the ibctl directory
+// is made read-only for the rest of the process.
+//
+// If an encryption key is available, sealed files can always be read. Writes
+// are only sealed if encryption is enabled in the config, in which case the
+// key is required.
+func ReadConfig(container appext.Container, dirPath string) (*ibctlconfig.Config, error) {
+	// Sealed files can be read before the encrypt setting is known, since
+	// reading the config also reads the price overrides file under data/.
+	if err := ConfigureEncryption(container, false); err != nil {
+		return nil, err
+	}
+	config, err := ibctlconfig.ReadConfig(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	if readOnly {
+		config.ReadOnly = true
+	}
+	if config.ReadOnly {
+		if err := filemode.SetReadOnly(config.DirPath); err != nil {
+			return nil, err
+		}
+	}
+	if err := ibctlmigrate.Check(dirPath); err != nil {
+		return nil, err
+	}
+	if err := ConfigureEncryption(container, config.Encrypt); err != nil {
+		return nil, err
+	}
+	filemode.Set(config.FileMode, config.DirMode)
+	return config, nil
+}
+
+// ConfigureEncryption configures protoio encryption. If encryptWrites is true,
+// an encryption key is required and all writes are sealed. Otherwise, a key is
+// used for reading sealed files if one is available.
+func ConfigureEncryption(container appext.Container, encryptWrites bool) error {
+	if !encryptWrites && lookupEncryptionKey(container) == "" {
+		protoio.SetEncryption(nil, false)
+		return nil
+	}
+	key, err := ReadEncryptionKey(container)
+	if err != nil {
+		return err
+	}
+	protoio.SetEncryption(key, encryptWrites)
+	return nil
+}
+
+// ReadEncryptionKey reads and decodes the encryption key from the environment,
+// falling back to the macOS keychain.
+func ReadEncryptionKey(container appext.Container) ([]byte, error) {
+	encodedKey := lookupEncryptionKey(container)
+	if encodedKey == "" {
+		return nil, errors.New(EncryptionKeyEnvVar + " environment variable (or keychain item \"" + encryptionKeyKeychainService + "\") is required, generate a key with \"openssl rand -base64 32\"")
+	}
+	key, err := cryptobox.ParseKey(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", EncryptionKeyEnvVar, err)
+	}
+	return key, nil
+}
+
 // NewDownloader constructs a Downloader by reading the config from the base directory,
 // extracting the IBKR token from the environment, and creating the required API clients.
-func NewDownloader(container appext.Container, dirPath string) (ibctldownload.Downloader, error) {
+//
+// If replayFilePath is set, or the IBCTL_FLEX_REPLAY environment variable is set,
+// Flex Query data is read from the saved XML response instead of the API.
+func NewDownloader(container appext.Container, dirPath string, replayFilePath string) (ibctldownload.Downloader, error) {
 	// Read and validate the configuration file from the base directory.
-	config, err := ibctlconfig.ReadConfig(dirPath)
+	config, err := ReadConfig(container, dirPath)
 	if err != nil {
 		return nil, err
 	}
+	return NewDownloaderForConfig(container, config, replayFilePath)
+}
+
+// NewDownloaderForConfig constructs a Downloader for an already-read config, so
+// that callers can override config options (e.g., strict mode) from flags.
+func NewDownloaderForConfig(container appext.Container, config *ibctlconfig.Config, replayFilePath string) (ibctldownload.Downloader, error) {
+	if config.ReadOnly {
+		return nil, fmt.Errorf("downloads are disabled, %s is read-only (readonly in %s or --%s)", config.DirPath, ibctlpath.ConfigFileName, ReadOnlyFlagName)
+	}
+	httpClient, err := NewHTTPClient(container, config)
+	if err != nil {
+		return nil, err
+	}
+	flexQueryClient, ibkrToken, err := NewFlexQueryClient(container, httpClient, replayFilePath)
+	if err != nil {
+		return nil, err
+	}
+	logins, err := newLogins(container, config, ibkrToken)
+	if err != nil {
+		return nil, err
+	}
+	// Extract the logger from the appext container.
+	logger := container.Logger()
+	// Construct the remaining API clients.
+	fxRateClient := frankfurter.NewClient(httpClient)
+	bocClient := bankofcanada.NewClient(httpClient)
+	priceClient := yahoofinance.NewClient(httpClient)
+	return &lockingDownloader{
+		Downloader: ibctldownload.NewDownloader(logger, logins, config, flexQueryClient, fxRateClient, bocClient, priceClient),
+		container:  container,
+		dirPath:    config.DirPath,
+	}, nil
+}
+
+// LockDir acquires the lock on the ibctl directory, which is held by commands
+// that modify data/ or cache/ so that concurrent commands, such as a scheduled
+// download and a manual one, cannot interleave their writes. If the lock is
+// held by another process, LockDir fails with an error wrapping
+// filelock.ErrLocked, unless wait is set, in which case it waits for the lock.
+//
+// The lock is reentrant within the process, and the returned function releases it.
+func LockDir(ctx context.Context, container appext.Container, dirPath string, wait bool) (func() error, error) {
+	lockFilePath := ibctlpath.LockFilePath(dirPath)
+	// Commands that modify data/ or cache/ lock the directory first, so they
+	// fail here in a read-only directory before writing anything.
+	if err := filemode.CheckWritable(lockFilePath); err != nil {
+		return nil, err
+	}
+	holder := fmt.Sprintf(
+		"pid %d: %s, since %s",
+		os.Getpid(),
+		strings.Join(append([]string{filepath.Base(os.Args[0])}, os.Args[1:]...), " "),
+		time.Now().Format(time.RFC3339),
+	)
+	release, err := filelock.Acquire(ctx, lockFilePath, holder, false)
+	if err == nil || !wait || !errors.Is(err, filelock.ErrLocked) {
+		return release, err
+	}
+	container.Logger().Info("waiting for lock", "error", err)
+	return filelock.Acquire(ctx, lockFilePath, holder, true)
+}
+
+// NewFlexQueryClient returns a Flex Query client and the IBKR token to use with it.
+//
+// If replayFilePath is empty, the IBCTL_FLEX_REPLAY environment variable is used.
+// If either is set, a replay client for the saved XML response is returned and
+// no token is required. Otherwise, the token is read from the environment.
+func NewFlexQueryClient(container appext.Container, httpClient *http.Client, replayFilePath string) (ibkrflexquery.Client, string, error) {
+	logger := container.Logger()
+	if replayFilePath == "" {
+		replayFilePath = container.Env(FlexReplayEnvVar)
+	}
+	if replayFilePath != "" {
+		// Read through protoio so encrypted raw archives can be replayed.
+		xmlData, err := protoio.ReadFile(replayFilePath)
+		if err != nil {
+			return nil, "", fmt.Errorf("reading flex query replay file: %w", err)
+		}
+		logger.Info("replaying flex query response", "path", replayFilePath)
+		return ibkrflexquery.NewReplayClient(logger, xmlData), "", nil
+	}
 	// Read the IBKR token from the environment via the app container.
 	ibkrToken := container.Env(ibkrFlexWebServiceTokenEnvVar)
 	if ibkrToken == "" {
-		return nil, errors.New(ibkrFlexWebServiceTokenEnvVar + " environment variable is required, set it to your IBKR Flex Web Service token (see \"ibctl --help\" for details)")
+		return nil, "", errors.New(ibkrFlexWebServiceTokenEnvVar + " environment variable is required, set it to your IBKR Flex Web Service token (see \"ibctl --help\" for details)")
 	}
-	// Extract the logger from the appext container.
-	logger := container.Logger()
-	// Construct the API clients.
-	flexQueryClient := ibkrflexquery.NewClient(logger)
-	fxRateClient := frankfurter.NewClient()
-	bocClient := bankofcanada.NewClient()
-	return ibctldownload.NewDownloader(logger, ibkrToken, config, flexQueryClient, fxRateClient, bocClient), nil
+	return ibkrflexquery.NewClient(logger, httpClient), ibkrToken, nil
+}
+
+// NewPriceStore returns the closing price cache of the config's directory for
+// valuing holdings on past dates. Missing prices are fetched from Yahoo
+// Finance, unless the directory is read-only. The trades give the trading
+// currency of each symbol.
+func NewPriceStore(container appext.Container, config *ibctlconfig.Config, trades []*datav1.Trade) (*pricestore.Store, error) {
+	if config.ReadOnly {
+		return ibctlprices.NewStore(config, nil, trades), nil
+	}
+	httpClient, err := NewHTTPClient(container, config)
+	if err != nil {
+		return nil, err
+	}
+	return ibctlprices.NewStore(config, yahoofinance.NewClient(httpClient), trades), nil
+}
+
+// NewHTTPClient returns the HTTP client for all API clients, configured with
+// the http settings of the config and the IBCTL_PROXY_URL, IBCTL_CA_BUNDLE,
+// and IBCTL_INSECURE_SKIP_VERIFY environment variables, which override the
+// config. The config may be nil for commands that do not read one.
+func NewHTTPClient(container appext.Container, config *ibctlconfig.Config) (*http.Client, error) {
+	var httpConfig httpclient.Config
+	if config != nil {
+		httpConfig = config.HTTP
+	}
+	if proxyURL := container.Env(ProxyURLEnvVar); proxyURL != "" {
+		httpConfig.ProxyURL = proxyURL
+	}
+	if caBundlePath := container.Env(CABundleEnvVar); caBundlePath != "" {
+		httpConfig.CABundlePath = caBundlePath
+	}
+	if value := container.Env(InsecureSkipVerifyEnvVar); value != "" {
+		insecureSkipVerify, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q, must be true or false", InsecureSkipVerifyEnvVar, value)
+		}
+		httpConfig.InsecureSkipVerify = insecureSkipVerify
+	}
+	if httpConfig.InsecureSkipVerify {
+		container.Logger().Warn("TLS certificate verification is disabled, only use this for debugging")
+	}
+	httpClient, err := httpclient.New(httpConfig)
+	if err != nil {
+		return nil, fmt.Errorf("configuring HTTP client: %w", err)
+	}
+	return httpClient, nil
+}
+
+// newLogins returns the logins to download, with the token of each login in
+// the config read from its environment variable. The first login uses
+// ibkrToken. If ibkrToken is empty, a saved response is being replayed, and
+// only the first login is downloaded, since every login would replay the
+// same response.
+func newLogins(container appext.Container, config *ibctlconfig.Config, ibkrToken string) ([]ibctldownload.Login, error) {
+	logins := []ibctldownload.Login{{Token: ibkrToken, FlexQueryID: config.IBKRFlexQueryID}}
+	if ibkrToken == "" {
+		return logins, nil
+	}
+	for _, login := range config.Logins {
+		if login.TokenEnvVar == "" {
+			continue
+		}
+		token := container.Env(login.TokenEnvVar)
+		if token == "" {
+			return nil, fmt.Errorf("%s environment variable is required, set it to the IBKR Flex Web Service token of Flex Query %s", login.TokenEnvVar, login.FlexQueryID)
+		}
+		logins = append(logins, ibctldownload.Login{Token: token, FlexQueryID: login.FlexQueryID})
+	}
+	return logins, nil
+}
+
+// ApplyGroup restricts the merged data to the accounts in the named group
+// from the config. If group is empty, the config and merged data are returned
+// unchanged.
+//
+// Manual cash adjustments are not tied to an account, so they are dropped
+// from the returned config when a group is selected.
+func ApplyGroup(
+	config *ibctlconfig.Config,
+	mergedData *ibctlmerge.MergedData,
+	group string,
+) (*ibctlconfig.Config, *ibctlmerge.MergedData, error) {
+	if group == "" {
+		return config, mergedData, nil
+	}
+	accountAliases, ok := config.Groups[group]
+	if !ok {
+		return nil, nil, appcmd.NewInvalidArgumentErrorf("unknown group %q, groups are defined in the groups section of %s", group, ibctlpath.ConfigFileName)
+	}
+	groupConfig := *config
+	groupConfig.CashAdjustments = nil
+	return &groupConfig, ibctlmerge.FilterAccounts(mergedData, accountAliases), nil
+}
+
+// CheckSnapshotAge checks when the position snapshot of each account in the
+// config, or in group if set, was downloaded. It warns about snapshots older
+// than config.SnapshotMaxAge, and returns an error if maxAge is positive and a
+// snapshot is older than maxAge. Accounts without download metadata, such as
+// those not downloaded since it was added, are skipped.
+func CheckSnapshotAge(container appext.Container, config *ibctlconfig.Config, group string, maxAge time.Duration) error {
+	accountAliases := slices.Sorted(maps.Keys(config.AccountAliases))
+	if group != "" {
+		accountAliases = config.Groups[group]
+	}
+	now := time.Now()
+	for _, alias := range accountAliases {
+		metadata := &datav1.Metadata{}
+		if err := protoio.ReadMessageJSON(ibctlpath.CacheAccountMetadataFilePath(config.DirPath, alias), metadata); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return err
+		}
+		if metadata.GetDownloadTime() == nil {
+			continue
+		}
+		downloadTime := metadata.GetDownloadTime().AsTime()
+		age := now.Sub(downloadTime).Round(time.Minute)
+		if maxAge > 0 && age > maxAge {
+			return fmt.Errorf("position snapshot of account %s was downloaded %s ago, more than --%s %s, run \"ibctl download\" or pass --download", alias, age, MaxAgeFlagName, maxAge)
+		}
+		if age > config.SnapshotMaxAge {
+			container.Logger().Warn("stale position snapshot, run \"ibctl download\" or pass --download",
+				"account", alias,
+				"downloaded", downloadTime.Local().Format(time.DateTime),
+				"age", age.String(),
+			)
+		}
+	}
+	return nil
+}
+
+// ReadDividendAccruals reads the open dividend accruals of each account in the
+// config, or in group if set: the dividends declared but not yet paid, as
+// cash transactions dated on the pay date. Accounts without accruals, such as
+// those not downloaded since they were added, are skipped.
+func ReadDividendAccruals(config *ibctlconfig.Config, group string) ([]*datav1.CashTransaction, error) {
+	accountAliases := slices.Sorted(maps.Keys(config.AccountAliases))
+	if group != "" {
+		accountAliases = config.Groups[group]
+	}
+	var dividendAccruals []*datav1.CashTransaction
+	for _, alias := range accountAliases {
+		accountDividendAccruals, err := protoio.ReadMessagesJSON(
+			ibctlpath.CacheAccountDividendAccrualsFilePath(config.DirPath, alias),
+			func() *datav1.CashTransaction { return &datav1.CashTransaction{} },
+		)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		dividendAccruals = append(dividendAccruals, accountDividendAccruals...)
+	}
+	return dividendAccruals, nil
+}
+
+// ParseAsOf parses the value of the --as-of flag (YYYY-MM-DD). Returns the
+// zero Date if the value is empty. Dates after today are rejected.
+func ParseAsOf(value string) (xtime.Date, error) {
+	if value == "" {
+		return xtime.Date{}, nil
+	}
+	asOf, err := xtime.ParseDate(value)
+	if err != nil {
+		return xtime.Date{}, appcmd.NewInvalidArgumentErrorf("invalid --%s date %q, expected YYYY-MM-DD format", AsOfFlagName, value)
+	}
+	if asOf.After(xtime.TimeToDate(time.Now())) {
+		return xtime.Date{}, appcmd.NewInvalidArgumentErrorf("--%s date %s is in the future", AsOfFlagName, asOf)
+	}
+	return asOf, nil
+}
+
+// AsOfDate returns the date output is computed as of: asOf as returned by
+// ParseAsOf, or today if asOf is zero.
+func AsOfDate(asOf xtime.Date) xtime.Date {
+	if asOf.IsZero() {
+		return xtime.TimeToDate(time.Now())
+	}
+	return asOf
+}
+
+// ParsePeriod parses the value of the --period flag. Returns the empty Period
+// if the value is empty.
+func ParsePeriod(value string) (ibkrflexquery.Period, error) {
+	period, err := ibkrflexquery.ParsePeriod(value)
+	if err != nil {
+		return "", appcmd.NewInvalidArgumentErrorf("invalid --%s: %v", PeriodFlagName, err)
+	}
+	return period, nil
+}
+
+// BindDirFlags registers the --dir and --workspace flags with the given flag
+// set, which both set dirPath. --workspace selects a directory by its name in
+// the user-level workspaces file. If neither flag is set, dirPath is
+// $IBCTL_DIR, or the current directory if IBCTL_DIR is not set.
+func BindDirFlags(flagSet *pflag.FlagSet, dirPath *string) {
+	*dirPath = defaultDirPath()
+	dirFlag := &dirFlag{dirPath: dirPath}
+	flagSet.Var(&dirValue{dirFlag: dirFlag}, DirFlagName, "The ibctl directory containing ibctl.yaml ($"+DirEnvVar+" if set)")
+	flagSet.Var(&workspaceValue{dirFlag: dirFlag}, WorkspaceFlagName, "The named ibctl directory from ~/.config/ibctl/"+ibctlpath.WorkspacesFileName+" (instead of --"+DirFlagName+")")
+}
+
+// BindDirsFlags is BindDirFlags for commands that combine multiple
+// directories. --dir and --workspace can be repeated and combined, and each
+// adds a directory to dirPaths.
+func BindDirsFlags(flagSet *pflag.FlagSet, dirPaths *[]string) {
+	*dirPaths = []string{defaultDirPath()}
+	dirFlag := &dirFlag{dirPaths: dirPaths}
+	flagSet.Var(&dirValue{dirFlag: dirFlag}, DirFlagName, "The ibctl directory containing ibctl.yaml ($"+DirEnvVar+" if set), repeatable to combine directories")
+	flagSet.Var(&workspaceValue{dirFlag: dirFlag}, WorkspaceFlagName, "The named ibctl directory from ~/.config/ibctl/"+ibctlpath.WorkspacesFileName+", repeatable to combine directories")
+}
+
+// dirFlag is the directory or directories set by the --dir and --workspace
+// flags. Exactly one of dirPath and dirPaths is set.
+type dirFlag struct {
+	dirPath  *string
+	dirPaths *[]string
+	set      bool
+}
+
+// add sets the directory, or adds it to the directories. The first directory
+// replaces the default.
+func (d *dirFlag) add(dirPath string) error {
+	if d.dirPaths == nil {
+		if d.set {
+			return fmt.Errorf("only one of --%s and --%s can be set", DirFlagName, WorkspaceFlagName)
+		}
+		*d.dirPath = dirPath
+	} else {
+		if !d.set {
+			*d.dirPaths = nil
+		}
+		*d.dirPaths = append(*d.dirPaths, dirPath)
+	}
+	d.set = true
+	return nil
+}
+
+// dirValue is the pflag.Value of the --dir flag.
+type dirValue struct {
+	dirFlag *dirFlag
+}
+
+// String implements pflag.Value.
+func (d *dirValue) String() string {
+	if d.dirFlag.dirPaths == nil {
+		return *d.dirFlag.dirPath
+	}
+	return strings.Join(*d.dirFlag.dirPaths, ",")
+}
+
+// Set implements pflag.Value.
+func (d *dirValue) Set(value string) error {
+	return d.dirFlag.add(value)
+}
+
+// Type implements pflag.Value.
+func (*dirValue) Type() string {
+	return "string"
+}
+
+// workspaceValue is the pflag.Value of the --workspace flag.
+type workspaceValue struct {
+	dirFlag *dirFlag
+	names   []string
+}
+
+// String implements pflag.Value.
+func (w *workspaceValue) String() string {
+	return strings.Join(w.names, ",")
+}
+
+// Set implements pflag.Value.
+func (w *workspaceValue) Set(value string) error {
+	dirPath, err := resolveWorkspace(value)
+	if err != nil {
+		return err
+	}
+	if err := w.dirFlag.add(dirPath); err != nil {
+		return err
+	}
+	w.names = append(w.names, value)
+	return nil
+}
+
+// Type implements pflag.Value.
+func (*workspaceValue) Type() string {
+	return "string"
+}
+
+// defaultDirPath returns the base directory used if neither --dir nor
+// --workspace is set: $IBCTL_DIR, or the current directory.
+func defaultDirPath() string {
+	if dirPath := os.Getenv(DirEnvVar); dirPath != "" {
+		return dirPath
+	}
+	return "."
+}
+
+// resolveWorkspace returns the directory of the named workspace from the
+// user-level workspaces file.
+func resolveWorkspace(name string) (string, error) {
+	homeDirPath, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	workspacesFilePath := ibctlpath.WorkspacesFilePath(homeDirPath)
+	workspaces, err := ibctlconfig.ReadWorkspaces(workspacesFilePath, homeDirPath)
+	if err != nil {
+		return "", err
+	}
+	dirPath, ok := workspaces[name]
+	if !ok {
+		return "", fmt.Errorf("unknown workspace %q, workspaces are defined in %s", name, workspacesFilePath)
+	}
+	return dirPath, nil
+}
+
+// RedactFlag is the value of the --redact flag. If the flag is not set, the
+// redact option in ibctl.yaml is used.
+type RedactFlag struct {
+	value bool
+	set   bool
+}
+
+// Bind registers the --redact flag with the given flag set.
+func (r *RedactFlag) Bind(flagSet *pflag.FlagSet) {
+	flagSet.VarPF(r, RedactFlagName, "", "Replace account aliases and IBKR account IDs with pseudonyms (defaults to redact in ibctl.yaml)").NoOptDefVal = "true"
+}
+
+// Enabled returns true if output is redacted for the config.
+func (r *RedactFlag) Enabled(config *ibctlconfig.Config) bool {
+	if r.set {
+		return r.value
+	}
+	return config.Redact
+}
+
+// String implements pflag.Value.
+func (r *RedactFlag) String() string {
+	return strconv.FormatBool(r.value)
+}
+
+// Set implements pflag.Value.
+func (r *RedactFlag) Set(value string) error {
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	r.value = parsed
+	r.set = true
+	return nil
+}
+
+// Type implements pflag.Value.
+func (*RedactFlag) Type() string {
+	return "bool"
+}
+
+// DebugHTTPFlags are the values of the --debug-http and --debug-http-body flags.
+type DebugHTTPFlags struct {
+	enabled bool
+	body    bool
+}
+
+// Bind registers the --debug-http and --debug-http-body flags with the given flag set.
+func (d *DebugHTTPFlags) Bind(flagSet *pflag.FlagSet) {
+	flagSet.BoolVar(&d.enabled, DebugHTTPFlagName, false, "Trace the redacted URL, status, and timing of every API request to a file under cache/debug/")
+	flagSet.BoolVar(&d.body, DebugHTTPBodyFlagName, false, "Also trace the start of every API response body (implies --"+DebugHTTPFlagName+")")
+}
+
+// Open creates a trace file under cache/debug/ and sets it as the HTTP trace
+// of the config, so that every request of the API clients created for the
+// config is traced. The returned function closes the trace file. If neither
+// flag is set, nothing is traced and the returned function does nothing.
+func (d *DebugHTTPFlags) Open(container appext.Container, config *ibctlconfig.Config) (func() error, error) {
+	if !d.enabled && !d.body {
+		return func() error { return nil }, nil
+	}
+	debugDirPath := ibctlpath.CacheDebugDirPath(config.DirPath)
+	if err := filemode.MkdirAll(debugDirPath); err != nil {
+		return nil, err
+	}
+	traceFilePath := filepath.Join(debugDirPath, "http-"+time.Now().UTC().Format("20060102T150405Z")+".log")
+	// Response bodies can contain account data, so the trace is only readable by the user.
+	file, err := filemode.OpenFile(traceFilePath, os.O_WRONLY|os.O_APPEND)
+	if err != nil {
+		return nil, err
+	}
+	config.HTTP.Trace = file
+	if d.body {
+		config.HTTP.TraceBodyBytes = httpTraceBodyBytes
+	}
+	container.Logger().Info("tracing HTTP requests", "path", traceFilePath)
+	return file.Close, nil
+}
+
+// WriteTable writes a table with totals to stdout, or through the PAGER
+// command if pager is set and stdout is a terminal. When written directly to
+// a terminal, columns are dropped in dropOrder until the table fits the
+// terminal width; the pager scrolls horizontally instead. If cellColor is
+// non-nil, cells are colored with it and the totals row is cyan.
+func WriteTable(
+	ctx context.Context,
+	container appext.Container,
+	pager bool,
+	headers []string,
+	rows [][]string,
+	totalsRow []string,
+	dropOrder []int,
+	cellColor cliio.CellColorFunc,
+) (retErr error) {
+	var writer io.Writer = os.Stdout
+	width := cliio.TerminalWidth(os.Stdout)
+	if pager && width > 0 {
+		pagerWriter, err := startPager(ctx, container)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			retErr = errors.Join(retErr, pagerWriter.Close())
+		}()
+		writer = pagerWriter
+		width = 0
+	}
+	headers, rows, totalsRow, columnIndexes := cliio.FitTable(width, headers, rows, totalsRow, dropOrder)
+	if cellColor == nil {
+		return cliio.WriteTableWithTotals(writer, headers, rows, totalsRow)
+	}
+	return cliio.WriteColoredTableWithTotals(writer, headers, rows, totalsRow, func(rowIndex int, columnIndex int) cliio.Color {
+		return cellColor(rowIndex, columnIndexes[columnIndex])
+	}, cliio.ColorCyan)
+}
+
+// *** PRIVATE ***
+
+// lockingDownloader is a Downloader that holds the lock on the ibctl directory
+// while downloading, so commands that download with --download are covered too.
+// Dry runs write nothing and do not take the lock.
+type lockingDownloader struct {
+	ibctldownload.Downloader
+
+	container appext.Container
+	dirPath   string
+}
+
+func (d *lockingDownloader) Download(ctx context.Context) (retErr error) {
+	release, err := LockDir(ctx, d.container, d.dirPath, false)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = errors.Join(retErr, release())
+	}()
+	return d.Downloader.Download(ctx)
+}
+
+func (d *lockingDownloader) DownloadWithSummary(ctx context.Context) (_ *ibctldownload.Summary, retErr error) {
+	release, err := LockDir(ctx, d.container, d.dirPath, false)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		retErr = errors.Join(retErr, release())
+	}()
+	return d.Downloader.DownloadWithSummary(ctx)
+}
+
+// pagerWriter writes to the stdin of a running pager process.
+type pagerWriter struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+}
+
+// startPager starts the PAGER command, or defaultPager if it is not set,
+// with its output going to stdout.
+func startPager(ctx context.Context, container appext.Container) (*pagerWriter, error) {
+	pagerCommand := container.Env(pagerEnvVar)
+	if pagerCommand == "" {
+		pagerCommand = defaultPager
+	}
+	fields := strings.Fields(pagerCommand)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%s is blank", pagerEnvVar)
+	}
+	cmd := exec.CommandContext(ctx, fields[0], fields[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting pager: %w", err)
+	}
+	return &pagerWriter{cmd: cmd, stdin: stdin}, nil
+}
+
+// Write writes to the pager. Output after the user quits the pager is discarded.
+func (p *pagerWriter) Write(data []byte) (int, error) {
+	n, err := p.stdin.Write(data)
+	if errors.Is(err, syscall.EPIPE) {
+		return len(data), nil
+	}
+	return n, err
+}
+
+// Close closes the pager's stdin and waits for the user to quit it.
+func (p *pagerWriter) Close() error {
+	if err := p.stdin.Close(); err != nil {
+		return err
+	}
+	if err := p.cmd.Wait(); err != nil {
+		return fmt.Errorf("running pager: %w", err)
+	}
+	return nil
+}
+
+// errorHint is the exit code and remediation hint for an error.
+type errorHint struct {
+	err      error
+	exitCode int
+	hint     string
+}
+
+// errorHints are the errors mapped by ErrorInterceptor.
+var errorHints = []errorHint{
+	{
+		err:      ibkrflexquery.ErrTokenInvalid,
+		exitCode: ExitCodeTokenInvalid,
+		hint:     "generate a new token in the IBKR portal under Performance & Reports > Flex Queries > Flex Web Service Configuration, and set it in " + ibkrFlexWebServiceTokenEnvVar,
+	},
+	{
+		err:      ibkrflexquery.ErrQueryMissingSection,
+		exitCode: ExitCodeQueryMissingSection,
+		hint:     "add the missing sections to the Flex Query in the IBKR portal with all fields enabled, and run \"ibctl probe\" to verify",
+	},
+	{
+		err:      ibkrflexquery.ErrRateLimited,
+		exitCode: ExitCodeRateLimited,
+		hint:     "IBKR limits the number of Flex Web Service requests per token, wait a few minutes and try again",
+	},
+	{
+		err:      ibctlmigrate.ErrStaleCache,
+		exitCode: ExitCodeStaleCache,
+		hint:     "run \"ibctl data migrate\" to upgrade the data in the ibctl directory",
+	},
+	{
+		err:      filelock.ErrLocked,
+		exitCode: ExitCodeLocked,
+		hint:     "another ibctl command is modifying the ibctl directory, retry when it finishes or pass --" + WaitFlagName + " to wait for it",
+	},
+}
+
+// lookupEncryptionKey returns the encoded encryption key from the environment,
+// falling back to the keychain. Returns an empty string if neither has a key.
+func lookupEncryptionKey(container appext.Container) string {
+	if encodedKey := container.Env(EncryptionKeyEnvVar); encodedKey != "" {
+		return encodedKey
+	}
+	return readKeychainEncryptionKey()
+}
+
+// readKeychainEncryptionKey reads the encryption key from the macOS keychain.
+// Returns an empty string on other platforms or if the item does not exist.
+func readKeychainEncryptionKey() string {
+	if runtime.GOOS != "darwin" {
+		return ""
+	}
+	output, err := exec.Command("security", "find-generic-password", "-s", encryptionKeyKeychainService, "-w").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
 }
@@ -9,11 +9,23 @@ import (
 
 	"buf.build/go/app/appcmd"
 	"buf.build/go/app/appext"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/cash"
 	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/config"
 	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/data"
 	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/download"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/export"
 	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/holding"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/income"
 	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/probe"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/query"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/report"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/selfupdate"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/serve"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/trade"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/command/version"
+	"github.com/bufdev/ibctl/cmd/ibctl/internal/ibctlcmd"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlversion"
+	"github.com/spf13/pflag"
 )
 
 func main() {
@@ -22,23 +34,53 @@ func main() {
 
 // newRootCommand creates the root ibctl command with all sub-commands.
 func newRootCommand(name string) *appcmd.Command {
-	builder := appext.NewBuilder(name)
+	profileFlags := ibctlcmd.NewProfileFlags()
+	builder := appext.NewBuilder(
+		name,
+		appext.BuilderWithInterceptor(ibctlcmd.ErrorInterceptor),
+		appext.BuilderWithInterceptor(profileFlags.Interceptor),
+	)
 	return &appcmd.Command{
 		Use:   name,
 		Short: "Analyze Interactive Brokers holdings and trades",
 		Long: `Analyze Interactive Brokers holdings and trades.
 
-All commands operate on an ibctl directory (--dir flag, defaults to current directory)
-containing ibctl.yaml and well-known subdirectories for data, cache, and statements.
+All commands operate on an ibctl directory (--dir flag, defaults to $IBCTL_DIR or
+the current directory) containing ibctl.yaml and well-known subdirectories for
+data, cache, and statements. --workspace <name> selects a directory by its name
+in ~/.config/ibctl/workspaces.yaml instead.
 
-Run "ibctl config init" to create a new ibctl directory.`,
-		BindPersistentFlags: builder.BindRoot,
+--readonly, or readonly: true in ibctl.yaml, prevents any write to the ibctl
+directory, for safely inspecting an archived or synced copy: downloads and
+commands that modify data fail, and the merged data cache is kept in a
+temporary directory.
+
+Run "ibctl config init" to create a new ibctl directory.
+
+--profile reports the time spent in each phase of a command, such as merge
+and FIFO, to stderr after it completes. --cpu-profile and --mem-profile write
+pprof profiles for "go tool pprof".`,
+		Version: ibctlversion.Get().Version,
+		BindPersistentFlags: func(flagSet *pflag.FlagSet) {
+			builder.BindRoot(flagSet)
+			profileFlags.Bind(flagSet)
+			ibctlcmd.BindReadOnlyFlag(flagSet)
+		},
 		SubCommands: []*appcmd.Command{
+			cash.NewCommand("cash", builder),
 			config.NewCommand("config", builder),
 			data.NewCommand("data", builder),
 			download.NewCommand("download", builder),
+			export.NewCommand("export", builder),
 			holding.NewCommand("holding", builder),
+			income.NewCommand("income", builder),
 			probe.NewCommand("probe", builder),
+			query.NewCommand("query", builder),
+			report.NewCommand("report", builder),
+			selfupdate.NewCommand("self-update", builder),
+			serve.NewCommand("serve", builder),
+			trade.NewCommand("trade", builder),
+			version.NewCommand("version", builder),
 		},
 	}
 }
@@ -34,9 +34,13 @@ type CashPosition struct {
 	// The account alias this cash balance belongs to (e.g., "individual").
 	AccountId string `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
 	// The cash balance as a Money value (currency code + amount).
-	Balance       *v1.Money `protobuf:"bytes,2,opt,name=balance,proto3" json:"balance,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	Balance *v1.Money `protobuf:"bytes,2,opt,name=balance,proto3" json:"balance,omitempty"`
+	// The settled cash balance, excluding the cash of trades that have not
+	// settled yet. The difference to balance is the unsettled cash.
+	// Unset for cash positions downloaded before it was recorded.
+	SettledBalance *v1.Money `protobuf:"bytes,3,opt,name=settled_balance,json=settledBalance,proto3" json:"settled_balance,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *CashPosition) Reset() {
@@ -83,15 +87,23 @@ func (x *CashPosition) GetBalance() *v1.Money {
 	return nil
 }
 
+func (x *CashPosition) GetSettledBalance() *v1.Money {
+	if x != nil {
+		return x.SettledBalance
+	}
+	return nil
+}
+
 var File_ibctl_data_v1_cash_position_proto protoreflect.FileDescriptor
 
 const file_ibctl_data_v1_cash_position_proto_rawDesc = "" +
 	"\n" +
-	"!ibctl/data/v1/cash_position.proto\x12\ribctl.data.v1\x1a\x1bbuf/validate/validate.proto\x1a\x1dstandard/money/v1/money.proto\"q\n" +
+	"!ibctl/data/v1/cash_position.proto\x12\ribctl.data.v1\x1a\x1bbuf/validate/validate.proto\x1a\x1dstandard/money/v1/money.proto\"\xb4\x01\n" +
 	"\fCashPosition\x12%\n" +
 	"\n" +
 	"account_id\x18\x01 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\taccountId\x12:\n" +
-	"\abalance\x18\x02 \x01(\v2\x18.standard.money.v1.MoneyB\x06\xbaH\x03\xc8\x01\x01R\abalanceB\xc0\x01\n" +
+	"\abalance\x18\x02 \x01(\v2\x18.standard.money.v1.MoneyB\x06\xbaH\x03\xc8\x01\x01R\abalance\x12A\n" +
+	"\x0fsettled_balance\x18\x03 \x01(\v2\x18.standard.money.v1.MoneyR\x0esettledBalanceB\xc0\x01\n" +
 	"\x11com.ibctl.data.v1B\x11CashPositionProtoP\x01ZBgithub.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1;datav1\xa2\x02\x03IDX\xaa\x02\rIbctl.Data.V1\xca\x02\rIbctl\\Data\\V1\xe2\x02\x19Ibctl\\Data\\V1\\GPBMetadata\xea\x02\x0fIbctl::Data::V1b\x06proto3"
 
 var (
@@ -113,11 +125,12 @@ var file_ibctl_data_v1_cash_position_proto_goTypes = []any{
 }
 var file_ibctl_data_v1_cash_position_proto_depIdxs = []int32{
 	1, // 0: ibctl.data.v1.CashPosition.balance:type_name -> standard.money.v1.Money
-	1, // [1:1] is the sub-list for method output_type
-	1, // [1:1] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	1, // 1: ibctl.data.v1.CashPosition.settled_balance:type_name -> standard.money.v1.Money
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
 }
 
 func init() { file_ibctl_data_v1_cash_position_proto_init() }
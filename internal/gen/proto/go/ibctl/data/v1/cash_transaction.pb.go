@@ -0,0 +1,306 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: ibctl/data/v1/cash_transaction.proto
+
+package datav1
+
+import (
+	_ "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	v11 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/money/v1"
+	v1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/time/v1"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// CashTransactionType indicates the kind of cash transaction.
+type CashTransactionType int32
+
+const (
+	CashTransactionType_CASH_TRANSACTION_TYPE_UNSPECIFIED CashTransactionType = 0
+	// Ordinary cash dividend.
+	CashTransactionType_CASH_TRANSACTION_TYPE_DIVIDEND CashTransactionType = 1
+	// Payment in lieu of a dividend (e.g., on shares lent out).
+	CashTransactionType_CASH_TRANSACTION_TYPE_PAYMENT_IN_LIEU CashTransactionType = 2
+	// Tax withheld at source on dividends or interest.
+	CashTransactionType_CASH_TRANSACTION_TYPE_WITHHOLDING_TAX CashTransactionType = 3
+	// Interest received (broker interest or bond coupons).
+	CashTransactionType_CASH_TRANSACTION_TYPE_INTEREST_RECEIVED CashTransactionType = 4
+	// Interest paid (margin interest or accrued bond interest paid).
+	CashTransactionType_CASH_TRANSACTION_TYPE_INTEREST_PAID CashTransactionType = 5
+	// Cash deposit or withdrawal.
+	CashTransactionType_CASH_TRANSACTION_TYPE_DEPOSIT_WITHDRAWAL CashTransactionType = 6
+	// Fee (e.g., market data subscriptions, other fees).
+	CashTransactionType_CASH_TRANSACTION_TYPE_FEE CashTransactionType = 7
+	// Commission adjustment.
+	CashTransactionType_CASH_TRANSACTION_TYPE_COMMISSION_ADJUSTMENT CashTransactionType = 8
+	// Any other cash transaction not covered above.
+	CashTransactionType_CASH_TRANSACTION_TYPE_OTHER CashTransactionType = 9
+)
+
+// Enum value maps for CashTransactionType.
+var (
+	CashTransactionType_name = map[int32]string{
+		0: "CASH_TRANSACTION_TYPE_UNSPECIFIED",
+		1: "CASH_TRANSACTION_TYPE_DIVIDEND",
+		2: "CASH_TRANSACTION_TYPE_PAYMENT_IN_LIEU",
+		3: "CASH_TRANSACTION_TYPE_WITHHOLDING_TAX",
+		4: "CASH_TRANSACTION_TYPE_INTEREST_RECEIVED",
+		5: "CASH_TRANSACTION_TYPE_INTEREST_PAID",
+		6: "CASH_TRANSACTION_TYPE_DEPOSIT_WITHDRAWAL",
+		7: "CASH_TRANSACTION_TYPE_FEE",
+		8: "CASH_TRANSACTION_TYPE_COMMISSION_ADJUSTMENT",
+		9: "CASH_TRANSACTION_TYPE_OTHER",
+	}
+	CashTransactionType_value = map[string]int32{
+		"CASH_TRANSACTION_TYPE_UNSPECIFIED":           0,
+		"CASH_TRANSACTION_TYPE_DIVIDEND":              1,
+		"CASH_TRANSACTION_TYPE_PAYMENT_IN_LIEU":       2,
+		"CASH_TRANSACTION_TYPE_WITHHOLDING_TAX":       3,
+		"CASH_TRANSACTION_TYPE_INTEREST_RECEIVED":     4,
+		"CASH_TRANSACTION_TYPE_INTEREST_PAID":         5,
+		"CASH_TRANSACTION_TYPE_DEPOSIT_WITHDRAWAL":    6,
+		"CASH_TRANSACTION_TYPE_FEE":                   7,
+		"CASH_TRANSACTION_TYPE_COMMISSION_ADJUSTMENT": 8,
+		"CASH_TRANSACTION_TYPE_OTHER":                 9,
+	}
+)
+
+func (x CashTransactionType) Enum() *CashTransactionType {
+	p := new(CashTransactionType)
+	*p = x
+	return p
+}
+
+func (x CashTransactionType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CashTransactionType) Descriptor() protoreflect.EnumDescriptor {
+	return file_ibctl_data_v1_cash_transaction_proto_enumTypes[0].Descriptor()
+}
+
+func (CashTransactionType) Type() protoreflect.EnumType {
+	return &file_ibctl_data_v1_cash_transaction_proto_enumTypes[0]
+}
+
+func (x CashTransactionType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CashTransactionType.Descriptor instead.
+func (CashTransactionType) EnumDescriptor() ([]byte, []int) {
+	return file_ibctl_data_v1_cash_transaction_proto_rawDescGZIP(), []int{0}
+}
+
+// CashTransaction represents a single cash movement from the IBKR Flex Query
+// Cash Transactions section (dividends, withholding tax, interest, fees,
+// deposits, and withdrawals).
+type CashTransaction struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The account alias this cash transaction belongs to (e.g., "rrsp", "holdco").
+	AccountId string `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	// The type of cash transaction.
+	Type CashTransactionType `protobuf:"varint,2,opt,name=type,proto3,enum=ibctl.data.v1.CashTransactionType" json:"type,omitempty"`
+	// The date of the cash transaction.
+	Date *v1.Date `protobuf:"bytes,3,opt,name=date,proto3" json:"date,omitempty"`
+	// The signed amount. Positive for inflows, negative for outflows.
+	Amount *v11.Money `protobuf:"bytes,4,opt,name=amount,proto3" json:"amount,omitempty"`
+	// The three-letter ISO 4217 currency code.
+	CurrencyCode string `protobuf:"bytes,5,opt,name=currency_code,json=currencyCode,proto3" json:"currency_code,omitempty"`
+	// The ticker symbol of the related security, if any (e.g., the dividend payer).
+	Symbol string `protobuf:"bytes,6,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	// The description from IBKR.
+	Description string `protobuf:"bytes,7,opt,name=description,proto3" json:"description,omitempty"`
+	// The unique transaction identifier from IBKR, if available.
+	TransactionId string `protobuf:"bytes,8,opt,name=transaction_id,json=transactionId,proto3" json:"transaction_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CashTransaction) Reset() {
+	*x = CashTransaction{}
+	mi := &file_ibctl_data_v1_cash_transaction_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CashTransaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CashTransaction) ProtoMessage() {}
+
+func (x *CashTransaction) ProtoReflect() protoreflect.Message {
+	mi := &file_ibctl_data_v1_cash_transaction_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CashTransaction.ProtoReflect.Descriptor instead.
+func (*CashTransaction) Descriptor() ([]byte, []int) {
+	return file_ibctl_data_v1_cash_transaction_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CashTransaction) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *CashTransaction) GetType() CashTransactionType {
+	if x != nil {
+		return x.Type
+	}
+	return CashTransactionType_CASH_TRANSACTION_TYPE_UNSPECIFIED
+}
+
+func (x *CashTransaction) GetDate() *v1.Date {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+func (x *CashTransaction) GetAmount() *v11.Money {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+func (x *CashTransaction) GetCurrencyCode() string {
+	if x != nil {
+		return x.CurrencyCode
+	}
+	return ""
+}
+
+func (x *CashTransaction) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *CashTransaction) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *CashTransaction) GetTransactionId() string {
+	if x != nil {
+		return x.TransactionId
+	}
+	return ""
+}
+
+var File_ibctl_data_v1_cash_transaction_proto protoreflect.FileDescriptor
+
+const file_ibctl_data_v1_cash_transaction_proto_rawDesc = "" +
+	"\n" +
+	"$ibctl/data/v1/cash_transaction.proto\x12\ribctl.data.v1\x1a\x1bbuf/validate/validate.proto\x1a\x1dstandard/money/v1/money.proto\x1a\x1bstandard/time/v1/date.proto\"\x8d\x04\n" +
+	"\x0fCashTransaction\x12%\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\taccountId\x12@\n" +
+	"\x04type\x18\x02 \x01(\x0e2\".ibctl.data.v1.CashTransactionTypeB\b\xbaH\x05\x82\x01\x02 \x00R\x04type\x122\n" +
+	"\x04date\x18\x03 \x01(\v2\x16.standard.time.v1.DateB\x06\xbaH\x03\xc8\x01\x01R\x04date\x128\n" +
+	"\x06amount\x18\x04 \x01(\v2\x18.standard.money.v1.MoneyB\x06\xbaH\x03\xc8\x01\x01R\x06amount\x126\n" +
+	"\rcurrency_code\x18\x05 \x01(\tB\x11\xbaH\x0er\f2\n" +
+	"^[A-Z]{3}$R\fcurrencyCode\x12\x16\n" +
+	"\x06symbol\x18\x06 \x01(\tR\x06symbol\x12 \n" +
+	"\vdescription\x18\a \x01(\tR\vdescription\x12%\n" +
+	"\x0etransaction_id\x18\b \x01(\tR\rtransactionId:\x89\x01\xbaH\x85\x01\x1a\x82\x01\n" +
+	"\x0famount_currency\x12>amount currency_code must match cash transaction currency_code\x1a/this.amount.currency_code == this.currency_code*\xab\x03\n" +
+	"\x13CashTransactionType\x12%\n" +
+	"!CASH_TRANSACTION_TYPE_UNSPECIFIED\x10\x00\x12\"\n" +
+	"\x1eCASH_TRANSACTION_TYPE_DIVIDEND\x10\x01\x12)\n" +
+	"%CASH_TRANSACTION_TYPE_PAYMENT_IN_LIEU\x10\x02\x12)\n" +
+	"%CASH_TRANSACTION_TYPE_WITHHOLDING_TAX\x10\x03\x12+\n" +
+	"'CASH_TRANSACTION_TYPE_INTEREST_RECEIVED\x10\x04\x12'\n" +
+	"#CASH_TRANSACTION_TYPE_INTEREST_PAID\x10\x05\x12,\n" +
+	"(CASH_TRANSACTION_TYPE_DEPOSIT_WITHDRAWAL\x10\x06\x12\x1d\n" +
+	"\x19CASH_TRANSACTION_TYPE_FEE\x10\a\x12/\n" +
+	"+CASH_TRANSACTION_TYPE_COMMISSION_ADJUSTMENT\x10\b\x12\x1f\n" +
+	"\x1bCASH_TRANSACTION_TYPE_OTHER\x10\tB\xc3\x01\n" +
+	"\x11com.ibctl.data.v1B\x14CashTransactionProtoP\x01ZBgithub.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1;datav1\xa2\x02\x03IDX\xaa\x02\rIbctl.Data.V1\xca\x02\rIbctl\\Data\\V1\xe2\x02\x19Ibctl\\Data\\V1\\GPBMetadata\xea\x02\x0fIbctl::Data::V1b\x06proto3"
+
+var (
+	file_ibctl_data_v1_cash_transaction_proto_rawDescOnce sync.Once
+	file_ibctl_data_v1_cash_transaction_proto_rawDescData []byte
+)
+
+func file_ibctl_data_v1_cash_transaction_proto_rawDescGZIP() []byte {
+	file_ibctl_data_v1_cash_transaction_proto_rawDescOnce.Do(func() {
+		file_ibctl_data_v1_cash_transaction_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_ibctl_data_v1_cash_transaction_proto_rawDesc), len(file_ibctl_data_v1_cash_transaction_proto_rawDesc)))
+	})
+	return file_ibctl_data_v1_cash_transaction_proto_rawDescData
+}
+
+var file_ibctl_data_v1_cash_transaction_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_ibctl_data_v1_cash_transaction_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_ibctl_data_v1_cash_transaction_proto_goTypes = []any{
+	(CashTransactionType)(0), // 0: ibctl.data.v1.CashTransactionType
+	(*CashTransaction)(nil),  // 1: ibctl.data.v1.CashTransaction
+	(*v1.Date)(nil),          // 2: standard.time.v1.Date
+	(*v11.Money)(nil),        // 3: standard.money.v1.Money
+}
+var file_ibctl_data_v1_cash_transaction_proto_depIdxs = []int32{
+	0, // 0: ibctl.data.v1.CashTransaction.type:type_name -> ibctl.data.v1.CashTransactionType
+	2, // 1: ibctl.data.v1.CashTransaction.date:type_name -> standard.time.v1.Date
+	3, // 2: ibctl.data.v1.CashTransaction.amount:type_name -> standard.money.v1.Money
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_ibctl_data_v1_cash_transaction_proto_init() }
+func file_ibctl_data_v1_cash_transaction_proto_init() {
+	if File_ibctl_data_v1_cash_transaction_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ibctl_data_v1_cash_transaction_proto_rawDesc), len(file_ibctl_data_v1_cash_transaction_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_ibctl_data_v1_cash_transaction_proto_goTypes,
+		DependencyIndexes: file_ibctl_data_v1_cash_transaction_proto_depIdxs,
+		EnumInfos:         file_ibctl_data_v1_cash_transaction_proto_enumTypes,
+		MessageInfos:      file_ibctl_data_v1_cash_transaction_proto_msgTypes,
+	}.Build()
+	File_ibctl_data_v1_cash_transaction_proto = out.File
+	file_ibctl_data_v1_cash_transaction_proto_goTypes = nil
+	file_ibctl_data_v1_cash_transaction_proto_depIdxs = nil
+}
@@ -0,0 +1,241 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: ibctl/data/v1/closed_lot.proto
+
+package datav1
+
+import (
+	_ "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	v11 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/math/v1"
+	v12 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/money/v1"
+	v1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/time/v1"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ClosedLot is a single tax lot closed by a trade, as matched by IBKR.
+//
+// These come from the Lot rows of the IBKR Flex Query Trades section (the
+// "Closed Lots" level of detail), and record which opening lot IBKR matched
+// against each closing trade.
+type ClosedLot struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The account alias this closed lot belongs to (e.g., "rrsp", "holdco").
+	AccountId string `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	// The IBKR ID of the trade that closed the lot, if available.
+	ClosingTradeId string `protobuf:"bytes,2,opt,name=closing_trade_id,json=closingTradeId,proto3" json:"closing_trade_id,omitempty"`
+	// The ticker symbol.
+	Symbol string `protobuf:"bytes,3,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	// The IBKR asset category (e.g., "STK", "OPT", "BOND").
+	AssetCategory string `protobuf:"bytes,4,opt,name=asset_category,json=assetCategory,proto3" json:"asset_category,omitempty"`
+	// The date the lot was opened.
+	OpenDate *v1.Date `protobuf:"bytes,5,opt,name=open_date,json=openDate,proto3" json:"open_date,omitempty"`
+	// The date the lot was closed.
+	CloseDate *v1.Date `protobuf:"bytes,6,opt,name=close_date,json=closeDate,proto3" json:"close_date,omitempty"`
+	// The quantity closed. Positive for long lots, negative for short lots.
+	Quantity *v11.Decimal `protobuf:"bytes,7,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	// The cost basis of the closed quantity.
+	Cost *v12.Money `protobuf:"bytes,8,opt,name=cost,proto3" json:"cost,omitempty"`
+	// The realized P&L of the closed quantity as computed by IBKR.
+	FifoPnlRealized *v12.Money `protobuf:"bytes,9,opt,name=fifo_pnl_realized,json=fifoPnlRealized,proto3" json:"fifo_pnl_realized,omitempty"`
+	// The three-letter ISO 4217 currency code.
+	CurrencyCode  string `protobuf:"bytes,10,opt,name=currency_code,json=currencyCode,proto3" json:"currency_code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClosedLot) Reset() {
+	*x = ClosedLot{}
+	mi := &file_ibctl_data_v1_closed_lot_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClosedLot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClosedLot) ProtoMessage() {}
+
+func (x *ClosedLot) ProtoReflect() protoreflect.Message {
+	mi := &file_ibctl_data_v1_closed_lot_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClosedLot.ProtoReflect.Descriptor instead.
+func (*ClosedLot) Descriptor() ([]byte, []int) {
+	return file_ibctl_data_v1_closed_lot_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ClosedLot) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *ClosedLot) GetClosingTradeId() string {
+	if x != nil {
+		return x.ClosingTradeId
+	}
+	return ""
+}
+
+func (x *ClosedLot) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *ClosedLot) GetAssetCategory() string {
+	if x != nil {
+		return x.AssetCategory
+	}
+	return ""
+}
+
+func (x *ClosedLot) GetOpenDate() *v1.Date {
+	if x != nil {
+		return x.OpenDate
+	}
+	return nil
+}
+
+func (x *ClosedLot) GetCloseDate() *v1.Date {
+	if x != nil {
+		return x.CloseDate
+	}
+	return nil
+}
+
+func (x *ClosedLot) GetQuantity() *v11.Decimal {
+	if x != nil {
+		return x.Quantity
+	}
+	return nil
+}
+
+func (x *ClosedLot) GetCost() *v12.Money {
+	if x != nil {
+		return x.Cost
+	}
+	return nil
+}
+
+func (x *ClosedLot) GetFifoPnlRealized() *v12.Money {
+	if x != nil {
+		return x.FifoPnlRealized
+	}
+	return nil
+}
+
+func (x *ClosedLot) GetCurrencyCode() string {
+	if x != nil {
+		return x.CurrencyCode
+	}
+	return ""
+}
+
+var File_ibctl_data_v1_closed_lot_proto protoreflect.FileDescriptor
+
+const file_ibctl_data_v1_closed_lot_proto_rawDesc = "" +
+	"\n" +
+	"\x1eibctl/data/v1/closed_lot.proto\x12\ribctl.data.v1\x1a\x1bbuf/validate/validate.proto\x1a\x1estandard/math/v1/decimal.proto\x1a\x1dstandard/money/v1/money.proto\x1a\x1bstandard/time/v1/date.proto\"\xdd\x06\n" +
+	"\tClosedLot\x12%\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\taccountId\x12(\n" +
+	"\x10closing_trade_id\x18\x02 \x01(\tR\x0eclosingTradeId\x12\x1e\n" +
+	"\x06symbol\x18\x03 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\x06symbol\x12%\n" +
+	"\x0easset_category\x18\x04 \x01(\tR\rassetCategory\x12;\n" +
+	"\topen_date\x18\x05 \x01(\v2\x16.standard.time.v1.DateB\x06\xbaH\x03\xc8\x01\x01R\bopenDate\x12=\n" +
+	"\n" +
+	"close_date\x18\x06 \x01(\v2\x16.standard.time.v1.DateB\x06\xbaH\x03\xc8\x01\x01R\tcloseDate\x12=\n" +
+	"\bquantity\x18\a \x01(\v2\x19.standard.math.v1.DecimalB\x06\xbaH\x03\xc8\x01\x01R\bquantity\x12,\n" +
+	"\x04cost\x18\b \x01(\v2\x18.standard.money.v1.MoneyR\x04cost\x12D\n" +
+	"\x11fifo_pnl_realized\x18\t \x01(\v2\x18.standard.money.v1.MoneyR\x0ffifoPnlRealized\x126\n" +
+	"\rcurrency_code\x18\n" +
+	" \x01(\tB\x11\xbaH\x0er\f2\n" +
+	"^[A-Z]{3}$R\fcurrencyCode:\xd0\x02\xbaH\xcc\x02\x1a\x89\x01\n" +
+	"\rcost_currency\x126cost currency_code must match closed lot currency_code\x1a@!has(this.cost) || this.cost.currency_code == this.currency_code\x1a\xbd\x01\n" +
+	"\x1afifo_pnl_realized_currency\x12Cfifo_pnl_realized currency_code must match closed lot currency_code\x1aZ!has(this.fifo_pnl_realized) || this.fifo_pnl_realized.currency_code == this.currency_codeB\xbd\x01\n" +
+	"\x11com.ibctl.data.v1B\x0eClosedLotProtoP\x01ZBgithub.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1;datav1\xa2\x02\x03IDX\xaa\x02\rIbctl.Data.V1\xca\x02\rIbctl\\Data\\V1\xe2\x02\x19Ibctl\\Data\\V1\\GPBMetadata\xea\x02\x0fIbctl::Data::V1b\x06proto3"
+
+var (
+	file_ibctl_data_v1_closed_lot_proto_rawDescOnce sync.Once
+	file_ibctl_data_v1_closed_lot_proto_rawDescData []byte
+)
+
+func file_ibctl_data_v1_closed_lot_proto_rawDescGZIP() []byte {
+	file_ibctl_data_v1_closed_lot_proto_rawDescOnce.Do(func() {
+		file_ibctl_data_v1_closed_lot_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_ibctl_data_v1_closed_lot_proto_rawDesc), len(file_ibctl_data_v1_closed_lot_proto_rawDesc)))
+	})
+	return file_ibctl_data_v1_closed_lot_proto_rawDescData
+}
+
+var file_ibctl_data_v1_closed_lot_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_ibctl_data_v1_closed_lot_proto_goTypes = []any{
+	(*ClosedLot)(nil),   // 0: ibctl.data.v1.ClosedLot
+	(*v1.Date)(nil),     // 1: standard.time.v1.Date
+	(*v11.Decimal)(nil), // 2: standard.math.v1.Decimal
+	(*v12.Money)(nil),   // 3: standard.money.v1.Money
+}
+var file_ibctl_data_v1_closed_lot_proto_depIdxs = []int32{
+	1, // 0: ibctl.data.v1.ClosedLot.open_date:type_name -> standard.time.v1.Date
+	1, // 1: ibctl.data.v1.ClosedLot.close_date:type_name -> standard.time.v1.Date
+	2, // 2: ibctl.data.v1.ClosedLot.quantity:type_name -> standard.math.v1.Decimal
+	3, // 3: ibctl.data.v1.ClosedLot.cost:type_name -> standard.money.v1.Money
+	3, // 4: ibctl.data.v1.ClosedLot.fifo_pnl_realized:type_name -> standard.money.v1.Money
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_ibctl_data_v1_closed_lot_proto_init() }
+func file_ibctl_data_v1_closed_lot_proto_init() {
+	if File_ibctl_data_v1_closed_lot_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ibctl_data_v1_closed_lot_proto_rawDesc), len(file_ibctl_data_v1_closed_lot_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_ibctl_data_v1_closed_lot_proto_goTypes,
+		DependencyIndexes: file_ibctl_data_v1_closed_lot_proto_depIdxs,
+		MessageInfos:      file_ibctl_data_v1_closed_lot_proto_msgTypes,
+	}.Build()
+	File_ibctl_data_v1_closed_lot_proto = out.File
+	file_ibctl_data_v1_closed_lot_proto_goTypes = nil
+	file_ibctl_data_v1_closed_lot_proto_depIdxs = nil
+}
@@ -179,8 +179,13 @@ type ImportedTransaction struct {
 	Tax *v11.Money `protobuf:"bytes,14,opt,name=tax,proto3" json:"tax,omitempty"`
 	// Accrued interest on bond transactions.
 	AccruedInterest *v11.Money `protobuf:"bytes,15,opt,name=accrued_interest,json=accruedInterest,proto3" json:"accrued_interest,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+	// Set for transactions extracted best-effort from statements without
+	// structured data, such as PDF statements, which should be verified
+	// against the original statements. Their trades have the
+	// "statement_extract" source.
+	LowConfidence bool `protobuf:"varint,16,opt,name=low_confidence,json=lowConfidence,proto3" json:"low_confidence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ImportedTransaction) Reset() {
@@ -318,11 +323,18 @@ func (x *ImportedTransaction) GetAccruedInterest() *v11.Money {
 	return nil
 }
 
+func (x *ImportedTransaction) GetLowConfidence() bool {
+	if x != nil {
+		return x.LowConfidence
+	}
+	return false
+}
+
 var File_ibctl_data_v1_imported_transaction_proto protoreflect.FileDescriptor
 
 const file_ibctl_data_v1_imported_transaction_proto_rawDesc = "" +
 	"\n" +
-	"(ibctl/data/v1/imported_transaction.proto\x12\ribctl.data.v1\x1a\x1bbuf/validate/validate.proto\x1a\x1dstandard/money/v1/money.proto\x1a\x1bstandard/time/v1/date.proto\"\x83\x05\n" +
+	"(ibctl/data/v1/imported_transaction.proto\x12\ribctl.data.v1\x1a\x1bbuf/validate/validate.proto\x1a\x1dstandard/money/v1/money.proto\x1a\x1bstandard/time/v1/date.proto\"\xaa\x05\n" +
 	"\x13ImportedTransaction\x12%\n" +
 	"\n" +
 	"account_id\x18\x01 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\taccountId\x122\n" +
@@ -342,7 +354,8 @@ const file_ibctl_data_v1_imported_transaction_proto_rawDesc = "" +
 	"\vsecurity_id\x18\r \x01(\tR\n" +
 	"securityId\x12*\n" +
 	"\x03tax\x18\x0e \x01(\v2\x18.standard.money.v1.MoneyR\x03tax\x12C\n" +
-	"\x10accrued_interest\x18\x0f \x01(\v2\x18.standard.money.v1.MoneyR\x0faccruedInterest*\x9d\x05\n" +
+	"\x10accrued_interest\x18\x0f \x01(\v2\x18.standard.money.v1.MoneyR\x0faccruedInterest\x12%\n" +
+	"\x0elow_confidence\x18\x10 \x01(\bR\rlowConfidence*\x9d\x05\n" +
 	"\x17ImportedTransactionType\x12)\n" +
 	"%IMPORTED_TRANSACTION_TYPE_UNSPECIFIED\x10\x00\x12!\n" +
 	"\x1dIMPORTED_TRANSACTION_TYPE_BUY\x10\x01\x12\"\n" +
@@ -0,0 +1,368 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: ibctl/data/v1/merged_data.proto
+
+package datav1
+
+import (
+	_ "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// MergedData is a cached result of merging Flex Query data, Activity Statement
+// CSVs, and seed data across all accounts.
+//
+// The cache is valid only while input_fingerprint matches the fingerprint of
+// the current input files.
+type MergedData struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The hex-encoded SHA-256 fingerprint of all merge input files and account aliases.
+	InputFingerprint string `protobuf:"bytes,1,opt,name=input_fingerprint,json=inputFingerprint,proto3" json:"input_fingerprint,omitempty"`
+	// The deduplicated, sorted list of all trades across all accounts.
+	Trades []*Trade `protobuf:"bytes,2,rep,name=trades,proto3" json:"trades,omitempty"`
+	// The most recent set of open positions across all accounts.
+	Positions []*Position `protobuf:"bytes,3,rep,name=positions,proto3" json:"positions,omitempty"`
+	// The list of position transfers across all accounts.
+	Transfers []*Transfer `protobuf:"bytes,4,rep,name=transfers,proto3" json:"transfers,omitempty"`
+	// The list of transferred trade cost basis records across all accounts.
+	TradeTransfers []*TradeTransfer `protobuf:"bytes,5,rep,name=trade_transfers,json=tradeTransfers,proto3" json:"trade_transfers,omitempty"`
+	// The list of corporate action events across all accounts.
+	CorporateActions []*CorporateAction `protobuf:"bytes,6,rep,name=corporate_actions,json=corporateActions,proto3" json:"corporate_actions,omitempty"`
+	// The list of cash balances by currency across all accounts.
+	CashPositions []*CashPosition `protobuf:"bytes,7,rep,name=cash_positions,json=cashPositions,proto3" json:"cash_positions,omitempty"`
+	// The list of cash transactions across all accounts.
+	CashTransactions []*CashTransaction `protobuf:"bytes,8,rep,name=cash_transactions,json=cashTransactions,proto3" json:"cash_transactions,omitempty"`
+	// The CSV trades suppressed because they matched Flex Query trades.
+	DuplicateMatches []*DuplicateMatch `protobuf:"bytes,9,rep,name=duplicate_matches,json=duplicateMatches,proto3" json:"duplicate_matches,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *MergedData) Reset() {
+	*x = MergedData{}
+	mi := &file_ibctl_data_v1_merged_data_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MergedData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MergedData) ProtoMessage() {}
+
+func (x *MergedData) ProtoReflect() protoreflect.Message {
+	mi := &file_ibctl_data_v1_merged_data_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MergedData.ProtoReflect.Descriptor instead.
+func (*MergedData) Descriptor() ([]byte, []int) {
+	return file_ibctl_data_v1_merged_data_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *MergedData) GetInputFingerprint() string {
+	if x != nil {
+		return x.InputFingerprint
+	}
+	return ""
+}
+
+func (x *MergedData) GetTrades() []*Trade {
+	if x != nil {
+		return x.Trades
+	}
+	return nil
+}
+
+func (x *MergedData) GetPositions() []*Position {
+	if x != nil {
+		return x.Positions
+	}
+	return nil
+}
+
+func (x *MergedData) GetTransfers() []*Transfer {
+	if x != nil {
+		return x.Transfers
+	}
+	return nil
+}
+
+func (x *MergedData) GetTradeTransfers() []*TradeTransfer {
+	if x != nil {
+		return x.TradeTransfers
+	}
+	return nil
+}
+
+func (x *MergedData) GetCorporateActions() []*CorporateAction {
+	if x != nil {
+		return x.CorporateActions
+	}
+	return nil
+}
+
+func (x *MergedData) GetCashPositions() []*CashPosition {
+	if x != nil {
+		return x.CashPositions
+	}
+	return nil
+}
+
+func (x *MergedData) GetCashTransactions() []*CashTransaction {
+	if x != nil {
+		return x.CashTransactions
+	}
+	return nil
+}
+
+func (x *MergedData) GetDuplicateMatches() []*DuplicateMatch {
+	if x != nil {
+		return x.DuplicateMatches
+	}
+	return nil
+}
+
+// DuplicateMatch records a set of CSV trades suppressed as duplicates of a set
+// of Flex Query trades.
+type DuplicateMatch struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The account alias.
+	AccountId string `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	// The ticker symbol.
+	Symbol string `protobuf:"bytes,2,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	// The trade date (YYYY-MM-DD).
+	Date string `protobuf:"bytes,3,opt,name=date,proto3" json:"date,omitempty"`
+	// The matched signed quantity.
+	Quantity string `protobuf:"bytes,4,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	// The (weighted average) CSV trade price.
+	CsvPrice string `protobuf:"bytes,5,opt,name=csv_price,json=csvPrice,proto3" json:"csv_price,omitempty"`
+	// The (weighted average) Flex Query trade price.
+	FlexPrice string `protobuf:"bytes,6,opt,name=flex_price,json=flexPrice,proto3" json:"flex_price,omitempty"`
+	// The suppressed CSV trade IDs.
+	CsvTradeIds []string `protobuf:"bytes,7,rep,name=csv_trade_ids,json=csvTradeIds,proto3" json:"csv_trade_ids,omitempty"`
+	// The Flex Query trade IDs that were kept.
+	FlexTradeIds []string `protobuf:"bytes,8,rep,name=flex_trade_ids,json=flexTradeIds,proto3" json:"flex_trade_ids,omitempty"`
+	// How the trades were matched: "execution", "order", or "day".
+	Match         string `protobuf:"bytes,9,opt,name=match,proto3" json:"match,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DuplicateMatch) Reset() {
+	*x = DuplicateMatch{}
+	mi := &file_ibctl_data_v1_merged_data_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DuplicateMatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DuplicateMatch) ProtoMessage() {}
+
+func (x *DuplicateMatch) ProtoReflect() protoreflect.Message {
+	mi := &file_ibctl_data_v1_merged_data_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DuplicateMatch.ProtoReflect.Descriptor instead.
+func (*DuplicateMatch) Descriptor() ([]byte, []int) {
+	return file_ibctl_data_v1_merged_data_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DuplicateMatch) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *DuplicateMatch) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *DuplicateMatch) GetDate() string {
+	if x != nil {
+		return x.Date
+	}
+	return ""
+}
+
+func (x *DuplicateMatch) GetQuantity() string {
+	if x != nil {
+		return x.Quantity
+	}
+	return ""
+}
+
+func (x *DuplicateMatch) GetCsvPrice() string {
+	if x != nil {
+		return x.CsvPrice
+	}
+	return ""
+}
+
+func (x *DuplicateMatch) GetFlexPrice() string {
+	if x != nil {
+		return x.FlexPrice
+	}
+	return ""
+}
+
+func (x *DuplicateMatch) GetCsvTradeIds() []string {
+	if x != nil {
+		return x.CsvTradeIds
+	}
+	return nil
+}
+
+func (x *DuplicateMatch) GetFlexTradeIds() []string {
+	if x != nil {
+		return x.FlexTradeIds
+	}
+	return nil
+}
+
+func (x *DuplicateMatch) GetMatch() string {
+	if x != nil {
+		return x.Match
+	}
+	return ""
+}
+
+var File_ibctl_data_v1_merged_data_proto protoreflect.FileDescriptor
+
+const file_ibctl_data_v1_merged_data_proto_rawDesc = "" +
+	"\n" +
+	"\x1fibctl/data/v1/merged_data.proto\x12\ribctl.data.v1\x1a\x1bbuf/validate/validate.proto\x1a!ibctl/data/v1/cash_position.proto\x1a$ibctl/data/v1/cash_transaction.proto\x1a$ibctl/data/v1/corporate_action.proto\x1a\x1cibctl/data/v1/position.proto\x1a\x19ibctl/data/v1/trade.proto\x1a\x1cibctl/data/v1/transfer.proto\"\xce\x04\n" +
+	"\n" +
+	"MergedData\x123\n" +
+	"\x11input_fingerprint\x18\x01 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\x10inputFingerprint\x12,\n" +
+	"\x06trades\x18\x02 \x03(\v2\x14.ibctl.data.v1.TradeR\x06trades\x125\n" +
+	"\tpositions\x18\x03 \x03(\v2\x17.ibctl.data.v1.PositionR\tpositions\x125\n" +
+	"\ttransfers\x18\x04 \x03(\v2\x17.ibctl.data.v1.TransferR\ttransfers\x12E\n" +
+	"\x0ftrade_transfers\x18\x05 \x03(\v2\x1c.ibctl.data.v1.TradeTransferR\x0etradeTransfers\x12K\n" +
+	"\x11corporate_actions\x18\x06 \x03(\v2\x1e.ibctl.data.v1.CorporateActionR\x10corporateActions\x12B\n" +
+	"\x0ecash_positions\x18\a \x03(\v2\x1b.ibctl.data.v1.CashPositionR\rcashPositions\x12K\n" +
+	"\x11cash_transactions\x18\b \x03(\v2\x1e.ibctl.data.v1.CashTransactionR\x10cashTransactions\x12J\n" +
+	"\x11duplicate_matches\x18\t \x03(\v2\x1d.ibctl.data.v1.DuplicateMatchR\x10duplicateMatches\"\xab\x02\n" +
+	"\x0eDuplicateMatch\x12%\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\taccountId\x12\x1e\n" +
+	"\x06symbol\x18\x02 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\x06symbol\x12\x1a\n" +
+	"\x04date\x18\x03 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\x04date\x12\x1a\n" +
+	"\bquantity\x18\x04 \x01(\tR\bquantity\x12\x1b\n" +
+	"\tcsv_price\x18\x05 \x01(\tR\bcsvPrice\x12\x1d\n" +
+	"\n" +
+	"flex_price\x18\x06 \x01(\tR\tflexPrice\x12\"\n" +
+	"\rcsv_trade_ids\x18\a \x03(\tR\vcsvTradeIds\x12$\n" +
+	"\x0eflex_trade_ids\x18\b \x03(\tR\fflexTradeIds\x12\x14\n" +
+	"\x05match\x18\t \x01(\tR\x05matchB\xbe\x01\n" +
+	"\x11com.ibctl.data.v1B\x0fMergedDataProtoP\x01ZBgithub.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1;datav1\xa2\x02\x03IDX\xaa\x02\rIbctl.Data.V1\xca\x02\rIbctl\\Data\\V1\xe2\x02\x19Ibctl\\Data\\V1\\GPBMetadata\xea\x02\x0fIbctl::Data::V1b\x06proto3"
+
+var (
+	file_ibctl_data_v1_merged_data_proto_rawDescOnce sync.Once
+	file_ibctl_data_v1_merged_data_proto_rawDescData []byte
+)
+
+func file_ibctl_data_v1_merged_data_proto_rawDescGZIP() []byte {
+	file_ibctl_data_v1_merged_data_proto_rawDescOnce.Do(func() {
+		file_ibctl_data_v1_merged_data_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_ibctl_data_v1_merged_data_proto_rawDesc), len(file_ibctl_data_v1_merged_data_proto_rawDesc)))
+	})
+	return file_ibctl_data_v1_merged_data_proto_rawDescData
+}
+
+var file_ibctl_data_v1_merged_data_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_ibctl_data_v1_merged_data_proto_goTypes = []any{
+	(*MergedData)(nil),      // 0: ibctl.data.v1.MergedData
+	(*DuplicateMatch)(nil),  // 1: ibctl.data.v1.DuplicateMatch
+	(*Trade)(nil),           // 2: ibctl.data.v1.Trade
+	(*Position)(nil),        // 3: ibctl.data.v1.Position
+	(*Transfer)(nil),        // 4: ibctl.data.v1.Transfer
+	(*TradeTransfer)(nil),   // 5: ibctl.data.v1.TradeTransfer
+	(*CorporateAction)(nil), // 6: ibctl.data.v1.CorporateAction
+	(*CashPosition)(nil),    // 7: ibctl.data.v1.CashPosition
+	(*CashTransaction)(nil), // 8: ibctl.data.v1.CashTransaction
+}
+var file_ibctl_data_v1_merged_data_proto_depIdxs = []int32{
+	2, // 0: ibctl.data.v1.MergedData.trades:type_name -> ibctl.data.v1.Trade
+	3, // 1: ibctl.data.v1.MergedData.positions:type_name -> ibctl.data.v1.Position
+	4, // 2: ibctl.data.v1.MergedData.transfers:type_name -> ibctl.data.v1.Transfer
+	5, // 3: ibctl.data.v1.MergedData.trade_transfers:type_name -> ibctl.data.v1.TradeTransfer
+	6, // 4: ibctl.data.v1.MergedData.corporate_actions:type_name -> ibctl.data.v1.CorporateAction
+	7, // 5: ibctl.data.v1.MergedData.cash_positions:type_name -> ibctl.data.v1.CashPosition
+	8, // 6: ibctl.data.v1.MergedData.cash_transactions:type_name -> ibctl.data.v1.CashTransaction
+	1, // 7: ibctl.data.v1.MergedData.duplicate_matches:type_name -> ibctl.data.v1.DuplicateMatch
+	8, // [8:8] is the sub-list for method output_type
+	8, // [8:8] is the sub-list for method input_type
+	8, // [8:8] is the sub-list for extension type_name
+	8, // [8:8] is the sub-list for extension extendee
+	0, // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_ibctl_data_v1_merged_data_proto_init() }
+func file_ibctl_data_v1_merged_data_proto_init() {
+	if File_ibctl_data_v1_merged_data_proto != nil {
+		return
+	}
+	file_ibctl_data_v1_cash_position_proto_init()
+	file_ibctl_data_v1_cash_transaction_proto_init()
+	file_ibctl_data_v1_corporate_action_proto_init()
+	file_ibctl_data_v1_position_proto_init()
+	file_ibctl_data_v1_trade_proto_init()
+	file_ibctl_data_v1_transfer_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ibctl_data_v1_merged_data_proto_rawDesc), len(file_ibctl_data_v1_merged_data_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_ibctl_data_v1_merged_data_proto_goTypes,
+		DependencyIndexes: file_ibctl_data_v1_merged_data_proto_depIdxs,
+		MessageInfos:      file_ibctl_data_v1_merged_data_proto_msgTypes,
+	}.Build()
+	File_ibctl_data_v1_merged_data_proto = out.File
+	file_ibctl_data_v1_merged_data_proto_goTypes = nil
+	file_ibctl_data_v1_merged_data_proto_depIdxs = nil
+}
@@ -44,7 +44,15 @@ type TaxLot struct {
 	// The cost_basis_price Money field must use this same currency code.
 	CurrencyCode string `protobuf:"bytes,5,opt,name=currency_code,json=currencyCode,proto3" json:"currency_code,omitempty"`
 	// The account alias this tax lot belongs to (e.g., "rrsp", "holdco").
-	AccountId     string `protobuf:"bytes,6,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	AccountId string `protobuf:"bytes,6,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	// The source of the trade that opened the lot (see Trade.source).
+	// Empty for lots opened by IBKR-reported trades.
+	Source string `protobuf:"bytes,7,opt,name=source,proto3" json:"source,omitempty"`
+	// The deterministic lot identifier, formatted as
+	// "<account_id>/<symbol>/<YYYY-MM-DD>/<sequence>". The sequence numbers lots
+	// opened for the same account, symbol, and date from 1 in FIFO order, and is
+	// assigned when the lot is opened so it stays stable as earlier lots close.
+	LotId         string `protobuf:"bytes,8,opt,name=lot_id,json=lotId,proto3" json:"lot_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -121,6 +129,20 @@ func (x *TaxLot) GetAccountId() string {
 	return ""
 }
 
+func (x *TaxLot) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *TaxLot) GetLotId() string {
+	if x != nil {
+		return x.LotId
+	}
+	return ""
+}
+
 // ComputedPosition represents a position derived from tax lots.
 type ComputedPosition struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -208,7 +230,7 @@ var File_ibctl_data_v1_taxlot_proto protoreflect.FileDescriptor
 
 const file_ibctl_data_v1_taxlot_proto_rawDesc = "" +
 	"\n" +
-	"\x1aibctl/data/v1/taxlot.proto\x12\ribctl.data.v1\x1a\x1bbuf/validate/validate.proto\x1a\x1estandard/math/v1/decimal.proto\x1a\x1dstandard/money/v1/money.proto\x1a\x1bstandard/time/v1/date.proto\"\xf0\x03\n" +
+	"\x1aibctl/data/v1/taxlot.proto\x12\ribctl.data.v1\x1a\x1bbuf/validate/validate.proto\x1a\x1estandard/math/v1/decimal.proto\x1a\x1dstandard/money/v1/money.proto\x1a\x1bstandard/time/v1/date.proto\"\x9f\x04\n" +
 	"\x06TaxLot\x12\x1e\n" +
 	"\x06symbol\x18\x01 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\x06symbol\x12;\n" +
 	"\topen_date\x18\x02 \x01(\v2\x16.standard.time.v1.DateB\x06\xbaH\x03\xc8\x01\x01R\bopenDate\x12=\n" +
@@ -217,7 +239,9 @@ const file_ibctl_data_v1_taxlot_proto_rawDesc = "" +
 	"\rcurrency_code\x18\x05 \x01(\tB\x11\xbaH\x0er\f2\n" +
 	"^[A-Z]{3}$R\fcurrencyCode\x12%\n" +
 	"\n" +
-	"account_id\x18\x06 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\taccountId:\x9e\x01\xbaH\x9a\x01\x1a\x97\x01\n" +
+	"account_id\x18\x06 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\taccountId\x12\x16\n" +
+	"\x06source\x18\a \x01(\tR\x06source\x12\x15\n" +
+	"\x06lot_id\x18\b \x01(\tR\x05lotId:\x9e\x01\xbaH\x9a\x01\x1a\x97\x01\n" +
 	"\x19cost_basis_price_currency\x12?cost_basis_price currency_code must match tax lot currency_code\x1a9this.cost_basis_price.currency_code == this.currency_code\"\xee\x03\n" +
 	"\x10ComputedPosition\x12\x1e\n" +
 	"\x06symbol\x18\x01 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\x06symbol\x12=\n" +
@@ -110,7 +110,13 @@ type Trade struct {
 	// The realized P&L computed by IBKR using FIFO.
 	FifoPnlRealized *v12.Money `protobuf:"bytes,13,opt,name=fifo_pnl_realized,json=fifoPnlRealized,proto3" json:"fifo_pnl_realized,omitempty"`
 	// The account alias this trade belongs to (e.g., "rrsp", "holdco").
-	AccountId     string `protobuf:"bytes,14,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	AccountId string `protobuf:"bytes,14,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	// The source of a trade not reported by IBKR (e.g., "manual", "transfer_basis").
+	// Empty for trades from Flex Queries and Activity Statements.
+	Source string `protobuf:"bytes,15,opt,name=source,proto3" json:"source,omitempty"`
+	// The IBKR order ID (ibOrderID). Legs of a multi-leg or combo order share
+	// the same order ID. Empty if not available (e.g., Activity Statement trades).
+	OrderId       string `protobuf:"bytes,16,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -243,11 +249,25 @@ func (x *Trade) GetAccountId() string {
 	return ""
 }
 
+func (x *Trade) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Trade) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
 var File_ibctl_data_v1_trade_proto protoreflect.FileDescriptor
 
 const file_ibctl_data_v1_trade_proto_rawDesc = "" +
 	"\n" +
-	"\x19ibctl/data/v1/trade.proto\x12\ribctl.data.v1\x1a\x1bbuf/validate/validate.proto\x1a\x1estandard/math/v1/decimal.proto\x1a\x1dstandard/money/v1/money.proto\x1a\x1bstandard/time/v1/date.proto\"\xca\n" +
+	"\x19ibctl/data/v1/trade.proto\x12\ribctl.data.v1\x1a\x1bbuf/validate/validate.proto\x1a\x1estandard/math/v1/decimal.proto\x1a\x1dstandard/money/v1/money.proto\x1a\x1bstandard/time/v1/date.proto\"\xfd\n" +
 	"\n" +
 	"\x05Trade\x12!\n" +
 	"\btrade_id\x18\x01 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\atradeId\x12=\n" +
@@ -271,7 +291,9 @@ const file_ibctl_data_v1_trade_proto_rawDesc = "" +
 	"^[A-Z]{3}$R\fcurrencyCode\x12D\n" +
 	"\x11fifo_pnl_realized\x18\r \x01(\v2\x18.standard.money.v1.MoneyR\x0ffifoPnlRealized\x12%\n" +
 	"\n" +
-	"account_id\x18\x0e \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\taccountId:\xcd\x04\xbaH\xc9\x04\x1a\x86\x01\n" +
+	"account_id\x18\x0e \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\taccountId\x12\x16\n" +
+	"\x06source\x18\x0f \x01(\tR\x06source\x12\x19\n" +
+	"\border_id\x18\x10 \x01(\tR\aorderId:\xcd\x04\xbaH\xc9\x04\x1a\x86\x01\n" +
 	"\x14trade_price_currency\x128trade_price currency_code must match trade currency_code\x1a4this.trade_price.currency_code == this.currency_code\x1a}\n" +
 	"\x11proceeds_currency\x125proceeds currency_code must match trade currency_code\x1a1this.proceeds.currency_code == this.currency_code\x1a\x83\x01\n" +
 	"\x13commission_currency\x127commission currency_code must match trade currency_code\x1a3this.commission.currency_code == this.currency_code\x1a\xb8\x01\n" +
@@ -0,0 +1,969 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: ibctl/service/v1/service.proto
+
+package servicev1
+
+import (
+	v1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/math/v1"
+	v12 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/money/v1"
+	v11 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/time/v1"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type DownloadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadRequest) Reset() {
+	*x = DownloadRequest{}
+	mi := &file_ibctl_service_v1_service_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadRequest) ProtoMessage() {}
+
+func (x *DownloadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ibctl_service_v1_service_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadRequest.ProtoReflect.Descriptor instead.
+func (*DownloadRequest) Descriptor() ([]byte, []int) {
+	return file_ibctl_service_v1_service_proto_rawDescGZIP(), []int{0}
+}
+
+type DownloadResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadResponse) Reset() {
+	*x = DownloadResponse{}
+	mi := &file_ibctl_service_v1_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadResponse) ProtoMessage() {}
+
+func (x *DownloadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ibctl_service_v1_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadResponse.ProtoReflect.Descriptor instead.
+func (*DownloadResponse) Descriptor() ([]byte, []int) {
+	return file_ibctl_service_v1_service_proto_rawDescGZIP(), []int{1}
+}
+
+type GetHoldingsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The account group from ibctl.yaml to restrict to. All accounts if empty.
+	Group         string `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetHoldingsRequest) Reset() {
+	*x = GetHoldingsRequest{}
+	mi := &file_ibctl_service_v1_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetHoldingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetHoldingsRequest) ProtoMessage() {}
+
+func (x *GetHoldingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ibctl_service_v1_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetHoldingsRequest.ProtoReflect.Descriptor instead.
+func (*GetHoldingsRequest) Descriptor() ([]byte, []int) {
+	return file_ibctl_service_v1_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetHoldingsRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+type GetHoldingsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Holdings      []*Holding             `protobuf:"bytes,1,rep,name=holdings,proto3" json:"holdings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetHoldingsResponse) Reset() {
+	*x = GetHoldingsResponse{}
+	mi := &file_ibctl_service_v1_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetHoldingsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetHoldingsResponse) ProtoMessage() {}
+
+func (x *GetHoldingsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ibctl_service_v1_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetHoldingsResponse.ProtoReflect.Descriptor instead.
+func (*GetHoldingsResponse) Descriptor() ([]byte, []int) {
+	return file_ibctl_service_v1_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetHoldingsResponse) GetHoldings() []*Holding {
+	if x != nil {
+		return x.Holdings
+	}
+	return nil
+}
+
+type GetLotsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The account group from ibctl.yaml to restrict to. All accounts if empty.
+	Group string `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	// The symbol to restrict to. All symbols if empty.
+	Symbol        string `protobuf:"bytes,2,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetLotsRequest) Reset() {
+	*x = GetLotsRequest{}
+	mi := &file_ibctl_service_v1_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetLotsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLotsRequest) ProtoMessage() {}
+
+func (x *GetLotsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ibctl_service_v1_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLotsRequest.ProtoReflect.Descriptor instead.
+func (*GetLotsRequest) Descriptor() ([]byte, []int) {
+	return file_ibctl_service_v1_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetLotsRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *GetLotsRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+type GetLotsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Lots          []*Lot                 `protobuf:"bytes,1,rep,name=lots,proto3" json:"lots,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetLotsResponse) Reset() {
+	*x = GetLotsResponse{}
+	mi := &file_ibctl_service_v1_service_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetLotsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLotsResponse) ProtoMessage() {}
+
+func (x *GetLotsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ibctl_service_v1_service_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLotsResponse.ProtoReflect.Descriptor instead.
+func (*GetLotsResponse) Descriptor() ([]byte, []int) {
+	return file_ibctl_service_v1_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetLotsResponse) GetLots() []*Lot {
+	if x != nil {
+		return x.Lots
+	}
+	return nil
+}
+
+type GetRealizedGainsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The account group from ibctl.yaml to restrict to. All accounts if empty.
+	Group         string `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRealizedGainsRequest) Reset() {
+	*x = GetRealizedGainsRequest{}
+	mi := &file_ibctl_service_v1_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRealizedGainsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRealizedGainsRequest) ProtoMessage() {}
+
+func (x *GetRealizedGainsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ibctl_service_v1_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRealizedGainsRequest.ProtoReflect.Descriptor instead.
+func (*GetRealizedGainsRequest) Descriptor() ([]byte, []int) {
+	return file_ibctl_service_v1_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetRealizedGainsRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+type GetRealizedGainsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RealizedGains []*RealizedGain        `protobuf:"bytes,1,rep,name=realized_gains,json=realizedGains,proto3" json:"realized_gains,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRealizedGainsResponse) Reset() {
+	*x = GetRealizedGainsResponse{}
+	mi := &file_ibctl_service_v1_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRealizedGainsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRealizedGainsResponse) ProtoMessage() {}
+
+func (x *GetRealizedGainsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ibctl_service_v1_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRealizedGainsResponse.ProtoReflect.Descriptor instead.
+func (*GetRealizedGainsResponse) Descriptor() ([]byte, []int) {
+	return file_ibctl_service_v1_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetRealizedGainsResponse) GetRealizedGains() []*RealizedGain {
+	if x != nil {
+		return x.RealizedGains
+	}
+	return nil
+}
+
+// Holding is a single holding aggregated across accounts.
+//
+// Prices and values are formatted decimal strings, as in "ibctl holding list
+// --format json".
+type Holding struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The ticker symbol.
+	Symbol string `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	// The native currency of last_price and average_price.
+	CurrencyCode string `protobuf:"bytes,2,opt,name=currency_code,json=currencyCode,proto3" json:"currency_code,omitempty"`
+	// The most recent market price per share in the native currency.
+	LastPrice string `protobuf:"bytes,3,opt,name=last_price,json=lastPrice,proto3" json:"last_price,omitempty"`
+	// The weighted average cost basis price per share in the native currency.
+	AveragePrice string `protobuf:"bytes,4,opt,name=average_price,json=averagePrice,proto3" json:"average_price,omitempty"`
+	// The last price in USD.
+	LastPriceUsd string `protobuf:"bytes,5,opt,name=last_price_usd,json=lastPriceUsd,proto3" json:"last_price_usd,omitempty"`
+	// The average cost basis price in USD.
+	AveragePriceUsd string `protobuf:"bytes,6,opt,name=average_price_usd,json=averagePriceUsd,proto3" json:"average_price_usd,omitempty"`
+	// The market value in USD.
+	MarketValueUsd string `protobuf:"bytes,7,opt,name=market_value_usd,json=marketValueUsd,proto3" json:"market_value_usd,omitempty"`
+	// The unrealized P&L in USD.
+	UnrealizedPnlUsd string `protobuf:"bytes,8,opt,name=unrealized_pnl_usd,json=unrealizedPnlUsd,proto3" json:"unrealized_pnl_usd,omitempty"`
+	// The short-term unrealized P&L in USD.
+	StcgUsd string `protobuf:"bytes,9,opt,name=stcg_usd,json=stcgUsd,proto3" json:"stcg_usd,omitempty"`
+	// The long-term unrealized P&L in USD.
+	LtcgUsd string `protobuf:"bytes,10,opt,name=ltcg_usd,json=ltcgUsd,proto3" json:"ltcg_usd,omitempty"`
+	// The total quantity held.
+	Position *v1.Decimal `protobuf:"bytes,11,opt,name=position,proto3" json:"position,omitempty"`
+	// The user-defined asset category.
+	Category string `protobuf:"bytes,12,opt,name=category,proto3" json:"category,omitempty"`
+	// The user-defined asset type.
+	Type string `protobuf:"bytes,13,opt,name=type,proto3" json:"type,omitempty"`
+	// The user-defined sector.
+	Sector string `protobuf:"bytes,14,opt,name=sector,proto3" json:"sector,omitempty"`
+	// The user-defined geography.
+	Geo           string `protobuf:"bytes,15,opt,name=geo,proto3" json:"geo,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Holding) Reset() {
+	*x = Holding{}
+	mi := &file_ibctl_service_v1_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Holding) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Holding) ProtoMessage() {}
+
+func (x *Holding) ProtoReflect() protoreflect.Message {
+	mi := &file_ibctl_service_v1_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Holding.ProtoReflect.Descriptor instead.
+func (*Holding) Descriptor() ([]byte, []int) {
+	return file_ibctl_service_v1_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Holding) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Holding) GetCurrencyCode() string {
+	if x != nil {
+		return x.CurrencyCode
+	}
+	return ""
+}
+
+func (x *Holding) GetLastPrice() string {
+	if x != nil {
+		return x.LastPrice
+	}
+	return ""
+}
+
+func (x *Holding) GetAveragePrice() string {
+	if x != nil {
+		return x.AveragePrice
+	}
+	return ""
+}
+
+func (x *Holding) GetLastPriceUsd() string {
+	if x != nil {
+		return x.LastPriceUsd
+	}
+	return ""
+}
+
+func (x *Holding) GetAveragePriceUsd() string {
+	if x != nil {
+		return x.AveragePriceUsd
+	}
+	return ""
+}
+
+func (x *Holding) GetMarketValueUsd() string {
+	if x != nil {
+		return x.MarketValueUsd
+	}
+	return ""
+}
+
+func (x *Holding) GetUnrealizedPnlUsd() string {
+	if x != nil {
+		return x.UnrealizedPnlUsd
+	}
+	return ""
+}
+
+func (x *Holding) GetStcgUsd() string {
+	if x != nil {
+		return x.StcgUsd
+	}
+	return ""
+}
+
+func (x *Holding) GetLtcgUsd() string {
+	if x != nil {
+		return x.LtcgUsd
+	}
+	return ""
+}
+
+func (x *Holding) GetPosition() *v1.Decimal {
+	if x != nil {
+		return x.Position
+	}
+	return nil
+}
+
+func (x *Holding) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *Holding) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Holding) GetSector() string {
+	if x != nil {
+		return x.Sector
+	}
+	return ""
+}
+
+func (x *Holding) GetGeo() string {
+	if x != nil {
+		return x.Geo
+	}
+	return ""
+}
+
+// Lot is a single open tax lot.
+//
+// Prices and values are formatted decimal strings, as in "ibctl holding lot
+// list --format json".
+type Lot struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The ticker symbol.
+	Symbol string `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	// The account alias.
+	AccountId string `protobuf:"bytes,2,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	// The date the lot was opened.
+	OpenDate *v11.Date `protobuf:"bytes,3,opt,name=open_date,json=openDate,proto3" json:"open_date,omitempty"`
+	// The remaining quantity.
+	Quantity *v1.Decimal `protobuf:"bytes,4,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	// The native currency code.
+	CurrencyCode string `protobuf:"bytes,5,opt,name=currency_code,json=currencyCode,proto3" json:"currency_code,omitempty"`
+	// The cost basis price per share in the native currency.
+	AveragePrice string `protobuf:"bytes,6,opt,name=average_price,json=averagePrice,proto3" json:"average_price,omitempty"`
+	// The unrealized P&L in the native currency.
+	Pnl string `protobuf:"bytes,7,opt,name=pnl,proto3" json:"pnl,omitempty"`
+	// The market value in the native currency.
+	Value string `protobuf:"bytes,8,opt,name=value,proto3" json:"value,omitempty"`
+	// The cost basis price per share in USD.
+	AveragePriceUsd string `protobuf:"bytes,9,opt,name=average_price_usd,json=averagePriceUsd,proto3" json:"average_price_usd,omitempty"`
+	// The unrealized P&L in USD.
+	PnlUsd string `protobuf:"bytes,10,opt,name=pnl_usd,json=pnlUsd,proto3" json:"pnl_usd,omitempty"`
+	// The market value in USD.
+	ValueUsd string `protobuf:"bytes,11,opt,name=value_usd,json=valueUsd,proto3" json:"value_usd,omitempty"`
+	// The short-term unrealized P&L in USD.
+	StcgUsd string `protobuf:"bytes,12,opt,name=stcg_usd,json=stcgUsd,proto3" json:"stcg_usd,omitempty"`
+	// The long-term unrealized P&L in USD.
+	LtcgUsd string `protobuf:"bytes,13,opt,name=ltcg_usd,json=ltcgUsd,proto3" json:"ltcg_usd,omitempty"`
+	// Where the lot's opening trade came from if not IBKR (e.g., "manual").
+	Source        string `protobuf:"bytes,14,opt,name=source,proto3" json:"source,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Lot) Reset() {
+	*x = Lot{}
+	mi := &file_ibctl_service_v1_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Lot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Lot) ProtoMessage() {}
+
+func (x *Lot) ProtoReflect() protoreflect.Message {
+	mi := &file_ibctl_service_v1_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Lot.ProtoReflect.Descriptor instead.
+func (*Lot) Descriptor() ([]byte, []int) {
+	return file_ibctl_service_v1_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Lot) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Lot) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *Lot) GetOpenDate() *v11.Date {
+	if x != nil {
+		return x.OpenDate
+	}
+	return nil
+}
+
+func (x *Lot) GetQuantity() *v1.Decimal {
+	if x != nil {
+		return x.Quantity
+	}
+	return nil
+}
+
+func (x *Lot) GetCurrencyCode() string {
+	if x != nil {
+		return x.CurrencyCode
+	}
+	return ""
+}
+
+func (x *Lot) GetAveragePrice() string {
+	if x != nil {
+		return x.AveragePrice
+	}
+	return ""
+}
+
+func (x *Lot) GetPnl() string {
+	if x != nil {
+		return x.Pnl
+	}
+	return ""
+}
+
+func (x *Lot) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *Lot) GetAveragePriceUsd() string {
+	if x != nil {
+		return x.AveragePriceUsd
+	}
+	return ""
+}
+
+func (x *Lot) GetPnlUsd() string {
+	if x != nil {
+		return x.PnlUsd
+	}
+	return ""
+}
+
+func (x *Lot) GetValueUsd() string {
+	if x != nil {
+		return x.ValueUsd
+	}
+	return ""
+}
+
+func (x *Lot) GetStcgUsd() string {
+	if x != nil {
+		return x.StcgUsd
+	}
+	return ""
+}
+
+func (x *Lot) GetLtcgUsd() string {
+	if x != nil {
+		return x.LtcgUsd
+	}
+	return ""
+}
+
+func (x *Lot) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+// RealizedGain is the gain or loss from closing all or part of a tax lot.
+type RealizedGain struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The account alias.
+	AccountId string `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	// The ticker symbol.
+	Symbol string `protobuf:"bytes,2,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	// The date the lot was opened.
+	OpenDate *v11.Date `protobuf:"bytes,3,opt,name=open_date,json=openDate,proto3" json:"open_date,omitempty"`
+	// The trade date of the closing trade.
+	CloseDate *v11.Date `protobuf:"bytes,4,opt,name=close_date,json=closeDate,proto3" json:"close_date,omitempty"`
+	// The closed quantity, always positive.
+	Quantity *v1.Decimal `protobuf:"bytes,5,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	// The realized gain (negative for a loss) in the trade currency, excluding commissions.
+	Gain *v12.Money `protobuf:"bytes,6,opt,name=gain,proto3" json:"gain,omitempty"`
+	// Whether the lot was held for at least 365 days.
+	LongTerm      bool `protobuf:"varint,7,opt,name=long_term,json=longTerm,proto3" json:"long_term,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RealizedGain) Reset() {
+	*x = RealizedGain{}
+	mi := &file_ibctl_service_v1_service_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RealizedGain) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RealizedGain) ProtoMessage() {}
+
+func (x *RealizedGain) ProtoReflect() protoreflect.Message {
+	mi := &file_ibctl_service_v1_service_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RealizedGain.ProtoReflect.Descriptor instead.
+func (*RealizedGain) Descriptor() ([]byte, []int) {
+	return file_ibctl_service_v1_service_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *RealizedGain) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *RealizedGain) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *RealizedGain) GetOpenDate() *v11.Date {
+	if x != nil {
+		return x.OpenDate
+	}
+	return nil
+}
+
+func (x *RealizedGain) GetCloseDate() *v11.Date {
+	if x != nil {
+		return x.CloseDate
+	}
+	return nil
+}
+
+func (x *RealizedGain) GetQuantity() *v1.Decimal {
+	if x != nil {
+		return x.Quantity
+	}
+	return nil
+}
+
+func (x *RealizedGain) GetGain() *v12.Money {
+	if x != nil {
+		return x.Gain
+	}
+	return nil
+}
+
+func (x *RealizedGain) GetLongTerm() bool {
+	if x != nil {
+		return x.LongTerm
+	}
+	return false
+}
+
+var File_ibctl_service_v1_service_proto protoreflect.FileDescriptor
+
+const file_ibctl_service_v1_service_proto_rawDesc = "" +
+	"\n" +
+	"\x1eibctl/service/v1/service.proto\x12\x10ibctl.service.v1\x1a\x1estandard/math/v1/decimal.proto\x1a\x1dstandard/money/v1/money.proto\x1a\x1bstandard/time/v1/date.proto\"\x11\n" +
+	"\x0fDownloadRequest\"\x12\n" +
+	"\x10DownloadResponse\"*\n" +
+	"\x12GetHoldingsRequest\x12\x14\n" +
+	"\x05group\x18\x01 \x01(\tR\x05group\"L\n" +
+	"\x13GetHoldingsResponse\x125\n" +
+	"\bholdings\x18\x01 \x03(\v2\x19.ibctl.service.v1.HoldingR\bholdings\">\n" +
+	"\x0eGetLotsRequest\x12\x14\n" +
+	"\x05group\x18\x01 \x01(\tR\x05group\x12\x16\n" +
+	"\x06symbol\x18\x02 \x01(\tR\x06symbol\"<\n" +
+	"\x0fGetLotsResponse\x12)\n" +
+	"\x04lots\x18\x01 \x03(\v2\x15.ibctl.service.v1.LotR\x04lots\"/\n" +
+	"\x17GetRealizedGainsRequest\x12\x14\n" +
+	"\x05group\x18\x01 \x01(\tR\x05group\"a\n" +
+	"\x18GetRealizedGainsResponse\x12E\n" +
+	"\x0erealized_gains\x18\x01 \x03(\v2\x1e.ibctl.service.v1.RealizedGainR\rrealizedGains\"\xfb\x03\n" +
+	"\aHolding\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12#\n" +
+	"\rcurrency_code\x18\x02 \x01(\tR\fcurrencyCode\x12\x1d\n" +
+	"\n" +
+	"last_price\x18\x03 \x01(\tR\tlastPrice\x12#\n" +
+	"\raverage_price\x18\x04 \x01(\tR\faveragePrice\x12$\n" +
+	"\x0elast_price_usd\x18\x05 \x01(\tR\flastPriceUsd\x12*\n" +
+	"\x11average_price_usd\x18\x06 \x01(\tR\x0faveragePriceUsd\x12(\n" +
+	"\x10market_value_usd\x18\a \x01(\tR\x0emarketValueUsd\x12,\n" +
+	"\x12unrealized_pnl_usd\x18\b \x01(\tR\x10unrealizedPnlUsd\x12\x19\n" +
+	"\bstcg_usd\x18\t \x01(\tR\astcgUsd\x12\x19\n" +
+	"\bltcg_usd\x18\n" +
+	" \x01(\tR\altcgUsd\x125\n" +
+	"\bposition\x18\v \x01(\v2\x19.standard.math.v1.DecimalR\bposition\x12\x1a\n" +
+	"\bcategory\x18\f \x01(\tR\bcategory\x12\x12\n" +
+	"\x04type\x18\r \x01(\tR\x04type\x12\x16\n" +
+	"\x06sector\x18\x0e \x01(\tR\x06sector\x12\x10\n" +
+	"\x03geo\x18\x0f \x01(\tR\x03geo\"\xca\x03\n" +
+	"\x03Lot\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x02 \x01(\tR\taccountId\x123\n" +
+	"\topen_date\x18\x03 \x01(\v2\x16.standard.time.v1.DateR\bopenDate\x125\n" +
+	"\bquantity\x18\x04 \x01(\v2\x19.standard.math.v1.DecimalR\bquantity\x12#\n" +
+	"\rcurrency_code\x18\x05 \x01(\tR\fcurrencyCode\x12#\n" +
+	"\raverage_price\x18\x06 \x01(\tR\faveragePrice\x12\x10\n" +
+	"\x03pnl\x18\a \x01(\tR\x03pnl\x12\x14\n" +
+	"\x05value\x18\b \x01(\tR\x05value\x12*\n" +
+	"\x11average_price_usd\x18\t \x01(\tR\x0faveragePriceUsd\x12\x17\n" +
+	"\apnl_usd\x18\n" +
+	" \x01(\tR\x06pnlUsd\x12\x1b\n" +
+	"\tvalue_usd\x18\v \x01(\tR\bvalueUsd\x12\x19\n" +
+	"\bstcg_usd\x18\f \x01(\tR\astcgUsd\x12\x19\n" +
+	"\bltcg_usd\x18\r \x01(\tR\altcgUsd\x12\x16\n" +
+	"\x06source\x18\x0e \x01(\tR\x06source\"\xb3\x02\n" +
+	"\fRealizedGain\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\tR\taccountId\x12\x16\n" +
+	"\x06symbol\x18\x02 \x01(\tR\x06symbol\x123\n" +
+	"\topen_date\x18\x03 \x01(\v2\x16.standard.time.v1.DateR\bopenDate\x125\n" +
+	"\n" +
+	"close_date\x18\x04 \x01(\v2\x16.standard.time.v1.DateR\tcloseDate\x125\n" +
+	"\bquantity\x18\x05 \x01(\v2\x19.standard.math.v1.DecimalR\bquantity\x12,\n" +
+	"\x04gain\x18\x06 \x01(\v2\x18.standard.money.v1.MoneyR\x04gain\x12\x1b\n" +
+	"\tlong_term\x18\a \x01(\bR\blongTerm2\x87\x03\n" +
+	"\fIbctlService\x12Q\n" +
+	"\bDownload\x12!.ibctl.service.v1.DownloadRequest\x1a\".ibctl.service.v1.DownloadResponse\x12_\n" +
+	"\vGetHoldings\x12$.ibctl.service.v1.GetHoldingsRequest\x1a%.ibctl.service.v1.GetHoldingsResponse\"\x03\x90\x02\x01\x12S\n" +
+	"\aGetLots\x12 .ibctl.service.v1.GetLotsRequest\x1a!.ibctl.service.v1.GetLotsResponse\"\x03\x90\x02\x01\x12n\n" +
+	"\x10GetRealizedGains\x12).ibctl.service.v1.GetRealizedGainsRequest\x1a*.ibctl.service.v1.GetRealizedGainsResponse\"\x03\x90\x02\x01B\xd0\x01\n" +
+	"\x14com.ibctl.service.v1B\fServiceProtoP\x01ZHgithub.com/bufdev/ibctl/internal/gen/proto/go/ibctl/service/v1;servicev1\xa2\x02\x03ISX\xaa\x02\x10Ibctl.Service.V1\xca\x02\x10Ibctl\\Service\\V1\xe2\x02\x1cIbctl\\Service\\V1\\GPBMetadata\xea\x02\x12Ibctl::Service::V1b\x06proto3"
+
+var (
+	file_ibctl_service_v1_service_proto_rawDescOnce sync.Once
+	file_ibctl_service_v1_service_proto_rawDescData []byte
+)
+
+func file_ibctl_service_v1_service_proto_rawDescGZIP() []byte {
+	file_ibctl_service_v1_service_proto_rawDescOnce.Do(func() {
+		file_ibctl_service_v1_service_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_ibctl_service_v1_service_proto_rawDesc), len(file_ibctl_service_v1_service_proto_rawDesc)))
+	})
+	return file_ibctl_service_v1_service_proto_rawDescData
+}
+
+var file_ibctl_service_v1_service_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_ibctl_service_v1_service_proto_goTypes = []any{
+	(*DownloadRequest)(nil),          // 0: ibctl.service.v1.DownloadRequest
+	(*DownloadResponse)(nil),         // 1: ibctl.service.v1.DownloadResponse
+	(*GetHoldingsRequest)(nil),       // 2: ibctl.service.v1.GetHoldingsRequest
+	(*GetHoldingsResponse)(nil),      // 3: ibctl.service.v1.GetHoldingsResponse
+	(*GetLotsRequest)(nil),           // 4: ibctl.service.v1.GetLotsRequest
+	(*GetLotsResponse)(nil),          // 5: ibctl.service.v1.GetLotsResponse
+	(*GetRealizedGainsRequest)(nil),  // 6: ibctl.service.v1.GetRealizedGainsRequest
+	(*GetRealizedGainsResponse)(nil), // 7: ibctl.service.v1.GetRealizedGainsResponse
+	(*Holding)(nil),                  // 8: ibctl.service.v1.Holding
+	(*Lot)(nil),                      // 9: ibctl.service.v1.Lot
+	(*RealizedGain)(nil),             // 10: ibctl.service.v1.RealizedGain
+	(*v1.Decimal)(nil),               // 11: standard.math.v1.Decimal
+	(*v11.Date)(nil),                 // 12: standard.time.v1.Date
+	(*v12.Money)(nil),                // 13: standard.money.v1.Money
+}
+var file_ibctl_service_v1_service_proto_depIdxs = []int32{
+	8,  // 0: ibctl.service.v1.GetHoldingsResponse.holdings:type_name -> ibctl.service.v1.Holding
+	9,  // 1: ibctl.service.v1.GetLotsResponse.lots:type_name -> ibctl.service.v1.Lot
+	10, // 2: ibctl.service.v1.GetRealizedGainsResponse.realized_gains:type_name -> ibctl.service.v1.RealizedGain
+	11, // 3: ibctl.service.v1.Holding.position:type_name -> standard.math.v1.Decimal
+	12, // 4: ibctl.service.v1.Lot.open_date:type_name -> standard.time.v1.Date
+	11, // 5: ibctl.service.v1.Lot.quantity:type_name -> standard.math.v1.Decimal
+	12, // 6: ibctl.service.v1.RealizedGain.open_date:type_name -> standard.time.v1.Date
+	12, // 7: ibctl.service.v1.RealizedGain.close_date:type_name -> standard.time.v1.Date
+	11, // 8: ibctl.service.v1.RealizedGain.quantity:type_name -> standard.math.v1.Decimal
+	13, // 9: ibctl.service.v1.RealizedGain.gain:type_name -> standard.money.v1.Money
+	0,  // 10: ibctl.service.v1.IbctlService.Download:input_type -> ibctl.service.v1.DownloadRequest
+	2,  // 11: ibctl.service.v1.IbctlService.GetHoldings:input_type -> ibctl.service.v1.GetHoldingsRequest
+	4,  // 12: ibctl.service.v1.IbctlService.GetLots:input_type -> ibctl.service.v1.GetLotsRequest
+	6,  // 13: ibctl.service.v1.IbctlService.GetRealizedGains:input_type -> ibctl.service.v1.GetRealizedGainsRequest
+	1,  // 14: ibctl.service.v1.IbctlService.Download:output_type -> ibctl.service.v1.DownloadResponse
+	3,  // 15: ibctl.service.v1.IbctlService.GetHoldings:output_type -> ibctl.service.v1.GetHoldingsResponse
+	5,  // 16: ibctl.service.v1.IbctlService.GetLots:output_type -> ibctl.service.v1.GetLotsResponse
+	7,  // 17: ibctl.service.v1.IbctlService.GetRealizedGains:output_type -> ibctl.service.v1.GetRealizedGainsResponse
+	14, // [14:18] is the sub-list for method output_type
+	10, // [10:14] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_ibctl_service_v1_service_proto_init() }
+func file_ibctl_service_v1_service_proto_init() {
+	if File_ibctl_service_v1_service_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ibctl_service_v1_service_proto_rawDesc), len(file_ibctl_service_v1_service_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_ibctl_service_v1_service_proto_goTypes,
+		DependencyIndexes: file_ibctl_service_v1_service_proto_depIdxs,
+		MessageInfos:      file_ibctl_service_v1_service_proto_msgTypes,
+	}.Build()
+	File_ibctl_service_v1_service_proto = out.File
+	file_ibctl_service_v1_service_proto_goTypes = nil
+	file_ibctl_service_v1_service_proto_depIdxs = nil
+}
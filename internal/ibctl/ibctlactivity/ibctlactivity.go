@@ -0,0 +1,137 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctlactivity computes trading activity per day and month of a year.
+//
+// Activity is the number of trades and the traded notional, the absolute value
+// of the trade proceeds converted to USD. It is used to review activity
+// patterns and to spot gaps in the data, such as a month without any trades
+// where trades were expected.
+package ibctlactivity
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/bufdev/ibctl/internal/pkg/timepb"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+)
+
+const (
+	// MetricTrades shades heatmaps by the number of trades.
+	MetricTrades = "trades"
+	// MetricNotional shades heatmaps by the traded notional in USD.
+	MetricNotional = "notional"
+)
+
+// Metrics is the list of all metrics.
+var Metrics = []string{MetricTrades, MetricNotional}
+
+// Activity is the trading activity of a year.
+type Activity struct {
+	// Year is the year.
+	Year int `json:"year"`
+	// Trades is the number of trades in the year.
+	Trades int `json:"trades"`
+	// NotionalUSD is the traded notional of the year in USD.
+	NotionalUSD string `json:"notional_usd"`
+	// Months is the activity of each month of the year, in order.
+	Months []*PeriodActivity `json:"months"`
+	// Days is the activity of each day of the year, in order.
+	Days []*PeriodActivity `json:"days"`
+}
+
+// PeriodActivity is the trading activity of a day or month.
+type PeriodActivity struct {
+	// Period is the date (YYYY-MM-DD) or month (YYYY-MM).
+	Period string `json:"period"`
+	// Trades is the number of trades.
+	Trades int `json:"trades"`
+	// NotionalUSD is the traded notional in USD.
+	NotionalUSD string `json:"notional_usd"`
+
+	notionalUSDMicros int64
+}
+
+// Value returns the value of the metric for the period.
+func (a *PeriodActivity) Value(metric string) float64 {
+	if metric == MetricNotional {
+		return float64(a.notionalUSDMicros)
+	}
+	return float64(a.Trades)
+}
+
+// NotionalUSDMicros returns the traded notional in USD micros.
+func (a *PeriodActivity) NotionalUSDMicros() int64 {
+	return a.notionalUSDMicros
+}
+
+// PeriodActivityHeaders returns the column headers for period activity table/CSV output.
+func PeriodActivityHeaders() []string {
+	return []string{"PERIOD", "TRADES", "NOTIONAL_USD"}
+}
+
+// PeriodActivityToRow converts a PeriodActivity to a string slice for CSV output.
+func PeriodActivityToRow(a *PeriodActivity) []string {
+	return []string{
+		a.Period,
+		strconv.Itoa(a.Trades),
+		a.NotionalUSD,
+	}
+}
+
+// GetActivity returns the trading activity of the year. Trades in currencies
+// without an FX rate to USD are counted, but not included in the notional.
+func GetActivity(trades []*datav1.Trade, year int, fxStore *ibctlfxrates.Store) (*Activity, error) {
+	activity := &Activity{Year: year}
+	for month := time.January; month <= time.December; month++ {
+		activity.Months = append(activity.Months, &PeriodActivity{Period: fmt.Sprintf("%04d-%02d", year, month)})
+	}
+	firstDay := xtime.Date{Year: year, Month: time.January, Day: 1}
+	for date := firstDay; date.Year == year; date = date.AddDays(1) {
+		activity.Days = append(activity.Days, &PeriodActivity{Period: date.String()})
+	}
+	var notionalUSDMicros int64
+	for _, trade := range trades {
+		date, err := timepb.ProtoToDate(trade.GetTradeDate())
+		if err != nil {
+			return nil, fmt.Errorf("trade %s: %w", trade.GetTradeId(), err)
+		}
+		if date.Year != year {
+			continue
+		}
+		var tradeNotionalUSDMicros int64
+		if proceedsUSD, ok := fxStore.ConvertToUSD(trade.GetProceeds()); ok {
+			tradeNotionalUSDMicros = moneypb.MoneyToMicros(proceedsUSD)
+			if tradeNotionalUSDMicros < 0 {
+				tradeNotionalUSDMicros = -tradeNotionalUSDMicros
+			}
+		}
+		for _, periodActivity := range []*PeriodActivity{
+			activity.Months[date.Month-1],
+			activity.Days[date.DaysSince(firstDay)],
+		} {
+			periodActivity.Trades++
+			periodActivity.notionalUSDMicros += tradeNotionalUSDMicros
+		}
+		activity.Trades++
+		notionalUSDMicros += tradeNotionalUSDMicros
+	}
+	activity.NotionalUSD = microsToString(notionalUSDMicros)
+	for _, periodActivities := range [][]*PeriodActivity{activity.Months, activity.Days} {
+		for _, periodActivity := range periodActivities {
+			periodActivity.NotionalUSD = microsToString(periodActivity.notionalUSDMicros)
+		}
+	}
+	return activity, nil
+}
+
+// microsToString returns the raw decimal string of a USD micros value.
+func microsToString(micros int64) string {
+	return moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", micros))
+}
@@ -0,0 +1,52 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctlactivity
+
+import (
+	"testing"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	timev1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/time/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetActivity(t *testing.T) {
+	t.Parallel()
+	trades := []*datav1.Trade{
+		newTrade("1", 2024, 12, 31, "USD", -1000),
+		newTrade("2", 2025, 1, 2, "USD", -1000),
+		newTrade("3", 2025, 1, 2, "USD", 250),
+		newTrade("4", 2025, 3, 31, "USD", 500),
+		// No FX rate is available, so the trade is counted without notional.
+		newTrade("5", 2025, 3, 31, "EUR", -700),
+	}
+	activity, err := GetActivity(trades, 2025, ibctlfxrates.NewStore(t.TempDir()))
+	require.NoError(t, err)
+	require.Equal(t, 2025, activity.Year)
+	require.Equal(t, 4, activity.Trades)
+	require.Equal(t, "1750", activity.NotionalUSD)
+	require.Len(t, activity.Months, 12)
+	require.Len(t, activity.Days, 365)
+	require.Equal(t, []string{"2025-01", "2", "1250"}, PeriodActivityToRow(activity.Months[0]))
+	require.Equal(t, []string{"2025-02", "0", "0"}, PeriodActivityToRow(activity.Months[1]))
+	require.Equal(t, []string{"2025-03", "2", "500"}, PeriodActivityToRow(activity.Months[2]))
+	require.Equal(t, []string{"2025-01-02", "2", "1250"}, PeriodActivityToRow(activity.Days[1]))
+	require.Equal(t, []string{"2025-03-31", "2", "500"}, PeriodActivityToRow(activity.Days[89]))
+	require.Equal(t, float64(2), activity.Days[89].Value(MetricTrades))
+	require.Equal(t, float64(500_000_000), activity.Days[89].Value(MetricNotional))
+}
+
+func newTrade(tradeID string, year uint32, month uint32, day uint32, currencyCode string, proceeds int64) *datav1.Trade {
+	return &datav1.Trade{
+		TradeId:      tradeID,
+		TradeDate:    &timev1.Date{Year: year, Month: month, Day: day},
+		Symbol:       "AAPL",
+		Proceeds:     moneypb.MoneyFromMicros(currencyCode, proceeds*1_000_000),
+		CurrencyCode: currencyCode,
+		AccountId:    "individual",
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctlalert evaluates configured alert rules against holdings.
+//
+// Percentages are relative to net liq, the total market value of all holdings
+// including cash.
+package ibctlalert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlholdings"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+)
+
+// cashCategory is the category ibctlholdings assigns to cash holdings.
+const cashCategory = "CASH"
+
+// Alert is a triggered alert rule.
+type Alert struct {
+	// Name is the name of the alert rule.
+	Name string `json:"name"`
+	// Message describes why the alert triggered.
+	Message string `json:"message"`
+}
+
+// Evaluate returns the alerts triggered by the holdings, in rule order.
+// A position rule may trigger once per position. STCG rules only consider
+// taxableHoldings, the holdings of taxable accounts. Allocation rules split
+// holdings across sectors and geos by their look-through weights.
+func Evaluate(
+	holdings []*ibctlholdings.HoldingOverview,
+	taxableHoldings []*ibctlholdings.HoldingOverview,
+	alertConfigs []ibctlconfig.AlertConfig,
+	lookthroughs map[string]ibctlconfig.Lookthrough,
+) ([]*Alert, error) {
+	var netLiqMicros, stcgMicros int64
+	for _, h := range holdings {
+		netLiqMicros += mathpb.ParseMicros(h.MarketValueUSD)
+	}
+	for _, h := range taxableHoldings {
+		stcgMicros += mathpb.ParseMicros(h.STCGUSD)
+	}
+	var alerts []*Alert
+	for _, alertConfig := range alertConfigs {
+		switch alertConfig.Type {
+		case ibctlconfig.AlertTypeAllocation:
+			classification, err := ibctlholdings.ParseClassification(alertConfig.Classification)
+			if err != nil {
+				return nil, fmt.Errorf("alert %q: %w", alertConfig.Name, err)
+			}
+			for _, c := range ibctlholdings.GetClassificationList(holdings, classification, lookthroughs) {
+				if !strings.EqualFold(c.Category, alertConfig.Value) {
+					continue
+				}
+				if pct := percentOf(mathpb.ParseMicros(c.MarketValueUSD), netLiqMicros); pct > alertConfig.MaxPct {
+					alerts = append(alerts, &Alert{
+						Name:    alertConfig.Name,
+						Message: fmt.Sprintf("%s %s is %.2f%% of net liq, above %.2f%%", classification, c.Category, pct, alertConfig.MaxPct),
+					})
+				}
+			}
+		case ibctlconfig.AlertTypePosition:
+			for _, h := range holdings {
+				if h.Category == cashCategory {
+					continue
+				}
+				if pct := percentOf(mathpb.ParseMicros(h.MarketValueUSD), netLiqMicros); pct > alertConfig.MaxPct {
+					alerts = append(alerts, &Alert{
+						Name:    alertConfig.Name,
+						Message: fmt.Sprintf("position %s is %.2f%% of net liq, above %.2f%%", h.Symbol, pct, alertConfig.MaxPct),
+					})
+				}
+			}
+		case ibctlconfig.AlertTypeSTCG:
+			if stcgMicros > alertConfig.MaxUSDMicros {
+				alerts = append(alerts, &Alert{
+					Name:    alertConfig.Name,
+					Message: fmt.Sprintf("STCG is %s, above %s", cliio.FormatUSDMicros(stcgMicros), cliio.FormatUSDMicros(alertConfig.MaxUSDMicros)),
+				})
+			}
+		default:
+			return nil, fmt.Errorf("alert %q: unknown type %q", alertConfig.Name, alertConfig.Type)
+		}
+	}
+	return alerts, nil
+}
+
+// *** PRIVATE ***
+
+// percentOf returns value as a percentage of total, or 0 if total is not positive.
+func percentOf(valueMicros int64, totalMicros int64) float64 {
+	if totalMicros <= 0 {
+		return 0
+	}
+	return float64(valueMicros) / float64(totalMicros) * 100
+}
@@ -0,0 +1,54 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctlalert
+
+import (
+	"testing"
+
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlholdings"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluate(t *testing.T) {
+	t.Parallel()
+	holdings := []*ibctlholdings.HoldingOverview{
+		{Symbol: "NET", MarketValueUSD: "500", STCGUSD: "100", Sector: "TECH"},
+		{Symbol: "MSFT", MarketValueUSD: "200", STCGUSD: "50", Sector: "TECH"},
+		{Symbol: "XOM", MarketValueUSD: "100", Sector: "ENERGY"},
+		{Symbol: "USD", MarketValueUSD: "200", Category: "CASH"},
+	}
+	alerts, err := Evaluate(holdings, holdings, []ibctlconfig.AlertConfig{
+		{Name: "tech", Type: ibctlconfig.AlertTypeAllocation, Classification: "sector", Value: "tech", MaxPct: 60},
+		{Name: "energy", Type: ibctlconfig.AlertTypeAllocation, Classification: "sector", Value: "ENERGY", MaxPct: 60},
+		{Name: "position", Type: ibctlconfig.AlertTypePosition, MaxPct: 15},
+		{Name: "stcg", Type: ibctlconfig.AlertTypeSTCG, MaxUSDMicros: 100_000_000},
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []*Alert{
+		{Name: "tech", Message: "sector TECH is 70.00% of net liq, above 60.00%"},
+		{Name: "position", Message: "position NET is 50.00% of net liq, above 15.00%"},
+		{Name: "position", Message: "position MSFT is 20.00% of net liq, above 15.00%"},
+		{Name: "stcg", Message: "STCG is $150.00, above $100.00"},
+	}, alerts)
+}
+
+func TestEvaluateLookthrough(t *testing.T) {
+	t.Parallel()
+	holdings := []*ibctlholdings.HoldingOverview{
+		{Symbol: "NET", MarketValueUSD: "400", Sector: "TECH"},
+		{Symbol: "VT", MarketValueUSD: "600", Type: "ETF"},
+	}
+	lookthroughs := map[string]ibctlconfig.Lookthrough{
+		"VT": {Sector: map[string]float64{"TECH": 0.25, "ENERGY": 0.75}},
+	}
+	alerts, err := Evaluate(holdings, holdings, []ibctlconfig.AlertConfig{
+		{Name: "tech", Type: ibctlconfig.AlertTypeAllocation, Classification: "sector", Value: "TECH", MaxPct: 50},
+	}, lookthroughs)
+	require.NoError(t, err)
+	require.Equal(t, []*Alert{
+		{Name: "tech", Message: "sector TECH is 55.00% of net liq, above 50.00%"},
+	}, alerts)
+}
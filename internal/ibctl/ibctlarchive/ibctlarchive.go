@@ -0,0 +1,154 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctlarchive creates zip archives of the ibctl directory.
+package ibctlarchive
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlredact"
+	"github.com/bufdev/ibctl/internal/pkg/protoio"
+)
+
+// topLevelNames are the entries allowed at the root of an ibctl directory archive.
+var topLevelNames = map[string]struct{}{
+	ibctlpath.ConfigFileName: {},
+	"data":                   {},
+	"cache":                  {},
+	"activity_statements":    {},
+	"trade_confirmations":    {},
+	"seed":                   {},
+}
+
+// gitNames are the entries at the root of the ibctl directory created by git
+// when data is committed with git.auto_commit. They are never archived: they
+// are not ibctl data, and git objects are compressed, so they cannot be redacted.
+var gitNames = map[string]struct{}{
+	".git":       {},
+	".gitignore": {},
+}
+
+// WriteZip writes a zip archive of every file and directory under dirPath to
+// the writer. Entry names are relative to dirPath. Any absolute path in
+// excludePaths (e.g., the output file itself) is skipped, including the
+// contents of excluded directories. The .git directory and .gitignore file at
+// the root of dirPath are always skipped.
+func WriteZip(writer io.Writer, dirPath string, excludePaths ...string) error {
+	return writeZip(writer, dirPath, nil, excludePaths)
+}
+
+// WriteRedactedZip is WriteZip with account aliases and IBKR account IDs
+// replaced in every entry name and file by the redactor. Sealed files are
+// decrypted before they are redacted, so the archive can be read without the
+// encryption key.
+func WriteRedactedZip(writer io.Writer, dirPath string, redactor *ibctlredact.Redactor, excludePaths ...string) error {
+	return writeZip(writer, dirPath, redactor, excludePaths)
+}
+
+// Validate checks that the entries of an archive look like an ibctl directory
+// created by WriteZip: ibctl.yaml at the root, only the expected top-level
+// entries (ibctl.yaml, data/, cache/, activity_statements/,
+// trade_confirmations/, seed/), only regular files and directories, and no
+// paths that escape the target directory.
+func Validate(zipFiles []*zip.File) error {
+	var hasConfigFile bool
+	for _, zipFile := range zipFiles {
+		name := zipFile.Name
+		// Zip entries always use forward slashes; reject absolute and escaping paths.
+		cleanName := path.Clean(name)
+		if path.IsAbs(name) || cleanName == ".." || strings.HasPrefix(cleanName, "../") || strings.Contains(name, `\`) {
+			return fmt.Errorf("entry %q escapes the target directory", name)
+		}
+		if !zipFile.Mode().IsRegular() && !zipFile.Mode().IsDir() {
+			return fmt.Errorf("entry %q is not a regular file or directory", name)
+		}
+		topLevelName, _, _ := strings.Cut(cleanName, "/")
+		if _, ok := topLevelNames[topLevelName]; !ok {
+			return fmt.Errorf("unexpected top-level entry %q", topLevelName)
+		}
+		if cleanName == ibctlpath.ConfigFileName && !zipFile.FileInfo().IsDir() {
+			hasConfigFile = true
+		}
+	}
+	if !hasConfigFile {
+		return fmt.Errorf("%s not found at the archive root", ibctlpath.ConfigFileName)
+	}
+	return nil
+}
+
+// *** PRIVATE ***
+
+// writeZip writes the zip archive, redacting entries if redactor is non-nil.
+func writeZip(writer io.Writer, dirPath string, redactor *ibctlredact.Redactor, excludePaths []string) error {
+	excluded := make(map[string]struct{}, len(excludePaths))
+	for _, excludePath := range excludePaths {
+		excluded[excludePath] = struct{}{}
+	}
+	zipWriter := zip.NewWriter(writer)
+	if err := filepath.Walk(dirPath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// Compute the relative path for the zip entry.
+		relPath, err := filepath.Rel(dirPath, filePath)
+		if err != nil {
+			return err
+		}
+		_, isExcluded := excluded[filePath]
+		_, isGit := gitNames[relPath]
+		if isExcluded || isGit {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// Skip the root directory entry.
+		if relPath == "." {
+			return nil
+		}
+		// Zip entries always use forward slashes.
+		relPath = filepath.ToSlash(relPath)
+		if redactor != nil {
+			relPath = redactor.Text(relPath)
+		}
+		// For directories, add a trailing slash entry.
+		if info.IsDir() {
+			_, err := zipWriter.Create(relPath + "/")
+			return err
+		}
+		// Add the file to the zip archive.
+		entryWriter, err := zipWriter.Create(relPath)
+		if err != nil {
+			return err
+		}
+		if redactor != nil {
+			data, err := protoio.ReadFile(filePath)
+			if err != nil {
+				return err
+			}
+			_, err = io.WriteString(entryWriter, redactor.Text(string(data)))
+			return err
+		}
+		file, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(entryWriter, file)
+		return err
+	}); err != nil {
+		_ = zipWriter.Close()
+		return err
+	}
+	// Close the zip writer to flush the archive.
+	return zipWriter.Close()
+}
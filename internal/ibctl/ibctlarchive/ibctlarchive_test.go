@@ -0,0 +1,139 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctlarchive
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlredact"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteZipRoundTrip(t *testing.T) {
+	t.Parallel()
+	dirPath := newGitDir(t)
+	var buffer bytes.Buffer
+	require.NoError(t, WriteZip(&buffer, dirPath, ibctlpath.LockFilePath(dirPath)))
+	entries := readZip(t, buffer.Bytes())
+	// The archive is accepted by data unzip.
+	zipReader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	require.NoError(t, err)
+	require.NoError(t, Validate(zipReader.File))
+	require.Equal(t, "accounts:\n  individual: U1111111\n", entries[ibctlpath.ConfigFileName])
+	require.Contains(t, entries, "data/accounts/individual/trades.json")
+	for name := range entries {
+		require.NotRegexp(t, `^\.git`, name)
+		require.NotEqual(t, "ibctl.lock", name)
+	}
+}
+
+func TestWriteZipBackup(t *testing.T) {
+	t.Parallel()
+	dirPath := newGitDir(t)
+	writeFile(t, filepath.Join(ibctlpath.DataBackupsDirPath(dirPath), "20250101T000000Z", "accounts", "individual", "trades.json"), "{}\n")
+	// As in data backup, the local backup generations and the lock file are excluded.
+	var buffer bytes.Buffer
+	require.NoError(t, WriteZip(&buffer, dirPath, ibctlpath.DataBackupsDirPath(dirPath), ibctlpath.LockFilePath(dirPath)))
+	entries := readZip(t, buffer.Bytes())
+	require.Contains(t, entries, "data/accounts/individual/trades.json")
+	for name := range entries {
+		require.NotRegexp(t, `^data/backups`, name)
+		require.NotEqual(t, "ibctl.lock", name)
+	}
+	// Without the exclusions, both would be archived.
+	buffer.Reset()
+	require.NoError(t, WriteZip(&buffer, dirPath))
+	entries = readZip(t, buffer.Bytes())
+	require.Contains(t, entries, "data/backups/20250101T000000Z/accounts/individual/trades.json")
+	require.Contains(t, entries, "ibctl.lock")
+}
+
+func TestWriteRedactedZip(t *testing.T) {
+	t.Parallel()
+	dirPath := newGitDir(t)
+	var buffer bytes.Buffer
+	redactor := ibctlredact.NewRedactor(map[string]string{"individual": "U1111111"})
+	require.NoError(t, WriteRedactedZip(&buffer, dirPath, redactor, ibctlpath.LockFilePath(dirPath)))
+	entries := readZip(t, buffer.Bytes())
+	require.NotEmpty(t, entries)
+	for name, content := range entries {
+		// Compressed git objects cannot be redacted, so they must not be archived.
+		require.NotRegexp(t, `^\.git`, name)
+		require.NotContains(t, name, "individual")
+		require.NotContains(t, content, "U1111111", name)
+		require.NotContains(t, content, "individual", name)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+	newZip := func(names ...string) []*zip.File {
+		var buffer bytes.Buffer
+		zipWriter := zip.NewWriter(&buffer)
+		for _, name := range names {
+			_, err := zipWriter.Create(name)
+			require.NoError(t, err)
+		}
+		require.NoError(t, zipWriter.Close())
+		zipReader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+		require.NoError(t, err)
+		return zipReader.File
+	}
+	require.NoError(t, Validate(newZip("ibctl.yaml", "data/", "data/version")))
+	require.ErrorContains(t, Validate(newZip("data/version")), "not found")
+	require.ErrorContains(t, Validate(newZip("ibctl.yaml", ".git/config")), "unexpected top-level entry")
+	require.ErrorContains(t, Validate(newZip("ibctl.yaml", "../escape")), "escapes")
+}
+
+// newGitDir returns an ibctl directory with an account, a lock file, and a
+// git repository whose files contain the account alias and ID, as created
+// by git.auto_commit.
+func newGitDir(t *testing.T) string {
+	dirPath := t.TempDir()
+	writeFile(t, ibctlpath.ConfigFilePath(dirPath), "accounts:\n  individual: U1111111\n")
+	writeFile(t, filepath.Join(ibctlpath.DataAccountDirPath(dirPath, "individual"), "trades.json"), `{"accountId":"individual"}`+"\n")
+	writeFile(t, ibctlpath.LockFilePath(dirPath), "")
+	writeFile(t, filepath.Join(dirPath, ".gitignore"), "cache/\n")
+	if _, err := exec.LookPath("git"); err == nil {
+		require.NoError(t, exec.Command("git", "-C", dirPath, "init", "--quiet").Run())
+	}
+	// Git objects are compressed, so the redactor cannot see the alias and ID in them.
+	var object bytes.Buffer
+	zlibWriter := zlib.NewWriter(&object)
+	_, err := zlibWriter.Write([]byte(`blob 27` + "\x00" + `{"accountId":"individual"}` + "\n"))
+	require.NoError(t, err)
+	require.NoError(t, zlibWriter.Close())
+	writeFile(t, filepath.Join(dirPath, ".git", "objects", "ab", "cdef"), object.String())
+	writeFile(t, filepath.Join(dirPath, ".git", "logs", "HEAD"), "individual U1111111\n")
+	return dirPath
+}
+
+func readZip(t *testing.T, data []byte) map[string]string {
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	entries := make(map[string]string, len(zipReader.File))
+	for _, zipFile := range zipReader.File {
+		reader, err := zipFile.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, reader.Close())
+		entries[zipFile.Name] = string(content)
+	}
+	return entries
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
@@ -0,0 +1,352 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctlaudit flags suspicious trade records in merged data.
+//
+// The audit applies heuristics that catch data errors the merge and FIFO
+// computation would otherwise silently absorb:
+//
+//   - A trade price deviating more than 50% from the prices of the same
+//     symbol on neighboring trading days, which usually means a mistyped
+//     manual trade or a price in the wrong unit. Stock splits can also
+//     trigger it.
+//   - Duplicate trade IDs with different contents, which means two sources
+//     disagree about the same execution.
+//   - A settle date before the trade date.
+//   - A quantity sign inconsistent with the trade side (buys are positive,
+//     sells are negative).
+//   - A trade extracted best-effort from a statement PDF, which should be
+//     verified against the original statement.
+//
+// Each finding has a severity: errors are records that are wrong, and
+// warnings are records that are unusual and should be reviewed.
+package ibctlaudit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlstatement"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/bufdev/ibctl/internal/pkg/timepb"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+)
+
+const (
+	// SeverityError is the severity of a record that is wrong.
+	SeverityError = "error"
+	// SeverityWarning is the severity of a record that is unusual.
+	SeverityWarning = "warning"
+
+	// CheckPriceDeviation is the check for prices deviating from neighboring days.
+	CheckPriceDeviation = "price_deviation"
+	// CheckDuplicateTradeID is the check for duplicate trade IDs with different contents.
+	CheckDuplicateTradeID = "duplicate_trade_id"
+	// CheckSettleDate is the check for settle dates before trade dates.
+	CheckSettleDate = "settle_date"
+	// CheckQuantitySide is the check for quantity signs inconsistent with the side.
+	CheckQuantitySide = "quantity_side"
+	// CheckLowConfidence is the check for trades extracted from statement PDFs.
+	CheckLowConfidence = "low_confidence"
+
+	// neighborWindowDays is the number of calendar days before and after a
+	// trade that neighboring trading days are taken from.
+	neighborWindowDays = 7
+	// maxPriceDeviationPercent is the percentage a trade price may deviate
+	// from the neighboring days' prices before it is flagged.
+	maxPriceDeviationPercent = 50
+)
+
+// Finding is a suspicious trade record.
+type Finding struct {
+	// Severity is the finding severity (error, warning).
+	Severity string `json:"severity"`
+	// Check is the name of the check that flagged the record.
+	Check string `json:"check"`
+	// Account is the account alias.
+	Account string `json:"account"`
+	// Symbol is the ticker symbol.
+	Symbol string `json:"symbol"`
+	// TradeID is the trade ID.
+	TradeID string `json:"trade_id"`
+	// Date is the trade date (YYYY-MM-DD).
+	Date string `json:"date"`
+	// Detail describes what is suspicious about the record.
+	Detail string `json:"detail"`
+}
+
+// FindingHeaders returns the column headers for finding table/CSV output.
+func FindingHeaders() []string {
+	return []string{"SEVERITY", "CHECK", "ACCOUNT", "SYMBOL", "TRADE ID", "DATE", "DETAIL"}
+}
+
+// FindingToRow converts a Finding to a string slice for table/CSV output.
+func FindingToRow(f *Finding) []string {
+	return []string{
+		f.Severity,
+		f.Check,
+		f.Account,
+		f.Symbol,
+		f.TradeID,
+		f.Date,
+		f.Detail,
+	}
+}
+
+// AuditTrades returns the findings for the given trades, sorted by date, then
+// account, symbol, and check.
+func AuditTrades(trades []*datav1.Trade) ([]*Finding, error) {
+	var findings []*Finding
+	for _, trade := range trades {
+		findings = append(findings, auditTrade(trade)...)
+	}
+	findings = append(findings, findDuplicateTradeIDs(trades)...)
+	priceFindings, err := findPriceDeviations(trades)
+	if err != nil {
+		return nil, err
+	}
+	findings = append(findings, priceFindings...)
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Date != findings[j].Date {
+			return findings[i].Date < findings[j].Date
+		}
+		if findings[i].Account != findings[j].Account {
+			return findings[i].Account < findings[j].Account
+		}
+		if findings[i].Symbol != findings[j].Symbol {
+			return findings[i].Symbol < findings[j].Symbol
+		}
+		return findings[i].Check < findings[j].Check
+	})
+	return findings, nil
+}
+
+// auditTrade returns the findings of the checks on a single trade.
+func auditTrade(trade *datav1.Trade) []*Finding {
+	var findings []*Finding
+	tradeDate := protoDateString(trade.GetTradeDate())
+	if settleDate := protoDateString(trade.GetSettleDate()); trade.GetSettleDate() != nil && settleDate < tradeDate {
+		findings = append(findings, newFinding(
+			trade,
+			SeverityError,
+			CheckSettleDate,
+			fmt.Sprintf("settle date %s is before trade date %s", settleDate, tradeDate),
+		))
+	}
+	quantityMicros := mathpb.ToMicros(trade.GetQuantity())
+	switch trade.GetSide() {
+	case datav1.TradeSide_TRADE_SIDE_BUY:
+		if quantityMicros < 0 {
+			findings = append(findings, newFinding(
+				trade,
+				SeverityError,
+				CheckQuantitySide,
+				fmt.Sprintf("buy with negative quantity %s", mathpb.ToString(trade.GetQuantity())),
+			))
+		}
+	case datav1.TradeSide_TRADE_SIDE_SELL:
+		if quantityMicros > 0 {
+			findings = append(findings, newFinding(
+				trade,
+				SeverityError,
+				CheckQuantitySide,
+				fmt.Sprintf("sell with positive quantity %s", mathpb.ToString(trade.GetQuantity())),
+			))
+		}
+	}
+	if trade.GetSource() == ibctlstatement.Source {
+		findings = append(findings, newFinding(
+			trade,
+			SeverityWarning,
+			CheckLowConfidence,
+			"extracted from a statement PDF, verify against the original statement",
+		))
+	}
+	return findings
+}
+
+// findDuplicateTradeIDs returns a finding for each trade ID shared by trades
+// with different contents. Identical copies of a trade are not flagged.
+func findDuplicateTradeIDs(trades []*datav1.Trade) []*Finding {
+	tradeIDToTrades := make(map[string][]*datav1.Trade)
+	var tradeIDs []string
+	for _, trade := range trades {
+		tradeID := trade.GetTradeId()
+		if tradeID == "" {
+			continue
+		}
+		if _, ok := tradeIDToTrades[tradeID]; !ok {
+			tradeIDs = append(tradeIDs, tradeID)
+		}
+		tradeIDToTrades[tradeID] = append(tradeIDToTrades[tradeID], trade)
+	}
+	var findings []*Finding
+	for _, tradeID := range tradeIDs {
+		sameIDTrades := tradeIDToTrades[tradeID]
+		fieldNames := differingFieldNames(sameIDTrades)
+		if len(fieldNames) == 0 {
+			continue
+		}
+		findings = append(findings, newFinding(
+			sameIDTrades[0],
+			SeverityError,
+			CheckDuplicateTradeID,
+			fmt.Sprintf("%d trades share this trade ID and differ in %s", len(sameIDTrades), strings.Join(fieldNames, ", ")),
+		))
+	}
+	return findings
+}
+
+// differingFieldNames returns the names of the fields whose values differ
+// between the first trade and any other trade, in field order.
+func differingFieldNames(trades []*datav1.Trade) []string {
+	first := trades[0].ProtoReflect()
+	fields := first.Descriptor().Fields()
+	var fieldNames []string
+	for i := range fields.Len() {
+		field := fields.Get(i)
+		for _, trade := range trades[1:] {
+			other := trade.ProtoReflect()
+			if first.Has(field) != other.Has(field) || !first.Get(field).Equal(other.Get(field)) {
+				fieldNames = append(fieldNames, string(field.Name()))
+				break
+			}
+		}
+	}
+	return fieldNames
+}
+
+// symbolKey identifies the trades of a symbol whose prices are comparable.
+type symbolKey struct {
+	symbol       string
+	currencyCode string
+}
+
+// tradingDay is the mean trade price of a symbol on a date.
+type tradingDay struct {
+	date             xtime.Date
+	priceMicrosTotal int64
+	count            int64
+}
+
+// meanPriceMicros returns the mean trade price of the day.
+func (d *tradingDay) meanPriceMicros() int64 {
+	return d.priceMicrosTotal / d.count
+}
+
+// findPriceDeviations returns a finding for each trade whose price deviates
+// more than maxPriceDeviationPercent from the mean price of every neighboring
+// trading day of the same symbol within neighborWindowDays.
+//
+// Options are skipped, since their prices routinely move more than that
+// between days.
+func findPriceDeviations(trades []*datav1.Trade) ([]*Finding, error) {
+	keyToDays := make(map[symbolKey][]*tradingDay)
+	type pricedTrade struct {
+		trade       *datav1.Trade
+		key         symbolKey
+		date        xtime.Date
+		priceMicros int64
+	}
+	var pricedTrades []pricedTrade
+	for _, trade := range trades {
+		switch trade.GetAssetCategory() {
+		case "OPT", "FOP":
+			continue
+		}
+		priceMicros := moneypb.MoneyToMicros(trade.GetTradePrice())
+		if priceMicros <= 0 {
+			continue
+		}
+		date, err := timepb.ProtoToDate(trade.GetTradeDate())
+		if err != nil {
+			return nil, fmt.Errorf("trade %s: %w", trade.GetTradeId(), err)
+		}
+		key := symbolKey{symbol: trade.GetSymbol(), currencyCode: trade.GetCurrencyCode()}
+		pricedTrades = append(pricedTrades, pricedTrade{trade: trade, key: key, date: date, priceMicros: priceMicros})
+		days := keyToDays[key]
+		var day *tradingDay
+		for _, existing := range days {
+			if existing.date == date {
+				day = existing
+				break
+			}
+		}
+		if day == nil {
+			day = &tradingDay{date: date}
+			keyToDays[key] = append(days, day)
+		}
+		day.priceMicrosTotal += priceMicros
+		day.count++
+	}
+	var findings []*Finding
+	for _, pricedTrade := range pricedTrades {
+		var closestDay *tradingDay
+		var closestDeviationPercent float64
+		for _, day := range keyToDays[pricedTrade.key] {
+			daysApart := pricedTrade.date.DaysSince(day.date)
+			if daysApart == 0 || daysApart > neighborWindowDays || daysApart < -neighborWindowDays {
+				continue
+			}
+			deviationPercent := deviationPercent(pricedTrade.priceMicros, day.meanPriceMicros())
+			if closestDay == nil || deviationPercent < closestDeviationPercent {
+				closestDay = day
+				closestDeviationPercent = deviationPercent
+			}
+		}
+		if closestDay == nil || closestDeviationPercent <= maxPriceDeviationPercent {
+			continue
+		}
+		findings = append(findings, newFinding(
+			pricedTrade.trade,
+			SeverityWarning,
+			CheckPriceDeviation,
+			fmt.Sprintf(
+				"price %s deviates %.0f%% from %s on %s",
+				mathpb.ToString(mathpb.FromMicros(pricedTrade.priceMicros)),
+				closestDeviationPercent,
+				mathpb.ToString(mathpb.FromMicros(closestDay.meanPriceMicros())),
+				closestDay.date.String(),
+			),
+		))
+	}
+	return findings, nil
+}
+
+// deviationPercent returns the absolute percentage priceMicros deviates from referenceMicros.
+func deviationPercent(priceMicros int64, referenceMicros int64) float64 {
+	difference := priceMicros - referenceMicros
+	if difference < 0 {
+		difference = -difference
+	}
+	return float64(difference) / float64(referenceMicros) * 100
+}
+
+// newFinding returns a new Finding for the trade.
+func newFinding(trade *datav1.Trade, severity string, check string, detail string) *Finding {
+	return &Finding{
+		Severity: severity,
+		Check:    check,
+		Account:  trade.GetAccountId(),
+		Symbol:   trade.GetSymbol(),
+		TradeID:  trade.GetTradeId(),
+		Date:     protoDateString(trade.GetTradeDate()),
+		Detail:   detail,
+	}
+}
+
+// protoDateString returns a sortable YYYY-MM-DD string from a proto Date.
+func protoDateString(d interface {
+	GetYear() uint32
+	GetMonth() uint32
+	GetDay() uint32
+}) string {
+	if d == nil {
+		return ""
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", d.GetYear(), d.GetMonth(), d.GetDay())
+}
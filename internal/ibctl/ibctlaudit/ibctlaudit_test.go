@@ -0,0 +1,121 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctlaudit
+
+import (
+	"testing"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	timev1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/time/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlstatement"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditTrades(t *testing.T) {
+	t.Parallel()
+	// A clean trade with a conflicting copy from another source.
+	duplicate := newTrade("1", 3, "AAPL", "STK", 10, 150)
+	conflicting := newTrade("1", 3, "AAPL", "STK", 10, 150)
+	conflicting.Quantity = mathpb.FromMicros(12_000_000)
+	conflicting.Source = "manual"
+	// A sell recorded with a positive quantity that settles before it trades.
+	badSell := newTrade("2", 5, "AAPL", "STK", 5, 151)
+	badSell.Side = datav1.TradeSide_TRADE_SIDE_SELL
+	badSell.SettleDate = &timev1.Date{Year: 2025, Month: 3, Day: 4}
+	trades := []*datav1.Trade{
+		duplicate,
+		conflicting,
+		badSell,
+		// A price entered in cents, surrounded by normal prices.
+		newTrade("3", 6, "AAPL", "STK", 1, 15200),
+		newTrade("4", 7, "AAPL", "STK", 1, 153),
+		// Options are not checked for price deviations.
+		newTrade("5", 3, "AAPL  250321C00150000", "OPT", 1, 2),
+		newTrade("6", 4, "AAPL  250321C00150000", "OPT", 1, 9),
+		// Prices more than 7 days apart are not neighbors.
+		newTrade("7", 3, "MSFT", "STK", 1, 100),
+		newTrade("8", 20, "MSFT", "STK", 1, 300),
+	}
+	// A trade extracted from a statement PDF.
+	extracted := newTrade("9", 10, "MSFT", "STK", 1, 101)
+	extracted.Source = ibctlstatement.Source
+	trades = append(trades, extracted)
+	findings, err := AuditTrades(trades)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		[]*Finding{
+			{
+				Severity: SeverityError,
+				Check:    CheckDuplicateTradeID,
+				Account:  "individual",
+				Symbol:   "AAPL",
+				TradeID:  "1",
+				Date:     "2025-03-03",
+				Detail:   "2 trades share this trade ID and differ in quantity, source",
+			},
+			{
+				Severity: SeverityError,
+				Check:    CheckQuantitySide,
+				Account:  "individual",
+				Symbol:   "AAPL",
+				TradeID:  "2",
+				Date:     "2025-03-05",
+				Detail:   "sell with positive quantity 5",
+			},
+			{
+				Severity: SeverityError,
+				Check:    CheckSettleDate,
+				Account:  "individual",
+				Symbol:   "AAPL",
+				TradeID:  "2",
+				Date:     "2025-03-05",
+				Detail:   "settle date 2025-03-04 is before trade date 2025-03-05",
+			},
+			{
+				Severity: SeverityWarning,
+				Check:    CheckPriceDeviation,
+				Account:  "individual",
+				Symbol:   "AAPL",
+				TradeID:  "3",
+				Date:     "2025-03-06",
+				Detail:   "price 15200 deviates 9835% from 153 on 2025-03-07",
+			},
+			{
+				Severity: SeverityWarning,
+				Check:    CheckLowConfidence,
+				Account:  "individual",
+				Symbol:   "MSFT",
+				TradeID:  "9",
+				Date:     "2025-03-10",
+				Detail:   "extracted from a statement PDF, verify against the original statement",
+			},
+		},
+		findings,
+	)
+}
+
+func newTrade(tradeID string, day uint32, symbol string, assetCategory string, quantity int64, price int64) *datav1.Trade {
+	side := datav1.TradeSide_TRADE_SIDE_BUY
+	if quantity < 0 {
+		side = datav1.TradeSide_TRADE_SIDE_SELL
+	}
+	return &datav1.Trade{
+		TradeId:       tradeID,
+		TradeDate:     &timev1.Date{Year: 2025, Month: 3, Day: day},
+		SettleDate:    &timev1.Date{Year: 2025, Month: 3, Day: day + 1},
+		Symbol:        symbol,
+		AssetCategory: assetCategory,
+		Side:          side,
+		Quantity:      mathpb.FromMicros(quantity * 1_000_000),
+		TradePrice:    moneypb.MoneyFromMicros("USD", price*1_000_000),
+		Proceeds:      moneypb.MoneyFromMicros("USD", -quantity*price*1_000_000),
+		Commission:    moneypb.MoneyFromMicros("USD", -1_000_000),
+		CurrencyCode:  "USD",
+		AccountId:     "individual",
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctlbackup keeps rolling backups of persistent account data.
+//
+// Each backup is a generation: a full copy of data/accounts/ stored under
+// data/backups/<generation>/accounts/, where the generation name is a UTC
+// timestamp (e.g., "20260102T150405Z"). Generations sort lexically by time.
+// Only the most recent generations are retained.
+package ibctlbackup
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/bufdev/ibctl/internal/pkg/filemode"
+)
+
+// DefaultRetention is the default number of backup generations to keep.
+const DefaultRetention = 5
+
+// generationTimeLayout is the time layout used for generation names.
+const generationTimeLayout = "20060102T150405Z"
+
+// Backup copies the data accounts directory into a new generation under the
+// backups directory, then prunes all but the newest retention generations.
+//
+// Returns the new generation name, or an empty string if there was nothing
+// to back up (the data accounts directory does not exist, or is identical to
+// the newest generation).
+func Backup(dataAccountsDirPath string, backupsDirPath string, retention int) (string, error) {
+	if _, err := os.Stat(dataAccountsDirPath); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+	generations, err := ListGenerations(backupsDirPath)
+	if err != nil {
+		return "", err
+	}
+	// Skip the backup if nothing changed since the newest generation.
+	if len(generations) > 0 {
+		equal, err := dirsEqual(dataAccountsDirPath, filepath.Join(backupsDirPath, generations[0], "accounts"))
+		if err != nil {
+			return "", err
+		}
+		if equal {
+			return "", nil
+		}
+	}
+	if err := filemode.MkdirAll(backupsDirPath); err != nil {
+		return "", err
+	}
+	generation, err := newGeneration(backupsDirPath)
+	if err != nil {
+		return "", err
+	}
+	// Copy into a temporary directory and rename, so a partial backup is never
+	// mistaken for a complete generation.
+	tempDirPath := filepath.Join(backupsDirPath, "."+generation+".tmp")
+	if err := os.RemoveAll(tempDirPath); err != nil {
+		return "", err
+	}
+	if err := copyDir(dataAccountsDirPath, filepath.Join(tempDirPath, "accounts")); err != nil {
+		_ = os.RemoveAll(tempDirPath)
+		return "", fmt.Errorf("copying data to backup: %w", err)
+	}
+	if err := os.Rename(tempDirPath, filepath.Join(backupsDirPath, generation)); err != nil {
+		_ = os.RemoveAll(tempDirPath)
+		return "", err
+	}
+	if err := prune(backupsDirPath, retention); err != nil {
+		return "", fmt.Errorf("pruning backups: %w", err)
+	}
+	return generation, nil
+}
+
+// ListGenerations returns all backup generation names, newest first.
+// Returns an empty slice if the backups directory does not exist.
+func ListGenerations(backupsDirPath string) ([]string, error) {
+	entries, err := os.ReadDir(backupsDirPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var generations []string
+	for _, entry := range entries {
+		// Generations are directories named by timestamp; skip anything else,
+		// including in-progress temporary directories.
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := time.Parse(generationTimeLayout, entry.Name()); err != nil {
+			continue
+		}
+		generations = append(generations, entry.Name())
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(generations)))
+	return generations, nil
+}
+
+// Restore replaces the data accounts directory with the given backup generation.
+//
+// The current data is backed up as a new generation first, so a restore can
+// itself be rolled back. The swap is done with renames so the data accounts
+// directory is never left partially restored.
+//
+// The generation must be one of ListGenerations, so that it cannot name a
+// directory outside the backups directory.
+func Restore(dataAccountsDirPath string, backupsDirPath string, generation string, retention int) error {
+	generations, err := ListGenerations(backupsDirPath)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(generations, generation) {
+		return fmt.Errorf("backup generation %q not found", generation)
+	}
+	generationAccountsDirPath := filepath.Join(backupsDirPath, generation, "accounts")
+	if _, err := os.Stat(generationAccountsDirPath); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("backup generation %q not found", generation)
+		}
+		return err
+	}
+	// Keep the generation being restored from being pruned by the pre-restore backup.
+	if _, err := Backup(dataAccountsDirPath, backupsDirPath, retention+1); err != nil {
+		return fmt.Errorf("backing up current data: %w", err)
+	}
+	// Stage the restored copy next to the live directory, then swap.
+	stagingDirPath := dataAccountsDirPath + ".restore-tmp"
+	oldDirPath := dataAccountsDirPath + ".restore-old"
+	if err := os.RemoveAll(stagingDirPath); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(oldDirPath); err != nil {
+		return err
+	}
+	if err := copyDir(generationAccountsDirPath, stagingDirPath); err != nil {
+		_ = os.RemoveAll(stagingDirPath)
+		return fmt.Errorf("copying backup: %w", err)
+	}
+	if err := os.Rename(dataAccountsDirPath, oldDirPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		_ = os.RemoveAll(stagingDirPath)
+		return err
+	}
+	if err := os.Rename(stagingDirPath, dataAccountsDirPath); err != nil {
+		// Put the original data back.
+		_ = os.Rename(oldDirPath, dataAccountsDirPath)
+		return err
+	}
+	if err := os.RemoveAll(oldDirPath); err != nil {
+		return err
+	}
+	return prune(backupsDirPath, retention)
+}
+
+// *** PRIVATE ***
+
+// newGeneration returns a generation name for the current time that does not
+// collide with an existing generation.
+func newGeneration(backupsDirPath string) (string, error) {
+	now := time.Now().UTC()
+	for {
+		generation := now.Format(generationTimeLayout)
+		_, err := os.Stat(filepath.Join(backupsDirPath, generation))
+		if errors.Is(err, fs.ErrNotExist) {
+			return generation, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		// Two backups within the same second; use the next second.
+		now = now.Add(time.Second)
+	}
+}
+
+// prune removes all but the newest retention generations.
+func prune(backupsDirPath string, retention int) error {
+	generations, err := ListGenerations(backupsDirPath)
+	if err != nil {
+		return err
+	}
+	if retention < 1 || len(generations) <= retention {
+		return nil
+	}
+	for _, generation := range generations[retention:] {
+		if err := os.RemoveAll(filepath.Join(backupsDirPath, generation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyDir recursively copies the source directory to the destination directory.
+func copyDir(sourceDirPath string, destinationDirPath string) error {
+	return filepath.WalkDir(sourceDirPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(sourceDirPath, path)
+		if err != nil {
+			return err
+		}
+		destinationPath := filepath.Join(destinationDirPath, relPath)
+		if d.IsDir() {
+			return filemode.MkdirAll(destinationPath)
+		}
+		return copyFile(path, destinationPath)
+	})
+}
+
+// dirsEqual returns true if both directories contain the same relative file
+// paths with identical contents.
+func dirsEqual(dirPath1 string, dirPath2 string) (bool, error) {
+	files1, err := readDirFiles(dirPath1)
+	if err != nil {
+		return false, err
+	}
+	files2, err := readDirFiles(dirPath2)
+	if err != nil {
+		return false, err
+	}
+	if len(files1) != len(files2) {
+		return false, nil
+	}
+	for relPath, data := range files1 {
+		if other, ok := files2[relPath]; !ok || !bytes.Equal(data, other) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// readDirFiles reads every regular file under the directory, keyed by relative path.
+// Returns an empty map if the directory does not exist.
+func readDirFiles(dirPath string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	err := filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(dirPath, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[relPath] = data
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return files, nil
+}
+
+// copyFile copies a single regular file.
+func copyFile(sourcePath string, destinationPath string) error {
+	source, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	destination, err := filemode.OpenFile(destinationPath, os.O_WRONLY|os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(destination, source); err != nil {
+		_ = destination.Close()
+		return err
+	}
+	return destination.Close()
+}
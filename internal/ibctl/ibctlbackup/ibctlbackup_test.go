@@ -0,0 +1,156 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctlbackup
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackup(t *testing.T) {
+	t.Parallel()
+	dirPath := t.TempDir()
+	dataAccountsDirPath := ibctlpath.DataAccountsDirPath(dirPath)
+	backupsDirPath := ibctlpath.DataBackupsDirPath(dirPath)
+	// Nothing is backed up before any account data exists.
+	generation, err := Backup(dataAccountsDirPath, backupsDirPath, DefaultRetention)
+	require.NoError(t, err)
+	require.Empty(t, generation)
+
+	writeFile(t, filepath.Join(dataAccountsDirPath, "individual", "trades.json"), "v1")
+	writeFile(t, filepath.Join(dataAccountsDirPath, "rrsp", "trades.json"), "v1")
+	writeFile(t, ibctlpath.LockFilePath(dirPath), "")
+	firstGeneration, err := Backup(dataAccountsDirPath, backupsDirPath, DefaultRetention)
+	require.NoError(t, err)
+	require.NotEmpty(t, firstGeneration)
+	// The generation is a copy of data/accounts only, without the lock file
+	// or the backups themselves.
+	require.Equal(
+		t,
+		map[string]string{
+			"accounts/individual/trades.json": "v1",
+			"accounts/rrsp/trades.json":       "v1",
+		},
+		readFiles(t, filepath.Join(backupsDirPath, firstGeneration)),
+	)
+	// Nothing is backed up if the data is unchanged.
+	generation, err = Backup(dataAccountsDirPath, backupsDirPath, DefaultRetention)
+	require.NoError(t, err)
+	require.Empty(t, generation)
+
+	// A change is backed up as a new generation, even within the same second.
+	writeFile(t, filepath.Join(dataAccountsDirPath, "individual", "trades.json"), "v2")
+	secondGeneration, err := Backup(dataAccountsDirPath, backupsDirPath, DefaultRetention)
+	require.NoError(t, err)
+	require.NotEmpty(t, secondGeneration)
+	require.NotEqual(t, firstGeneration, secondGeneration)
+	generations, err := ListGenerations(backupsDirPath)
+	require.NoError(t, err)
+	require.Equal(t, []string{secondGeneration, firstGeneration}, generations)
+	require.Equal(t, "v2", readFiles(t, filepath.Join(backupsDirPath, secondGeneration))["accounts/individual/trades.json"])
+}
+
+func TestBackupRetention(t *testing.T) {
+	t.Parallel()
+	dirPath := t.TempDir()
+	dataAccountsDirPath := ibctlpath.DataAccountsDirPath(dirPath)
+	backupsDirPath := ibctlpath.DataBackupsDirPath(dirPath)
+	for _, generation := range []string{
+		"20250101T000000Z",
+		"20250102T000000Z",
+		"20250103T000000Z",
+		"20250104T000000Z",
+	} {
+		writeFile(t, filepath.Join(backupsDirPath, generation, "accounts", "individual", "trades.json"), generation)
+	}
+	// Entries that are not generations are neither listed nor pruned.
+	writeFile(t, filepath.Join(backupsDirPath, ".20250105T000000Z.tmp", "accounts", "individual", "trades.json"), "partial")
+	writeFile(t, filepath.Join(backupsDirPath, "notes.txt"), "notes")
+
+	writeFile(t, filepath.Join(dataAccountsDirPath, "individual", "trades.json"), "current")
+	generation, err := Backup(dataAccountsDirPath, backupsDirPath, 3)
+	require.NoError(t, err)
+	require.NotEmpty(t, generation)
+	generations, err := ListGenerations(backupsDirPath)
+	require.NoError(t, err)
+	require.Equal(t, []string{generation, "20250104T000000Z", "20250103T000000Z"}, generations)
+	require.NoDirExists(t, filepath.Join(backupsDirPath, "20250102T000000Z"))
+	require.DirExists(t, filepath.Join(backupsDirPath, ".20250105T000000Z.tmp"))
+	require.FileExists(t, filepath.Join(backupsDirPath, "notes.txt"))
+
+	generations, err = ListGenerations(filepath.Join(dirPath, "missing"))
+	require.NoError(t, err)
+	require.Empty(t, generations)
+}
+
+func TestRestore(t *testing.T) {
+	t.Parallel()
+	dirPath := t.TempDir()
+	dataAccountsDirPath := ibctlpath.DataAccountsDirPath(dirPath)
+	backupsDirPath := ibctlpath.DataBackupsDirPath(dirPath)
+	writeFile(t, filepath.Join(dataAccountsDirPath, "individual", "trades.json"), "v1")
+	firstGeneration, err := Backup(dataAccountsDirPath, backupsDirPath, DefaultRetention)
+	require.NoError(t, err)
+	writeFile(t, filepath.Join(dataAccountsDirPath, "individual", "trades.json"), "v2")
+	writeFile(t, filepath.Join(dataAccountsDirPath, "rrsp", "trades.json"), "v2")
+
+	require.NoError(t, Restore(dataAccountsDirPath, backupsDirPath, firstGeneration, DefaultRetention))
+	require.Equal(t, map[string]string{"individual/trades.json": "v1"}, readFiles(t, dataAccountsDirPath))
+	// The data replaced by the restore was backed up first.
+	generations, err := ListGenerations(backupsDirPath)
+	require.NoError(t, err)
+	require.Len(t, generations, 2)
+	require.Equal(
+		t,
+		map[string]string{
+			"accounts/individual/trades.json": "v2",
+			"accounts/rrsp/trades.json":       "v2",
+		},
+		readFiles(t, filepath.Join(backupsDirPath, generations[0])),
+	)
+	require.NoDirExists(t, dataAccountsDirPath+".restore-tmp")
+	require.NoDirExists(t, dataAccountsDirPath+".restore-old")
+
+	require.ErrorContains(t, Restore(dataAccountsDirPath, backupsDirPath, "20200101T000000Z", DefaultRetention), "not found")
+	// A generation outside the backups directory is not restored, even if it
+	// has an accounts directory.
+	writeFile(t, filepath.Join(dirPath, "outside", "accounts", "individual", "trades.json"), "outside")
+	require.ErrorContains(t, Restore(dataAccountsDirPath, backupsDirPath, filepath.Join("..", "..", "outside"), DefaultRetention), "not found")
+	require.Equal(t, map[string]string{"individual/trades.json": "v1"}, readFiles(t, dataAccountsDirPath))
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+// readFiles returns the content of every file under the directory, keyed by
+// slash-separated relative path.
+func readFiles(t *testing.T, dirPath string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	require.NoError(t, filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		relPath, err := filepath.Rel(dirPath, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(relPath)] = string(data)
+		return nil
+	}))
+	return files
+}
@@ -0,0 +1,358 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctlbeancount exports merged IBKR activity as a Beancount
+// (https://beancount.github.io) plain-text accounting ledger.
+//
+// Each IBKR account alias gets its own set of accounts under
+// Assets:IBKR:<Alias>, Income:IBKR:<Alias>, Expenses:IBKR:<Alias>, and
+// Equity:IBKR:<Alias>. Securities are held at cost in a single FIFO-booked
+// Securities account, so Beancount matches sells against lots the same way
+// ibctl does, and realized gains are booked to the CapitalGains account.
+//
+// Every transaction carries the IBKR ID as metadata (trade_id or
+// transaction_id), so repeated exports can be diffed or deduplicated.
+package ibctlbeancount
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+	"unicode"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+)
+
+// assetCategoryCash is the IBKR asset category for FX conversions (e.g., USD.CAD),
+// which are currency exchanges rather than security trades.
+const assetCategoryCash = "CASH"
+
+// Write writes a Beancount ledger of all trades, cash transactions, and
+// position transfers in mergedData.
+//
+// Position transfers in without a transfer price are flagged with "!" and
+// booked at zero cost, since their cost basis is unknown.
+func Write(writer io.Writer, mergedData *ibctlmerge.MergedData) error {
+	ledger := newLedger()
+	positions := make(map[string]int64)
+	for _, trade := range mergedData.Trades {
+		if err := ledger.addTrade(trade, positions); err != nil {
+			return err
+		}
+	}
+	for _, cashTransaction := range mergedData.CashTransactions {
+		ledger.addCashTransaction(cashTransaction)
+	}
+	for _, transfer := range mergedData.Transfers {
+		ledger.addTransfer(transfer)
+	}
+	_, err := io.WriteString(writer, ledger.String())
+	return err
+}
+
+// *** PRIVATE ***
+
+type ledger struct {
+	// accountToOpenDate is the earliest date each account is used.
+	accountToOpenDate map[string]string
+	entries           []*entry
+}
+
+type entry struct {
+	date string
+	text string
+}
+
+func newLedger() *ledger {
+	return &ledger{
+		accountToOpenDate: make(map[string]string),
+	}
+}
+
+// addTrade adds a trade. positions is the running position in micros per
+// account and commodity, used to tell opening legs, which are booked at their
+// total cost, from closing legs, which are matched against open lots.
+func (l *ledger) addTrade(trade *datav1.Trade, positions map[string]int64) error {
+	date := protoDateString(trade.GetTradeDate())
+	alias := trade.GetAccountId()
+	currencyCode := trade.GetCurrencyCode()
+	quantityMicros := mathpb.ToMicros(trade.GetQuantity())
+	proceedsMicros := moneypb.MoneyToMicros(trade.GetProceeds())
+	commissionMicros := moneypb.MoneyToMicros(trade.GetCommission())
+	cashAccount := l.account(date, alias, "Assets", "Cash")
+	var postings []string
+	if trade.GetAssetCategory() == assetCategoryCash {
+		// FX conversions are quoted as BASE.QUOTE, with the quantity in the base currency.
+		baseCurrencyCode, _, ok := strings.Cut(trade.GetSymbol(), ".")
+		if !ok {
+			return fmt.Errorf("trade %s: FX conversion symbol %q is not BASE.QUOTE", trade.GetTradeId(), trade.GetSymbol())
+		}
+		postings = append(postings, posting(cashAccount, fmt.Sprintf("%s %s @@ %s %s", formatMicros(quantityMicros), baseCurrencyCode, formatMicros(abs(proceedsMicros)), currencyCode)))
+	} else {
+		securitiesAccount := l.account(date, alias, "Assets", "Securities")
+		commodity := commodityName(trade.GetSymbol())
+		positionKey := alias + "/" + commodity
+		position := positions[positionKey]
+		// The closing leg is the part of the quantity that reduces the position toward zero.
+		var closingMicros int64
+		if position != 0 && (position > 0) != (quantityMicros > 0) {
+			closingMicros = quantityMicros
+			if abs(quantityMicros) > abs(position) {
+				closingMicros = -position
+			}
+		}
+		openingMicros := quantityMicros - closingMicros
+		if closingMicros != 0 {
+			postings = append(postings, posting(securitiesAccount, fmt.Sprintf("%s %s {} @ %s %s", formatMicros(closingMicros), commodity, moneypb.MoneyValueToString(trade.GetTradePrice()), currencyCode)))
+		}
+		if openingMicros != 0 {
+			// The opening leg's share of the proceeds is its total cost.
+			costMicros := abs(proportion(proceedsMicros, openingMicros, quantityMicros))
+			postings = append(postings, posting(securitiesAccount, fmt.Sprintf("%s %s {{%s %s}}", formatMicros(openingMicros), commodity, formatMicros(costMicros), currencyCode)))
+		}
+		positions[positionKey] = position + quantityMicros
+		if closingMicros != 0 {
+			postings = append(postings, posting(l.account(date, alias, "Income", "CapitalGains"), ""))
+		}
+	}
+	postings = append(postings, posting(cashAccount, formatMicros(proceedsMicros+commissionMicros)+" "+currencyCode))
+	if commissionMicros != 0 {
+		postings = append(postings, posting(l.account(date, alias, "Expenses", "Commissions"), formatMicros(-commissionMicros)+" "+currencyCode))
+	}
+	side := strings.TrimPrefix(trade.GetSide().String(), "TRADE_SIDE_")
+	l.addEntry(
+		date,
+		"*",
+		fmt.Sprintf("%s %s %s", side, formatMicros(abs(quantityMicros)), trade.GetSymbol()),
+		[]string{metadata("trade_id", trade.GetTradeId())},
+		postings,
+	)
+	return nil
+}
+
+func (l *ledger) addCashTransaction(cashTransaction *datav1.CashTransaction) {
+	date := protoDateString(cashTransaction.GetDate())
+	alias := cashTransaction.GetAccountId()
+	var otherAccount string
+	switch cashTransaction.GetType() {
+	case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_DIVIDEND,
+		datav1.CashTransactionType_CASH_TRANSACTION_TYPE_PAYMENT_IN_LIEU:
+		otherAccount = l.account(date, alias, "Income", "Dividends")
+	case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_WITHHOLDING_TAX:
+		otherAccount = l.account(date, alias, "Expenses", "WithholdingTax")
+	case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_INTEREST_RECEIVED:
+		otherAccount = l.account(date, alias, "Income", "Interest")
+	case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_INTEREST_PAID:
+		otherAccount = l.account(date, alias, "Expenses", "Interest")
+	case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_DEPOSIT_WITHDRAWAL:
+		otherAccount = l.account(date, alias, "Equity", "Transfers")
+	case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_FEE:
+		otherAccount = l.account(date, alias, "Expenses", "Fees")
+	case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_COMMISSION_ADJUSTMENT:
+		otherAccount = l.account(date, alias, "Expenses", "Commissions")
+	default:
+		otherAccount = l.account(date, alias, "Income", "Other")
+	}
+	var meta []string
+	if cashTransaction.GetTransactionId() != "" {
+		meta = append(meta, metadata("transaction_id", cashTransaction.GetTransactionId()))
+	}
+	narration := cashTransaction.GetDescription()
+	if narration == "" {
+		narration = strings.TrimPrefix(cashTransaction.GetType().String(), "CASH_TRANSACTION_TYPE_")
+	}
+	l.addEntryWithPayee(
+		date,
+		"*",
+		cashTransaction.GetSymbol(),
+		narration,
+		meta,
+		[]string{
+			posting(l.account(date, alias, "Assets", "Cash"), moneypb.MoneyValueToString(cashTransaction.GetAmount())+" "+cashTransaction.GetCurrencyCode()),
+			posting(otherAccount, ""),
+		},
+	)
+}
+
+func (l *ledger) addTransfer(transfer *datav1.Transfer) {
+	date := protoDateString(transfer.GetDate())
+	alias := transfer.GetAccountId()
+	commodity := commodityName(transfer.GetSymbol())
+	quantityMicros := abs(mathpb.ToMicros(transfer.GetQuantity()))
+	securitiesAccount := l.account(date, alias, "Assets", "Securities")
+	transfersAccount := l.account(date, alias, "Equity", "Transfers")
+	flag := "*"
+	var amount string
+	if transfer.GetDirection() == datav1.TransferDirection_TRANSFER_DIRECTION_OUT {
+		amount = fmt.Sprintf("%s %s {}", formatMicros(-quantityMicros), commodity)
+	} else {
+		currencyCode := transfer.GetCurrencyCode()
+		if currencyCode == "" {
+			currencyCode = "USD"
+		}
+		priceMicros := moneypb.MoneyToMicros(transfer.GetTransferPrice())
+		if transfer.GetTransferPrice() == nil {
+			flag = "!"
+		}
+		amount = fmt.Sprintf("%s %s {%s %s}", formatMicros(quantityMicros), commodity, formatMicros(priceMicros), currencyCode)
+	}
+	narration := fmt.Sprintf("TRANSFER %s %s %s", strings.TrimPrefix(transfer.GetDirection().String(), "TRANSFER_DIRECTION_"), formatMicros(quantityMicros), transfer.GetSymbol())
+	if flag == "!" {
+		narration += " (cost basis unknown)"
+	}
+	l.addEntry(
+		date,
+		flag,
+		narration,
+		nil,
+		[]string{
+			posting(securitiesAccount, amount),
+			posting(transfersAccount, ""),
+		},
+	)
+}
+
+func (l *ledger) addEntry(date string, flag string, narration string, meta []string, postings []string) {
+	l.addEntryWithPayee(date, flag, "", narration, meta, postings)
+}
+
+func (l *ledger) addEntryWithPayee(date string, flag string, payee string, narration string, meta []string, postings []string) {
+	var builder strings.Builder
+	builder.WriteString(date + " " + flag)
+	if payee != "" {
+		builder.WriteString(" " + quote(payee))
+	}
+	builder.WriteString(" " + quote(narration) + "\n")
+	for _, line := range append(meta, postings...) {
+		builder.WriteString("  " + line + "\n")
+	}
+	l.entries = append(l.entries, &entry{date: date, text: builder.String()})
+}
+
+// account returns the account name for the alias and records its first use.
+func (l *ledger) account(date string, alias string, accountType string, name string) string {
+	account := accountType + ":IBKR:" + accountComponent(alias) + ":" + name
+	if openDate, ok := l.accountToOpenDate[account]; !ok || date < openDate {
+		l.accountToOpenDate[account] = date
+	}
+	return account
+}
+
+// String returns the ledger: the options, an open directive per account, and
+// then the entries in date order.
+func (l *ledger) String() string {
+	var builder strings.Builder
+	builder.WriteString("; Generated by ibctl export beancount.\n\n")
+	builder.WriteString(`option "operating_currency" "USD"` + "\n\n")
+	accounts := make([]string, 0, len(l.accountToOpenDate))
+	for account := range l.accountToOpenDate {
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		if l.accountToOpenDate[accounts[i]] != l.accountToOpenDate[accounts[j]] {
+			return l.accountToOpenDate[accounts[i]] < l.accountToOpenDate[accounts[j]]
+		}
+		return accounts[i] < accounts[j]
+	})
+	for _, account := range accounts {
+		builder.WriteString(l.accountToOpenDate[account] + " open " + account)
+		if strings.HasSuffix(account, ":Securities") {
+			builder.WriteString(` "FIFO"`)
+		}
+		builder.WriteString("\n")
+	}
+	sort.SliceStable(l.entries, func(i, j int) bool {
+		return l.entries[i].date < l.entries[j].date
+	})
+	for _, entry := range l.entries {
+		builder.WriteString("\n" + entry.text)
+	}
+	return builder.String()
+}
+
+func posting(account string, amount string) string {
+	if amount == "" {
+		return account
+	}
+	return account + "  " + amount
+}
+
+func metadata(key string, value string) string {
+	return key + ": " + quote(value)
+}
+
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ").Replace(s) + `"`
+}
+
+// accountComponent returns a valid Beancount account name component for an
+// account alias: capitalized, with characters other than letters, digits, and
+// dashes replaced by dashes.
+func accountComponent(alias string) string {
+	runes := []rune(alias)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			runes[i] = '-'
+		}
+	}
+	if len(runes) == 0 || !unicode.IsLetter(runes[0]) && !unicode.IsDigit(runes[0]) {
+		runes = append([]rune{'A'}, runes...)
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// commodityName returns a valid Beancount commodity name for a symbol:
+// uppercase, starting with a letter, ending with a letter or digit, and with
+// spaces and other invalid characters replaced by dashes.
+//
+// For example, "BRK B" becomes "BRK-B" and "SPY 250620C00600000" becomes
+// "SPY-250620C00600000".
+func commodityName(symbol string) string {
+	runes := []rune(strings.ToUpper(symbol))
+	for i, r := range runes {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '.' && r != '_' && r != '-' && r != '\'' {
+			runes[i] = '-'
+		}
+	}
+	name := strings.TrimRight(string(runes), ".-_'")
+	if name == "" || name[0] < 'A' || name[0] > 'Z' {
+		name = "X" + name
+	}
+	return name
+}
+
+// proportion returns value * numerator / denominator without overflow.
+func proportion(value int64, numerator int64, denominator int64) int64 {
+	if numerator == denominator {
+		return value
+	}
+	result := new(big.Int).Mul(big.NewInt(value), big.NewInt(numerator))
+	return result.Quo(result, big.NewInt(denominator)).Int64()
+}
+
+func formatMicros(micros int64) string {
+	return mathpb.ToString(mathpb.FromMicros(micros))
+}
+
+func abs(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// protoDateString returns a YYYY-MM-DD string from a proto Date.
+func protoDateString(d interface {
+	GetYear() uint32
+	GetMonth() uint32
+	GetDay() uint32
+}) string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.GetYear(), d.GetMonth(), d.GetDay())
+}
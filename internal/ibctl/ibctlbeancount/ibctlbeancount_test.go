@@ -0,0 +1,82 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctlbeancount
+
+import (
+	"strings"
+	"testing"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	timev1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/time/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+	mergedData := &ibctlmerge.MergedData{
+		Trades: []*datav1.Trade{
+			newTrade("1", 1, "AAPL", "STK", 10, 150, -1500),
+			// Sells 15 of a 10 share position: closes 10 and opens a 5 share short.
+			newTrade("2", 2, "AAPL", "STK", -15, 160, 2400),
+			newTrade("3", 3, "USD.CAD", "CASH", 1000, 1.35, -1350),
+		},
+		CashTransactions: []*datav1.CashTransaction{
+			{
+				AccountId:     "individual",
+				Type:          datav1.CashTransactionType_CASH_TRANSACTION_TYPE_DIVIDEND,
+				Date:          &timev1.Date{Year: 2025, Month: 3, Day: 4},
+				Amount:        moneypb.MoneyFromMicros("USD", 2_500_000),
+				CurrencyCode:  "USD",
+				Symbol:        "AAPL",
+				Description:   "AAPL Cash Dividend",
+				TransactionId: "42",
+			},
+		},
+	}
+	var builder strings.Builder
+	require.NoError(t, Write(&builder, mergedData))
+	ledger := builder.String()
+	require.Contains(t, ledger, `2025-03-01 open Assets:IBKR:Individual:Securities "FIFO"`)
+	require.Contains(t, ledger, "  Assets:IBKR:Individual:Securities  10 AAPL {{1500 USD}}\n")
+	require.Contains(t, ledger, "  Assets:IBKR:Individual:Securities  -10 AAPL {} @ 160 USD\n")
+	require.Contains(t, ledger, "  Assets:IBKR:Individual:Securities  -5 AAPL {{800 USD}}\n")
+	require.Contains(t, ledger, "  Income:IBKR:Individual:CapitalGains\n")
+	require.Contains(t, ledger, "  Assets:IBKR:Individual:Cash  2399 USD\n")
+	require.Contains(t, ledger, "  Expenses:IBKR:Individual:Commissions  1 USD\n")
+	require.Contains(t, ledger, "  Assets:IBKR:Individual:Cash  1000 USD @@ 1350 CAD\n")
+	require.Contains(t, ledger, `2025-03-04 * "AAPL" "AAPL Cash Dividend"`)
+	require.Contains(t, ledger, "  Income:IBKR:Individual:Dividends\n")
+
+	require.Equal(t, "BRK-B", commodityName("BRK B"))
+	require.Equal(t, "SPY-250620C00600000", commodityName("SPY 250620C00600000"))
+	require.Equal(t, "X7203", commodityName("7203"))
+}
+
+func newTrade(tradeID string, day uint32, symbol string, assetCategory string, quantity int64, price float64, proceeds int64) *datav1.Trade {
+	currencyCode := "USD"
+	if assetCategory == "CASH" {
+		currencyCode = "CAD"
+	}
+	side := datav1.TradeSide_TRADE_SIDE_BUY
+	if quantity < 0 {
+		side = datav1.TradeSide_TRADE_SIDE_SELL
+	}
+	return &datav1.Trade{
+		TradeId:       tradeID,
+		TradeDate:     &timev1.Date{Year: 2025, Month: 3, Day: day},
+		Symbol:        symbol,
+		AssetCategory: assetCategory,
+		Side:          side,
+		Quantity:      mathpb.FromMicros(quantity * 1_000_000),
+		TradePrice:    moneypb.MoneyFromMicros(currencyCode, int64(price*1_000_000)),
+		Proceeds:      moneypb.MoneyFromMicros(currencyCode, proceeds*1_000_000),
+		Commission:    moneypb.MoneyFromMicros(currencyCode, -1_000_000),
+		CurrencyCode:  currencyCode,
+		AccountId:     "individual",
+	}
+}
@@ -0,0 +1,866 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctlcash reconstructs per-currency cash balances from transaction
+// history.
+//
+// Cash positions are otherwise only known from the latest Flex Query Cash
+// Report. The cash ledger replays every cash movement in the merged data:
+//
+//   - Security trades move their proceeds and commission in the trade currency.
+//   - FX conversion trades (asset category CASH, symbol BASE.QUOTE) move the
+//     quantity in the base currency, and the proceeds and commission in the
+//     quote currency.
+//   - Cash transactions (deposits, withdrawals, dividends, withholding tax,
+//     interest, and fees) move their amount.
+//
+// Trades from sources outside IBKR (manual trades and transfer basis lots) and
+// seed data from previous brokers have no IBKR cash effect and are skipped.
+// The reconstructed balances are only as complete as the history: a gap
+// before the first Activity Statement shows up as a difference against the
+// Cash Report.
+//
+// Balances are negative while an account borrows on margin. IBKR charges
+// interest on the debit balance monthly, as a Broker Interest Paid cash
+// transaction described as "<currency> DEBIT INT FOR <MON-YYYY>". The margin
+// costs combine these charges with the average reconstructed debit balance of
+// the month to estimate the annualized rate paid. Credit balances earn
+// interest the same way, described as "<currency> CREDIT INT FOR <MON-YYYY>",
+// and the interest rates combine the interest earned and paid with the
+// average credit and debit balances to estimate both effective rates.
+//
+// Trades settle after their trade date, typically one business day later for
+// stocks. Until then, their cash is in the Cash Report ending cash but not in
+// its ending settled cash. The settlement report lists the unsettled trades,
+// and reconciles their cash against the difference between the two.
+package ibctlcash
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+)
+
+// Balance is the cash balance of an account in one currency at the end of a
+// date with cash movements.
+type Balance struct {
+	// Account is the account alias.
+	Account string `json:"account"`
+	// Currency is the currency code.
+	Currency string `json:"currency"`
+	// Date is the date of the cash movements (YYYY-MM-DD).
+	Date string `json:"date"`
+	// Change is the net cash movement on Date.
+	Change string `json:"change"`
+	// Balance is the balance at the end of Date.
+	Balance string `json:"balance"`
+}
+
+// BalanceHeaders returns the column headers for balance table/CSV output.
+func BalanceHeaders() []string {
+	return []string{"ACCOUNT", "CURRENCY", "DATE", "CHANGE", "BALANCE"}
+}
+
+// BalanceToRow converts a Balance to a string slice for table/CSV output.
+func BalanceToRow(b *Balance) []string {
+	return []string{
+		b.Account,
+		b.Currency,
+		b.Date,
+		b.Change,
+		b.Balance,
+	}
+}
+
+// Divergence is a difference between the reconstructed cash balance of an
+// account in one currency and the balance in the Cash Report.
+type Divergence struct {
+	// Account is the account alias.
+	Account string `json:"account"`
+	// Currency is the currency code.
+	Currency string `json:"currency"`
+	// LedgerBalance is the balance reconstructed from transaction history.
+	LedgerBalance string `json:"ledger_balance"`
+	// ReportedBalance is the balance in the Cash Report.
+	ReportedBalance string `json:"reported_balance"`
+	// Difference is reported - ledger, the cash movement missing from the history.
+	Difference string `json:"difference"`
+}
+
+// DivergenceHeaders returns the column headers for divergence table/CSV output.
+func DivergenceHeaders() []string {
+	return []string{"ACCOUNT", "CURRENCY", "LEDGER", "REPORTED", "DIFFERENCE"}
+}
+
+// DivergenceToRow converts a Divergence to a string slice for table/CSV output.
+func DivergenceToRow(d *Divergence) []string {
+	return []string{
+		d.Account,
+		d.Currency,
+		d.LedgerBalance,
+		d.ReportedBalance,
+		d.Difference,
+	}
+}
+
+// MarginCost is the margin interest an account paid in one currency for one month.
+type MarginCost struct {
+	// Account is the account alias.
+	Account string `json:"account"`
+	// Currency is the currency code.
+	Currency string `json:"currency"`
+	// Month is the month the interest was charged for (YYYY-MM).
+	Month string `json:"month"`
+	// AverageDebit is the average reconstructed debit balance over the month,
+	// as a positive amount.
+	AverageDebit string `json:"average_debit"`
+	// Interest is the margin interest charged, as a positive amount.
+	Interest string `json:"interest"`
+	// Rate is the annualized interest rate (e.g., "5.83%"), empty if the
+	// reconstructed balance had no debit during the month.
+	Rate string `json:"rate"`
+}
+
+// MarginCostHeaders returns the column headers for margin cost table/CSV output.
+func MarginCostHeaders() []string {
+	return []string{"ACCOUNT", "CURRENCY", "MONTH", "AVG DEBIT", "INTEREST", "RATE"}
+}
+
+// MarginCostToRow converts a MarginCost to a string slice for table/CSV output.
+func MarginCostToRow(m *MarginCost) []string {
+	return []string{
+		m.Account,
+		m.Currency,
+		m.Month,
+		m.AverageDebit,
+		m.Interest,
+		m.Rate,
+	}
+}
+
+// InterestRate is the interest an account earned and paid in one currency over
+// a period, with the effective annualized rates implied by the average
+// reconstructed balances.
+type InterestRate struct {
+	// Account is the account alias.
+	Account string `json:"account"`
+	// Currency is the currency code.
+	Currency string `json:"currency"`
+	// Period is the month (YYYY-MM), or the first and last month of the
+	// period (YYYY-MM to YYYY-MM).
+	Period string `json:"period"`
+	// AverageCredit is the average reconstructed credit balance over the period.
+	AverageCredit string `json:"average_credit"`
+	// Earned is the interest earned on credit balances.
+	Earned string `json:"earned"`
+	// EarnedRate is the annualized rate earned (e.g., "3.83%"), empty if the
+	// reconstructed balance had no credit during the period.
+	EarnedRate string `json:"earned_rate"`
+	// AverageDebit is the average reconstructed debit balance over the
+	// period, as a positive amount.
+	AverageDebit string `json:"average_debit"`
+	// Paid is the interest paid on debit balances, as a positive amount.
+	Paid string `json:"paid"`
+	// PaidRate is the annualized rate paid (e.g., "5.83%"), empty if the
+	// reconstructed balance had no debit during the period.
+	PaidRate string `json:"paid_rate"`
+}
+
+// InterestRateHeaders returns the column headers for interest rate table/CSV output.
+func InterestRateHeaders() []string {
+	return []string{"ACCOUNT", "CURRENCY", "PERIOD", "AVG CREDIT", "EARNED", "EARNED RATE", "AVG DEBIT", "PAID", "PAID RATE"}
+}
+
+// InterestRateToRow converts an InterestRate to a string slice for table/CSV output.
+func InterestRateToRow(r *InterestRate) []string {
+	return []string{
+		r.Account,
+		r.Currency,
+		r.Period,
+		r.AverageCredit,
+		r.Earned,
+		r.EarnedRate,
+		r.AverageDebit,
+		r.Paid,
+		r.PaidRate,
+	}
+}
+
+// UnsettledTrade is the cash movement in one currency of a trade that has not
+// settled yet. FX conversions have one for each currency of the pair.
+type UnsettledTrade struct {
+	// Account is the account alias.
+	Account string `json:"account"`
+	// Symbol is the ticker symbol.
+	Symbol string `json:"symbol"`
+	// TradeID is the IBKR trade ID.
+	TradeID string `json:"trade_id"`
+	// TradeDate is the trade date (YYYY-MM-DD).
+	TradeDate string `json:"trade_date"`
+	// SettleDate is the settle date (YYYY-MM-DD).
+	SettleDate string `json:"settle_date"`
+	// Currency is the currency code of the cash movement.
+	Currency string `json:"currency"`
+	// Cash is the cash the trade moves when it settles, positive for cash received.
+	Cash string `json:"cash"`
+}
+
+// UnsettledTradeHeaders returns the column headers for unsettled trade table/CSV output.
+func UnsettledTradeHeaders() []string {
+	return []string{"ACCOUNT", "SYMBOL", "TRADE ID", "TRADE DATE", "SETTLE DATE", "CURRENCY", "CASH"}
+}
+
+// UnsettledTradeToRow converts an UnsettledTrade to a string slice for table/CSV output.
+func UnsettledTradeToRow(u *UnsettledTrade) []string {
+	return []string{
+		u.Account,
+		u.Symbol,
+		u.TradeID,
+		u.TradeDate,
+		u.SettleDate,
+		u.Currency,
+		u.Cash,
+	}
+}
+
+// Settlement is the unsettled cash of an account in one currency, from the
+// unsettled trades and from the Cash Report.
+type Settlement struct {
+	// Account is the account alias.
+	Account string `json:"account"`
+	// Currency is the currency code.
+	Currency string `json:"currency"`
+	// Trades is the number of unsettled trades.
+	Trades int `json:"trades"`
+	// TradesCash is the total cash of the unsettled trades.
+	TradesCash string `json:"trades_cash"`
+	// EndingCash is the Cash Report ending cash, empty if there is no Cash
+	// Report settled cash to reconcile against.
+	EndingCash string `json:"ending_cash,omitempty"`
+	// EndingSettledCash is the Cash Report ending settled cash.
+	EndingSettledCash string `json:"ending_settled_cash,omitempty"`
+	// UnsettledCash is EndingCash - EndingSettledCash.
+	UnsettledCash string `json:"unsettled_cash,omitempty"`
+	// Difference is UnsettledCash - TradesCash, the unsettled cash not
+	// explained by the unsettled trades.
+	Difference string `json:"difference,omitempty"`
+}
+
+// SettlementHeaders returns the column headers for settlement table/CSV output.
+func SettlementHeaders() []string {
+	return []string{"ACCOUNT", "CURRENCY", "TRADES", "TRADES CASH", "ENDING CASH", "SETTLED CASH", "UNSETTLED CASH", "DIFFERENCE"}
+}
+
+// SettlementToRow converts a Settlement to a string slice for table/CSV output.
+func SettlementToRow(s *Settlement) []string {
+	return []string{
+		s.Account,
+		s.Currency,
+		strconv.Itoa(s.Trades),
+		s.TradesCash,
+		s.EndingCash,
+		s.EndingSettledCash,
+		s.UnsettledCash,
+		s.Difference,
+	}
+}
+
+// GetUnsettledTrades returns the cash movements of the trades settling after
+// asOf, sorted by settle date, then account, trade date, and symbol. Trades
+// without a settle date and trades from outside IBKR are skipped.
+func GetUnsettledTrades(trades []*datav1.Trade, asOf xtime.Date) []*UnsettledTrade {
+	asOfString := asOf.String()
+	var unsettledTrades []*UnsettledTrade
+	for _, trade := range trades {
+		if trade.GetSource() != "" || trade.GetSettleDate() == nil {
+			continue
+		}
+		settleDate := protoDateString(trade.GetSettleDate())
+		if settleDate <= asOfString {
+			continue
+		}
+		for _, movement := range tradeCashMovements(trade) {
+			unsettledTrades = append(unsettledTrades, &UnsettledTrade{
+				Account:    trade.GetAccountId(),
+				Symbol:     trade.GetSymbol(),
+				TradeID:    trade.GetTradeId(),
+				TradeDate:  protoDateString(trade.GetTradeDate()),
+				SettleDate: settleDate,
+				Currency:   movement.currency,
+				Cash:       microsToString(movement.micros),
+			})
+		}
+	}
+	sort.SliceStable(unsettledTrades, func(i, j int) bool {
+		if unsettledTrades[i].SettleDate != unsettledTrades[j].SettleDate {
+			return unsettledTrades[i].SettleDate < unsettledTrades[j].SettleDate
+		}
+		if unsettledTrades[i].Account != unsettledTrades[j].Account {
+			return unsettledTrades[i].Account < unsettledTrades[j].Account
+		}
+		if unsettledTrades[i].TradeDate != unsettledTrades[j].TradeDate {
+			return unsettledTrades[i].TradeDate < unsettledTrades[j].TradeDate
+		}
+		return unsettledTrades[i].Symbol < unsettledTrades[j].Symbol
+	})
+	return unsettledTrades
+}
+
+// GetSettlements returns the unsettled cash of each account and currency with
+// unsettled trades or unsettled cash in the Cash Report, sorted by account then
+// currency.
+//
+// Cash positions without a settled balance, downloaded before it was recorded
+// or from a Flex Query without it, have nothing to reconcile against, so only
+// the cash of the unsettled trades is set for them.
+func GetSettlements(unsettledTrades []*UnsettledTrade, cashPositions []*datav1.CashPosition) []*Settlement {
+	keyToSettlement := make(map[balanceKey]*Settlement)
+	getSettlement := func(key balanceKey) *Settlement {
+		settlement, ok := keyToSettlement[key]
+		if !ok {
+			settlement = &Settlement{Account: key.account, Currency: key.currency}
+			keyToSettlement[key] = settlement
+		}
+		return settlement
+	}
+	keyToTradesCashMicros := make(map[balanceKey]int64)
+	for _, unsettledTrade := range unsettledTrades {
+		key := balanceKey{account: unsettledTrade.Account, currency: unsettledTrade.Currency}
+		getSettlement(key).Trades++
+		keyToTradesCashMicros[key] += mathpb.ParseMicros(unsettledTrade.Cash)
+	}
+	type cashReport struct {
+		endingMicros        int64
+		endingSettledMicros int64
+	}
+	keyToCashReport := make(map[balanceKey]*cashReport)
+	for _, cashPosition := range cashPositions {
+		if cashPosition.GetSettledBalance() == nil {
+			continue
+		}
+		key := balanceKey{
+			account:  cashPosition.GetAccountId(),
+			currency: cashPosition.GetBalance().GetCurrencyCode(),
+		}
+		report, ok := keyToCashReport[key]
+		if !ok {
+			report = &cashReport{}
+			keyToCashReport[key] = report
+		}
+		report.endingMicros += moneypb.MoneyToMicros(cashPosition.GetBalance())
+		report.endingSettledMicros += moneypb.MoneyToMicros(cashPosition.GetSettledBalance())
+	}
+	for key, report := range keyToCashReport {
+		unsettledMicros := report.endingMicros - report.endingSettledMicros
+		if unsettledMicros == 0 && keyToSettlement[key] == nil {
+			continue
+		}
+		settlement := getSettlement(key)
+		settlement.EndingCash = microsToString(report.endingMicros)
+		settlement.EndingSettledCash = microsToString(report.endingSettledMicros)
+		settlement.UnsettledCash = microsToString(unsettledMicros)
+		settlement.Difference = microsToString(unsettledMicros - keyToTradesCashMicros[key])
+	}
+	settlements := make([]*Settlement, 0, len(keyToSettlement))
+	for key, settlement := range keyToSettlement {
+		settlement.TradesCash = microsToString(keyToTradesCashMicros[key])
+		settlements = append(settlements, settlement)
+	}
+	sort.Slice(settlements, func(i, j int) bool {
+		if settlements[i].Account != settlements[j].Account {
+			return settlements[i].Account < settlements[j].Account
+		}
+		return settlements[i].Currency < settlements[j].Currency
+	})
+	return settlements
+}
+
+// GetBalances replays the cash movements of the trades and cash transactions,
+// and returns the balance of each account and currency at the end of every
+// date with cash movements, sorted by account, currency, then date.
+func GetBalances(trades []*datav1.Trade, cashTransactions []*datav1.CashTransaction) []*Balance {
+	ledger := newLedger(trades, cashTransactions)
+	var balances []*Balance
+	for _, key := range ledger.sortedKeys() {
+		dateToChangeMicros := ledger.keyToDateToChangeMicros[key]
+		dates := make([]string, 0, len(dateToChangeMicros))
+		for date := range dateToChangeMicros {
+			dates = append(dates, date)
+		}
+		sort.Strings(dates)
+		var balanceMicros int64
+		for _, date := range dates {
+			changeMicros := dateToChangeMicros[date]
+			balanceMicros += changeMicros
+			balances = append(balances, &Balance{
+				Account:  key.account,
+				Currency: key.currency,
+				Date:     date,
+				Change:   microsToString(changeMicros),
+				Balance:  microsToString(balanceMicros),
+			})
+		}
+	}
+	return balances
+}
+
+// Reconcile compares the final reconstructed balance of each account and
+// currency with the Cash Report cash positions, and returns every account and
+// currency that differs by more than one cent, sorted by account then currency.
+//
+// Only accounts with cash positions are compared, since an account without a
+// Cash Report has nothing to reconcile against. A currency on only one side
+// is compared against a zero balance.
+func Reconcile(trades []*datav1.Trade, cashTransactions []*datav1.CashTransaction, cashPositions []*datav1.CashPosition) []*Divergence {
+	ledger := newLedger(trades, cashTransactions)
+	reportedMicros := make(map[balanceKey]int64)
+	reportedAccounts := make(map[string]struct{})
+	for _, cashPosition := range cashPositions {
+		key := balanceKey{
+			account:  cashPosition.GetAccountId(),
+			currency: cashPosition.GetBalance().GetCurrencyCode(),
+		}
+		reportedMicros[key] += moneypb.MoneyToMicros(cashPosition.GetBalance())
+		reportedAccounts[key.account] = struct{}{}
+	}
+	keys := make(map[balanceKey]struct{})
+	for key := range reportedMicros {
+		keys[key] = struct{}{}
+	}
+	for key := range ledger.keyToDateToChangeMicros {
+		if _, ok := reportedAccounts[key.account]; ok {
+			keys[key] = struct{}{}
+		}
+	}
+	var divergences []*Divergence
+	for key := range keys {
+		ledgerMicros := ledger.balanceMicros(key)
+		differenceMicros := reportedMicros[key] - ledgerMicros
+		if absMicros(differenceMicros) <= reconcileToleranceMicros {
+			continue
+		}
+		divergences = append(divergences, &Divergence{
+			Account:         key.account,
+			Currency:        key.currency,
+			LedgerBalance:   microsToString(ledgerMicros),
+			ReportedBalance: microsToString(reportedMicros[key]),
+			Difference:      microsToString(differenceMicros),
+		})
+	}
+	sort.Slice(divergences, func(i, j int) bool {
+		if divergences[i].Account != divergences[j].Account {
+			return divergences[i].Account < divergences[j].Account
+		}
+		return divergences[i].Currency < divergences[j].Currency
+	})
+	return divergences
+}
+
+// IsMarginInterest returns true if the cash transaction is margin interest
+// charged on a debit balance.
+func IsMarginInterest(cashTransaction *datav1.CashTransaction) bool {
+	return cashTransaction.GetType() == datav1.CashTransactionType_CASH_TRANSACTION_TYPE_INTEREST_PAID &&
+		strings.Contains(strings.ToUpper(cashTransaction.GetDescription()), marginInterestDescription)
+}
+
+// IsCashInterest returns true if the cash transaction is interest earned on a
+// credit balance or charged on a debit balance, as opposed to, e.g., bond
+// interest.
+func IsCashInterest(cashTransaction *datav1.CashTransaction) bool {
+	if IsMarginInterest(cashTransaction) {
+		return true
+	}
+	return cashTransaction.GetType() == datav1.CashTransactionType_CASH_TRANSACTION_TYPE_INTEREST_RECEIVED &&
+		strings.Contains(strings.ToUpper(cashTransaction.GetDescription()), creditInterestDescription)
+}
+
+// GetMarginCosts returns the margin interest of each account, currency, and
+// month, with the average debit balance of the month reconstructed from the
+// trades and cash transactions and the annualized rate it implies, sorted by
+// account, currency, then month.
+//
+// Interest is attributed to the month in its description, or to the month
+// before it was posted if the description has none, since IBKR posts the
+// interest for a month at the start of the next. The rate is an estimate: IBKR
+// charges interest on settled balances at tiered rates, while the
+// reconstructed balances are by trade date.
+func GetMarginCosts(trades []*datav1.Trade, cashTransactions []*datav1.CashTransaction) []*MarginCost {
+	type monthKey struct {
+		balanceKey
+		month string
+	}
+	interestMicros := make(map[monthKey]int64)
+	for _, cashTransaction := range cashTransactions {
+		if !IsMarginInterest(cashTransaction) {
+			continue
+		}
+		key := monthKey{
+			balanceKey: balanceKey{
+				account:  cashTransaction.GetAccountId(),
+				currency: cashTransaction.GetCurrencyCode(),
+			},
+			month: interestMonth(cashTransaction),
+		}
+		// Interest paid is a negative cash movement, reported as a positive cost.
+		interestMicros[key] -= moneypb.MoneyToMicros(cashTransaction.GetAmount())
+	}
+	ledger := newLedger(trades, cashTransactions)
+	marginCosts := make([]*MarginCost, 0, len(interestMicros))
+	for key, micros := range interestMicros {
+		marginCost := &MarginCost{
+			Account:  key.account,
+			Currency: key.currency,
+			Month:    key.month,
+			Interest: microsToString(micros),
+		}
+		monthStart, err := time.Parse(monthLayout, key.month)
+		if err == nil {
+			days := monthStart.AddDate(0, 1, -1).Day()
+			_, averageDebitMicros := ledger.averageBalanceMicros(key.balanceKey, monthStart, days)
+			marginCost.AverageDebit = microsToString(averageDebitMicros)
+			marginCost.Rate = annualizedRate(micros, averageDebitMicros, days)
+		}
+		marginCosts = append(marginCosts, marginCost)
+	}
+	sort.Slice(marginCosts, func(i, j int) bool {
+		a, b := marginCosts[i], marginCosts[j]
+		if a.Account != b.Account {
+			return a.Account < b.Account
+		}
+		if a.Currency != b.Currency {
+			return a.Currency < b.Currency
+		}
+		return a.Month < b.Month
+	})
+	return marginCosts
+}
+
+// GetInterestRates returns the interest each account earned and paid in each
+// currency, with the effective annualized rates implied by the average
+// reconstructed credit and debit balances, sorted by account then currency.
+//
+// With monthly, there is one rate per month with interest, and otherwise one
+// rate per account and currency over every month from the first to the last
+// month with interest, so months where the balance earned no interest, such
+// as for balances below IBKR's minimum, lower the effective rate. Interest is
+// attributed to months as in GetMarginCosts, and the rates are estimates for
+// the same reasons.
+func GetInterestRates(trades []*datav1.Trade, cashTransactions []*datav1.CashTransaction, monthly bool) []*InterestRate {
+	keyToMonthToInterest := make(map[balanceKey]map[string]*monthInterest)
+	for _, cashTransaction := range cashTransactions {
+		if !IsCashInterest(cashTransaction) {
+			continue
+		}
+		key := balanceKey{
+			account:  cashTransaction.GetAccountId(),
+			currency: cashTransaction.GetCurrencyCode(),
+		}
+		monthToInterest, ok := keyToMonthToInterest[key]
+		if !ok {
+			monthToInterest = make(map[string]*monthInterest)
+			keyToMonthToInterest[key] = monthToInterest
+		}
+		month := interestMonth(cashTransaction)
+		interest, ok := monthToInterest[month]
+		if !ok {
+			interest = &monthInterest{}
+			monthToInterest[month] = interest
+		}
+		amountMicros := moneypb.MoneyToMicros(cashTransaction.GetAmount())
+		if IsMarginInterest(cashTransaction) {
+			// Interest paid is a negative cash movement, reported as a positive amount.
+			interest.paidMicros -= amountMicros
+		} else {
+			interest.earnedMicros += amountMicros
+		}
+	}
+	ledger := newLedger(trades, cashTransactions)
+	var interestRates []*InterestRate
+	for key, monthToInterest := range keyToMonthToInterest {
+		months := make([]string, 0, len(monthToInterest))
+		for month := range monthToInterest {
+			months = append(months, month)
+		}
+		sort.Strings(months)
+		if monthly {
+			for _, month := range months {
+				if interestRate := newInterestRate(ledger, key, month, month, monthToInterest); interestRate != nil {
+					interestRates = append(interestRates, interestRate)
+				}
+			}
+			continue
+		}
+		if interestRate := newInterestRate(ledger, key, months[0], months[len(months)-1], monthToInterest); interestRate != nil {
+			interestRates = append(interestRates, interestRate)
+		}
+	}
+	sort.Slice(interestRates, func(i, j int) bool {
+		a, b := interestRates[i], interestRates[j]
+		if a.Account != b.Account {
+			return a.Account < b.Account
+		}
+		if a.Currency != b.Currency {
+			return a.Currency < b.Currency
+		}
+		return a.Period < b.Period
+	})
+	return interestRates
+}
+
+// *** PRIVATE ***
+
+// creditInterestDescription is the part of the description IBKR gives
+// interest earned on credit balances, e.g., "USD CREDIT INT FOR MAR-2025".
+const creditInterestDescription = "CREDIT INT"
+
+// marginInterestDescription is the part of the description IBKR gives margin
+// interest, e.g., "USD DEBIT INT FOR MAR-2025".
+const marginInterestDescription = "DEBIT INT"
+
+// monthLayout is the layout of an interest month.
+const monthLayout = "2006-01"
+
+// interestMonthRegexp matches the month of an interest description, e.g.,
+// "FOR MAR-2025".
+var interestMonthRegexp = regexp.MustCompile(`FOR ([A-Z]{3}-\d{4})`)
+
+// interestMonth returns the month (YYYY-MM) an interest cash transaction was
+// earned or charged for.
+func interestMonth(cashTransaction *datav1.CashTransaction) string {
+	if match := interestMonthRegexp.FindStringSubmatch(strings.ToUpper(cashTransaction.GetDescription())); match != nil {
+		// Month names are parsed case-insensitively.
+		if month, err := time.Parse("Jan-2006", match[1]); err == nil {
+			return month.Format(monthLayout)
+		}
+	}
+	date := cashTransaction.GetDate()
+	postingMonth := time.Date(int(date.GetYear()), time.Month(date.GetMonth()), 1, 0, 0, 0, 0, time.UTC)
+	return postingMonth.AddDate(0, -1, 0).Format(monthLayout)
+}
+
+// monthInterest is the interest earned and paid for a month, as positive amounts.
+type monthInterest struct {
+	earnedMicros int64
+	paidMicros   int64
+}
+
+// newInterestRate returns the interest rate of an account and currency over
+// the months from firstMonth to lastMonth, or nil if a month is invalid.
+func newInterestRate(
+	ledger *ledger,
+	key balanceKey,
+	firstMonth string,
+	lastMonth string,
+	monthToInterest map[string]*monthInterest,
+) *InterestRate {
+	start, err := time.Parse(monthLayout, firstMonth)
+	if err != nil {
+		return nil
+	}
+	end, err := time.Parse(monthLayout, lastMonth)
+	if err != nil {
+		return nil
+	}
+	end = end.AddDate(0, 1, 0)
+	days := int(end.Sub(start).Hours() / 24)
+	var earnedMicros, paidMicros int64
+	for month := start; month.Before(end); month = month.AddDate(0, 1, 0) {
+		if interest, ok := monthToInterest[month.Format(monthLayout)]; ok {
+			earnedMicros += interest.earnedMicros
+			paidMicros += interest.paidMicros
+		}
+	}
+	averageCreditMicros, averageDebitMicros := ledger.averageBalanceMicros(key, start, days)
+	period := firstMonth
+	if lastMonth != firstMonth {
+		period = firstMonth + " to " + lastMonth
+	}
+	return &InterestRate{
+		Account:       key.account,
+		Currency:      key.currency,
+		Period:        period,
+		AverageCredit: microsToString(averageCreditMicros),
+		Earned:        microsToString(earnedMicros),
+		EarnedRate:    annualizedRate(earnedMicros, averageCreditMicros, days),
+		AverageDebit:  microsToString(averageDebitMicros),
+		Paid:          microsToString(paidMicros),
+		PaidRate:      annualizedRate(paidMicros, averageDebitMicros, days),
+	}
+}
+
+// annualizedRate returns the annualized rate of interest on an average
+// balance over a number of days (e.g., "5.83%"), or empty if the average
+// balance is zero.
+func annualizedRate(interestMicros int64, averageBalanceMicros int64, days int) string {
+	if averageBalanceMicros <= 0 {
+		return ""
+	}
+	rate := float64(interestMicros) / float64(averageBalanceMicros) * 365 / float64(days) * 100
+	return fmt.Sprintf("%.2f%%", rate)
+}
+
+// assetCategoryCash is the IBKR asset category for FX conversion trades.
+const assetCategoryCash = "CASH"
+
+// reconcileToleranceMicros is the balance difference ignored as rounding (one cent).
+const reconcileToleranceMicros = 10_000
+
+// balanceKey identifies the cash balance of an account in one currency.
+type balanceKey struct {
+	account  string
+	currency string
+}
+
+// ledger holds the net cash movement of each account and currency per date.
+type ledger struct {
+	keyToDateToChangeMicros map[balanceKey]map[string]int64
+}
+
+// newLedger replays the cash movements of the trades and cash transactions.
+func newLedger(trades []*datav1.Trade, cashTransactions []*datav1.CashTransaction) *ledger {
+	l := &ledger{
+		keyToDateToChangeMicros: make(map[balanceKey]map[string]int64),
+	}
+	for _, trade := range trades {
+		// Trades from outside IBKR have no IBKR cash effect.
+		if trade.GetSource() != "" {
+			continue
+		}
+		date := protoDateString(trade.GetTradeDate())
+		for _, movement := range tradeCashMovements(trade) {
+			l.add(trade.GetAccountId(), movement.currency, date, movement.micros)
+		}
+	}
+	for _, cashTransaction := range cashTransactions {
+		l.add(
+			cashTransaction.GetAccountId(),
+			cashTransaction.GetCurrencyCode(),
+			protoDateString(cashTransaction.GetDate()),
+			moneypb.MoneyToMicros(cashTransaction.GetAmount()),
+		)
+	}
+	return l
+}
+
+// cashMovement is a cash movement in one currency.
+type cashMovement struct {
+	currency string
+	micros   int64
+}
+
+// tradeCashMovements returns the cash movements of a trade: the proceeds and
+// commission in the trade currency, and for FX conversions, the quantity in
+// the base currency of the pair. Zero movements are skipped.
+func tradeCashMovements(trade *datav1.Trade) []cashMovement {
+	var movements []cashMovement
+	if cashMicros := moneypb.MoneyToMicros(trade.GetProceeds()) + moneypb.MoneyToMicros(trade.GetCommission()); cashMicros != 0 {
+		movements = append(movements, cashMovement{currency: trade.GetCurrencyCode(), micros: cashMicros})
+	}
+	if trade.GetAssetCategory() == assetCategoryCash {
+		// The quantity of an FX conversion is in the base currency of the pair.
+		if baseCurrency, _, ok := strings.Cut(trade.GetSymbol(), "."); ok {
+			if quantityMicros := mathpb.ToMicros(trade.GetQuantity()); quantityMicros != 0 {
+				movements = append(movements, cashMovement{currency: baseCurrency, micros: quantityMicros})
+			}
+		}
+	}
+	return movements
+}
+
+// add adds a cash movement to the ledger. Zero movements are skipped so that
+// trades without cash, such as seed data, do not create balances.
+func (l *ledger) add(account string, currency string, date string, changeMicros int64) {
+	if changeMicros == 0 || currency == "" {
+		return
+	}
+	key := balanceKey{account: account, currency: currency}
+	dateToChangeMicros, ok := l.keyToDateToChangeMicros[key]
+	if !ok {
+		dateToChangeMicros = make(map[string]int64)
+		l.keyToDateToChangeMicros[key] = dateToChangeMicros
+	}
+	dateToChangeMicros[date] += changeMicros
+}
+
+// balanceMicros returns the final balance of an account and currency.
+func (l *ledger) balanceMicros(key balanceKey) int64 {
+	var balanceMicros int64
+	for _, changeMicros := range l.keyToDateToChangeMicros[key] {
+		balanceMicros += changeMicros
+	}
+	return balanceMicros
+}
+
+// averageBalanceMicros returns the averages of the credit balance and of the
+// debit balance, as a positive amount, at the end of each of the days starting
+// at start. Days with a debit balance count as zero for the credit average,
+// and days with a credit balance count as zero for the debit average.
+func (l *ledger) averageBalanceMicros(key balanceKey, start time.Time, days int) (int64, int64) {
+	dateToChangeMicros := l.keyToDateToChangeMicros[key]
+	dates := make([]string, 0, len(dateToChangeMicros))
+	for date := range dateToChangeMicros {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	var balanceMicros, creditSumMicros, debitSumMicros int64
+	var next int
+	for day := range days {
+		date := start.AddDate(0, 0, day).Format(time.DateOnly)
+		for next < len(dates) && dates[next] <= date {
+			balanceMicros += dateToChangeMicros[dates[next]]
+			next++
+		}
+		if balanceMicros > 0 {
+			creditSumMicros += balanceMicros
+		} else {
+			debitSumMicros -= balanceMicros
+		}
+	}
+	return creditSumMicros / int64(days), debitSumMicros / int64(days)
+}
+
+// sortedKeys returns the ledger keys sorted by account then currency.
+func (l *ledger) sortedKeys() []balanceKey {
+	keys := make([]balanceKey, 0, len(l.keyToDateToChangeMicros))
+	for key := range l.keyToDateToChangeMicros {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].account != keys[j].account {
+			return keys[i].account < keys[j].account
+		}
+		return keys[i].currency < keys[j].currency
+	})
+	return keys
+}
+
+// microsToString formats a micros amount as a decimal string.
+func microsToString(micros int64) string {
+	return mathpb.ToString(mathpb.FromMicros(micros))
+}
+
+// absMicros returns the absolute value of a micros amount.
+func absMicros(micros int64) int64 {
+	if micros < 0 {
+		return -micros
+	}
+	return micros
+}
+
+// protoDateString returns a sortable YYYY-MM-DD string from a proto Date.
+func protoDateString(d interface {
+	GetYear() uint32
+	GetMonth() uint32
+	GetDay() uint32
+}) string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.GetYear(), d.GetMonth(), d.GetDay())
+}
@@ -0,0 +1,212 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctlcash
+
+import (
+	"testing"
+	"time"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	timev1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/time/v1"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBalancesAndReconcile(t *testing.T) {
+	t.Parallel()
+	trades := []*datav1.Trade{
+		// Buys 10 AAPL for 1500 USD plus 1 USD commission.
+		newTrade(3, "AAPL", "STK", "USD", 10, -1500),
+		// Converts 1000 USD to 1350 CAD, with 2 CAD commission.
+		newTrade(4, "USD.CAD", "CASH", "CAD", -1000, 1350),
+	}
+	// Manual trades have no IBKR cash effect.
+	manualTrade := newTrade(4, "PRIVATE", "STK", "USD", 100, -10000)
+	manualTrade.Source = "manual"
+	trades = append(trades, manualTrade)
+	cashTransactions := []*datav1.CashTransaction{
+		newCashTransaction(2, datav1.CashTransactionType_CASH_TRANSACTION_TYPE_DEPOSIT_WITHDRAWAL, "USD", 5_000_000_000),
+		newCashTransaction(5, datav1.CashTransactionType_CASH_TRANSACTION_TYPE_DIVIDEND, "USD", 2_500_000),
+		newCashTransaction(5, datav1.CashTransactionType_CASH_TRANSACTION_TYPE_WITHHOLDING_TAX, "USD", -375_000),
+	}
+	balances := GetBalances(trades, cashTransactions)
+	require.Equal(t, []*Balance{
+		{Account: "individual", Currency: "CAD", Date: "2025-03-04", Change: "1348", Balance: "1348"},
+		{Account: "individual", Currency: "USD", Date: "2025-03-02", Change: "5000", Balance: "5000"},
+		{Account: "individual", Currency: "USD", Date: "2025-03-03", Change: "-1501", Balance: "3499"},
+		{Account: "individual", Currency: "USD", Date: "2025-03-04", Change: "-1000", Balance: "2499"},
+		{Account: "individual", Currency: "USD", Date: "2025-03-05", Change: "2.125", Balance: "2501.125"},
+	}, balances)
+
+	cashPositions := []*datav1.CashPosition{
+		{AccountId: "individual", Balance: moneypb.MoneyFromMicros("USD", 2_501_130_000)},
+		{AccountId: "individual", Balance: moneypb.MoneyFromMicros("CAD", 1_400_000_000)},
+		// Accounts without history are still compared.
+		{AccountId: "rrsp", Balance: moneypb.MoneyFromMicros("USD", 100_000_000)},
+	}
+	require.Equal(t, []*Divergence{
+		{Account: "individual", Currency: "CAD", LedgerBalance: "1348", ReportedBalance: "1400", Difference: "52"},
+		{Account: "rrsp", Currency: "USD", LedgerBalance: "0", ReportedBalance: "100", Difference: "100"},
+	}, Reconcile(trades, cashTransactions, cashPositions))
+}
+
+func TestGetMarginCosts(t *testing.T) {
+	t.Parallel()
+	trades := []*datav1.Trade{
+		// Buys 100 AAPL for 15000 USD plus 1 USD commission, borrowing 10001 USD.
+		newTrade(3, "AAPL", "STK", "USD", 100, -15000),
+	}
+	marginInterest := newCashTransaction(3, datav1.CashTransactionType_CASH_TRANSACTION_TYPE_INTEREST_PAID, "USD", -45_000_000)
+	marginInterest.Date.Month = 4
+	marginInterest.Description = "USD Debit Int for MAR-2025"
+	// Margin interest without a month in the description is for the month before it was posted.
+	undatedMarginInterest := newCashTransaction(5, datav1.CashTransactionType_CASH_TRANSACTION_TYPE_INTEREST_PAID, "USD", -1_000_000)
+	undatedMarginInterest.Description = "USD DEBIT INT"
+	// Bond interest paid is not margin interest.
+	bondInterest := newCashTransaction(5, datav1.CashTransactionType_CASH_TRANSACTION_TYPE_INTEREST_PAID, "USD", -2_000_000)
+	bondInterest.Description = "PURCHASE ACCRUED INT US TREASURY"
+	cashTransactions := []*datav1.CashTransaction{
+		newCashTransaction(2, datav1.CashTransactionType_CASH_TRANSACTION_TYPE_DEPOSIT_WITHDRAWAL, "USD", 5_000_000_000),
+		marginInterest,
+		undatedMarginInterest,
+		bondInterest,
+	}
+	require.True(t, IsMarginInterest(marginInterest))
+	require.False(t, IsMarginInterest(bondInterest))
+	// The balance is -10001 USD from March 3, and -10004 USD after the interest
+	// paid on March 5, so the average debit over March's 31 days is
+	// (10001 * 2 + 10004 * 27) / 31.
+	require.Equal(t, []*MarginCost{
+		{Account: "individual", Currency: "USD", Month: "2025-02", AverageDebit: "0", Interest: "1"},
+		{Account: "individual", Currency: "USD", Month: "2025-03", AverageDebit: "9358.387096", Interest: "45", Rate: "5.66%"},
+	}, GetMarginCosts(trades, cashTransactions))
+}
+
+func TestGetInterestRates(t *testing.T) {
+	t.Parallel()
+	// Deposits 36500 USD on March 1.
+	deposit := newCashTransaction(1, datav1.CashTransactionType_CASH_TRANSACTION_TYPE_DEPOSIT_WITHDRAWAL, "USD", 36_500_000_000)
+	// Credit interest for March, posted on April 3.
+	marchInterest := newCashTransaction(3, datav1.CashTransactionType_CASH_TRANSACTION_TYPE_INTEREST_RECEIVED, "USD", 93_000_000)
+	marchInterest.Date.Month = 4
+	marchInterest.Description = "USD Credit Int for MAR-2025"
+	// Credit interest for May, posted on June 3, and no interest for April.
+	mayInterest := newCashTransaction(3, datav1.CashTransactionType_CASH_TRANSACTION_TYPE_INTEREST_RECEIVED, "USD", 93_000_000)
+	mayInterest.Date.Month = 6
+	mayInterest.Description = "USD CREDIT INT FOR MAY-2025"
+	// Bond interest received is not cash interest.
+	bondInterest := newCashTransaction(5, datav1.CashTransactionType_CASH_TRANSACTION_TYPE_INTEREST_RECEIVED, "USD", 50_000_000)
+	bondInterest.Date.Month = 6
+	bondInterest.Description = "BOND COUPON PAYMENT US TREASURY"
+	cashTransactions := []*datav1.CashTransaction{
+		deposit,
+		marchInterest,
+		mayInterest,
+		bondInterest,
+	}
+	require.True(t, IsCashInterest(marchInterest))
+	require.False(t, IsCashInterest(bondInterest))
+	// The balance is 36500 USD through March, so March earned
+	// 93 / 36500 * 365 / 31, and 36593 USD from April 3.
+	require.Equal(t, []*InterestRate{
+		{Account: "individual", Currency: "USD", Period: "2025-03", AverageCredit: "36500", Earned: "93", EarnedRate: "3.00%", AverageDebit: "0", Paid: "0"},
+		{Account: "individual", Currency: "USD", Period: "2025-05", AverageCredit: "36593", Earned: "93", EarnedRate: "2.99%", AverageDebit: "0", Paid: "0"},
+	}, GetInterestRates(nil, cashTransactions, true))
+	// The effective rate over March through May includes April, which earned no interest.
+	require.Equal(t, []*InterestRate{
+		{Account: "individual", Currency: "USD", Period: "2025-03 to 2025-05", AverageCredit: "36559.641304", Earned: "186", EarnedRate: "2.02%", AverageDebit: "0", Paid: "0"},
+	}, GetInterestRates(nil, cashTransactions, false))
+}
+
+func TestGetSettlements(t *testing.T) {
+	t.Parallel()
+	// Buys 10 AAPL for 1500 USD plus 1 USD commission, settled on March 4.
+	settledTrade := newTrade(3, "AAPL", "STK", "USD", 10, -1500)
+	settledTrade.SettleDate = &timev1.Date{Year: 2025, Month: 3, Day: 4}
+	// Sells 10 MSFT for 2000 USD less 1 USD commission, settling on March 5.
+	sellTrade := newTrade(4, "MSFT", "STK", "USD", -10, 2000)
+	sellTrade.SettleDate = &timev1.Date{Year: 2025, Month: 3, Day: 5}
+	// Converts 1000 USD to 1350 CAD, with 2 CAD commission, settling on March 6.
+	fxTrade := newTrade(4, "USD.CAD", "CASH", "CAD", -1000, 1350)
+	fxTrade.SettleDate = &timev1.Date{Year: 2025, Month: 3, Day: 6}
+	// Manual trades have no IBKR cash effect.
+	manualTrade := newTrade(4, "PRIVATE", "STK", "USD", 100, -10000)
+	manualTrade.SettleDate = &timev1.Date{Year: 2025, Month: 3, Day: 5}
+	manualTrade.Source = "manual"
+	trades := []*datav1.Trade{
+		settledTrade,
+		sellTrade,
+		fxTrade,
+		manualTrade,
+		// Trades without a settle date are skipped.
+		newTrade(4, "NVDA", "STK", "USD", 1, -100),
+	}
+	unsettledTrades := GetUnsettledTrades(trades, xtime.Date{Year: 2025, Month: time.March, Day: 4})
+	require.Equal(t, []*UnsettledTrade{
+		{Account: "individual", Symbol: "MSFT", TradeDate: "2025-03-04", SettleDate: "2025-03-05", Currency: "USD", Cash: "1999"},
+		{Account: "individual", Symbol: "USD.CAD", TradeDate: "2025-03-04", SettleDate: "2025-03-06", Currency: "CAD", Cash: "1348"},
+		{Account: "individual", Symbol: "USD.CAD", TradeDate: "2025-03-04", SettleDate: "2025-03-06", Currency: "USD", Cash: "-1000"},
+	}, unsettledTrades)
+
+	cashPositions := []*datav1.CashPosition{
+		{
+			AccountId:      "individual",
+			Balance:        moneypb.MoneyFromMicros("USD", 5_000_000_000),
+			SettledBalance: moneypb.MoneyFromMicros("USD", 4_001_500_000),
+		},
+		{
+			AccountId:      "individual",
+			Balance:        moneypb.MoneyFromMicros("CAD", 1_348_000_000),
+			SettledBalance: moneypb.MoneyFromMicros("CAD", 0),
+		},
+		// Unsettled cash without unsettled trades.
+		{
+			AccountId:      "individual",
+			Balance:        moneypb.MoneyFromMicros("EUR", 100_000_000),
+			SettledBalance: moneypb.MoneyFromMicros("EUR", 80_000_000),
+		},
+		// Cash positions without a settled balance and settled cash positions are skipped.
+		{AccountId: "rrsp", Balance: moneypb.MoneyFromMicros("USD", 100_000_000)},
+		{
+			AccountId:      "rrsp",
+			Balance:        moneypb.MoneyFromMicros("EUR", 50_000_000),
+			SettledBalance: moneypb.MoneyFromMicros("EUR", 50_000_000),
+		},
+	}
+	require.Equal(t, []*Settlement{
+		{Account: "individual", Currency: "CAD", Trades: 1, TradesCash: "1348", EndingCash: "1348", EndingSettledCash: "0", UnsettledCash: "1348", Difference: "0"},
+		{Account: "individual", Currency: "EUR", TradesCash: "0", EndingCash: "100", EndingSettledCash: "80", UnsettledCash: "20", Difference: "20"},
+		{Account: "individual", Currency: "USD", Trades: 2, TradesCash: "999", EndingCash: "5000", EndingSettledCash: "4001.5", UnsettledCash: "998.5", Difference: "-0.5"},
+	}, GetSettlements(unsettledTrades, cashPositions))
+}
+
+func newTrade(day uint32, symbol string, assetCategory string, currencyCode string, quantity int64, proceeds int64) *datav1.Trade {
+	commission := int64(-1_000_000)
+	if assetCategory == "CASH" {
+		commission = -2_000_000
+	}
+	return &datav1.Trade{
+		TradeDate:     &timev1.Date{Year: 2025, Month: 3, Day: day},
+		Symbol:        symbol,
+		AssetCategory: assetCategory,
+		Quantity:      mathpb.FromMicros(quantity * 1_000_000),
+		Proceeds:      moneypb.MoneyFromMicros(currencyCode, proceeds*1_000_000),
+		Commission:    moneypb.MoneyFromMicros(currencyCode, commission),
+		CurrencyCode:  currencyCode,
+		AccountId:     "individual",
+	}
+}
+
+func newCashTransaction(day uint32, cashTransactionType datav1.CashTransactionType, currencyCode string, amountMicros int64) *datav1.CashTransaction {
+	return &datav1.CashTransaction{
+		AccountId:    "individual",
+		Type:         cashTransactionType,
+		Date:         &timev1.Date{Year: 2025, Month: 3, Day: day},
+		Amount:       moneypb.MoneyFromMicros(currencyCode, amountMicros),
+		CurrencyCode: currencyCode,
+	}
+}
@@ -9,18 +9,45 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io/fs"
+	"maps"
+	"math"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlgit"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctltaxrules"
+	"github.com/bufdev/ibctl/internal/pkg/filemode"
+	"github.com/bufdev/ibctl/internal/pkg/httpclient"
+	"github.com/bufdev/ibctl/internal/pkg/ibkrflexquery"
 	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/protoio"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
 	"gopkg.in/yaml.v3"
 )
 
 // validAliasPattern matches lowercase alphanumeric strings with hyphens, used for account aliases.
 var validAliasPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
 
+// validAccountIDPattern matches IBKR account IDs (e.g., "U1234567", "DU1234567").
+var validAccountIDPattern = regexp.MustCompile(`^[A-Z]{1,3}[0-9]+$`)
+
+// validEnvVarPattern matches environment variable names (e.g., "IBKR_FLEX_TOKEN_JOINT").
+var validEnvVarPattern = regexp.MustCompile(`^[A-Z_][A-Z0-9_]*$`)
+
+// validCurrencyCodePattern matches three-letter ISO 4217 currency codes (e.g., "CAD").
+var validCurrencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// validCountryCodePattern validates ISO 3166-1 alpha-2 country codes.
+var validCountryCodePattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
 // configTemplate is the default configuration file template with comments.
 // yaml.v3 does not preserve comments, so we hardcode the template string.
 const configTemplate = `# The configuration file version.
@@ -36,6 +63,22 @@ version: v1
 #
 # The Flex Web Service token must be set via the IBKR_FLEX_WEB_SERVICE_TOKEN environment variable.
 flex_query_id: ""
+# The period preset that overrides the period configured in the Flex Query.
+#
+# Optional. One of LastBusinessDay, LastBusinessWeek, Last30CalendarDays,
+# MonthToDate, LastMonth, LastQuarter, YearToDate, LastYear, or
+# Last365CalendarDays. Applies to the Flex Query of every login. Can be
+# overridden per run with "ibctl download --period".
+# flex_query_period: Last30CalendarDays
+# Additional IBKR logins, each with its own Flex Web Service token and Flex Query.
+#
+# Optional. For accounts under a different IBKR login than flex_query_id. Each
+# login's token is read from the token_env environment variable. Downloads
+# fetch the Flex Query of every login and store each account under its alias;
+# all accounts must be in the accounts section.
+# logins:
+#   - flex_query_id: "654321"
+#     token_env: IBKR_FLEX_TOKEN_JOINT
 # Account aliases mapping.
 #
 # Required. Maps user-chosen aliases to IBKR account IDs.
@@ -43,6 +86,22 @@ flex_query_id: ""
 # Aliases must be lowercase alphanumeric with hyphens (e.g., "rrsp", "hold-co").
 accounts:
   # my-account: "U1234567"
+# Account tax treatment.
+#
+# Optional. Maps account aliases to taxable (the default), deferred (e.g., RRSP,
+# IRA), or exempt (e.g., TFSA, Roth IRA). Only taxable accounts contribute to
+# capital gains tax estimates; the others are reported separately.
+# account_types:
+#   rrsp: deferred
+#   tfsa: exempt
+# Account groups.
+#
+# Optional. Maps group names to lists of account aliases, so holdings, allocations,
+# and tax estimates can be computed per entity with --group (e.g., "ibctl holding
+# value --group holdco"). Group names follow the same rules as account aliases.
+# groups:
+#   personal: [rrsp, tfsa]
+#   holdco: [hold-co]
 # Symbol classification configuration.
 #
 # Optional. Adds category, type, sector, and geo metadata to holdings output.
@@ -52,23 +111,417 @@ accounts:
 #     type: STOCK
 #     sector: TECH
 #     geo: US
+#   # Optional look-through for "ibctl holding currency list": the percentage of
+#   # the holding's value exposed to each currency (e.g., an ADR or a global ETF).
+#   # Percentages must add up to 100. Without it, the trading currency is used.
+#   - name: VT
+#     category: EQUITY
+#     type: ETF
+#     currencies:
+#       USD: 62
+#       EUR: 12
+#       JPY: 6
+#       GBP: 4
+#       CAD: 3
+#       CHF: 13
+#   # Optional cost basis method: fifo (the default) or average. With average,
+#   # every lot of the symbol has the average cost of the position, as allowed
+#   # for mutual funds, while keeping each lot's date for STCG and LTCG.
+#   - name: VFIAX
+#     category: EQUITY
+#     type: FUND
+#     cost_basis: average
+#   # Optional Yahoo Finance symbol for prices downloaded for --as-of and
+#   # quotes for --watch. Non-US listings need their exchange suffix. Without
+#   # it, the symbol is used with spaces replaced by dashes (e.g., BRK-B).
+#   - name: SHOP
+#     category: EQUITY
+#     type: STOCK
+#     quote_symbol: SHOP.TO
+# Symbols to ignore.
+#
+# Optional. Excludes symbols, such as worthless spin-off stubs or promotional
+# shares, from holdings, lot lists, and position verification. Their trades
+# are kept in the data and still count for trades, taxes, and reconciliation.
+# ignore_symbols: [XYZ.OLD, PROMO]
+# ETF look-through weights.
+#
+# Optional. Maps symbols (e.g., ETFs) to the percentage of their value in each
+# sector and geo, so "ibctl holding category list --by sector" and "--by geo",
+# and allocation alerts, split the holding across its constituents instead of
+# showing it as a single bucket. Percentages for each must add up to 100.
+# lookthrough:
+#   VT:
+#     sector:
+#       TECH: 25
+#       FINANCIALS: 16
+#       OTHER: 59
+#     geo:
+#       US: 62
+#       INTL: 38
+# Benchmark compositions.
+#
+# Optional. Maps benchmark names to the percentage of the benchmark in each
+# category, type, sector, or geo, so "ibctl report benchmark" can compare the
+# portfolio weights to the benchmark with over/underweight columns. Keys must
+# match the classifications in the symbols section. Percentages for each must
+# add up to 100.
+# benchmarks:
+#   SP500:
+#     sector:
+#       TECH: 32
+#       FINANCIALS: 13
+#       HEALTHCARE: 10
+#       OTHER: 45
+# Tax rates for estimates in "ibctl holding value" and "ibctl holding tax-projection".
+#
+# Optional. Rates are fractions (0.408 is 40.8%). The income rate applies to
+# dividends and interest and defaults to the stcg rate. Projected tax is also
+# shown in base_currency, which defaults to USD.
+#
+# The jurisdiction (us, ca, or au) selects the rules for when gains become
+# long-term, and defaults to us, where lots are long-term when held for more
+# than one year. In ca there are no long-term gains, and all gains are taxed at
+# the stcg rate. In au, ltcg defaults to half the stcg rate, for the CGT
+# discount. long_term_days overrides the holding period with a fixed number of
+# days.
+# taxes:
+#   jurisdiction: us
+#   stcg: 0.408
+#   ltcg: 0.28
+#   income: 0.408
+#   base_currency: CAD
+#   # For "ibctl holding estimated-tax": the prior year's total tax in USD and
+#   # the percentage of it required for the safe harbor (110 for high incomes).
+#   prior_year_tax: "42000"
+#   prior_year_pct: 110
+#   # For "ibctl income withholding": the treaty withholding rate on dividends
+#   # per source country, used to flag over-withholding that may be reclaimable.
+#   treaty_rates:
+#     US: 0.15
+#     CH: 0.15
+# Whether to save the raw Flex Query XML on every download.
+#
+# Optional. Each account's statement is saved unmodified to
+# cache/raw/<alias>/<timestamp>.xml, so historical downloads can be
+# re-processed later with "ibctl download --replay <file>".
+# archive_raw: true
+# Whether to fail downloads on Flex Query records that cannot be converted.
+#
+# Optional. By default, unparseable transfers, trade transfers, corporate
+# actions, cash positions, and cash transactions are skipped with a warning and
+# saved to data/quarantine/<alias>/<timestamp>.xml for inspection. With strict,
+# the download fails instead. Can also be enabled per run with "ibctl download --strict".
+# strict: true
+# Whether the ibctl directory is read-only.
+#
+# Optional. Nothing under the directory is written: downloads and commands that
+# modify data fail, and the merged data cache is kept in a temporary directory.
+# Use this to inspect an archived or synced copy of the directory safely. Can
+# also be enabled per run with --readonly.
+# readonly: true
+# Whether to encrypt files under data/ and cache/ at rest.
+#
+# Optional. Files are encrypted with the key in the IBCTL_ENCRYPTION_KEY
+# environment variable (or the macOS keychain item "ibctl-encryption-key").
+# After changing this, run "ibctl data encryption migrate" to rewrite existing files.
+# encrypt: true
+# Permissions of the files and directories written under data/ and cache/.
+#
+# Optional. Octal modes, defaulting to 0600 and 0700 so that other users of a
+# shared machine cannot read financial data. Modes are applied regardless of
+# the umask. Existing files get the new mode when they are next written, and
+# existing directories are left alone.
+# file_mode: "0640"
+# dir_mode: "0750"
+# Whether exports redact account aliases and IBKR account IDs by default.
+#
+# Optional. Redacted exports replace each account alias with a stable pseudonym
+# (account-1, account-2, ... in sorted alias order) and drop descriptions that
+# mention an account, so they can be shared with an advisor. Can be overridden
+# per run with --redact or --redact=false.
+# redact: true
+# How old the downloaded position snapshot can be before holdings commands warn.
+#
+# Optional. Defaults to 72h. Holdings commands warn with a suggestion to run
+# "ibctl download" when any account's positions and cash balances were
+# downloaded longer ago than this. Pass --max-age to fail instead.
+# snapshot_max_age: 24h
+# Alert rules checked by "ibctl holding value".
+#
+# Optional. Each triggered rule prints a WARN line and makes the command exit
+# with code 2, for scripted monitoring. Types:
+#   allocation - a classification value (category, type, sector, or geo) above max_pct of net liq
+#   position   - any single non-cash position above max_pct of net liq
+#   stcg       - total unrealized short-term capital gains above max_usd
+# alerts:
+#   - name: tech-concentration
+#     type: allocation
+#     classification: sector
+#     value: TECH
+#     max_pct: 40
+#   - name: single-position
+#     type: position
+#     max_pct: 20
+#   - name: stcg
+#     type: stcg
+#     max_usd: "50000"
+# Remote backup configuration for "ibctl data backup".
+#
+# Optional. Archives are encrypted with the key in the IBCTL_ENCRYPTION_KEY
+# environment variable before upload. Types are s3, gcs, and local.
+# S3 credentials are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.
+# GCS uses HMAC keys from GCS_HMAC_ACCESS_KEY_ID and GCS_HMAC_SECRET.
+# backup:
+#   retention: 7
+#   targets:
+#     - name: offsite
+#       type: s3
+#       bucket: my-bucket
+#       prefix: ibctl/
+#       region: us-east-1
+#     - name: nas
+#       type: local
+#       path: /Volumes/nas/ibctl
+# Ghostfolio instance for "ibctl export ghostfolio --push".
+#
+# Optional. The security token is read from the GHOSTFOLIO_ACCESS_TOKEN
+# environment variable. accounts maps account aliases to Ghostfolio account
+# IDs; activities of unmapped aliases go to the default Ghostfolio account.
+# ghostfolio:
+#   url: https://ghostfol.io
+#   accounts:
+#     individual: 00000000-0000-0000-0000-000000000000
+# HTTP settings for all API clients (IBKR, exchange rates, backups, Ghostfolio).
+#
+# Optional. Without proxy_url, the HTTPS_PROXY, HTTP_PROXY, and NO_PROXY
+# environment variables are used. ca_bundle is a PEM file of CA certificates
+# trusted in addition to the system roots, for networks that intercept TLS.
+# insecure_skip_verify disables certificate verification, for debugging only.
+# The IBCTL_PROXY_URL, IBCTL_CA_BUNDLE, and IBCTL_INSECURE_SKIP_VERIFY
+# environment variables override these settings.
+# http:
+#   proxy_url: http://proxy.example.com:3128
+#   ca_bundle: /etc/ssl/corp-ca.pem
+# Git history of the data directory.
+#
+# Optional. With auto_commit, data/ (without data/backups/) is committed to git
+# after every successful download, initializing a repository in the ibctl
+# directory if it is not already in one. "ibctl data log" shows the history.
+# commit_message can use the placeholders {accounts}, {new_trades}, {trades},
+# {updated_positions}, and {positions}.
+# git:
+#   auto_commit: true
+#   commit_message: "ibctl download: {new_trades} new trades, {updated_positions} updated positions"
+# Notifications about downloads.
+#
+# Optional. When a download finds positions that were opened, closed, or
+# changed quantity since the previous download, command is run with a report
+# of the changes on stdin, so that unexpected activity is noticed. The command
+# is run with "sh -c", and a failing command does not fail the download.
+# notify:
+#   command: mail -s "ibctl position changes" me@example.com
 `
 
+// DefaultTaxPriorYearPct is the default percentage of the prior-year tax
+// required for the safe harbor on estimated payments.
+const DefaultTaxPriorYearPct = 100
+
+// DefaultBackupRetention is the default number of remote backup archives kept per target.
+const DefaultBackupRetention = 7
+
+// DefaultSnapshotMaxAge is the default age after which holdings commands warn
+// about a stale position snapshot.
+const DefaultSnapshotMaxAge = 72 * time.Hour
+
+// Alert types.
+const (
+	// AlertTypeAllocation triggers when a classification value exceeds a percentage of net liq.
+	AlertTypeAllocation = "allocation"
+	// AlertTypePosition triggers when any single non-cash position exceeds a percentage of net liq.
+	AlertTypePosition = "position"
+	// AlertTypeSTCG triggers when total unrealized short-term capital gains exceed a USD amount.
+	AlertTypeSTCG = "stcg"
+)
+
+// Account types.
+const (
+	// AccountTypeTaxable is an account whose gains are taxed when realized.
+	AccountTypeTaxable = "taxable"
+	// AccountTypeDeferred is a tax-deferred account (e.g., RRSP, traditional IRA),
+	// taxed as income on withdrawal rather than as capital gains.
+	AccountTypeDeferred = "deferred"
+	// AccountTypeExempt is a tax-exempt account (e.g., TFSA, Roth IRA).
+	AccountTypeExempt = "exempt"
+)
+
+// AccountTypes is the list of all account types, in display order.
+var AccountTypes = []string{AccountTypeTaxable, AccountTypeDeferred, AccountTypeExempt}
+
+// Cost basis methods.
+const (
+	// CostBasisFIFO assigns each lot the price it was bought at, and sells
+	// consume the oldest lots first.
+	CostBasisFIFO = "fifo"
+	// CostBasisAverage assigns every open lot the average cost of the position,
+	// as allowed for mutual funds. Sells still consume the oldest lots first,
+	// so acquisition dates are kept for the short-term and long-term split.
+	CostBasisAverage = "average"
+)
+
+// CostBasisMethods is the list of all cost basis methods.
+var CostBasisMethods = []string{CostBasisFIFO, CostBasisAverage}
+
+// Backup target types.
+const (
+	// BackupTargetTypeS3 is an Amazon S3 (or S3-compatible) bucket.
+	BackupTargetTypeS3 = "s3"
+	// BackupTargetTypeGCS is a Google Cloud Storage bucket accessed with HMAC keys.
+	BackupTargetTypeGCS = "gcs"
+	// BackupTargetTypeLocal is a local directory, such as a mounted or synced drive.
+	BackupTargetTypeLocal = "local"
+)
+
 // ExternalConfigV1 is the YAML-serializable configuration file structure for version v1.
 type ExternalConfigV1 struct {
 	// Version is the configuration file version (must be "v1").
 	Version string `yaml:"version"`
 	// FlexQueryID is the Flex Query ID.
 	FlexQueryID string `yaml:"flex_query_id"`
+	// FlexQueryPeriod is the optional period preset that overrides the Flex Query's configured period.
+	FlexQueryPeriod string `yaml:"flex_query_period"`
+	// Logins is the optional list of additional IBKR logins with their own token and Flex Query.
+	Logins []ExternalLoginConfigV1 `yaml:"logins"`
 	// Accounts maps user-chosen aliases to IBKR account IDs.
 	Accounts map[string]string `yaml:"accounts"`
+	// AccountTypes maps account aliases to account types ("taxable", "deferred", or "exempt").
+	AccountTypes map[string]string `yaml:"account_types"`
+	// Groups maps group names to lists of account aliases.
+	Groups map[string][]string `yaml:"groups"`
 	// Symbols is the optional list of symbol classifications.
 	Symbols []ExternalSymbolConfigV1 `yaml:"symbols"`
+	// IgnoreSymbols is the optional list of symbols excluded from holdings,
+	// lot lists, and position verification.
+	IgnoreSymbols []string `yaml:"ignore_symbols"`
+	// Lookthrough maps symbols to their sector and geo look-through weights.
+	Lookthrough map[string]ExternalLookthroughConfigV1 `yaml:"lookthrough"`
+	// Benchmarks maps benchmark names to their composition.
+	Benchmarks map[string]ExternalBenchmarkConfigV1 `yaml:"benchmarks"`
 	// Adjustments maps currency codes to manual cash adjustments (positive or negative).
 	// Applied to cash positions in the holdings display.
 	Adjustments map[string]string `yaml:"adjustments"`
 	// Taxes configures capital gains tax rates for portfolio value computation.
 	Taxes *ExternalTaxConfigV1 `yaml:"taxes"`
+	// ArchiveRaw enables saving the raw Flex Query XML on every download.
+	ArchiveRaw bool `yaml:"archive_raw"`
+	// Strict fails downloads on Flex Query records that cannot be converted.
+	Strict bool `yaml:"strict"`
+	// ReadOnly prevents writes to the ibctl directory.
+	ReadOnly bool `yaml:"readonly"`
+	// Encrypt enables at-rest encryption of files under data/ and cache/.
+	Encrypt bool `yaml:"encrypt"`
+	// FileMode is the optional octal permissions (e.g., "0640") of written files.
+	FileMode string `yaml:"file_mode"`
+	// DirMode is the optional octal permissions (e.g., "0750") of created directories.
+	DirMode string `yaml:"dir_mode"`
+	// Redact enables redaction of account identifiers in exports by default.
+	Redact bool `yaml:"redact"`
+	// SnapshotMaxAge is the optional age (e.g., "24h") after which holdings commands warn about a stale position snapshot.
+	SnapshotMaxAge string `yaml:"snapshot_max_age"`
+	// Alerts is the optional list of alert rules checked by holding value.
+	Alerts []ExternalAlertConfigV1 `yaml:"alerts"`
+	// Backup configures remote backup targets.
+	Backup *ExternalBackupConfigV1 `yaml:"backup"`
+	// Ghostfolio configures the Ghostfolio instance to push activities to.
+	Ghostfolio *ExternalGhostfolioConfigV1 `yaml:"ghostfolio"`
+	// HTTP configures the HTTP client of all API clients.
+	HTTP *ExternalHTTPConfigV1 `yaml:"http"`
+	// Git configures the git history of the data directory.
+	Git *ExternalGitConfigV1 `yaml:"git"`
+	// Notify configures notifications about downloads.
+	Notify *ExternalNotifyConfigV1 `yaml:"notify"`
+}
+
+// ExternalLoginConfigV1 holds an additional IBKR login in v1 config.
+type ExternalLoginConfigV1 struct {
+	// FlexQueryID is the Flex Query ID of the login.
+	FlexQueryID string `yaml:"flex_query_id"`
+	// TokenEnv is the environment variable with the login's Flex Web Service token.
+	TokenEnv string `yaml:"token_env"`
+}
+
+// ExternalAlertConfigV1 holds a single alert rule in v1 config.
+type ExternalAlertConfigV1 struct {
+	// Name is the unique alert name.
+	Name string `yaml:"name"`
+	// Type is the alert type ("allocation", "position", or "stcg").
+	Type string `yaml:"type"`
+	// Classification is the classification for allocation alerts ("category", "type", "sector", or "geo").
+	Classification string `yaml:"classification"`
+	// Value is the classification value for allocation alerts (e.g., "TECH").
+	Value string `yaml:"value"`
+	// MaxPct is the maximum percentage of net liq for allocation and position alerts (e.g., 40 for 40%).
+	MaxPct float64 `yaml:"max_pct"`
+	// MaxUSD is the maximum USD amount for stcg alerts (e.g., "50000").
+	MaxUSD string `yaml:"max_usd"`
+}
+
+// ExternalBackupConfigV1 holds remote backup configuration.
+type ExternalBackupConfigV1 struct {
+	// Retention is the number of archives to keep per target. Defaults to 7.
+	Retention int `yaml:"retention"`
+	// Targets is the list of backup targets.
+	Targets []ExternalBackupTargetConfigV1 `yaml:"targets"`
+}
+
+// ExternalHTTPConfigV1 holds HTTP client configuration.
+type ExternalHTTPConfigV1 struct {
+	// ProxyURL is the URL of the proxy for all requests (e.g., "http://proxy.example.com:3128").
+	ProxyURL string `yaml:"proxy_url"`
+	// CABundle is the path of a PEM file of additional CA certificates.
+	CABundle string `yaml:"ca_bundle"`
+	// InsecureSkipVerify disables TLS certificate verification.
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+}
+
+// ExternalGitConfigV1 holds the configuration of the git history of the data directory.
+type ExternalGitConfigV1 struct {
+	// AutoCommit commits data/ to git after every successful download.
+	AutoCommit bool `yaml:"auto_commit"`
+	// CommitMessage is the commit message template. Defaults to ibctlgit.DefaultCommitMessage.
+	CommitMessage string `yaml:"commit_message"`
+}
+
+// ExternalNotifyConfigV1 holds notification configuration in v1 config.
+type ExternalNotifyConfigV1 struct {
+	// Command is the shell command run with the position change report on stdin.
+	Command string `yaml:"command"`
+}
+
+// ExternalGhostfolioConfigV1 holds Ghostfolio configuration.
+type ExternalGhostfolioConfigV1 struct {
+	// URL is the base URL of the Ghostfolio instance (e.g., "https://ghostfol.io").
+	URL string `yaml:"url"`
+	// Accounts maps account aliases to Ghostfolio account IDs.
+	Accounts map[string]string `yaml:"accounts"`
+}
+
+// ExternalBackupTargetConfigV1 holds a single remote backup target in v1 config.
+type ExternalBackupTargetConfigV1 struct {
+	// Name is the unique target name.
+	Name string `yaml:"name"`
+	// Type is the target type ("s3", "gcs", or "local").
+	Type string `yaml:"type"`
+	// Bucket is the bucket name for s3 and gcs targets.
+	Bucket string `yaml:"bucket"`
+	// Prefix is the optional object key prefix for s3 and gcs targets.
+	Prefix string `yaml:"prefix"`
+	// Region is the bucket region for s3 targets.
+	Region string `yaml:"region"`
+	// Endpoint optionally overrides the endpoint URL for S3-compatible storage.
+	Endpoint string `yaml:"endpoint"`
+	// Path is the directory path for local targets.
+	Path string `yaml:"path"`
 }
 
 // ExternalTaxConfigV1 holds capital gains tax rate configuration.
@@ -76,7 +529,27 @@ type ExternalTaxConfigV1 struct {
 	// STCG is the short-term capital gains tax rate (e.g., 0.408 for 40.8%).
 	STCG float64 `yaml:"stcg"`
 	// LTCG is the long-term capital gains tax rate (e.g., 0.28 for 28%).
-	LTCG float64 `yaml:"ltcg"`
+	// Defaults to the jurisdiction's default long-term rate.
+	LTCG *float64 `yaml:"ltcg"`
+	// Income is the tax rate for dividends and interest. Defaults to the STCG rate.
+	Income *float64 `yaml:"income"`
+	// BaseCurrency is the currency tax is paid in (e.g., "CAD"). Defaults to USD.
+	BaseCurrency string `yaml:"base_currency"`
+	// Jurisdiction is the tax jurisdiction whose holding-period and rate rules
+	// apply ("us", "ca", or "au"). Defaults to "us".
+	Jurisdiction string `yaml:"jurisdiction"`
+	// LongTermDays overrides the jurisdiction's holding period, so lots are
+	// long-term once held this many days.
+	LongTermDays int `yaml:"long_term_days"`
+	// PriorYearTax is the total tax for the prior year in USD (e.g., "42000"), used
+	// for safe-harbor estimated payments.
+	PriorYearTax string `yaml:"prior_year_tax"`
+	// PriorYearPct is the percentage of PriorYearTax that must be paid for the
+	// prior-year safe harbor (e.g., 110 for high incomes). Defaults to 100.
+	PriorYearPct float64 `yaml:"prior_year_pct"`
+	// TreatyRates maps ISO 3166-1 alpha-2 source country codes to the treaty
+	// withholding tax rate on dividends (e.g., 0.15 for 15%).
+	TreatyRates map[string]float64 `yaml:"treaty_rates"`
 }
 
 // ExternalSymbolConfigV1 holds classification metadata for a symbol in v1 config.
@@ -91,6 +564,36 @@ type ExternalSymbolConfigV1 struct {
 	Sector string `yaml:"sector"`
 	// Geo is the geographic classification (e.g., "US", "INTL").
 	Geo string `yaml:"geo"`
+	// Currencies is the optional currency look-through, mapping currency codes
+	// to the percentage of the holding's value exposed to each currency.
+	Currencies map[string]float64 `yaml:"currencies"`
+	// CostBasis is the cost basis method ("fifo" or "average"). Defaults to "fifo".
+	CostBasis string `yaml:"cost_basis"`
+	// Margin is the optional initial margin per contract of a futures or CFD
+	// symbol, as a decimal in the symbol's trading currency.
+	Margin string `yaml:"margin"`
+	// QuoteSymbol is the optional Yahoo Finance symbol of the symbol (e.g., "SHOP.TO").
+	QuoteSymbol string `yaml:"quote_symbol"`
+}
+
+// ExternalLookthroughConfigV1 holds the look-through weights for a symbol in v1 config.
+type ExternalLookthroughConfigV1 struct {
+	// Sector maps sectors to the percentage of the symbol's value in each (e.g., TECH: 25).
+	Sector map[string]float64 `yaml:"sector"`
+	// Geo maps geographies to the percentage of the symbol's value in each (e.g., US: 62).
+	Geo map[string]float64 `yaml:"geo"`
+}
+
+// ExternalBenchmarkConfigV1 holds the composition of a benchmark in v1 config.
+type ExternalBenchmarkConfigV1 struct {
+	// Category maps categories to the percentage of the benchmark in each (e.g., EQUITY: 60).
+	Category map[string]float64 `yaml:"category"`
+	// Type maps types to the percentage of the benchmark in each (e.g., STOCK: 100).
+	Type map[string]float64 `yaml:"type"`
+	// Sector maps sectors to the percentage of the benchmark in each (e.g., TECH: 32).
+	Sector map[string]float64 `yaml:"sector"`
+	// Geo maps geographies to the percentage of the benchmark in each (e.g., US: 100).
+	Geo map[string]float64 `yaml:"geo"`
 }
 
 // Config is the validated runtime configuration derived from the config file.
@@ -100,12 +603,30 @@ type Config struct {
 	DirPath string
 	// IBKRFlexQueryID is the Flex Query ID.
 	IBKRFlexQueryID string
+	// FlexQueryPeriod is the period preset that overrides the configured
+	// period of every login's Flex Query, or empty to use the configured period.
+	FlexQueryPeriod ibkrflexquery.Period
+	// Logins is every IBKR login to download from, starting with the login of
+	// IBKRFlexQueryID.
+	Logins []Login
 	// AccountAliases maps account aliases to IBKR account IDs (e.g., "rrsp" → "U1234567").
 	AccountAliases map[string]string
 	// AccountIDToAlias maps IBKR account IDs to aliases (e.g., "U1234567" → "rrsp").
 	AccountIDToAlias map[string]string
+	// AccountTypes maps every account alias to its account type (e.g., "rrsp" → "deferred").
+	// Aliases not configured in account_types are taxable.
+	AccountTypes map[string]string
+	// Groups maps group names to sorted lists of account aliases (e.g., "personal" → ["rrsp", "tfsa"]).
+	Groups map[string][]string
 	// SymbolConfigs maps ticker symbols to their classification metadata.
 	SymbolConfigs map[string]SymbolConfig
+	// IgnoreSymbols is the set of symbols excluded from holdings, lot lists,
+	// and position verification.
+	IgnoreSymbols map[string]struct{}
+	// Lookthroughs maps symbols to their sector and geo look-through weights.
+	Lookthroughs map[string]Lookthrough
+	// Benchmarks maps benchmark names to their composition.
+	Benchmarks map[string]Benchmark
 	// CashAdjustments maps currency codes to manual cash adjustments in micros.
 	// Applied to cash positions in the holdings display.
 	CashAdjustments map[string]int64
@@ -113,6 +634,131 @@ type Config struct {
 	TaxRateSTCG float64
 	// TaxRateLTCG is the long-term capital gains tax rate (e.g., 0.28).
 	TaxRateLTCG float64
+	// TaxRateIncome is the tax rate for dividends and interest (e.g., 0.408).
+	TaxRateIncome float64
+	// TaxBaseCurrency is the currency tax is paid in (e.g., "CAD").
+	TaxBaseCurrency string
+	// TaxRules is the holding-period and rate rules for the tax jurisdiction.
+	TaxRules *ibctltaxrules.Rules
+	// TaxPriorYearMicros is the total tax for the prior year in USD micros, or 0 if not configured.
+	TaxPriorYearMicros int64
+	// TaxPriorYearPct is the percentage of the prior-year tax required for the safe harbor (e.g., 100).
+	TaxPriorYearPct float64
+	// TaxTreatyRates maps source country codes to the treaty withholding tax rate on dividends (e.g., 0.15).
+	TaxTreatyRates map[string]float64
+	// ArchiveRaw is true if the raw Flex Query XML is saved on every download.
+	ArchiveRaw bool
+	// Strict is true if downloads fail on Flex Query records that cannot be converted,
+	// instead of skipping and quarantining them.
+	Strict bool
+	// ReadOnly is true if nothing under the ibctl directory is written.
+	ReadOnly bool
+	// Encrypt is true if files under data/ and cache/ are encrypted at rest.
+	Encrypt bool
+	// FileMode is the permissions of written files.
+	FileMode fs.FileMode
+	// DirMode is the permissions of created directories.
+	DirMode fs.FileMode
+	// Redact is true if exports redact account aliases and IBKR account IDs by default.
+	Redact bool
+	// SnapshotMaxAge is the age after which holdings commands warn about a stale position snapshot.
+	SnapshotMaxAge time.Duration
+	// Alerts is the list of alert rules checked by holding value.
+	Alerts []AlertConfig
+	// BackupRetention is the number of remote backup archives to keep per target.
+	BackupRetention int
+	// BackupTargets is the list of remote backup targets.
+	BackupTargets []BackupTargetConfig
+	// GhostfolioURL is the base URL of the Ghostfolio instance, or empty if not configured.
+	GhostfolioURL string
+	// GhostfolioAccountIDs maps account aliases to Ghostfolio account IDs.
+	GhostfolioAccountIDs map[string]string
+	// HTTP is the configuration of the HTTP client of all API clients.
+	HTTP httpclient.Config
+	// GitAutoCommit is true if data/ is committed to git after every successful download.
+	GitAutoCommit bool
+	// GitCommitMessage is the auto-commit message template with {name} placeholders.
+	GitCommitMessage string
+	// NotifyCommand is the shell command run with the position change report
+	// after a download, or empty if notifications are disabled.
+	NotifyCommand string
+	// PriceOverrides maps symbols to manually pinned prices from
+	// data/prices/overrides.yaml, used when IBKR reports no market price.
+	PriceOverrides map[string]PriceOverride
+}
+
+// PriceOverride is a manually pinned price for a symbol without a market
+// price, such as a delisted or halted security.
+type PriceOverride struct {
+	// PriceMicros is the price per unit in micros of the symbol's trading currency.
+	PriceMicros int64
+	// Date is the date the price is as of.
+	Date xtime.Date
+}
+
+// Lookthrough holds the validated look-through weights for a symbol. Weights
+// are fractions summing to 1, or nil if not configured for the classification.
+type Lookthrough struct {
+	// Sector maps sectors to the fraction of the symbol's value in each.
+	Sector map[string]float64
+	// Geo maps geographies to the fraction of the symbol's value in each.
+	Geo map[string]float64
+}
+
+// Benchmark holds the validated composition of a benchmark. Weights are
+// fractions summing to 1, or nil if not configured for the classification.
+type Benchmark struct {
+	// Category maps categories to the fraction of the benchmark in each.
+	Category map[string]float64
+	// Type maps types to the fraction of the benchmark in each.
+	Type map[string]float64
+	// Sector maps sectors to the fraction of the benchmark in each.
+	Sector map[string]float64
+	// Geo maps geographies to the fraction of the benchmark in each.
+	Geo map[string]float64
+}
+
+// AlertConfig holds a validated alert rule.
+type AlertConfig struct {
+	// Name is the unique alert name.
+	Name string
+	// Type is the alert type (one of the AlertType constants).
+	Type string
+	// Classification is the classification for allocation alerts ("category", "type", "sector", or "geo").
+	Classification string
+	// Value is the classification value for allocation alerts.
+	Value string
+	// MaxPct is the maximum percentage of net liq for allocation and position alerts.
+	MaxPct float64
+	// MaxUSDMicros is the maximum USD amount in micros for stcg alerts.
+	MaxUSDMicros int64
+}
+
+// Login holds a validated IBKR login.
+type Login struct {
+	// FlexQueryID is the Flex Query ID.
+	FlexQueryID string
+	// TokenEnvVar is the environment variable with the Flex Web Service token,
+	// or empty for the IBKR_FLEX_WEB_SERVICE_TOKEN environment variable.
+	TokenEnvVar string
+}
+
+// BackupTargetConfig holds a validated remote backup target.
+type BackupTargetConfig struct {
+	// Name is the unique target name.
+	Name string
+	// Type is the target type (one of the BackupTargetType constants).
+	Type string
+	// Bucket is the bucket name for s3 and gcs targets.
+	Bucket string
+	// Prefix is the object key prefix for s3 and gcs targets.
+	Prefix string
+	// Region is the bucket region for s3 targets.
+	Region string
+	// Endpoint is the endpoint URL override for s3 targets.
+	Endpoint string
+	// Path is the directory path for local targets.
+	Path string
 }
 
 // SymbolConfig holds classification metadata for a symbol.
@@ -125,6 +771,16 @@ type SymbolConfig struct {
 	Sector string
 	// Geo is the geographic classification (e.g., "US", "INTL").
 	Geo string
+	// Currencies maps currency codes to the fraction of the holding's value
+	// exposed to each currency (summing to 1), or is nil to use the trading currency.
+	Currencies map[string]float64
+	// CostBasis is the cost basis method ("fifo" or "average").
+	CostBasis string
+	// MarginMicros is the initial margin per contract in micros of the
+	// symbol's trading currency, or 0 if not configured.
+	MarginMicros int64
+	// QuoteSymbol is the Yahoo Finance symbol of the symbol, or empty if not configured.
+	QuoteSymbol string
 }
 
 // NewConfigV1 validates an ExternalConfigV1 and returns a runtime Config.
@@ -136,6 +792,46 @@ func NewConfigV1(externalConfig ExternalConfigV1, dirPath string) (*Config, erro
 	if externalConfig.FlexQueryID == "" {
 		return nil, errors.New("flex_query_id is required")
 	}
+	flexQueryPeriod, err := ibkrflexquery.ParsePeriod(externalConfig.FlexQueryPeriod)
+	if err != nil {
+		return nil, fmt.Errorf("flex_query_period: %w", err)
+	}
+	snapshotMaxAge := DefaultSnapshotMaxAge
+	if externalConfig.SnapshotMaxAge != "" {
+		snapshotMaxAge, err = time.ParseDuration(externalConfig.SnapshotMaxAge)
+		if err != nil {
+			return nil, fmt.Errorf("snapshot_max_age: %w", err)
+		}
+		if snapshotMaxAge <= 0 {
+			return nil, fmt.Errorf("snapshot_max_age must be positive, got %q", externalConfig.SnapshotMaxAge)
+		}
+	}
+	fileMode, err := parseMode("file_mode", externalConfig.FileMode, filemode.DefaultFileMode, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	dirMode, err := parseMode("dir_mode", externalConfig.DirMode, filemode.DefaultDirMode, 0o700)
+	if err != nil {
+		return nil, err
+	}
+	logins := []Login{{FlexQueryID: externalConfig.FlexQueryID}}
+	tokenEnvVars := make(map[string]struct{}, len(externalConfig.Logins))
+	for i, externalLogin := range externalConfig.Logins {
+		if externalLogin.FlexQueryID == "" {
+			return nil, fmt.Errorf("logins[%d]: flex_query_id is required", i)
+		}
+		if !validEnvVarPattern.MatchString(externalLogin.TokenEnv) {
+			return nil, fmt.Errorf("logins[%d]: token_env %q is invalid, must be an uppercase environment variable name", i, externalLogin.TokenEnv)
+		}
+		if externalLogin.TokenEnv == "IBKR_FLEX_WEB_SERVICE_TOKEN" {
+			return nil, fmt.Errorf("logins[%d]: token_env IBKR_FLEX_WEB_SERVICE_TOKEN is the token of flex_query_id", i)
+		}
+		if _, ok := tokenEnvVars[externalLogin.TokenEnv]; ok {
+			return nil, fmt.Errorf("logins[%d]: duplicate token_env %q", i, externalLogin.TokenEnv)
+		}
+		tokenEnvVars[externalLogin.TokenEnv] = struct{}{}
+		logins = append(logins, Login{FlexQueryID: externalLogin.FlexQueryID, TokenEnvVar: externalLogin.TokenEnv})
+	}
 	if len(externalConfig.Accounts) == 0 {
 		return nil, errors.New("accounts is required, must have at least one account alias mapping")
 	}
@@ -155,6 +851,25 @@ func NewConfigV1(externalConfig ExternalConfigV1, dirPath string) (*Config, erro
 		accountAliases[alias] = accountID
 		accountIDToAlias[accountID] = alias
 	}
+	// Resolve account types, defaulting to taxable.
+	accountTypes := make(map[string]string, len(accountAliases))
+	for alias := range accountAliases {
+		accountTypes[alias] = AccountTypeTaxable
+	}
+	for alias, accountType := range externalConfig.AccountTypes {
+		if _, ok := accountAliases[alias]; !ok {
+			return nil, fmt.Errorf("account_types references unknown account alias %q", alias)
+		}
+		if !slices.Contains(AccountTypes, accountType) {
+			return nil, fmt.Errorf("account type %q for alias %q is invalid, must be taxable, deferred, or exempt", accountType, alias)
+		}
+		accountTypes[alias] = accountType
+	}
+	// Validate account groups against the account aliases.
+	groups, err := newGroups(externalConfig.Groups, accountAliases)
+	if err != nil {
+		return nil, err
+	}
 	// Build symbol configs map, checking for duplicates.
 	symbolConfigs := make(map[string]SymbolConfig, len(externalConfig.Symbols))
 	for _, s := range externalConfig.Symbols {
@@ -164,13 +879,94 @@ func NewConfigV1(externalConfig ExternalConfigV1, dirPath string) (*Config, erro
 		if _, ok := symbolConfigs[s.Name]; ok {
 			return nil, fmt.Errorf("duplicate symbol name %q", s.Name)
 		}
+		currencies, err := newSymbolCurrencies(s.Currencies)
+		if err != nil {
+			return nil, fmt.Errorf("invalid currencies for symbol %q: %w", s.Name, err)
+		}
+		costBasis := CostBasisFIFO
+		if s.CostBasis != "" {
+			if !slices.Contains(CostBasisMethods, s.CostBasis) {
+				return nil, fmt.Errorf("cost_basis %q for symbol %q is invalid, must be fifo or average", s.CostBasis, s.Name)
+			}
+			costBasis = s.CostBasis
+		}
+		var marginMicros int64
+		if s.Margin != "" {
+			units, micros, err := mathpb.ParseToUnitsMicros(s.Margin)
+			if err != nil {
+				return nil, fmt.Errorf("invalid margin for symbol %q: %w", s.Name, err)
+			}
+			marginMicros = units*1_000_000 + micros
+			if marginMicros <= 0 {
+				return nil, fmt.Errorf("margin %q for symbol %q must be positive", s.Margin, s.Name)
+			}
+		}
 		symbolConfigs[s.Name] = SymbolConfig{
-			Category: s.Category,
-			Type:     s.Type,
-			Sector:   s.Sector,
-			Geo:      s.Geo,
+			Category:     s.Category,
+			Type:         s.Type,
+			Sector:       s.Sector,
+			Geo:          s.Geo,
+			Currencies:   currencies,
+			CostBasis:    costBasis,
+			MarginMicros: marginMicros,
+			QuoteSymbol:  s.QuoteSymbol,
 		}
 	}
+	// Validate look-through weights.
+	lookthroughs := make(map[string]Lookthrough, len(externalConfig.Lookthrough))
+	for symbol, externalLookthrough := range externalConfig.Lookthrough {
+		if symbol == "" {
+			return nil, errors.New("lookthrough symbol is required")
+		}
+		sector, err := newWeights(externalLookthrough.Sector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid lookthrough sector weights for symbol %q: %w", symbol, err)
+		}
+		geo, err := newWeights(externalLookthrough.Geo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid lookthrough geo weights for symbol %q: %w", symbol, err)
+		}
+		lookthroughs[symbol] = Lookthrough{Sector: sector, Geo: geo}
+	}
+	// Validate benchmark compositions.
+	benchmarks := make(map[string]Benchmark, len(externalConfig.Benchmarks))
+	for name, externalBenchmark := range externalConfig.Benchmarks {
+		if name == "" {
+			return nil, errors.New("benchmark name is required")
+		}
+		var benchmark Benchmark
+		for _, classification := range []struct {
+			name    string
+			pcts    map[string]float64
+			weights *map[string]float64
+		}{
+			{name: "category", pcts: externalBenchmark.Category, weights: &benchmark.Category},
+			{name: "type", pcts: externalBenchmark.Type, weights: &benchmark.Type},
+			{name: "sector", pcts: externalBenchmark.Sector, weights: &benchmark.Sector},
+			{name: "geo", pcts: externalBenchmark.Geo, weights: &benchmark.Geo},
+		} {
+			weights, err := newWeights(classification.pcts)
+			if err != nil {
+				return nil, fmt.Errorf("invalid benchmark %s weights for %q: %w", classification.name, name, err)
+			}
+			*classification.weights = weights
+		}
+		if benchmark.Category == nil && benchmark.Type == nil && benchmark.Sector == nil && benchmark.Geo == nil {
+			return nil, fmt.Errorf("benchmark %q must have category, type, sector, or geo weights", name)
+		}
+		benchmarks[name] = benchmark
+	}
+	// Validate the ignored symbols.
+	ignoreSymbols := make(map[string]struct{}, len(externalConfig.IgnoreSymbols))
+	for _, symbol := range externalConfig.IgnoreSymbols {
+		if symbol == "" {
+			return nil, errors.New("ignore_symbols: symbol is empty")
+		}
+		if _, ok := ignoreSymbols[symbol]; ok {
+			return nil, fmt.Errorf("ignore_symbols: duplicate symbol %q", symbol)
+		}
+		ignoreSymbols[symbol] = struct{}{}
+	}
 	// Parse cash adjustments, validating currency codes and decimal values.
 	cashAdjustments := make(map[string]int64, len(externalConfig.Adjustments))
 	for currency, value := range externalConfig.Adjustments {
@@ -181,23 +977,300 @@ func NewConfigV1(externalConfig ExternalConfigV1, dirPath string) (*Config, erro
 		cashAdjustments[currency] = units*1_000_000 + micros
 	}
 	// Extract tax rates if configured.
-	var taxRateSTCG, taxRateLTCG float64
+	var taxRateSTCG, taxRateLTCG, taxRateIncome float64
+	taxBaseCurrency := "USD"
+	var taxPriorYearMicros int64
+	taxPriorYearPct := float64(DefaultTaxPriorYearPct)
+	var taxTreatyRates map[string]float64
+	var taxJurisdiction ibctltaxrules.Jurisdiction
+	var taxLongTermDays int
+	if externalConfig.Taxes != nil {
+		taxJurisdiction = ibctltaxrules.Jurisdiction(externalConfig.Taxes.Jurisdiction)
+		taxLongTermDays = externalConfig.Taxes.LongTermDays
+	}
+	taxRules, err := ibctltaxrules.NewRules(taxJurisdiction, taxLongTermDays)
+	if err != nil {
+		return nil, fmt.Errorf("invalid taxes: %w", err)
+	}
 	if externalConfig.Taxes != nil {
 		taxRateSTCG = externalConfig.Taxes.STCG
-		taxRateLTCG = externalConfig.Taxes.LTCG
+		taxRateLTCG = taxRules.DefaultLTCGRate(taxRateSTCG)
+		if externalConfig.Taxes.LTCG != nil {
+			if !taxRules.HasLongTerm() {
+				return nil, fmt.Errorf("taxes ltcg cannot be set for jurisdiction %q, which has no long-term capital gains", taxRules.Jurisdiction())
+			}
+			taxRateLTCG = *externalConfig.Taxes.LTCG
+		}
+		// Dividends and interest are typically taxed as ordinary income, like STCG.
+		taxRateIncome = taxRateSTCG
+		if externalConfig.Taxes.Income != nil {
+			taxRateIncome = *externalConfig.Taxes.Income
+		}
+		if externalConfig.Taxes.BaseCurrency != "" {
+			if !validCurrencyCodePattern.MatchString(externalConfig.Taxes.BaseCurrency) {
+				return nil, fmt.Errorf("taxes base_currency %q is invalid, must be a three-letter currency code", externalConfig.Taxes.BaseCurrency)
+			}
+			taxBaseCurrency = externalConfig.Taxes.BaseCurrency
+		}
+		if externalConfig.Taxes.PriorYearTax != "" {
+			units, micros, err := mathpb.ParseToUnitsMicros(externalConfig.Taxes.PriorYearTax)
+			if err != nil {
+				return nil, fmt.Errorf("invalid taxes prior_year_tax: %w", err)
+			}
+			taxPriorYearMicros = units*1_000_000 + micros
+		}
+		if externalConfig.Taxes.PriorYearPct < 0 {
+			return nil, errors.New("taxes prior_year_pct must not be negative")
+		}
+		if externalConfig.Taxes.PriorYearPct > 0 {
+			taxPriorYearPct = externalConfig.Taxes.PriorYearPct
+		}
+		for countryCode, rate := range externalConfig.Taxes.TreatyRates {
+			if !validCountryCodePattern.MatchString(countryCode) {
+				return nil, fmt.Errorf("taxes treaty_rates country code %q is invalid, must be a two-letter ISO 3166-1 code", countryCode)
+			}
+			if rate < 0 || rate > 1 {
+				return nil, fmt.Errorf("taxes treaty_rates rate for %s must be between 0 and 1, got %v", countryCode, rate)
+			}
+		}
+		taxTreatyRates = externalConfig.Taxes.TreatyRates
+	}
+	// Validate alert rules.
+	alerts, err := newAlertConfigs(externalConfig.Alerts)
+	if err != nil {
+		return nil, err
+	}
+	// Validate backup targets.
+	backupRetention := DefaultBackupRetention
+	var backupTargets []BackupTargetConfig
+	if externalConfig.Backup != nil {
+		if externalConfig.Backup.Retention < 0 {
+			return nil, errors.New("backup retention must not be negative")
+		}
+		if externalConfig.Backup.Retention > 0 {
+			backupRetention = externalConfig.Backup.Retention
+		}
+		backupTargetNames := make(map[string]struct{}, len(externalConfig.Backup.Targets))
+		for _, target := range externalConfig.Backup.Targets {
+			if target.Name == "" {
+				return nil, errors.New("backup target name is required")
+			}
+			if _, ok := backupTargetNames[target.Name]; ok {
+				return nil, fmt.Errorf("duplicate backup target name %q", target.Name)
+			}
+			backupTargetNames[target.Name] = struct{}{}
+			switch target.Type {
+			case BackupTargetTypeS3:
+				if target.Bucket == "" || target.Region == "" {
+					return nil, fmt.Errorf("backup target %q: bucket and region are required for s3", target.Name)
+				}
+			case BackupTargetTypeGCS:
+				if target.Bucket == "" {
+					return nil, fmt.Errorf("backup target %q: bucket is required for gcs", target.Name)
+				}
+			case BackupTargetTypeLocal:
+				if target.Path == "" {
+					return nil, fmt.Errorf("backup target %q: path is required for local", target.Name)
+				}
+			default:
+				return nil, fmt.Errorf("backup target %q: unknown type %q, must be s3, gcs, or local", target.Name, target.Type)
+			}
+			backupTargets = append(backupTargets, BackupTargetConfig(target))
+		}
+	}
+	// Validate the Ghostfolio instance.
+	var ghostfolioURL string
+	ghostfolioAccountIDs := make(map[string]string)
+	if externalConfig.Ghostfolio != nil {
+		parsedURL, err := url.Parse(externalConfig.Ghostfolio.URL)
+		if err != nil || (parsedURL.Scheme != "https" && parsedURL.Scheme != "http") || parsedURL.Host == "" {
+			return nil, fmt.Errorf("ghostfolio url %q is invalid, must be an http or https URL", externalConfig.Ghostfolio.URL)
+		}
+		ghostfolioURL = externalConfig.Ghostfolio.URL
+		for alias, accountID := range externalConfig.Ghostfolio.Accounts {
+			if _, ok := accountAliases[alias]; !ok {
+				return nil, fmt.Errorf("ghostfolio accounts references unknown account alias %q", alias)
+			}
+			if accountID == "" {
+				return nil, fmt.Errorf("ghostfolio account ID for alias %q is required", alias)
+			}
+			ghostfolioAccountIDs[alias] = accountID
+		}
+	}
+	// Validate the HTTP settings. A relative CA bundle path is relative to the base directory.
+	var httpConfig httpclient.Config
+	if externalConfig.HTTP != nil {
+		if externalConfig.HTTP.ProxyURL != "" {
+			if _, err := httpclient.ParseProxyURL(externalConfig.HTTP.ProxyURL); err != nil {
+				return nil, fmt.Errorf("http: %w", err)
+			}
+		}
+		caBundlePath := externalConfig.HTTP.CABundle
+		if caBundlePath != "" && !filepath.IsAbs(caBundlePath) {
+			caBundlePath = filepath.Join(dirPath, caBundlePath)
+		}
+		httpConfig = httpclient.Config{
+			ProxyURL:           externalConfig.HTTP.ProxyURL,
+			CABundlePath:       caBundlePath,
+			InsecureSkipVerify: externalConfig.HTTP.InsecureSkipVerify,
+		}
+	}
+	// Validate the git settings.
+	gitCommitMessage := ibctlgit.DefaultCommitMessage
+	var gitAutoCommit bool
+	if externalConfig.Git != nil {
+		gitAutoCommit = externalConfig.Git.AutoCommit
+		if externalConfig.Git.CommitMessage != "" {
+			if strings.TrimSpace(externalConfig.Git.CommitMessage) == "" {
+				return nil, errors.New("git commit_message must not be blank")
+			}
+			gitCommitMessage = externalConfig.Git.CommitMessage
+		}
+	}
+	// Validate the notify settings.
+	var notifyCommand string
+	if externalConfig.Notify != nil {
+		notifyCommand = strings.TrimSpace(externalConfig.Notify.Command)
+		if notifyCommand == "" {
+			return nil, errors.New("notify command must be set")
+		}
 	}
 	return &Config{
-		DirPath:          dirPath,
-		IBKRFlexQueryID:  externalConfig.FlexQueryID,
-		AccountAliases:   accountAliases,
-		AccountIDToAlias: accountIDToAlias,
-		SymbolConfigs:    symbolConfigs,
-		CashAdjustments:  cashAdjustments,
-		TaxRateSTCG:      taxRateSTCG,
-		TaxRateLTCG:      taxRateLTCG,
+		DirPath:              dirPath,
+		IBKRFlexQueryID:      externalConfig.FlexQueryID,
+		FlexQueryPeriod:      flexQueryPeriod,
+		Logins:               logins,
+		AccountAliases:       accountAliases,
+		AccountIDToAlias:     accountIDToAlias,
+		AccountTypes:         accountTypes,
+		Groups:               groups,
+		SymbolConfigs:        symbolConfigs,
+		IgnoreSymbols:        ignoreSymbols,
+		Lookthroughs:         lookthroughs,
+		Benchmarks:           benchmarks,
+		CashAdjustments:      cashAdjustments,
+		TaxRateSTCG:          taxRateSTCG,
+		TaxRateLTCG:          taxRateLTCG,
+		TaxRateIncome:        taxRateIncome,
+		TaxBaseCurrency:      taxBaseCurrency,
+		TaxRules:             taxRules,
+		TaxPriorYearMicros:   taxPriorYearMicros,
+		TaxPriorYearPct:      taxPriorYearPct,
+		TaxTreatyRates:       taxTreatyRates,
+		ArchiveRaw:           externalConfig.ArchiveRaw,
+		Strict:               externalConfig.Strict,
+		ReadOnly:             externalConfig.ReadOnly,
+		Encrypt:              externalConfig.Encrypt,
+		FileMode:             fileMode,
+		DirMode:              dirMode,
+		Redact:               externalConfig.Redact,
+		SnapshotMaxAge:       snapshotMaxAge,
+		Alerts:               alerts,
+		BackupRetention:      backupRetention,
+		BackupTargets:        backupTargets,
+		GhostfolioURL:        ghostfolioURL,
+		GhostfolioAccountIDs: ghostfolioAccountIDs,
+		HTTP:                 httpConfig,
+		GitAutoCommit:        gitAutoCommit,
+		GitCommitMessage:     gitCommitMessage,
+		NotifyCommand:        notifyCommand,
 	}, nil
 }
 
+// parseMode parses an octal permissions value of the config key, returning
+// defaultMode if it is empty. The mode must include the required permissions,
+// so that ibctl can still read and write what it writes.
+func parseMode(key string, value string, defaultMode fs.FileMode, required fs.FileMode) (fs.FileMode, error) {
+	if value == "" {
+		return defaultMode, nil
+	}
+	mode, err := strconv.ParseUint(strings.TrimPrefix(value, "0o"), 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("%s %q is invalid, must be an octal mode such as %04o", key, value, defaultMode)
+	}
+	if fs.FileMode(mode)&required != required {
+		return 0, fmt.Errorf("%s %q must include %04o, so ibctl can access what it writes", key, value, required)
+	}
+	return fs.FileMode(mode), nil
+}
+
+// newSymbolCurrencies validates a symbol's currency look-through percentages
+// and converts them to fractions. Returns nil if no look-through is configured.
+func newSymbolCurrencies(externalCurrencies map[string]float64) (map[string]float64, error) {
+	for currencyCode := range externalCurrencies {
+		if !validCurrencyCodePattern.MatchString(currencyCode) {
+			return nil, fmt.Errorf("currency code %q is invalid, must be a three-letter ISO 4217 code", currencyCode)
+		}
+	}
+	return newWeights(externalCurrencies)
+}
+
+// newWeights validates percentages that must add up to 100 and converts them
+// to fractions. Returns nil if there are no percentages.
+func newWeights(pcts map[string]float64) (map[string]float64, error) {
+	if len(pcts) == 0 {
+		return nil, nil
+	}
+	weights := make(map[string]float64, len(pcts))
+	var totalPct float64
+	for key, pct := range pcts {
+		if key == "" {
+			return nil, errors.New("empty key")
+		}
+		if pct <= 0 || pct > 100 {
+			return nil, fmt.Errorf("percentage for %s must be greater than 0 and at most 100, got %v", key, pct)
+		}
+		weights[key] = pct / 100
+		totalPct += pct
+	}
+	if math.Abs(totalPct-100) > 0.01 {
+		return nil, fmt.Errorf("percentages must add up to 100, got %v", totalPct)
+	}
+	return weights, nil
+}
+
+// AverageCostSymbols returns the set of symbols using the average cost basis method.
+func (c *Config) AverageCostSymbols() map[string]struct{} {
+	averageCostSymbols := make(map[string]struct{})
+	for symbol, symbolConfig := range c.SymbolConfigs {
+		if symbolConfig.CostBasis == CostBasisAverage {
+			averageCostSymbols[symbol] = struct{}{}
+		}
+	}
+	return averageCostSymbols
+}
+
+// CacheTaxLotsDirPath returns the directory tax lot results are cached in,
+// or empty if DirPath is empty, in which case tax lots are not cached.
+func (c *Config) CacheTaxLotsDirPath() string {
+	if c.DirPath == "" {
+		return ""
+	}
+	return ibctlpath.CacheTaxLotsDirPath(c.DirPath)
+}
+
+// QuoteSymbol returns the Yahoo Finance symbol to fetch prices of the symbol
+// with: the configured quote_symbol, or else the symbol with spaces replaced
+// by dashes, as Yahoo Finance writes share classes (e.g., "BRK B" is "BRK-B").
+func (c *Config) QuoteSymbol(symbol string) string {
+	if quoteSymbol := c.SymbolConfigs[symbol].QuoteSymbol; quoteSymbol != "" {
+		return quoteSymbol
+	}
+	return strings.ReplaceAll(symbol, " ", "-")
+}
+
+// AccountAliasesForType returns the sorted account aliases with the account type.
+func (c *Config) AccountAliasesForType(accountType string) []string {
+	var accountAliases []string
+	for alias, aliasAccountType := range c.AccountTypes {
+		if aliasAccountType == accountType {
+			accountAliases = append(accountAliases, alias)
+		}
+	}
+	slices.Sort(accountAliases)
+	return accountAliases
+}
+
 // ReadConfig reads and validates the configuration file from the base directory.
 // The dirPath is the base directory containing ibctl.yaml.
 func ReadConfig(dirPath string) (*Config, error) {
@@ -222,9 +1295,92 @@ func ReadConfig(dirPath string) (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid configuration in %s: %w", configFilePath, err)
 	}
+	priceOverrides, err := ReadPriceOverrides(ibctlpath.DataPriceOverridesFilePath(absDirPath))
+	if err != nil {
+		return nil, err
+	}
+	config.PriceOverrides = priceOverrides
 	return config, nil
 }
 
+// ReadPriceOverrides reads the price overrides file, which maps symbols to a
+// manually pinned price in the symbol's trading currency and the date it is
+// as of:
+//
+//	XYZ:
+//	  price: 1.25
+//	  date: 2025-06-30
+//
+// A missing file has no overrides. The file is edited by hand, so it is
+// always plaintext, but a file sealed by an older version of ibctl is
+// decrypted if an encryption key is configured.
+func ReadPriceOverrides(filePath string) (map[string]PriceOverride, error) {
+	data, err := protoio.ReadFile(filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading price overrides: %w", err)
+	}
+	var externalPriceOverrides map[string]externalPriceOverride
+	if err := unmarshalYAMLStrict(data, &externalPriceOverrides); err != nil {
+		return nil, fmt.Errorf("parsing price overrides %s: %w", filePath, err)
+	}
+	priceOverrides := make(map[string]PriceOverride, len(externalPriceOverrides))
+	for symbol, externalPriceOverride := range externalPriceOverrides {
+		units, micros, err := mathpb.ParseToUnitsMicros(externalPriceOverride.Price)
+		if err != nil {
+			return nil, fmt.Errorf("invalid price override for %s in %s: %w", symbol, filePath, err)
+		}
+		priceMicros := units*1_000_000 + micros
+		if priceMicros <= 0 {
+			return nil, fmt.Errorf("invalid price override for %s in %s: price must be positive", symbol, filePath)
+		}
+		date, err := xtime.ParseDate(externalPriceOverride.Date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid price override for %s in %s: date must be YYYY-MM-DD: %w", symbol, filePath, err)
+		}
+		priceOverrides[symbol] = PriceOverride{PriceMicros: priceMicros, Date: date}
+	}
+	return priceOverrides, nil
+}
+
+// ReadWorkspaces reads the user-level workspaces file, which maps workspace
+// names to ibctl directories:
+//
+//	personal: ~/Documents/ibkr/personal
+//	holdco: /Volumes/holdco/ibkr
+//
+// Directories starting with ~/ are relative to homeDirPath, and other
+// directories must be absolute. A missing file has no workspaces.
+func ReadWorkspaces(filePath string, homeDirPath string) (map[string]string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading workspaces: %w", err)
+	}
+	var externalWorkspaces map[string]string
+	if err := unmarshalYAMLStrict(data, &externalWorkspaces); err != nil {
+		return nil, fmt.Errorf("parsing workspaces %s: %w", filePath, err)
+	}
+	workspaces := make(map[string]string, len(externalWorkspaces))
+	for name, dirPath := range externalWorkspaces {
+		if name == "" {
+			return nil, fmt.Errorf("invalid workspace in %s: name is empty", filePath)
+		}
+		if rest, ok := strings.CutPrefix(dirPath, "~/"); ok {
+			dirPath = filepath.Join(homeDirPath, rest)
+		}
+		if !filepath.IsAbs(dirPath) {
+			return nil, fmt.Errorf("invalid workspace %s in %s: directory %q must be absolute or start with ~/", name, filePath, dirPath)
+		}
+		workspaces[name] = filepath.Clean(dirPath)
+	}
+	return workspaces, nil
+}
+
 // InitConfig creates a new configuration file with a documented template in the base directory.
 // Returns an error if the file already exists.
 func InitConfig(dirPath string) error {
@@ -233,10 +1389,10 @@ func InitConfig(dirPath string) error {
 		return fmt.Errorf("configuration file already exists: %s", configFilePath)
 	}
 	// Create the directory if it doesn't exist.
-	if err := os.MkdirAll(dirPath, 0o755); err != nil {
+	if err := filemode.MkdirAll(dirPath); err != nil {
 		return fmt.Errorf("creating directory: %w", err)
 	}
-	return os.WriteFile(configFilePath, []byte(configTemplate), 0o644)
+	return filemode.WriteFile(configFilePath, []byte(configTemplate))
 }
 
 // ValidateConfig reads and validates the configuration file in the base directory.
@@ -245,8 +1401,214 @@ func ValidateConfig(dirPath string) error {
 	return err
 }
 
+// ListAccounts returns the account aliases mapped to IBKR account IDs from the
+// configuration file in the base directory.
+//
+// Only the accounts block is read, so this works even if the rest of the
+// configuration is incomplete (e.g., flex_query_id is not yet set).
+func ListAccounts(dirPath string) (map[string]string, error) {
+	data, err := os.ReadFile(ibctlpath.ConfigFilePath(dirPath))
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+	var externalConfig ExternalConfigV1
+	if err := unmarshalYAMLStrict(data, &externalConfig); err != nil {
+		return nil, fmt.Errorf("parsing config file: %w", err)
+	}
+	return externalConfig.Accounts, nil
+}
+
+// AddAccount adds an account alias mapping to the configuration file in the
+// base directory, preserving comments and the order of existing entries.
+//
+// Returns an error if the alias or account ID is invalid or already configured.
+func AddAccount(dirPath string, alias string, accountID string) error {
+	if !validAliasPattern.MatchString(alias) {
+		return fmt.Errorf("account alias %q is invalid, must be lowercase alphanumeric with hyphens", alias)
+	}
+	if !validAccountIDPattern.MatchString(accountID) {
+		return fmt.Errorf("account ID %q is invalid, must be an IBKR account number such as U1234567", accountID)
+	}
+	configFilePath := ibctlpath.ConfigFilePath(dirPath)
+	documentNode, err := readConfigNode(configFilePath)
+	if err != nil {
+		return err
+	}
+	accountsNode := getOrCreateValue(documentNode.Content[0], "accounts", yaml.MappingNode)
+	for i := 0; i+1 < len(accountsNode.Content); i += 2 {
+		existingAlias, existingAccountID := accountsNode.Content[i].Value, accountsNode.Content[i+1].Value
+		if existingAlias == alias {
+			return fmt.Errorf("account alias %q already exists", alias)
+		}
+		if existingAccountID == accountID {
+			return fmt.Errorf("account ID %q is already used by alias %q", accountID, existingAlias)
+		}
+	}
+	accountsNode.Content = append(
+		accountsNode.Content,
+		newStringNode(alias),
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: accountID, Style: yaml.DoubleQuotedStyle},
+	)
+	return writeConfigNode(configFilePath, documentNode)
+}
+
+// SetSymbol adds or updates the classification of a symbol in the symbols list
+// of the configuration file in the base directory, preserving comments.
+//
+// Empty fields in symbolConfig leave the existing values unchanged. Returns
+// true if a new symbol entry was added.
+func SetSymbol(dirPath string, name string, symbolConfig SymbolConfig) (bool, error) {
+	if name == "" {
+		return false, errors.New("symbol name is required")
+	}
+	configFilePath := ibctlpath.ConfigFilePath(dirPath)
+	documentNode, err := readConfigNode(configFilePath)
+	if err != nil {
+		return false, err
+	}
+	symbolsNode := getOrCreateValue(documentNode.Content[0], "symbols", yaml.SequenceNode)
+	var symbolNode *yaml.Node
+	for _, itemNode := range symbolsNode.Content {
+		if itemNode.Kind == yaml.MappingNode && getMappingString(itemNode, "name") == name {
+			symbolNode = itemNode
+			break
+		}
+	}
+	added := symbolNode == nil
+	if added {
+		symbolNode = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		setMappingString(symbolNode, "name", name)
+		symbolsNode.Content = append(symbolsNode.Content, symbolNode)
+	}
+	for _, field := range []struct {
+		key   string
+		value string
+	}{
+		{key: "category", value: symbolConfig.Category},
+		{key: "type", value: symbolConfig.Type},
+		{key: "sector", value: symbolConfig.Sector},
+		{key: "geo", value: symbolConfig.Geo},
+	} {
+		if field.value != "" {
+			setMappingString(symbolNode, field.key, field.value)
+		}
+	}
+	return added, writeConfigNode(configFilePath, documentNode)
+}
+
 // *** PRIVATE ***
 
+// externalPriceOverride is an entry of the price overrides file.
+type externalPriceOverride struct {
+	// Price is the price per unit in the symbol's trading currency.
+	Price string `yaml:"price"`
+	// Date is the date the price is as of (YYYY-MM-DD).
+	Date string `yaml:"date"`
+}
+
+// readConfigNode reads the configuration file as a YAML document node, which
+// preserves comments. The document's single child is the root mapping node.
+func readConfigNode(configFilePath string) (*yaml.Node, error) {
+	data, err := os.ReadFile(configFilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("configuration file not found at %s, run \"ibctl config init\" to create one", configFilePath)
+		}
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+	var documentNode yaml.Node
+	if err := yaml.Unmarshal(data, &documentNode); err != nil {
+		return nil, fmt.Errorf("parsing config file %s: %w", configFilePath, err)
+	}
+	if documentNode.Kind != yaml.DocumentNode || len(documentNode.Content) != 1 || documentNode.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config file %s must contain a YAML mapping", configFilePath)
+	}
+	return &documentNode, nil
+}
+
+// writeConfigNode atomically writes the YAML document node to the configuration file.
+// Comments after the last key are kept in the document node's foot comment.
+func writeConfigNode(configFilePath string, documentNode *yaml.Node) error {
+	var buffer bytes.Buffer
+	yamlEncoder := yaml.NewEncoder(&buffer)
+	yamlEncoder.SetIndent(2)
+	if err := yamlEncoder.Encode(documentNode); err != nil {
+		return fmt.Errorf("encoding config file: %w", err)
+	}
+	if err := yamlEncoder.Close(); err != nil {
+		return fmt.Errorf("encoding config file: %w", err)
+	}
+	if err := filemode.CheckWritable(configFilePath); err != nil {
+		return err
+	}
+	// Keep the permissions of the existing file, which the user may have set.
+	info, err := os.Stat(configFilePath)
+	if err != nil {
+		return err
+	}
+	tempFilePath := configFilePath + ".tmp"
+	if err := os.WriteFile(tempFilePath, buffer.Bytes(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err := os.Chmod(tempFilePath, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(tempFilePath, configFilePath)
+}
+
+// getOrCreateValue returns the value node for the key in the mapping node,
+// converting an empty value to the kind (a mapping or sequence) and appending
+// the key if it is missing.
+func getOrCreateValue(mappingNode *yaml.Node, key string, kind yaml.Kind) *yaml.Node {
+	tag := "!!map"
+	if kind == yaml.SequenceNode {
+		tag = "!!seq"
+	}
+	for i := 0; i+1 < len(mappingNode.Content); i += 2 {
+		if mappingNode.Content[i].Value != key {
+			continue
+		}
+		valueNode := mappingNode.Content[i+1]
+		// An empty value (e.g., "accounts:" with only comments) parses as a null scalar.
+		if valueNode.Kind == yaml.ScalarNode && valueNode.Tag == "!!null" {
+			valueNode.Kind = kind
+			valueNode.Tag = tag
+			valueNode.Value = ""
+		}
+		return valueNode
+	}
+	valueNode := &yaml.Node{Kind: kind, Tag: tag}
+	mappingNode.Content = append(mappingNode.Content, newStringNode(key), valueNode)
+	return valueNode
+}
+
+// setMappingString sets the string value for the key in the mapping node,
+// appending the key if it is missing.
+func setMappingString(mappingNode *yaml.Node, key string, value string) {
+	for i := 0; i+1 < len(mappingNode.Content); i += 2 {
+		if mappingNode.Content[i].Value == key {
+			mappingNode.Content[i+1] = newStringNode(value)
+			return
+		}
+	}
+	mappingNode.Content = append(mappingNode.Content, newStringNode(key), newStringNode(value))
+}
+
+// getMappingString returns the scalar value for the key in the mapping node, or an empty string.
+func getMappingString(mappingNode *yaml.Node, key string) string {
+	for i := 0; i+1 < len(mappingNode.Content); i += 2 {
+		if mappingNode.Content[i].Value == key {
+			return mappingNode.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+// newStringNode returns a plain string scalar node.
+func newStringNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
 // unmarshalYAMLStrict unmarshals the data as YAML with strict field checking.
 // If the data length is 0, this is a no-op.
 func unmarshalYAMLStrict(data []byte, v any) error {
@@ -261,3 +1623,78 @@ func unmarshalYAMLStrict(data []byte, v any) error {
 	}
 	return nil
 }
+
+// newGroups validates the account groups and returns them with sorted, deduplicated aliases.
+func newGroups(externalGroups map[string][]string, accountAliases map[string]string) (map[string][]string, error) {
+	groups := make(map[string][]string, len(externalGroups))
+	for name, aliases := range externalGroups {
+		if !validAliasPattern.MatchString(name) {
+			return nil, fmt.Errorf("group name %q is invalid, must be lowercase alphanumeric with hyphens", name)
+		}
+		if len(aliases) == 0 {
+			return nil, fmt.Errorf("group %q must have at least one account alias", name)
+		}
+		aliasSet := make(map[string]struct{}, len(aliases))
+		for _, alias := range aliases {
+			if _, ok := accountAliases[alias]; !ok {
+				return nil, fmt.Errorf("group %q references unknown account alias %q", name, alias)
+			}
+			aliasSet[alias] = struct{}{}
+		}
+		groups[name] = slices.Sorted(maps.Keys(aliasSet))
+	}
+	return groups, nil
+}
+
+// newAlertConfigs validates the alert rules.
+func newAlertConfigs(externalAlerts []ExternalAlertConfigV1) ([]AlertConfig, error) {
+	alerts := make([]AlertConfig, 0, len(externalAlerts))
+	alertNames := make(map[string]struct{}, len(externalAlerts))
+	for _, externalAlert := range externalAlerts {
+		if externalAlert.Name == "" {
+			return nil, errors.New("alert name is required")
+		}
+		if _, ok := alertNames[externalAlert.Name]; ok {
+			return nil, fmt.Errorf("duplicate alert name %q", externalAlert.Name)
+		}
+		alertNames[externalAlert.Name] = struct{}{}
+		alert := AlertConfig{
+			Name:           externalAlert.Name,
+			Type:           externalAlert.Type,
+			Classification: externalAlert.Classification,
+			Value:          externalAlert.Value,
+			MaxPct:         externalAlert.MaxPct,
+		}
+		switch externalAlert.Type {
+		case AlertTypeAllocation:
+			switch externalAlert.Classification {
+			case "category", "type", "sector", "geo":
+			default:
+				return nil, fmt.Errorf("alert %q: classification must be category, type, sector, or geo", externalAlert.Name)
+			}
+			if externalAlert.Value == "" {
+				return nil, fmt.Errorf("alert %q: value is required for allocation", externalAlert.Name)
+			}
+			if externalAlert.MaxPct <= 0 {
+				return nil, fmt.Errorf("alert %q: max_pct must be positive", externalAlert.Name)
+			}
+		case AlertTypePosition:
+			if externalAlert.MaxPct <= 0 {
+				return nil, fmt.Errorf("alert %q: max_pct must be positive", externalAlert.Name)
+			}
+		case AlertTypeSTCG:
+			if externalAlert.MaxUSD == "" {
+				return nil, fmt.Errorf("alert %q: max_usd is required for stcg", externalAlert.Name)
+			}
+			units, micros, err := mathpb.ParseToUnitsMicros(externalAlert.MaxUSD)
+			if err != nil {
+				return nil, fmt.Errorf("alert %q: invalid max_usd: %w", externalAlert.Name, err)
+			}
+			alert.MaxUSDMicros = units*1_000_000 + micros
+		default:
+			return nil, fmt.Errorf("alert %q: unknown type %q, must be allocation, position, or stcg", externalAlert.Name, externalAlert.Type)
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, nil
+}
@@ -0,0 +1,358 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctlconfig
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctltaxrules"
+	"github.com/bufdev/ibctl/internal/pkg/cryptobox"
+	"github.com/bufdev/ibctl/internal/pkg/protoio"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddAccount(t *testing.T) {
+	t.Parallel()
+	dirPath := t.TempDir()
+	require.NoError(t, InitConfig(dirPath))
+	require.NoError(t, AddAccount(dirPath, "rrsp", "U1234567"))
+	require.NoError(t, AddAccount(dirPath, "hold-co", "U7654321"))
+	require.ErrorContains(t, AddAccount(dirPath, "rrsp", "U1111111"), "already exists")
+	require.ErrorContains(t, AddAccount(dirPath, "tfsa", "U1234567"), "already used")
+	require.ErrorContains(t, AddAccount(dirPath, "Bad", "U2222222"), "invalid")
+	accounts, err := ListAccounts(dirPath)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"rrsp": "U1234567", "hold-co": "U7654321"}, accounts)
+	// Comments from the template are preserved.
+	data, err := os.ReadFile(ibctlpath.ConfigFilePath(dirPath))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "# The Flex Query ID")
+	require.Contains(t, string(data), "# Symbol classification configuration.")
+	require.Contains(t, string(data), `rrsp: "U1234567"`)
+}
+
+func TestSetSymbol(t *testing.T) {
+	t.Parallel()
+	dirPath := t.TempDir()
+	require.NoError(t, InitConfig(dirPath))
+	added, err := SetSymbol(dirPath, "NET", SymbolConfig{Category: "EQUITY", Type: "STOCK"})
+	require.NoError(t, err)
+	require.True(t, added)
+	// Updating only changes the given fields.
+	added, err = SetSymbol(dirPath, "NET", SymbolConfig{Type: "ETF", Sector: "TECH"})
+	require.NoError(t, err)
+	require.False(t, added)
+	data, err := os.ReadFile(ibctlpath.ConfigFilePath(dirPath))
+	require.NoError(t, err)
+	var externalConfig ExternalConfigV1
+	require.NoError(t, unmarshalYAMLStrict(data, &externalConfig))
+	require.Equal(
+		t,
+		[]ExternalSymbolConfigV1{{Name: "NET", Category: "EQUITY", Type: "ETF", Sector: "TECH"}},
+		externalConfig.Symbols,
+	)
+}
+
+func TestNewConfigV1Groups(t *testing.T) {
+	t.Parallel()
+	externalConfig := ExternalConfigV1{
+		Version:     "v1",
+		FlexQueryID: "123456",
+		Accounts:    map[string]string{"rrsp": "U1234567", "tfsa": "U2345678", "hold-co": "U3456789"},
+		Groups:      map[string][]string{"personal": {"tfsa", "rrsp", "tfsa"}, "holdco": {"hold-co"}},
+	}
+	config, err := NewConfigV1(externalConfig, "")
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{"personal": {"rrsp", "tfsa"}, "holdco": {"hold-co"}}, config.Groups)
+	externalConfig.Groups = map[string][]string{"personal": {"ira"}}
+	_, err = NewConfigV1(externalConfig, "")
+	require.ErrorContains(t, err, `unknown account alias "ira"`)
+	externalConfig.Groups = map[string][]string{"Personal": {"rrsp"}}
+	_, err = NewConfigV1(externalConfig, "")
+	require.ErrorContains(t, err, "invalid")
+}
+
+func TestNewConfigV1Logins(t *testing.T) {
+	t.Parallel()
+	externalConfig := ExternalConfigV1{
+		Version:     "v1",
+		FlexQueryID: "123456",
+		Logins:      []ExternalLoginConfigV1{{FlexQueryID: "654321", TokenEnv: "IBKR_FLEX_TOKEN_JOINT"}},
+		Accounts:    map[string]string{"individual": "U1234567", "joint": "U2345678"},
+	}
+	config, err := NewConfigV1(externalConfig, "")
+	require.NoError(t, err)
+	require.Equal(t, []Login{{FlexQueryID: "123456"}, {FlexQueryID: "654321", TokenEnvVar: "IBKR_FLEX_TOKEN_JOINT"}}, config.Logins)
+	externalConfig.Logins = append(externalConfig.Logins, ExternalLoginConfigV1{FlexQueryID: "777777", TokenEnv: "IBKR_FLEX_TOKEN_JOINT"})
+	_, err = NewConfigV1(externalConfig, "")
+	require.ErrorContains(t, err, "duplicate token_env")
+	externalConfig.Logins = []ExternalLoginConfigV1{{FlexQueryID: "654321", TokenEnv: "ibkr-token"}}
+	_, err = NewConfigV1(externalConfig, "")
+	require.ErrorContains(t, err, "invalid")
+	externalConfig.Logins = []ExternalLoginConfigV1{{TokenEnv: "IBKR_FLEX_TOKEN_JOINT"}}
+	_, err = NewConfigV1(externalConfig, "")
+	require.ErrorContains(t, err, "flex_query_id is required")
+}
+
+func TestNewConfigV1AccountTypes(t *testing.T) {
+	t.Parallel()
+	externalConfig := ExternalConfigV1{
+		Version:      "v1",
+		FlexQueryID:  "123456",
+		Accounts:     map[string]string{"rrsp": "U1234567", "tfsa": "U2345678", "individual": "U3456789"},
+		AccountTypes: map[string]string{"rrsp": AccountTypeDeferred, "tfsa": AccountTypeExempt},
+	}
+	config, err := NewConfigV1(externalConfig, "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"individual"}, config.AccountAliasesForType(AccountTypeTaxable))
+	require.Equal(t, []string{"rrsp"}, config.AccountAliasesForType(AccountTypeDeferred))
+	require.Equal(t, []string{"tfsa"}, config.AccountAliasesForType(AccountTypeExempt))
+	externalConfig.AccountTypes = map[string]string{"rrsp": "sheltered"}
+	_, err = NewConfigV1(externalConfig, "")
+	require.ErrorContains(t, err, "invalid")
+}
+
+func TestNewConfigV1SnapshotMaxAge(t *testing.T) {
+	t.Parallel()
+	externalConfig := ExternalConfigV1{
+		Version:     "v1",
+		FlexQueryID: "123456",
+		Accounts:    map[string]string{"individual": "U1234567"},
+	}
+	config, err := NewConfigV1(externalConfig, "")
+	require.NoError(t, err)
+	require.Equal(t, DefaultSnapshotMaxAge, config.SnapshotMaxAge)
+	externalConfig.SnapshotMaxAge = "24h"
+	config, err = NewConfigV1(externalConfig, "")
+	require.NoError(t, err)
+	require.Equal(t, 24*time.Hour, config.SnapshotMaxAge)
+	externalConfig.SnapshotMaxAge = "1d"
+	_, err = NewConfigV1(externalConfig, "")
+	require.ErrorContains(t, err, "snapshot_max_age")
+	externalConfig.SnapshotMaxAge = "-1h"
+	_, err = NewConfigV1(externalConfig, "")
+	require.ErrorContains(t, err, "must be positive")
+}
+
+func TestNewConfigV1Modes(t *testing.T) {
+	t.Parallel()
+	externalConfig := ExternalConfigV1{
+		Version:     "v1",
+		FlexQueryID: "123456",
+		Accounts:    map[string]string{"individual": "U1234567"},
+	}
+	config, err := NewConfigV1(externalConfig, "")
+	require.NoError(t, err)
+	require.Equal(t, fs.FileMode(0o600), config.FileMode)
+	require.Equal(t, fs.FileMode(0o700), config.DirMode)
+	externalConfig.FileMode = "0640"
+	externalConfig.DirMode = "0o750"
+	config, err = NewConfigV1(externalConfig, "")
+	require.NoError(t, err)
+	require.Equal(t, fs.FileMode(0o640), config.FileMode)
+	require.Equal(t, fs.FileMode(0o750), config.DirMode)
+	externalConfig.FileMode = "0644x"
+	_, err = NewConfigV1(externalConfig, "")
+	require.ErrorContains(t, err, "file_mode")
+	externalConfig.FileMode = "01644"
+	_, err = NewConfigV1(externalConfig, "")
+	require.ErrorContains(t, err, "file_mode")
+	externalConfig.FileMode = "0400"
+	_, err = NewConfigV1(externalConfig, "")
+	require.ErrorContains(t, err, "must include 0600")
+	externalConfig.FileMode = ""
+	externalConfig.DirMode = "0600"
+	_, err = NewConfigV1(externalConfig, "")
+	require.ErrorContains(t, err, "must include 0700")
+}
+
+func TestNewConfigV1IgnoreSymbols(t *testing.T) {
+	t.Parallel()
+	externalConfig := ExternalConfigV1{
+		Version:       "v1",
+		FlexQueryID:   "123456",
+		Accounts:      map[string]string{"individual": "U1234567"},
+		IgnoreSymbols: []string{"XYZ.OLD", "PROMO"},
+	}
+	config, err := NewConfigV1(externalConfig, "")
+	require.NoError(t, err)
+	require.Equal(t, map[string]struct{}{"XYZ.OLD": {}, "PROMO": {}}, config.IgnoreSymbols)
+	externalConfig.IgnoreSymbols = []string{"PROMO", "PROMO"}
+	_, err = NewConfigV1(externalConfig, "")
+	require.ErrorContains(t, err, "duplicate symbol")
+}
+
+func TestReadPriceOverrides(t *testing.T) {
+	t.Parallel()
+	filePath := filepath.Join(t.TempDir(), "overrides.yaml")
+	// A missing file has no overrides.
+	priceOverrides, err := ReadPriceOverrides(filePath)
+	require.NoError(t, err)
+	require.Empty(t, priceOverrides)
+	require.NoError(t, os.WriteFile(filePath, []byte("XYZ:\n  price: 1.25\n  date: 2025-06-30\n"), 0o600))
+	priceOverrides, err = ReadPriceOverrides(filePath)
+	require.NoError(t, err)
+	require.Equal(t, map[string]PriceOverride{
+		"XYZ": {PriceMicros: 1_250_000, Date: xtime.Date{Year: 2025, Month: time.June, Day: 30}},
+	}, priceOverrides)
+	require.NoError(t, os.WriteFile(filePath, []byte("XYZ:\n  price: 1.25\n"), 0o600))
+	_, err = ReadPriceOverrides(filePath)
+	require.ErrorContains(t, err, "date must be YYYY-MM-DD")
+	require.NoError(t, os.WriteFile(filePath, []byte("XYZ:\n  price: 0\n  date: 2025-06-30\n"), 0o600))
+	_, err = ReadPriceOverrides(filePath)
+	require.ErrorContains(t, err, "price must be positive")
+}
+
+func TestReadPriceOverridesEncrypted(t *testing.T) {
+	// Not parallel: encryption is process-wide.
+	encodedKey, err := cryptobox.NewKey()
+	require.NoError(t, err)
+	key, err := cryptobox.ParseKey(encodedKey)
+	require.NoError(t, err)
+	sealed, err := cryptobox.Seal(key, []byte("XYZ:\n  price: 1.25\n  date: 2025-06-30\n"))
+	require.NoError(t, err)
+	filePath := filepath.Join(t.TempDir(), "overrides.yaml")
+	require.NoError(t, os.WriteFile(filePath, sealed, 0o600))
+	protoio.SetEncryption(key, true)
+	t.Cleanup(func() { protoio.SetEncryption(nil, false) })
+	priceOverrides, err := ReadPriceOverrides(filePath)
+	require.NoError(t, err)
+	require.Equal(t, map[string]PriceOverride{
+		"XYZ": {PriceMicros: 1_250_000, Date: xtime.Date{Year: 2025, Month: time.June, Day: 30}},
+	}, priceOverrides)
+	// A plaintext file is read with encryption enabled.
+	require.NoError(t, os.WriteFile(filePath, []byte("XYZ:\n  price: 2\n  date: 2025-06-30\n"), 0o600))
+	priceOverrides, err = ReadPriceOverrides(filePath)
+	require.NoError(t, err)
+	require.Equal(t, int64(2_000_000), priceOverrides["XYZ"].PriceMicros)
+}
+
+func TestReadWorkspaces(t *testing.T) {
+	t.Parallel()
+	filePath := filepath.Join(t.TempDir(), "workspaces.yaml")
+	// A missing file has no workspaces.
+	workspaces, err := ReadWorkspaces(filePath, "/home/user")
+	require.NoError(t, err)
+	require.Empty(t, workspaces)
+	require.NoError(t, os.WriteFile(filePath, []byte("personal: ~/ibkr/personal\nholdco: /mnt/holdco/\n"), 0o600))
+	workspaces, err = ReadWorkspaces(filePath, "/home/user")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"personal": "/home/user/ibkr/personal",
+		"holdco":   "/mnt/holdco",
+	}, workspaces)
+	require.NoError(t, os.WriteFile(filePath, []byte("personal: ibkr/personal\n"), 0o600))
+	_, err = ReadWorkspaces(filePath, "/home/user")
+	require.ErrorContains(t, err, "must be absolute")
+}
+
+func TestNewConfigV1SymbolCurrencies(t *testing.T) {
+	t.Parallel()
+	externalConfig := ExternalConfigV1{
+		Version:     "v1",
+		FlexQueryID: "123456",
+		Accounts:    map[string]string{"individual": "U1234567"},
+		Symbols: []ExternalSymbolConfigV1{
+			{Name: "VT", Currencies: map[string]float64{"USD": 60, "EUR": 40}},
+		},
+	}
+	config, err := NewConfigV1(externalConfig, "")
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{"USD": 0.6, "EUR": 0.4}, config.SymbolConfigs["VT"].Currencies)
+	externalConfig.Symbols[0].Currencies = map[string]float64{"USD": 60, "EUR": 30}
+	_, err = NewConfigV1(externalConfig, "")
+	require.ErrorContains(t, err, "add up to 100")
+	externalConfig.Symbols[0].Currencies = map[string]float64{"usd": 100}
+	_, err = NewConfigV1(externalConfig, "")
+	require.ErrorContains(t, err, "invalid")
+}
+
+func TestNewConfigV1TreatyRates(t *testing.T) {
+	t.Parallel()
+	externalConfig := ExternalConfigV1{
+		Version:     "v1",
+		FlexQueryID: "123456",
+		Accounts:    map[string]string{"individual": "U1234567"},
+		Taxes:       &ExternalTaxConfigV1{TreatyRates: map[string]float64{"US": 0.15}},
+	}
+	config, err := NewConfigV1(externalConfig, "")
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{"US": 0.15}, config.TaxTreatyRates)
+	externalConfig.Taxes.TreatyRates = map[string]float64{"US": 15}
+	_, err = NewConfigV1(externalConfig, "")
+	require.ErrorContains(t, err, "between 0 and 1")
+	externalConfig.Taxes.TreatyRates = map[string]float64{"USA": 0.15}
+	_, err = NewConfigV1(externalConfig, "")
+	require.ErrorContains(t, err, "invalid")
+}
+
+func TestNewConfigV1TaxJurisdiction(t *testing.T) {
+	t.Parallel()
+	externalConfig := ExternalConfigV1{
+		Version:     "v1",
+		FlexQueryID: "123456",
+		Accounts:    map[string]string{"individual": "U1234567"},
+	}
+	config, err := NewConfigV1(externalConfig, "")
+	require.NoError(t, err)
+	require.Equal(t, ibctltaxrules.JurisdictionUS, config.TaxRules.Jurisdiction())
+	externalConfig.Taxes = &ExternalTaxConfigV1{Jurisdiction: "au", STCG: 0.4}
+	config, err = NewConfigV1(externalConfig, "")
+	require.NoError(t, err)
+	require.Equal(t, 0.2, config.TaxRateLTCG)
+	ltcg := 0.2
+	externalConfig.Taxes = &ExternalTaxConfigV1{Jurisdiction: "ca", STCG: 0.25, LTCG: &ltcg}
+	_, err = NewConfigV1(externalConfig, "")
+	require.ErrorContains(t, err, "no long-term capital gains")
+	externalConfig.Taxes = &ExternalTaxConfigV1{Jurisdiction: "uk"}
+	_, err = NewConfigV1(externalConfig, "")
+	require.ErrorContains(t, err, "invalid jurisdiction")
+}
+
+func TestNewConfigV1Notify(t *testing.T) {
+	t.Parallel()
+	externalConfig := ExternalConfigV1{
+		Version:     "v1",
+		FlexQueryID: "123456",
+		Accounts:    map[string]string{"individual": "U1234567"},
+	}
+	config, err := NewConfigV1(externalConfig, "")
+	require.NoError(t, err)
+	require.Empty(t, config.NotifyCommand)
+	externalConfig.Notify = &ExternalNotifyConfigV1{Command: " mail -s changes me@example.com "}
+	config, err = NewConfigV1(externalConfig, "")
+	require.NoError(t, err)
+	require.Equal(t, "mail -s changes me@example.com", config.NotifyCommand)
+	externalConfig.Notify = &ExternalNotifyConfigV1{}
+	_, err = NewConfigV1(externalConfig, "")
+	require.ErrorContains(t, err, "notify command must be set")
+}
+
+func TestNewConfigV1Benchmarks(t *testing.T) {
+	t.Parallel()
+	externalConfig := ExternalConfigV1{
+		Version:     "v1",
+		FlexQueryID: "123456",
+		Accounts:    map[string]string{"individual": "U1234567"},
+		Benchmarks: map[string]ExternalBenchmarkConfigV1{
+			"SP500": {Sector: map[string]float64{"TECH": 40, "OTHER": 60}},
+		},
+	}
+	config, err := NewConfigV1(externalConfig, "")
+	require.NoError(t, err)
+	require.Equal(t, map[string]Benchmark{"SP500": {Sector: map[string]float64{"TECH": 0.4, "OTHER": 0.6}}}, config.Benchmarks)
+	externalConfig.Benchmarks["SP500"] = ExternalBenchmarkConfigV1{Sector: map[string]float64{"TECH": 40}}
+	_, err = NewConfigV1(externalConfig, "")
+	require.ErrorContains(t, err, `invalid benchmark sector weights for "SP500"`)
+	externalConfig.Benchmarks["SP500"] = ExternalBenchmarkConfigV1{}
+	_, err = NewConfigV1(externalConfig, "")
+	require.ErrorContains(t, err, `benchmark "SP500" must have category, type, sector, or geo weights`)
+}
@@ -5,92 +5,321 @@
 // Package ibctldownload provides the download orchestrator for IBKR data.
 //
 // The downloader fetches data via the IBKR Flex Query API and stores it per
-// account under v1/<alias>/. Trades are deduplicated by trade ID, exchange
+// account under data/accounts/<alias>/. Trades are deduplicated by trade ID, exchange
 // rates by date+currency pair. Downloads are idempotent — running multiple
 // times safely merges new data into the cache.
 package ibctldownload
 
 import (
+	"bytes"
 	"context"
+	"encoding/xml"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	moneyv1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/money/v1"
+	timev1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/time/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlbackup"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlgit"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmanual"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmigrate"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlprices"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlreconcile"
 	"github.com/bufdev/ibctl/internal/pkg/bankofcanada"
+	"github.com/bufdev/ibctl/internal/pkg/filemode"
 	"github.com/bufdev/ibctl/internal/pkg/frankfurter"
 	"github.com/bufdev/ibctl/internal/pkg/ibkractivitycsv"
 	"github.com/bufdev/ibctl/internal/pkg/ibkrflexquery"
+	"github.com/bufdev/ibctl/internal/pkg/ibkrtradeconfirm"
 	"github.com/bufdev/ibctl/internal/pkg/mathpb"
 	"github.com/bufdev/ibctl/internal/pkg/moneypb"
 	"github.com/bufdev/ibctl/internal/pkg/protoio"
 	"github.com/bufdev/ibctl/internal/pkg/timepb"
+	"github.com/bufdev/ibctl/internal/pkg/timing"
+	"github.com/bufdev/ibctl/internal/pkg/yahoofinance"
 	"github.com/bufdev/ibctl/internal/standard/xtime"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // Downloader is the interface for downloading and caching IBKR data.
 type Downloader interface {
 	// Download fetches IBKR data via the Flex Query API and merges it with
-	// cached data. Data is stored per account under v1/<alias>/.
+	// cached data. Data is stored per account under data/accounts/<alias>/.
 	// Idempotent — safe to call multiple times.
 	Download(ctx context.Context) error
+	// DownloadWithSummary is Download, returning a summary of the changes made.
+	DownloadWithSummary(ctx context.Context) (*Summary, error)
+	// DryRun fetches IBKR data via the Flex Query API and returns the changes
+	// that Download would make, without writing anything.
+	DryRun(ctx context.Context) ([]*Change, error)
+}
+
+// Change is a single change that a download would make to the data or cache.
+type Change struct {
+	// Account is the account alias, or empty for FX rates.
+	Account string `json:"account,omitempty"`
+	// Kind is the kind of change: "trade", "position", or "fx".
+	Kind string `json:"kind"`
+	// Key identifies the changed item: the trade ID, the position symbol, or
+	// the FX pair (e.g., "EUR.USD").
+	Key string `json:"key"`
+	// Before is the current value: the cached position quantity or the cached
+	// FX date range. Empty for new trades and new positions.
+	Before string `json:"before"`
+	// After is the value after the download: the new trade (date, symbol, and
+	// quantity), the new position quantity, or the FX date range to fetch.
+	// Empty for removed positions.
+	After string `json:"after"`
+}
+
+// ChangeHeaders returns the column headers for change table/CSV output.
+func ChangeHeaders() []string {
+	return []string{"ACCOUNT", "KIND", "KEY", "BEFORE", "AFTER"}
+}
+
+// ChangeToRow converts a Change to a string slice for table/CSV output.
+func ChangeToRow(c *Change) []string {
+	return []string{
+		c.Account,
+		c.Kind,
+		c.Key,
+		c.Before,
+		c.After,
+	}
+}
+
+// Summary summarizes the changes made by a download.
+type Summary struct {
+	// Accounts are the summaries of the downloaded accounts, in statement order.
+	Accounts []*AccountSummary `json:"accounts"`
+	// FXPairsRefreshed are the FX pairs whose rates were fetched (e.g., "EUR.USD").
+	FXPairsRefreshed []string `json:"fx_pairs_refreshed"`
+	// PricesRefreshed are the symbols whose daily closing prices were fetched.
+	PricesRefreshed []string `json:"prices_refreshed"`
+	// Warnings are problems not tied to an account that did not fail the download.
+	Warnings []string `json:"warnings"`
+	// Commit is the abbreviated hash of the git commit of the data, or empty if
+	// auto-commit is disabled or the data did not change.
+	Commit string `json:"commit,omitempty"`
+}
+
+// AccountSummary summarizes the changes made by a download to one account.
+type AccountSummary struct {
+	// Account is the account alias.
+	Account string `json:"account"`
+	// NewTrades is the number of trades not previously in trades.json.
+	NewTrades int `json:"new_trades"`
+	// Trades is the number of trades in trades.json after the download.
+	Trades int `json:"trades"`
+	// UpdatedPositions is the number of symbols whose position quantity changed,
+	// including new and closed positions.
+	UpdatedPositions int `json:"updated_positions"`
+	// Positions is the number of positions after the download.
+	Positions int `json:"positions"`
+	// AccruedDividends is the number of dividends declared but not yet paid.
+	AccruedDividends int `json:"accrued_dividends"`
+	// PositionChanges are the positions that were opened, closed, or changed
+	// quantity since the previous snapshot, sorted by symbol. Empty on the
+	// first download of the account.
+	PositionChanges []*PositionChange `json:"position_changes"`
+	// Warnings are problems with the account that did not fail the download.
+	Warnings []string `json:"warnings"`
+}
+
+// AccountSummaryHeaders returns the column headers for account summary table/CSV output.
+func AccountSummaryHeaders() []string {
+	return []string{"ACCOUNT", "NEW_TRADES", "TRADES", "UPDATED_POSITIONS", "POSITIONS", "ACCRUED_DIVIDENDS", "WARNINGS"}
+}
+
+// AccountSummaryToRow converts an AccountSummary to a string slice for table/CSV output.
+func AccountSummaryToRow(s *AccountSummary) []string {
+	return []string{
+		s.Account,
+		strconv.Itoa(s.NewTrades),
+		strconv.Itoa(s.Trades),
+		strconv.Itoa(s.UpdatedPositions),
+		strconv.Itoa(s.Positions),
+		strconv.Itoa(s.AccruedDividends),
+		strconv.Itoa(len(s.Warnings)),
+	}
+}
+
+// Position change types.
+const (
+	// PositionChangeTypeNew is a position that was not in the previous snapshot.
+	PositionChangeTypeNew = "new"
+	// PositionChangeTypeClosed is a position that is no longer held.
+	PositionChangeTypeClosed = "closed"
+	// PositionChangeTypeChanged is a position whose quantity changed.
+	PositionChangeTypeChanged = "changed"
+)
+
+// PositionChange is a position that differs from the previous snapshot.
+type PositionChange struct {
+	// Account is the account alias.
+	Account string `json:"account"`
+	// Symbol is the ticker symbol.
+	Symbol string `json:"symbol"`
+	// Type is the change type (new, closed, changed).
+	Type string `json:"type"`
+	// Before is the quantity in the previous snapshot, or empty for new positions.
+	Before string `json:"before"`
+	// After is the quantity after the download, or empty for closed positions.
+	After string `json:"after"`
+}
+
+// PositionChangeHeaders returns the column headers for position change table/CSV output.
+func PositionChangeHeaders() []string {
+	return []string{"ACCOUNT", "SYMBOL", "TYPE", "BEFORE", "AFTER"}
+}
+
+// PositionChangeToRow converts a PositionChange to a string slice for table/CSV output.
+func PositionChangeToRow(c *PositionChange) []string {
+	return []string{
+		c.Account,
+		c.Symbol,
+		c.Type,
+		c.Before,
+		c.After,
+	}
+}
+
+// PositionChanges returns the position changes of all accounts in the summary.
+func (s *Summary) PositionChanges() []*PositionChange {
+	var positionChanges []*PositionChange
+	for _, accountSummary := range s.Accounts {
+		positionChanges = append(positionChanges, accountSummary.PositionChanges...)
+	}
+	return positionChanges
+}
+
+// Login is an IBKR login whose Flex Query is downloaded.
+type Login struct {
+	// Token is the Flex Web Service token of the login.
+	Token string
+	// FlexQueryID is the Flex Query ID of the login.
+	FlexQueryID string
 }
 
 // NewDownloader creates a new Downloader with all required dependencies.
-// The ibkrToken is the Flex Web Service token from the IBKR_FLEX_WEB_SERVICE_TOKEN environment variable.
+// The Flex Query of every login is downloaded, and the statements of all
+// logins are stored per account.
 func NewDownloader(
 	logger *slog.Logger,
-	ibkrToken string,
+	logins []Login,
 	config *ibctlconfig.Config,
 	flexQueryClient ibkrflexquery.Client,
 	fxRateClient frankfurter.Client,
 	bocClient bankofcanada.Client,
+	priceClient yahoofinance.Client,
 ) Downloader {
 	return &downloader{
 		logger:          logger,
-		ibkrToken:       ibkrToken,
+		logins:          logins,
 		config:          config,
 		flexQueryClient: flexQueryClient,
 		fxRateClient:    fxRateClient,
 		bocClient:       bocClient,
+		priceClient:     priceClient,
 	}
 }
 
+// RequiredSections are the Flex Query sections that a download fails without,
+// since holdings cannot be computed correctly without them.
+var RequiredSections = []string{
+	ibkrflexquery.SectionTrades,
+	ibkrflexquery.SectionOpenPositions,
+}
+
 type downloader struct {
 	logger          *slog.Logger
-	ibkrToken       string
+	logins          []Login
 	config          *ibctlconfig.Config
 	flexQueryClient ibkrflexquery.Client
 	fxRateClient    frankfurter.Client
 	bocClient       bankofcanada.Client
+	priceClient     yahoofinance.Client
 }
 
 func (d *downloader) Download(ctx context.Context) error {
+	_, err := d.DownloadWithSummary(ctx)
+	return err
+}
+
+func (d *downloader) DownloadWithSummary(ctx context.Context) (*Summary, error) {
+	defer timing.Start("download")()
 	// Compute directory paths from the base directory. Trades go to data/ (persistent),
 	// everything else goes to cache/ (blow-away safe).
 	dataAccountsDir := ibctlpath.DataAccountsDirPath(d.config.DirPath)
 	cacheAccountsDir := ibctlpath.CacheAccountsDirPath(d.config.DirPath)
 	cacheFXDir := ibctlpath.CacheFXDirPath(d.config.DirPath)
+	// Refuse to write into data that was written by a different data format version.
+	if err := ibctlmigrate.Check(d.config.DirPath); err != nil {
+		return nil, err
+	}
 	// Create the directory structure.
-	if err := os.MkdirAll(dataAccountsDir, 0o755); err != nil {
-		return fmt.Errorf("creating data accounts directory: %w", err)
+	if err := filemode.MkdirAll(dataAccountsDir); err != nil {
+		return nil, fmt.Errorf("creating data accounts directory: %w", err)
 	}
-	if err := os.MkdirAll(cacheAccountsDir, 0o755); err != nil {
-		return fmt.Errorf("creating cache accounts directory: %w", err)
+	if err := ibctlmigrate.WriteVersion(d.config.DirPath); err != nil {
+		return nil, fmt.Errorf("writing data version: %w", err)
 	}
-	d.logger.Info("downloading flex query data")
-	// Fetch data using the query's configured period (single API call).
-	var zeroDate xtime.Date
-	statements, err := d.flexQueryClient.Download(ctx, d.ibkrToken, d.config.IBKRFlexQueryID, zeroDate, zeroDate)
+	if err := filemode.MkdirAll(cacheAccountsDir); err != nil {
+		return nil, fmt.Errorf("creating cache accounts directory: %w", err)
+	}
+	statements, missing, err := d.downloadStatements(ctx, d.config.ArchiveRaw)
 	if err != nil {
-		return fmt.Errorf("downloading flex query: %w", err)
+		return nil, err
+	}
+	// Empty lists are non-nil so that JSON output has arrays rather than null.
+	summary := &Summary{
+		Accounts:         []*AccountSummary{},
+		FXPairsRefreshed: []string{},
+		PricesRefreshed:  []string{},
+		Warnings:         []string{},
+	}
+	// Fail if a query does not include the sections holdings are computed
+	// from, before anything is written, and warn about the other sections.
+	var missingRequiredSections []string
+	for _, section := range missing.sections {
+		if slices.Contains(RequiredSections, section) {
+			missingRequiredSections = append(missingRequiredSections, section)
+		}
+	}
+	if len(missingRequiredSections) > 0 {
+		return nil, fmt.Errorf("downloading flex query: %w: %s", ibkrflexquery.ErrQueryMissingSection, strings.Join(missingRequiredSections, ", "))
+	}
+	for _, section := range missing.sections {
+		d.logger.Warn("flex query does not include section, add it to the query in the IBKR portal", "section", section)
+		summary.Warnings = append(summary.Warnings, fmt.Sprintf("flex query does not include the %s section", section))
+	}
+	for _, section := range ibkrflexquery.Sections {
+		fields := missing.sectionToFields[section]
+		if len(fields) == 0 {
+			continue
+		}
+		d.logger.Warn("flex query section does not include fields, select all fields of the section in the IBKR portal", "section", section, "fields", strings.Join(fields, ","))
+		summary.Warnings = append(summary.Warnings, fmt.Sprintf("flex query %s section does not include the fields %s", section, strings.Join(fields, ", ")))
+	}
+	// Back up persistent data before it is modified by this download, so a bad download can be rolled back.
+	generation, err := ibctlbackup.Backup(dataAccountsDir, ibctlpath.DataBackupsDirPath(d.config.DirPath), ibctlbackup.DefaultRetention)
+	if err != nil {
+		return nil, fmt.Errorf("backing up data: %w", err)
+	}
+	if generation != "" {
+		d.logger.Debug("data backed up", "generation", generation)
 	}
-	d.logger.Info("flex query data downloaded", "accounts", len(statements))
 	// Collect all trades across accounts for FX rate gap detection.
 	var allTrades []*datav1.Trade
 	// Process each account's statement.
@@ -101,48 +330,381 @@ func (d *downloader) Download(ctx context.Context) error {
 			d.logger.Warn("unknown account ID, skipping (add it to the accounts section in config)",
 				"account_id", statement.AccountId,
 			)
+			summary.Warnings = append(summary.Warnings, "unknown account ID skipped (add it to the accounts section in config)")
 			continue
 		}
 		// Create per-account directories under both data and cache.
 		dataAccountDir := filepath.Join(dataAccountsDir, alias)
 		cacheAccountDir := filepath.Join(cacheAccountsDir, alias)
-		if err := os.MkdirAll(dataAccountDir, 0o755); err != nil {
-			return fmt.Errorf("creating data account directory for %s: %w", alias, err)
+		if err := filemode.MkdirAll(dataAccountDir); err != nil {
+			return nil, fmt.Errorf("creating data account directory for %s: %w", alias, err)
 		}
-		if err := os.MkdirAll(cacheAccountDir, 0o755); err != nil {
-			return fmt.Errorf("creating cache account directory for %s: %w", alias, err)
+		if err := filemode.MkdirAll(cacheAccountDir); err != nil {
+			return nil, fmt.Errorf("creating cache account directory for %s: %w", alias, err)
 		}
 		// Process and write account-specific data.
-		trades, err := d.processAccountData(alias, dataAccountDir, cacheAccountDir, &statement)
+		accountSummary := &AccountSummary{Account: alias, PositionChanges: []*PositionChange{}, Warnings: []string{}}
+		trades, err := d.processAccountData(alias, dataAccountDir, cacheAccountDir, &statement, accountSummary)
 		if err != nil {
-			return fmt.Errorf("processing account %s: %w", alias, err)
+			return nil, fmt.Errorf("processing account %s: %w", alias, err)
 		}
+		summary.Accounts = append(summary.Accounts, accountSummary)
 		allTrades = append(allTrades, trades...)
 	}
 	// Eagerly download FX rates for all non-USD currencies found in trades.
 	// Rates are stored per pair in fx/{BASE}.{QUOTE}/rates.json.
-	if err := d.downloadFXRates(ctx, cacheFXDir, allTrades); err != nil {
+	if err := d.downloadFXRates(ctx, cacheFXDir, allTrades, summary); err != nil {
 		d.logger.Warn("failed to download FX rates", "error", err)
+		summary.Warnings = append(summary.Warnings, fmt.Sprintf("failed to download FX rates: %v", err))
+	}
+	// Download the daily closing prices of all traded symbols into cache/prices/,
+	// for valuing holdings on past dates.
+	if err := d.downloadPrices(ctx, summary); err != nil {
+		d.logger.Warn("failed to download prices", "error", err)
+		summary.Warnings = append(summary.Warnings, fmt.Sprintf("failed to download prices: %v", err))
+	}
+	// Commit the data to git if auto-commit is enabled. A failed commit does
+	// not fail the download, since the data was written.
+	if d.config.GitAutoCommit {
+		commit, err := ibctlgit.CommitData(ctx, d.config.DirPath, ibctlgit.FormatCommitMessage(d.config.GitCommitMessage, summaryCounts(summary)))
+		if err != nil {
+			d.logger.Warn("failed to commit data to git", "error", err)
+			summary.Warnings = append(summary.Warnings, fmt.Sprintf("failed to commit data to git: %v", err))
+		} else if commit != "" {
+			d.logger.Info("data committed to git", "commit", commit)
+			summary.Commit = commit
+		}
 	}
 	d.logger.Info("download complete")
+	return summary, nil
+}
+
+// summaryCounts returns the totals of the summary across accounts, keyed by
+// the names of the commit message placeholders.
+func summaryCounts(summary *Summary) map[string]int {
+	counts := map[string]int{
+		"accounts":          len(summary.Accounts),
+		"new_trades":        0,
+		"trades":            0,
+		"updated_positions": 0,
+		"positions":         0,
+	}
+	for _, accountSummary := range summary.Accounts {
+		counts["new_trades"] += accountSummary.NewTrades
+		counts["trades"] += accountSummary.Trades
+		counts["updated_positions"] += accountSummary.UpdatedPositions
+		counts["positions"] += accountSummary.Positions
+	}
+	return counts
+}
+
+func (d *downloader) DryRun(ctx context.Context) ([]*Change, error) {
+	if err := ibctlmigrate.Check(d.config.DirPath); err != nil {
+		return nil, err
+	}
+	statements, _, err := d.downloadStatements(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	var changes []*Change
+	var allTrades []*datav1.Trade
+	for _, statement := range statements {
+		alias, ok := d.config.AccountIDToAlias[statement.AccountId]
+		if !ok {
+			d.logger.Warn("unknown account ID, skipping (add it to the accounts section in config)",
+				"account_id", statement.AccountId,
+			)
+			continue
+		}
+		accountChanges, trades, err := d.diffAccountData(alias, &statement)
+		if err != nil {
+			return nil, fmt.Errorf("processing account %s: %w", alias, err)
+		}
+		changes = append(changes, accountChanges...)
+		allTrades = append(allTrades, trades...)
+	}
+	// FX rates are fetched for the merged trades, as in Download.
+	pairs, earliestDate, latestDate := d.fxPairs(allTrades)
+	cacheFXDir := ibctlpath.CacheFXDirPath(d.config.DirPath)
+	for _, pair := range pairs {
+		pairKey := pair.base + "." + pair.quote
+		cachedRates, _ := protoio.ReadMessagesJSON(filepath.Join(cacheFXDir, pairKey, "rates.json"), func() *datav1.ExchangeRate { return &datav1.ExchangeRate{} })
+		cachedEarliest, cachedLatest := exchangeRateRange(cachedRates)
+		if cachedRatesCover(cachedEarliest, cachedLatest, earliestDate, latestDate) {
+			continue
+		}
+		change := &Change{
+			Kind:  "fx",
+			Key:   pairKey,
+			After: earliestDate + ".." + latestDate,
+		}
+		if cachedEarliest != "" {
+			change.Before = cachedEarliest + ".." + cachedLatest
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// downloadStatements downloads the Flex Query of every login, using the
+// configured period preset or else each query's configured period, and returns the statements of all logins and the
+// sections and fields missing from any of the queries. If an account is in the
+// statements of more than one login, only the first login's statement is
+// returned. If archiveRaw is true, each raw response is archived before it is
+// parsed.
+func (d *downloader) downloadStatements(ctx context.Context, archiveRaw bool) ([]ibkrflexquery.FlexStatement, *missing, error) {
+	var zeroDate xtime.Date
+	var statements []ibkrflexquery.FlexStatement
+	missing := &missing{sectionToFields: make(map[string][]string)}
+	accountIDs := make(map[string]struct{})
+	now := time.Now()
+	for _, login := range d.logins {
+		d.logger.Info("downloading flex query data", "query_id", login.FlexQueryID)
+		xmlData, err := d.flexQueryClient.DownloadRaw(ctx, login.Token, login.FlexQueryID, d.config.FlexQueryPeriod, zeroDate, zeroDate)
+		if err != nil {
+			return nil, nil, d.downloadError(login, err)
+		}
+		// Archive the raw XML before conversion so it can be re-processed if converters change.
+		if archiveRaw {
+			if err := d.archiveRaw(xmlData, now); err != nil {
+				return nil, nil, fmt.Errorf("archiving raw flex query response: %w", err)
+			}
+		}
+		loginStatements, err := ibkrflexquery.ParseResponse(xmlData)
+		if err != nil {
+			return nil, nil, d.downloadError(login, err)
+		}
+		d.logger.Info("flex query data downloaded", "query_id", login.FlexQueryID, "accounts", len(loginStatements))
+		loginMissingSections, err := ibkrflexquery.MissingSections(xmlData)
+		if err != nil {
+			return nil, nil, d.downloadError(login, err)
+		}
+		for _, section := range loginMissingSections {
+			if !slices.Contains(missing.sections, section) {
+				missing.sections = append(missing.sections, section)
+			}
+		}
+		loginMissingFields, err := ibkrflexquery.MissingFields(xmlData)
+		if err != nil {
+			return nil, nil, d.downloadError(login, err)
+		}
+		for section, fields := range loginMissingFields {
+			for _, field := range fields {
+				if !slices.Contains(missing.sectionToFields[section], field) {
+					missing.sectionToFields[section] = append(missing.sectionToFields[section], field)
+				}
+			}
+		}
+		for _, statement := range loginStatements {
+			if _, ok := accountIDs[statement.AccountId]; ok {
+				d.logger.Warn("account downloaded by more than one login, using the first", "query_id", login.FlexQueryID)
+				continue
+			}
+			accountIDs[statement.AccountId] = struct{}{}
+			statements = append(statements, statement)
+		}
+	}
+	return statements, missing, nil
+}
+
+// missing is what the Flex Queries of the logins do not include.
+type missing struct {
+	// sections are the missing sections, in Sections order for each login.
+	sections []string
+	// sectionToFields maps sections to their missing fields.
+	sectionToFields map[string][]string
+}
+
+// downloadError wraps an error downloading the Flex Query of a login. The
+// query ID is only included if there is more than one login.
+func (d *downloader) downloadError(login Login, err error) error {
+	if len(d.logins) > 1 {
+		return fmt.Errorf("downloading flex query %s: %w", login.FlexQueryID, err)
+	}
+	return fmt.Errorf("downloading flex query: %w", err)
+}
+
+// diffAccountData converts an account's XML data to protos and compares it
+// with the data and cache directories, returning the new trades and changed
+// positions. Also returns the merged trades for FX rate processing.
+func (d *downloader) diffAccountData(alias string, statement *ibkrflexquery.FlexStatement) ([]*Change, []*datav1.Trade, error) {
+	dataAccountDir := filepath.Join(ibctlpath.DataAccountsDirPath(d.config.DirPath), alias)
+	cacheAccountDir := filepath.Join(ibctlpath.CacheAccountsDirPath(d.config.DirPath), alias)
+	newTrades, err := d.convertTrades(statement.Trades, alias)
+	if err != nil {
+		return nil, nil, err
+	}
+	cachedTrades, _ := protoio.ReadMessagesJSON(filepath.Join(dataAccountDir, "trades.json"), func() *datav1.Trade { return &datav1.Trade{} })
+	changes := newTradeChanges(alias, cachedTrades, newTrades)
+	positions, err := d.convertPositions(statement.OpenPositions, alias)
+	if err != nil {
+		return nil, nil, err
+	}
+	cachedPositions, _ := protoio.ReadMessagesJSON(filepath.Join(cacheAccountDir, "positions.json"), func() *datav1.Position { return &datav1.Position{} })
+	changes = append(changes, positionChanges(alias, cachedPositions, positions)...)
+	d.logger.Info("account data compared",
+		"account", alias,
+		"cached_trades", len(cachedTrades),
+		"new_trades", len(newTrades),
+		"positions", len(positions),
+	)
+	return changes, d.mergeTrades(cachedTrades, newTrades), nil
+}
+
+// newTradeChanges returns a change for each new trade not in the cached trades.
+func newTradeChanges(alias string, cachedTrades []*datav1.Trade, newTrades []*datav1.Trade) []*Change {
+	cachedTradeIDs := make(map[string]struct{}, len(cachedTrades))
+	for _, trade := range cachedTrades {
+		cachedTradeIDs[trade.GetTradeId()] = struct{}{}
+	}
+	var changes []*Change
+	for _, trade := range newTrades {
+		if _, ok := cachedTradeIDs[trade.GetTradeId()]; ok {
+			continue
+		}
+		changes = append(changes, &Change{
+			Account: alias,
+			Kind:    "trade",
+			Key:     trade.GetTradeId(),
+			After:   tradeDateString(trade) + " " + trade.GetSymbol() + " " + mathpb.ToString(trade.GetQuantity()),
+		})
+	}
+	return changes
+}
+
+// positionChanges returns a change for each symbol whose position quantity
+// differs between the cached positions and the new positions, sorted by symbol.
+func positionChanges(alias string, cachedPositions []*datav1.Position, positions []*datav1.Position) []*Change {
+	cachedSymbolToQuantity := positionQuantityMicros(cachedPositions)
+	symbolToQuantity := positionQuantityMicros(positions)
+	symbols := make([]string, 0, len(cachedSymbolToQuantity)+len(symbolToQuantity))
+	for symbol := range cachedSymbolToQuantity {
+		symbols = append(symbols, symbol)
+	}
+	for symbol := range symbolToQuantity {
+		if _, ok := cachedSymbolToQuantity[symbol]; !ok {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Strings(symbols)
+	var changes []*Change
+	for _, symbol := range symbols {
+		cachedQuantity, cachedOK := cachedSymbolToQuantity[symbol]
+		quantity, ok := symbolToQuantity[symbol]
+		if cachedOK == ok && cachedQuantity == quantity {
+			continue
+		}
+		change := &Change{
+			Account: alias,
+			Kind:    "position",
+			Key:     symbol,
+		}
+		if cachedOK {
+			change.Before = mathpb.ToString(mathpb.FromMicros(cachedQuantity))
+		}
+		if ok {
+			change.After = mathpb.ToString(mathpb.FromMicros(quantity))
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// positionQuantityMicros returns the total quantity of the positions per symbol.
+func positionQuantityMicros(positions []*datav1.Position) map[string]int64 {
+	symbolToQuantity := make(map[string]int64, len(positions))
+	for _, position := range positions {
+		symbolToQuantity[position.GetSymbol()] += mathpb.ToMicros(position.GetQuantity())
+	}
+	return symbolToQuantity
+}
+
+// archiveRaw writes each account's raw statement XML to cache/raw/<alias>/<timestamp>.xml.
+// Statements for accounts not in the config are skipped.
+func (d *downloader) archiveRaw(xmlData []byte, now time.Time) error {
+	accountIDToData, err := ibkrflexquery.SplitResponse(xmlData)
+	if err != nil {
+		return err
+	}
+	fileName := now.UTC().Format("20060102T150405Z") + ".xml"
+	for accountID, accountData := range accountIDToData {
+		alias, ok := d.config.AccountIDToAlias[accountID]
+		if !ok {
+			continue
+		}
+		rawAccountDir := ibctlpath.CacheRawAccountDirPath(d.config.DirPath, alias)
+		if err := filemode.MkdirAll(rawAccountDir); err != nil {
+			return err
+		}
+		filePath := filepath.Join(rawAccountDir, fileName)
+		if err := protoio.WriteFile(filePath, accountData); err != nil {
+			return err
+		}
+		d.logger.Debug("raw flex query response archived", "account", alias, "path", filePath)
+	}
 	return nil
 }
 
 // processAccountData converts XML data to protos, merges with existing cache,
 // and writes per-account data files. Trades go to dataAccountDir (persistent),
 // all other snapshots go to cacheAccountDir (blow-away safe).
-// Returns the merged trades for FX rate processing.
-func (d *downloader) processAccountData(alias string, dataAccountDir string, cacheAccountDir string, statement *ibkrflexquery.FlexStatement) ([]*datav1.Trade, error) {
+// The changes are recorded in accountSummary. Returns the merged trades for FX
+// rate processing.
+func (d *downloader) processAccountData(alias string, dataAccountDir string, cacheAccountDir string, statement *ibkrflexquery.FlexStatement, accountSummary *AccountSummary) ([]*datav1.Trade, error) {
+	// Convert the sections whose unparseable records are skipped before writing
+	// anything, so that strict mode fails the download without partial writes.
+	quarantine := &quarantine{}
+	transfers, err := d.convertTransfers(statement.Transfers, alias, quarantine)
+	if err != nil {
+		return nil, err
+	}
+	tradeTransfers, err := d.convertTradeTransfers(statement.TradeTransfers, alias, quarantine)
+	if err != nil {
+		return nil, err
+	}
+	corporateActions, err := d.convertCorporateActions(statement.CorporateActions, alias, quarantine)
+	if err != nil {
+		return nil, err
+	}
+	cashPositions, err := d.convertCashPositions(statement.CashReport, alias, quarantine)
+	if err != nil {
+		return nil, err
+	}
+	cashTransactions, err := d.convertCashTransactions(statement.CashTransactions, alias, quarantine)
+	if err != nil {
+		return nil, err
+	}
+	dividendAccruals, err := d.convertDividendAccruals(statement.DividendAccruals, alias, quarantine)
+	if err != nil {
+		return nil, err
+	}
 	// Convert and merge trades — written to persistent data directory.
 	newTrades, err := d.convertTrades(statement.Trades, alias)
 	if err != nil {
 		return nil, err
 	}
-	trades := d.mergeTradesWithCache(newTrades, dataAccountDir)
 	tradesPath := filepath.Join(dataAccountDir, "trades.json")
+	// No cache or read error — start fresh with just the new trades.
+	cachedTrades, _ := protoio.ReadMessagesJSON(tradesPath, func() *datav1.Trade { return &datav1.Trade{} })
+	trades := d.mergeTrades(cachedTrades, newTrades)
+	accountSummary.NewTrades = len(newTradeChanges(alias, cachedTrades, newTrades))
+	accountSummary.Trades = len(trades)
 	if err := protoio.WriteMessagesJSON(tradesPath, trades); err != nil {
 		return nil, fmt.Errorf("writing trades: %w", err)
 	}
+	// Convert and merge IBKR's closed lots — also persistent, as they cover the
+	// same window as trades.
+	newClosedLots, err := d.convertClosedLots(statement.Lots, alias)
+	if err != nil {
+		return nil, err
+	}
+	closedLotsPath := filepath.Join(dataAccountDir, "closed_lots.json")
+	closedLots := mergeClosedLotsWithCache(newClosedLots, closedLotsPath)
+	if len(closedLots) > 0 {
+		if err := protoio.WriteMessagesJSON(closedLotsPath, closedLots); err != nil {
+			return nil, fmt.Errorf("writing closed lots: %w", err)
+		}
+	}
 	// All remaining data is snapshot-based and goes to cache directory.
 	// Positions are always overwritten with the latest snapshot.
 	positions, err := d.convertPositions(statement.OpenPositions, alias)
@@ -150,63 +712,222 @@ func (d *downloader) processAccountData(alias string, dataAccountDir string, cac
 		return nil, err
 	}
 	positionsPath := filepath.Join(cacheAccountDir, "positions.json")
+	previousPositions, ok, err := d.readPreviousPositions(alias, positionsPath)
+	if err != nil {
+		return nil, err
+	}
+	changes := positionChanges(alias, previousPositions, positions)
+	accountSummary.UpdatedPositions = len(changes)
+	accountSummary.Positions = len(positions)
+	// Without a previous snapshot, every position would be reported as new.
+	if ok {
+		accountSummary.PositionChanges = changesToPositionChanges(changes)
+	}
 	if err := protoio.WriteMessagesJSON(positionsPath, positions); err != nil {
 		return nil, fmt.Errorf("writing positions: %w", err)
 	}
-	// Convert and write transfers.
-	transfers, err := d.convertTransfers(statement.Transfers, alias)
-	if err != nil {
+	// Keep a dated copy of the positions snapshot in the persistent data directory
+	// so that consecutive snapshots can be reconciled against trades and transfers.
+	if err := d.writePositionSnapshot(alias, statement.ToDate, positions, accountSummary); err != nil {
 		return nil, err
 	}
 	transfersPath := filepath.Join(cacheAccountDir, "transfers.json")
 	if err := protoio.WriteMessagesJSON(transfersPath, transfers); err != nil {
 		return nil, fmt.Errorf("writing transfers: %w", err)
 	}
-	// Convert and write trade transfers.
-	tradeTransfers, err := d.convertTradeTransfers(statement.TradeTransfers, alias)
-	if err != nil {
-		return nil, err
-	}
 	tradeTransfersPath := filepath.Join(cacheAccountDir, "trade_transfers.json")
 	if err := protoio.WriteMessagesJSON(tradeTransfersPath, tradeTransfers); err != nil {
 		return nil, fmt.Errorf("writing trade transfers: %w", err)
 	}
-	// Convert and write corporate actions.
-	corporateActions, err := d.convertCorporateActions(statement.CorporateActions, alias)
-	if err != nil {
-		return nil, err
-	}
 	corporateActionsPath := filepath.Join(cacheAccountDir, "corporate_actions.json")
 	if err := protoio.WriteMessagesJSON(corporateActionsPath, corporateActions); err != nil {
 		return nil, fmt.Errorf("writing corporate actions: %w", err)
 	}
-	// Convert and write cash positions from the Cash Report section.
-	cashPositions := d.convertCashPositions(statement.CashReport, alias)
+	// Cash positions come from the Cash Report section.
 	cashPositionsPath := filepath.Join(cacheAccountDir, "cash_positions.json")
 	if err := protoio.WriteMessagesJSON(cashPositionsPath, cashPositions); err != nil {
 		return nil, fmt.Errorf("writing cash positions: %w", err)
 	}
+	// Cash transactions are dividends, interest, withholding tax, and fees.
+	cashTransactionsPath := filepath.Join(cacheAccountDir, "cash_transactions.json")
+	if err := protoio.WriteMessagesJSON(cashTransactionsPath, cashTransactions); err != nil {
+		return nil, fmt.Errorf("writing cash transactions: %w", err)
+	}
+	// Dividend accruals are the dividends declared but not yet paid.
+	if err := protoio.WriteMessagesJSON(ibctlpath.CacheAccountDividendAccrualsFilePath(d.config.DirPath, alias), dividendAccruals); err != nil {
+		return nil, fmt.Errorf("writing dividend accruals: %w", err)
+	}
+	accountSummary.AccruedDividends = len(dividendAccruals)
+	// Record when the position and cash snapshots were downloaded, so that
+	// commands can warn about stale snapshots.
+	metadata := &datav1.Metadata{
+		DownloadTime: timestamppb.New(time.Now()),
+	}
+	if err := protoio.WriteMessageJSON(ibctlpath.CacheAccountMetadataFilePath(d.config.DirPath, alias), metadata); err != nil {
+		return nil, fmt.Errorf("writing metadata: %w", err)
+	}
+	if len(quarantine.records) > 0 {
+		if err := d.writeQuarantine(alias, quarantine, time.Now()); err != nil {
+			return nil, err
+		}
+		for _, record := range quarantine.records {
+			accountSummary.Warnings = append(accountSummary.Warnings, fmt.Sprintf("skipped unparseable %s %d: %v", record.element, record.index, record.err))
+		}
+	}
 	d.logger.Info("account data written",
 		"account", alias,
 		"trades", len(trades),
+		"closed_lots", len(closedLots),
 		"positions", len(positions),
 		"transfers", len(transfers),
 		"trade_transfers", len(tradeTransfers),
 		"corporate_actions", len(corporateActions),
 		"cash_positions", len(cashPositions),
+		"cash_transactions", len(cashTransactions),
+		"dividend_accruals", len(dividendAccruals),
 	)
 	return trades, nil
 }
 
-// mergeTradesWithCache reads existing cached trades from the account directory
-// and merges new trades, deduplicating by trade ID.
-func (d *downloader) mergeTradesWithCache(newTrades []*datav1.Trade, accountDir string) []*datav1.Trade {
-	tradesPath := filepath.Join(accountDir, "trades.json")
-	cachedTrades, err := protoio.ReadMessagesJSON(tradesPath, func() *datav1.Trade { return &datav1.Trade{} })
+// readPreviousPositions returns the positions of the previous download of the
+// account: the cached positions, or if the cache was cleared, the latest dated
+// snapshot. Returns false if the account has neither.
+func (d *downloader) readPreviousPositions(alias string, positionsPath string) ([]*datav1.Position, bool, error) {
+	cachedPositions, err := protoio.ReadMessagesJSON(positionsPath, func() *datav1.Position { return &datav1.Position{} })
+	if err == nil {
+		return cachedPositions, true, nil
+	}
+	snapshots, err := ibctlreconcile.ReadPositionSnapshots(ibctlpath.DataAccountSnapshotsDirPath(d.config.DirPath, alias), alias)
 	if err != nil {
-		// No cache or read error — start fresh with just the new trades.
-		return newTrades
+		return nil, false, err
+	}
+	if len(snapshots) == 0 {
+		return nil, false, nil
+	}
+	return snapshots[len(snapshots)-1].Positions, true, nil
+}
+
+// changesToPositionChanges converts position Changes to PositionChanges.
+func changesToPositionChanges(changes []*Change) []*PositionChange {
+	positionChanges := make([]*PositionChange, 0, len(changes))
+	for _, change := range changes {
+		positionChange := &PositionChange{
+			Account: change.Account,
+			Symbol:  change.Key,
+			Type:    PositionChangeTypeChanged,
+			Before:  change.Before,
+			After:   change.After,
+		}
+		switch {
+		case change.Before == "":
+			positionChange.Type = PositionChangeTypeNew
+		case change.After == "":
+			positionChange.Type = PositionChangeTypeClosed
+		}
+		positionChanges = append(positionChanges, positionChange)
+	}
+	return positionChanges
+}
+
+// writePositionSnapshot writes positions to data/accounts/<alias>/snapshots/<YYYY-MM-DD>/positions.json.
+// The snapshot date is the statement's toDate, falling back to today if it is
+// absent or unparseable, which is recorded as a warning in accountSummary.
+func (d *downloader) writePositionSnapshot(alias string, statementToDate string, positions []*datav1.Position, accountSummary *AccountSummary) error {
+	snapshotDate := time.Now()
+	if statementToDate != "" {
+		parsedDate, err := parseIBKRDate(statementToDate)
+		if err != nil {
+			d.logger.Warn("unparseable statement toDate, using today for position snapshot", "to_date", statementToDate, "error", err)
+			accountSummary.Warnings = append(accountSummary.Warnings, fmt.Sprintf("unparseable statement toDate %q, used today for position snapshot", statementToDate))
+		} else {
+			snapshotDate = parsedDate
+		}
+	}
+	snapshotDir := filepath.Join(ibctlpath.DataAccountSnapshotsDirPath(d.config.DirPath, alias), snapshotDate.Format("2006-01-02"))
+	if err := filemode.MkdirAll(snapshotDir); err != nil {
+		return fmt.Errorf("creating snapshot directory for %s: %w", alias, err)
+	}
+	if err := protoio.WriteMessagesJSON(filepath.Join(snapshotDir, "positions.json"), positions); err != nil {
+		return fmt.Errorf("writing position snapshot: %w", err)
 	}
+	return nil
+}
+
+// quarantine collects the Flex Query records of an account that were skipped
+// because they could not be converted.
+type quarantine struct {
+	records []*quarantineRecord
+}
+
+// quarantineRecord is a skipped Flex Query record.
+type quarantineRecord struct {
+	// element is the Flex Query XML element name (e.g., "Transfer").
+	element string
+	// index is the index of the record within its section.
+	index int
+	// record is the parsed XML record, marshaled back to XML when written.
+	record any
+	// err is the conversion error.
+	err error
+}
+
+// skip handles a Flex Query record that could not be converted. In strict mode
+// it returns an error that fails the download; otherwise the record is added
+// to the quarantine and nil is returned.
+func (d *downloader) skip(quarantine *quarantine, element string, index int, record any, err error) error {
+	if d.config.Strict {
+		return fmt.Errorf("converting %s %d (strict mode): %w", element, index, err)
+	}
+	d.logger.Warn("skipping unparseable record", "element", element, "index", index, "error", err)
+	quarantine.records = append(quarantine.records, &quarantineRecord{
+		element: element,
+		index:   index,
+		record:  record,
+		err:     err,
+	})
+	return nil
+}
+
+// writeQuarantine writes the quarantined records to
+// data/quarantine/<alias>/<timestamp>.xml. Each record is written as its Flex
+// Query XML element, preceded by a comment with the conversion error.
+func (d *downloader) writeQuarantine(alias string, quarantine *quarantine, now time.Time) error {
+	var buffer bytes.Buffer
+	buffer.WriteString("<Quarantine>\n")
+	encoder := xml.NewEncoder(&buffer)
+	for _, record := range quarantine.records {
+		// XML comments cannot contain "--".
+		comment := strings.ReplaceAll(fmt.Sprintf(" %s %d: %v ", record.element, record.index, record.err), "--", "- -")
+		if err := encoder.EncodeToken(xml.Comment(comment)); err != nil {
+			return fmt.Errorf("encoding quarantined %s: %w", record.element, err)
+		}
+		if err := encoder.Flush(); err != nil {
+			return err
+		}
+		buffer.WriteString("\n")
+		if err := encoder.EncodeElement(record.record, xml.StartElement{Name: xml.Name{Local: record.element}}); err != nil {
+			return fmt.Errorf("encoding quarantined %s: %w", record.element, err)
+		}
+		if err := encoder.Flush(); err != nil {
+			return err
+		}
+		buffer.WriteString("\n")
+	}
+	buffer.WriteString("</Quarantine>\n")
+	quarantineDir := ibctlpath.DataQuarantineAccountDirPath(d.config.DirPath, alias)
+	if err := filemode.MkdirAll(quarantineDir); err != nil {
+		return fmt.Errorf("creating quarantine directory for %s: %w", alias, err)
+	}
+	filePath := filepath.Join(quarantineDir, now.UTC().Format("20060102T150405Z")+".xml")
+	if err := protoio.WriteFile(filePath, buffer.Bytes()); err != nil {
+		return fmt.Errorf("writing quarantine: %w", err)
+	}
+	d.logger.Warn("unparseable records quarantined", "account", alias, "records", len(quarantine.records), "path", filePath)
+	return nil
+}
+
+// mergeTrades merges new trades into cached trades, deduplicating by trade ID.
+func (d *downloader) mergeTrades(cachedTrades []*datav1.Trade, newTrades []*datav1.Trade) []*datav1.Trade {
 	// Build a map of all trades by trade ID, starting with cached trades.
 	tradeMap := make(map[string]*datav1.Trade, len(cachedTrades)+len(newTrades))
 	for _, trade := range cachedTrades {
@@ -233,6 +954,60 @@ func (d *downloader) mergeTradesWithCache(newTrades []*datav1.Trade, accountDir
 	return merged
 }
 
+// mergeClosedLotsWithCache reads existing closed lots from closedLotsPath and
+// merges new closed lots, deduplicating by closing trade, open date, and quantity.
+func mergeClosedLotsWithCache(newClosedLots []*datav1.ClosedLot, closedLotsPath string) []*datav1.ClosedLot {
+	cachedClosedLots, err := protoio.ReadMessagesJSON(closedLotsPath, func() *datav1.ClosedLot { return &datav1.ClosedLot{} })
+	if err != nil {
+		return newClosedLots
+	}
+	closedLotMap := make(map[string]*datav1.ClosedLot, len(cachedClosedLots)+len(newClosedLots))
+	for _, closedLots := range [][]*datav1.ClosedLot{cachedClosedLots, newClosedLots} {
+		for _, closedLot := range closedLots {
+			key := fmt.Sprintf(
+				"%s|%s|%s|%s|%s",
+				closedLot.GetClosingTradeId(),
+				closedLot.GetSymbol(),
+				closedLotDateString(closedLot.GetCloseDate()),
+				closedLotDateString(closedLot.GetOpenDate()),
+				mathpb.ToString(closedLot.GetQuantity()),
+			)
+			closedLotMap[key] = closedLot
+		}
+	}
+	merged := make([]*datav1.ClosedLot, 0, len(closedLotMap))
+	for _, closedLot := range closedLotMap {
+		merged = append(merged, closedLot)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		closeDateI := closedLotDateString(merged[i].GetCloseDate())
+		closeDateJ := closedLotDateString(merged[j].GetCloseDate())
+		if closeDateI != closeDateJ {
+			return closeDateI < closeDateJ
+		}
+		if merged[i].GetClosingTradeId() != merged[j].GetClosingTradeId() {
+			return merged[i].GetClosingTradeId() < merged[j].GetClosingTradeId()
+		}
+		openDateI := closedLotDateString(merged[i].GetOpenDate())
+		openDateJ := closedLotDateString(merged[j].GetOpenDate())
+		if openDateI != openDateJ {
+			return openDateI < openDateJ
+		}
+		// Lots of the same closing trade and open date are ordered by symbol and
+		// quantity, so the order does not depend on map iteration.
+		if merged[i].GetSymbol() != merged[j].GetSymbol() {
+			return merged[i].GetSymbol() < merged[j].GetSymbol()
+		}
+		return mathpb.ToString(merged[i].GetQuantity()) < mathpb.ToString(merged[j].GetQuantity())
+	})
+	return merged
+}
+
+// closedLotDateString returns a sortable date string from a closed lot date.
+func closedLotDateString(d *timev1.Date) string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.GetYear(), d.GetMonth(), d.GetDay())
+}
+
 // tradeDateString returns a sortable date string from a trade's trade_date.
 func tradeDateString(trade *datav1.Trade) string {
 	if d := trade.GetTradeDate(); d != nil {
@@ -253,10 +1028,81 @@ func exchangeRateDateString(rate *datav1.ExchangeRate) string {
 // across all data sources (Flex Query trades, seed transactions, Activity
 // Statement CSVs). Rates are stored per pair in fx/{BASE}.{QUOTE}/rates.json.
 // Only fetches rates for dates not already cached.
-func (d *downloader) downloadFXRates(ctx context.Context, fxDirPath string, flexQueryTrades []*datav1.Trade) error {
-	if err := os.MkdirAll(fxDirPath, 0o755); err != nil {
+func (d *downloader) downloadFXRates(ctx context.Context, fxDirPath string, flexQueryTrades []*datav1.Trade, summary *Summary) error {
+	if err := filemode.MkdirAll(fxDirPath); err != nil {
 		return fmt.Errorf("creating fx directory: %w", err)
 	}
+	pairs, earliestDate, latestDate := d.fxPairs(flexQueryTrades)
+	// Fetch and write rates for each pair.
+	for _, pair := range pairs {
+		pairKey := pair.base + "." + pair.quote
+		refreshed, err := d.downloadPairRates(ctx, fxDirPath, pair.base, pair.quote, pair.provider, earliestDate, latestDate)
+		if err != nil {
+			d.logger.Warn("failed to download FX rates for pair",
+				"pair", pairKey,
+				"provider", pair.provider,
+				"error", err,
+			)
+			summary.Warnings = append(summary.Warnings, fmt.Sprintf("failed to download FX rates for %s from %s: %v", pairKey, pair.provider, err))
+			continue
+		}
+		if refreshed {
+			summary.FXPairsRefreshed = append(summary.FXPairsRefreshed, pairKey)
+		}
+	}
+	return nil
+}
+
+// downloadPrices downloads the daily closing prices of every priced symbol in
+// the merged trades of all data sources, over the dates the symbol was held.
+// Only dates not already cached are fetched. A symbol whose prices cannot be
+// fetched is a warning, so that one unknown symbol does not fail the download.
+func (d *downloader) downloadPrices(ctx context.Context, summary *Summary) error {
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(d.config.DirPath),
+		ibctlpath.CacheAccountsDirPath(d.config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(d.config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(d.config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(d.config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(d.config.DirPath),
+		ibctlpath.SeedDirPath(d.config.DirPath),
+		ibctlpath.DataManualDirPath(d.config.DirPath),
+		d.config.AccountAliases,
+		ibctlmerge.MergeWithTrades(),
+	)
+	if err != nil {
+		return err
+	}
+	symbolRanges, err := ibctlprices.SymbolRanges(mergedData.Trades, d.config, xtime.TimeToDate(time.Now()))
+	if err != nil {
+		return err
+	}
+	priceStore := ibctlprices.NewStore(d.config, d.priceClient, mergedData.Trades)
+	for _, symbolRange := range symbolRanges {
+		refreshed, err := priceStore.Update(ctx, symbolRange.Symbol, symbolRange.Start, symbolRange.End)
+		if err != nil {
+			d.logger.Warn("failed to download prices for symbol", "symbol", symbolRange.Symbol, "error", err)
+			summary.Warnings = append(summary.Warnings, fmt.Sprintf("failed to download prices for %s: %v", symbolRange.Symbol, err))
+			continue
+		}
+		if refreshed {
+			summary.PricesRefreshed = append(summary.PricesRefreshed, symbolRange.Symbol)
+		}
+	}
+	return nil
+}
+
+// fxPair is a currency pair whose rates are downloaded from a provider.
+type fxPair struct {
+	base     string
+	quote    string
+	provider string // "frankfurter" or "bankofcanada"
+}
+
+// fxPairs returns the currency pairs to download and the date range to cover
+// them for, from the earliest date in any data source to today. Returns no
+// pairs if there are no non-USD currencies in any data source.
+func (d *downloader) fxPairs(flexQueryTrades []*datav1.Trade) ([]fxPair, string, string) {
 	// Collect all non-USD currencies and date range across ALL data sources:
 	// Flex Query trades, seed transactions, and Activity Statement CSVs.
 	currencies := make(map[string]bool)
@@ -303,7 +1149,10 @@ func (d *downloader) downloadFXRates(ctx context.Context, fxDirPath string, flex
 	if _, err := os.Stat(activityStatementsDirPath); err == nil {
 		for alias := range d.config.AccountAliases {
 			csvDir := filepath.Join(activityStatementsDirPath, alias)
-			csvStatements, err := ibkractivitycsv.ParseDirectory(csvDir)
+			csvStatements, err := ibkractivitycsv.ParseDirectoryWithCache(
+				csvDir,
+				filepath.Join(ibctlpath.CacheActivityStatementsDirPath(d.config.DirPath), alias),
+			)
 			if err != nil {
 				continue
 			}
@@ -316,9 +1165,29 @@ func (d *downloader) downloadFXRates(ctx context.Context, fxDirPath string, flex
 			}
 		}
 	}
+	// Source 4: Trade Confirmation Flex report trades.
+	for alias := range d.config.AccountAliases {
+		tradeConfirms, err := ibkrtradeconfirm.ParseDirectory(filepath.Join(ibctlpath.TradeConfirmationsDirPath(d.config.DirPath), alias))
+		if err != nil {
+			continue
+		}
+		for _, tradeConfirm := range tradeConfirms {
+			trackCurrencyDate(tradeConfirm.CurrencyCode, tradeConfirm.TradeDate.Format("2006-01-02"))
+		}
+	}
+	// Source 5: Manually entered trades.
+	for alias := range d.config.AccountAliases {
+		manualTrades, err := ibctlmanual.ReadTrades(ibctlpath.DataManualDirPath(d.config.DirPath), alias)
+		if err != nil {
+			continue
+		}
+		for _, trade := range manualTrades {
+			trackCurrencyDate(trade.GetCurrencyCode(), tradeDateString(trade))
+		}
+	}
 	if len(currencies) == 0 || earliestDate == "" {
 		d.logger.Info("no non-USD currencies found in any data source, skipping FX rate download")
-		return nil
+		return nil, "", ""
 	}
 	d.logger.Info("FX rate date range determined",
 		"earliest", earliestDate,
@@ -333,70 +1202,47 @@ func (d *downloader) downloadFXRates(ctx context.Context, fxDirPath string, flex
 	// For each non-USD currency, download X→USD rates from frankfurter.dev.
 	// For each non-CAD currency (including USD), download X→CAD rates from Bank of Canada.
 	// The currency set always includes CAD (from FX trades), so we always get USD.CAD.
-	type pairSpec struct {
-		base     string
-		quote    string
-		provider string // "frankfurter" or "bankofcanada"
-	}
-	var pairs []pairSpec
+	var pairs []fxPair
 	for currency := range currencies {
 		if currency != "USD" {
 			// Non-USD, non-CAD currencies need X→USD from frankfurter.
-			pairs = append(pairs, pairSpec{base: currency, quote: "USD", provider: "frankfurter"})
+			pairs = append(pairs, fxPair{base: currency, quote: "USD", provider: "frankfurter"})
 		}
 		if currency != "CAD" {
 			// Non-CAD currencies need X→CAD from Bank of Canada.
-			pairs = append(pairs, pairSpec{base: currency, quote: "CAD", provider: "bankofcanada"})
+			pairs = append(pairs, fxPair{base: currency, quote: "CAD", provider: "bankofcanada"})
 		}
 	}
 	// Always download USD→CAD from Bank of Canada.
-	pairs = append(pairs, pairSpec{base: "USD", quote: "CAD", provider: "bankofcanada"})
-	// Fetch and write rates for each pair.
-	for _, pair := range pairs {
-		if err := d.downloadPairRates(ctx, fxDirPath, pair.base, pair.quote, pair.provider, earliestDate, latestDate); err != nil {
-			d.logger.Warn("failed to download FX rates for pair",
-				"pair", pair.base+"."+pair.quote,
-				"provider", pair.provider,
-				"error", err,
-			)
+	pairs = append(pairs, fxPair{base: "USD", quote: "CAD", provider: "bankofcanada"})
+	// Sort for deterministic download order.
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].base != pairs[j].base {
+			return pairs[i].base < pairs[j].base
 		}
-	}
-	return nil
+		return pairs[i].quote < pairs[j].quote
+	})
+	return pairs, earliestDate, latestDate
 }
 
 // downloadPairRates downloads FX rates for a single currency pair from the
 // specified provider, merges with existing cached rates, and writes the result.
-// Only dates not already in the cache are fetched.
-func (d *downloader) downloadPairRates(ctx context.Context, fxDirPath string, base string, quote string, provider string, startDate string, endDate string) error {
+// Only dates not already in the cache are fetched. Returns true if rates were
+// fetched, and false if the cache already covered the date range.
+func (d *downloader) downloadPairRates(ctx context.Context, fxDirPath string, base string, quote string, provider string, startDate string, endDate string) (bool, error) {
 	pairKey := base + "." + quote
 	pairDir := filepath.Join(fxDirPath, pairKey)
-	if err := os.MkdirAll(pairDir, 0o755); err != nil {
-		return fmt.Errorf("creating pair directory: %w", err)
+	if err := filemode.MkdirAll(pairDir); err != nil {
+		return false, fmt.Errorf("creating pair directory: %w", err)
 	}
 	ratesPath := filepath.Join(pairDir, "rates.json")
 	// Load existing cached rates for this pair.
 	cachedRates, _ := protoio.ReadMessagesJSON(ratesPath, func() *datav1.ExchangeRate { return &datav1.ExchangeRate{} })
-	// Determine the date range covered by cached rates.
-	var cachedEarliest, cachedLatest string
-	for _, rate := range cachedRates {
-		dateStr := exchangeRateDateString(rate)
-		if cachedEarliest == "" || dateStr < cachedEarliest {
-			cachedEarliest = dateStr
-		}
-		if cachedLatest == "" || dateStr > cachedLatest {
-			cachedLatest = dateStr
-		}
-	}
 	// Skip the API call if cached rates already cover the requested range.
-	// The latest cached rate must be within 4 days of the end date to account
-	// for weekends and holidays when no rates are published.
-	if cachedEarliest != "" && cachedEarliest <= startDate && cachedLatest != "" {
-		latestCached, err := time.Parse("2006-01-02", cachedLatest)
-		endParsed, err2 := time.Parse("2006-01-02", endDate)
-		if err == nil && err2 == nil && endParsed.Sub(latestCached).Hours() <= 96 {
-			d.logger.Info("FX rates already cached", "pair", pairKey, "cached_range", cachedEarliest+".."+cachedLatest)
-			return nil
-		}
+	cachedEarliest, cachedLatest := exchangeRateRange(cachedRates)
+	if cachedRatesCover(cachedEarliest, cachedLatest, startDate, endDate) {
+		d.logger.Info("FX rates already cached", "pair", pairKey, "cached_range", cachedEarliest+".."+cachedLatest)
+		return false, nil
 	}
 	// Fetch rates from the appropriate provider for the full date range.
 	d.logger.Info("downloading FX rates", "pair", pairKey, "provider", provider, "start", startDate, "end", endDate)
@@ -410,7 +1256,7 @@ func (d *downloader) downloadPairRates(ctx context.Context, fxDirPath string, ba
 	case "frankfurter":
 		rates, err := d.fxRateClient.GetRates(ctx, base, quote, startDate, endDate)
 		if err != nil {
-			return fmt.Errorf("fetching rates from frankfurter: %w", err)
+			return false, fmt.Errorf("fetching rates from frankfurter: %w", err)
 		}
 		for _, r := range rates {
 			parsedDate, err := time.Parse("2006-01-02", r.Date)
@@ -435,7 +1281,7 @@ func (d *downloader) downloadPairRates(ctx context.Context, fxDirPath string, ba
 	case "bankofcanada":
 		rates, err := d.bocClient.GetRates(ctx, base, startDate, endDate)
 		if err != nil {
-			return fmt.Errorf("fetching rates from bankofcanada: %w", err)
+			return false, fmt.Errorf("fetching rates from bankofcanada: %w", err)
 		}
 		for _, r := range rates {
 			parsedDate, err := time.Parse("2006-01-02", r.Date)
@@ -458,7 +1304,7 @@ func (d *downloader) downloadPairRates(ctx context.Context, fxDirPath string, ba
 			})
 		}
 	default:
-		return fmt.Errorf("unknown provider: %s", provider)
+		return false, fmt.Errorf("unknown provider: %s", provider)
 	}
 	// Merge fetched rates with cached rates (existing dates are not overwritten).
 	rateMap := make(map[string]*datav1.ExchangeRate, len(cachedRates)+len(fetchedRates))
@@ -481,10 +1327,45 @@ func (d *downloader) downloadPairRates(ctx context.Context, fxDirPath string, ba
 		return exchangeRateDateString(merged[i]) < exchangeRateDateString(merged[j])
 	})
 	if err := protoio.WriteMessagesJSON(ratesPath, merged); err != nil {
-		return fmt.Errorf("writing rates: %w", err)
+		return false, fmt.Errorf("writing rates: %w", err)
 	}
 	d.logger.Info("FX rates written", "pair", pairKey, "cached", len(cachedRates), "fetched", len(fetchedRates), "total", len(merged))
-	return nil
+	return true, nil
+}
+
+// exchangeRateRange returns the earliest and latest dates of the rates, or
+// empty strings if there are no rates.
+func exchangeRateRange(rates []*datav1.ExchangeRate) (string, string) {
+	var earliest, latest string
+	for _, rate := range rates {
+		dateStr := exchangeRateDateString(rate)
+		if earliest == "" || dateStr < earliest {
+			earliest = dateStr
+		}
+		if latest == "" || dateStr > latest {
+			latest = dateStr
+		}
+	}
+	return earliest, latest
+}
+
+// cachedRatesCover returns true if cached rates from cachedEarliest to
+// cachedLatest cover startDate to endDate. The latest cached rate must be
+// within 4 days of the end date to account for weekends and holidays when no
+// rates are published.
+func cachedRatesCover(cachedEarliest string, cachedLatest string, startDate string, endDate string) bool {
+	if cachedEarliest == "" || cachedEarliest > startDate || cachedLatest == "" {
+		return false
+	}
+	latestCached, err := time.Parse("2006-01-02", cachedLatest)
+	if err != nil {
+		return false
+	}
+	endParsed, err := time.Parse("2006-01-02", endDate)
+	if err != nil {
+		return false
+	}
+	return endParsed.Sub(latestCached).Hours() <= 96
 }
 
 // convertTrades converts XML trades to proto trades, setting the account alias.
@@ -500,6 +1381,19 @@ func (d *downloader) convertTrades(xmlTrades []ibkrflexquery.XMLTrade, accountAl
 	return trades, nil
 }
 
+// convertClosedLots converts XML closed lots to proto closed lots, setting the account alias.
+func (d *downloader) convertClosedLots(xmlLots []ibkrflexquery.XMLLot, accountAlias string) ([]*datav1.ClosedLot, error) {
+	closedLots := make([]*datav1.ClosedLot, 0, len(xmlLots))
+	for i := range xmlLots {
+		closedLot, err := xmlLotToProto(&xmlLots[i], accountAlias)
+		if err != nil {
+			return nil, fmt.Errorf("converting closed lot %d: %w", i, err)
+		}
+		closedLots = append(closedLots, closedLot)
+	}
+	return closedLots, nil
+}
+
 // convertPositions converts XML positions to proto positions, setting the account alias.
 func (d *downloader) convertPositions(xmlPositions []ibkrflexquery.XMLPosition, accountAlias string) ([]*datav1.Position, error) {
 	positions := make([]*datav1.Position, 0, len(xmlPositions))
@@ -514,12 +1408,14 @@ func (d *downloader) convertPositions(xmlPositions []ibkrflexquery.XMLPosition,
 }
 
 // convertTransfers converts XML transfers to proto transfers, setting the account alias.
-func (d *downloader) convertTransfers(xmlTransfers []ibkrflexquery.XMLTransfer, accountAlias string) ([]*datav1.Transfer, error) {
+func (d *downloader) convertTransfers(xmlTransfers []ibkrflexquery.XMLTransfer, accountAlias string, quarantine *quarantine) ([]*datav1.Transfer, error) {
 	transfers := make([]*datav1.Transfer, 0, len(xmlTransfers))
 	for i := range xmlTransfers {
 		transfer, err := xmlTransferToProto(&xmlTransfers[i], accountAlias)
 		if err != nil {
-			d.logger.Warn("skipping unparseable transfer", "index", i, "error", err)
+			if err := d.skip(quarantine, "Transfer", i, &xmlTransfers[i], err); err != nil {
+				return nil, err
+			}
 			continue
 		}
 		transfers = append(transfers, transfer)
@@ -528,12 +1424,14 @@ func (d *downloader) convertTransfers(xmlTransfers []ibkrflexquery.XMLTransfer,
 }
 
 // convertTradeTransfers converts XML trade transfers to proto trade transfers.
-func (d *downloader) convertTradeTransfers(xmlTradeTransfers []ibkrflexquery.XMLTradeTransfer, accountAlias string) ([]*datav1.TradeTransfer, error) {
+func (d *downloader) convertTradeTransfers(xmlTradeTransfers []ibkrflexquery.XMLTradeTransfer, accountAlias string, quarantine *quarantine) ([]*datav1.TradeTransfer, error) {
 	tradeTransfers := make([]*datav1.TradeTransfer, 0, len(xmlTradeTransfers))
 	for i := range xmlTradeTransfers {
 		tt, err := xmlTradeTransferToProto(&xmlTradeTransfers[i], accountAlias)
 		if err != nil {
-			d.logger.Warn("skipping unparseable trade transfer", "index", i, "error", err)
+			if err := d.skip(quarantine, "TradeTransfer", i, &xmlTradeTransfers[i], err); err != nil {
+				return nil, err
+			}
 			continue
 		}
 		tradeTransfers = append(tradeTransfers, tt)
@@ -542,12 +1440,14 @@ func (d *downloader) convertTradeTransfers(xmlTradeTransfers []ibkrflexquery.XML
 }
 
 // convertCorporateActions converts XML corporate actions to proto corporate actions.
-func (d *downloader) convertCorporateActions(xmlActions []ibkrflexquery.XMLCorporateAction, accountAlias string) ([]*datav1.CorporateAction, error) {
+func (d *downloader) convertCorporateActions(xmlActions []ibkrflexquery.XMLCorporateAction, accountAlias string, quarantine *quarantine) ([]*datav1.CorporateAction, error) {
 	actions := make([]*datav1.CorporateAction, 0, len(xmlActions))
 	for i := range xmlActions {
 		action, err := xmlCorporateActionToProto(&xmlActions[i], accountAlias)
 		if err != nil {
-			d.logger.Warn("skipping unparseable corporate action", "index", i, "error", err)
+			if err := d.skip(quarantine, "CorporateAction", i, &xmlActions[i], err); err != nil {
+				return nil, err
+			}
 			continue
 		}
 		actions = append(actions, action)
@@ -556,33 +1456,138 @@ func (d *downloader) convertCorporateActions(xmlActions []ibkrflexquery.XMLCorpo
 }
 
 // convertCashPositions converts XML cash report entries to CashPosition protos.
-// Filters out zero-balance currencies and the BASE_SUMMARY row.
-func (d *downloader) convertCashPositions(xmlCashReport []ibkrflexquery.XMLCashReportCurrency, accountAlias string) []*datav1.CashPosition {
+// Filters out currencies with zero balances and the BASE_SUMMARY row.
+func (d *downloader) convertCashPositions(xmlCashReport []ibkrflexquery.XMLCashReportCurrency, accountAlias string, quarantine *quarantine) ([]*datav1.CashPosition, error) {
 	var cashPositions []*datav1.CashPosition
-	for _, cr := range xmlCashReport {
+	for i, cr := range xmlCashReport {
 		// Skip the BASE_SUMMARY aggregate row.
 		if cr.Currency == "BASE_SUMMARY" {
 			continue
 		}
 		// Use EndingCash as the balance (includes unsettled trades to match IBKR portal).
-		if cr.EndingCash == "" || cr.EndingCash == "0" {
-			continue
+		endingCash := cr.EndingCash
+		if endingCash == "" {
+			endingCash = "0"
 		}
-		balance, err := moneypb.NewProtoMoney(cr.Currency, cr.EndingCash)
+		balance, err := moneypb.NewProtoMoney(cr.Currency, endingCash)
 		if err != nil {
-			d.logger.Warn("skipping unparseable cash position", "currency", cr.Currency, "error", err)
+			if err := d.skip(quarantine, "CashReportCurrency", i, &xmlCashReport[i], err); err != nil {
+				return nil, err
+			}
 			continue
 		}
-		// Skip zero balances after parsing.
-		if moneypb.MoneyToMicros(balance) == 0 {
+		// EndingSettledCash excludes unsettled trades, for the settlement
+		// report. It is empty if the Flex Query does not include it.
+		var settledBalance *moneyv1.Money
+		if cr.EndingSettledCash != "" {
+			settledBalance, err = moneypb.NewProtoMoney(cr.Currency, cr.EndingSettledCash)
+			if err != nil {
+				if err := d.skip(quarantine, "CashReportCurrency", i, &xmlCashReport[i], err); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+		// Skip currencies without cash, settled or not.
+		if moneypb.MoneyToMicros(balance) == 0 && moneypb.MoneyToMicros(settledBalance) == 0 {
 			continue
 		}
 		cashPositions = append(cashPositions, &datav1.CashPosition{
-			AccountId: accountAlias,
-			Balance:   balance,
+			AccountId:      accountAlias,
+			Balance:        balance,
+			SettledBalance: settledBalance,
 		})
 	}
-	return cashPositions
+	return cashPositions, nil
+}
+
+// convertCashTransactions converts XML cash transactions to proto cash transactions.
+func (d *downloader) convertCashTransactions(xmlCashTransactions []ibkrflexquery.XMLCashTransaction, accountAlias string, quarantine *quarantine) ([]*datav1.CashTransaction, error) {
+	cashTransactions := make([]*datav1.CashTransaction, 0, len(xmlCashTransactions))
+	for i := range xmlCashTransactions {
+		cashTransaction, err := xmlCashTransactionToProto(&xmlCashTransactions[i], accountAlias)
+		if err != nil {
+			if err := d.skip(quarantine, "CashTransaction", i, &xmlCashTransactions[i], err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		cashTransactions = append(cashTransactions, cashTransaction)
+	}
+	return cashTransactions, nil
+}
+
+// convertDividendAccruals converts the XML changes in dividend accruals to the
+// open accruals, as proto cash transactions of type dividend dated on the pay
+// date with the accrued net amount. The changes of each dividend are summed,
+// so dividends whose accrual was reversed when they were paid are omitted.
+func (d *downloader) convertDividendAccruals(xmlDividendAccruals []ibkrflexquery.XMLDividendAccrual, accountAlias string, quarantine *quarantine) ([]*datav1.CashTransaction, error) {
+	type accrualKey struct {
+		symbol   string
+		currency string
+		exDate   string
+		payDate  string
+	}
+	var keys []accrualKey
+	keyToAccrual := make(map[accrualKey]*datav1.CashTransaction)
+	for i := range xmlDividendAccruals {
+		xmlDividendAccrual := &xmlDividendAccruals[i]
+		accrual, err := xmlDividendAccrualToProto(xmlDividendAccrual, accountAlias)
+		if err != nil {
+			if err := d.skip(quarantine, "ChangeInDividendAccrual", i, xmlDividendAccrual, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		key := accrualKey{
+			symbol:   xmlDividendAccrual.Symbol,
+			currency: xmlDividendAccrual.Currency,
+			exDate:   xmlDividendAccrual.ExDate,
+			payDate:  xmlDividendAccrual.PayDate,
+		}
+		existing, ok := keyToAccrual[key]
+		if !ok {
+			keys = append(keys, key)
+			keyToAccrual[key] = accrual
+			continue
+		}
+		existing.Amount = moneypb.MoneyAdd(existing.GetAmount(), accrual.GetAmount())
+	}
+	var dividendAccruals []*datav1.CashTransaction
+	for _, key := range keys {
+		if accrual := keyToAccrual[key]; moneypb.MoneyToMicros(accrual.GetAmount()) != 0 {
+			dividendAccruals = append(dividendAccruals, accrual)
+		}
+	}
+	return dividendAccruals, nil
+}
+
+// xmlDividendAccrualToProto converts an XML change in a dividend accrual to a
+// proto CashTransaction of type dividend dated on the pay date.
+func xmlDividendAccrualToProto(xmlDividendAccrual *ibkrflexquery.XMLDividendAccrual, accountAlias string) (*datav1.CashTransaction, error) {
+	payDate, err := parseIBKRDate(xmlDividendAccrual.PayDate)
+	if err != nil {
+		return nil, fmt.Errorf("parsing dividend accrual pay date %q: %w", xmlDividendAccrual.PayDate, err)
+	}
+	protoPayDate, err := timepb.NewProtoDate(payDate.Year(), payDate.Month(), payDate.Day())
+	if err != nil {
+		return nil, err
+	}
+	currencyCode := xmlDividendAccrual.Currency
+	amount, err := moneypb.NewProtoMoney(currencyCode, xmlDividendAccrual.NetAmount)
+	if err != nil {
+		return nil, fmt.Errorf("parsing dividend accrual net amount %q: %w", xmlDividendAccrual.NetAmount, err)
+	}
+	return &datav1.CashTransaction{
+		AccountId:     accountAlias,
+		Type:          datav1.CashTransactionType_CASH_TRANSACTION_TYPE_DIVIDEND,
+		Date:          protoPayDate,
+		Amount:        amount,
+		CurrencyCode:  currencyCode,
+		Symbol:        xmlDividendAccrual.Symbol,
+		Description:   "Accrued dividend, ex-date " + xmlDividendAccrual.ExDate,
+		TransactionId: xmlDividendAccrual.ActionID,
+	}, nil
 }
 
 // xmlTradeToProto converts an XML trade from the Flex Query response to a proto Trade.
@@ -643,9 +1648,70 @@ func xmlTradeToProto(xmlTrade *ibkrflexquery.XMLTrade, accountAlias string) (*da
 		Commission:      commission,
 		CurrencyCode:    currencyCode,
 		FifoPnlRealized: fifoPnlRealized,
+		OrderId:         xmlTrade.IBOrderID,
 	}, nil
 }
 
+// xmlLotToProto converts an XML closed lot from the Flex Query response to a proto ClosedLot.
+func xmlLotToProto(xmlLot *ibkrflexquery.XMLLot, accountAlias string) (*datav1.ClosedLot, error) {
+	closeDate, err := parseIBKRDate(xmlLot.TradeDate)
+	if err != nil {
+		return nil, fmt.Errorf("parsing closed lot trade date %q: %w", xmlLot.TradeDate, err)
+	}
+	protoCloseDate, err := timepb.NewProtoDate(closeDate.Year(), closeDate.Month(), closeDate.Day())
+	if err != nil {
+		return nil, err
+	}
+	// Parse the open date from the openDateTime field (format: YYYYMMDD or YYYYMMDD;HHMMSS).
+	openDateStr := xmlLot.OpenDateTime
+	if len(openDateStr) >= 8 {
+		openDateStr = openDateStr[:8]
+	}
+	openDate, err := parseIBKRDate(openDateStr)
+	if err != nil {
+		return nil, fmt.Errorf("parsing closed lot open date %q: %w", xmlLot.OpenDateTime, err)
+	}
+	protoOpenDate, err := timepb.NewProtoDate(openDate.Year(), openDate.Month(), openDate.Day())
+	if err != nil {
+		return nil, err
+	}
+	// The sign of the lot quantity is not consistent across IBKR reports, so
+	// derive it from the closing side: a sell closes a long lot, a buy closes a short lot.
+	quantityMicros := mathpb.ParseMicros(xmlLot.Quantity)
+	if quantityMicros < 0 {
+		quantityMicros = -quantityMicros
+	}
+	if parseTradeSide(xmlLot.BuySell) == datav1.TradeSide_TRADE_SIDE_BUY {
+		quantityMicros = -quantityMicros
+	}
+	currencyCode := xmlLot.Currency
+	closedLot := &datav1.ClosedLot{
+		AccountId:      accountAlias,
+		ClosingTradeId: xmlLot.TradeID,
+		Symbol:         xmlLot.Symbol,
+		AssetCategory:  xmlLot.AssetCategory,
+		OpenDate:       protoOpenDate,
+		CloseDate:      protoCloseDate,
+		Quantity:       mathpb.FromMicros(quantityMicros),
+		CurrencyCode:   currencyCode,
+	}
+	if xmlLot.Cost != "" {
+		cost, err := moneypb.NewProtoMoney(currencyCode, xmlLot.Cost)
+		if err != nil {
+			return nil, fmt.Errorf("parsing closed lot cost: %w", err)
+		}
+		closedLot.Cost = cost
+	}
+	if xmlLot.FifoPnlRealized != "" {
+		fifoPnlRealized, err := moneypb.NewProtoMoney(currencyCode, xmlLot.FifoPnlRealized)
+		if err != nil {
+			return nil, fmt.Errorf("parsing closed lot fifo pnl realized: %w", err)
+		}
+		closedLot.FifoPnlRealized = fifoPnlRealized
+	}
+	return closedLot, nil
+}
+
 // xmlPositionToProto converts an XML position from the Flex Query response to a proto Position.
 func xmlPositionToProto(xmlPosition *ibkrflexquery.XMLPosition, accountAlias string) (*datav1.Position, error) {
 	currencyCode := xmlPosition.Currency
@@ -842,6 +1908,39 @@ func xmlCorporateActionToProto(xmlAction *ibkrflexquery.XMLCorporateAction, acco
 	return action, nil
 }
 
+// xmlCashTransactionToProto converts an XML cash transaction to a proto CashTransaction.
+func xmlCashTransactionToProto(xmlCT *ibkrflexquery.XMLCashTransaction, accountAlias string) (*datav1.CashTransaction, error) {
+	// Parse the date from the dateTime field (format: YYYYMMDD or YYYYMMDD;HHMMSS).
+	dateStr := xmlCT.DateTime
+	if len(dateStr) >= 8 {
+		dateStr = dateStr[:8]
+	}
+	parsedDate, err := parseIBKRDate(dateStr)
+	if err != nil {
+		return nil, fmt.Errorf("parsing cash transaction date %q: %w", xmlCT.DateTime, err)
+	}
+	protoDate, err := timepb.NewProtoDate(parsedDate.Year(), parsedDate.Month(), parsedDate.Day())
+	if err != nil {
+		return nil, err
+	}
+	// Parse the signed amount in the transaction currency.
+	currencyCode := xmlCT.Currency
+	amount, err := moneypb.NewProtoMoney(currencyCode, xmlCT.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("parsing cash transaction amount %q: %w", xmlCT.Amount, err)
+	}
+	return &datav1.CashTransaction{
+		AccountId:     accountAlias,
+		Type:          parseCashTransactionType(xmlCT.Type),
+		Date:          protoDate,
+		Amount:        amount,
+		CurrencyCode:  currencyCode,
+		Symbol:        xmlCT.Symbol,
+		Description:   xmlCT.Description,
+		TransactionId: xmlCT.TransactionID,
+	}, nil
+}
+
 // parseTradeSide converts an IBKR buy/sell string to a TradeSide enum value.
 func parseTradeSide(s string) datav1.TradeSide {
 	switch s {
@@ -899,6 +1998,31 @@ func parseCorporateActionType(s string) datav1.CorporateActionType {
 	}
 }
 
+// parseCashTransactionType converts an IBKR cash transaction type string to a CashTransactionType enum value.
+// Unrecognized types map to OTHER so that no cash movement is dropped.
+func parseCashTransactionType(s string) datav1.CashTransactionType {
+	switch s {
+	case "Dividends":
+		return datav1.CashTransactionType_CASH_TRANSACTION_TYPE_DIVIDEND
+	case "Payment In Lieu Of Dividends":
+		return datav1.CashTransactionType_CASH_TRANSACTION_TYPE_PAYMENT_IN_LIEU
+	case "Withholding Tax":
+		return datav1.CashTransactionType_CASH_TRANSACTION_TYPE_WITHHOLDING_TAX
+	case "Broker Interest Received", "Bond Interest Received":
+		return datav1.CashTransactionType_CASH_TRANSACTION_TYPE_INTEREST_RECEIVED
+	case "Broker Interest Paid", "Bond Interest Paid":
+		return datav1.CashTransactionType_CASH_TRANSACTION_TYPE_INTEREST_PAID
+	case "Deposits/Withdrawals", "Deposits & Withdrawals":
+		return datav1.CashTransactionType_CASH_TRANSACTION_TYPE_DEPOSIT_WITHDRAWAL
+	case "Other Fees":
+		return datav1.CashTransactionType_CASH_TRANSACTION_TYPE_FEE
+	case "Commission Adjustments":
+		return datav1.CashTransactionType_CASH_TRANSACTION_TYPE_COMMISSION_ADJUSTMENT
+	default:
+		return datav1.CashTransactionType_CASH_TRANSACTION_TYPE_OTHER
+	}
+}
+
 // parseIBKRDate parses an IBKR date string in YYYYMMDD format.
 func parseIBKRDate(s string) (time.Time, error) {
 	return time.Parse("20060102", s)
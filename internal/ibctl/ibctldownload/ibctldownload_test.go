@@ -0,0 +1,37 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctldownload
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/pkg/ibkrflexquery"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertCashTransactions(t *testing.T) {
+	t.Parallel()
+	xmlCashTransactions := []ibkrflexquery.XMLCashTransaction{
+		{DateTime: "20250303;202000", Currency: "CAD", Type: "Dividends", Amount: "100", Symbol: "RY"},
+		{DateTime: "20250303;202000", Currency: "CAD", Type: "Dividends", Amount: "not a number", Symbol: "RY"},
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	// Unparseable records are skipped and quarantined.
+	d := &downloader{logger: logger, config: &ibctlconfig.Config{}}
+	skipped := &quarantine{}
+	cashTransactions, err := d.convertCashTransactions(xmlCashTransactions, "individual", skipped)
+	require.NoError(t, err)
+	require.Len(t, cashTransactions, 1)
+	require.Len(t, skipped.records, 1)
+	require.Equal(t, "CashTransaction", skipped.records[0].element)
+	require.Equal(t, 1, skipped.records[0].index)
+	// In strict mode, they fail the conversion.
+	d = &downloader{logger: logger, config: &ibctlconfig.Config{Strict: true}}
+	_, err = d.convertCashTransactions(xmlCashTransactions, "individual", &quarantine{})
+	require.ErrorContains(t, err, "converting CashTransaction 1 (strict mode)")
+}
@@ -9,12 +9,16 @@
 // protobuf JSON file using the ExchangeRate proto, one entry per date.
 //
 // The Store lazily loads rate files on first access per pair and caches
-// them in memory. For holdings display, the most recent rate is used.
+// them in memory. For holdings display, the most recent rate is used, or the
+// most recent rate on or before the as-of date for a Store created with
+// NewStoreAsOf. Historical amounts, such as realized gains and income, are
+// converted with ConvertToUSDOnDate at the rate in effect on their date.
 package ibctlfxrates
 
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
 	"sync"
 
 	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
@@ -22,21 +26,43 @@ import (
 	"github.com/bufdev/ibctl/internal/pkg/mathpb"
 	"github.com/bufdev/ibctl/internal/pkg/moneypb"
 	"github.com/bufdev/ibctl/internal/pkg/protoio"
+	"github.com/bufdev/ibctl/internal/pkg/timing"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
 )
 
 // microsFactor is the number of micros per unit (6 decimal places).
 const microsFactor = 1_000_000
 
+// Rate is the exchange rate used by a conversion, so converted values can be
+// verified against broker statements.
+type Rate struct {
+	// Pair is the currency pair as "BASE.QUOTE" (e.g., "CAD.USD").
+	Pair string `json:"pair"`
+	// Rate is the number of quote currency units per base currency unit, at
+	// the micros precision used for conversion.
+	Rate string `json:"rate"`
+	// Date is the date of the rate (YYYY-MM-DD).
+	Date string `json:"date"`
+	// Provider is the API the rate was downloaded from (e.g., "frankfurter").
+	Provider string `json:"provider"`
+}
+
 // Store provides FX rate lookups from per-pair rate files on disk.
 // Rate files are lazily loaded on first access and cached in memory.
 type Store struct {
 	// fxDirPath is the root FX data directory (e.g., data/v1/fx).
 	fxDirPath string
+	// asOf is the date (YYYY-MM-DD) after which rates are ignored, or empty for no limit.
+	asOf string
 	// mu protects the pairs map for concurrent lazy loading.
 	mu sync.Mutex
 	// pairs maps "BASE.QUOTE" to the loaded rate data for that pair.
 	// Nil value means the pair was attempted but no data was found.
 	pairs map[string]*pairData
+	// cacheHits is the number of pair lookups served from memory.
+	cacheHits int64
+	// cacheMisses is the number of pair lookups that read the rate file from disk.
+	cacheMisses int64
 }
 
 // NewStore creates a Store that reads from the FX directory.
@@ -48,6 +74,14 @@ func NewStore(fxDirPath string) *Store {
 	}
 }
 
+// NewStoreAsOf creates a Store that reads from the FX directory and ignores
+// rates after the date, so conversions use the rates in effect on that date.
+func NewStoreAsOf(fxDirPath string, asOf xtime.Date) *Store {
+	store := NewStore(fxDirPath)
+	store.asOf = asOf.String()
+	return store
+}
+
 // ConvertToUSD converts a Money value to USD using the most recent available rate.
 // Returns the USD value as a Money proto and true if the conversion succeeded.
 // Returns nil and false if the rate is not available for the currency.
@@ -69,24 +103,128 @@ func (s *Store) ConvertToUSD(money *moneyv1.Money) (*moneyv1.Money, bool) {
 	if rateMicros == 0 {
 		return nil, false
 	}
-	valueMicros := moneypb.MoneyToMicros(money)
-	// value_usd = value * rate. Divide first to avoid int64 overflow.
-	units := valueMicros / microsFactor
-	remainder := valueMicros % microsFactor
-	usdMicros := units*rateMicros + remainder*rateMicros/microsFactor
-	return moneypb.MoneyFromMicros("USD", usdMicros), true
+	return moneypb.MoneyFromMicros("USD", convertMicros(moneypb.MoneyToMicros(money), rateMicros)), true
+}
+
+// ConvertToUSDOnDate converts a Money value to USD using the most recent rate
+// on or before the date, so that amounts realized or paid on the date are
+// converted at the rate in effect then rather than today's rate. Returns nil
+// and false if no rate on or before the date is available for the currency.
+// USD values are returned as-is.
+func (s *Store) ConvertToUSDOnDate(money *moneyv1.Money, date xtime.Date) (*moneyv1.Money, bool) {
+	if money == nil {
+		return nil, false
+	}
+	currencyCode := money.GetCurrencyCode()
+	if currencyCode == "USD" {
+		return money, true
+	}
+	pair := s.loadPair(currencyCode, "USD")
+	if pair == nil {
+		return nil, false
+	}
+	rateMicros := pair.rateMicrosOnDate(date.String())
+	if rateMicros == 0 {
+		return nil, false
+	}
+	return moneypb.MoneyFromMicros("USD", convertMicros(moneypb.MoneyToMicros(money), rateMicros)), true
+}
+
+// RateToUSD returns the rate ConvertToUSD uses for the currency. Returns nil
+// and false for USD, which is not converted, and if no rate is available.
+func (s *Store) RateToUSD(currencyCode string) (*Rate, bool) {
+	if currencyCode == "USD" {
+		return nil, false
+	}
+	pair := s.loadPair(currencyCode, "USD")
+	if pair == nil || pair.latestRateMicros == 0 {
+		return nil, false
+	}
+	return &Rate{
+		Pair:     currencyCode + ".USD",
+		Rate:     mathpb.ToString(mathpb.FromMicros(pair.latestRateMicros)),
+		Date:     pair.latestDate,
+		Provider: pair.latestProvider,
+	}, true
+}
+
+// Convert converts a Money value to the currency using the most recent
+// available rate. The direct pair is used if available, otherwise the value is
+// converted through USD. Returns nil and false if no rate is available.
+func (s *Store) Convert(money *moneyv1.Money, currencyCode string) (*moneyv1.Money, bool) {
+	if money == nil {
+		return nil, false
+	}
+	if money.GetCurrencyCode() == currencyCode {
+		return money, true
+	}
+	if converted, ok := s.convertDirect(money, currencyCode); ok {
+		return converted, true
+	}
+	usdMoney, ok := s.ConvertToUSD(money)
+	if !ok {
+		return nil, false
+	}
+	if currencyCode == "USD" {
+		return usdMoney, true
+	}
+	return s.convertDirect(usdMoney, currencyCode)
+}
+
+// CacheStats returns the number of currency pair lookups served from the
+// in-memory cache and the number that read the pair's rate file from disk.
+func (s *Store) CacheStats() (hits int64, misses int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cacheHits, s.cacheMisses
 }
 
 // *** PRIVATE ***
 
+// convertDirect converts a Money value using the most recent rate of the direct
+// pair to the currency. Returns nil and false if the pair has no rates.
+func (s *Store) convertDirect(money *moneyv1.Money, currencyCode string) (*moneyv1.Money, bool) {
+	pair := s.loadPair(money.GetCurrencyCode(), currencyCode)
+	if pair == nil || pair.latestRateMicros == 0 {
+		return nil, false
+	}
+	return moneypb.MoneyFromMicros(currencyCode, convertMicros(moneypb.MoneyToMicros(money), pair.latestRateMicros)), true
+}
+
+// convertMicros returns the value in micros multiplied by the rate in micros.
+func convertMicros(valueMicros int64, rateMicros int64) int64 {
+	// Divide first to avoid int64 overflow.
+	units := valueMicros / microsFactor
+	remainder := valueMicros % microsFactor
+	return units*rateMicros + remainder*rateMicros/microsFactor
+}
+
 // pairData holds the loaded rate data for a single currency pair.
 type pairData struct {
 	// latestRateMicros is the most recent rate in micros.
 	latestRateMicros int64
 	// latestDate is the date string of the most recent rate.
 	latestDate string
+	// latestProvider is the provider of the most recent rate.
+	latestProvider string
 	// rates maps date strings (YYYY-MM-DD) to rate micros for date-specific lookups.
 	rates map[string]int64
+	// dates is the sorted keys of rates.
+	dates []string
+}
+
+// rateMicrosOnDate returns the rate on the date (YYYY-MM-DD), or the most
+// recent rate before it for weekends and holidays. Returns 0 if there is none.
+func (p *pairData) rateMicrosOnDate(date string) int64 {
+	// Find the first date on or after the date.
+	i := sort.SearchStrings(p.dates, date)
+	if i < len(p.dates) && p.dates[i] == date {
+		return p.rates[date]
+	}
+	if i == 0 {
+		return 0
+	}
+	return p.rates[p.dates[i-1]]
 }
 
 // loadPair lazily loads the rate file for a currency pair, returning the
@@ -97,8 +235,11 @@ func (s *Store) loadPair(base string, quote string) *pairData {
 	defer s.mu.Unlock()
 	// Return cached data if already loaded (even if nil = no data found).
 	if pair, loaded := s.pairs[pairKey]; loaded {
+		s.cacheHits++
 		return pair
 	}
+	s.cacheMisses++
+	defer timing.Start("fx load")()
 	// Load the rates file for this pair from disk.
 	ratesPath := filepath.Join(s.fxDirPath, pairKey, "rates.json")
 	rates, err := protoio.ReadMessagesJSON(ratesPath, func() *datav1.ExchangeRate { return &datav1.ExchangeRate{} })
@@ -113,14 +254,28 @@ func (s *Store) loadPair(base string, quote string) *pairData {
 	}
 	for _, rate := range rates {
 		dateStr := fmt.Sprintf("%04d-%02d-%02d", rate.GetDate().GetYear(), rate.GetDate().GetMonth(), rate.GetDate().GetDay())
+		if s.asOf != "" && dateStr > s.asOf {
+			continue
+		}
 		rateMicros := mathpb.ToMicros(rate.GetRate())
 		pair.rates[dateStr] = rateMicros
 		// Track the most recent rate for "latest" lookups.
 		if pair.latestDate == "" || dateStr > pair.latestDate {
 			pair.latestRateMicros = rateMicros
 			pair.latestDate = dateStr
+			pair.latestProvider = rate.GetProvider()
 		}
 	}
+	if len(pair.rates) == 0 {
+		// All rates are after the as-of date.
+		s.pairs[pairKey] = nil
+		return nil
+	}
+	pair.dates = make([]string, 0, len(pair.rates))
+	for dateStr := range pair.rates {
+		pair.dates = append(pair.dates, dateStr)
+	}
+	sort.Strings(pair.dates)
 	s.pairs[pairKey] = pair
 	return pair
 }
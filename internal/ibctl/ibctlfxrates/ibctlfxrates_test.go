@@ -0,0 +1,88 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctlfxrates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	timev1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/time/v1"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/bufdev/ibctl/internal/pkg/protoio"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateToUSD(t *testing.T) {
+	t.Parallel()
+	fxDirPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(fxDirPath, "CAD.USD"), 0o755))
+	require.NoError(t, protoio.WriteMessagesJSON(filepath.Join(fxDirPath, "CAD.USD", "rates.json"), []*datav1.ExchangeRate{
+		newExchangeRate(t, 2025, 3, 3, "0.7", "frankfurter"),
+		newExchangeRate(t, 2025, 3, 4, "0.72", "frankfurter"),
+	}))
+	store := NewStore(fxDirPath)
+	rate, ok := store.RateToUSD("CAD")
+	require.True(t, ok)
+	require.Equal(t, &Rate{Pair: "CAD.USD", Rate: "0.72", Date: "2025-03-04", Provider: "frankfurter"}, rate)
+	// The rate is the one ConvertToUSD uses.
+	converted, ok := store.ConvertToUSD(moneypb.MoneyFromMicros("CAD", 100_000_000))
+	require.True(t, ok)
+	require.Equal(t, int64(72_000_000), moneypb.MoneyToMicros(converted))
+	// USD is not converted, and currencies without rates have no rate.
+	_, ok = store.RateToUSD("USD")
+	require.False(t, ok)
+	_, ok = store.RateToUSD("EUR")
+	require.False(t, ok)
+	// Stores as of a date use the rate in effect on the date.
+	rate, ok = NewStoreAsOf(fxDirPath, xtime.Date{Year: 2025, Month: 3, Day: 3}).RateToUSD("CAD")
+	require.True(t, ok)
+	require.Equal(t, "0.7", rate.Rate)
+	require.Equal(t, "2025-03-03", rate.Date)
+}
+
+func TestConvertToUSDOnDate(t *testing.T) {
+	t.Parallel()
+	fxDirPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(fxDirPath, "CAD.USD"), 0o755))
+	require.NoError(t, protoio.WriteMessagesJSON(filepath.Join(fxDirPath, "CAD.USD", "rates.json"), []*datav1.ExchangeRate{
+		// Friday and Monday.
+		newExchangeRate(t, 2025, 3, 7, "0.7", "frankfurter"),
+		newExchangeRate(t, 2025, 3, 10, "0.72", "frankfurter"),
+	}))
+	store := NewStore(fxDirPath)
+	cad := moneypb.MoneyFromMicros("CAD", 100_000_000)
+	converted, ok := store.ConvertToUSDOnDate(cad, xtime.Date{Year: 2025, Month: 3, Day: 7})
+	require.True(t, ok)
+	require.Equal(t, int64(70_000_000), moneypb.MoneyToMicros(converted))
+	// Weekends use the rate of the Friday before.
+	converted, ok = store.ConvertToUSDOnDate(cad, xtime.Date{Year: 2025, Month: 3, Day: 9})
+	require.True(t, ok)
+	require.Equal(t, int64(70_000_000), moneypb.MoneyToMicros(converted))
+	converted, ok = store.ConvertToUSDOnDate(cad, xtime.Date{Year: 2025, Month: 3, Day: 10})
+	require.True(t, ok)
+	require.Equal(t, int64(72_000_000), moneypb.MoneyToMicros(converted))
+	// There is no rate before the first date.
+	_, ok = store.ConvertToUSDOnDate(cad, xtime.Date{Year: 2025, Month: 3, Day: 6})
+	require.False(t, ok)
+	converted, ok = store.ConvertToUSDOnDate(moneypb.MoneyFromMicros("USD", 1_000_000), xtime.Date{Year: 2025, Month: 3, Day: 6})
+	require.True(t, ok)
+	require.Equal(t, int64(1_000_000), moneypb.MoneyToMicros(converted))
+}
+
+func newExchangeRate(t *testing.T, year uint32, month uint32, day uint32, value string, provider string) *datav1.ExchangeRate {
+	rate, err := mathpb.NewDecimal(value)
+	require.NoError(t, err)
+	return &datav1.ExchangeRate{
+		Date:              &timev1.Date{Year: year, Month: month, Day: day},
+		BaseCurrencyCode:  "CAD",
+		QuoteCurrencyCode: "USD",
+		Rate:              rate,
+		Provider:          provider,
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctlghostfolio converts merged IBKR activity to Ghostfolio activities.
+package ibctlghostfolio
+
+import (
+	"fmt"
+	"time"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/pkg/ghostfolio"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+)
+
+// assetCategoryStock is the IBKR asset category for stocks and ETFs.
+const assetCategoryStock = "STK"
+
+// ExportFile is the Ghostfolio JSON import file format.
+type ExportFile struct {
+	// Meta describes the export.
+	Meta ExportMeta `json:"meta"`
+	// Activities is the list of activities.
+	Activities []ghostfolio.Activity `json:"activities"`
+}
+
+// ExportMeta describes an export file.
+type ExportMeta struct {
+	// Date is the time of the export in ISO 8601 format.
+	Date string `json:"date"`
+	// Version is the name of the exporting program.
+	Version string `json:"version"`
+}
+
+// NewExportFile returns an ExportFile for the activities, exported at now.
+func NewExportFile(activities []ghostfolio.Activity, now time.Time) *ExportFile {
+	return &ExportFile{
+		Meta: ExportMeta{
+			Date:    now.UTC().Format(time.RFC3339),
+			Version: "ibctl",
+		},
+		Activities: activities,
+	}
+}
+
+// GetActivities returns the Ghostfolio activities for the trades and cash
+// transactions in mergedData. accountIDs maps account aliases to Ghostfolio
+// account IDs; activities of unmapped aliases have no account ID.
+//
+// Only stock trades (IBKR asset category STK, which includes ETFs) are
+// included, since Ghostfolio cannot price options, futures, or bonds from
+// IBKR symbols. Withholding tax, fees, and interest paid are fees, and
+// deposits, withdrawals, and reversals (negative dividends and interest) are
+// not included, since Ghostfolio has no activity types for them.
+func GetActivities(mergedData *ibctlmerge.MergedData, accountIDs map[string]string) []ghostfolio.Activity {
+	var activities []ghostfolio.Activity
+	for _, trade := range mergedData.Trades {
+		if trade.GetAssetCategory() != assetCategoryStock {
+			continue
+		}
+		quantityMicros := mathpb.ToMicros(trade.GetQuantity())
+		if quantityMicros == 0 {
+			continue
+		}
+		activityType := ghostfolio.ActivityTypeBuy
+		if quantityMicros < 0 {
+			activityType = ghostfolio.ActivityTypeSell
+		}
+		quantity := microsToFloat(abs(quantityMicros))
+		activities = append(activities, ghostfolio.Activity{
+			AccountID:  accountIDs[trade.GetAccountId()],
+			Comment:    "IBKR trade " + trade.GetTradeId(),
+			Currency:   trade.GetCurrencyCode(),
+			DataSource: ghostfolio.DataSourceYahoo,
+			Date:       protoDateISO(trade.GetTradeDate()),
+			Fee:        microsToFloat(abs(moneypb.MoneyToMicros(trade.GetCommission()))),
+			Quantity:   quantity,
+			Symbol:     trade.GetSymbol(),
+			Type:       activityType,
+			// Derived from the proceeds so that quantity * unit price is the trade value.
+			UnitPrice: microsToFloat(abs(moneypb.MoneyToMicros(trade.GetProceeds()))) / quantity,
+		})
+	}
+	for _, cashTransaction := range mergedData.CashTransactions {
+		activity, ok := cashTransactionToActivity(cashTransaction)
+		if !ok {
+			continue
+		}
+		activity.AccountID = accountIDs[cashTransaction.GetAccountId()]
+		activities = append(activities, activity)
+	}
+	return activities
+}
+
+// *** PRIVATE ***
+
+func cashTransactionToActivity(cashTransaction *datav1.CashTransaction) (ghostfolio.Activity, bool) {
+	amountMicros := moneypb.MoneyToMicros(cashTransaction.GetAmount())
+	activity := ghostfolio.Activity{
+		Currency: cashTransaction.GetCurrencyCode(),
+		Date:     protoDateISO(cashTransaction.GetDate()),
+	}
+	if cashTransaction.GetTransactionId() != "" {
+		activity.Comment = "IBKR transaction " + cashTransaction.GetTransactionId()
+	}
+	switch cashTransaction.GetType() {
+	case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_DIVIDEND,
+		datav1.CashTransactionType_CASH_TRANSACTION_TYPE_PAYMENT_IN_LIEU:
+		if amountMicros <= 0 || cashTransaction.GetSymbol() == "" {
+			return ghostfolio.Activity{}, false
+		}
+		activity.Type = ghostfolio.ActivityTypeDividend
+		activity.DataSource = ghostfolio.DataSourceYahoo
+		activity.Symbol = cashTransaction.GetSymbol()
+		activity.Quantity = 1
+		activity.UnitPrice = microsToFloat(amountMicros)
+	case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_INTEREST_RECEIVED:
+		if amountMicros <= 0 {
+			return ghostfolio.Activity{}, false
+		}
+		activity.Type = ghostfolio.ActivityTypeInterest
+		activity.DataSource = ghostfolio.DataSourceManual
+		activity.Symbol = "Interest"
+		activity.Quantity = 1
+		activity.UnitPrice = microsToFloat(amountMicros)
+	case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_WITHHOLDING_TAX,
+		datav1.CashTransactionType_CASH_TRANSACTION_TYPE_FEE,
+		datav1.CashTransactionType_CASH_TRANSACTION_TYPE_INTEREST_PAID:
+		if amountMicros >= 0 {
+			return ghostfolio.Activity{}, false
+		}
+		activity.Type = ghostfolio.ActivityTypeFee
+		activity.DataSource = ghostfolio.DataSourceManual
+		activity.Symbol = feeName(cashTransaction)
+		activity.Fee = microsToFloat(-amountMicros)
+	default:
+		return ghostfolio.Activity{}, false
+	}
+	return activity, true
+}
+
+// feeName returns the name of the manual symbol for a fee.
+func feeName(cashTransaction *datav1.CashTransaction) string {
+	switch cashTransaction.GetType() {
+	case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_WITHHOLDING_TAX:
+		if cashTransaction.GetSymbol() != "" {
+			return "Withholding tax " + cashTransaction.GetSymbol()
+		}
+		return "Withholding tax"
+	case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_INTEREST_PAID:
+		return "Interest paid"
+	default:
+		return "Fees"
+	}
+}
+
+func microsToFloat(micros int64) float64 {
+	return float64(micros) / 1_000_000
+}
+
+func abs(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// protoDateISO returns an ISO 8601 timestamp at midnight UTC of a proto Date.
+func protoDateISO(d interface {
+	GetYear() uint32
+	GetMonth() uint32
+	GetDay() uint32
+}) string {
+	return fmt.Sprintf("%04d-%02d-%02dT00:00:00.000Z", d.GetYear(), d.GetMonth(), d.GetDay())
+}
@@ -0,0 +1,161 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctlgit commits the persistent data of an ibctl directory to git
+// and reads its history, using the git command-line tool.
+//
+// Only data/ is committed, without data/backups/, since git history already
+// covers what the rolling backups do. Other changes in the repository, staged
+// or not, are left alone, so the ibctl directory can be part of a repository
+// that is also used for other files.
+package ibctlgit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// DefaultCommitMessage is the default auto-commit message template.
+const DefaultCommitMessage = "ibctl download: {new_trades} new trades, {updated_positions} updated positions"
+
+// dataPathspecs are the pathspecs of the committed data, relative to the ibctl directory.
+var dataPathspecs = []string{"data", ":(exclude)data/backups"}
+
+// Commit is a commit that changed the data.
+type Commit struct {
+	// Hash is the abbreviated commit hash.
+	Hash string `json:"hash"`
+	// Time is the author time in RFC 3339 format.
+	Time string `json:"time"`
+	// Message is the subject line of the commit message.
+	Message string `json:"message"`
+	// FilesChanged is the number of data files changed by the commit.
+	FilesChanged int `json:"files_changed"`
+}
+
+// CommitHeaders returns the column headers for commit table/CSV output.
+func CommitHeaders() []string {
+	return []string{"COMMIT", "TIME", "FILES", "MESSAGE"}
+}
+
+// CommitToRow converts a Commit to a string slice for table/CSV output.
+func CommitToRow(c *Commit) []string {
+	return []string{
+		c.Hash,
+		c.Time,
+		strconv.Itoa(c.FilesChanged),
+		c.Message,
+	}
+}
+
+// FormatCommitMessage replaces the {name} placeholders in the template with
+// their values. Unknown placeholders are left as is.
+func FormatCommitMessage(template string, values map[string]int) string {
+	oldnew := make([]string, 0, 2*len(values))
+	for name, value := range values {
+		oldnew = append(oldnew, "{"+name+"}", strconv.Itoa(value))
+	}
+	return strings.NewReplacer(oldnew...).Replace(template)
+}
+
+// CommitData commits the changes to the data of the ibctl directory with the
+// message, initializing a repository in dirPath if it is not already in one.
+// Returns the abbreviated hash of the new commit, or empty if the data had no
+// changes.
+func CommitData(ctx context.Context, dirPath string, message string) (string, error) {
+	if _, err := runGit(ctx, dirPath, "rev-parse", "--is-inside-work-tree"); err != nil {
+		if _, err := runGit(ctx, dirPath, "init", "--quiet"); err != nil {
+			return "", err
+		}
+	}
+	if _, err := runGit(ctx, dirPath, append([]string{"add", "--all", "--"}, dataPathspecs...)...); err != nil {
+		return "", err
+	}
+	// git diff --quiet exits with 1 if there are staged changes.
+	_, err := runGit(ctx, dirPath, append([]string{"diff", "--cached", "--quiet", "--"}, dataPathspecs...)...)
+	if err == nil {
+		return "", nil
+	}
+	var exitError *exec.ExitError
+	if !errors.As(err, &exitError) || exitError.ExitCode() != 1 {
+		return "", err
+	}
+	// Only commit the data, even if other changes are staged.
+	if _, err := runGit(ctx, dirPath, append([]string{"commit", "--quiet", "--message", message, "--"}, dataPathspecs...)...); err != nil {
+		return "", err
+	}
+	output, err := runGit(ctx, dirPath, "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// Log returns the most recent commits that changed the data of the ibctl
+// directory, newest first. If limit is positive, at most limit commits are
+// returned.
+func Log(ctx context.Context, dirPath string, limit int) ([]*Commit, error) {
+	args := []string{"log", "--format=%x1e%h%x1f%aI%x1f%s", "--shortstat"}
+	if limit > 0 {
+		args = append(args, "--max-count="+strconv.Itoa(limit))
+	}
+	output, err := runGit(ctx, dirPath, append(append(args, "--"), dataPathspecs...)...)
+	if err != nil {
+		return nil, err
+	}
+	return parseLog(output)
+}
+
+// *** PRIVATE ***
+
+// runGit runs git in dirPath and returns its stdout. The error includes the
+// stderr of git, and wraps an *exec.ExitError if git ran and failed.
+func runGit(ctx context.Context, dirPath string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dirPath}, args...)...)
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("git %s: %w: %s", args[0], err, message)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// parseLog parses the output of git log with one record per commit, each
+// starting with a record separator and having unit-separated fields, followed
+// by the --shortstat line.
+func parseLog(output string) ([]*Commit, error) {
+	var commits []*Commit
+	for record := range strings.SplitSeq(output, "\x1e") {
+		record = strings.TrimSpace(record)
+		if record == "" {
+			continue
+		}
+		header, stat, _ := strings.Cut(record, "\n")
+		fields := strings.Split(header, "\x1f")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected git log record: %q", header)
+		}
+		commit := &Commit{
+			Hash:    fields[0],
+			Time:    fields[1],
+			Message: fields[2],
+		}
+		// The stat line starts with the number of files changed (e.g., " 3 files changed, ...").
+		if filesChanged, _, ok := strings.Cut(strings.TrimSpace(stat), " "); ok {
+			commit.FilesChanged, _ = strconv.Atoi(filesChanged)
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctlgit
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatCommitMessage(t *testing.T) {
+	t.Parallel()
+	require.Equal(
+		t,
+		"ibctl download: 3 new trades, 1 updated positions {unknown}",
+		FormatCommitMessage(DefaultCommitMessage+" {unknown}", map[string]int{"new_trades": 3, "updated_positions": 1}),
+	)
+}
+
+func TestCommitDataAndLog(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	// Not parallel: the git identity is set in the environment.
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	ctx := context.Background()
+	dirPath := t.TempDir()
+	writeTestFile(t, filepath.Join(dirPath, "data", "accounts", "rrsp", "trades.json"), "{}\n")
+	writeTestFile(t, filepath.Join(dirPath, "data", "backups", "1", "trades.json"), "{}\n")
+	writeTestFile(t, filepath.Join(dirPath, "notes.txt"), "not data\n")
+	hash, err := CommitData(ctx, dirPath, "first")
+	require.NoError(t, err)
+	require.NotEmpty(t, hash)
+	// Nothing changed, so there is nothing to commit.
+	hash, err = CommitData(ctx, dirPath, "empty")
+	require.NoError(t, err)
+	require.Empty(t, hash)
+	writeTestFile(t, filepath.Join(dirPath, "data", "accounts", "rrsp", "trades.json"), "{}\n{}\n")
+	writeTestFile(t, filepath.Join(dirPath, "data", "accounts", "rrsp", "closed_lots.json"), "{}\n")
+	hash, err = CommitData(ctx, dirPath, "second")
+	require.NoError(t, err)
+	require.NotEmpty(t, hash)
+	commits, err := Log(ctx, dirPath, 0)
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	require.Equal(t, hash, commits[0].Hash)
+	require.Equal(t, "second", commits[0].Message)
+	require.Equal(t, 2, commits[0].FilesChanged)
+	require.Equal(t, "first", commits[1].Message)
+	// Backups and files outside data/ are not committed.
+	require.Equal(t, 1, commits[1].FilesChanged)
+	commits, err = Log(ctx, dirPath, 1)
+	require.NoError(t, err)
+	require.Len(t, commits, 1)
+}
+
+func writeTestFile(t *testing.T, filePath string, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0o755))
+	require.NoError(t, os.WriteFile(filePath, []byte(content), 0o644))
+}
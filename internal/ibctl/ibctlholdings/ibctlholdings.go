@@ -10,24 +10,39 @@
 package ibctlholdings
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
 	mathv1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/math/v1"
+	moneyv1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/money/v1"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlnotes"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlprices"
 	"github.com/bufdev/ibctl/internal/ibctl/ibctltaxlot"
 	"github.com/bufdev/ibctl/internal/pkg/cliio"
 	"github.com/bufdev/ibctl/internal/pkg/mathpb"
 	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/bufdev/ibctl/internal/pkg/pricestore"
+	"github.com/bufdev/ibctl/internal/pkg/timepb"
 	"github.com/bufdev/ibctl/internal/standard/xtime"
 )
 
 // assetCategoryCash is the IBKR asset category for cash/FX positions.
 const assetCategoryCash = "CASH"
 
+// asOfPriceLookbackDays is the number of days before an as-of date that a
+// closing price may be from, covering weekends and holidays. Older prices are
+// not the price on the date.
+const asOfPriceLookbackDays = 7
+
 // assetCategoryBond is the IBKR asset category for bond positions.
 // Bond prices are percentages of par, so market value and P&L are divided by 100.
 const assetCategoryBond = "BOND"
@@ -57,13 +72,14 @@ type HoldingOverview struct {
 	LastPriceUSD string `json:"last_price_usd,omitempty"`
 	// AveragePriceUSD is the average cost basis price converted to USD.
 	AveragePriceUSD string `json:"average_price_usd,omitempty"`
-	// MarketValueUSD is position * last price USD.
+	// MarketValueUSD is position * last price USD. For futures and CFDs, which
+	// are margined, this is the unrealized P&L rather than the notional value.
 	MarketValueUSD string `json:"market_value_usd,omitempty"`
 	// UnrealizedPnLUSD is (last price USD - avg price USD) * position.
 	UnrealizedPnLUSD string `json:"unrealized_pnl_usd,omitempty"`
-	// STCGUSD is the short-term (<365 days) unrealized P&L in USD, computed per lot.
+	// STCGUSD is the short-term unrealized P&L in USD, computed per lot.
 	STCGUSD string `json:"stcg_usd,omitempty"`
-	// LTCGUSD is the long-term (>=365 days) unrealized P&L in USD, computed per lot.
+	// LTCGUSD is the long-term unrealized P&L in USD, computed per lot.
 	LTCGUSD string `json:"ltcg_usd,omitempty"`
 	// Position is the total quantity held.
 	Position *mathv1.Decimal `json:"position"`
@@ -75,11 +91,35 @@ type HoldingOverview struct {
 	Sector string `json:"sector,omitempty"`
 	// Geo is the user-defined geographic classification (e.g., "US", "INTL").
 	Geo string `json:"geo,omitempty"`
+	// NotionalUSD is position * last price USD * contract multiplier for
+	// futures and CFDs, and empty otherwise.
+	NotionalUSD string `json:"notional_usd,omitempty"`
+	// MarginUSD is the initial margin of the position in USD for futures and
+	// CFDs with a margin configured in ibctl.yaml, and empty otherwise.
+	MarginUSD string `json:"margin_usd,omitempty"`
+	// FXRate is the rate the USD values were converted with, set by AddFXRates.
+	FXRate *ibctlfxrates.Rate `json:"fx_rate,omitempty"`
+	// PriceSource is "manual" if the last price is a price override from
+	// data/prices/overrides.yaml, and empty for IBKR market prices.
+	PriceSource string `json:"price_source,omitempty"`
+	// PriceDate is the date of a manual price override (YYYY-MM-DD).
+	PriceDate string `json:"price_date,omitempty"`
+	// DividendsPerShareUSD is the dividends per share paid in the trailing
+	// twelve months in USD, set by AddIncomeYields.
+	DividendsPerShareUSD string `json:"dividends_per_share_usd,omitempty"`
+	// Yield is DividendsPerShareUSD / LastPriceUSD (e.g., "2.31%").
+	Yield string `json:"yield,omitempty"`
+	// ProjectedIncomeUSD is DividendsPerShareUSD * position, the annual
+	// dividend income if the trailing dividends continue.
+	ProjectedIncomeUSD string `json:"projected_income_usd,omitempty"`
 }
 
+// PriceSourceManual is the price source of prices pinned in data/prices/overrides.yaml.
+const PriceSourceManual = "manual"
+
 // HoldingsOverviewHeaders returns the column headers for table/CSV output.
 func HoldingsOverviewHeaders() []string {
-	return []string{"SYMBOL", "CURRENCY", "LAST PRICE", "AVG PRICE", "LAST USD", "AVG USD", "MKT VAL USD", "UNRLZD P&L USD", "STCG USD", "LTCG USD", "POSITION", "CATEGORY", "TYPE", "SECTOR", "GEO"}
+	return []string{"SYMBOL", "CURRENCY", "LAST PRICE", "AVG PRICE", "LAST USD", "AVG USD", "MKT VAL USD", "UNRLZD P&L USD", "STCG USD", "LTCG USD", "POSITION", "CATEGORY", "TYPE", "SECTOR", "GEO", "NOTIONAL USD", "MARGIN USD", "YIELD", "PROJ INCOME USD"}
 }
 
 // HoldingOverviewToRow converts a HoldingOverview to a string slice for CSV output.
@@ -101,16 +141,21 @@ func HoldingOverviewToRow(h *HoldingOverview) []string {
 		h.Type,
 		h.Sector,
 		h.Geo,
+		h.NotionalUSD,
+		h.MarginUSD,
+		h.Yield,
+		h.ProjectedIncomeUSD,
 	}
 }
 
 // HoldingOverviewToTableRow converts a HoldingOverview to a string slice for
-// table display. USD values are rounded to cents with $ prefix and comma separators.
+// table display. USD values are rounded to cents with $ prefix and comma
+// separators, and manual prices are marked with their date.
 func HoldingOverviewToTableRow(h *HoldingOverview) []string {
 	return []string{
 		h.Symbol,
 		h.Currency,
-		h.LastPrice,
+		formatLastPrice(h.LastPrice, h.PriceSource, h.PriceDate),
 		h.AveragePrice,
 		cliio.FormatUSD(h.LastPriceUSD),
 		cliio.FormatUSD(h.AveragePriceUSD),
@@ -123,37 +168,92 @@ func HoldingOverviewToTableRow(h *HoldingOverview) []string {
 		h.Type,
 		h.Sector,
 		h.Geo,
+		cliio.FormatUSD(h.NotionalUSD),
+		cliio.FormatUSD(h.MarginUSD),
+		h.Yield,
+		cliio.FormatUSD(h.ProjectedIncomeUSD),
 	}
 }
 
-// Totals holds the formatted total values for the summary row.
+// Totals holds the total values for the summary row. Values are decimal
+// strings, formatted with cliio.FormatUSD for table display.
 type Totals struct {
 	// MarketValueUSD is the total market value across all holdings.
-	MarketValueUSD string
+	MarketValueUSD string `json:"market_value_usd"`
 	// UnrealizedPnLUSD is the total unrealized P&L across all holdings.
-	UnrealizedPnLUSD string
+	UnrealizedPnLUSD string `json:"unrealized_pnl_usd"`
 	// STCGUSD is the total short-term unrealized P&L across all holdings.
-	STCGUSD string
+	STCGUSD string `json:"stcg_usd"`
 	// LTCGUSD is the total long-term unrealized P&L across all holdings.
-	LTCGUSD string
+	LTCGUSD string `json:"ltcg_usd"`
+	// Yield is ProjectedIncomeUSD / MarketValueUSD, the income yield of the
+	// holdings (e.g., "1.85%"), or empty if the market value is 0.
+	Yield string `json:"yield"`
+	// ProjectedIncomeUSD is the total projected annual dividend income across all holdings.
+	ProjectedIncomeUSD string `json:"projected_income_usd"`
 }
 
 // ComputeTotals sums the USD value columns across all holdings.
-// Returns formatted USD strings (rounded to cents with $ prefix).
+// Totals of large bond face values and crypto quantities do not overflow.
 func ComputeTotals(holdings []*HoldingOverview) *Totals {
-	var totalMktValMicros, totalPnLMicros, totalSTCGMicros, totalLTCGMicros int64
+	totals := &Totals{
+		MarketValueUSD:     "0",
+		UnrealizedPnLUSD:   "0",
+		STCGUSD:            "0",
+		LTCGUSD:            "0",
+		ProjectedIncomeUSD: "0",
+	}
 	for _, h := range holdings {
-		totalMktValMicros += mathpb.ParseMicros(h.MarketValueUSD)
-		totalPnLMicros += mathpb.ParseMicros(h.UnrealizedPnLUSD)
-		totalSTCGMicros += mathpb.ParseMicros(h.STCGUSD)
-		totalLTCGMicros += mathpb.ParseMicros(h.LTCGUSD)
+		totals.MarketValueUSD = addTotalString(totals.MarketValueUSD, h.MarketValueUSD)
+		totals.UnrealizedPnLUSD = addTotalString(totals.UnrealizedPnLUSD, h.UnrealizedPnLUSD)
+		totals.STCGUSD = addTotalString(totals.STCGUSD, h.STCGUSD)
+		totals.LTCGUSD = addTotalString(totals.LTCGUSD, h.LTCGUSD)
+		totals.ProjectedIncomeUSD = addTotalString(totals.ProjectedIncomeUSD, h.ProjectedIncomeUSD)
 	}
-	return &Totals{
-		MarketValueUSD:   cliio.FormatUSDMicros(totalMktValMicros),
-		UnrealizedPnLUSD: cliio.FormatUSDMicros(totalPnLMicros),
-		STCGUSD:          cliio.FormatUSDMicros(totalSTCGMicros),
-		LTCGUSD:          cliio.FormatUSDMicros(totalLTCGMicros),
+	totals.Yield = yieldString(totals.ProjectedIncomeUSD, totals.MarketValueUSD)
+	return totals
+}
+
+// TotalsToRow converts Totals to a TOTAL row aligned with
+// HoldingsOverviewHeaders for CSV output.
+func TotalsToRow(totals *Totals) []string {
+	row := make([]string, len(HoldingsOverviewHeaders()))
+	row[0] = "TOTAL"
+	row[6] = totals.MarketValueUSD
+	row[7] = totals.UnrealizedPnLUSD
+	row[8] = totals.STCGUSD
+	row[9] = totals.LTCGUSD
+	row[17] = totals.Yield
+	row[18] = totals.ProjectedIncomeUSD
+	return row
+}
+
+// TotalsToTableRow converts Totals to a TOTAL row aligned with
+// HoldingsOverviewHeaders for table display.
+func TotalsToTableRow(totals *Totals) []string {
+	row := TotalsToRow(totals)
+	for i := 6; i <= 9; i++ {
+		row[i] = cliio.FormatUSD(row[i])
 	}
+	row[18] = cliio.FormatUSD(row[18])
+	return row
+}
+
+// AccountTotalsToRow converts the Totals of an account's holdings to a
+// subtotal row for CSV output, aligned with HoldingsOverviewHeaders. The
+// symbol column is "SUBTOTAL <alias>".
+func AccountTotalsToRow(accountAlias string, totals *Totals) []string {
+	row := TotalsToRow(totals)
+	row[0] = "SUBTOTAL " + accountAlias
+	return row
+}
+
+// AccountTotalsToTableRow converts the Totals of an account's holdings to a
+// subtotal row for table display, with USD values formatted.
+func AccountTotalsToTableRow(accountAlias string, totals *Totals) []string {
+	row := TotalsToTableRow(totals)
+	row[0] = "SUBTOTAL " + accountAlias
+	return row
 }
 
 // LotListResult contains the lot list output for a single symbol.
@@ -186,9 +286,9 @@ type LotOverview struct {
 	PnLUSD string `json:"pnl_usd"`
 	// ValueUSD is the current market value in USD.
 	ValueUSD string `json:"value_usd"`
-	// STCGUSD is the short-term P&L in USD (held < 365 days). Equals PnLUSD or 0.
+	// STCGUSD is the short-term P&L in USD. Equals PnLUSD or 0.
 	STCGUSD string `json:"stcg_usd"`
-	// LTCGUSD is the long-term P&L in USD (held >= 365 days). Equals PnLUSD or 0.
+	// LTCGUSD is the long-term P&L in USD. Equals PnLUSD or 0.
 	LTCGUSD string `json:"ltcg_usd"`
 	// Category is the user-defined asset category (e.g., "EQUITY").
 	Category string `json:"category,omitempty"`
@@ -198,11 +298,24 @@ type LotOverview struct {
 	Sector string `json:"sector,omitempty"`
 	// Geo is the user-defined geographic classification (e.g., "US", "INTL").
 	Geo string `json:"geo,omitempty"`
+	// Source is where the lot's opening trade came from if not IBKR (e.g., "manual", "transfer_basis", "statement_extract").
+	Source string `json:"source,omitempty"`
+	// LotID is the deterministic lot identifier (account/symbol/open date/sequence).
+	LotID string `json:"lot_id"`
+	// Tags is the sorted list of tags from the account's notes.
+	Tags []string `json:"tags,omitempty"`
+	// Note is the free-text note from the account's notes.
+	Note string `json:"note,omitempty"`
+	// FXRate is the rate the USD values were converted with, set by AddLotFXRates.
+	FXRate *ibctlfxrates.Rate `json:"fx_rate,omitempty"`
+	// PriceSource is "manual" if the lot is valued at a price override from
+	// data/prices/overrides.yaml, and empty for IBKR market prices.
+	PriceSource string `json:"price_source,omitempty"`
 }
 
 // LotListHeaders returns the column headers for lot list table/CSV output.
 func LotListHeaders() []string {
-	return []string{"SYMBOL", "ACCOUNT", "DATE", "QUANTITY", "CURRENCY", "AVG PRICE", "P&L", "VALUE", "AVG USD", "P&L USD", "STCG USD", "LTCG USD", "VALUE USD", "CATEGORY", "TYPE", "SECTOR", "GEO"}
+	return []string{"SYMBOL", "ACCOUNT", "DATE", "QUANTITY", "CURRENCY", "AVG PRICE", "P&L", "VALUE", "AVG USD", "P&L USD", "STCG USD", "LTCG USD", "VALUE USD", "CATEGORY", "TYPE", "SECTOR", "GEO", "SOURCE", "LOT ID", "TAGS", "NOTE"}
 }
 
 // LotOverviewToRow converts a LotOverview to a string slice for CSV output.
@@ -225,12 +338,21 @@ func LotOverviewToRow(l *LotOverview) []string {
 		l.Type,
 		l.Sector,
 		l.Geo,
+		l.Source,
+		l.LotID,
+		strings.Join(l.Tags, ","),
+		l.Note,
 	}
 }
 
 // LotOverviewToTableRow converts a LotOverview to a string slice for table display.
 // USD columns are formatted with $ prefix, comma separators, rounded to cents.
+// Values at a manual price override are marked.
 func LotOverviewToTableRow(l *LotOverview) []string {
+	value := l.Value
+	if l.PriceSource == PriceSourceManual {
+		value += " (" + PriceSourceManual + ")"
+	}
 	return []string{
 		l.Symbol,
 		l.Account,
@@ -239,7 +361,7 @@ func LotOverviewToTableRow(l *LotOverview) []string {
 		l.Currency,
 		l.AveragePrice,
 		l.PnL,
-		l.Value,
+		value,
 		cliio.FormatUSD(l.AverageUSD),
 		cliio.FormatUSD(l.PnLUSD),
 		cliio.FormatUSD(l.STCGUSD),
@@ -249,19 +371,24 @@ func LotOverviewToTableRow(l *LotOverview) []string {
 		l.Type,
 		l.Sector,
 		l.Geo,
+		l.Source,
+		l.LotID,
+		strings.Join(l.Tags, ","),
+		l.Note,
 	}
 }
 
-// LotTotals holds the formatted total values for the lot list summary row.
+// LotTotals holds the total values for the lot list summary row. Values are
+// decimal strings, formatted with cliio.FormatUSD for table display.
 type LotTotals struct {
 	// PnLUSD is the total unrealized P&L in USD.
-	PnLUSD string
+	PnLUSD string `json:"pnl_usd"`
 	// ValueUSD is the total market value in USD.
-	ValueUSD string
+	ValueUSD string `json:"value_usd"`
 	// STCGUSD is the total short-term P&L in USD.
-	STCGUSD string
+	STCGUSD string `json:"stcg_usd"`
 	// LTCGUSD is the total long-term P&L in USD.
-	LTCGUSD string
+	LTCGUSD string `json:"ltcg_usd"`
 }
 
 // ComputeLotTotals sums the USD value columns across all lots.
@@ -274,11 +401,230 @@ func ComputeLotTotals(lots []*LotOverview) *LotTotals {
 		totalLTCGMicros += mathpb.ParseMicros(l.LTCGUSD)
 	}
 	return &LotTotals{
-		PnLUSD:   cliio.FormatUSDMicros(totalPnLMicros),
-		ValueUSD: cliio.FormatUSDMicros(totalValueMicros),
-		STCGUSD:  cliio.FormatUSDMicros(totalSTCGMicros),
-		LTCGUSD:  cliio.FormatUSDMicros(totalLTCGMicros),
+		PnLUSD:   moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", totalPnLMicros)),
+		ValueUSD: moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", totalValueMicros)),
+		STCGUSD:  moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", totalSTCGMicros)),
+		LTCGUSD:  moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", totalLTCGMicros)),
+	}
+}
+
+// LotTotalsToRow converts LotTotals to a TOTAL row aligned with
+// LotListHeaders for CSV output.
+func LotTotalsToRow(totals *LotTotals) []string {
+	row := make([]string, len(LotListHeaders()))
+	row[0] = "TOTAL"
+	row[9] = totals.PnLUSD
+	row[10] = totals.STCGUSD
+	row[11] = totals.LTCGUSD
+	row[12] = totals.ValueUSD
+	return row
+}
+
+// LotTotalsToTableRow converts LotTotals to a TOTAL row aligned with
+// LotListHeaders for table display.
+func LotTotalsToTableRow(totals *LotTotals) []string {
+	row := LotTotalsToRow(totals)
+	for i := 9; i <= 12; i++ {
+		row[i] = cliio.FormatUSD(row[i])
+	}
+	return row
+}
+
+// AnnotateLots sets the tags and note of each lot from notes.
+func AnnotateLots(lots []*LotOverview, notes *ibctlnotes.Notes) {
+	for _, l := range lots {
+		annotation := notes.LotAnnotation(l.Account, l.Symbol, l.LotID)
+		l.Tags = annotation.Tags
+		l.Note = annotation.Note
+	}
+}
+
+// FilterLotsByTag returns the lots with a tag matching tag. See ibctlnotes.MatchesTag.
+func FilterLotsByTag(lots []*LotOverview, tag string) []*LotOverview {
+	var filtered []*LotOverview
+	for _, l := range lots {
+		if ibctlnotes.MatchesTag(l.Tags, tag) {
+			filtered = append(filtered, l)
+		}
+	}
+	return filtered
+}
+
+// Lot list grouping modes, for subtotals per group.
+const (
+	// LotGroupBySymbol groups lots by symbol.
+	LotGroupBySymbol = "symbol"
+	// LotGroupByAccount groups lots by account alias.
+	LotGroupByAccount = "account"
+	// LotGroupByYear groups lots by the year they were opened.
+	LotGroupByYear = "year"
+	// LotGroupByTag groups lots by tag. A lot with several tags is in the group
+	// of each, and untagged lots are in the LotGroupUntagged group.
+	LotGroupByTag = "tag"
+)
+
+// LotGroupUntagged is the group key of untagged lots when grouping by tag.
+const LotGroupUntagged = "(untagged)"
+
+// LotGroup is a group of lots in a lot list with their subtotals.
+type LotGroup struct {
+	// Key is the group key: the symbol, account alias, open year, or tag.
+	Key string
+	// Lots are the lots in the group, in lot list order.
+	Lots []*LotOverview
+	// Quantity is the total quantity, only set when grouping by symbol.
+	Quantity string
+	// PnLUSD is the total unrealized P&L in USD.
+	PnLUSD string
+	// STCGUSD is the total short-term P&L in USD.
+	STCGUSD string
+	// LTCGUSD is the total long-term P&L in USD.
+	LTCGUSD string
+	// ValueUSD is the total market value in USD.
+	ValueUSD string
+}
+
+// GroupLots groups lots by LotGroupBySymbol, LotGroupByAccount,
+// LotGroupByYear, or LotGroupByTag, and computes the subtotals of each group.
+// Groups are sorted by key.
+//
+// When grouping by tag, a lot with several tags is counted in each group, so
+// the subtotals can add up to more than the total.
+func GroupLots(lots []*LotOverview, groupBy string) ([]*LotGroup, error) {
+	var keysFunc func(*LotOverview) []string
+	switch groupBy {
+	case LotGroupBySymbol:
+		keysFunc = func(l *LotOverview) []string { return []string{l.Symbol} }
+	case LotGroupByAccount:
+		keysFunc = func(l *LotOverview) []string { return []string{l.Account} }
+	case LotGroupByYear:
+		keysFunc = func(l *LotOverview) []string {
+			year, _, _ := strings.Cut(l.Date, "-")
+			return []string{year}
+		}
+	case LotGroupByTag:
+		keysFunc = func(l *LotOverview) []string {
+			if len(l.Tags) == 0 {
+				return []string{LotGroupUntagged}
+			}
+			return l.Tags
+		}
+	default:
+		return nil, fmt.Errorf("unknown lot grouping %q, must be one of %s, %s, %s, %s", groupBy, LotGroupBySymbol, LotGroupByAccount, LotGroupByYear, LotGroupByTag)
+	}
+	keyToGroup := make(map[string]*LotGroup)
+	var groups []*LotGroup
+	for _, l := range lots {
+		for _, key := range keysFunc(l) {
+			group, ok := keyToGroup[key]
+			if !ok {
+				group = &LotGroup{Key: key}
+				keyToGroup[key] = group
+				groups = append(groups, group)
+			}
+			group.Lots = append(group.Lots, l)
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Key < groups[j].Key
+	})
+	for _, group := range groups {
+		var quantityMicros, pnlMicros, stcgMicros, ltcgMicros, valueMicros int64
+		for _, l := range group.Lots {
+			quantityMicros += mathpb.ToMicros(l.Quantity)
+			pnlMicros += mathpb.ParseMicros(l.PnLUSD)
+			stcgMicros += mathpb.ParseMicros(l.STCGUSD)
+			ltcgMicros += mathpb.ParseMicros(l.LTCGUSD)
+			valueMicros += mathpb.ParseMicros(l.ValueUSD)
+		}
+		// Quantities of different symbols cannot be added.
+		if groupBy == LotGroupBySymbol {
+			group.Quantity = mathpb.ToString(mathpb.FromMicros(quantityMicros))
+		}
+		group.PnLUSD = moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", pnlMicros))
+		group.STCGUSD = moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", stcgMicros))
+		group.LTCGUSD = moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", ltcgMicros))
+		group.ValueUSD = moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", valueMicros))
+	}
+	return groups, nil
+}
+
+// LotGroupToRow converts a LotGroup to a subtotal row for CSV output, aligned
+// with LotListHeaders. The symbol column is "SUBTOTAL <key>".
+func LotGroupToRow(g *LotGroup) []string {
+	row := make([]string, len(LotListHeaders()))
+	row[0] = "SUBTOTAL " + g.Key
+	row[3] = g.Quantity
+	row[9] = g.PnLUSD
+	row[10] = g.STCGUSD
+	row[11] = g.LTCGUSD
+	row[12] = g.ValueUSD
+	return row
+}
+
+// LotGroupToTableRow converts a LotGroup to a subtotal row for table display,
+// with USD values formatted.
+func LotGroupToTableRow(g *LotGroup) []string {
+	row := LotGroupToRow(g)
+	for _, i := range []int{9, 10, 11, 12} {
+		row[i] = cliio.FormatUSD(row[i])
+	}
+	return row
+}
+
+// Classification is a symbol classification that holdings can be aggregated by.
+type Classification string
+
+const (
+	// ClassificationCategory aggregates by asset category (e.g., "EQUITY").
+	ClassificationCategory Classification = "category"
+	// ClassificationType aggregates by asset type (e.g., "STOCK", "ETF").
+	ClassificationType Classification = "type"
+	// ClassificationSector aggregates by sector (e.g., "TECH").
+	ClassificationSector Classification = "sector"
+	// ClassificationGeo aggregates by geography (e.g., "US", "INTL").
+	ClassificationGeo Classification = "geo"
+)
+
+// ParseClassification parses a string into a Classification.
+func ParseClassification(s string) (Classification, error) {
+	switch classification := Classification(strings.ToLower(s)); classification {
+	case ClassificationCategory, ClassificationType, ClassificationSector, ClassificationGeo:
+		return classification, nil
+	default:
+		return "", fmt.Errorf("unknown classification %q, must be one of: category, type, sector, geo", s)
+	}
+}
+
+// value returns the holding's value for the classification.
+func (c Classification) value(h *HoldingOverview) string {
+	switch c {
+	case ClassificationType:
+		return h.Type
+	case ClassificationSector:
+		return h.Sector
+	case ClassificationGeo:
+		return h.Geo
+	default:
+		return h.Category
+	}
+}
+
+// Weights returns the fraction of the holding's value in each classification
+// value, using the symbol's sector or geo look-through weights if configured.
+func (c Classification) Weights(h *HoldingOverview, lookthroughs map[string]ibctlconfig.Lookthrough) map[string]float64 {
+	lookthrough := lookthroughs[h.Symbol]
+	switch {
+	case c == ClassificationSector && len(lookthrough.Sector) > 0:
+		return lookthrough.Sector
+	case c == ClassificationGeo && len(lookthrough.Geo) > 0:
+		return lookthrough.Geo
+	}
+	value := c.value(h)
+	if value == "" {
+		value = "UNCATEGORIZED"
 	}
+	return map[string]float64{value: 1}
 }
 
 // CategoryOverview represents holdings aggregated by category.
@@ -326,8 +672,44 @@ func CategoryOverviewToTableRow(c *CategoryOverview) []string {
 	}
 }
 
+// ComputeCategoryTotals sums the categories into a CategoryOverview whose
+// category is "TOTAL", for the summary row.
+func ComputeCategoryTotals(categories []*CategoryOverview) *CategoryOverview {
+	var totalMktValMicros, totalPnLMicros, totalSTCGMicros, totalLTCGMicros int64
+	for _, c := range categories {
+		totalMktValMicros += mathpb.ParseMicros(c.MarketValueUSD)
+		totalPnLMicros += mathpb.ParseMicros(c.UnrealizedPnLUSD)
+		totalSTCGMicros += mathpb.ParseMicros(c.STCGUSD)
+		totalLTCGMicros += mathpb.ParseMicros(c.LTCGUSD)
+	}
+	var pctStr string
+	if totalMktValMicros != 0 {
+		pctStr = "100.00%"
+	}
+	return &CategoryOverview{
+		Category:         "TOTAL",
+		MarketValueUSD:   moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", totalMktValMicros)),
+		NetLiqPct:        pctStr,
+		UnrealizedPnLUSD: moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", totalPnLMicros)),
+		STCGUSD:          moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", totalSTCGMicros)),
+		LTCGUSD:          moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", totalLTCGMicros)),
+	}
+}
+
 // GetCategoryList aggregates holdings by category from a HoldingsResult.
 func GetCategoryList(holdings []*HoldingOverview) []*CategoryOverview {
+	return GetClassificationList(holdings, ClassificationCategory, nil)
+}
+
+// GetClassificationList aggregates holdings by the classification from a HoldingsResult.
+// The Category field of each returned CategoryOverview holds the classification value.
+// Holdings with sector or geo look-through weights are split across the
+// weighted values when aggregating by sector or geo.
+func GetClassificationList(
+	holdings []*HoldingOverview,
+	classification Classification,
+	lookthroughs map[string]ibctlconfig.Lookthrough,
+) []*CategoryOverview {
 	// Accumulate per-category totals in micros.
 	type categoryData struct {
 		mktValMicros int64
@@ -338,20 +720,21 @@ func GetCategoryList(holdings []*HoldingOverview) []*CategoryOverview {
 	dataMap := make(map[string]*categoryData)
 	var totalMktValMicros int64
 	for _, h := range holdings {
-		cat := h.Category
-		if cat == "" {
-			cat = "UNCATEGORIZED"
-		}
-		data, ok := dataMap[cat]
-		if !ok {
-			data = &categoryData{}
-			dataMap[cat] = data
-		}
 		mktVal := mathpb.ParseMicros(h.MarketValueUSD)
-		data.mktValMicros += mktVal
-		data.pnlMicros += mathpb.ParseMicros(h.UnrealizedPnLUSD)
-		data.stcgMicros += mathpb.ParseMicros(h.STCGUSD)
-		data.ltcgMicros += mathpb.ParseMicros(h.LTCGUSD)
+		pnl := mathpb.ParseMicros(h.UnrealizedPnLUSD)
+		stcg := mathpb.ParseMicros(h.STCGUSD)
+		ltcg := mathpb.ParseMicros(h.LTCGUSD)
+		for cat, weight := range classification.Weights(h, lookthroughs) {
+			data, ok := dataMap[cat]
+			if !ok {
+				data = &categoryData{}
+				dataMap[cat] = data
+			}
+			data.mktValMicros += weightMicros(mktVal, weight)
+			data.pnlMicros += weightMicros(pnl, weight)
+			data.stcgMicros += weightMicros(stcg, weight)
+			data.ltcgMicros += weightMicros(ltcg, weight)
+		}
 		totalMktValMicros += mktVal
 	}
 	// Build category overview entries with net liq percentage.
@@ -372,13 +755,275 @@ func GetCategoryList(holdings []*HoldingOverview) []*CategoryOverview {
 			LTCGUSD:          moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", data.ltcgMicros)),
 		})
 	}
-	// Sort by category name for deterministic output.
+	// Sort by classification value for deterministic output.
 	sort.Slice(categories, func(i, j int) bool {
 		return categories[i].Category < categories[j].Category
 	})
 	return categories
 }
 
+// BenchmarkWeights returns the benchmark's weights for the classification, or
+// nil if the benchmark has no weights for it.
+func (c Classification) BenchmarkWeights(benchmark ibctlconfig.Benchmark) map[string]float64 {
+	switch c {
+	case ClassificationType:
+		return benchmark.Type
+	case ClassificationSector:
+		return benchmark.Sector
+	case ClassificationGeo:
+		return benchmark.Geo
+	default:
+		return benchmark.Category
+	}
+}
+
+// BenchmarkComparison compares the portfolio weight of a classification value
+// to its weight in a benchmark.
+type BenchmarkComparison struct {
+	// Category is the classification value (e.g., "TECH").
+	Category string `json:"category"`
+	// MarketValueUSD is the market value of the portfolio in the classification value in USD.
+	MarketValueUSD string `json:"market_value_usd"`
+	// PortfolioPct is the percentage of the portfolio value (e.g., "45.23%").
+	PortfolioPct string `json:"portfolio_pct"`
+	// BenchmarkPct is the percentage of the benchmark (e.g., "32.00%").
+	BenchmarkPct string `json:"benchmark_pct"`
+	// OverUnderPct is PortfolioPct - BenchmarkPct, positive if overweight.
+	OverUnderPct string `json:"over_under_pct"`
+	// OverUnderUSD is the market value above (positive) or below (negative)
+	// the benchmark weight of the portfolio value in USD.
+	OverUnderUSD string `json:"over_under_usd"`
+}
+
+// BenchmarkComparisonHeaders returns the column headers for benchmark comparison output.
+func BenchmarkComparisonHeaders() []string {
+	return []string{"CATEGORY", "MKT VAL USD", "PORTFOLIO %", "BENCHMARK %", "OVER/UNDER %", "OVER/UNDER USD"}
+}
+
+// BenchmarkComparisonToRow converts a BenchmarkComparison to a string slice for CSV output.
+func BenchmarkComparisonToRow(c *BenchmarkComparison) []string {
+	return []string{
+		c.Category,
+		c.MarketValueUSD,
+		c.PortfolioPct,
+		c.BenchmarkPct,
+		c.OverUnderPct,
+		c.OverUnderUSD,
+	}
+}
+
+// BenchmarkComparisonToTableRow converts a BenchmarkComparison to a string slice for table display.
+func BenchmarkComparisonToTableRow(c *BenchmarkComparison) []string {
+	return []string{
+		c.Category,
+		cliio.FormatUSD(c.MarketValueUSD),
+		c.PortfolioPct,
+		c.BenchmarkPct,
+		c.OverUnderPct,
+		cliio.FormatUSD(c.OverUnderUSD),
+	}
+}
+
+// GetBenchmarkComparison compares the classification list from
+// GetClassificationList to the benchmark weights of the same classification.
+// Values only in the portfolio have a benchmark weight of 0, and values only
+// in the benchmark have a market value of 0. Returns the comparisons sorted by
+// classification value, and a totals comparison whose category is "TOTAL".
+func GetBenchmarkComparison(
+	categories []*CategoryOverview,
+	benchmarkWeights map[string]float64,
+) ([]*BenchmarkComparison, *BenchmarkComparison) {
+	categoryToMktValMicros := make(map[string]int64, len(categories))
+	var totalMktValMicros int64
+	for _, c := range categories {
+		mktValMicros := mathpb.ParseMicros(c.MarketValueUSD)
+		categoryToMktValMicros[c.Category] = mktValMicros
+		totalMktValMicros += mktValMicros
+	}
+	for category := range benchmarkWeights {
+		if _, ok := categoryToMktValMicros[category]; !ok {
+			categoryToMktValMicros[category] = 0
+		}
+	}
+	comparisons := make([]*BenchmarkComparison, 0, len(categoryToMktValMicros))
+	var totalBenchmarkWeight float64
+	for category, mktValMicros := range categoryToMktValMicros {
+		benchmarkWeight := benchmarkWeights[category]
+		totalBenchmarkWeight += benchmarkWeight
+		comparison := &BenchmarkComparison{
+			Category:       category,
+			MarketValueUSD: moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", mktValMicros)),
+			BenchmarkPct:   fmt.Sprintf("%.2f%%", benchmarkWeight*100),
+		}
+		if totalMktValMicros != 0 {
+			portfolioWeight := float64(mktValMicros) / float64(totalMktValMicros)
+			overUnderMicros := mktValMicros - weightMicros(totalMktValMicros, benchmarkWeight)
+			comparison.PortfolioPct = fmt.Sprintf("%.2f%%", portfolioWeight*100)
+			comparison.OverUnderPct = fmt.Sprintf("%+.2f%%", (portfolioWeight-benchmarkWeight)*100)
+			comparison.OverUnderUSD = moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", overUnderMicros))
+		}
+		comparisons = append(comparisons, comparison)
+	}
+	// Sort by classification value for deterministic output.
+	sort.Slice(comparisons, func(i, j int) bool {
+		return comparisons[i].Category < comparisons[j].Category
+	})
+	totals := &BenchmarkComparison{
+		Category:       "TOTAL",
+		MarketValueUSD: moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", totalMktValMicros)),
+		BenchmarkPct:   fmt.Sprintf("%.2f%%", totalBenchmarkWeight*100),
+	}
+	if totalMktValMicros != 0 {
+		totals.PortfolioPct = "100.00%"
+	}
+	return comparisons, totals
+}
+
+// CurrencyExposure represents holdings and cash aggregated by currency.
+type CurrencyExposure struct {
+	// Currency is the three-letter ISO 4217 currency code.
+	Currency string `json:"currency"`
+	// SecuritiesUSD is the market value of securities exposed to the currency in USD.
+	SecuritiesUSD string `json:"securities_usd"`
+	// CashUSD is the cash balance in the currency in USD.
+	CashUSD string `json:"cash_usd"`
+	// MarketValueUSD is the total exposure (securities and cash) in USD.
+	MarketValueUSD string `json:"market_value_usd"`
+	// NetLiqPct is the percentage of total portfolio value (e.g., "45.23%").
+	NetLiqPct string `json:"net_liq_pct"`
+	// NativeValue is the total exposure in the currency itself.
+	NativeValue string `json:"native_value"`
+	// BaseCurrency is the base currency from the taxes section of ibctl.yaml.
+	BaseCurrency string `json:"base_currency"`
+	// BaseValue is the total exposure in the base currency.
+	BaseValue string `json:"base_value"`
+}
+
+// CurrencyExposureHeaders returns the column headers for currency list output,
+// naming the base currency value column after the base currency.
+func CurrencyExposureHeaders(baseCurrency string) []string {
+	return []string{"CURRENCY", "SECURITIES USD", "CASH USD", "MKT VAL USD", "NET LIQ %", "NATIVE VALUE", baseCurrency + " VALUE"}
+}
+
+// CurrencyExposureToRow converts a CurrencyExposure to a string slice for CSV output.
+func CurrencyExposureToRow(c *CurrencyExposure) []string {
+	return []string{
+		c.Currency,
+		c.SecuritiesUSD,
+		c.CashUSD,
+		c.MarketValueUSD,
+		c.NetLiqPct,
+		c.NativeValue,
+		c.BaseValue,
+	}
+}
+
+// CurrencyExposureToTableRow converts a CurrencyExposure to a string slice for table display.
+func CurrencyExposureToTableRow(c *CurrencyExposure) []string {
+	return []string{
+		c.Currency,
+		cliio.FormatUSD(c.SecuritiesUSD),
+		cliio.FormatUSD(c.CashUSD),
+		cliio.FormatUSD(c.MarketValueUSD),
+		c.NetLiqPct,
+		c.NativeValue,
+		c.BaseValue,
+	}
+}
+
+// GetCurrencyExposure aggregates holdings by currency. Securities are assigned
+// to their trading currency, or split across currencies by the symbol's
+// currencies look-through in ibctl.yaml (e.g., for ADRs and global ETFs). Cash
+// is assigned to its own currency. Holdings without a USD market value are skipped.
+// Native and base currency values are left empty if no FX rate is available.
+func GetCurrencyExposure(
+	holdings []*HoldingOverview,
+	config *ibctlconfig.Config,
+	fxStore *ibctlfxrates.Store,
+) []*CurrencyExposure {
+	// Accumulate per-currency values in micros.
+	type currencyData struct {
+		securitiesMicros int64
+		cashMicros       int64
+		nativeMicros     int64
+		nativeMissing    bool
+	}
+	dataMap := make(map[string]*currencyData)
+	getData := func(currency string) *currencyData {
+		data, ok := dataMap[currency]
+		if !ok {
+			data = &currencyData{}
+			dataMap[currency] = data
+		}
+		return data
+	}
+	var totalMicros int64
+	for _, h := range holdings {
+		if h.MarketValueUSD == "" {
+			continue
+		}
+		valueMicros := mathpb.ParseMicros(h.MarketValueUSD)
+		totalMicros += valueMicros
+		if h.Category == assetCategoryCash {
+			// Cash balances are already in their currency, so no conversion is needed.
+			data := getData(h.Currency)
+			data.cashMicros += valueMicros
+			data.nativeMicros += mathpb.ToMicros(h.Position)
+			continue
+		}
+		currencies := config.SymbolConfigs[h.Symbol].Currencies
+		if len(currencies) == 0 {
+			currencies = map[string]float64{h.Currency: 1}
+		}
+		for currency, fraction := range currencies {
+			exposureMicros := weightMicros(valueMicros, fraction)
+			data := getData(currency)
+			data.securitiesMicros += exposureMicros
+			native, ok := fxStore.Convert(moneypb.MoneyFromMicros("USD", exposureMicros), currency)
+			if !ok {
+				data.nativeMissing = true
+				continue
+			}
+			data.nativeMicros += moneypb.MoneyToMicros(native)
+		}
+	}
+	baseCurrency := config.TaxBaseCurrency
+	exposures := make([]*CurrencyExposure, 0, len(dataMap))
+	for currency, data := range dataMap {
+		exposureMicros := data.securitiesMicros + data.cashMicros
+		exposure := &CurrencyExposure{
+			Currency:       currency,
+			SecuritiesUSD:  moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", data.securitiesMicros)),
+			CashUSD:        moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", data.cashMicros)),
+			MarketValueUSD: moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", exposureMicros)),
+			BaseCurrency:   baseCurrency,
+		}
+		if totalMicros != 0 {
+			exposure.NetLiqPct = fmt.Sprintf("%.2f%%", float64(exposureMicros)/float64(totalMicros)*100)
+		}
+		if !data.nativeMissing {
+			exposure.NativeValue = moneypb.MoneyValueToString(moneypb.MoneyFromMicros(currency, data.nativeMicros))
+		}
+		if base, ok := fxStore.Convert(moneypb.MoneyFromMicros("USD", exposureMicros), baseCurrency); ok {
+			exposure.BaseValue = moneypb.MoneyValueToString(base)
+		}
+		exposures = append(exposures, exposure)
+	}
+	// Sort by currency code for deterministic output.
+	sort.Slice(exposures, func(i, j int) bool {
+		return exposures[i].Currency < exposures[j].Currency
+	})
+	return exposures
+}
+
+// weightMicros returns the weighted fraction of a micros value, rounded to the nearest micro.
+func weightMicros(micros int64, weight float64) int64 {
+	if weight == 1 {
+		return micros
+	}
+	return int64(math.Round(float64(micros) * weight))
+}
+
 // GetLotList returns individual tax lots, optionally filtered by symbol.
 // If symbol is empty, all lots are returned.
 func GetLotList(
@@ -388,39 +1033,74 @@ func GetLotList(
 	config *ibctlconfig.Config,
 	fxStore *ibctlfxrates.Store,
 ) (*LotListResult, error) {
-	// Filter out CASH asset category trades.
+	return getLotList(symbol, trades, positions, config, fxStore, xtime.TimeToDate(time.Now()))
+}
+
+// GetLotListAsOf returns the tax lots held at the end of the date, optionally
+// filtered by symbol, valued at the closing prices in priceStore and
+// classified as short-term or long-term as of the date. The fxStore should be
+// created with ibctlfxrates.NewStoreAsOf for point-in-time conversion. See
+// GetHoldingsOverviewAsOf for how prices are fetched.
+func GetLotListAsOf(
+	ctx context.Context,
+	asOf xtime.Date,
+	symbol string,
+	trades []*datav1.Trade,
+	config *ibctlconfig.Config,
+	fxStore *ibctlfxrates.Store,
+	priceStore *pricestore.Store,
+) (*LotListResult, error) {
+	trades = TruncateTrades(trades, asOf)
+	positions, err := asOfPositions(ctx, trades, asOf, config, priceStore)
+	if err != nil {
+		return nil, err
+	}
+	return getLotList(symbol, trades, positions, config, fxStore, asOf)
+}
+
+// TruncateTrades returns the trades with a trade date on or before the date.
+func TruncateTrades(trades []*datav1.Trade, asOf xtime.Date) []*datav1.Trade {
+	var truncated []*datav1.Trade
+	for _, trade := range trades {
+		tradeDate, err := timepb.ProtoToDate(trade.GetTradeDate())
+		if err != nil || tradeDate.After(asOf) {
+			continue
+		}
+		truncated = append(truncated, trade)
+	}
+	return truncated
+}
+
+func getLotList(
+	symbol string,
+	trades []*datav1.Trade,
+	positions []*datav1.Position,
+	config *ibctlconfig.Config,
+	fxStore *ibctlfxrates.Store,
+	today xtime.Date,
+) (*LotListResult, error) {
+	// Filter out CASH asset category trades and ignored symbols.
 	var securityTrades []*datav1.Trade
 	for _, trade := range trades {
-		if trade.GetAssetCategory() == assetCategoryCash {
+		if trade.GetAssetCategory() == assetCategoryCash || isIgnoredSymbol(config, trade.GetSymbol()) {
 			continue
 		}
 		securityTrades = append(securityTrades, trade)
 	}
 	// Compute FIFO tax lots from all security trades.
-	taxLotResult, err := ibctltaxlot.ComputeTaxLots(securityTrades)
+	taxLotResult, err := ibctltaxlot.ComputeTaxLotsCached(config.CacheTaxLotsDirPath(), securityTrades, config.AverageCostSymbols())
 	if err != nil {
 		return nil, err
 	}
-	// Build a map of last prices and bond status from IBKR-reported positions.
-	type positionData struct {
-		lastPriceMicros int64
-		isBond          bool
-	}
-	positionMap := make(map[string]*positionData, len(positions))
+	// Build a map of IBKR-reported positions for last prices and value scaling,
+	// falling back to price overrides for symbols without a market price.
+	positions, priceOverrides := applyPriceOverrides(trades, positions, config.PriceOverrides, today)
+	positionMap := make(map[string]*datav1.Position, len(positions))
 	for _, pos := range positions {
 		if pos.GetAssetCategory() == assetCategoryCash {
 			continue
 		}
-		positionMap[pos.GetSymbol()] = &positionData{
-			lastPriceMicros: moneypb.MoneyToMicros(pos.GetMarketPrice()),
-			isBond:          pos.GetAssetCategory() == assetCategoryBond,
-		}
-	}
-	// Compute today's date for holding period classification.
-	today := xtime.Date{
-		Year:  time.Now().Year(),
-		Month: time.Now().Month(),
-		Day:   time.Now().Day(),
+		positionMap[pos.GetSymbol()] = pos
 	}
 	// Build the lot overview, optionally filtering by symbol.
 	var lots []*LotOverview
@@ -430,13 +1110,11 @@ func GetLotList(
 		if symbol != "" && lotSymbol != symbol {
 			continue
 		}
-		// Look up position data for this symbol.
-		pd := positionMap[lotSymbol]
+		// Look up the position for this symbol.
+		pos := positionMap[lotSymbol]
 		var lastPriceMicros int64
-		var isBond bool
-		if pd != nil {
-			lastPriceMicros = pd.lastPriceMicros
-			isBond = pd.isBond
+		if pos != nil {
+			lastPriceMicros = moneypb.MoneyToMicros(pos.GetMarketPrice())
 		}
 		costMicros := moneypb.MoneyToMicros(lot.GetCostBasisPrice())
 		lotQtyMicros := mathpb.ToMicros(lot.GetQuantity())
@@ -449,9 +1127,11 @@ func GetLotList(
 			valueMicros = lastPriceMicros*lotQtyUnits + lastPriceMicros*lotQtyRemainder/1_000_000
 			pnlPerUnit := lastPriceMicros - costMicros
 			pnlMicros = pnlPerUnit*lotQtyUnits + pnlPerUnit*lotQtyRemainder/1_000_000
-			if isBond {
-				valueMicros /= 100
-				pnlMicros /= 100
+			valueMicros = scaleMicros(valueMicros, pos)
+			pnlMicros = scaleMicros(pnlMicros, pos)
+			// Margined positions are worth their unrealized P&L.
+			if ibctltaxlot.IsMultiplierCategory(pos.GetAssetCategory()) {
+				valueMicros = pnlMicros
 			}
 		}
 		// Format the open date.
@@ -468,6 +1148,11 @@ func GetLotList(
 			AveragePrice: moneypb.MoneyValueToString(lot.GetCostBasisPrice()),
 			PnL:          moneypb.MoneyValueToString(moneypb.MoneyFromMicros(currency, pnlMicros)),
 			Value:        moneypb.MoneyValueToString(moneypb.MoneyFromMicros(currency, valueMicros)),
+			Source:       lot.GetSource(),
+			LotID:        lot.GetLotId(),
+		}
+		if _, ok := priceOverrides[lotSymbol]; ok {
+			l.PriceSource = PriceSourceManual
 		}
 		// Merge symbol classification from config.
 		if symbolConfig, ok := config.SymbolConfigs[lotSymbol]; ok {
@@ -493,7 +1178,7 @@ func GetLotList(
 		// Classify P&L as short-term or long-term based on holding period.
 		// For a single lot, the entire P&L is one or the other.
 		if l.PnLUSD != "" {
-			longTerm, ltErr := ibctltaxlot.IsLongTerm(lot, today)
+			longTerm, ltErr := ibctltaxlot.IsLongTerm(lot, today, config.TaxRules)
 			if ltErr == nil {
 				if longTerm {
 					l.STCGUSD = "0"
@@ -519,6 +1204,134 @@ func GetLotList(
 	return &LotListResult{Lots: lots}, nil
 }
 
+// LotAging is a short-term tax lot that becomes long-term within the aging window.
+type LotAging struct {
+	// Symbol is the ticker symbol.
+	Symbol string `json:"symbol"`
+	// Account is the account alias.
+	Account string `json:"account"`
+	// Date is the lot open date (YYYY-MM-DD).
+	Date string `json:"date"`
+	// LongTermDate is the first date the lot is long-term (YYYY-MM-DD).
+	LongTermDate string `json:"long_term_date"`
+	// DaysUntilLongTerm is the number of days from today until LongTermDate.
+	DaysUntilLongTerm int `json:"days_until_long_term"`
+	// Quantity is the remaining quantity in this lot.
+	Quantity *mathv1.Decimal `json:"quantity"`
+	// GainUSD is the unrealized P&L in USD that shifts from STCG to LTCG.
+	GainUSD string `json:"gain_usd"`
+	// TaxDeltaUSD is the estimated tax saved by selling after LongTermDate
+	// instead of today: GainUSD × (STCG rate − LTCG rate). Negative for losses,
+	// whose deduction is worth less once long-term.
+	TaxDeltaUSD string `json:"tax_delta_usd"`
+	// LotID is the deterministic lot identifier (account/symbol/open date/sequence).
+	LotID string `json:"lot_id"`
+}
+
+// LotAgingHeaders returns the column headers for lot aging table/CSV output.
+func LotAgingHeaders() []string {
+	return []string{"SYMBOL", "ACCOUNT", "DATE", "LONG-TERM DATE", "DAYS", "QUANTITY", "GAIN USD", "TAX DELTA USD", "LOT ID"}
+}
+
+// LotAgingToRow converts a LotAging to a string slice for CSV output.
+func LotAgingToRow(l *LotAging) []string {
+	return []string{
+		l.Symbol,
+		l.Account,
+		l.Date,
+		l.LongTermDate,
+		strconv.Itoa(l.DaysUntilLongTerm),
+		mathpb.ToString(l.Quantity),
+		l.GainUSD,
+		l.TaxDeltaUSD,
+		l.LotID,
+	}
+}
+
+// LotAgingToTableRow converts a LotAging to a string slice for table display.
+func LotAgingToTableRow(l *LotAging) []string {
+	row := LotAgingToRow(l)
+	row[6] = cliio.FormatUSD(l.GainUSD)
+	row[7] = cliio.FormatUSD(l.TaxDeltaUSD)
+	return row
+}
+
+// GetLotAging returns the short-term lots in taxable accounts that become
+// long-term within the given number of days, sorted by long-term date. Lots
+// in deferred and exempt accounts are skipped, since their holding period has
+// no tax effect. Lots without a USD P&L (no market price or FX rate) are skipped.
+func GetLotAging(
+	days int,
+	trades []*datav1.Trade,
+	positions []*datav1.Position,
+	config *ibctlconfig.Config,
+	fxStore *ibctlfxrates.Store,
+) ([]*LotAging, error) {
+	lotListResult, err := GetLotList("", trades, positions, config, fxStore)
+	if err != nil {
+		return nil, err
+	}
+	today := xtime.TimeToDate(time.Now())
+	rateDifference := config.TaxRateSTCG - config.TaxRateLTCG
+	var agings []*LotAging
+	for _, l := range lotListResult.Lots {
+		if l.PnLUSD == "" {
+			continue
+		}
+		if accountType := config.AccountTypes[l.Account]; accountType != "" && accountType != ibctlconfig.AccountTypeTaxable {
+			continue
+		}
+		openDate, err := xtime.ParseDate(l.Date)
+		if err != nil {
+			continue
+		}
+		longTermDate, ok := config.TaxRules.LongTermDate(openDate)
+		if !ok {
+			continue
+		}
+		daysUntilLongTerm := longTermDate.DaysSince(today)
+		if daysUntilLongTerm <= 0 || daysUntilLongTerm > days {
+			continue
+		}
+		gainMicros := mathpb.ParseMicros(l.PnLUSD)
+		taxDeltaMicros := int64(math.Round(float64(gainMicros) * rateDifference))
+		agings = append(agings, &LotAging{
+			Symbol:            l.Symbol,
+			Account:           l.Account,
+			Date:              l.Date,
+			LongTermDate:      longTermDate.String(),
+			DaysUntilLongTerm: daysUntilLongTerm,
+			Quantity:          l.Quantity,
+			GainUSD:           l.PnLUSD,
+			TaxDeltaUSD:       moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", taxDeltaMicros)),
+			LotID:             l.LotID,
+		})
+	}
+	// Stable, so lots that become long-term on the same date stay in symbol then account order.
+	sort.SliceStable(agings, func(i, j int) bool {
+		return agings[i].DaysUntilLongTerm < agings[j].DaysUntilLongTerm
+	})
+	return agings, nil
+}
+
+// AddFXRates sets the FX rate of every holding whose USD values were converted
+// from another currency, so the conversions can be audited. The fxStore must
+// be the store the holdings were computed with.
+func AddFXRates(holdings []*HoldingOverview, fxStore *ibctlfxrates.Store) {
+	for _, holding := range holdings {
+		holding.FXRate, _ = fxStore.RateToUSD(holding.Currency)
+	}
+}
+
+// AddLotFXRates sets the FX rate of every lot whose USD values were converted
+// from another currency, so the conversions can be audited. The fxStore must
+// be the store the lots were computed with.
+func AddLotFXRates(lots []*LotOverview, fxStore *ibctlfxrates.Store) {
+	for _, lot := range lots {
+		lot.FXRate, _ = fxStore.RateToUSD(lot.Currency)
+	}
+}
+
 // GetHoldingsOverview computes the holdings overview from trade data using FIFO,
 // then verifies against IBKR-reported positions.
 // The result is a combined view aggregated across all accounts.
@@ -529,27 +1342,75 @@ func GetHoldingsOverview(
 	cashPositions []*datav1.CashPosition,
 	config *ibctlconfig.Config,
 	fxStore *ibctlfxrates.Store,
+) (*HoldingsResult, error) {
+	return getHoldingsOverview(trades, positions, cashPositions, config, fxStore, xtime.TimeToDate(time.Now()))
+}
+
+// GetHoldingsOverviewAsOf computes the holdings overview at the end of the
+// date from the trades on or before it, valued at the closing prices in
+// priceStore and classified as short-term or long-term as of the date. The
+// fxStore should be created with ibctlfxrates.NewStoreAsOf for point-in-time
+// conversion. Closing prices of held symbols missing from priceStore are
+// fetched if it has a provider. A held symbol without a closing price in the
+// week up to the date, or a price override dated on or before it, is an error.
+//
+// Cash balances and IBKR-reported positions are only known for the present,
+// so the result has no cash holdings and no position discrepancies.
+func GetHoldingsOverviewAsOf(
+	ctx context.Context,
+	asOf xtime.Date,
+	trades []*datav1.Trade,
+	config *ibctlconfig.Config,
+	fxStore *ibctlfxrates.Store,
+	priceStore *pricestore.Store,
+) (*HoldingsResult, error) {
+	trades = TruncateTrades(trades, asOf)
+	positions, err := asOfPositions(ctx, trades, asOf, config, priceStore)
+	if err != nil {
+		return nil, err
+	}
+	result, err := getHoldingsOverview(trades, positions, nil, config, fxStore, asOf)
+	if err != nil {
+		return nil, err
+	}
+	// The positions only carry historical prices, so there is nothing to verify against.
+	result.PositionDiscrepancies = nil
+	return result, nil
+}
+
+func getHoldingsOverview(
+	trades []*datav1.Trade,
+	positions []*datav1.Position,
+	cashPositions []*datav1.CashPosition,
+	config *ibctlconfig.Config,
+	fxStore *ibctlfxrates.Store,
+	today xtime.Date,
 ) (*HoldingsResult, error) {
 	// Filter out CASH asset category trades (FX conversions like USD.CAD).
-	// These are currency exchanges, not security trades.
+	// These are currency exchanges, not security trades. Symbols ignored in
+	// ibctl.yaml are filtered out as well.
 	var securityTrades []*datav1.Trade
 	for _, trade := range trades {
-		if trade.GetAssetCategory() == assetCategoryCash {
+		if trade.GetAssetCategory() == assetCategoryCash || isIgnoredSymbol(config, trade.GetSymbol()) {
 			continue
 		}
 		securityTrades = append(securityTrades, trade)
 	}
 	// Compute FIFO tax lots from all security trades (seed + CSV + Flex Query).
-	taxLotResult, err := ibctltaxlot.ComputeTaxLots(securityTrades)
+	taxLotResult, err := ibctltaxlot.ComputeTaxLotsCached(config.CacheTaxLotsDirPath(), securityTrades, config.AverageCostSymbols())
 	if err != nil {
 		return nil, err
 	}
 	// Compute per-account positions from tax lots.
-	computedPositions := ibctltaxlot.ComputePositions(taxLotResult.TaxLots)
-	// Filter out CASH positions from IBKR-reported data before verification.
+	computedPositions, err := ibctltaxlot.ComputePositions(taxLotResult.TaxLots)
+	if err != nil {
+		return nil, err
+	}
+	// Filter out CASH positions and ignored symbols from IBKR-reported data
+	// before verification.
 	var securityPositions []*datav1.Position
 	for _, pos := range positions {
-		if pos.GetAssetCategory() == assetCategoryCash {
+		if pos.GetAssetCategory() == assetCategoryCash || isIgnoredSymbol(config, pos.GetSymbol()) {
 			continue
 		}
 		securityPositions = append(securityPositions, pos)
@@ -557,14 +1418,16 @@ func GetHoldingsOverview(
 	// Verify per-account computed positions against IBKR-reported positions.
 	discrepancies := ibctltaxlot.VerifyPositions(computedPositions, securityPositions)
 
-	// Build a map of market prices from IBKR-reported security positions.
+	// Build a map of market prices from IBKR-reported security positions,
+	// falling back to price overrides for symbols without a market price.
 	// Stores both the display string and the position proto for FX conversion.
 	type marketPriceData struct {
 		displayValue string
 		money        *datav1.Position
 	}
-	marketPrices := make(map[string]marketPriceData, len(securityPositions))
-	for _, pos := range securityPositions {
+	pricedPositions, priceOverrides := applyPriceOverrides(trades, securityPositions, config.PriceOverrides, today)
+	marketPrices := make(map[string]marketPriceData, len(pricedPositions))
+	for _, pos := range pricedPositions {
 		marketPrices[pos.GetSymbol()] = marketPriceData{
 			displayValue: moneypb.MoneyValueToString(pos.GetMarketPrice()),
 			money:        pos,
@@ -572,10 +1435,12 @@ func GetHoldingsOverview(
 	}
 
 	// Aggregate computed positions across accounts for combined display.
+	// Quantities and costs are decimal strings so that large bond face values
+	// and crypto quantities do not overflow.
 	type combinedData struct {
-		quantityMicros  int64
-		totalCostMicros int64
-		currencyCode    string
+		quantity     string
+		totalCost    string
+		currencyCode string
 	}
 	combinedMap := make(map[string]*combinedData)
 	for _, pos := range computedPositions {
@@ -585,39 +1450,50 @@ func GetHoldingsOverview(
 			data = &combinedData{currencyCode: pos.GetCurrencyCode()}
 			combinedMap[symbol] = data
 		}
-		qtyMicros := mathpb.ToMicros(pos.GetQuantity())
-		data.quantityMicros += qtyMicros
+		quantity := mathpb.ToString(pos.GetQuantity())
 		// Accumulate total cost for weighted average (price * quantity).
-		// Divide quantity first to avoid int64 overflow with large bond quantities.
-		priceMicros := moneypb.MoneyToMicros(pos.GetAverageCostBasisPrice())
-		qtyUnits := qtyMicros / 1_000_000
-		qtyRemainder := qtyMicros % 1_000_000
-		data.totalCostMicros += priceMicros*qtyUnits + priceMicros*qtyRemainder/1_000_000
+		cost, err := mathpb.MultiplyStrings(moneypb.MoneyValueToString(pos.GetAverageCostBasisPrice()), quantity)
+		if err != nil {
+			return nil, fmt.Errorf("computing cost of %s: %w", symbol, err)
+		}
+		if data.totalCost, err = mathpb.AddStrings(data.totalCost, cost); err != nil {
+			return nil, fmt.Errorf("computing cost of %s: %w", symbol, err)
+		}
+		if data.quantity, err = mathpb.AddStrings(data.quantity, quantity); err != nil {
+			return nil, fmt.Errorf("computing position of %s: %w", symbol, err)
+		}
 	}
 
 	// Build holdings overview from aggregated positions.
 	var holdings []*HoldingOverview
 	for symbol, data := range combinedMap {
-		if data.quantityMicros == 0 {
+		if data.quantity == "0" {
 			continue
 		}
+		position, err := mathpb.NewDecimal(data.quantity)
+		if err != nil {
+			return nil, fmt.Errorf("position of %s: %w", symbol, err)
+		}
 		// Weighted average cost basis = total cost / total quantity.
-		// Divide quantity first to avoid int64 overflow with large bond quantities.
-		qtyUnits := data.quantityMicros / 1_000_000
-		var avgCostMicros int64
-		if qtyUnits != 0 {
-			avgCostMicros = data.totalCostMicros / qtyUnits
-		} else {
-			avgCostMicros = data.totalCostMicros * 1_000_000 / data.quantityMicros
+		averageCost, err := mathpb.DivideStrings(data.totalCost, data.quantity)
+		if err != nil {
+			return nil, fmt.Errorf("computing average cost of %s: %w", symbol, err)
+		}
+		avgCostMoney, err := moneypb.NewProtoMoney(data.currencyCode, averageCost)
+		if err != nil {
+			return nil, fmt.Errorf("average cost of %s: %w", symbol, err)
 		}
 		priceData := marketPrices[symbol]
-		avgCostMoney := moneypb.MoneyFromMicros(data.currencyCode, avgCostMicros)
 		holding := &HoldingOverview{
 			Symbol:       symbol,
 			Currency:     data.currencyCode,
 			LastPrice:    priceData.displayValue,
 			AveragePrice: moneypb.MoneyValueToString(avgCostMoney),
-			Position:     mathpb.FromMicros(data.quantityMicros),
+			Position:     position,
+		}
+		if priceOverride, ok := priceOverrides[symbol]; ok {
+			holding.PriceSource = PriceSourceManual
+			holding.PriceDate = priceOverride.Date.String()
 		}
 		// Convert prices to USD using the most recent FX rate, then compute
 		// market value and unrealized P&L in USD.
@@ -633,27 +1509,35 @@ func GetHoldingsOverview(
 				avgPriceUSDMicros = moneypb.MoneyToMicros(usdMoney)
 				holding.AveragePriceUSD = moneypb.MoneyValueToString(usdMoney)
 			}
-			// Market value USD = last price USD * position.
-			// Bond prices are percentages of par, so divide by 100 for bonds.
-			// Divide quantity first to avoid int64 overflow with large bond face values.
-			isBond := priceData.money != nil && priceData.money.GetAssetCategory() == assetCategoryBond
+			// Market value USD = last price USD * position, scaled for bonds
+			// (percentages of par) and futures and CFDs (contract multipliers).
 			if lastPriceUSDMicros != 0 {
-				qtyRemainder := data.quantityMicros % 1_000_000
-				mktValMicros := lastPriceUSDMicros*qtyUnits + lastPriceUSDMicros*qtyRemainder/1_000_000
-				if isBond {
-					mktValMicros /= 100
+				if holding.MarketValueUSD, err = scaledValue(holding.LastPriceUSD, data.quantity, priceData.money); err != nil {
+					return nil, fmt.Errorf("computing market value of %s: %w", symbol, err)
 				}
-				holding.MarketValueUSD = moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", mktValMicros))
 			}
 			// Unrealized P&L USD = (last price USD - avg price USD) * position.
 			if lastPriceUSDMicros != 0 && avgPriceUSDMicros != 0 {
-				pnlPerShareMicros := lastPriceUSDMicros - avgPriceUSDMicros
-				qtyRemainder := data.quantityMicros % 1_000_000
-				pnlMicros := pnlPerShareMicros*qtyUnits + pnlPerShareMicros*qtyRemainder/1_000_000
-				if isBond {
-					pnlMicros /= 100
+				pnlPerShare := mathpb.ToString(mathpb.FromMicros(lastPriceUSDMicros - avgPriceUSDMicros))
+				if holding.UnrealizedPnLUSD, err = scaledValue(pnlPerShare, data.quantity, priceData.money); err != nil {
+					return nil, fmt.Errorf("computing unrealized P&L of %s: %w", symbol, err)
+				}
+			}
+			// Futures and CFDs are margined, so the notional value is reported
+			// separately and the market value is the unrealized P&L.
+			if priceData.money != nil && ibctltaxlot.IsMultiplierCategory(priceData.money.GetAssetCategory()) {
+				holding.NotionalUSD = holding.MarketValueUSD
+				holding.MarketValueUSD = holding.UnrealizedPnLUSD
+				if symbolConfig, ok := config.SymbolConfigs[symbol]; ok && symbolConfig.MarginMicros != 0 {
+					contracts := position.GetUnits()
+					if contracts < 0 {
+						contracts = -contracts
+					}
+					marginMoney := moneypb.MoneyFromMicros(data.currencyCode, symbolConfig.MarginMicros*contracts)
+					if usdMoney, ok := fxStore.ConvertToUSD(marginMoney); ok {
+						holding.MarginUSD = moneypb.MoneyValueToString(usdMoney)
+					}
 				}
-				holding.UnrealizedPnLUSD = moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", pnlMicros))
 			}
 		}
 		// Merge symbol classification from config.
@@ -667,21 +1551,13 @@ func GetHoldingsOverview(
 	}
 
 	// Compute per-lot STCG/LTCG split from individual tax lots.
-	// Each lot's P&L is classified as short-term (<365 days) or long-term (>=365 days).
-	today := xtime.Date{
-		Year:  time.Now().Year(),
-		Month: time.Now().Month(),
-		Day:   time.Now().Day(),
-	}
+	// Each lot's P&L is classified as short-term or long-term by the configured tax rules.
 	// Build a map of last price USD micros per symbol for lot-level P&L computation.
 	lastPriceUSDMap := make(map[string]int64, len(holdings))
-	isBondMap := make(map[string]bool, len(holdings))
 	for _, h := range holdings {
 		if h.LastPriceUSD != "" {
 			lastPriceUSDMap[h.Symbol] = mathpb.ParseMicros(h.LastPriceUSD)
 		}
-		priceData := marketPrices[h.Symbol]
-		isBondMap[h.Symbol] = priceData.money != nil && priceData.money.GetAssetCategory() == assetCategoryBond
 	}
 	// Accumulate STCG and LTCG per symbol from individual tax lots.
 	type gainSplit struct {
@@ -706,18 +1582,14 @@ func GetHoldingsOverview(
 		lotQtyMicros := mathpb.ToMicros(lot.GetQuantity())
 		lotQtyUnits := lotQtyMicros / 1_000_000
 		lotQtyRemainder := lotQtyMicros % 1_000_000
-		lotPnLMicros := pnlPerUnitMicros*lotQtyUnits + pnlPerUnitMicros*lotQtyRemainder/1_000_000
-		// Bond prices are percentages of par — divide P&L by 100.
-		if isBondMap[symbol] {
-			lotPnLMicros /= 100
-		}
+		lotPnLMicros := scaleMicros(pnlPerUnitMicros*lotQtyUnits+pnlPerUnitMicros*lotQtyRemainder/1_000_000, marketPrices[symbol].money)
 		// Classify by holding period.
 		gs := gainsBySymbol[symbol]
 		if gs == nil {
 			gs = &gainSplit{}
 			gainsBySymbol[symbol] = gs
 		}
-		longTerm, err := ibctltaxlot.IsLongTerm(lot, today)
+		longTerm, err := ibctltaxlot.IsLongTerm(lot, today, config.TaxRules)
 		if err != nil {
 			continue
 		}
@@ -808,7 +1680,138 @@ func GetHoldingsOverview(
 		holdings = append(holdings, holding)
 	}
 
-	// Sort by category (cash last) then symbol for deterministic output.
+	sortHoldings(holdings)
+	return &HoldingsResult{
+		Holdings:              holdings,
+		UnmatchedSells:        taxLotResult.UnmatchedSells,
+		PositionDiscrepancies: discrepancies,
+	}, nil
+}
+
+// CombineHoldingsResults combines the holdings results of separate ibctl
+// directories into a single result, as if all accounts were in one directory.
+//
+// Holdings of the same symbol are combined into one holding: positions and
+// USD values are summed, and average prices are weighted by position. Prices,
+// classifications, and FX rates are taken from the first result with the
+// symbol. A USD value is empty if it is empty in any of the results, since the
+// sum would be understated. Unmatched sells and position discrepancies are
+// concatenated.
+func CombineHoldingsResults(results []*HoldingsResult) *HoldingsResult {
+	combinedResult := &HoldingsResult{}
+	type combinedHolding struct {
+		holding            *HoldingOverview
+		totalCost          string
+		totalCostUSD       string
+		hasAveragePriceUSD bool
+	}
+	symbolToCombined := make(map[string]*combinedHolding)
+	var symbols []string
+	for _, result := range results {
+		combinedResult.UnmatchedSells = append(combinedResult.UnmatchedSells, result.UnmatchedSells...)
+		combinedResult.PositionDiscrepancies = append(combinedResult.PositionDiscrepancies, result.PositionDiscrepancies...)
+		for _, h := range result.Holdings {
+			quantity := mathpb.ToString(h.Position)
+			combined, ok := symbolToCombined[h.Symbol]
+			if !ok {
+				holding := *h
+				combined = &combinedHolding{
+					holding:            &holding,
+					hasAveragePriceUSD: h.AveragePriceUSD != "",
+				}
+				symbolToCombined[h.Symbol] = combined
+				symbols = append(symbols, h.Symbol)
+			} else {
+				holding := combined.holding
+				holding.Position = addDecimals(holding.Position, h.Position)
+				holding.MarketValueUSD = addMicrosStrings(holding.MarketValueUSD, h.MarketValueUSD)
+				holding.UnrealizedPnLUSD = addMicrosStrings(holding.UnrealizedPnLUSD, h.UnrealizedPnLUSD)
+				holding.STCGUSD = addMicrosStrings(holding.STCGUSD, h.STCGUSD)
+				holding.LTCGUSD = addMicrosStrings(holding.LTCGUSD, h.LTCGUSD)
+				holding.NotionalUSD = addMicrosStrings(holding.NotionalUSD, h.NotionalUSD)
+				holding.MarginUSD = addMicrosStrings(holding.MarginUSD, h.MarginUSD)
+				// Dividends per share do not depend on the directory, but only
+				// directories with dividend records have them.
+				if holding.DividendsPerShareUSD == "" {
+					holding.DividendsPerShareUSD = h.DividendsPerShareUSD
+				}
+				combined.hasAveragePriceUSD = combined.hasAveragePriceUSD && h.AveragePriceUSD != ""
+			}
+			// Accumulate total cost (price * quantity) for the weighted average.
+			combined.totalCost = addTotalString(combined.totalCost, multiplyStrings(h.AveragePrice, quantity))
+			combined.totalCostUSD = addTotalString(combined.totalCostUSD, multiplyStrings(h.AveragePriceUSD, quantity))
+		}
+	}
+	for _, symbol := range symbols {
+		combined := symbolToCombined[symbol]
+		holding := combined.holding
+		quantity := mathpb.ToString(holding.Position)
+		// Long and short positions in separate directories can net to zero.
+		if quantity == "0" {
+			continue
+		}
+		// Cash holdings have a fixed price of 1, and their USD price is the FX rate.
+		if holding.Category != assetCategoryCash {
+			holding.AveragePrice = divideStrings(combined.totalCost, quantity)
+			holding.AveragePriceUSD = ""
+			if combined.hasAveragePriceUSD {
+				holding.AveragePriceUSD = divideStrings(combined.totalCostUSD, quantity)
+			}
+		}
+		setIncomeYield(holding)
+		combinedResult.Holdings = append(combinedResult.Holdings, holding)
+	}
+	sortHoldings(combinedResult.Holdings)
+	return combinedResult
+}
+
+// AddIncomeYields sets the dividends per share, yield, and projected annual
+// income of every holding with trailing dividends per share in
+// symbolToDividendsPerShare, as returned by
+// ibctlincome.GetTrailingDividendsPerShare. Holdings whose dividends cannot be
+// converted to USD are left unset.
+func AddIncomeYields(holdings []*HoldingOverview, symbolToDividendsPerShare map[string]*moneyv1.Money, fxStore *ibctlfxrates.Store) {
+	for _, h := range holdings {
+		dividendsPerShare, ok := symbolToDividendsPerShare[h.Symbol]
+		if !ok || h.Category == assetCategoryCash {
+			continue
+		}
+		dividendsPerShareUSD, ok := fxStore.ConvertToUSD(dividendsPerShare)
+		if !ok {
+			continue
+		}
+		h.DividendsPerShareUSD = moneypb.MoneyValueToString(dividendsPerShareUSD)
+		setIncomeYield(h)
+	}
+}
+
+// setIncomeYield sets the yield and projected income of the holding from its
+// dividends per share.
+func setIncomeYield(h *HoldingOverview) {
+	if h.DividendsPerShareUSD == "" {
+		return
+	}
+	h.ProjectedIncomeUSD = multiplyStrings(h.DividendsPerShareUSD, mathpb.ToString(h.Position))
+	h.Yield = yieldString(h.DividendsPerShareUSD, h.LastPriceUSD)
+}
+
+// yieldString returns income / value for decimal strings as a percentage
+// (e.g., "2.31%"), or empty if value is empty or not positive.
+func yieldString(income string, value string) string {
+	incomeFloat, err := strconv.ParseFloat(income, 64)
+	if err != nil {
+		return ""
+	}
+	valueFloat, err := strconv.ParseFloat(value, 64)
+	if err != nil || valueFloat <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.2f%%", incomeFloat/valueFloat*100)
+}
+
+// sortHoldings sorts holdings by category (cash last) then symbol for
+// deterministic output.
+func sortHoldings(holdings []*HoldingOverview) {
 	sort.Slice(holdings, func(i, j int) bool {
 		// Cash positions sort after all other categories.
 		iCash := holdings[i].Category == assetCategoryCash
@@ -818,9 +1821,240 @@ func GetHoldingsOverview(
 		}
 		return holdings[i].Symbol < holdings[j].Symbol
 	})
-	return &HoldingsResult{
-		Holdings:              holdings,
-		UnmatchedSells:        taxLotResult.UnmatchedSells,
-		PositionDiscrepancies: discrepancies,
-	}, nil
+}
+
+// addMicrosStrings returns the sum of two decimal strings, or empty if either is empty.
+func addMicrosStrings(a string, b string) string {
+	if a == "" || b == "" {
+		return ""
+	}
+	return addTotalString(a, b)
+}
+
+// addTotalString returns total + value for decimal strings, with an empty
+// value as 0. The total is unchanged if the value is invalid.
+func addTotalString(total string, value string) string {
+	sum, err := mathpb.AddStrings(total, value)
+	if err != nil {
+		return total
+	}
+	return sum
+}
+
+// multiplyStrings returns a * b for decimal strings, with empty strings as 0,
+// or empty if either is invalid.
+func multiplyStrings(a string, b string) string {
+	product, err := mathpb.MultiplyStrings(a, b)
+	if err != nil {
+		return ""
+	}
+	return product
+}
+
+// divideStrings returns total / quantity for decimal strings, truncated to 6
+// decimal places, or empty if quantity is zero or either is invalid.
+func divideStrings(total string, quantity string) string {
+	quotient, err := mathpb.DivideStrings(total, quantity)
+	if err != nil {
+		return ""
+	}
+	return quotient
+}
+
+// addDecimals returns a + b, or a if the sum does not fit in a Decimal.
+func addDecimals(a *mathv1.Decimal, b *mathv1.Decimal) *mathv1.Decimal {
+	sum, err := mathpb.Add(a, b)
+	if err != nil {
+		return a
+	}
+	return sum
+}
+
+// scaledValue returns price * quantity for decimal strings, scaled for the
+// asset category of the position as in scaleMicros.
+func scaledValue(price string, quantity string, position *datav1.Position) (string, error) {
+	value, err := mathpb.MultiplyStrings(price, quantity)
+	if err != nil {
+		return "", err
+	}
+	if position == nil {
+		return value, nil
+	}
+	if position.GetAssetCategory() == assetCategoryBond {
+		return mathpb.DivideStrings(value, "100")
+	}
+	return mathpb.MultiplyStrings(value, strconv.FormatInt(ibctltaxlot.PositionMultiplier(position), 10))
+}
+
+// scaleMicros scales a price times quantity in micros to a value for the
+// asset category of the position. Bond prices are percentages of par, so the
+// value is divided by 100, and futures and CFD prices are per unit of the
+// contract multiplier, so the value is multiplied by it. A nil position is
+// not scaled.
+func scaleMicros(micros int64, position *datav1.Position) int64 {
+	if position == nil {
+		return micros
+	}
+	if position.GetAssetCategory() == assetCategoryBond {
+		return micros / 100
+	}
+	return micros * ibctltaxlot.PositionMultiplier(position)
+}
+
+// isIgnoredSymbol returns true if the symbol is in the ignore_symbols list of ibctl.yaml.
+func isIgnoredSymbol(config *ibctlconfig.Config, symbol string) bool {
+	_, ok := config.IgnoreSymbols[symbol]
+	return ok
+}
+
+// applyPriceOverrides returns the positions with a price-only position, as in
+// asOfPositions, for every symbol in the trades with a price override dated
+// on or before the date and no non-zero market price in the positions. The
+// price-only position replaces any positions of the symbol. The applied
+// overrides are returned by symbol.
+func applyPriceOverrides(
+	trades []*datav1.Trade,
+	positions []*datav1.Position,
+	priceOverrides map[string]ibctlconfig.PriceOverride,
+	asOf xtime.Date,
+) ([]*datav1.Position, map[string]ibctlconfig.PriceOverride) {
+	if len(priceOverrides) == 0 {
+		return positions, nil
+	}
+	pricedSymbols := make(map[string]struct{})
+	for _, pos := range positions {
+		if moneypb.MoneyToMicros(pos.GetMarketPrice()) != 0 {
+			pricedSymbols[pos.GetSymbol()] = struct{}{}
+		}
+	}
+	symbolToTrade := make(map[string]*datav1.Trade)
+	for _, trade := range trades {
+		if trade.GetAssetCategory() == assetCategoryCash || ibctltaxlot.IsMarkToMarketSettlement(trade) {
+			continue
+		}
+		symbolToTrade[trade.GetSymbol()] = trade
+	}
+	appliedPriceOverrides := make(map[string]ibctlconfig.PriceOverride)
+	var overridePositions []*datav1.Position
+	for symbol, priceOverride := range priceOverrides {
+		trade, ok := symbolToTrade[symbol]
+		if !ok || priceOverride.Date.After(asOf) {
+			continue
+		}
+		if _, ok := pricedSymbols[symbol]; ok {
+			continue
+		}
+		currency := trade.GetCurrencyCode()
+		appliedPriceOverrides[symbol] = priceOverride
+		overridePositions = append(overridePositions, &datav1.Position{
+			Symbol:        symbol,
+			AssetCategory: trade.GetAssetCategory(),
+			Quantity:      mathpb.FromMicros(1_000_000),
+			MarketPrice:   moneypb.MoneyFromMicros(currency, priceOverride.PriceMicros),
+			MarketValue:   moneypb.MoneyFromMicros(currency, priceOverride.PriceMicros*ibctltaxlot.TradeMultiplier(trade)),
+			CurrencyCode:  currency,
+		})
+	}
+	if len(overridePositions) == 0 {
+		return positions, nil
+	}
+	result := make([]*datav1.Position, 0, len(positions)+len(overridePositions))
+	for _, pos := range positions {
+		if _, ok := appliedPriceOverrides[pos.GetSymbol()]; !ok {
+			result = append(result, pos)
+		}
+	}
+	return append(result, overridePositions...), appliedPriceOverrides
+}
+
+// formatLastPrice returns the last price for table display, marking manual
+// price overrides with their date (e.g., "1.25 (manual 2025-06-30)").
+func formatLastPrice(lastPrice string, priceSource string, priceDate string) string {
+	if priceSource != PriceSourceManual || lastPrice == "" {
+		return lastPrice
+	}
+	return lastPrice + " (" + PriceSourceManual + " " + priceDate + ")"
+}
+
+// asOfPositions returns a position per symbol held at the end of the date
+// carrying its closing price on the date, or the most recent one in the
+// preceding asOfPriceLookbackDays, for valuing holdings at the date. Only the
+// symbol, asset category, and market price are meaningful, plus a quantity of
+// 1 and a market value of the price times the contract multiplier so that
+// ibctltaxlot.PositionMultiplier works.
+//
+// Prices of stocks and funds missing from priceStore are fetched if it has a
+// provider, since other asset categories are not priced by ibctlprices. Symbols
+// with a price override dated on or before the date are left to
+// applyPriceOverrides, and every other symbol without a price is an error.
+func asOfPositions(
+	ctx context.Context,
+	trades []*datav1.Trade,
+	asOf xtime.Date,
+	config *ibctlconfig.Config,
+	priceStore *pricestore.Store,
+) ([]*datav1.Position, error) {
+	var securityTrades []*datav1.Trade
+	symbolToTrade := make(map[string]*datav1.Trade)
+	for _, trade := range trades {
+		if trade.GetAssetCategory() == assetCategoryCash || ibctltaxlot.IsMarkToMarketSettlement(trade) || isIgnoredSymbol(config, trade.GetSymbol()) {
+			continue
+		}
+		securityTrades = append(securityTrades, trade)
+		symbolToTrade[trade.GetSymbol()] = trade
+	}
+	// Only symbols with open lots at the date need a price.
+	taxLotResult, err := ibctltaxlot.ComputeTaxLotsCached(config.CacheTaxLotsDirPath(), securityTrades, config.AverageCostSymbols())
+	if err != nil {
+		return nil, err
+	}
+	heldSymbols := make(map[string]struct{})
+	for _, taxLot := range taxLotResult.TaxLots {
+		heldSymbols[taxLot.GetSymbol()] = struct{}{}
+	}
+	symbols := make([]string, 0, len(heldSymbols))
+	for symbol := range heldSymbols {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	lookbackStart := asOf.AddDays(-asOfPriceLookbackDays)
+	var positions []*datav1.Position
+	var errs []error
+	for _, symbol := range symbols {
+		trade := symbolToTrade[symbol]
+		// A failed fetch is only an error if no price is cached either.
+		var fetchErr error
+		if priceStore.HasProvider() && ibctlprices.IsPriced(trade.GetAssetCategory()) {
+			_, fetchErr = priceStore.Update(ctx, symbol, lookbackStart, asOf)
+		}
+		price, ok, err := priceStore.Close(symbol, asOf)
+		if err != nil {
+			return nil, err
+		}
+		if !ok || price.Date.Before(lookbackStart) {
+			if priceOverride, ok := config.PriceOverrides[symbol]; ok && !priceOverride.Date.After(asOf) {
+				continue
+			}
+			if fetchErr != nil {
+				errs = append(errs, fmt.Errorf("no price for %s on %s: %w", symbol, asOf, fetchErr))
+			} else {
+				errs = append(errs, fmt.Errorf("no price for %s on %s", symbol, asOf))
+			}
+			continue
+		}
+		currency := trade.GetCurrencyCode()
+		priceMicros := mathpb.ParseMicros(price.Close)
+		positions = append(positions, &datav1.Position{
+			Symbol:        symbol,
+			AssetCategory: trade.GetAssetCategory(),
+			Quantity:      mathpb.FromMicros(1_000_000),
+			MarketPrice:   moneypb.MoneyFromMicros(currency, priceMicros),
+			MarketValue:   moneypb.MoneyFromMicros(currency, priceMicros*ibctltaxlot.TradeMultiplier(trade)),
+			CurrencyCode:  currency,
+		})
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return positions, nil
 }
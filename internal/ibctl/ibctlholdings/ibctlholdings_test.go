@@ -0,0 +1,242 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctlholdings
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctltaxlot"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctltaxrules"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/bufdev/ibctl/internal/pkg/pricestore"
+	"github.com/bufdev/ibctl/internal/pkg/timepb"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCombineHoldingsResults(t *testing.T) {
+	t.Parallel()
+	aapl1 := newHolding(t, "AAPL", "10", "150", "150")
+	aapl1.MarketValueUSD = "1800"
+	aapl2 := newHolding(t, "AAPL", "30", "170", "170")
+	aapl2.MarketValueUSD = "5400.5"
+	result := CombineHoldingsResults([]*HoldingsResult{
+		{
+			Holdings: []*HoldingOverview{
+				aapl1,
+				newHolding(t, "MSFT", "5", "300", ""),
+				newHolding(t, "TSLA", "3", "200", "200"),
+			},
+			UnmatchedSells: []ibctltaxlot.UnmatchedSell{{Symbol: "AAPL"}},
+		},
+		{
+			Holdings: []*HoldingOverview{
+				aapl2,
+				newHolding(t, "MSFT", "5", "320", "320"),
+				newHolding(t, "TSLA", "-3", "210", "210"),
+				newHolding(t, "VTI", "2", "250", "250"),
+			},
+		},
+	})
+	require.Len(t, result.UnmatchedSells, 1)
+	// Long and short positions that net to zero are dropped.
+	require.Equal(t, []string{"AAPL", "MSFT", "VTI"}, holdingSymbols(result.Holdings))
+	aapl := result.Holdings[0]
+	require.Equal(t, "40", mathpb.ToString(aapl.Position))
+	// (10 * 150 + 30 * 170) / 40
+	require.Equal(t, "165", aapl.AveragePrice)
+	require.Equal(t, "165", aapl.AveragePriceUSD)
+	require.Equal(t, "7200.5", aapl.MarketValueUSD)
+	// A USD average price missing in any result is missing in the combined holding.
+	msft := result.Holdings[1]
+	require.Equal(t, "310", msft.AveragePrice)
+	require.Empty(t, msft.AveragePriceUSD)
+	require.Equal(t, "250", result.Holdings[2].AveragePrice)
+}
+
+func TestCombineHoldingsResultsFractional(t *testing.T) {
+	t.Parallel()
+	result := CombineHoldingsResults([]*HoldingsResult{
+		{Holdings: []*HoldingOverview{newHolding(t, "BTC", "1", "100", "100")}},
+		{Holdings: []*HoldingOverview{newHolding(t, "BTC", "0.5", "130", "130")}},
+		{Holdings: []*HoldingOverview{newHolding(t, "ETH", "0.25", "2000", "2000")}},
+	})
+	require.Len(t, result.Holdings, 2)
+	btc := result.Holdings[0]
+	require.Equal(t, "1.5", mathpb.ToString(btc.Position))
+	// (1 * 100 + 0.5 * 130) / 1.5, not divided by the whole units of the position.
+	require.Equal(t, "110", btc.AveragePrice)
+	require.Equal(t, "110", btc.AveragePriceUSD)
+	// A position below one unit is averaged as is.
+	require.Equal(t, "2000", result.Holdings[1].AveragePrice)
+}
+
+func TestCombineHoldingsResultsLargeCost(t *testing.T) {
+	t.Parallel()
+	// A total cost above about $9.2M overflows int64 micros when scaled by 1e6.
+	result := CombineHoldingsResults([]*HoldingsResult{
+		{Holdings: []*HoldingOverview{newHolding(t, "BRK A", "20", "700000", "700000")}},
+		{Holdings: []*HoldingOverview{newHolding(t, "BRK A", "10.5", "730000", "730000")}},
+	})
+	require.Len(t, result.Holdings, 1)
+	// (20 * 700000 + 10.5 * 730000) / 30.5
+	require.Equal(t, "710327.868852", result.Holdings[0].AveragePrice)
+}
+
+func TestCombineHoldingsResultsLargePosition(t *testing.T) {
+	t.Parallel()
+	// The total cost of a 200 billion face value bond position overflows int64 micros.
+	bond := newHolding(t, "T", "200000000000", "100.5", "100.5")
+	bond.MarketValueUSD = "201000000000"
+	result := CombineHoldingsResults([]*HoldingsResult{
+		{Holdings: []*HoldingOverview{bond}},
+		{Holdings: []*HoldingOverview{newHolding(t, "T", "100000000000", "99", "99")}},
+	})
+	require.Len(t, result.Holdings, 1)
+	require.Equal(t, "300000000000", mathpb.ToString(result.Holdings[0].Position))
+	require.Equal(t, "100", result.Holdings[0].AveragePrice)
+	require.Equal(t, "100", result.Holdings[0].AveragePriceUSD)
+}
+
+func TestComputeTotalsLarge(t *testing.T) {
+	t.Parallel()
+	// The total market value of two positions of $9T each overflows int64 micros.
+	holdings := []*HoldingOverview{
+		{Symbol: "T", MarketValueUSD: "9000000000000", UnrealizedPnLUSD: "-1.5", ProjectedIncomeUSD: "360000000000"},
+		{Symbol: "BTC", MarketValueUSD: "9000000000000.25", UnrealizedPnLUSD: "2"},
+	}
+	totals := ComputeTotals(holdings)
+	require.Equal(t, "18000000000000.25", totals.MarketValueUSD)
+	require.Equal(t, "0.5", totals.UnrealizedPnLUSD)
+	require.Equal(t, "0", totals.STCGUSD)
+	require.Equal(t, "360000000000", totals.ProjectedIncomeUSD)
+	require.Equal(t, "2.00%", totals.Yield)
+}
+
+func TestScaledValue(t *testing.T) {
+	t.Parallel()
+	// Bond prices are percentages of par.
+	value, err := scaledValue("100.5", "200000000000", &datav1.Position{AssetCategory: assetCategoryBond})
+	require.NoError(t, err)
+	require.Equal(t, "201000000000", value)
+	value, err = scaledValue("65000.5", "200000000", nil)
+	require.NoError(t, err)
+	require.Equal(t, "13000100000000", value)
+}
+
+func TestGetHoldingsOverviewAsOf(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	// A Monday, so the latest close is of the Friday before.
+	asOf := xtime.Date{Year: 2025, Month: time.June, Day: 30}
+	trades := []*datav1.Trade{
+		newTestTrade(t, "AAPL", "2025-01-10", datav1.TradeSide_TRADE_SIDE_BUY, 10),
+		// Sold before the date, so no price is needed.
+		newTestTrade(t, "MSFT", "2025-02-03", datav1.TradeSide_TRADE_SIDE_BUY, 5),
+		newTestTrade(t, "MSFT", "2025-03-03", datav1.TradeSide_TRADE_SIDE_SELL, -5),
+		// Bought after the date.
+		newTestTrade(t, "NVDA", "2025-07-01", datav1.TradeSide_TRADE_SIDE_BUY, 5),
+		newTestTrade(t, "XYZ", "2025-01-10", datav1.TradeSide_TRADE_SIDE_BUY, 100),
+	}
+	provider := &testPriceProvider{
+		symbolToPrices: map[string][]pricestore.Price{
+			"AAPL": {
+				{Date: xtime.Date{Year: 2025, Month: time.June, Day: 26}, Close: "200"},
+				{Date: xtime.Date{Year: 2025, Month: time.June, Day: 27}, Close: "201.08"},
+			},
+		},
+	}
+	pricesDirPath := t.TempDir()
+	priceStore := pricestore.NewStore(pricesDirPath, provider)
+	fxStore := ibctlfxrates.NewStoreAsOf(t.TempDir(), asOf)
+	taxRules, err := ibctltaxrules.NewRules(ibctltaxrules.JurisdictionUS, 0)
+	require.NoError(t, err)
+	config := &ibctlconfig.Config{TaxRules: taxRules}
+	// XYZ has no price and no price override.
+	_, err = GetHoldingsOverviewAsOf(ctx, asOf, trades, config, fxStore, priceStore)
+	require.EqualError(t, err, "no price for XYZ on 2025-06-30: fetching XYZ prices for 2025-06-23..2025-06-30: Not Found")
+	require.Equal(t, []string{"AAPL", "XYZ"}, provider.requestedSymbols)
+
+	config.PriceOverrides = map[string]ibctlconfig.PriceOverride{
+		"XYZ": {PriceMicros: 1_500_000, Date: xtime.Date{Year: 2025, Month: time.May, Day: 1}},
+	}
+	result, err := GetHoldingsOverviewAsOf(ctx, asOf, trades, config, fxStore, priceStore)
+	require.NoError(t, err)
+	require.Equal(t, []string{"AAPL", "XYZ"}, holdingSymbols(result.Holdings))
+	require.Equal(t, "201.08", result.Holdings[0].LastPrice)
+	require.Equal(t, PriceSourceManual, result.Holdings[1].PriceSource)
+
+	// Without a provider, only cached prices from the week up to the date are used.
+	readOnlyStore := pricestore.NewStore(pricesDirPath, nil)
+	result, err = GetHoldingsOverviewAsOf(ctx, asOf, trades, config, fxStore, readOnlyStore)
+	require.NoError(t, err)
+	require.Equal(t, "201.08", result.Holdings[0].LastPrice)
+	laterAsOf := asOf.AddDays(14)
+	_, err = GetHoldingsOverviewAsOf(ctx, laterAsOf, trades, config, ibctlfxrates.NewStoreAsOf(t.TempDir(), laterAsOf), readOnlyStore)
+	require.EqualError(t, err, "no price for AAPL on 2025-07-14\nno price for NVDA on 2025-07-14")
+}
+
+func newHolding(t *testing.T, symbol string, position string, averagePrice string, averagePriceUSD string) *HoldingOverview {
+	t.Helper()
+	positionDecimal, err := mathpb.NewDecimal(position)
+	require.NoError(t, err)
+	return &HoldingOverview{
+		Symbol:          symbol,
+		Currency:        "USD",
+		AveragePrice:    averagePrice,
+		AveragePriceUSD: averagePriceUSD,
+		Position:        positionDecimal,
+	}
+}
+
+func holdingSymbols(holdings []*HoldingOverview) []string {
+	symbols := make([]string, 0, len(holdings))
+	for _, holding := range holdings {
+		symbols = append(symbols, holding.Symbol)
+	}
+	return symbols
+}
+
+// testPriceProvider is a pricestore.Provider serving fixed prices, and
+// failing for symbols without any.
+type testPriceProvider struct {
+	symbolToPrices   map[string][]pricestore.Price
+	requestedSymbols []string
+}
+
+func (p *testPriceProvider) GetDailyCloses(_ context.Context, symbol string, _ xtime.Date, _ xtime.Date) ([]pricestore.Price, error) {
+	p.requestedSymbols = append(p.requestedSymbols, symbol)
+	prices, ok := p.symbolToPrices[symbol]
+	if !ok {
+		return nil, errors.New("Not Found")
+	}
+	return prices, nil
+}
+
+func newTestTrade(t *testing.T, symbol string, date string, side datav1.TradeSide, quantity int64) *datav1.Trade {
+	t.Helper()
+	tradeDate, err := xtime.ParseDate(date)
+	require.NoError(t, err)
+	protoDate, err := timepb.NewProtoDate(tradeDate.Year, tradeDate.Month, tradeDate.Day)
+	require.NoError(t, err)
+	return &datav1.Trade{
+		TradeId:       symbol + date,
+		TradeDate:     protoDate,
+		Symbol:        symbol,
+		AssetCategory: "STK",
+		Side:          side,
+		Quantity:      mathpb.FromMicros(quantity * 1_000_000),
+		TradePrice:    moneypb.MoneyFromMicros("USD", 100_000_000),
+		CurrencyCode:  "USD",
+		AccountId:     "individual",
+	}
+}
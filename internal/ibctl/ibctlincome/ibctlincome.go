@@ -0,0 +1,927 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctlincome provides income and cash flow reporting for ibctl.
+//
+// Income is computed from the Flex Query Cash Transactions section:
+// dividends, payments in lieu, withholding tax, and interest. Other cash
+// flows (deposits, withdrawals, fees, commission adjustments) can optionally
+// be included for a full cash flow view. Deposits, withdrawals, and fees from
+// Activity Statement CSVs are merged into the cash transactions, so the cash
+// flow view also covers the history before the Flex Query window.
+//
+// The dividend calendar projects future dividend payments of current holdings
+// from the cadence and per-share rates of past dividend payments, and the
+// trailing dividends per share give the dividend yield of current holdings.
+package ibctlincome
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	mathv1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/math/v1"
+	moneyv1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/money/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/bufdev/ibctl/internal/pkg/timepb"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+)
+
+// cashTransactionTypePrefix is the enum name prefix stripped for display.
+const cashTransactionTypePrefix = "CASH_TRANSACTION_TYPE_"
+
+// IncomeListResult contains the income list output.
+type IncomeListResult struct {
+	// Transactions is the list of cash transactions for display, sorted by date.
+	Transactions []*IncomeOverview
+}
+
+// IncomeOverview represents a single cash transaction for display.
+type IncomeOverview struct {
+	// Date is the transaction date (YYYY-MM-DD).
+	Date string `json:"date"`
+	// Account is the account alias.
+	Account string `json:"account"`
+	// Type is the cash transaction type (e.g., "DIVIDEND", "WITHHOLDING_TAX").
+	Type string `json:"type"`
+	// Symbol is the related ticker symbol, if any.
+	Symbol string `json:"symbol,omitempty"`
+	// Currency is the native currency code.
+	Currency string `json:"currency"`
+	// Amount is the signed amount in native currency.
+	Amount string `json:"amount"`
+	// AmountUSD is the signed amount converted to USD. Empty if no FX rate is available.
+	AmountUSD string `json:"amount_usd,omitempty"`
+	// Description is the IBKR description.
+	Description string `json:"description,omitempty"`
+}
+
+// incomeListAmountUSDColumn is the index of the AMOUNT USD column of IncomeListHeaders.
+const incomeListAmountUSDColumn = 6
+
+// IncomeListHeaders returns the column headers for income list table/CSV output.
+func IncomeListHeaders() []string {
+	return []string{"DATE", "ACCOUNT", "TYPE", "SYMBOL", "CURRENCY", "AMOUNT", "AMOUNT USD", "DESCRIPTION"}
+}
+
+// IncomeOverviewToRow converts an IncomeOverview to a string slice for CSV output.
+func IncomeOverviewToRow(i *IncomeOverview) []string {
+	return []string{
+		i.Date,
+		i.Account,
+		i.Type,
+		i.Symbol,
+		i.Currency,
+		i.Amount,
+		i.AmountUSD,
+		i.Description,
+	}
+}
+
+// IncomeOverviewToTableRow converts an IncomeOverview to a string slice for table display.
+// The USD column is formatted with $ prefix, comma separators, rounded to cents.
+func IncomeOverviewToTableRow(i *IncomeOverview) []string {
+	return []string{
+		i.Date,
+		i.Account,
+		i.Type,
+		i.Symbol,
+		i.Currency,
+		i.Amount,
+		cliio.FormatUSD(i.AmountUSD),
+		i.Description,
+	}
+}
+
+// IncomeTotals holds the formatted total values for the income list summary row.
+type IncomeTotals struct {
+	// AmountUSD is the net total of all amounts in USD.
+	AmountUSD string
+	// MissingUSD is the number of amounts without a USD conversion, which are
+	// not included in AmountUSD.
+	MissingUSD int
+}
+
+// ComputeIncomeTotals sums the USD amounts across all transactions.
+func ComputeIncomeTotals(transactions []*IncomeOverview) *IncomeTotals {
+	var totalMicros int64
+	var missingUSD int
+	for _, i := range transactions {
+		if i.AmountUSD == "" {
+			missingUSD++
+			continue
+		}
+		totalMicros += mathpb.ParseMicros(i.AmountUSD)
+	}
+	return &IncomeTotals{
+		AmountUSD:  cliio.FormatUSDMicros(totalMicros),
+		MissingUSD: missingUSD,
+	}
+}
+
+// IncomeTotalsToTableRow converts IncomeTotals to a TOTAL row aligned with
+// IncomeListHeaders for table display.
+func IncomeTotalsToTableRow(totals *IncomeTotals) []string {
+	return totals.tableRow(len(IncomeListHeaders()), incomeListAmountUSDColumn)
+}
+
+// IsIncomeType returns true if the cash transaction type is investment income
+// or a direct offset to it (dividends, payments in lieu, withholding tax, interest).
+func IsIncomeType(cashTransactionType datav1.CashTransactionType) bool {
+	switch cashTransactionType {
+	case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_DIVIDEND,
+		datav1.CashTransactionType_CASH_TRANSACTION_TYPE_PAYMENT_IN_LIEU,
+		datav1.CashTransactionType_CASH_TRANSACTION_TYPE_WITHHOLDING_TAX,
+		datav1.CashTransactionType_CASH_TRANSACTION_TYPE_INTEREST_RECEIVED,
+		datav1.CashTransactionType_CASH_TRANSACTION_TYPE_INTEREST_PAID:
+		return true
+	default:
+		return false
+	}
+}
+
+// GetIncomeList builds the income list from cash transactions.
+//
+// If includeAllCashFlows is false, only income types are included (see IsIncomeType).
+// If includeAllCashFlows is true, every cash transaction is included, giving a
+// complete cash flow view including deposits, withdrawals, and fees.
+// Amounts are converted to USD at the FX rate on their date, so historical
+// amounts do not change as rates move.
+func GetIncomeList(
+	cashTransactions []*datav1.CashTransaction,
+	includeAllCashFlows bool,
+	fxStore *ibctlfxrates.Store,
+) (*IncomeListResult, error) {
+	result := &IncomeListResult{}
+	for _, cashTransaction := range cashTransactions {
+		if !includeAllCashFlows && !IsIncomeType(cashTransaction.GetType()) {
+			continue
+		}
+		amount := cashTransaction.GetAmount()
+		if amount == nil {
+			return nil, fmt.Errorf("cash transaction for account %s on %s has no amount", cashTransaction.GetAccountId(), dateString(cashTransaction))
+		}
+		date, err := timepb.ProtoToDate(cashTransaction.GetDate())
+		if err != nil {
+			return nil, fmt.Errorf("cash transaction for account %s: %w", cashTransaction.GetAccountId(), err)
+		}
+		overview := &IncomeOverview{
+			Date:        dateString(cashTransaction),
+			Account:     cashTransaction.GetAccountId(),
+			Type:        CashTransactionTypeString(cashTransaction.GetType()),
+			Symbol:      cashTransaction.GetSymbol(),
+			Currency:    amount.GetCurrencyCode(),
+			Amount:      moneypb.MoneyValueToString(amount),
+			Description: cashTransaction.GetDescription(),
+		}
+		// Convert to USD if a rate is available.
+		if amountUSD, ok := fxStore.ConvertToUSDOnDate(amount, date); ok {
+			overview.AmountUSD = moneypb.MoneyValueToString(amountUSD)
+		}
+		result.Transactions = append(result.Transactions, overview)
+	}
+	return result, nil
+}
+
+// CashTransactionTypeString returns the display name of a cash transaction type
+// without the enum prefix (e.g., "DIVIDEND").
+func CashTransactionTypeString(cashTransactionType datav1.CashTransactionType) string {
+	return strings.TrimPrefix(cashTransactionType.String(), cashTransactionTypePrefix)
+}
+
+// DividendCalendarEntry is a projected dividend payment.
+type DividendCalendarEntry struct {
+	// Month is the month of the projected payment (YYYY-MM).
+	Month string `json:"month"`
+	// Date is the estimated payment date (YYYY-MM-DD).
+	Date string `json:"date"`
+	// Symbol is the ticker symbol.
+	Symbol string `json:"symbol"`
+	// Frequency is the payment cadence inferred from history (e.g., "QUARTERLY").
+	Frequency string `json:"frequency"`
+	// Shares is the current share count across all accounts.
+	Shares string `json:"shares"`
+	// PerShare is the most recent dividend per share. Empty if the per-share
+	// rate could not be parsed, in which case Amount is the most recent payment.
+	PerShare string `json:"per_share,omitempty"`
+	// Currency is the dividend currency code.
+	Currency string `json:"currency"`
+	// Amount is the projected gross amount in the dividend currency.
+	Amount string `json:"amount"`
+	// AmountUSD is the projected gross amount in USD. Empty if no FX rate is available.
+	AmountUSD string `json:"amount_usd,omitempty"`
+}
+
+// dividendCalendarAmountUSDColumn is the index of the AMOUNT USD column of
+// DividendCalendarHeaders.
+const dividendCalendarAmountUSDColumn = 8
+
+// DividendCalendarHeaders returns the column headers for income calendar table/CSV output.
+func DividendCalendarHeaders() []string {
+	return []string{"MONTH", "DATE", "SYMBOL", "FREQUENCY", "SHARES", "PER SHARE", "CURRENCY", "AMOUNT", "AMOUNT USD"}
+}
+
+// DividendCalendarEntryToRow converts a DividendCalendarEntry to a string slice for CSV output.
+func DividendCalendarEntryToRow(e *DividendCalendarEntry) []string {
+	return []string{
+		e.Month,
+		e.Date,
+		e.Symbol,
+		e.Frequency,
+		e.Shares,
+		e.PerShare,
+		e.Currency,
+		e.Amount,
+		e.AmountUSD,
+	}
+}
+
+// DividendCalendarEntryToTableRow converts a DividendCalendarEntry to a string slice for table display.
+func DividendCalendarEntryToTableRow(e *DividendCalendarEntry) []string {
+	return []string{
+		e.Month,
+		e.Date,
+		e.Symbol,
+		e.Frequency,
+		e.Shares,
+		e.PerShare,
+		e.Currency,
+		e.Amount,
+		cliio.FormatUSD(e.AmountUSD),
+	}
+}
+
+// ComputeDividendCalendarTotals sums the USD amounts across all projected payments.
+func ComputeDividendCalendarTotals(entries []*DividendCalendarEntry) *IncomeTotals {
+	var totalMicros int64
+	var missingUSD int
+	for _, e := range entries {
+		if e.AmountUSD == "" {
+			missingUSD++
+			continue
+		}
+		totalMicros += mathpb.ParseMicros(e.AmountUSD)
+	}
+	return &IncomeTotals{
+		AmountUSD:  cliio.FormatUSDMicros(totalMicros),
+		MissingUSD: missingUSD,
+	}
+}
+
+// DividendCalendarTotalsToTableRow converts IncomeTotals to a TOTAL row
+// aligned with DividendCalendarHeaders for table display.
+func DividendCalendarTotalsToTableRow(totals *IncomeTotals) []string {
+	return totals.tableRow(len(DividendCalendarHeaders()), dividendCalendarAmountUSDColumn)
+}
+
+// GetDividendCalendar projects the dividend payments of currently held symbols
+// after start and within the given number of months, sorted by date and symbol.
+//
+// shares maps each held symbol to its current share count across all
+// accounts. For each symbol, the payment cadence is inferred from the median
+// interval between its recent dividend payment dates, and payments are
+// projected from the most recent payment date. The projected amount is the
+// most recent per-share rate, parsed from the IBKR description (e.g., "Cash
+// Dividend USD 0.24 per Share"), times the current share count, or the most
+// recent payment if the rate cannot be parsed. Amounts are gross of
+// withholding tax. Symbols with fewer than two payments are skipped, since no
+// cadence can be inferred.
+func GetDividendCalendar(
+	cashTransactions []*datav1.CashTransaction,
+	shares map[string]*mathv1.Decimal,
+	start xtime.Date,
+	months int,
+	fxStore *ibctlfxrates.Store,
+) ([]*DividendCalendarEntry, error) {
+	histories := make(map[string]*dividendHistory)
+	for _, cashTransaction := range cashTransactions {
+		switch cashTransaction.GetType() {
+		case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_DIVIDEND,
+			datav1.CashTransactionType_CASH_TRANSACTION_TYPE_PAYMENT_IN_LIEU:
+		default:
+			continue
+		}
+		symbol := cashTransaction.GetSymbol()
+		amountMicros := moneypb.MoneyToMicros(cashTransaction.GetAmount())
+		// Reversals of earlier dividends are not payments.
+		if symbol == "" || amountMicros <= 0 {
+			continue
+		}
+		if mathpb.ToMicros(shares[symbol]) <= 0 {
+			continue
+		}
+		date, err := timepb.ProtoToDate(cashTransaction.GetDate())
+		if err != nil {
+			return nil, fmt.Errorf("cash transaction for account %s: %w", cashTransaction.GetAccountId(), err)
+		}
+		history, ok := histories[symbol]
+		if !ok {
+			history = &dividendHistory{amountMicrosByDate: make(map[xtime.Date]int64)}
+			histories[symbol] = history
+		}
+		history.amountMicrosByDate[date] += amountMicros
+		// Track the per-share rate and currency of the most recent payment.
+		if date.Before(history.lastDate) {
+			continue
+		}
+		if date.After(history.lastDate) {
+			history.lastDate = date
+			history.perShareMicros = 0
+			history.currency = cashTransaction.GetAmount().GetCurrencyCode()
+		}
+		if history.perShareMicros == 0 {
+			history.perShareMicros = parsePerShareMicros(cashTransaction.GetDescription())
+		}
+	}
+	end := addMonths(start, months)
+	var entries []*DividendCalendarEntry
+	for symbol, history := range histories {
+		intervalMonths, frequency, ok := history.cadence()
+		if !ok {
+			continue
+		}
+		sharesMicros := mathpb.ToMicros(shares[symbol])
+		amountMicros := history.amountMicrosByDate[history.lastDate]
+		var perShare string
+		if history.perShareMicros > 0 {
+			perShare = mathpb.ToString(mathpb.FromMicros(history.perShareMicros))
+			amountMicros = multiplyMicros(history.perShareMicros, sharesMicros)
+		}
+		amount := moneypb.MoneyFromMicros(history.currency, amountMicros)
+		var amountUSD string
+		if converted, ok := fxStore.ConvertToUSD(amount); ok {
+			amountUSD = moneypb.MoneyValueToString(converted)
+		}
+		// Project from the most recent payment rather than stepping from each
+		// projection, so month-end clamping does not drift.
+		for i := 1; ; i++ {
+			date := addMonths(history.lastDate, i*intervalMonths)
+			if date.After(end) {
+				break
+			}
+			if !date.After(start) {
+				continue
+			}
+			entries = append(entries, &DividendCalendarEntry{
+				Month:     fmt.Sprintf("%04d-%02d", date.Year, date.Month),
+				Date:      date.String(),
+				Symbol:    symbol,
+				Frequency: frequency,
+				Shares:    mathpb.ToString(shares[symbol]),
+				PerShare:  perShare,
+				Currency:  history.currency,
+				Amount:    moneypb.MoneyValueToString(amount),
+				AmountUSD: amountUSD,
+			})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Date != entries[j].Date {
+			return entries[i].Date < entries[j].Date
+		}
+		return entries[i].Symbol < entries[j].Symbol
+	})
+	return entries, nil
+}
+
+// GetTrailingDividendsPerShare returns the dividends per share of each symbol
+// paid in the twelve months up to and including asOf, in the currency of the
+// most recent payment, keyed by symbol.
+//
+// The per-share rates are parsed from the IBKR dividend descriptions, and a
+// rate paid to several accounts on the same date is counted once. Payments
+// without a per-share rate in the description are skipped, since the number
+// of shares they were paid on is unknown. Symbols without any per-share rate
+// are not returned.
+func GetTrailingDividendsPerShare(
+	cashTransactions []*datav1.CashTransaction,
+	asOf xtime.Date,
+) (map[string]*moneyv1.Money, error) {
+	type payment struct {
+		date           xtime.Date
+		perShareMicros int64
+	}
+	type trailingDividends struct {
+		payments       map[payment]struct{}
+		perShareMicros int64
+		lastDate       xtime.Date
+		currency       string
+	}
+	start := addMonths(asOf, -12)
+	symbolToTrailingDividends := make(map[string]*trailingDividends)
+	for _, cashTransaction := range cashTransactions {
+		switch cashTransaction.GetType() {
+		case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_DIVIDEND,
+			datav1.CashTransactionType_CASH_TRANSACTION_TYPE_PAYMENT_IN_LIEU:
+		default:
+			continue
+		}
+		symbol := cashTransaction.GetSymbol()
+		// Reversals of earlier dividends are not payments.
+		if symbol == "" || moneypb.MoneyToMicros(cashTransaction.GetAmount()) <= 0 {
+			continue
+		}
+		perShareMicros := parsePerShareMicros(cashTransaction.GetDescription())
+		if perShareMicros <= 0 {
+			continue
+		}
+		date, err := timepb.ProtoToDate(cashTransaction.GetDate())
+		if err != nil {
+			return nil, fmt.Errorf("cash transaction for account %s: %w", cashTransaction.GetAccountId(), err)
+		}
+		if !date.After(start) || date.After(asOf) {
+			continue
+		}
+		dividends, ok := symbolToTrailingDividends[symbol]
+		if !ok {
+			dividends = &trailingDividends{payments: make(map[payment]struct{})}
+			symbolToTrailingDividends[symbol] = dividends
+		}
+		key := payment{date: date, perShareMicros: perShareMicros}
+		if _, ok := dividends.payments[key]; ok {
+			continue
+		}
+		dividends.payments[key] = struct{}{}
+		dividends.perShareMicros += perShareMicros
+		if !date.Before(dividends.lastDate) {
+			dividends.lastDate = date
+			dividends.currency = cashTransaction.GetAmount().GetCurrencyCode()
+		}
+	}
+	symbolToDividendsPerShare := make(map[string]*moneyv1.Money, len(symbolToTrailingDividends))
+	for symbol, dividends := range symbolToTrailingDividends {
+		symbolToDividendsPerShare[symbol] = moneypb.MoneyFromMicros(dividends.currency, dividends.perShareMicros)
+	}
+	return symbolToDividendsPerShare, nil
+}
+
+// WithholdingSummary is the dividend withholding tax for one source country and year.
+type WithholdingSummary struct {
+	// Country is the ISO 3166-1 alpha-2 source country code, or "UNKNOWN".
+	Country string `json:"country"`
+	// Year is the payment year.
+	Year int `json:"year"`
+	// GrossUSD is the gross dividends in USD.
+	GrossUSD string `json:"gross_usd"`
+	// WithheldUSD is the net tax withheld in USD, as a positive amount.
+	WithheldUSD string `json:"withheld_usd"`
+	// EffectiveRate is the withheld tax as a percentage of gross dividends (e.g., "30.00%").
+	EffectiveRate string `json:"effective_rate"`
+	// TreatyRate is the configured treaty rate (e.g., "15.00%"). Empty if not configured.
+	TreatyRate string `json:"treaty_rate,omitempty"`
+	// ExcessUSD is the tax withheld above the treaty rate in USD, summed over
+	// over-withheld payments. Empty if no treaty rate is configured.
+	ExcessUSD string `json:"excess_usd,omitempty"`
+	// Payments is the number of dividend payments.
+	Payments int `json:"payments"`
+	// OverWithheldPayments is the number of payments withheld above the treaty rate.
+	OverWithheldPayments int `json:"over_withheld_payments"`
+	// Reclaim is true if any payment was withheld above the treaty rate.
+	Reclaim bool `json:"reclaim"`
+}
+
+// WithholdingSummaryHeaders returns the column headers for withholding summary table/CSV output.
+func WithholdingSummaryHeaders() []string {
+	return []string{"COUNTRY", "YEAR", "GROSS USD", "WITHHELD USD", "EFFECTIVE RATE", "TREATY RATE", "EXCESS USD", "PAYMENTS", "OVER-WITHHELD", "RECLAIM"}
+}
+
+// WithholdingSummaryToRow converts a WithholdingSummary to a string slice for CSV output.
+func WithholdingSummaryToRow(w *WithholdingSummary) []string {
+	return []string{
+		w.Country,
+		strconv.Itoa(w.Year),
+		w.GrossUSD,
+		w.WithheldUSD,
+		w.EffectiveRate,
+		w.TreatyRate,
+		w.ExcessUSD,
+		strconv.Itoa(w.Payments),
+		strconv.Itoa(w.OverWithheldPayments),
+		reclaimString(w.Reclaim),
+	}
+}
+
+// WithholdingSummaryToTableRow converts a WithholdingSummary to a string slice for table display.
+func WithholdingSummaryToTableRow(w *WithholdingSummary) []string {
+	return []string{
+		w.Country,
+		strconv.Itoa(w.Year),
+		cliio.FormatUSD(w.GrossUSD),
+		cliio.FormatUSD(w.WithheldUSD),
+		w.EffectiveRate,
+		w.TreatyRate,
+		cliio.FormatUSD(w.ExcessUSD),
+		strconv.Itoa(w.Payments),
+		strconv.Itoa(w.OverWithheldPayments),
+		reclaimString(w.Reclaim),
+	}
+}
+
+// WithholdingPayment is a single dividend payment with its withholding tax.
+type WithholdingPayment struct {
+	// Date is the payment date (YYYY-MM-DD).
+	Date string `json:"date"`
+	// Account is the account alias.
+	Account string `json:"account"`
+	// Symbol is the ticker symbol.
+	Symbol string `json:"symbol"`
+	// Country is the ISO 3166-1 alpha-2 source country code, or "UNKNOWN".
+	Country string `json:"country"`
+	// Currency is the native currency code.
+	Currency string `json:"currency"`
+	// Gross is the gross dividend in native currency.
+	Gross string `json:"gross"`
+	// Withheld is the net tax withheld in native currency, as a positive amount.
+	Withheld string `json:"withheld"`
+	// Rate is the withheld tax as a percentage of the gross dividend (e.g., "30.00%").
+	Rate string `json:"rate"`
+	// TreatyRate is the configured treaty rate (e.g., "15.00%"). Empty if not configured.
+	TreatyRate string `json:"treaty_rate,omitempty"`
+	// Excess is the tax withheld above the treaty rate in native currency.
+	Excess string `json:"excess,omitempty"`
+	// ExcessUSD is the tax withheld above the treaty rate in USD. Empty if no FX rate is available.
+	ExcessUSD string `json:"excess_usd,omitempty"`
+}
+
+// WithholdingPaymentHeaders returns the column headers for withholding payment table/CSV output.
+func WithholdingPaymentHeaders() []string {
+	return []string{"DATE", "ACCOUNT", "SYMBOL", "COUNTRY", "CURRENCY", "GROSS", "WITHHELD", "RATE", "TREATY RATE", "EXCESS", "EXCESS USD"}
+}
+
+// WithholdingPaymentToRow converts a WithholdingPayment to a string slice for CSV output.
+func WithholdingPaymentToRow(w *WithholdingPayment) []string {
+	return []string{
+		w.Date,
+		w.Account,
+		w.Symbol,
+		w.Country,
+		w.Currency,
+		w.Gross,
+		w.Withheld,
+		w.Rate,
+		w.TreatyRate,
+		w.Excess,
+		w.ExcessUSD,
+	}
+}
+
+// WithholdingPaymentToTableRow converts a WithholdingPayment to a string slice for table display.
+func WithholdingPaymentToTableRow(w *WithholdingPayment) []string {
+	row := WithholdingPaymentToRow(w)
+	row[10] = cliio.FormatUSD(w.ExcessUSD)
+	return row
+}
+
+// WithholdingResult contains the withholding report output.
+type WithholdingResult struct {
+	// Summaries is the per-country, per-year summary, sorted by country and year.
+	Summaries []*WithholdingSummary
+	// ReclaimCandidates is the payments withheld above the treaty rate, sorted by date.
+	ReclaimCandidates []*WithholdingPayment
+}
+
+// GetWithholdingReport summarizes dividend withholding tax by source country
+// and year, and compares each payment's withholding rate with the treaty rate
+// for its source country.
+//
+// Withholding tax is matched to dividends and payments in lieu by account,
+// symbol, and date, and refunds of withholding tax net against it. The source
+// country is parsed from the IBKR withholding description (e.g., "- US Tax"),
+// falling back to the country prefix of the ISIN in the dividend description.
+// A payment is a reclaim candidate if its tax exceeds the treaty rate by more
+// than half a percentage point of the gross dividend, which tolerates rounding.
+// treatyRates maps country codes to rates as fractions (e.g., 0.15). Amounts
+// are converted to USD at the FX rate on the payment date, and amounts
+// without an available FX rate are left out of the USD totals.
+func GetWithholdingReport(
+	cashTransactions []*datav1.CashTransaction,
+	treatyRates map[string]float64,
+	fxStore *ibctlfxrates.Store,
+) (*WithholdingResult, error) {
+	paymentMap := make(map[withholdingPaymentKey]*withholdingPaymentData)
+	var paymentKeys []withholdingPaymentKey
+	for _, cashTransaction := range cashTransactions {
+		var isWithholding bool
+		switch cashTransaction.GetType() {
+		case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_DIVIDEND,
+			datav1.CashTransactionType_CASH_TRANSACTION_TYPE_PAYMENT_IN_LIEU:
+		case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_WITHHOLDING_TAX:
+			isWithholding = true
+		default:
+			continue
+		}
+		amount := cashTransaction.GetAmount()
+		if amount == nil {
+			return nil, fmt.Errorf("cash transaction for account %s on %s has no amount", cashTransaction.GetAccountId(), dateString(cashTransaction))
+		}
+		date, err := timepb.ProtoToDate(cashTransaction.GetDate())
+		if err != nil {
+			return nil, fmt.Errorf("cash transaction for account %s: %w", cashTransaction.GetAccountId(), err)
+		}
+		key := withholdingPaymentKey{
+			account:  cashTransaction.GetAccountId(),
+			symbol:   cashTransaction.GetSymbol(),
+			date:     date,
+			currency: amount.GetCurrencyCode(),
+		}
+		data, ok := paymentMap[key]
+		if !ok {
+			data = &withholdingPaymentData{}
+			paymentMap[key] = data
+			paymentKeys = append(paymentKeys, key)
+		}
+		if isWithholding {
+			// Withholding is negative and refunds are positive, so negate to a positive tax.
+			data.withheldMicros -= moneypb.MoneyToMicros(amount)
+			if country := parseWithholdingCountry(cashTransaction.GetDescription()); country != "" {
+				data.country = country
+			}
+		} else {
+			data.grossMicros += moneypb.MoneyToMicros(amount)
+		}
+		if data.isinCountry == "" {
+			data.isinCountry = parseISINCountry(cashTransaction.GetDescription())
+		}
+	}
+	type summaryKey struct {
+		country string
+		year    int
+	}
+	type summaryData struct {
+		grossMicros          int64
+		withheldMicros       int64
+		excessMicros         int64
+		payments             int
+		overWithheldPayments int
+	}
+	summaryMap := make(map[summaryKey]*summaryData)
+	result := &WithholdingResult{}
+	for _, key := range paymentKeys {
+		data := paymentMap[key]
+		country := data.country
+		if country == "" {
+			country = data.isinCountry
+		}
+		if country == "" {
+			country = unknownCountry
+		}
+		summary, ok := summaryMap[summaryKey{country: country, year: key.date.Year}]
+		if !ok {
+			summary = &summaryData{}
+			summaryMap[summaryKey{country: country, year: key.date.Year}] = summary
+		}
+		summary.grossMicros += toUSDMicros(fxStore, key.currency, data.grossMicros, key.date)
+		summary.withheldMicros += toUSDMicros(fxStore, key.currency, data.withheldMicros, key.date)
+		if data.grossMicros > 0 {
+			summary.payments++
+		}
+		treatyRate, ok := treatyRates[country]
+		if !ok || data.grossMicros <= 0 {
+			continue
+		}
+		excessMicros := data.withheldMicros - int64(math.Round(float64(data.grossMicros)*treatyRate))
+		if float64(excessMicros) <= float64(data.grossMicros)*reclaimTolerance {
+			continue
+		}
+		summary.overWithheldPayments++
+		excessUSDMicros := toUSDMicros(fxStore, key.currency, excessMicros, key.date)
+		summary.excessMicros += excessUSDMicros
+		candidate := &WithholdingPayment{
+			Date:       key.date.String(),
+			Account:    key.account,
+			Symbol:     key.symbol,
+			Country:    country,
+			Currency:   key.currency,
+			Gross:      moneypb.MoneyValueToString(moneypb.MoneyFromMicros(key.currency, data.grossMicros)),
+			Withheld:   moneypb.MoneyValueToString(moneypb.MoneyFromMicros(key.currency, data.withheldMicros)),
+			Rate:       rateString(float64(data.withheldMicros) / float64(data.grossMicros)),
+			TreatyRate: rateString(treatyRate),
+			Excess:     moneypb.MoneyValueToString(moneypb.MoneyFromMicros(key.currency, excessMicros)),
+		}
+		if excessUSD, ok := fxStore.ConvertToUSDOnDate(moneypb.MoneyFromMicros(key.currency, excessMicros), key.date); ok {
+			candidate.ExcessUSD = moneypb.MoneyValueToString(excessUSD)
+		}
+		result.ReclaimCandidates = append(result.ReclaimCandidates, candidate)
+	}
+	for key, data := range summaryMap {
+		// Skip countries and years with neither dividends nor withholding.
+		if data.grossMicros == 0 && data.withheldMicros == 0 {
+			continue
+		}
+		summary := &WithholdingSummary{
+			Country:              key.country,
+			Year:                 key.year,
+			GrossUSD:             moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", data.grossMicros)),
+			WithheldUSD:          moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", data.withheldMicros)),
+			Payments:             data.payments,
+			OverWithheldPayments: data.overWithheldPayments,
+			Reclaim:              data.overWithheldPayments > 0,
+		}
+		if data.grossMicros != 0 {
+			summary.EffectiveRate = rateString(float64(data.withheldMicros) / float64(data.grossMicros))
+		}
+		if treatyRate, ok := treatyRates[key.country]; ok {
+			summary.TreatyRate = rateString(treatyRate)
+			summary.ExcessUSD = moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", data.excessMicros))
+		}
+		result.Summaries = append(result.Summaries, summary)
+	}
+	sort.Slice(result.Summaries, func(i, j int) bool {
+		if result.Summaries[i].Country != result.Summaries[j].Country {
+			return result.Summaries[i].Country < result.Summaries[j].Country
+		}
+		return result.Summaries[i].Year < result.Summaries[j].Year
+	})
+	sort.SliceStable(result.ReclaimCandidates, func(i, j int) bool {
+		return result.ReclaimCandidates[i].Date < result.ReclaimCandidates[j].Date
+	})
+	return result, nil
+}
+
+// *** PRIVATE ***
+
+// tableRow returns a totals row with the given number of columns and the USD
+// total at amountUSDColumn. Amounts without a USD conversion are not included
+// in the total, so the row is labeled as partial if there are any.
+func (t *IncomeTotals) tableRow(columns int, amountUSDColumn int) []string {
+	row := make([]string, columns)
+	row[0] = "TOTAL"
+	if t.MissingUSD > 0 {
+		row[0] = "PARTIAL TOTAL"
+	}
+	row[amountUSDColumn] = t.AmountUSD
+	return row
+}
+
+// dateString returns the YYYY-MM-DD date of a cash transaction.
+func dateString(cashTransaction *datav1.CashTransaction) string {
+	d := cashTransaction.GetDate()
+	if d == nil {
+		return ""
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", d.GetYear(), d.GetMonth(), d.GetDay())
+}
+
+// perShareRegexp matches the per-share rate in IBKR dividend descriptions
+// (e.g., "AAPL(US0378331005) Cash Dividend USD 0.24 per Share (Ordinary Dividend)").
+var perShareRegexp = regexp.MustCompile(`(?i)\b[A-Z]{3} ([0-9]+(?:\.[0-9]+)?) per share\b`)
+
+// cadenceIntervals is the number of recent payment intervals used to infer the cadence.
+const cadenceIntervals = 6
+
+// dividendHistory is the dividend payment history of a symbol.
+type dividendHistory struct {
+	// amountMicrosByDate is the total payment per payment date across all accounts.
+	amountMicrosByDate map[xtime.Date]int64
+	// lastDate is the most recent payment date.
+	lastDate xtime.Date
+	// perShareMicros is the per-share rate of the most recent payment, or 0 if unknown.
+	perShareMicros int64
+	// currency is the currency of the most recent payment.
+	currency string
+}
+
+// cadence returns the number of months between payments and its frequency
+// name, inferred from the median of the most recent payment intervals.
+// Returns false if there are fewer than two payments.
+func (h *dividendHistory) cadence() (int, string, bool) {
+	dates := make([]xtime.Date, 0, len(h.amountMicrosByDate))
+	for date := range h.amountMicrosByDate {
+		dates = append(dates, date)
+	}
+	if len(dates) < 2 {
+		return 0, "", false
+	}
+	sort.Slice(dates, func(i, j int) bool {
+		return dates[i].Before(dates[j])
+	})
+	var intervals []int
+	for i := len(dates) - 1; i > 0 && len(intervals) < cadenceIntervals; i-- {
+		intervals = append(intervals, dates[i].DaysSince(dates[i-1]))
+	}
+	sort.Ints(intervals)
+	median := intervals[len(intervals)/2]
+	switch {
+	case median <= 45:
+		return 1, "MONTHLY", true
+	case median <= 135:
+		return 3, "QUARTERLY", true
+	case median <= 270:
+		return 6, "SEMIANNUAL", true
+	default:
+		return 12, "ANNUAL", true
+	}
+}
+
+// parsePerShareMicros returns the per-share rate in an IBKR dividend
+// description in micros, or 0 if there is none.
+func parsePerShareMicros(description string) int64 {
+	match := perShareRegexp.FindStringSubmatch(description)
+	if match == nil {
+		return 0
+	}
+	return mathpb.ParseMicros(match[1])
+}
+
+// multiplyMicros returns the product of two micros values in micros, using
+// big integers to avoid overflowing the intermediate product.
+func multiplyMicros(aMicros int64, bMicros int64) int64 {
+	result := new(big.Int).Mul(big.NewInt(aMicros), big.NewInt(bMicros))
+	return result.Quo(result, big.NewInt(1_000_000)).Int64()
+}
+
+// addMonths returns the date n months after the date, clamping the day to the
+// end of the resulting month (e.g., January 31 plus one month is February 28).
+func addMonths(date xtime.Date, n int) xtime.Date {
+	firstOfMonth := time.Date(date.Year, date.Month+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	return xtime.Date{Year: firstOfMonth.Year(), Month: firstOfMonth.Month(), Day: min(date.Day, lastDay)}
+}
+
+// unknownCountry is the country of withholding whose source country cannot be determined.
+const unknownCountry = "UNKNOWN"
+
+// reclaimTolerance is the fraction of the gross dividend that withholding may
+// exceed the treaty rate by before a payment is a reclaim candidate.
+const reclaimTolerance = 0.005
+
+// withholdingCountryRegexp matches the source country in IBKR withholding tax
+// descriptions (e.g., "NESN(CH0038863350) Cash Dividend CHF 3.00 per Share - CH Tax").
+var withholdingCountryRegexp = regexp.MustCompile(`- ([A-Z]{2}) Tax\b`)
+
+// isinRegexp matches an ISIN in parentheses in IBKR descriptions (e.g., "AAPL(US0378331005)").
+var isinRegexp = regexp.MustCompile(`\(([A-Z]{2})[A-Z0-9]{9}[0-9]\)`)
+
+// withholdingPaymentKey identifies a dividend payment and its withholding tax.
+type withholdingPaymentKey struct {
+	account  string
+	symbol   string
+	date     xtime.Date
+	currency string
+}
+
+// withholdingPaymentData is the gross dividend and withholding of a payment.
+type withholdingPaymentData struct {
+	grossMicros    int64
+	withheldMicros int64
+	// country is the source country from the withholding description.
+	country string
+	// isinCountry is the country prefix of the ISIN in any of the descriptions.
+	isinCountry string
+}
+
+// parseWithholdingCountry returns the source country code in an IBKR
+// withholding tax description, or empty if there is none.
+func parseWithholdingCountry(description string) string {
+	match := withholdingCountryRegexp.FindStringSubmatch(description)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// parseISINCountry returns the country prefix of the ISIN in an IBKR
+// description, or empty if there is none.
+func parseISINCountry(description string) string {
+	match := isinRegexp.FindStringSubmatch(description)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// toUSDMicros converts an amount in micros to USD micros at the FX rate on the
+// date, or returns 0 if no FX rate is available.
+func toUSDMicros(fxStore *ibctlfxrates.Store, currency string, micros int64, date xtime.Date) int64 {
+	if micros == 0 {
+		return 0
+	}
+	converted, ok := fxStore.ConvertToUSDOnDate(moneypb.MoneyFromMicros(currency, micros), date)
+	if !ok {
+		return 0
+	}
+	return moneypb.MoneyToMicros(converted)
+}
+
+// rateString formats a fraction as a percentage (e.g., 0.15 as "15.00%").
+func rateString(rate float64) string {
+	return fmt.Sprintf("%.2f%%", rate*100)
+}
+
+// reclaimString returns "YES" if the summary has reclaim candidates, and empty otherwise.
+func reclaimString(reclaim bool) string {
+	if reclaim {
+		return "YES"
+	}
+	return ""
+}
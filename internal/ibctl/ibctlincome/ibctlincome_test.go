@@ -0,0 +1,234 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctlincome
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	mathv1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/math/v1"
+	moneyv1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/money/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/bufdev/ibctl/internal/pkg/protoio"
+	"github.com/bufdev/ibctl/internal/pkg/timepb"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDividendCalendar(t *testing.T) {
+	t.Parallel()
+	cashTransactions := []*datav1.CashTransaction{
+		// Quarterly AAPL dividends, paid to two accounts in the latest quarter.
+		newTestDividend(t, "ind", "AAPL", 2025, time.August, 14, "USD", 2_400_000, "AAPL(US0378331005) Cash Dividend USD 0.24 per Share (Ordinary Dividend)"),
+		newTestDividend(t, "ind", "AAPL", 2025, time.November, 13, "USD", 2_500_000, "AAPL(US0378331005) Cash Dividend USD 0.25 per Share (Ordinary Dividend)"),
+		newTestDividend(t, "rrsp", "AAPL", 2025, time.November, 13, "USD", 5_000_000, "AAPL(US0378331005) Cash Dividend USD 0.25 per Share (Ordinary Dividend)"),
+		// Monthly dividends without a parseable per-share rate.
+		newTestDividend(t, "ind", "O", 2025, time.November, 30, "USD", 3_000_000, "O Dividend"),
+		newTestDividend(t, "ind", "O", 2025, time.December, 31, "USD", 3_100_000, "O Dividend"),
+		// A single payment has no cadence.
+		newTestDividend(t, "ind", "XOM", 2025, time.December, 10, "USD", 1_000_000, "XOM(US30231G1022) Cash Dividend USD 0.99 per Share"),
+		// Symbols no longer held are skipped.
+		newTestDividend(t, "ind", "MSFT", 2025, time.September, 11, "USD", 1_000_000, "MSFT Cash Dividend USD 0.83 per Share"),
+		newTestDividend(t, "ind", "MSFT", 2025, time.December, 11, "USD", 1_000_000, "MSFT Cash Dividend USD 0.91 per Share"),
+	}
+	shares := map[string]*mathv1.Decimal{
+		"AAPL": mathpb.FromMicros(40_000_000),
+		"O":    mathpb.FromMicros(50_000_000),
+		"XOM":  mathpb.FromMicros(10_000_000),
+	}
+	entries, err := GetDividendCalendar(
+		cashTransactions,
+		shares,
+		xtime.Date{Year: 2026, Month: time.January, Day: 15},
+		3,
+		ibctlfxrates.NewStore(t.TempDir()),
+	)
+	require.NoError(t, err)
+	require.Equal(t, []*DividendCalendarEntry{
+		{Month: "2026-01", Date: "2026-01-31", Symbol: "O", Frequency: "MONTHLY", Shares: "50", Currency: "USD", Amount: "3.1", AmountUSD: "3.1"},
+		{Month: "2026-02", Date: "2026-02-13", Symbol: "AAPL", Frequency: "QUARTERLY", Shares: "40", PerShare: "0.25", Currency: "USD", Amount: "10", AmountUSD: "10"},
+		// Month-end payments are clamped to the end of shorter months.
+		{Month: "2026-02", Date: "2026-02-28", Symbol: "O", Frequency: "MONTHLY", Shares: "50", Currency: "USD", Amount: "3.1", AmountUSD: "3.1"},
+		{Month: "2026-03", Date: "2026-03-31", Symbol: "O", Frequency: "MONTHLY", Shares: "50", Currency: "USD", Amount: "3.1", AmountUSD: "3.1"},
+	}, entries)
+}
+
+func newTestDividend(
+	t *testing.T,
+	account string,
+	symbol string,
+	year int,
+	month time.Month,
+	day int,
+	currency string,
+	amountMicros int64,
+	description string,
+) *datav1.CashTransaction {
+	date, err := timepb.NewProtoDate(year, month, day)
+	require.NoError(t, err)
+	return &datav1.CashTransaction{
+		AccountId:    account,
+		Type:         datav1.CashTransactionType_CASH_TRANSACTION_TYPE_DIVIDEND,
+		Date:         date,
+		Amount:       moneypb.MoneyFromMicros(currency, amountMicros),
+		CurrencyCode: currency,
+		Symbol:       symbol,
+		Description:  description,
+	}
+}
+
+func TestGetTrailingDividendsPerShare(t *testing.T) {
+	t.Parallel()
+	cashTransactions := []*datav1.CashTransaction{
+		// Older than twelve months.
+		newTestDividend(t, "ind", "AAPL", 2024, time.November, 14, "USD", 2_400_000, "AAPL(US0378331005) Cash Dividend USD 0.24 per Share (Ordinary Dividend)"),
+		newTestDividend(t, "ind", "AAPL", 2025, time.May, 15, "USD", 2_500_000, "AAPL(US0378331005) Cash Dividend USD 0.25 per Share (Ordinary Dividend)"),
+		// The same payment to two accounts is counted once.
+		newTestDividend(t, "ind", "AAPL", 2025, time.November, 13, "USD", 2_600_000, "AAPL(US0378331005) Cash Dividend USD 0.26 per Share (Ordinary Dividend)"),
+		newTestDividend(t, "rrsp", "AAPL", 2025, time.November, 13, "USD", 5_200_000, "AAPL(US0378331005) Cash Dividend USD 0.26 per Share (Ordinary Dividend)"),
+		// After the as-of date.
+		newTestDividend(t, "ind", "AAPL", 2026, time.February, 12, "USD", 2_600_000, "AAPL(US0378331005) Cash Dividend USD 0.26 per Share (Ordinary Dividend)"),
+		// Reversals and payments without a per-share rate are skipped.
+		newTestDividend(t, "ind", "AAPL", 2025, time.December, 1, "USD", -2_600_000, "AAPL(US0378331005) Cash Dividend USD 0.26 per Share - Reversal"),
+		newTestDividend(t, "ind", "O", 2025, time.December, 31, "USD", 3_100_000, "O Dividend"),
+		newTestDividend(t, "ind", "SHOP", 2025, time.June, 30, "CAD", 1_000_000, "SHOP Cash Dividend CAD 0.10 per Share"),
+	}
+	symbolToDividendsPerShare, err := GetTrailingDividendsPerShare(cashTransactions, xtime.Date{Year: 2026, Month: time.January, Day: 15})
+	require.NoError(t, err)
+	require.Equal(t, map[string]*moneyv1.Money{
+		"AAPL": moneypb.MoneyFromMicros("USD", 510_000),
+		"SHOP": moneypb.MoneyFromMicros("CAD", 100_000),
+	}, symbolToDividendsPerShare)
+}
+
+func TestGetWithholdingReport(t *testing.T) {
+	t.Parallel()
+	withholding := func(account string, symbol string, year int, month time.Month, day int, amountMicros int64, description string) *datav1.CashTransaction {
+		cashTransaction := newTestDividend(t, account, symbol, year, month, day, "USD", amountMicros, description)
+		cashTransaction.Type = datav1.CashTransactionType_CASH_TRANSACTION_TYPE_WITHHOLDING_TAX
+		return cashTransaction
+	}
+	cashTransactions := []*datav1.CashTransaction{
+		// Withheld at the 15% treaty rate.
+		newTestDividend(t, "ind", "AAPL", 2025, time.May, 15, "USD", 100_000_000, "AAPL(US0378331005) Cash Dividend USD 0.25 per Share (Ordinary Dividend)"),
+		withholding("ind", "AAPL", 2025, time.May, 15, -15_000_000, "AAPL(US0378331005) Cash Dividend USD 0.25 per Share - US Tax"),
+		// Withheld at 30%, without a treaty form on file.
+		newTestDividend(t, "ind", "MSFT", 2025, time.June, 12, "USD", 200_000_000, "MSFT(US5949181045) Cash Dividend USD 0.83 per Share (Ordinary Dividend)"),
+		withholding("ind", "MSFT", 2025, time.June, 12, -60_000_000, "MSFT(US5949181045) Cash Dividend USD 0.83 per Share - US Tax"),
+		// Over-withholding refunded in a later year nets against that year.
+		withholding("ind", "MSFT", 2026, time.February, 2, 30_000_000, "MSFT(US5949181045) Cash Dividend USD 0.83 per Share - US Tax"),
+		// Swiss withholding with no treaty rate configured, with the country from the ISIN.
+		newTestDividend(t, "ind", "NESN", 2025, time.April, 20, "USD", 50_000_000, "NESN(CH0038863350) Cash Dividend USD 3.00 per Share"),
+		withholding("ind", "NESN", 2025, time.April, 20, -17_500_000, "NESN(CH0038863350) Cash Dividend USD 3.00 per Share"),
+	}
+	result, err := GetWithholdingReport(cashTransactions, map[string]float64{"US": 0.15}, ibctlfxrates.NewStore(t.TempDir()))
+	require.NoError(t, err)
+	require.Equal(t, []*WithholdingSummary{
+		{Country: "CH", Year: 2025, GrossUSD: "50", WithheldUSD: "17.5", EffectiveRate: "35.00%", Payments: 1},
+		{
+			Country:              "US",
+			Year:                 2025,
+			GrossUSD:             "300",
+			WithheldUSD:          "75",
+			EffectiveRate:        "25.00%",
+			TreatyRate:           "15.00%",
+			ExcessUSD:            "30",
+			Payments:             2,
+			OverWithheldPayments: 1,
+			Reclaim:              true,
+		},
+		{Country: "US", Year: 2026, GrossUSD: "0", WithheldUSD: "-30", TreatyRate: "15.00%", ExcessUSD: "0"},
+	}, result.Summaries)
+	require.Equal(t, []*WithholdingPayment{
+		{
+			Date:       "2025-06-12",
+			Account:    "ind",
+			Symbol:     "MSFT",
+			Country:    "US",
+			Currency:   "USD",
+			Gross:      "200",
+			Withheld:   "60",
+			Rate:       "30.00%",
+			TreatyRate: "15.00%",
+			Excess:     "30",
+			ExcessUSD:  "30",
+		},
+	}, result.ReclaimCandidates)
+}
+
+func TestGetIncomeList(t *testing.T) {
+	t.Parallel()
+	fxDirPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(fxDirPath, "CAD.USD"), 0o755))
+	require.NoError(t, protoio.WriteMessagesJSON(filepath.Join(fxDirPath, "CAD.USD", "rates.json"), []*datav1.ExchangeRate{
+		newTestExchangeRate(t, 2025, time.March, 3, 700_000),
+		newTestExchangeRate(t, 2025, time.June, 2, 750_000),
+	}))
+	cashTransactions := []*datav1.CashTransaction{
+		newTestDividend(t, "individual", "RY", 2025, time.March, 3, "CAD", 100_000_000, ""),
+		newTestDividend(t, "individual", "RY", 2025, time.June, 2, "CAD", 100_000_000, ""),
+		// Before the first rate.
+		newTestDividend(t, "individual", "RY", 2024, time.December, 2, "CAD", 100_000_000, ""),
+	}
+	result, err := GetIncomeList(cashTransactions, false, ibctlfxrates.NewStore(fxDirPath))
+	require.NoError(t, err)
+	require.Len(t, result.Transactions, 3)
+	// Each payment is converted at the rate on its date.
+	require.Equal(t, "70", result.Transactions[0].AmountUSD)
+	require.Equal(t, "75", result.Transactions[1].AmountUSD)
+	require.Empty(t, result.Transactions[2].AmountUSD)
+}
+
+func newTestExchangeRate(t *testing.T, year int, month time.Month, day int, rateMicros int64) *datav1.ExchangeRate {
+	date, err := timepb.NewProtoDate(year, month, day)
+	require.NoError(t, err)
+	return &datav1.ExchangeRate{
+		Date:              date,
+		BaseCurrencyCode:  "CAD",
+		QuoteCurrencyCode: "USD",
+		Rate:              mathpb.FromMicros(rateMicros),
+		Provider:          "frankfurter",
+	}
+}
+
+func TestComputeIncomeTotals(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "AMOUNT USD", IncomeListHeaders()[incomeListAmountUSDColumn])
+	require.Equal(t, "AMOUNT USD", DividendCalendarHeaders()[dividendCalendarAmountUSDColumn])
+
+	totals := ComputeIncomeTotals([]*IncomeOverview{
+		{AmountUSD: "10.50"},
+		{AmountUSD: "-1.50"},
+	})
+	require.Equal(t, &IncomeTotals{AmountUSD: cliio.FormatUSDMicros(9_000_000)}, totals)
+	row := IncomeTotalsToTableRow(totals)
+	require.Len(t, row, len(IncomeListHeaders()))
+	require.Equal(t, "TOTAL", row[0])
+	require.Equal(t, totals.AmountUSD, row[incomeListAmountUSDColumn])
+
+	// Amounts without a USD conversion are counted and the total is labeled as partial.
+	totals = ComputeIncomeTotals([]*IncomeOverview{
+		{AmountUSD: "10.50"},
+		{Currency: "EUR", Amount: "5.00"},
+	})
+	require.Equal(t, &IncomeTotals{AmountUSD: cliio.FormatUSDMicros(10_500_000), MissingUSD: 1}, totals)
+	require.Equal(t, "PARTIAL TOTAL", IncomeTotalsToTableRow(totals)[0])
+
+	totals = ComputeDividendCalendarTotals([]*DividendCalendarEntry{
+		{AmountUSD: "2.50"},
+		{Currency: "CAD", Amount: "3.00"},
+	})
+	require.Equal(t, 1, totals.MissingUSD)
+	row = DividendCalendarTotalsToTableRow(totals)
+	require.Len(t, row, len(DividendCalendarHeaders()))
+	require.Equal(t, "PARTIAL TOTAL", row[0])
+	require.Equal(t, cliio.FormatUSDMicros(2_500_000), row[dividendCalendarAmountUSDColumn])
+}
@@ -0,0 +1,237 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctlmanual manages manually entered trades for positions held
+// outside IBKR (e.g., private placements).
+//
+// Manual trades are stored per account in data/manual/<alias>/trades.json
+// using the Trade proto, and are merged alongside Flex Query, Activity
+// Statement, and seed data.
+package ibctlmanual
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"buf.build/go/protovalidate"
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/bufdev/ibctl/internal/pkg/filemode"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/bufdev/ibctl/internal/pkg/protoio"
+	"github.com/bufdev/ibctl/internal/pkg/timepb"
+)
+
+// TradesFileName is the name of the manual trades file within each account directory.
+const TradesFileName = "trades.json"
+
+// Source is the Trade source of manually entered trades.
+const Source = "manual"
+
+// tradeIDPrefix is the prefix of all manual trade IDs.
+const tradeIDPrefix = "manual-"
+
+// TradeInput is a manual trade as entered by the user, from flags or JSON.
+type TradeInput struct {
+	// Account is the account alias.
+	Account string `json:"account"`
+	// Symbol is the ticker symbol or other identifier.
+	Symbol string `json:"symbol"`
+	// Date is the trade date (YYYY-MM-DD).
+	Date string `json:"date"`
+	// Side is "buy" or "sell".
+	Side string `json:"side"`
+	// Quantity is the positive quantity traded.
+	Quantity string `json:"quantity"`
+	// Price is the price per share.
+	Price string `json:"price"`
+	// Currency is the three-letter currency code.
+	Currency string `json:"currency"`
+	// Commission is the optional positive commission paid.
+	Commission string `json:"commission,omitempty"`
+	// AssetCategory is the optional IBKR asset category. Defaults to "STK".
+	AssetCategory string `json:"asset_category,omitempty"`
+	// Description is the optional security description.
+	Description string `json:"description,omitempty"`
+}
+
+// ParseTradeInputs parses a single JSON trade object or a JSON array of trade objects.
+// Unknown fields are rejected.
+func ParseTradeInputs(data []byte) ([]TradeInput, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, errors.New("no trades in input")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if data[0] == '[' {
+		var tradeInputs []TradeInput
+		if err := decoder.Decode(&tradeInputs); err != nil {
+			return nil, fmt.Errorf("parsing trades: %w", err)
+		}
+		return tradeInputs, nil
+	}
+	var tradeInput TradeInput
+	if err := decoder.Decode(&tradeInput); err != nil {
+		return nil, fmt.Errorf("parsing trade: %w", err)
+	}
+	return []TradeInput{tradeInput}, nil
+}
+
+// NewTrade validates the input and returns the Trade proto for it.
+//
+// The trade ID is derived from the trade fields, so entering the same trade
+// twice is detected by AddTrades. Quantity and proceeds are signed by side,
+// and the commission is stored as a negative amount, matching IBKR.
+func NewTrade(tradeInput TradeInput, accountAliases map[string]string) (*datav1.Trade, error) {
+	if _, ok := accountAliases[tradeInput.Account]; !ok {
+		return nil, fmt.Errorf("unknown account alias %q", tradeInput.Account)
+	}
+	if tradeInput.Symbol == "" {
+		return nil, errors.New("symbol is required")
+	}
+	date, err := time.Parse(time.DateOnly, tradeInput.Date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", tradeInput.Date)
+	}
+	var side datav1.TradeSide
+	var sign int64
+	switch strings.ToLower(tradeInput.Side) {
+	case "buy":
+		side, sign = datav1.TradeSide_TRADE_SIDE_BUY, 1
+	case "sell":
+		side, sign = datav1.TradeSide_TRADE_SIDE_SELL, -1
+	default:
+		return nil, fmt.Errorf("invalid side %q, must be buy or sell", tradeInput.Side)
+	}
+	quantityMicros, err := parsePositiveMicros("quantity", tradeInput.Quantity, false)
+	if err != nil {
+		return nil, err
+	}
+	priceMicros, err := parsePositiveMicros("price", tradeInput.Price, true)
+	if err != nil {
+		return nil, err
+	}
+	commissionMicros, err := parsePositiveMicros("commission", tradeInput.Commission, true)
+	if err != nil {
+		return nil, err
+	}
+	protoDate, err := timepb.NewProtoDate(date.Year(), date.Month(), date.Day())
+	if err != nil {
+		return nil, err
+	}
+	assetCategory := tradeInput.AssetCategory
+	if assetCategory == "" {
+		assetCategory = "STK"
+	}
+	currencyCode := strings.ToUpper(tradeInput.Currency)
+	// Proceeds are negative for buys and positive for sells. Multiply units and
+	// remainder separately to avoid int64 overflow.
+	proceedsMicros := -sign * (priceMicros*(quantityMicros/1_000_000) + priceMicros*(quantityMicros%1_000_000)/1_000_000)
+	trade := &datav1.Trade{
+		TradeId:       newTradeID(tradeInput.Account, tradeInput.Symbol, tradeInput.Date, sign*quantityMicros, priceMicros),
+		TradeDate:     protoDate,
+		SettleDate:    protoDate,
+		Symbol:        tradeInput.Symbol,
+		Description:   tradeInput.Description,
+		AssetCategory: assetCategory,
+		Side:          side,
+		Quantity:      mathpb.FromMicros(sign * quantityMicros),
+		TradePrice:    moneypb.MoneyFromMicros(currencyCode, priceMicros),
+		Proceeds:      moneypb.MoneyFromMicros(currencyCode, proceedsMicros),
+		Commission:    moneypb.MoneyFromMicros(currencyCode, -commissionMicros),
+		CurrencyCode:  currencyCode,
+		AccountId:     tradeInput.Account,
+		Source:        Source,
+	}
+	if err := protovalidate.Validate(trade); err != nil {
+		return nil, err
+	}
+	return trade, nil
+}
+
+// AddTrades appends the trades to the manual trades file of each trade's
+// account under dataManualDirPath. Returns an error without writing anything
+// if any trade was already added.
+func AddTrades(dataManualDirPath string, trades []*datav1.Trade) error {
+	accountTrades := make(map[string][]*datav1.Trade)
+	for _, trade := range trades {
+		accountTrades[trade.GetAccountId()] = append(accountTrades[trade.GetAccountId()], trade)
+	}
+	// Read and check every account before writing so a duplicate leaves all files unchanged.
+	accountFileTrades := make(map[string][]*datav1.Trade, len(accountTrades))
+	for alias, newTrades := range accountTrades {
+		existingTrades, err := ReadTrades(dataManualDirPath, alias)
+		if err != nil {
+			return err
+		}
+		tradeIDs := make(map[string]struct{}, len(existingTrades)+len(newTrades))
+		for _, trade := range existingTrades {
+			tradeIDs[trade.GetTradeId()] = struct{}{}
+		}
+		for _, trade := range newTrades {
+			if _, ok := tradeIDs[trade.GetTradeId()]; ok {
+				tradeDate := trade.GetTradeDate()
+				return fmt.Errorf(
+					"%s trade of %s %s on %04d-%02d-%02d was already added",
+					trade.GetAccountId(), mathpb.ToString(trade.GetQuantity()), trade.GetSymbol(),
+					tradeDate.GetYear(), tradeDate.GetMonth(), tradeDate.GetDay(),
+				)
+			}
+			tradeIDs[trade.GetTradeId()] = struct{}{}
+		}
+		accountFileTrades[alias] = append(existingTrades, newTrades...)
+	}
+	for alias, fileTrades := range accountFileTrades {
+		accountDirPath := filepath.Join(dataManualDirPath, alias)
+		if err := filemode.MkdirAll(accountDirPath); err != nil {
+			return err
+		}
+		if err := protoio.WriteMessagesJSON(filepath.Join(accountDirPath, TradesFileName), fileTrades); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadTrades reads the manual trades of the account. Returns nil if there are none.
+func ReadTrades(dataManualDirPath string, alias string) ([]*datav1.Trade, error) {
+	trades, err := protoio.ReadMessagesJSON(filepath.Join(dataManualDirPath, alias, TradesFileName), func() *datav1.Trade { return &datav1.Trade{} })
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading manual trades for %s: %w", alias, err)
+	}
+	return trades, nil
+}
+
+// *** PRIVATE ***
+
+// parsePositiveMicros parses a decimal value that must be positive, or zero
+// if allowZero is true. An empty value is zero.
+func parsePositiveMicros(name string, value string, allowZero bool) (int64, error) {
+	units, micros, err := mathpb.ParseToUnitsMicros(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", name, value, err)
+	}
+	totalMicros := units*1_000_000 + micros
+	if totalMicros < 0 || (totalMicros == 0 && !allowZero) {
+		return 0, fmt.Errorf("%s must be positive, got %q", name, value)
+	}
+	return totalMicros, nil
+}
+
+// newTradeID returns a deterministic trade ID from the trade fields.
+func newTradeID(alias string, symbol string, date string, quantityMicros int64, priceMicros int64) string {
+	hash := sha256.Sum256(fmt.Appendf(nil, "%s|%s|%s|%d|%d", alias, symbol, date, quantityMicros, priceMicros))
+	return fmt.Sprintf("%s%x", tradeIDPrefix, hash[:8])
+}
@@ -0,0 +1,51 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctlmanual
+
+import (
+	"testing"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddTrades(t *testing.T) {
+	t.Parallel()
+	accountAliases := map[string]string{"individual": "U1234567"}
+	tradeInputs, err := ParseTradeInputs([]byte(`[
+		{"account": "individual", "symbol": "ACME", "date": "2024-03-01", "side": "buy", "quantity": "1000", "price": "2.5", "currency": "USD", "commission": "10"},
+		{"account": "individual", "symbol": "ACME", "date": "2025-03-01", "side": "sell", "quantity": "400", "price": "4", "currency": "USD"}
+	]`))
+	require.NoError(t, err)
+	require.Len(t, tradeInputs, 2)
+	buy, err := NewTrade(tradeInputs[0], accountAliases)
+	require.NoError(t, err)
+	require.Equal(t, datav1.TradeSide_TRADE_SIDE_BUY, buy.GetSide())
+	require.Equal(t, "1000", mathpb.ToString(buy.GetQuantity()))
+	require.Equal(t, int64(-2_500_000_000), moneypb.MoneyToMicros(buy.GetProceeds()))
+	require.Equal(t, int64(-10_000_000), moneypb.MoneyToMicros(buy.GetCommission()))
+	sell, err := NewTrade(tradeInputs[1], accountAliases)
+	require.NoError(t, err)
+	require.Equal(t, "-400", mathpb.ToString(sell.GetQuantity()))
+	require.Equal(t, int64(1_600_000_000), moneypb.MoneyToMicros(sell.GetProceeds()))
+
+	dataManualDirPath := t.TempDir()
+	require.NoError(t, AddTrades(dataManualDirPath, []*datav1.Trade{buy}))
+	require.NoError(t, AddTrades(dataManualDirPath, []*datav1.Trade{sell}))
+	trades, err := ReadTrades(dataManualDirPath, "individual")
+	require.NoError(t, err)
+	require.Len(t, trades, 2)
+	// Adding the same trade again is rejected.
+	require.Error(t, AddTrades(dataManualDirPath, []*datav1.Trade{buy}))
+
+	_, err = NewTrade(TradeInput{Account: "unknown", Symbol: "ACME", Date: "2024-03-01", Side: "buy", Quantity: "1", Price: "1", Currency: "USD"}, accountAliases)
+	require.Error(t, err)
+	_, err = NewTrade(TradeInput{Account: "individual", Symbol: "ACME", Date: "2024-03-01", Side: "buy", Quantity: "-1", Price: "1", Currency: "USD"}, accountAliases)
+	require.Error(t, err)
+	_, err = ParseTradeInputs([]byte(`{"account": "individual", "unknown": "x"}`))
+	require.Error(t, err)
+}
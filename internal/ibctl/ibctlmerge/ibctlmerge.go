@@ -6,24 +6,50 @@
 // and the Flex Query cache (supplement) into a unified view for commands to use.
 // Data is organized per account using account aliases.
 //
-// For overlapping date ranges, CSV data takes precedence because the Flex Query
-// API consolidates order executions differently than Activity Statements. Flex
-// Query trades are only used for dates not covered by CSVs.
+// The Flex Query and Activity Statement CSVs overlap and represent the same
+// trades at different granularities (the Flex Query preserves individual order
+// fills while CSVs may consolidate them). Duplicates are detected at trade
+// granularity with a fuzzy matcher (account, symbol, date, signed quantity,
+// price within tolerance) and the CSV side of each match is suppressed.
 package ibctlmerge
 
 import (
 	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"math"
+	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
 	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/bufdev/ibctl/internal/pkg/filemode"
 	"github.com/bufdev/ibctl/internal/pkg/ibkractivitycsv"
+	"github.com/bufdev/ibctl/internal/pkg/ibkrtradeconfirm"
 	"github.com/bufdev/ibctl/internal/pkg/mathpb"
 	"github.com/bufdev/ibctl/internal/pkg/moneypb"
 	"github.com/bufdev/ibctl/internal/pkg/protoio"
 	"github.com/bufdev/ibctl/internal/pkg/timepb"
+	"github.com/bufdev/ibctl/internal/pkg/timing"
+)
+
+// duplicatePriceTolerancePct is the relative price tolerance for matching a CSV
+// trade against Flex Query trades (0.1%). CSV prices may be rounded or averaged
+// across consolidated fills.
+const duplicatePriceTolerancePct = 0.001
+
+// Duplicate match types, by the matching pass that found them.
+const (
+	duplicateMatchExecution = "execution"
+	duplicateMatchOrder     = "order"
+	duplicateMatchDay       = "day"
 )
 
 // MergedData contains all data merged from Activity Statement CSVs and Flex Query cache
@@ -41,22 +67,325 @@ type MergedData struct {
 	CorporateActions []*datav1.CorporateAction
 	// CashPositions is the list of cash balances by currency across all accounts.
 	CashPositions []*datav1.CashPosition
+	// CashTransactions is the list of cash transactions (dividends, interest, fees) across all accounts.
+	CashTransactions []*datav1.CashTransaction
+	// DuplicateMatches records CSV trades that were suppressed because they
+	// matched Flex Query trades.
+	DuplicateMatches []*DuplicateMatch
+}
+
+// MergeOption is an option for Merge.
+type MergeOption func(*mergeOptions)
+
+// MergeWithTrades returns a new MergeOption that loads trades, and the
+// duplicate matches found while merging them.
+func MergeWithTrades() MergeOption {
+	return func(mergeOptions *mergeOptions) {
+		mergeOptions.trades = true
+	}
+}
+
+// MergeWithPositions returns a new MergeOption that loads positions.
+func MergeWithPositions() MergeOption {
+	return func(mergeOptions *mergeOptions) {
+		mergeOptions.positions = true
+	}
 }
 
-// Merge reads Activity Statement CSVs and Flex Query cached data for all accounts,
-// merges them, and returns the result.
+// MergeWithTransfers returns a new MergeOption that loads transfers.
+func MergeWithTransfers() MergeOption {
+	return func(mergeOptions *mergeOptions) {
+		mergeOptions.transfers = true
+	}
+}
+
+// MergeWithTradeTransfers returns a new MergeOption that loads trade transfers.
+func MergeWithTradeTransfers() MergeOption {
+	return func(mergeOptions *mergeOptions) {
+		mergeOptions.tradeTransfers = true
+	}
+}
+
+// MergeWithCorporateActions returns a new MergeOption that loads corporate actions.
+func MergeWithCorporateActions() MergeOption {
+	return func(mergeOptions *mergeOptions) {
+		mergeOptions.corporateActions = true
+	}
+}
+
+// MergeWithCashPositions returns a new MergeOption that loads cash positions.
+func MergeWithCashPositions() MergeOption {
+	return func(mergeOptions *mergeOptions) {
+		mergeOptions.cashPositions = true
+	}
+}
+
+// MergeWithCashTransactions returns a new MergeOption that loads cash transactions.
+func MergeWithCashTransactions() MergeOption {
+	return func(mergeOptions *mergeOptions) {
+		mergeOptions.cashTransactions = true
+	}
+}
+
+// DuplicateMatch records a set of CSV trades suppressed as duplicates of a set of
+// Flex Query trades. A match is one-to-one (same execution), an order rollup
+// (a CSV trade consolidates the executions of one order), or many-to-many (the
+// same-day total for a symbol and side agrees across sources).
+type DuplicateMatch struct {
+	// Match is how the trades were matched: "execution", "order", or "day".
+	Match string `json:"match"`
+	// Account is the account alias.
+	Account string `json:"account"`
+	// Symbol is the ticker symbol.
+	Symbol string `json:"symbol"`
+	// Date is the trade date (YYYY-MM-DD).
+	Date string `json:"date"`
+	// Quantity is the matched signed quantity.
+	Quantity string `json:"quantity"`
+	// CSVPrice is the (weighted average) CSV trade price.
+	CSVPrice string `json:"csv_price"`
+	// FlexPrice is the (weighted average) Flex Query trade price.
+	FlexPrice string `json:"flex_price"`
+	// CSVTradeIDs are the suppressed CSV trade IDs.
+	CSVTradeIDs []string `json:"csv_trade_ids"`
+	// FlexTradeIDs are the Flex Query trade IDs that were kept.
+	FlexTradeIDs []string `json:"flex_trade_ids"`
+}
+
+// DuplicateMatchHeaders returns the column headers for duplicate match table/CSV output.
+func DuplicateMatchHeaders() []string {
+	return []string{"MATCH", "ACCOUNT", "SYMBOL", "DATE", "QUANTITY", "CSV PRICE", "FLEX PRICE", "CSV TRADES", "FLEX TRADES"}
+}
+
+// DuplicateMatchToRow converts a DuplicateMatch to a string slice for table/CSV output.
+func DuplicateMatchToRow(m *DuplicateMatch) []string {
+	return []string{
+		m.Match,
+		m.Account,
+		m.Symbol,
+		m.Date,
+		m.Quantity,
+		m.CSVPrice,
+		m.FlexPrice,
+		strings.Join(m.CSVTradeIDs, " "),
+		strings.Join(m.FlexTradeIDs, " "),
+	}
+}
+
+// Merge reads Activity Statement CSVs, Trade Confirmation Flex reports, and Flex
+// Query cached data for all accounts, merges them, and returns the result. Parsed
+// CSVs are cached per account under cacheActivityStatementsDirPath.
+//
+// The merged result is cached at cacheMergedDataFilePath along with a content
+// fingerprint of every input file. If the fingerprint of the current inputs
+// matches the cached one, the cached result is returned without re-merging.
+// The digest of each input file is kept next to the cache with the file's size
+// and modification time, and only files whose size or modification time
+// changed are read again. If cacheMergedDataFilePath is empty, the result is
+// not cached.
 //
 // For each account, Flex Query trades are loaded first as the primary source
-// (they preserve individual order fills). CSV trades are then loaded only for
-// dates NOT covered by the Flex Query data, since the two sources represent
-// the same trades at different granularities (CSVs consolidate order executions
-// while the Flex Query preserves individual fills).
+// (they preserve individual order fills). Trade confirmations are added next,
+// skipping any whose trade ID was already seen, since they share trade IDs with
+// the Flex Query. CSV trades are then matched against both, and any CSV trade
+// that duplicates them is suppressed and recorded in DuplicateMatches. Unmatched
+// CSV trades are always included. Before matching, cancelled CSV trades are
+// dropped and corrected CSV trades replace the trades they correct, per their
+// IBKR trade codes. If tradeConfirmationsDirPath is empty, trade confirmations
+// are not read.
+// Seed data and manually entered trades are appended as-is.
+//
+// Deposits, withdrawals, and fees from the CSVs are added to the Flex Query
+// cash transactions, except those the Flex Query cash transactions already
+// have (same type, date, currency, and amount).
+//
+// By default all data is loaded. If any MergeOptions are given, only the data
+// they select is loaded and set, and the rest of MergedData is nil. The merged
+// data cache is only used if trades are selected, since merging trades is what
+// it saves; without trades, only the requested files are read.
 func Merge(
 	dataAccountsDirPath string,
 	cacheAccountsDirPath string,
 	activityStatementsDirPath string,
+	cacheActivityStatementsDirPath string,
+	tradeConfirmationsDirPath string,
+	cacheMergedDataFilePath string,
 	seedDirPath string,
+	dataManualDirPath string,
 	accountAliases map[string]string,
+	options ...MergeOption,
+) (*MergedData, error) {
+	defer timing.Start("merge")()
+	mergeOptions := newMergeOptions(options...)
+	// Without trades, there is no deduplication to save, and fingerprinting
+	// the cache would read every input, so only the requested files are read.
+	if cacheMergedDataFilePath == "" || !mergeOptions.trades {
+		return merge(dataAccountsDirPath, cacheAccountsDirPath, activityStatementsDirPath, cacheActivityStatementsDirPath, tradeConfirmationsDirPath, seedDirPath, dataManualDirPath, accountAliases, mergeOptions)
+	}
+	// In a read-only ibctl directory, the cache is kept in a temporary directory.
+	if filemode.CheckWritable(cacheMergedDataFilePath) != nil {
+		tempFilePath, err := tempCacheFilePath(cacheMergedDataFilePath)
+		if err != nil {
+			return nil, err
+		}
+		cacheMergedDataFilePath = tempFilePath
+	}
+	fileDigestsFilePath := fileDigestsFilePathFor(cacheMergedDataFilePath)
+	previousFileDigests := readFileDigests(fileDigestsFilePath)
+	fingerprint, fileDigests, err := computeInputFingerprint(previousFileDigests, dataAccountsDirPath, cacheAccountsDirPath, activityStatementsDirPath, tradeConfirmationsDirPath, seedDirPath, dataManualDirPath, accountAliases)
+	if err != nil {
+		return nil, fmt.Errorf("fingerprinting merge inputs: %w", err)
+	}
+	if !maps.Equal(fileDigests, previousFileDigests) {
+		// Best-effort write; a failure only means re-reading the files next time.
+		_ = writeFileDigests(fileDigestsFilePath, fileDigests)
+	}
+	// Use the cached result if the inputs have not changed.
+	if mergedData, ok := readMergedDataCache(cacheMergedDataFilePath, fingerprint); ok {
+		return mergeOptions.filter(mergedData), nil
+	}
+	// The cache always holds all data, so that every command can use it.
+	mergedData, err := merge(dataAccountsDirPath, cacheAccountsDirPath, activityStatementsDirPath, cacheActivityStatementsDirPath, tradeConfirmationsDirPath, seedDirPath, dataManualDirPath, accountAliases, newMergeOptions())
+	if err != nil {
+		return nil, err
+	}
+	// Best-effort cache write; a failure only means re-merging next time.
+	_ = writeMergedDataCache(cacheMergedDataFilePath, fingerprint, mergedData)
+	return mergeOptions.filter(mergedData), nil
+}
+
+// FilterAccounts returns a copy of the merged data containing only records
+// for the given account aliases. Order within each list is preserved.
+func FilterAccounts(mergedData *MergedData, accountAliases []string) *MergedData {
+	aliasSet := make(map[string]struct{}, len(accountAliases))
+	for _, alias := range accountAliases {
+		aliasSet[alias] = struct{}{}
+	}
+	filtered := &MergedData{
+		Trades:           filterByAccount(mergedData.Trades, aliasSet),
+		Positions:        filterByAccount(mergedData.Positions, aliasSet),
+		Transfers:        filterByAccount(mergedData.Transfers, aliasSet),
+		TradeTransfers:   filterByAccount(mergedData.TradeTransfers, aliasSet),
+		CorporateActions: filterByAccount(mergedData.CorporateActions, aliasSet),
+		CashPositions:    filterByAccount(mergedData.CashPositions, aliasSet),
+		CashTransactions: filterByAccount(mergedData.CashTransactions, aliasSet),
+	}
+	for _, match := range mergedData.DuplicateMatches {
+		if _, ok := aliasSet[match.Account]; ok {
+			filtered.DuplicateMatches = append(filtered.DuplicateMatches, match)
+		}
+	}
+	return filtered
+}
+
+// *** PRIVATE ***
+
+// mergeOptions are the data sources loaded by Merge.
+type mergeOptions struct {
+	trades           bool
+	positions        bool
+	transfers        bool
+	tradeTransfers   bool
+	corporateActions bool
+	cashPositions    bool
+	cashTransactions bool
+}
+
+// newMergeOptions returns the mergeOptions for the options, loading all data
+// if no options are given.
+func newMergeOptions(options ...MergeOption) *mergeOptions {
+	if len(options) == 0 {
+		return &mergeOptions{
+			trades:           true,
+			positions:        true,
+			transfers:        true,
+			tradeTransfers:   true,
+			corporateActions: true,
+			cashPositions:    true,
+			cashTransactions: true,
+		}
+	}
+	mergeOptions := &mergeOptions{}
+	for _, option := range options {
+		option(mergeOptions)
+	}
+	return mergeOptions
+}
+
+// filter returns a copy of the merged data with only the requested data set.
+func (o *mergeOptions) filter(mergedData *MergedData) *MergedData {
+	filtered := &MergedData{}
+	if o.trades {
+		filtered.Trades = mergedData.Trades
+		filtered.DuplicateMatches = mergedData.DuplicateMatches
+	}
+	if o.positions {
+		filtered.Positions = mergedData.Positions
+	}
+	if o.transfers {
+		filtered.Transfers = mergedData.Transfers
+	}
+	if o.tradeTransfers {
+		filtered.TradeTransfers = mergedData.TradeTransfers
+	}
+	if o.corporateActions {
+		filtered.CorporateActions = mergedData.CorporateActions
+	}
+	if o.cashPositions {
+		filtered.CashPositions = mergedData.CashPositions
+	}
+	if o.cashTransactions {
+		filtered.CashTransactions = mergedData.CashTransactions
+	}
+	return filtered
+}
+
+// filterByAccount returns the records whose account alias is in aliasSet.
+func filterByAccount[T interface{ GetAccountId() string }](records []T, aliasSet map[string]struct{}) []T {
+	var filtered []T
+	for _, record := range records {
+		if _, ok := aliasSet[record.GetAccountId()]; ok {
+			filtered = append(filtered, record)
+		}
+	}
+	return filtered
+}
+
+// mergedDataCacheVersion is mixed into the input fingerprint and is bumped
+// whenever the merge logic changes in a way that invalidates cached results.
+const mergedDataCacheVersion = 7
+
+// cacheAccountFileNames are the per-account cache files read by merge.
+var cacheAccountFileNames = []string{
+	"positions.json",
+	"transfers.json",
+	"trade_transfers.json",
+	"corporate_actions.json",
+	"cash_positions.json",
+	"cash_transactions.json",
+}
+
+// statementExtractSource is the Trade source of low-confidence imported
+// transactions, which is ibctlstatement.Source.
+const statementExtractSource = "statement_extract"
+
+// manualFileNames are the per-account files of user-provided trades read by merge.
+var manualFileNames = []string{
+	"trades.json",
+	"transfer_basis.json",
+}
+
+// merge performs the uncached merge of the data requested by mergeOptions. See Merge.
+func merge(
+	dataAccountsDirPath string,
+	cacheAccountsDirPath string,
+	activityStatementsDirPath string,
+	cacheActivityStatementsDirPath string,
+	tradeConfirmationsDirPath string,
+	seedDirPath string,
+	dataManualDirPath string,
+	accountAliases map[string]string,
+	mergeOptions *mergeOptions,
 ) (*MergedData, error) {
 	var allTrades []*datav1.Trade
 	var allPositions []*datav1.Position
@@ -64,98 +393,80 @@ func Merge(
 	var allTradeTransfers []*datav1.TradeTransfer
 	var allCorporateActions []*datav1.CorporateAction
 	var allCashPositions []*datav1.CashPosition
+	var allCashTransactions []*datav1.CashTransaction
+	var allDuplicateMatches []*DuplicateMatch
 	// Process each account: load Flex Query trades first, then supplement with CSVs.
 	for alias := range accountAliases {
-		// Step 1: Load Flex Query cached trades for this account.
-		// These are the primary source — they preserve individual order fills.
-		dataAccountDir := filepath.Join(dataAccountsDirPath, alias)
-		tradesPath := filepath.Join(dataAccountDir, "trades.json")
-		flexTrades, err := protoio.ReadMessagesJSON(tradesPath, func() *datav1.Trade { return &datav1.Trade{} })
-		if err != nil {
-			flexTrades = nil
-		}
-		// Build the set of symbols covered by Flex Query trades and their date range.
-		// CSV trades for these symbols within this range will be excluded.
-		flexSymbols := make(map[string]bool, len(flexTrades))
-		flexMinDate, flexMaxDate := tradeDateRange(flexTrades)
-		for _, trade := range flexTrades {
-			flexSymbols[trade.GetSymbol()] = true
-		}
-		allTrades = append(allTrades, flexTrades...)
-		// Step 2: Load Activity Statement CSV trades. For symbols covered by
-		// the Flex Query, only use CSV trades outside the Flex Query date range
-		// (CSVs extend history beyond the 365-day API window). For symbols NOT
-		// in the Flex Query, all CSV trades are included.
-		csvDir := filepath.Join(activityStatementsDirPath, alias)
-		csvStatements, err := ibkractivitycsv.ParseDirectory(csvDir)
-		if err == nil {
-			for _, statement := range csvStatements {
-				for i := range statement.Trades {
-					trade, err := csvTradeToProto(&statement.Trades[i], alias)
-					if err != nil {
-						continue
-					}
-					// Skip CSV trades only for symbols that have Flex Query coverage
-					// within the Flex Query date range. Symbols not in the Flex Query
-					// (e.g., from a different data source) are always included.
-					if flexMinDate != "" && flexMaxDate != "" && flexSymbols[trade.GetSymbol()] {
-						tradeDate := protoDateString(trade.GetTradeDate())
-						if tradeDate >= flexMinDate && tradeDate <= flexMaxDate {
-							continue
-						}
-					}
-					allTrades = append(allTrades, trade)
-				}
-			}
-		}
-		// Step 3: Load imported transactions from previous broker (seed data).
-		// These are the complete normalized transaction history (buys, sells,
-		// splits, dividends, expiries) from UBS/RBC, converted to Trade protos.
-		if seedDirPath != "" {
-			seedTxnPath := filepath.Join(seedDirPath, alias, "transactions.json")
-			importedTxns, err := protoio.ReadMessagesJSON(seedTxnPath, func() *datav1.ImportedTransaction { return &datav1.ImportedTransaction{} })
+		// Activity Statement CSVs have both trades and cash transactions, so
+		// they are parsed if either is requested.
+		var csvStatements []*ibkractivitycsv.ActivityStatement
+		if mergeOptions.trades || mergeOptions.cashTransactions {
+			csvDir := filepath.Join(activityStatementsDirPath, alias)
+			statements, err := ibkractivitycsv.ParseDirectoryWithCache(csvDir, filepath.Join(cacheActivityStatementsDirPath, alias))
 			if err == nil {
-				for _, txn := range importedTxns {
-					// Only security transactions (buys, sells, splits, etc.) become trades.
-					// Non-security transactions (dividends, interest, fees) return nil.
-					trade := importedTransactionToTrade(txn)
-					if trade != nil {
-						allTrades = append(allTrades, trade)
-					}
-				}
+				csvStatements = statements
 			}
 		}
+		if mergeOptions.trades {
+			trades, duplicateMatches := mergeAccountTrades(dataAccountsDirPath, tradeConfirmationsDirPath, seedDirPath, dataManualDirPath, alias, csvStatements)
+			allTrades = append(allTrades, trades...)
+			allDuplicateMatches = append(allDuplicateMatches, duplicateMatches...)
+		}
 		// Load snapshot data from the cache directory.
 		cacheAccountDir := filepath.Join(cacheAccountsDirPath, alias)
 		// Load Flex Query positions (provides current market prices for verification).
-		positionsPath := filepath.Join(cacheAccountDir, "positions.json")
-		positions, err := protoio.ReadMessagesJSON(positionsPath, func() *datav1.Position { return &datav1.Position{} })
-		if err == nil {
-			allPositions = append(allPositions, positions...)
+		if mergeOptions.positions {
+			positionsPath := filepath.Join(cacheAccountDir, "positions.json")
+			positions, err := protoio.ReadMessagesJSON(positionsPath, func() *datav1.Position { return &datav1.Position{} })
+			if err == nil {
+				allPositions = append(allPositions, positions...)
+			}
 		}
 		// Load transfers for this account.
-		transfersPath := filepath.Join(cacheAccountDir, "transfers.json")
-		transfers, err := protoio.ReadMessagesJSON(transfersPath, func() *datav1.Transfer { return &datav1.Transfer{} })
-		if err == nil {
-			allTransfers = append(allTransfers, transfers...)
+		if mergeOptions.transfers {
+			transfersPath := filepath.Join(cacheAccountDir, "transfers.json")
+			transfers, err := protoio.ReadMessagesJSON(transfersPath, func() *datav1.Transfer { return &datav1.Transfer{} })
+			if err == nil {
+				allTransfers = append(allTransfers, transfers...)
+			}
 		}
 		// Load trade transfers for this account.
-		tradeTransfersPath := filepath.Join(cacheAccountDir, "trade_transfers.json")
-		tradeTransfers, err := protoio.ReadMessagesJSON(tradeTransfersPath, func() *datav1.TradeTransfer { return &datav1.TradeTransfer{} })
-		if err == nil {
-			allTradeTransfers = append(allTradeTransfers, tradeTransfers...)
+		if mergeOptions.tradeTransfers {
+			tradeTransfersPath := filepath.Join(cacheAccountDir, "trade_transfers.json")
+			tradeTransfers, err := protoio.ReadMessagesJSON(tradeTransfersPath, func() *datav1.TradeTransfer { return &datav1.TradeTransfer{} })
+			if err == nil {
+				allTradeTransfers = append(allTradeTransfers, tradeTransfers...)
+			}
 		}
 		// Load corporate actions for this account.
-		corporateActionsPath := filepath.Join(cacheAccountDir, "corporate_actions.json")
-		corporateActions, err := protoio.ReadMessagesJSON(corporateActionsPath, func() *datav1.CorporateAction { return &datav1.CorporateAction{} })
-		if err == nil {
-			allCorporateActions = append(allCorporateActions, corporateActions...)
+		if mergeOptions.corporateActions {
+			corporateActionsPath := filepath.Join(cacheAccountDir, "corporate_actions.json")
+			corporateActions, err := protoio.ReadMessagesJSON(corporateActionsPath, func() *datav1.CorporateAction { return &datav1.CorporateAction{} })
+			if err == nil {
+				allCorporateActions = append(allCorporateActions, corporateActions...)
+			}
 		}
 		// Load cash positions for this account.
-		cashPositionsPath := filepath.Join(cacheAccountDir, "cash_positions.json")
-		cashPositions, err := protoio.ReadMessagesJSON(cashPositionsPath, func() *datav1.CashPosition { return &datav1.CashPosition{} })
-		if err == nil {
-			allCashPositions = append(allCashPositions, cashPositions...)
+		if mergeOptions.cashPositions {
+			cashPositionsPath := filepath.Join(cacheAccountDir, "cash_positions.json")
+			cashPositions, err := protoio.ReadMessagesJSON(cashPositionsPath, func() *datav1.CashPosition { return &datav1.CashPosition{} })
+			if err == nil {
+				allCashPositions = append(allCashPositions, cashPositions...)
+			}
+		}
+		// Load cash transactions for this account.
+		if mergeOptions.cashTransactions {
+			cashTransactionsPath := filepath.Join(cacheAccountDir, "cash_transactions.json")
+			cashTransactions, err := protoio.ReadMessagesJSON(cashTransactionsPath, func() *datav1.CashTransaction { return &datav1.CashTransaction{} })
+			if err != nil {
+				cashTransactions = nil
+			}
+			allCashTransactions = append(allCashTransactions, cashTransactions...)
+			// Add CSV deposits, withdrawals, and fees that the Flex Query cash
+			// transactions do not already have, which covers the history before
+			// the Flex Query window.
+			csvCashTransactions := csvStatementsCashTransactions(csvStatements, alias)
+			allCashTransactions = append(allCashTransactions, matchDuplicateCashTransactions(csvCashTransactions, cashTransactions)...)
 		}
 	}
 	// Sort all trades by date for deterministic output.
@@ -171,6 +482,15 @@ func Merge(
 		}
 		return allTrades[i].GetSymbol() < allTrades[j].GetSymbol()
 	})
+	// Sort all cash transactions by date, then account, for deterministic output.
+	sort.Slice(allCashTransactions, func(i, j int) bool {
+		dateI := protoDateString(allCashTransactions[i].GetDate())
+		dateJ := protoDateString(allCashTransactions[j].GetDate())
+		if dateI != dateJ {
+			return dateI < dateJ
+		}
+		return allCashTransactions[i].GetAccountId() < allCashTransactions[j].GetAccountId()
+	})
 	return &MergedData{
 		Trades:           allTrades,
 		Positions:        allPositions,
@@ -178,26 +498,615 @@ func Merge(
 		TradeTransfers:   allTradeTransfers,
 		CorporateActions: allCorporateActions,
 		CashPositions:    allCashPositions,
+		CashTransactions: allCashTransactions,
+		DuplicateMatches: allDuplicateMatches,
 	}, nil
 }
 
-// tradeDateRange returns the min and max trade dates as sortable strings.
-// Returns empty strings if there are no trades.
-func tradeDateRange(trades []*datav1.Trade) (string, string) {
-	var minDate, maxDate string
-	for _, trade := range trades {
-		dateStr := protoDateString(trade.GetTradeDate())
-		if dateStr == "" {
+// mergeAccountTrades returns the trades of the account from all sources and
+// the CSV trades suppressed as duplicates.
+//
+// Flex Query trades are the primary source, since they preserve individual
+// order fills. Trade confirmations and Activity Statement CSV trades
+// supplement them, with duplicates removed, and seed and manual trades are
+// appended as-is.
+func mergeAccountTrades(
+	dataAccountsDirPath string,
+	tradeConfirmationsDirPath string,
+	seedDirPath string,
+	dataManualDirPath string,
+	alias string,
+	csvStatements []*ibkractivitycsv.ActivityStatement,
+) ([]*datav1.Trade, []*DuplicateMatch) {
+	var trades []*datav1.Trade
+	var duplicateMatches []*DuplicateMatch
+	// Step 1: Load Flex Query cached trades for this account.
+	// These are the primary source — they preserve individual order fills.
+	dataAccountDir := filepath.Join(dataAccountsDirPath, alias)
+	tradesPath := filepath.Join(dataAccountDir, "trades.json")
+	flexTrades, err := protoio.ReadMessagesJSON(tradesPath, func() *datav1.Trade { return &datav1.Trade{} })
+	if err != nil {
+		flexTrades = nil
+	}
+	trades = append(trades, flexTrades...)
+	// Step 2: Load Trade Confirmation Flex report trades, which cover periods
+	// some users have no Activity Statements for. Executions have the same
+	// trade IDs as in the Flex Query, so duplicates are dropped by ID,
+	// including across overlapping reports.
+	primaryTrades := flexTrades
+	if tradeConfirmationsDirPath != "" {
+		tradeConfirms, err := ibkrtradeconfirm.ParseDirectory(filepath.Join(tradeConfirmationsDirPath, alias))
+		if err == nil {
+			seenTradeIDs := make(map[string]struct{}, len(flexTrades))
+			for _, trade := range flexTrades {
+				seenTradeIDs[trade.GetTradeId()] = struct{}{}
+			}
+			for _, tradeConfirm := range tradeConfirms {
+				trade, err := tradeConfirmToProto(tradeConfirm, alias)
+				if err != nil {
+					continue
+				}
+				if _, ok := seenTradeIDs[trade.GetTradeId()]; ok {
+					continue
+				}
+				seenTradeIDs[trade.GetTradeId()] = struct{}{}
+				primaryTrades = append(primaryTrades, trade)
+				trades = append(trades, trade)
+			}
+		}
+	}
+	// Step 3: Add Activity Statement CSV trades, with cancellations and
+	// corrections applied, and suppress those that duplicate Flex Query or
+	// trade confirmation trades. CSVs extend history beyond the 365-day API
+	// window, so most CSV trades will not match and are kept.
+	if len(csvStatements) > 0 {
+		var csvTrades []*datav1.Trade
+		for _, csvTrade := range applyTradeCodes(csvStatements) {
+			trade, err := csvTradeToProto(csvTrade, alias)
+			if err != nil {
+				continue
+			}
+			csvTrades = append(csvTrades, trade)
+		}
+		var uniqueCSVTrades []*datav1.Trade
+		uniqueCSVTrades, duplicateMatches = matchDuplicateTrades(csvTrades, primaryTrades)
+		trades = append(trades, uniqueCSVTrades...)
+	}
+	// Step 4: Load imported transactions from previous broker (seed data).
+	// These are the complete normalized transaction history (buys, sells,
+	// splits, dividends, expiries) from UBS/RBC, converted to Trade protos.
+	if seedDirPath != "" {
+		seedTxnPath := filepath.Join(seedDirPath, alias, "transactions.json")
+		importedTxns, err := protoio.ReadMessagesJSON(seedTxnPath, func() *datav1.ImportedTransaction { return &datav1.ImportedTransaction{} })
+		if err == nil {
+			for _, txn := range importedTxns {
+				// Only security transactions (buys, sells, splits, etc.) become trades.
+				// Non-security transactions (dividends, interest, fees) return nil.
+				trade := importedTransactionToTrade(txn)
+				if trade != nil {
+					trades = append(trades, trade)
+				}
+			}
+		}
+	}
+	// Step 5: Load manually entered trades (e.g., private placements held
+	// outside IBKR) and imported transfer basis lots. These are already
+	// Trade protos.
+	if dataManualDirPath != "" {
+		for _, fileName := range manualFileNames {
+			manualTradesPath := filepath.Join(dataManualDirPath, alias, fileName)
+			manualTrades, err := protoio.ReadMessagesJSON(manualTradesPath, func() *datav1.Trade { return &datav1.Trade{} })
+			if err == nil {
+				trades = append(trades, manualTrades...)
+			}
+		}
+	}
+	return trades, duplicateMatches
+}
+
+// computeInputFingerprint returns a hex-encoded SHA-256 fingerprint of every
+// file merge reads, covering the account aliases, file paths, and file contents.
+// Missing files contribute a marker so that creating or deleting a file changes
+// the fingerprint.
+//
+// The digest of a file in previousFileDigests is reused if the file's size and
+// modification time are unchanged. The digests of all existing input files are
+// returned along with the fingerprint.
+func computeInputFingerprint(
+	previousFileDigests map[string]fileDigest,
+	dataAccountsDirPath string,
+	cacheAccountsDirPath string,
+	activityStatementsDirPath string,
+	tradeConfirmationsDirPath string,
+	seedDirPath string,
+	dataManualDirPath string,
+	accountAliases map[string]string,
+) (string, map[string]fileDigest, error) {
+	aliases := make([]string, 0, len(accountAliases))
+	for alias := range accountAliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	hash := sha256.New()
+	fileDigests := make(map[string]fileDigest)
+	_, _ = fmt.Fprintf(hash, "version:%d\n", mergedDataCacheVersion)
+	for _, alias := range aliases {
+		filePaths := []string{filepath.Join(dataAccountsDirPath, alias, "trades.json")}
+		for _, fileName := range cacheAccountFileNames {
+			filePaths = append(filePaths, filepath.Join(cacheAccountsDirPath, alias, fileName))
+		}
+		csvFilePaths, err := listCSVFilePaths(filepath.Join(activityStatementsDirPath, alias))
+		if err != nil {
+			return "", nil, err
+		}
+		filePaths = append(filePaths, csvFilePaths...)
+		if tradeConfirmationsDirPath != "" {
+			tradeConfirmationFilePaths, err := ibkrtradeconfirm.ListFilePaths(filepath.Join(tradeConfirmationsDirPath, alias))
+			if err != nil {
+				return "", nil, err
+			}
+			filePaths = append(filePaths, tradeConfirmationFilePaths...)
+		}
+		if seedDirPath != "" {
+			filePaths = append(filePaths, filepath.Join(seedDirPath, alias, "transactions.json"))
+		}
+		if dataManualDirPath != "" {
+			for _, fileName := range manualFileNames {
+				filePaths = append(filePaths, filepath.Join(dataManualDirPath, alias, fileName))
+			}
+		}
+		_, _ = fmt.Fprintf(hash, "alias:%s\n", alias)
+		for _, filePath := range filePaths {
+			if err := hashFile(hash, filePath, previousFileDigests, fileDigests); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), fileDigests, nil
+}
+
+// fileDigest is the SHA-256 digest of an input file, recorded with the size
+// and modification time the file had when it was read.
+type fileDigest struct {
+	Size        int64  `json:"size"`
+	ModTimeNano int64  `json:"mod_time_nano"`
+	SHA256      string `json:"sha256"`
+}
+
+// hashFile writes the file path and a digest of its contents to the hash, and
+// records the digest in fileDigests. The digest in previousFileDigests is used
+// without reading the file if the file's size and modification time match it.
+// A missing file is recorded as such rather than returning an error.
+func hashFile(hash io.Writer, filePath string, previousFileDigests map[string]fileDigest, fileDigests map[string]fileDigest) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			_, _ = fmt.Fprintf(hash, "file:%s:missing\n", filePath)
+			return nil
+		}
+		return err
+	}
+	digest, ok := previousFileDigests[filePath]
+	if !ok || digest.Size != info.Size() || digest.ModTimeNano != info.ModTime().UnixNano() {
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		digest = fileDigest{
+			Size:        info.Size(),
+			ModTimeNano: info.ModTime().UnixNano(),
+			SHA256:      hex.EncodeToString(sum[:]),
+		}
+	}
+	fileDigests[filePath] = digest
+	_, _ = fmt.Fprintf(hash, "file:%s:%s\n", filePath, digest.SHA256)
+	return nil
+}
+
+// fileDigestsFilePathFor returns the path of the input file digests kept next
+// to the merged data cache file.
+func fileDigestsFilePathFor(cacheMergedDataFilePath string) string {
+	return strings.TrimSuffix(cacheMergedDataFilePath, filepath.Ext(cacheMergedDataFilePath)) + "_file_digests.json"
+}
+
+// readFileDigests reads the input file digests, returning nil if the file is
+// missing or unreadable.
+func readFileDigests(filePath string) map[string]fileDigest {
+	data, err := protoio.ReadFile(filePath)
+	if err != nil {
+		return nil
+	}
+	var fileDigests map[string]fileDigest
+	if err := json.Unmarshal(data, &fileDigests); err != nil {
+		return nil
+	}
+	return fileDigests
+}
+
+// writeFileDigests writes the input file digests.
+func writeFileDigests(filePath string, fileDigests map[string]fileDigest) error {
+	data, err := json.Marshal(fileDigests)
+	if err != nil {
+		return err
+	}
+	if err := filemode.MkdirAll(filepath.Dir(filePath)); err != nil {
+		return err
+	}
+	return protoio.WriteFile(filePath, data)
+}
+
+// listCSVFilePaths returns all *.csv files under the directory in walk order.
+// Returns nil if the directory does not exist.
+func listCSVFilePaths(dirPath string) ([]string, error) {
+	var filePaths []string
+	err := filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(strings.ToLower(d.Name()), ".csv") {
+			filePaths = append(filePaths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return filePaths, nil
+}
+
+// readMergedDataCache reads the cached merged data, returning false if the
+// cache is missing, unreadable, or was computed from different inputs.
+func readMergedDataCache(filePath string, fingerprint string) (*MergedData, bool) {
+	cached := &datav1.MergedData{}
+	if err := protoio.ReadMessageJSON(filePath, cached); err != nil {
+		return nil, false
+	}
+	if cached.GetInputFingerprint() != fingerprint {
+		return nil, false
+	}
+	duplicateMatches := make([]*DuplicateMatch, 0, len(cached.GetDuplicateMatches()))
+	for _, match := range cached.GetDuplicateMatches() {
+		duplicateMatches = append(duplicateMatches, &DuplicateMatch{
+			Match:        match.GetMatch(),
+			Account:      match.GetAccountId(),
+			Symbol:       match.GetSymbol(),
+			Date:         match.GetDate(),
+			Quantity:     match.GetQuantity(),
+			CSVPrice:     match.GetCsvPrice(),
+			FlexPrice:    match.GetFlexPrice(),
+			CSVTradeIDs:  match.GetCsvTradeIds(),
+			FlexTradeIDs: match.GetFlexTradeIds(),
+		})
+	}
+	return &MergedData{
+		Trades:           cached.GetTrades(),
+		Positions:        cached.GetPositions(),
+		Transfers:        cached.GetTransfers(),
+		TradeTransfers:   cached.GetTradeTransfers(),
+		CorporateActions: cached.GetCorporateActions(),
+		CashPositions:    cached.GetCashPositions(),
+		CashTransactions: cached.GetCashTransactions(),
+		DuplicateMatches: duplicateMatches,
+	}, true
+}
+
+// tempCacheFilePath returns the path of a cache file of a read-only ibctl
+// directory in the temporary directory, unique to the cache file path.
+func tempCacheFilePath(filePath string) (string, error) {
+	absFilePath, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256([]byte(absFilePath))
+	return filepath.Join(os.TempDir(), "ibctl-"+hex.EncodeToString(hash[:8]), filepath.Base(filePath)), nil
+}
+
+// writeMergedDataCache writes the merged data to the cache file with its input fingerprint.
+func writeMergedDataCache(filePath string, fingerprint string, mergedData *MergedData) error {
+	duplicateMatches := make([]*datav1.DuplicateMatch, 0, len(mergedData.DuplicateMatches))
+	for _, match := range mergedData.DuplicateMatches {
+		duplicateMatches = append(duplicateMatches, &datav1.DuplicateMatch{
+			AccountId:    match.Account,
+			Symbol:       match.Symbol,
+			Date:         match.Date,
+			Quantity:     match.Quantity,
+			CsvPrice:     match.CSVPrice,
+			FlexPrice:    match.FlexPrice,
+			CsvTradeIds:  match.CSVTradeIDs,
+			FlexTradeIds: match.FlexTradeIDs,
+			Match:        match.Match,
+		})
+	}
+	if err := filemode.MkdirAll(filepath.Dir(filePath)); err != nil {
+		return err
+	}
+	return protoio.WriteMessageJSON(filePath, &datav1.MergedData{
+		InputFingerprint: fingerprint,
+		Trades:           mergedData.Trades,
+		Positions:        mergedData.Positions,
+		Transfers:        mergedData.Transfers,
+		TradeTransfers:   mergedData.TradeTransfers,
+		CorporateActions: mergedData.CorporateActions,
+		CashPositions:    mergedData.CashPositions,
+		CashTransactions: mergedData.CashTransactions,
+		DuplicateMatches: duplicateMatches,
+	})
+}
+
+// duplicateKey groups trades that can be duplicates of each other.
+type duplicateKey struct {
+	accountAlias string
+	symbol       string
+	date         string
+	side         datav1.TradeSide
+}
+
+// matchDuplicateTrades matches CSV trades against Flex Query trades and returns
+// the CSV trades that are not duplicates, along with a record of every match.
+//
+// Flex Query trades are executions, while Activity Statement CSVs consolidate
+// the executions of an order into one trade. Matching happens in three passes
+// within each (account, symbol, date, side) group:
+//
+//  1. Execution: a CSV trade with the same signed quantity as an unmatched
+//     Flex Query trade and a price within tolerance.
+//  2. Order: a CSV trade with the same total quantity as the unmatched Flex
+//     Query trades of one order ID and a weighted average price within tolerance.
+//  3. Day: the remaining CSV trades in the group, taken together, have the same
+//     total quantity as the remaining Flex Query trades without an order ID and
+//     a weighted average price within tolerance. This covers trades cached
+//     before order IDs were recorded, and trade confirmations without order IDs.
+func matchDuplicateTrades(csvTrades []*datav1.Trade, flexTrades []*datav1.Trade) ([]*datav1.Trade, []*DuplicateMatch) {
+	// Group Flex Query trades by key.
+	flexByKey := make(map[duplicateKey][]*datav1.Trade)
+	for _, trade := range flexTrades {
+		key := newDuplicateKey(trade)
+		flexByKey[key] = append(flexByKey[key], trade)
+	}
+	flexMatched := make(map[*datav1.Trade]bool)
+	var matches []*DuplicateMatch
+	// Pass 1: execution matches.
+	var csvRemaining []*datav1.Trade
+	for _, csvTrade := range csvTrades {
+		key := newDuplicateKey(csvTrade)
+		matched := false
+		for _, flexTrade := range flexByKey[key] {
+			if flexMatched[flexTrade] {
+				continue
+			}
+			if mathpb.ToMicros(flexTrade.GetQuantity()) != mathpb.ToMicros(csvTrade.GetQuantity()) {
+				continue
+			}
+			if !pricesWithinTolerance(tradePriceFloat(csvTrade), tradePriceFloat(flexTrade)) {
+				continue
+			}
+			flexMatched[flexTrade] = true
+			matches = append(matches, newDuplicateMatch(duplicateMatchExecution, key, []*datav1.Trade{csvTrade}, []*datav1.Trade{flexTrade}))
+			matched = true
+			break
+		}
+		if !matched {
+			csvRemaining = append(csvRemaining, csvTrade)
+		}
+	}
+	// Pass 2: order matches. The unmatched executions of each order are rolled
+	// up, in order of first appearance. Remaining CSV trades are grouped for
+	// pass 3, preserving their original order.
+	flexOrdersByKey := make(map[duplicateKey][][]*datav1.Trade)
+	orderIndexes := make(map[duplicateKey]map[string]int)
+	for _, flexTrade := range flexTrades {
+		orderID := flexTrade.GetOrderId()
+		if flexMatched[flexTrade] || orderID == "" {
+			continue
+		}
+		key := newDuplicateKey(flexTrade)
+		if orderIndexes[key] == nil {
+			orderIndexes[key] = make(map[string]int)
+		}
+		index, ok := orderIndexes[key][orderID]
+		if !ok {
+			index = len(flexOrdersByKey[key])
+			orderIndexes[key][orderID] = index
+			flexOrdersByKey[key] = append(flexOrdersByKey[key], nil)
+		}
+		flexOrdersByKey[key][index] = append(flexOrdersByKey[key][index], flexTrade)
+	}
+	csvRemainingByKey := make(map[duplicateKey][]*datav1.Trade)
+	var csvKeys []duplicateKey
+	for _, csvTrade := range csvRemaining {
+		key := newDuplicateKey(csvTrade)
+		matched := false
+		for _, flexOrder := range flexOrdersByKey[key] {
+			if flexMatched[flexOrder[0]] {
+				continue
+			}
+			if totalQuantityMicros(flexOrder) != mathpb.ToMicros(csvTrade.GetQuantity()) {
+				continue
+			}
+			if !pricesWithinTolerance(tradePriceFloat(csvTrade), weightedAveragePrice(flexOrder)) {
+				continue
+			}
+			for _, flexTrade := range flexOrder {
+				flexMatched[flexTrade] = true
+			}
+			matches = append(matches, newDuplicateMatch(duplicateMatchOrder, key, []*datav1.Trade{csvTrade}, flexOrder))
+			matched = true
+			break
+		}
+		if matched {
+			continue
+		}
+		if _, ok := csvRemainingByKey[key]; !ok {
+			csvKeys = append(csvKeys, key)
+		}
+		csvRemainingByKey[key] = append(csvRemainingByKey[key], csvTrade)
+	}
+	// Pass 3: day matches per group, against trades without an order ID.
+	var unique []*datav1.Trade
+	for _, key := range csvKeys {
+		csvGroup := csvRemainingByKey[key]
+		var flexGroup []*datav1.Trade
+		for _, flexTrade := range flexByKey[key] {
+			if !flexMatched[flexTrade] && flexTrade.GetOrderId() == "" {
+				flexGroup = append(flexGroup, flexTrade)
+			}
+		}
+		if len(flexGroup) > 0 &&
+			totalQuantityMicros(csvGroup) == totalQuantityMicros(flexGroup) &&
+			pricesWithinTolerance(weightedAveragePrice(csvGroup), weightedAveragePrice(flexGroup)) {
+			for _, flexTrade := range flexGroup {
+				flexMatched[flexTrade] = true
+			}
+			matches = append(matches, newDuplicateMatch(duplicateMatchDay, key, csvGroup, flexGroup))
 			continue
 		}
-		if minDate == "" || dateStr < minDate {
-			minDate = dateStr
+		unique = append(unique, csvGroup...)
+	}
+	return unique, matches
+}
+
+// newDuplicateKey returns the duplicate grouping key for a trade.
+func newDuplicateKey(trade *datav1.Trade) duplicateKey {
+	return duplicateKey{
+		accountAlias: trade.GetAccountId(),
+		symbol:       trade.GetSymbol(),
+		date:         protoDateString(trade.GetTradeDate()),
+		side:         trade.GetSide(),
+	}
+}
+
+// newDuplicateMatch builds a DuplicateMatch record for matched CSV and Flex Query trades.
+func newDuplicateMatch(matchType string, key duplicateKey, csvTrades []*datav1.Trade, flexTrades []*datav1.Trade) *DuplicateMatch {
+	match := &DuplicateMatch{
+		Match:     matchType,
+		Account:   key.accountAlias,
+		Symbol:    key.symbol,
+		Date:      key.date,
+		Quantity:  mathpb.ToString(mathpb.FromMicros(totalQuantityMicros(csvTrades))),
+		CSVPrice:  formatPrice(weightedAveragePrice(csvTrades)),
+		FlexPrice: formatPrice(weightedAveragePrice(flexTrades)),
+	}
+	for _, trade := range csvTrades {
+		match.CSVTradeIDs = append(match.CSVTradeIDs, trade.GetTradeId())
+	}
+	for _, trade := range flexTrades {
+		match.FlexTradeIDs = append(match.FlexTradeIDs, trade.GetTradeId())
+	}
+	return match
+}
+
+// totalQuantityMicros returns the sum of signed trade quantities in micros.
+func totalQuantityMicros(trades []*datav1.Trade) int64 {
+	var total int64
+	for _, trade := range trades {
+		total += mathpb.ToMicros(trade.GetQuantity())
+	}
+	return total
+}
+
+// weightedAveragePrice returns the quantity-weighted average trade price.
+// Floats are sufficient here since the result is only used for tolerance checks and display.
+func weightedAveragePrice(trades []*datav1.Trade) float64 {
+	var totalValue, totalQuantity float64
+	for _, trade := range trades {
+		quantity := math.Abs(float64(mathpb.ToMicros(trade.GetQuantity())))
+		totalValue += tradePriceFloat(trade) * quantity
+		totalQuantity += quantity
+	}
+	if totalQuantity == 0 {
+		return 0
+	}
+	return totalValue / totalQuantity
+}
+
+// tradePriceFloat returns the trade price as a float64 in currency units.
+func tradePriceFloat(trade *datav1.Trade) float64 {
+	return float64(moneypb.MoneyToMicros(trade.GetTradePrice())) / 1_000_000
+}
+
+// pricesWithinTolerance returns true if two prices differ by no more than duplicatePriceTolerancePct.
+func pricesWithinTolerance(a float64, b float64) bool {
+	if a == b {
+		return true
+	}
+	reference := math.Max(math.Abs(a), math.Abs(b))
+	return math.Abs(a-b)/reference <= duplicatePriceTolerancePct
+}
+
+// formatPrice formats a float price as a decimal string with up to 6 decimal places.
+func formatPrice(price float64) string {
+	return mathpb.ToString(mathpb.FromMicros(int64(math.Round(price * 1_000_000))))
+}
+
+// tradeCodeKey identifies the executions of a symbol at one time, which a
+// cancellation or correction refers to.
+type tradeCodeKey struct {
+	symbol   string
+	dateTime time.Time
+}
+
+// applyTradeCodes returns the CSV trades of the statements with cancellations
+// and corrections applied:
+//
+//   - A cancelled trade (code "Ca") is dropped, along with the trades of the
+//     symbol at the same time with the same or the opposite quantity, which
+//     are the trade it cancels, including as listed in other statements.
+//   - A corrected trade (code "Co") replaces the trades of the symbol at the
+//     same time that are not corrections themselves, which are the trades it
+//     corrects.
+//
+// All other trade codes are informational.
+func applyTradeCodes(statements []*ibkractivitycsv.ActivityStatement) []*ibkractivitycsv.Trade {
+	// Cancelled quantities are keyed by their absolute value in micros.
+	cancelledQuantities := make(map[tradeCodeKey]map[int64]struct{})
+	corrected := make(map[tradeCodeKey]struct{})
+	var csvTrades []*ibkractivitycsv.Trade
+	for _, statement := range statements {
+		for i := range statement.Trades {
+			csvTrade := &statement.Trades[i]
+			csvTrades = append(csvTrades, csvTrade)
+			key := tradeCodeKey{symbol: csvTrade.Symbol, dateTime: csvTrade.DateTime}
+			if csvTrade.HasCode(ibkractivitycsv.TradeCodeCancelled) {
+				if _, ok := cancelledQuantities[key]; !ok {
+					cancelledQuantities[key] = make(map[int64]struct{})
+				}
+				cancelledQuantities[key][absQuantityMicros(csvTrade)] = struct{}{}
+			}
+			if csvTrade.HasCode(ibkractivitycsv.TradeCodeCorrected) {
+				corrected[key] = struct{}{}
+			}
+		}
+	}
+	result := make([]*ibkractivitycsv.Trade, 0, len(csvTrades))
+	for _, csvTrade := range csvTrades {
+		key := tradeCodeKey{symbol: csvTrade.Symbol, dateTime: csvTrade.DateTime}
+		if _, ok := cancelledQuantities[key][absQuantityMicros(csvTrade)]; ok {
+			continue
 		}
-		if maxDate == "" || dateStr > maxDate {
-			maxDate = dateStr
+		if _, ok := corrected[key]; ok && !csvTrade.HasCode(ibkractivitycsv.TradeCodeCorrected) {
+			continue
 		}
+		result = append(result, csvTrade)
+	}
+	return result
+}
+
+// absQuantityMicros returns the absolute quantity of the CSV trade in micros,
+// or -1 if the quantity cannot be parsed.
+func absQuantityMicros(csvTrade *ibkractivitycsv.Trade) int64 {
+	quantity, err := mathpb.NewDecimal(csvTrade.Quantity)
+	if err != nil {
+		return -1
 	}
-	return minDate, maxDate
+	quantityMicros := mathpb.ToMicros(quantity)
+	if quantityMicros < 0 {
+		return -quantityMicros
+	}
+	return quantityMicros
 }
 
 // csvTradeToProto converts an Activity Statement CSV trade to a proto Trade.
@@ -249,6 +1158,186 @@ func csvTradeToProto(csvTrade *ibkractivitycsv.Trade, accountAlias string) (*dat
 	}, nil
 }
 
+// csvStatementsCashTransactions converts the deposits, withdrawals, and fees of
+// the CSV statements of an account to proto CashTransactions.
+//
+// Statements can overlap (e.g., a yearly statement and a monthly statement),
+// so identical transactions (same type, date, currency, and amount) are
+// counted per statement and kept as many times as the statement with the
+// most of them has, rather than summed across statements.
+func csvStatementsCashTransactions(statements []*ibkractivitycsv.ActivityStatement, accountAlias string) []*datav1.CashTransaction {
+	keyToCashTransactions := make(map[cashTransactionKey][]*datav1.CashTransaction)
+	var keys []cashTransactionKey
+	for _, statement := range statements {
+		statementKeyToCashTransactions := make(map[cashTransactionKey][]*datav1.CashTransaction)
+		add := func(cashTransaction *datav1.CashTransaction, err error) {
+			if err != nil {
+				return
+			}
+			key := newCashTransactionKey(cashTransaction)
+			statementKeyToCashTransactions[key] = append(statementKeyToCashTransactions[key], cashTransaction)
+		}
+		for i := range statement.DepositWithdrawals {
+			depositWithdrawal := &statement.DepositWithdrawals[i]
+			add(newCSVCashTransaction(
+				accountAlias,
+				datav1.CashTransactionType_CASH_TRANSACTION_TYPE_DEPOSIT_WITHDRAWAL,
+				depositWithdrawal.Date,
+				depositWithdrawal.CurrencyCode,
+				depositWithdrawal.Amount,
+				depositWithdrawal.Description,
+			))
+		}
+		for i := range statement.Fees {
+			fee := &statement.Fees[i]
+			add(newCSVCashTransaction(
+				accountAlias,
+				datav1.CashTransactionType_CASH_TRANSACTION_TYPE_FEE,
+				fee.Date,
+				fee.CurrencyCode,
+				fee.Amount,
+				fee.Description,
+			))
+		}
+		for key, cashTransactions := range statementKeyToCashTransactions {
+			existing, ok := keyToCashTransactions[key]
+			if !ok {
+				keys = append(keys, key)
+			}
+			if len(cashTransactions) > len(existing) {
+				keyToCashTransactions[key] = cashTransactions
+			}
+		}
+	}
+	var result []*datav1.CashTransaction
+	for _, key := range keys {
+		result = append(result, keyToCashTransactions[key]...)
+	}
+	return result
+}
+
+// newCSVCashTransaction creates a proto CashTransaction from a CSV row, with a
+// deterministic transaction ID since CSVs don't have one.
+func newCSVCashTransaction(
+	accountAlias string,
+	cashTransactionType datav1.CashTransactionType,
+	date time.Time,
+	currencyCode string,
+	amountString string,
+	description string,
+) (*datav1.CashTransaction, error) {
+	protoDate, err := timepb.NewProtoDate(date.Year(), date.Month(), date.Day())
+	if err != nil {
+		return nil, err
+	}
+	amount, err := moneypb.NewProtoMoney(currencyCode, amountString)
+	if err != nil {
+		return nil, fmt.Errorf("parsing amount %q: %w", amountString, err)
+	}
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%d|%s|%s|%s|%s", cashTransactionType, date.Format("2006-01-02"), currencyCode, amountString, description)))
+	return &datav1.CashTransaction{
+		AccountId:     accountAlias,
+		Type:          cashTransactionType,
+		Date:          protoDate,
+		Amount:        amount,
+		CurrencyCode:  currencyCode,
+		Description:   description,
+		TransactionId: fmt.Sprintf("csv-%x", hash[:8]),
+	}, nil
+}
+
+// cashTransactionKey identifies cash transactions that can be duplicates of each other.
+type cashTransactionKey struct {
+	cashTransactionType datav1.CashTransactionType
+	date                string
+	currencyCode        string
+	amountMicros        int64
+}
+
+// newCashTransactionKey returns the duplicate key for a cash transaction.
+func newCashTransactionKey(cashTransaction *datav1.CashTransaction) cashTransactionKey {
+	return cashTransactionKey{
+		cashTransactionType: cashTransaction.GetType(),
+		date:                protoDateString(cashTransaction.GetDate()),
+		currencyCode:        cashTransaction.GetCurrencyCode(),
+		amountMicros:        moneypb.MoneyToMicros(cashTransaction.GetAmount()),
+	}
+}
+
+// matchDuplicateCashTransactions returns the CSV cash transactions that are not
+// duplicates of Flex Query cash transactions. Each Flex Query cash transaction
+// suppresses at most one CSV cash transaction with the same key.
+func matchDuplicateCashTransactions(csvCashTransactions []*datav1.CashTransaction, flexCashTransactions []*datav1.CashTransaction) []*datav1.CashTransaction {
+	flexKeyCounts := make(map[cashTransactionKey]int)
+	for _, cashTransaction := range flexCashTransactions {
+		flexKeyCounts[newCashTransactionKey(cashTransaction)]++
+	}
+	var unique []*datav1.CashTransaction
+	for _, cashTransaction := range csvCashTransactions {
+		key := newCashTransactionKey(cashTransaction)
+		if flexKeyCounts[key] > 0 {
+			flexKeyCounts[key]--
+			continue
+		}
+		unique = append(unique, cashTransaction)
+	}
+	return unique
+}
+
+// tradeConfirmToProto converts a Trade Confirmation Flex report execution to a proto Trade.
+func tradeConfirmToProto(tradeConfirm *ibkrtradeconfirm.TradeConfirm, accountAlias string) (*datav1.Trade, error) {
+	quantity, err := mathpb.NewDecimal(tradeConfirm.Quantity)
+	if err != nil {
+		return nil, fmt.Errorf("parsing quantity %q: %w", tradeConfirm.Quantity, err)
+	}
+	side := datav1.TradeSide_TRADE_SIDE_BUY
+	if mathpb.ToMicros(quantity) < 0 {
+		side = datav1.TradeSide_TRADE_SIDE_SELL
+	}
+	protoTradeDate, err := timepb.NewProtoDate(tradeConfirm.TradeDate.Year(), tradeConfirm.TradeDate.Month(), tradeConfirm.TradeDate.Day())
+	if err != nil {
+		return nil, err
+	}
+	protoSettleDate, err := timepb.NewProtoDate(tradeConfirm.SettleDate.Year(), tradeConfirm.SettleDate.Month(), tradeConfirm.SettleDate.Day())
+	if err != nil {
+		return nil, err
+	}
+	currencyCode := tradeConfirm.CurrencyCode
+	tradePrice, err := moneypb.NewProtoMoney(currencyCode, tradeConfirm.Price)
+	if err != nil {
+		return nil, fmt.Errorf("parsing trade price: %w", err)
+	}
+	proceeds, err := moneypb.NewProtoMoney(currencyCode, tradeConfirm.Proceeds)
+	if err != nil {
+		return nil, fmt.Errorf("parsing proceeds: %w", err)
+	}
+	commission, err := moneypb.NewProtoMoney(currencyCode, tradeConfirm.Commission)
+	if err != nil {
+		return nil, fmt.Errorf("parsing commission: %w", err)
+	}
+	// Reports without a trade ID column get a deterministic ID like CSV trades.
+	tradeID := tradeConfirm.TradeID
+	if tradeID == "" {
+		tradeID = generateTradeID(tradeConfirm.Symbol, tradeConfirm.TradeDate, tradeConfirm.Quantity, tradeConfirm.Price)
+	}
+	return &datav1.Trade{
+		TradeId:       tradeID,
+		AccountId:     accountAlias,
+		TradeDate:     protoTradeDate,
+		SettleDate:    protoSettleDate,
+		Symbol:        tradeConfirm.Symbol,
+		Description:   tradeConfirm.Description,
+		AssetCategory: tradeConfirm.AssetCategory,
+		Side:          side,
+		Quantity:      quantity,
+		TradePrice:    tradePrice,
+		Proceeds:      proceeds,
+		Commission:    commission,
+		CurrencyCode:  currencyCode,
+		OrderId:       tradeConfirm.OrderID,
+	}, nil
+}
+
 // generateTradeID creates a deterministic trade ID from trade fields.
 // Uses a hash prefix to keep it short while avoiding collisions.
 func generateTradeID(symbol string, dateTime time.Time, quantity string, price string) string {
@@ -316,7 +1405,7 @@ func importedTransactionToTrade(txn *datav1.ImportedTransaction) *datav1.Trade {
 		txn.GetDate().GetYear(), txn.GetDate().GetMonth(), txn.GetDate().GetDay(),
 		txn.GetQuantity(),
 	)
-	return &datav1.Trade{
+	trade := &datav1.Trade{
 		TradeId:       tradeID,
 		AccountId:     txn.GetAccountId(),
 		TradeDate:     txn.GetDate(),
@@ -330,6 +1419,11 @@ func importedTransactionToTrade(txn *datav1.ImportedTransaction) *datav1.Trade {
 		Commission:    moneypb.MoneyFromMicros(currencyCode, 0),
 		CurrencyCode:  currencyCode,
 	}
+	// Record that transactions extracted from statement PDFs need verification.
+	if txn.GetLowConfidence() {
+		trade.Source = statementExtractSource
+	}
+	return trade
 }
 
 // protoDateString returns a sortable date string from a proto Date.
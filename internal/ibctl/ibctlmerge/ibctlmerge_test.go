@@ -0,0 +1,190 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctlmerge
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	timev1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/time/v1"
+	"github.com/bufdev/ibctl/internal/pkg/ibkractivitycsv"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchDuplicateTrades(t *testing.T) {
+	t.Parallel()
+	flexTrades := []*datav1.Trade{
+		// One execution of 10 AAPL.
+		newTestTrade("f1", "", "AAPL", 3, 10, 150_000_000),
+		// Order o2 of 100 MSFT, split into two executions.
+		newTestTrade("f2", "o2", "MSFT", 3, 60, 400_000_000),
+		newTestTrade("f3", "o2", "MSFT", 3, 40, 400_500_000),
+		// Order o3 of 50 MSFT on the same day, which no CSV trade consolidates.
+		newTestTrade("f4", "o3", "MSFT", 3, 50, 401_000_000),
+		// Executions without an order ID, consolidated differently by the CSV.
+		newTestTrade("f5", "", "VTI", 4, 30, 250_000_000),
+		newTestTrade("f6", "", "VTI", 4, 20, 250_000_000),
+	}
+	csvTrades := []*datav1.Trade{
+		newTestTrade("c1", "", "AAPL", 3, 10, 150_000_000),
+		newTestTrade("c2", "", "MSFT", 3, 100, 400_200_000),
+		newTestTrade("c3", "", "VTI", 4, 25, 250_000_000),
+		newTestTrade("c4", "", "VTI", 4, 25, 250_000_000),
+		// Before the Flex Query window.
+		newTestTrade("c5", "", "AAPL", 2, 5, 140_000_000),
+	}
+	unique, matches := matchDuplicateTrades(csvTrades, flexTrades)
+	require.Equal(t, []string{"c5"}, tradeIDs(unique))
+	require.Len(t, matches, 3)
+	require.Equal(t, duplicateMatchExecution, matches[0].Match)
+	require.Equal(t, []string{"c1"}, matches[0].CSVTradeIDs)
+	require.Equal(t, []string{"f1"}, matches[0].FlexTradeIDs)
+	require.Equal(t, duplicateMatchOrder, matches[1].Match)
+	require.Equal(t, []string{"c2"}, matches[1].CSVTradeIDs)
+	require.Equal(t, []string{"f2", "f3"}, matches[1].FlexTradeIDs)
+	require.Equal(t, "100", matches[1].Quantity)
+	require.Equal(t, duplicateMatchDay, matches[2].Match)
+	require.Equal(t, []string{"c3", "c4"}, matches[2].CSVTradeIDs)
+	require.Equal(t, []string{"f5", "f6"}, matches[2].FlexTradeIDs)
+}
+
+func TestMergedDataCacheDuplicateMatches(t *testing.T) {
+	t.Parallel()
+	filePath := filepath.Join(t.TempDir(), "cache", "merged_data.json")
+	_, matches := matchDuplicateTrades(
+		[]*datav1.Trade{
+			newTestTrade("c1", "", "AAPL", 3, 10, 150_000_000),
+			newTestTrade("c2", "", "MSFT", 3, 100, 400_000_000),
+		},
+		[]*datav1.Trade{
+			newTestTrade("f1", "", "AAPL", 3, 10, 150_000_000),
+			newTestTrade("f2", "o2", "MSFT", 3, 60, 400_000_000),
+			newTestTrade("f3", "o2", "MSFT", 3, 40, 400_000_000),
+		},
+	)
+	require.Len(t, matches, 2)
+	require.NoError(t, writeMergedDataCache(filePath, "fingerprint", &MergedData{DuplicateMatches: matches}))
+	cached, ok := readMergedDataCache(filePath, "fingerprint")
+	require.True(t, ok)
+	require.Equal(t, matches, cached.DuplicateMatches)
+	require.Equal(t, duplicateMatchExecution, cached.DuplicateMatches[0].Match)
+	require.Equal(t, duplicateMatchOrder, cached.DuplicateMatches[1].Match)
+	_, ok = readMergedDataCache(filePath, "other")
+	require.False(t, ok)
+}
+
+func TestComputeInputFingerprintFileDigests(t *testing.T) {
+	t.Parallel()
+	dirPath := t.TempDir()
+	dataAccountsDirPath := filepath.Join(dirPath, "data", "accounts")
+	cacheAccountsDirPath := filepath.Join(dirPath, "cache", "accounts")
+	activityStatementsDirPath := filepath.Join(dirPath, "activity_statements")
+	tradesFilePath := filepath.Join(dataAccountsDirPath, "individual", "trades.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(tradesFilePath), 0o755))
+	require.NoError(t, os.WriteFile(tradesFilePath, []byte("{}\n"), 0o600))
+	accountAliases := map[string]string{"individual": "U1234567"}
+	fingerprint, fileDigests, err := computeInputFingerprint(nil, dataAccountsDirPath, cacheAccountsDirPath, activityStatementsDirPath, "", "", "", accountAliases)
+	require.NoError(t, err)
+	require.Len(t, fileDigests, 1)
+	require.Contains(t, fileDigests, tradesFilePath)
+	// Digests round-trip through the digests file.
+	fileDigestsFilePath := fileDigestsFilePathFor(filepath.Join(dirPath, "cache", "merged_data.json"))
+	require.Equal(t, filepath.Join(dirPath, "cache", "merged_data_file_digests.json"), fileDigestsFilePath)
+	require.NoError(t, writeFileDigests(fileDigestsFilePath, fileDigests))
+	require.Equal(t, fileDigests, readFileDigests(fileDigestsFilePath))
+	// A file with an unchanged size and modification time is not read again,
+	// so a recorded digest is used as-is.
+	staleFileDigests := map[string]fileDigest{tradesFilePath: fileDigests[tradesFilePath]}
+	staleFileDigest := staleFileDigests[tradesFilePath]
+	staleFileDigest.SHA256 = "stale"
+	staleFileDigests[tradesFilePath] = staleFileDigest
+	staleFingerprint, staleFileDigestsResult, err := computeInputFingerprint(staleFileDigests, dataAccountsDirPath, cacheAccountsDirPath, activityStatementsDirPath, "", "", "", accountAliases)
+	require.NoError(t, err)
+	require.NotEqual(t, fingerprint, staleFingerprint)
+	require.Equal(t, "stale", staleFileDigestsResult[tradesFilePath].SHA256)
+	// A file with a changed size is read again.
+	require.NoError(t, os.WriteFile(tradesFilePath, []byte("{\"trade_id\":\"1\"}\n"), 0o600))
+	changedFingerprint, changedFileDigests, err := computeInputFingerprint(staleFileDigests, dataAccountsDirPath, cacheAccountsDirPath, activityStatementsDirPath, "", "", "", accountAliases)
+	require.NoError(t, err)
+	require.NotEqual(t, fingerprint, changedFingerprint)
+	require.NotEqual(t, "stale", changedFileDigests[tradesFilePath].SHA256)
+	require.NotEqual(t, fileDigests[tradesFilePath].SHA256, changedFileDigests[tradesFilePath].SHA256)
+}
+
+func newTestTrade(tradeID string, orderID string, symbol string, day uint32, quantity int64, priceMicros int64) *datav1.Trade {
+	return &datav1.Trade{
+		TradeId:      tradeID,
+		OrderId:      orderID,
+		AccountId:    "individual",
+		TradeDate:    &timev1.Date{Year: 2025, Month: 3, Day: day},
+		Symbol:       symbol,
+		Side:         datav1.TradeSide_TRADE_SIDE_BUY,
+		Quantity:     mathpb.FromMicros(quantity * 1_000_000),
+		TradePrice:   moneypb.MoneyFromMicros("USD", priceMicros),
+		CurrencyCode: "USD",
+	}
+}
+
+func tradeIDs(trades []*datav1.Trade) []string {
+	ids := make([]string, 0, len(trades))
+	for _, trade := range trades {
+		ids = append(ids, trade.GetTradeId())
+	}
+	return ids
+}
+
+func TestApplyTradeCodes(t *testing.T) {
+	t.Parallel()
+	cancelTime := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	correctTime := time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)
+	keepTime := time.Date(2024, 3, 3, 10, 0, 0, 0, time.UTC)
+	statements := []*ibkractivitycsv.ActivityStatement{
+		{
+			Trades: []ibkractivitycsv.Trade{
+				// The original of the cancelled trade, listed in an older statement.
+				{Symbol: "AAPL", DateTime: cancelTime, Quantity: "100", Code: "O"},
+				{Symbol: "MSFT", DateTime: correctTime, Quantity: "50", Code: "O"},
+				{Symbol: "VTI", DateTime: keepTime, Quantity: "10", Code: "O;P"},
+			},
+		},
+		{
+			Trades: []ibkractivitycsv.Trade{
+				{Symbol: "AAPL", DateTime: cancelTime, Quantity: "-100", Code: "Ca"},
+				{Symbol: "MSFT", DateTime: correctTime, Quantity: "50", Code: "Ca;O"},
+				{Symbol: "MSFT", DateTime: correctTime, Quantity: "60", Code: "Co;O"},
+				// A trade of another symbol at the same time is unaffected.
+				{Symbol: "VTI", DateTime: cancelTime, Quantity: "100", Code: "O"},
+			},
+		},
+	}
+	trades := applyTradeCodes(statements)
+	var summaries []string
+	for _, trade := range trades {
+		summaries = append(summaries, trade.Symbol+" "+trade.Quantity+" "+trade.Code)
+	}
+	require.Equal(t, []string{"VTI 10 O;P", "MSFT 60 Co;O", "VTI 100 O"}, summaries)
+}
+
+func TestMergeOptionsFilter(t *testing.T) {
+	t.Parallel()
+	mergedData := &MergedData{
+		Trades:           []*datav1.Trade{{TradeId: "t1"}},
+		Positions:        []*datav1.Position{{Symbol: "AAPL"}},
+		CashTransactions: []*datav1.CashTransaction{{TransactionId: "c1"}},
+		DuplicateMatches: []*DuplicateMatch{{Match: duplicateMatchExecution}},
+	}
+	// No options loads all data.
+	require.Equal(t, mergedData, newMergeOptions().filter(mergedData))
+	filtered := newMergeOptions(MergeWithTrades(), MergeWithCashTransactions()).filter(mergedData)
+	require.Equal(t, mergedData.Trades, filtered.Trades)
+	require.Equal(t, mergedData.DuplicateMatches, filtered.DuplicateMatches)
+	require.Equal(t, mergedData.CashTransactions, filtered.CashTransactions)
+	require.Nil(t, filtered.Positions)
+}
@@ -0,0 +1,189 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctlmigrate versions the on-disk data format and migrates data
+// written by older versions of ibctl.
+//
+// The data format version is stored in the data/version marker file. Data
+// written before the marker existed is treated as version 1. When the meaning
+// of on-disk fields changes, CurrentVersion is bumped and a migration from the
+// previous version is added to the registry, so that old data is upgraded
+// explicitly instead of being silently misread.
+package ibctlmigrate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlbackup"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/pkg/filemode"
+)
+
+// CurrentVersion is the data format version written by this version of ibctl.
+const CurrentVersion = 1
+
+// ErrStaleCache is returned by Check when the data in the ibctl directory was
+// written by an older version of ibctl and must be migrated before it is read.
+var ErrStaleCache = errors.New("data is stale")
+
+// Migration upgrades data from FromVersion to FromVersion+1.
+type Migration struct {
+	// FromVersion is the data format version this migration upgrades from.
+	FromVersion int
+	// Description is a short human-readable description of the change.
+	Description string
+	// Migrate rewrites the data in the ibctl directory.
+	Migrate func(dirPath string) error
+}
+
+// ReadVersion returns the data format version of the ibctl directory.
+//
+// Returns 0 if there is no data yet. Data without a version marker is version 1.
+func ReadVersion(dirPath string) (int, error) {
+	data, err := os.ReadFile(ibctlpath.DataVersionFilePath(dirPath))
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return 0, err
+		}
+		// Data written before the version marker existed is version 1.
+		if _, err := os.Stat(ibctlpath.DataAccountsDirPath(dirPath)); err == nil {
+			return 1, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return 0, err
+		}
+		return 0, nil
+	}
+	version, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid data version marker %s: %q", ibctlpath.DataVersionFilePath(dirPath), strings.TrimSpace(string(data)))
+	}
+	return version, nil
+}
+
+// WriteVersion writes the current data format version marker.
+func WriteVersion(dirPath string) error {
+	return writeVersion(dirPath, CurrentVersion)
+}
+
+// Check returns an error if the data in the ibctl directory cannot be read by
+// this version of ibctl, either because it needs to be migrated or because it
+// was written by a newer version.
+func Check(dirPath string) error {
+	return check(dirPath, CurrentVersion)
+}
+
+// Pending returns the migrations needed to upgrade the ibctl directory to the
+// current version, in order.
+func Pending(dirPath string) ([]Migration, error) {
+	return pending(dirPath, migrations, CurrentVersion)
+}
+
+// Migrate upgrades the data in the ibctl directory to the current version.
+//
+// The data is backed up before the first migration is applied, and the version
+// marker is updated after each migration so an interrupted upgrade resumes
+// where it left off. Returns the applied migrations.
+func Migrate(dirPath string) ([]Migration, error) {
+	return migrate(dirPath, migrations, CurrentVersion)
+}
+
+// *** PRIVATE ***
+
+// migrations is the registry of all migrations, ordered by FromVersion.
+// Add a migration here when bumping CurrentVersion.
+var migrations = []Migration{}
+
+func check(dirPath string, currentVersion int) error {
+	version, err := ReadVersion(dirPath)
+	if err != nil {
+		return err
+	}
+	switch {
+	case version > currentVersion:
+		return fmt.Errorf("data in %s is at version %d, but this version of ibctl only supports up to version %d, upgrade ibctl", dirPath, version, currentVersion)
+	case version != 0 && version < currentVersion:
+		return fmt.Errorf("%w: data in %s is at version %d, but this version of ibctl requires version %d, run \"ibctl data migrate\"", ErrStaleCache, dirPath, version, currentVersion)
+	}
+	return nil
+}
+
+func pending(dirPath string, registry []Migration, currentVersion int) ([]Migration, error) {
+	version, err := ReadVersion(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	if version == 0 {
+		return nil, nil
+	}
+	if version > currentVersion {
+		return nil, fmt.Errorf("data in %s is at version %d, but this version of ibctl only supports up to version %d, upgrade ibctl", dirPath, version, currentVersion)
+	}
+	var result []Migration
+	for v := version; v < currentVersion; v++ {
+		migration, ok := findMigration(registry, v)
+		if !ok {
+			return nil, fmt.Errorf("no migration registered from data version %d", v)
+		}
+		result = append(result, migration)
+	}
+	return result, nil
+}
+
+func migrate(dirPath string, registry []Migration, currentVersion int) ([]Migration, error) {
+	pendingMigrations, err := pending(dirPath, registry, currentVersion)
+	if err != nil {
+		return nil, err
+	}
+	if len(pendingMigrations) == 0 {
+		return nil, nil
+	}
+	// Back up the data so a failed migration can be rolled back with "ibctl data restore".
+	if _, err := ibctlbackup.Backup(
+		ibctlpath.DataAccountsDirPath(dirPath),
+		ibctlpath.DataBackupsDirPath(dirPath),
+		ibctlbackup.DefaultRetention,
+	); err != nil {
+		return nil, fmt.Errorf("backing up data: %w", err)
+	}
+	var applied []Migration
+	for _, migration := range pendingMigrations {
+		if err := migration.Migrate(dirPath); err != nil {
+			return applied, fmt.Errorf("migrating data from version %d to %d: %w", migration.FromVersion, migration.FromVersion+1, err)
+		}
+		if err := writeVersion(dirPath, migration.FromVersion+1); err != nil {
+			return applied, err
+		}
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+// findMigration returns the migration from the version.
+func findMigration(registry []Migration, fromVersion int) (Migration, bool) {
+	for _, migration := range registry {
+		if migration.FromVersion == fromVersion {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+// writeVersion atomically writes the version marker.
+func writeVersion(dirPath string, version int) error {
+	filePath := ibctlpath.DataVersionFilePath(dirPath)
+	if err := filemode.MkdirAll(filepath.Dir(filePath)); err != nil {
+		return err
+	}
+	tempFilePath := filePath + ".tmp"
+	if err := filemode.WriteFile(tempFilePath, []byte(strconv.Itoa(version)+"\n")); err != nil {
+		return err
+	}
+	return os.Rename(tempFilePath, filePath)
+}
@@ -0,0 +1,77 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctlmigrate
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	t.Parallel()
+	dirPath := t.TempDir()
+	// No data yet: nothing to check or migrate.
+	version, err := ReadVersion(dirPath)
+	require.NoError(t, err)
+	require.Equal(t, 0, version)
+	require.NoError(t, check(dirPath, 3))
+	// Data without a marker is version 1.
+	require.NoError(t, os.MkdirAll(ibctlpath.DataAccountDirPath(dirPath, "a"), 0o755))
+	version, err = ReadVersion(dirPath)
+	require.NoError(t, err)
+	require.Equal(t, 1, version)
+	require.ErrorContains(t, check(dirPath, 3), "ibctl data migrate")
+	require.ErrorIs(t, check(dirPath, 3), ErrStaleCache)
+	var applied []int
+	registry := []Migration{
+		{FromVersion: 2, Migrate: func(string) error { applied = append(applied, 2); return nil }},
+		{FromVersion: 1, Migrate: func(string) error { applied = append(applied, 1); return nil }},
+	}
+	migrations, err := migrate(dirPath, registry, 3)
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	require.Equal(t, []int{1, 2}, applied)
+	version, err = ReadVersion(dirPath)
+	require.NoError(t, err)
+	require.Equal(t, 3, version)
+	require.NoError(t, check(dirPath, 3))
+	// Data from a newer version is refused.
+	require.ErrorContains(t, check(dirPath, 2), "upgrade ibctl")
+	// The data was backed up before migrating.
+	entries, err := os.ReadDir(ibctlpath.DataBackupsDirPath(dirPath))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+func TestMigrateFailureResumes(t *testing.T) {
+	t.Parallel()
+	dirPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(ibctlpath.DataAccountsDirPath(dirPath), "a"), 0o755))
+	failing := true
+	registry := []Migration{
+		{FromVersion: 1, Migrate: func(string) error { return nil }},
+		{FromVersion: 2, Migrate: func(string) error {
+			if failing {
+				return errors.New("boom")
+			}
+			return nil
+		}},
+	}
+	_, err := migrate(dirPath, registry, 3)
+	require.Error(t, err)
+	// The first migration is recorded, so only the second is pending.
+	version, err := ReadVersion(dirPath)
+	require.NoError(t, err)
+	require.Equal(t, 2, version)
+	failing = false
+	migrations, err := migrate(dirPath, registry, 3)
+	require.NoError(t, err)
+	require.Len(t, migrations, 1)
+}
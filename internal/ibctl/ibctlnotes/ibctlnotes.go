@@ -0,0 +1,187 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctlnotes reads user-maintained notes and tags for trades, lots,
+// and symbols.
+//
+// Notes are stored per account in data/notes/<alias>/notes.yaml, which ibctl
+// reads but never writes:
+//
+//	symbols:
+//	  NVDA:
+//	    tags: [thesis:ai]
+//	    note: Datacenter capex cycle
+//	trades:
+//	  "1234567890":
+//	    tags: [gift]
+//	lots:
+//	  individual/NVDA/2024-01-05/1:
+//	    tags: [esop]
+//	    note: RSU vest
+//
+// Trades are keyed by trade ID and lots by lot ID. A trade or lot has the tags
+// of its symbol in the account plus its own, so tagging a symbol tags all of
+// its trades and lots.
+package ibctlnotes
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/bufdev/ibctl/internal/pkg/protoio"
+	"gopkg.in/yaml.v3"
+)
+
+// NotesFileName is the name of the notes file within each account directory.
+const NotesFileName = "notes.yaml"
+
+// Annotation is the tags and free-text note of a trade, lot, or symbol.
+type Annotation struct {
+	// Tags is the sorted, deduplicated list of tags (e.g., "thesis:ai").
+	Tags []string
+	// Note is the free-text note, or empty.
+	Note string
+}
+
+// Notes holds the annotations of all accounts. The zero value has no annotations.
+type Notes struct {
+	accountToFile map[string]*externalNotesFile
+}
+
+// ReadNotes reads the notes of each account under dataNotesDirPath, where
+// accountAliases maps account aliases to IBKR account IDs. Accounts without a
+// notes file have no annotations.
+func ReadNotes(dataNotesDirPath string, accountAliases map[string]string) (*Notes, error) {
+	accountToFile := make(map[string]*externalNotesFile)
+	for alias := range accountAliases {
+		filePath := filepath.Join(dataNotesDirPath, alias, NotesFileName)
+		data, err := protoio.ReadFile(filePath)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		var notesFile externalNotesFile
+		if len(data) > 0 {
+			yamlDecoder := yaml.NewDecoder(bytes.NewReader(data))
+			// Reject unknown fields, which are likely typos.
+			yamlDecoder.KnownFields(true)
+			if err := yamlDecoder.Decode(&notesFile); err != nil {
+				return nil, fmt.Errorf("reading %s: %w", filePath, err)
+			}
+		}
+		if err := notesFile.validate(); err != nil {
+			return nil, fmt.Errorf("reading %s: %w", filePath, err)
+		}
+		accountToFile[alias] = &notesFile
+	}
+	return &Notes{accountToFile: accountToFile}, nil
+}
+
+// TradeAnnotation returns the annotation of the trade in the account,
+// combining the annotation of its symbol with its own.
+func (n *Notes) TradeAnnotation(accountAlias string, symbol string, tradeID string) Annotation {
+	notesFile := n.accountToFile[accountAlias]
+	if notesFile == nil {
+		return Annotation{}
+	}
+	return newAnnotation(notesFile.Symbols[symbol], notesFile.Trades[tradeID])
+}
+
+// LotAnnotation returns the annotation of the lot in the account, combining
+// the annotation of its symbol with its own.
+func (n *Notes) LotAnnotation(accountAlias string, symbol string, lotID string) Annotation {
+	notesFile := n.accountToFile[accountAlias]
+	if notesFile == nil {
+		return Annotation{}
+	}
+	return newAnnotation(notesFile.Symbols[symbol], notesFile.Lots[lotID])
+}
+
+// FilterTrades returns the trades with a tag matching tag. See MatchesTag.
+func (n *Notes) FilterTrades(trades []*datav1.Trade, tag string) []*datav1.Trade {
+	var filtered []*datav1.Trade
+	for _, trade := range trades {
+		annotation := n.TradeAnnotation(trade.GetAccountId(), trade.GetSymbol(), trade.GetTradeId())
+		if MatchesTag(annotation.Tags, tag) {
+			filtered = append(filtered, trade)
+		}
+	}
+	return filtered
+}
+
+// MatchesTag returns whether any of tags is tag, or has tag as its prefix
+// before a colon, so "thesis" matches "thesis:ai".
+func MatchesTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag || strings.HasPrefix(t, tag+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// *** PRIVATE ***
+
+// externalNotesFile is the notes.yaml file of an account.
+type externalNotesFile struct {
+	// Symbols maps symbols to their annotation.
+	Symbols map[string]externalAnnotation `yaml:"symbols"`
+	// Trades maps trade IDs to their annotation.
+	Trades map[string]externalAnnotation `yaml:"trades"`
+	// Lots maps lot IDs to their annotation.
+	Lots map[string]externalAnnotation `yaml:"lots"`
+}
+
+// externalAnnotation is an annotation in notes.yaml.
+type externalAnnotation struct {
+	// Tags is the list of tags.
+	Tags []string `yaml:"tags"`
+	// Note is the free-text note.
+	Note string `yaml:"note"`
+}
+
+func (f *externalNotesFile) validate() error {
+	for kind, keyToAnnotation := range map[string]map[string]externalAnnotation{
+		"symbol": f.Symbols,
+		"trade":  f.Trades,
+		"lot":    f.Lots,
+	} {
+		for key, annotation := range keyToAnnotation {
+			for _, tag := range annotation.Tags {
+				// Tags are displayed comma-separated, so they cannot contain commas or spaces.
+				if tag == "" || strings.ContainsFunc(tag, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+					return fmt.Errorf("invalid tag %q for %s %q, must be non-empty without commas or spaces", tag, kind, key)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// newAnnotation combines the annotations, with tags merged and notes joined in order.
+func newAnnotation(externalAnnotations ...externalAnnotation) Annotation {
+	var tags []string
+	var notes []string
+	for _, externalAnnotation := range externalAnnotations {
+		tags = append(tags, externalAnnotation.Tags...)
+		if externalAnnotation.Note != "" {
+			notes = append(notes, externalAnnotation.Note)
+		}
+	}
+	sort.Strings(tags)
+	return Annotation{
+		Tags: slices.Compact(tags),
+		Note: strings.Join(notes, "; "),
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctlnotes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadNotes(t *testing.T) {
+	t.Parallel()
+	dirPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dirPath, "individual"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dirPath, "individual", NotesFileName), []byte(`symbols:
+  NVDA:
+    tags: [thesis:ai]
+    note: Datacenter capex cycle
+trades:
+  1234567890:
+    tags: [gift, thesis:ai]
+lots:
+  individual/NVDA/2024-01-05/1:
+    tags: [esop]
+    note: RSU vest
+`), 0o600))
+	notes, err := ReadNotes(dirPath, map[string]string{"individual": "U1234567", "rrsp": "U7654321"})
+	require.NoError(t, err)
+	require.Equal(t, Annotation{Tags: []string{"gift", "thesis:ai"}, Note: "Datacenter capex cycle"}, notes.TradeAnnotation("individual", "NVDA", "1234567890"))
+	require.Equal(t, Annotation{Tags: []string{"gift", "thesis:ai"}}, notes.TradeAnnotation("individual", "AAPL", "1234567890"))
+	require.Equal(t, Annotation{Tags: []string{"esop", "thesis:ai"}, Note: "Datacenter capex cycle; RSU vest"}, notes.LotAnnotation("individual", "NVDA", "individual/NVDA/2024-01-05/1"))
+	// Symbol notes are per account.
+	require.Equal(t, Annotation{}, notes.LotAnnotation("rrsp", "NVDA", "rrsp/NVDA/2024-01-05/1"))
+	trades := []*datav1.Trade{
+		{AccountId: "individual", Symbol: "NVDA", TradeId: "1"},
+		{AccountId: "individual", Symbol: "AAPL", TradeId: "2"},
+		{AccountId: "rrsp", Symbol: "NVDA", TradeId: "3"},
+	}
+	require.Equal(t, trades[:1], notes.FilterTrades(trades, "thesis"))
+	require.Empty(t, notes.FilterTrades(trades, "thesis:a"))
+	require.NoError(t, os.WriteFile(filepath.Join(dirPath, "individual", NotesFileName), []byte("symbols:\n  NVDA:\n    tags: [\"thesis ai\"]\n"), 0o600))
+	_, err = ReadNotes(dirPath, map[string]string{"individual": "U1234567"})
+	require.ErrorContains(t, err, "invalid tag")
+}
@@ -8,15 +8,33 @@
 //
 // The base directory (--dir flag) contains:
 //
-//	ibctl.yaml                        Config file
-//	data/accounts/<alias>/            Persistent trade data
-//	cache/accounts/<alias>/           Blow-away-safe snapshots
-//	cache/fx/<BASE>.<QUOTE>/          FX rate data
-//	activity_statements/<alias>/      User-managed Activity Statement CSVs
-//	seed/<alias>/                     Optional pre-transfer tax lots
+//	ibctl.yaml                          Config file
+//	ibctl.lock                          Lock held by commands that modify data/ or cache/
+//	data/version                        Data format version marker
+//	data/accounts/<alias>/              Persistent trade data
+//	data/accounts/<alias>/snapshots/    Persistent dated position snapshots
+//	data/manual/<alias>/                Manually entered trades
+//	data/notes/<alias>/                 User-maintained trade and symbol notes
+//	data/backups/<generation>/          Rolling backups of data/accounts/
+//	data/quarantine/<alias>/            Flex Query records skipped during download
+//	data/prices/overrides.yaml          Manually pinned prices for symbols without a market price
+//	cache/accounts/<alias>/             Blow-away-safe snapshots
+//	cache/fx/<BASE>.<QUOTE>/            FX rate data
+//	cache/activity_statements/<alias>/  Parsed Activity Statement CSVs
+//	cache/merged_data.json              Merged data keyed on an input fingerprint
+//	cache/debug/                        HTTP trace files written with --debug-http
+//	activity_statements/<alias>/        User-managed Activity Statement CSVs
+//	trade_confirmations/<alias>/        User-managed Trade Confirmation Flex reports
+//	seed/<alias>/                       Optional pre-transfer tax lots
+//
+// The user-level workspaces file, which maps workspace names to base
+// directories, is ~/.config/ibctl/workspaces.yaml.
 package ibctlpath
 
-import "path/filepath"
+import (
+	"os"
+	"path/filepath"
+)
 
 // ConfigFileName is the well-known config file name within the base directory.
 const ConfigFileName = "ibctl.yaml"
@@ -26,6 +44,32 @@ func ConfigFilePath(dirPath string) string {
 	return filepath.Join(dirPath, ConfigFileName)
 }
 
+// WorkspacesFileName is the well-known file name of the user-level workspaces file.
+const WorkspacesFileName = "workspaces.yaml"
+
+// WorkspacesFilePath returns the path to the user-level workspaces file:
+// $XDG_CONFIG_HOME/ibctl/workspaces.yaml, or ~/.config/ibctl/workspaces.yaml
+// if XDG_CONFIG_HOME is not set.
+func WorkspacesFilePath(homeDirPath string) string {
+	if configDirPath := os.Getenv("XDG_CONFIG_HOME"); configDirPath != "" {
+		return filepath.Join(configDirPath, "ibctl", WorkspacesFileName)
+	}
+	return filepath.Join(homeDirPath, ".config", "ibctl", WorkspacesFileName)
+}
+
+// LockFileName is the well-known lock file name within the base directory.
+const LockFileName = "ibctl.lock"
+
+// LockFilePath returns the path to the lock file within the base directory.
+func LockFilePath(dirPath string) string {
+	return filepath.Join(dirPath, LockFileName)
+}
+
+// DataVersionFilePath returns the path to the data format version marker file.
+func DataVersionFilePath(dirPath string) string {
+	return filepath.Join(dirPath, "data", "version")
+}
+
 // DataAccountsDirPath returns the directory for persistent per-account trade data.
 func DataAccountsDirPath(dirPath string) string {
 	return filepath.Join(dirPath, "data", "accounts")
@@ -36,6 +80,39 @@ func DataAccountDirPath(dirPath string, alias string) string {
 	return filepath.Join(dirPath, "data", "accounts", alias)
 }
 
+// DataAccountSnapshotsDirPath returns the directory for a specific account's dated position snapshots.
+// Each snapshot is stored as <YYYY-MM-DD>/positions.json.
+func DataAccountSnapshotsDirPath(dirPath string, alias string) string {
+	return filepath.Join(dirPath, "data", "accounts", alias, "snapshots")
+}
+
+// DataManualDirPath returns the directory for manually entered per-account trades.
+func DataManualDirPath(dirPath string) string {
+	return filepath.Join(dirPath, "data", "manual")
+}
+
+// DataNotesDirPath returns the directory for user-maintained per-account notes and tags.
+func DataNotesDirPath(dirPath string) string {
+	return filepath.Join(dirPath, "data", "notes")
+}
+
+// DataBackupsDirPath returns the directory for rolling backups of persistent account data.
+func DataBackupsDirPath(dirPath string) string {
+	return filepath.Join(dirPath, "data", "backups")
+}
+
+// DataQuarantineAccountDirPath returns the directory for a specific account's
+// Flex Query records that were skipped because they could not be converted.
+func DataQuarantineAccountDirPath(dirPath string, alias string) string {
+	return filepath.Join(dirPath, "data", "quarantine", alias)
+}
+
+// DataPriceOverridesFilePath returns the path to the user-maintained file of
+// manually pinned prices.
+func DataPriceOverridesFilePath(dirPath string) string {
+	return filepath.Join(dirPath, "data", "prices", "overrides.yaml")
+}
+
 // CacheAccountsDirPath returns the directory for cached per-account snapshot data.
 func CacheAccountsDirPath(dirPath string) string {
 	return filepath.Join(dirPath, "cache", "accounts")
@@ -46,16 +123,63 @@ func CacheAccountDirPath(dirPath string, alias string) string {
 	return filepath.Join(dirPath, "cache", "accounts", alias)
 }
 
+// CacheAccountMetadataFilePath returns the path to a specific account's
+// download metadata, which records when its position snapshot was downloaded.
+func CacheAccountMetadataFilePath(dirPath string, alias string) string {
+	return filepath.Join(dirPath, "cache", "accounts", alias, "metadata.json")
+}
+
+// CacheAccountDividendAccrualsFilePath returns the path to a specific account's
+// open dividend accruals, the dividends declared but not yet paid.
+func CacheAccountDividendAccrualsFilePath(dirPath string, alias string) string {
+	return filepath.Join(dirPath, "cache", "accounts", alias, "dividend_accruals.json")
+}
+
 // CacheFXDirPath returns the directory for cached FX rate data.
 func CacheFXDirPath(dirPath string) string {
 	return filepath.Join(dirPath, "cache", "fx")
 }
 
+// CachePricesDirPath returns the directory for cached daily closing prices.
+func CachePricesDirPath(dirPath string) string {
+	return filepath.Join(dirPath, "cache", "prices")
+}
+
+// CacheActivityStatementsDirPath returns the directory for cached parsed Activity Statement CSVs.
+func CacheActivityStatementsDirPath(dirPath string) string {
+	return filepath.Join(dirPath, "cache", "activity_statements")
+}
+
+// CacheRawAccountDirPath returns the directory for a specific account's archived raw Flex Query XML.
+func CacheRawAccountDirPath(dirPath string, alias string) string {
+	return filepath.Join(dirPath, "cache", "raw", alias)
+}
+
+// CacheMergedDataFilePath returns the path to the cached merged data file.
+func CacheMergedDataFilePath(dirPath string) string {
+	return filepath.Join(dirPath, "cache", "merged_data.json")
+}
+
+// CacheTaxLotsDirPath returns the directory for cached tax lot results.
+func CacheTaxLotsDirPath(dirPath string) string {
+	return filepath.Join(dirPath, "cache", "tax_lots")
+}
+
+// CacheDebugDirPath returns the directory for HTTP trace files.
+func CacheDebugDirPath(dirPath string) string {
+	return filepath.Join(dirPath, "cache", "debug")
+}
+
 // ActivityStatementsDirPath returns the directory for Activity Statement CSVs.
 func ActivityStatementsDirPath(dirPath string) string {
 	return filepath.Join(dirPath, "activity_statements")
 }
 
+// TradeConfirmationsDirPath returns the directory for Trade Confirmation Flex reports.
+func TradeConfirmationsDirPath(dirPath string) string {
+	return filepath.Join(dirPath, "trade_confirmations")
+}
+
 // SeedDirPath returns the directory for permanent seed data from previous brokers.
 func SeedDirPath(dirPath string) string {
 	return filepath.Join(dirPath, "seed")
@@ -0,0 +1,150 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctlportfolioperformance exports merged IBKR activity as a
+// Portfolio Performance (https://www.portfolio-performance.info) CSV file.
+//
+// The CSV uses the column names of Portfolio Performance's "Account
+// Transactions" CSV import, which books buys and sells against both the cash
+// account and the securities account chosen in the import wizard.
+package ibctlportfolioperformance
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+)
+
+// assetCategoryCash is the IBKR asset category for FX conversions, which
+// Portfolio Performance cannot import as account transactions.
+const assetCategoryCash = "CASH"
+
+// Headers returns the CSV column headers.
+func Headers() []string {
+	return []string{"Date", "Type", "Value", "Transaction Currency", "Fees", "Shares", "Ticker Symbol", "Security Name", "Note"}
+}
+
+// Write writes the trades and cash transactions in mergedData as CSV.
+//
+// Values are unsigned, with the direction given by the type. FX conversions
+// and position transfers are not included. The note of each row has the
+// account alias and IBKR ID, for filtering and deduplication.
+func Write(writer io.Writer, mergedData *ibctlmerge.MergedData) error {
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.Write(Headers()); err != nil {
+		return err
+	}
+	for _, trade := range mergedData.Trades {
+		if trade.GetAssetCategory() == assetCategoryCash {
+			continue
+		}
+		quantityMicros := mathpb.ToMicros(trade.GetQuantity())
+		transactionType := "Buy"
+		if quantityMicros < 0 {
+			transactionType = "Sell"
+		}
+		// The value is the cash amount of the trade, including fees.
+		valueMicros := moneypb.MoneyToMicros(trade.GetProceeds()) + moneypb.MoneyToMicros(trade.GetCommission())
+		if err := csvWriter.Write([]string{
+			protoDateString(trade.GetTradeDate()),
+			transactionType,
+			formatMicros(abs(valueMicros)),
+			trade.GetCurrencyCode(),
+			formatMicros(abs(moneypb.MoneyToMicros(trade.GetCommission()))),
+			formatMicros(abs(quantityMicros)),
+			trade.GetSymbol(),
+			trade.GetDescription(),
+			fmt.Sprintf("%s trade %s", trade.GetAccountId(), trade.GetTradeId()),
+		}); err != nil {
+			return err
+		}
+	}
+	for _, cashTransaction := range mergedData.CashTransactions {
+		amountMicros := moneypb.MoneyToMicros(cashTransaction.GetAmount())
+		transactionType := cashTransactionType(cashTransaction.GetType(), amountMicros)
+		if transactionType == "" {
+			continue
+		}
+		note := cashTransaction.GetAccountId()
+		if cashTransaction.GetTransactionId() != "" {
+			note += " transaction " + cashTransaction.GetTransactionId()
+		}
+		if cashTransaction.GetDescription() != "" {
+			note += ": " + cashTransaction.GetDescription()
+		}
+		if err := csvWriter.Write([]string{
+			protoDateString(cashTransaction.GetDate()),
+			transactionType,
+			formatMicros(abs(amountMicros)),
+			cashTransaction.GetCurrencyCode(),
+			"",
+			"",
+			cashTransaction.GetSymbol(),
+			"",
+			note,
+		}); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// *** PRIVATE ***
+
+// cashTransactionType returns the Portfolio Performance transaction type for
+// a cash transaction, or an empty string if it has none.
+func cashTransactionType(cashTransactionType datav1.CashTransactionType, amountMicros int64) string {
+	positive := amountMicros >= 0
+	switch cashTransactionType {
+	case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_DIVIDEND,
+		datav1.CashTransactionType_CASH_TRANSACTION_TYPE_PAYMENT_IN_LIEU:
+		// Dividend reversals have no type of their own and are booked as taxes.
+		return choose(positive, "Dividend", "Taxes")
+	case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_WITHHOLDING_TAX:
+		return choose(positive, "Tax Refund", "Taxes")
+	case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_INTEREST_RECEIVED,
+		datav1.CashTransactionType_CASH_TRANSACTION_TYPE_INTEREST_PAID:
+		return choose(positive, "Interest", "Interest Charge")
+	case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_DEPOSIT_WITHDRAWAL:
+		return choose(positive, "Deposit", "Removal")
+	case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_FEE,
+		datav1.CashTransactionType_CASH_TRANSACTION_TYPE_COMMISSION_ADJUSTMENT:
+		return choose(positive, "Fees Refund", "Fees")
+	default:
+		return ""
+	}
+}
+
+func choose(condition bool, ifTrue string, ifFalse string) string {
+	if condition {
+		return ifTrue
+	}
+	return ifFalse
+}
+
+func formatMicros(micros int64) string {
+	return mathpb.ToString(mathpb.FromMicros(micros))
+}
+
+func abs(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// protoDateString returns a YYYY-MM-DD string from a proto Date.
+func protoDateString(d interface {
+	GetYear() uint32
+	GetMonth() uint32
+	GetDay() uint32
+}) string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.GetYear(), d.GetMonth(), d.GetDay())
+}
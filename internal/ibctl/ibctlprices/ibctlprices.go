@@ -0,0 +1,230 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctlprices fetches the prices of traded symbols from Yahoo Finance.
+//
+// Daily closing prices are cached in cache/prices/ by pricestore, for valuing
+// holdings on past dates, and the latest quotes revalue current positions
+// between downloads. Only stocks, ETFs, and funds are priced, since Yahoo
+// Finance does not know options, futures, or bonds by their IBKR symbols.
+// Symbols are mapped to Yahoo Finance symbols with ibctlconfig.Config.QuoteSymbol,
+// and prices in a currency other than the symbol's trading currency are
+// rejected, since they are of a different listing.
+package ibctlprices
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctltaxlot"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/bufdev/ibctl/internal/pkg/pricestore"
+	"github.com/bufdev/ibctl/internal/pkg/timepb"
+	"github.com/bufdev/ibctl/internal/pkg/yahoofinance"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+	"google.golang.org/protobuf/proto"
+)
+
+// pricedAssetCategories are the IBKR asset categories that are priced.
+var pricedAssetCategories = map[string]struct{}{
+	"STK":  {},
+	"FUND": {},
+}
+
+// IsPriced returns true if prices are fetched for the asset category.
+func IsPriced(assetCategory string) bool {
+	_, ok := pricedAssetCategories[assetCategory]
+	return ok
+}
+
+// NewStore returns the price cache of the config's directory. If client is
+// non-nil, prices missing from the cache are fetched with it, and must be in
+// the trading currency of the symbol in the trades. If client is nil, the
+// store only reads cached prices.
+func NewStore(config *ibctlconfig.Config, client yahoofinance.Client, trades []*datav1.Trade) *pricestore.Store {
+	var provider pricestore.Provider
+	if client != nil {
+		provider = &yahooFinanceProvider{
+			client:           client,
+			config:           config,
+			symbolToCurrency: symbolToCurrency(trades),
+		}
+	}
+	return pricestore.NewStore(ibctlpath.CachePricesDirPath(config.DirPath), provider)
+}
+
+// SymbolRange is the date range to cache the prices of a symbol for.
+type SymbolRange struct {
+	// Symbol is the IBKR ticker symbol.
+	Symbol string
+	// Start is the first trade date of the symbol.
+	Start xtime.Date
+	// End is today if the symbol is still held, or its last trade date otherwise.
+	End xtime.Date
+}
+
+// SymbolRanges returns the date range to cache prices for of each priced
+// symbol in the trades, sorted by symbol, covering every date the symbol was
+// held. Symbols ignored in the config are skipped.
+func SymbolRanges(trades []*datav1.Trade, config *ibctlconfig.Config, today xtime.Date) ([]*SymbolRange, error) {
+	symbolToRange := make(map[string]*SymbolRange)
+	var pricedTrades []*datav1.Trade
+	for _, trade := range trades {
+		symbol := trade.GetSymbol()
+		if !IsPriced(trade.GetAssetCategory()) {
+			continue
+		}
+		if _, ok := config.IgnoreSymbols[symbol]; ok {
+			continue
+		}
+		tradeDate, err := timepb.ProtoToDate(trade.GetTradeDate())
+		if err != nil {
+			return nil, fmt.Errorf("trade %s: %w", trade.GetTradeId(), err)
+		}
+		pricedTrades = append(pricedTrades, trade)
+		symbolRange, ok := symbolToRange[symbol]
+		if !ok {
+			symbolToRange[symbol] = &SymbolRange{Symbol: symbol, Start: tradeDate, End: tradeDate}
+			continue
+		}
+		if tradeDate.Before(symbolRange.Start) {
+			symbolRange.Start = tradeDate
+		}
+		if tradeDate.After(symbolRange.End) {
+			symbolRange.End = tradeDate
+		}
+	}
+	// Symbols with open lots are still held, so their prices are needed through today.
+	taxLotResult, err := ibctltaxlot.ComputeTaxLotsCached(config.CacheTaxLotsDirPath(), pricedTrades, config.AverageCostSymbols())
+	if err != nil {
+		return nil, err
+	}
+	for _, taxLot := range taxLotResult.TaxLots {
+		if symbolRange, ok := symbolToRange[taxLot.GetSymbol()]; ok && symbolRange.End.Before(today) {
+			symbolRange.End = today
+		}
+	}
+	symbolRanges := make([]*SymbolRange, 0, len(symbolToRange))
+	for _, symbolRange := range symbolToRange {
+		symbolRanges = append(symbolRanges, symbolRange)
+	}
+	sort.Slice(symbolRanges, func(i, j int) bool {
+		return symbolRanges[i].Symbol < symbolRanges[j].Symbol
+	})
+	return symbolRanges, nil
+}
+
+// ApplyQuotes returns the positions with the market price and value of each
+// priced position replaced by its latest quote from client. Positions of
+// symbols ignored in the config are not quoted. Positions whose quote cannot
+// be fetched keep their price, and the errors fetching them are returned
+// joined along with the positions. Other errors are returned without
+// positions.
+func ApplyQuotes(ctx context.Context, client yahoofinance.Client, config *ibctlconfig.Config, positions []*datav1.Position) ([]*datav1.Position, error) {
+	// Positions of the same symbol in several accounts share a quote.
+	symbolToPrice := make(map[string]string)
+	symbolToErr := make(map[string]error)
+	var errs []error
+	quoted := make([]*datav1.Position, 0, len(positions))
+	for _, position := range positions {
+		symbol := position.GetSymbol()
+		if _, ok := config.IgnoreSymbols[symbol]; ok || !IsPriced(position.GetAssetCategory()) {
+			quoted = append(quoted, position)
+			continue
+		}
+		price, ok := symbolToPrice[symbol]
+		if !ok {
+			if _, ok := symbolToErr[symbol]; ok {
+				quoted = append(quoted, position)
+				continue
+			}
+			quote, err := client.GetQuote(ctx, config.QuoteSymbol(symbol))
+			if err == nil {
+				err = checkCurrency(config, symbol, position.GetCurrencyCode(), quote.Currency)
+			}
+			if err != nil {
+				err = fmt.Errorf("fetching quote for %s: %w", symbol, err)
+				symbolToErr[symbol] = err
+				errs = append(errs, err)
+				quoted = append(quoted, position)
+				continue
+			}
+			price = quote.Price
+			symbolToPrice[symbol] = price
+		}
+		marketValue, err := mathpb.MultiplyStrings(price, mathpb.ToString(position.GetQuantity()))
+		if err != nil {
+			return nil, fmt.Errorf("valuing %s: %w", symbol, err)
+		}
+		currency := position.GetCurrencyCode()
+		quotedPosition := proto.Clone(position).(*datav1.Position)
+		quotedPosition.MarketPrice = moneypb.MoneyFromMicros(currency, mathpb.ParseMicros(price))
+		quotedPosition.MarketValue = moneypb.MoneyFromMicros(currency, mathpb.ParseMicros(marketValue))
+		quoted = append(quoted, quotedPosition)
+	}
+	return quoted, errors.Join(errs...)
+}
+
+// *** PRIVATE ***
+
+// yahooFinanceProvider is a pricestore.Provider backed by Yahoo Finance.
+type yahooFinanceProvider struct {
+	client           yahoofinance.Client
+	config           *ibctlconfig.Config
+	symbolToCurrency map[string]string
+}
+
+func (p *yahooFinanceProvider) GetDailyCloses(ctx context.Context, symbol string, start xtime.Date, end xtime.Date) ([]pricestore.Price, error) {
+	history, err := p.client.GetHistory(ctx, p.config.QuoteSymbol(symbol), start, end)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCurrency(p.config, symbol, p.symbolToCurrency[symbol], history.Currency); err != nil {
+		return nil, err
+	}
+	prices := make([]pricestore.Price, 0, len(history.Closes))
+	for _, dailyClose := range history.Closes {
+		prices = append(prices, pricestore.Price{
+			Date:  dailyClose.Date,
+			Close: dailyClose.Close,
+		})
+	}
+	return prices, nil
+}
+
+// checkCurrency returns an error if the currency of prices fetched for the
+// symbol is not its trading currency, which is the case when the quote symbol
+// is of another listing (e.g., SHOP on the NYSE for SHOP on the TSX). The
+// trading currency may be empty if unknown.
+func checkCurrency(config *ibctlconfig.Config, symbol string, tradingCurrency string, currency string) error {
+	if tradingCurrency == "" || currency == tradingCurrency {
+		return nil
+	}
+	return fmt.Errorf(
+		"%s prices are in %s, not the trading currency %s of %s, set quote_symbol for %s in %s to the symbol of the listing",
+		config.QuoteSymbol(symbol),
+		currency,
+		tradingCurrency,
+		symbol,
+		symbol,
+		ibctlpath.ConfigFileName,
+	)
+}
+
+// symbolToCurrency returns the trading currency of each symbol in the trades.
+func symbolToCurrency(trades []*datav1.Trade) map[string]string {
+	symbolToCurrency := make(map[string]string)
+	for _, trade := range trades {
+		if currency := trade.GetCurrencyCode(); currency != "" {
+			symbolToCurrency[trade.GetSymbol()] = currency
+		}
+	}
+	return symbolToCurrency
+}
@@ -0,0 +1,198 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctlprices
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/bufdev/ibctl/internal/pkg/timepb"
+	"github.com/bufdev/ibctl/internal/pkg/yahoofinance"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSymbolRanges(t *testing.T) {
+	t.Parallel()
+	config := &ibctlconfig.Config{IgnoreSymbols: map[string]struct{}{"PROMO": {}}}
+	trades := []*datav1.Trade{
+		// Still held, so priced through today.
+		newTrade(t, "AAPL", "STK", "USD", "2025-03-01", datav1.TradeSide_TRADE_SIDE_BUY, 10),
+		newTrade(t, "AAPL", "STK", "USD", "2025-01-10", datav1.TradeSide_TRADE_SIDE_BUY, 5),
+		// Sold, so priced through the sale.
+		newTrade(t, "MSFT", "STK", "USD", "2025-02-01", datav1.TradeSide_TRADE_SIDE_BUY, 5),
+		newTrade(t, "MSFT", "STK", "USD", "2025-04-01", datav1.TradeSide_TRADE_SIDE_SELL, -5),
+		// Not priced.
+		newTrade(t, "ESM5", "FUT", "USD", "2025-02-01", datav1.TradeSide_TRADE_SIDE_BUY, 1),
+		newTrade(t, "EUR.USD", "CASH", "USD", "2025-02-01", datav1.TradeSide_TRADE_SIDE_BUY, 1),
+		newTrade(t, "PROMO", "STK", "USD", "2025-02-01", datav1.TradeSide_TRADE_SIDE_BUY, 1),
+	}
+	today := xtime.Date{Year: 2025, Month: time.June, Day: 30}
+	symbolRanges, err := SymbolRanges(trades, config, today)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		[]*SymbolRange{
+			{Symbol: "AAPL", Start: xtime.Date{Year: 2025, Month: time.January, Day: 10}, End: today},
+			{Symbol: "MSFT", Start: xtime.Date{Year: 2025, Month: time.February, Day: 1}, End: xtime.Date{Year: 2025, Month: time.April, Day: 1}},
+		},
+		symbolRanges,
+	)
+}
+
+func TestNewStore(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	config := &ibctlconfig.Config{
+		DirPath: t.TempDir(),
+		SymbolConfigs: map[string]ibctlconfig.SymbolConfig{
+			"SHOP": {QuoteSymbol: "SHOP.TO"},
+		},
+	}
+	client := &testClient{
+		symbolToHistory: map[string]*yahoofinance.History{
+			"BRK-B": {
+				Currency: "USD",
+				Closes:   []yahoofinance.DailyClose{{Date: xtime.Date{Year: 2025, Month: time.June, Day: 2}, Close: "491.23"}},
+			},
+			// The NYSE listing of SHOP, which is in USD.
+			"SHOP": {Currency: "USD"},
+			"SHOP.TO": {
+				Currency: "CAD",
+				Closes:   []yahoofinance.DailyClose{{Date: xtime.Date{Year: 2025, Month: time.June, Day: 2}, Close: "150.2"}},
+			},
+		},
+	}
+	trades := []*datav1.Trade{
+		newTrade(t, "BRK B", "STK", "USD", "2025-06-02", datav1.TradeSide_TRADE_SIDE_BUY, 1),
+		newTrade(t, "SHOP", "STK", "CAD", "2025-06-02", datav1.TradeSide_TRADE_SIDE_BUY, 1),
+	}
+	start := xtime.Date{Year: 2025, Month: time.June, Day: 1}
+	end := xtime.Date{Year: 2025, Month: time.June, Day: 3}
+	priceStore := NewStore(config, client, trades)
+	_, err := priceStore.Update(ctx, "BRK B", start, end)
+	require.NoError(t, err)
+	_, err = priceStore.Update(ctx, "SHOP", start, end)
+	require.NoError(t, err)
+	price, ok, err := priceStore.Close("BRK B", end)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "491.23", price.Close)
+	price, ok, err = priceStore.Close("SHOP", end)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "150.2", price.Close)
+
+	// Without quote_symbol, the prices of SHOP are of the NYSE listing.
+	config.SymbolConfigs = nil
+	_, err = NewStore(config, client, trades).Update(ctx, "SHOP", start, end.AddDays(1))
+	require.ErrorContains(t, err, "SHOP prices are in USD, not the trading currency CAD of SHOP")
+}
+
+func TestApplyQuotes(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	config := &ibctlconfig.Config{IgnoreSymbols: map[string]struct{}{"PROMO": {}}}
+	client := &testClient{
+		symbolToQuote: map[string]*yahoofinance.Quote{
+			"AAPL": {Currency: "USD", Price: "210.5"},
+		},
+	}
+	positions := []*datav1.Position{
+		newPosition("AAPL", "STK", "USD", 10, 200_000_000, "individual"),
+		newPosition("AAPL", "STK", "USD", 5, 200_000_000, "rrsp"),
+		newPosition("ESM5", "FUT", "USD", 1, 5_000_000_000, "individual"),
+		newPosition("XYZ", "STK", "USD", 100, 1_000_000, "individual"),
+		newPosition("PROMO", "STK", "USD", 1, 1_000_000, "individual"),
+	}
+	quoted, err := ApplyQuotes(ctx, client, config, positions)
+	require.ErrorContains(t, err, "fetching quote for XYZ")
+	require.Len(t, quoted, 5)
+	require.Equal(t, int64(210_500_000), moneypb.MoneyToMicros(quoted[0].GetMarketPrice()))
+	require.Equal(t, int64(2_105_000_000), moneypb.MoneyToMicros(quoted[0].GetMarketValue()))
+	require.Equal(t, int64(1_052_500_000), moneypb.MoneyToMicros(quoted[1].GetMarketValue()))
+	require.Equal(t, "rrsp", quoted[1].GetAccountId())
+	// Unpriced positions and positions without a quote keep their price.
+	require.Same(t, positions[2], quoted[2])
+	require.Same(t, positions[3], quoted[3])
+	// Ignored symbols are not quoted.
+	require.Same(t, positions[4], quoted[4])
+	require.NotContains(t, client.quoteRequests, "PROMO")
+	// The positions are not modified.
+	require.Equal(t, int64(200_000_000), moneypb.MoneyToMicros(positions[0].GetMarketPrice()))
+	require.Equal(t, 1, client.quoteRequests["AAPL"])
+}
+
+// testClient is a yahoofinance.Client serving fixed histories and quotes.
+type testClient struct {
+	symbolToHistory map[string]*yahoofinance.History
+	symbolToQuote   map[string]*yahoofinance.Quote
+	quoteRequests   map[string]int
+}
+
+func (c *testClient) GetHistory(_ context.Context, symbol string, _ xtime.Date, _ xtime.Date) (*yahoofinance.History, error) {
+	history, ok := c.symbolToHistory[symbol]
+	if !ok {
+		return nil, errors.New("Not Found")
+	}
+	return history, nil
+}
+
+func (c *testClient) GetQuote(_ context.Context, symbol string) (*yahoofinance.Quote, error) {
+	if c.quoteRequests == nil {
+		c.quoteRequests = make(map[string]int)
+	}
+	c.quoteRequests[symbol]++
+	quote, ok := c.symbolToQuote[symbol]
+	if !ok {
+		return nil, errors.New("Not Found")
+	}
+	return quote, nil
+}
+
+func newTrade(
+	t *testing.T,
+	symbol string,
+	assetCategory string,
+	currencyCode string,
+	date string,
+	side datav1.TradeSide,
+	quantity int64,
+) *datav1.Trade {
+	t.Helper()
+	tradeDate, err := xtime.ParseDate(date)
+	require.NoError(t, err)
+	protoDate, err := timepb.NewProtoDate(tradeDate.Year, tradeDate.Month, tradeDate.Day)
+	require.NoError(t, err)
+	return &datav1.Trade{
+		TradeId:       symbol + date,
+		TradeDate:     protoDate,
+		Symbol:        symbol,
+		AssetCategory: assetCategory,
+		Side:          side,
+		Quantity:      mathpb.FromMicros(quantity * 1_000_000),
+		TradePrice:    moneypb.MoneyFromMicros(currencyCode, 100_000_000),
+		CurrencyCode:  currencyCode,
+		AccountId:     "individual",
+	}
+}
+
+func newPosition(symbol string, assetCategory string, currencyCode string, quantity int64, priceMicros int64, accountAlias string) *datav1.Position {
+	return &datav1.Position{
+		Symbol:        symbol,
+		AssetCategory: assetCategory,
+		Quantity:      mathpb.FromMicros(quantity * 1_000_000),
+		MarketPrice:   moneypb.MoneyFromMicros(currencyCode, priceMicros),
+		MarketValue:   moneypb.MoneyFromMicros(currencyCode, quantity*priceMicros),
+		CurrencyCode:  currencyCode,
+		AccountId:     accountAlias,
+	}
+}
@@ -0,0 +1,609 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctlprune removes stale files from the cache by category-specific
+// retention rules.
+//
+// cache/ is safe to delete entirely, but deleting it discards data that is
+// slow or impossible to re-download, such as FX rate history and archived raw
+// Flex Query XML. Pruning instead removes only what is stale:
+//
+//   - cache/raw/<alias>/: archived raw Flex Query XML older than the cutoff.
+//     The newest archive of each account is kept.
+//   - cache/debug/: HTTP trace files older than the cutoff.
+//   - cache/activity_statements/<alias>/: parsed results of Activity Statement
+//     CSVs that were since edited, replaced, or removed, regardless of age, as
+//     they are never read again.
+//   - cache/fx/<BASE>.<QUOTE>/: FX rates of pairs with a currency that is no
+//     longer in any trade, position, or cash record, not updated since the
+//     cutoff.
+//   - cache/prices/: closing prices of symbols no longer held, not updated
+//     since the cutoff.
+//   - cache/tax_lots/: nothing, as only the most recently used tax lot
+//     results are kept when they are written.
+//   - The cache directories of accounts no longer in ibctl.yaml.
+//
+// Position snapshots under data/accounts/<alias>/snapshots/ are persistent
+// data, and are only pruned if requested: snapshots older than the cutoff are
+// removed, except the newest snapshot of each account.
+package ibctlprune
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/pkg/ibkractivitycsv"
+)
+
+// DefaultAge is the default age after which files are old, as for
+// "ibctl data prune --older-than 180d".
+const DefaultAge = 180 * 24 * time.Hour
+
+// Result is the disk usage of the cache and what can be pruned from it.
+type Result struct {
+	// Usages is the disk usage of each entry of cache/, sorted by directory,
+	// followed by the position snapshots of each account if requested.
+	Usages []*Usage
+	// Candidates are the files and directories that can be pruned, sorted by path.
+	Candidates []*Candidate
+}
+
+// Usage is the disk usage of a directory and what can be pruned from it.
+type Usage struct {
+	// Directory is the path relative to the ibctl directory (e.g., "cache/fx").
+	Directory string `json:"directory"`
+	// Files is the number of files.
+	Files int `json:"files"`
+	// Bytes is the total size of the files.
+	Bytes int64 `json:"bytes"`
+	// PrunableFiles is the number of files that can be pruned.
+	PrunableFiles int `json:"prunable_files"`
+	// PrunableBytes is the total size of the files that can be pruned.
+	PrunableBytes int64 `json:"prunable_bytes"`
+	// Rule describes what is pruned from the directory.
+	Rule string `json:"rule"`
+}
+
+// UsageHeaders returns the column headers for usage table/CSV output.
+func UsageHeaders() []string {
+	return []string{"DIRECTORY", "FILES", "SIZE", "PRUNABLE FILES", "PRUNABLE SIZE", "RULE"}
+}
+
+// UsageToRow converts a Usage to a string slice for CSV output, with sizes in bytes.
+func UsageToRow(u *Usage) []string {
+	return []string{
+		u.Directory,
+		strconv.Itoa(u.Files),
+		strconv.FormatInt(u.Bytes, 10),
+		strconv.Itoa(u.PrunableFiles),
+		strconv.FormatInt(u.PrunableBytes, 10),
+		u.Rule,
+	}
+}
+
+// UsageToTableRow converts a Usage to a string slice for table output, with
+// human-readable sizes.
+func UsageToTableRow(u *Usage) []string {
+	return []string{
+		u.Directory,
+		strconv.Itoa(u.Files),
+		FormatSize(u.Bytes),
+		strconv.Itoa(u.PrunableFiles),
+		FormatSize(u.PrunableBytes),
+		u.Rule,
+	}
+}
+
+// Candidate is a file or directory that can be pruned.
+type Candidate struct {
+	// Path is the path relative to the ibctl directory.
+	Path string `json:"path"`
+	// Files is the number of files, which is more than one for directories.
+	Files int `json:"files"`
+	// Bytes is the total size of the files.
+	Bytes int64 `json:"bytes"`
+	// Reason is why the file or directory can be pruned.
+	Reason string `json:"reason"`
+}
+
+// Scan returns the disk usage of each entry of cache/ and the files and
+// directories that can be pruned from it, by the rules in the package
+// documentation. Files modified before cutoff are old.
+//
+// accountAliases are the configured account aliases, and mergedData must have
+// the trades, positions, and cash records, which determine the currencies and
+// symbols still in use. baseCurrency is the tax base currency, whose FX rates
+// are kept. If snapshots is true, position snapshots are also scanned.
+func Scan(
+	dirPath string,
+	accountAliases map[string]string,
+	mergedData *ibctlmerge.MergedData,
+	baseCurrency string,
+	cutoff time.Time,
+	snapshots bool,
+) (*Result, error) {
+	scanner := &scanner{
+		dirPath:        dirPath,
+		accountAliases: accountAliases,
+		currencies:     currencies(mergedData, baseCurrency),
+		symbols:        make(map[string]struct{}),
+		cutoff:         cutoff,
+	}
+	for _, position := range mergedData.Positions {
+		scanner.symbols[position.GetSymbol()] = struct{}{}
+	}
+	result := &Result{}
+	cacheDirPath := filepath.Join(dirPath, "cache")
+	entries, err := os.ReadDir(cacheDirPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		entryPath := filepath.Join(cacheDirPath, entry.Name())
+		var candidates []*Candidate
+		var rule string
+		switch entryPath {
+		case ibctlpath.CacheRawAccountDirPath(dirPath, ""):
+			rule = "older than the cutoff, keeping the newest per account"
+			candidates, err = scanner.scanRaw(entryPath)
+		case ibctlpath.CacheDebugDirPath(dirPath):
+			rule = "older than the cutoff"
+			candidates, err = scanner.scanOlderFiles(entryPath, "HTTP trace older than the cutoff")
+		case ibctlpath.CacheActivityStatementsDirPath(dirPath):
+			rule = "results of CSVs since edited, replaced, or removed"
+			candidates, err = scanner.scanActivityStatements(entryPath)
+		case ibctlpath.CacheFXDirPath(dirPath):
+			rule = "pairs of currencies no longer in the data, not updated since the cutoff"
+			candidates, err = scanner.scanFX(entryPath)
+		case ibctlpath.CachePricesDirPath(dirPath):
+			rule = "symbols no longer held, not updated since the cutoff"
+			candidates, err = scanner.scanPrices(entryPath)
+		case ibctlpath.CacheTaxLotsDirPath(dirPath):
+			// Tax lot results are pruned as they are written.
+			rule = "all but the 16 most recently used, on every write"
+		case ibctlpath.CacheAccountsDirPath(dirPath):
+			rule = "accounts no longer in " + ibctlpath.ConfigFileName
+			candidates, err = scanner.scanAccountDirs(entryPath)
+		default:
+			rule = "never pruned"
+		}
+		if err != nil {
+			return nil, err
+		}
+		usage, err := scanner.newUsage(entryPath, rule, candidates)
+		if err != nil {
+			return nil, err
+		}
+		result.Usages = append(result.Usages, usage)
+		result.Candidates = append(result.Candidates, candidates...)
+	}
+	if snapshots {
+		aliases := make([]string, 0, len(accountAliases))
+		for alias := range accountAliases {
+			aliases = append(aliases, alias)
+		}
+		sort.Strings(aliases)
+		for _, alias := range aliases {
+			snapshotsDirPath := ibctlpath.DataAccountSnapshotsDirPath(dirPath, alias)
+			if _, err := os.Stat(snapshotsDirPath); err != nil {
+				continue
+			}
+			candidates, err := scanner.scanSnapshots(snapshotsDirPath)
+			if err != nil {
+				return nil, err
+			}
+			usage, err := scanner.newUsage(snapshotsDirPath, "older than the cutoff, keeping the newest", candidates)
+			if err != nil {
+				return nil, err
+			}
+			result.Usages = append(result.Usages, usage)
+			result.Candidates = append(result.Candidates, candidates...)
+		}
+	}
+	sort.Slice(result.Candidates, func(i, j int) bool {
+		return result.Candidates[i].Path < result.Candidates[j].Path
+	})
+	return result, nil
+}
+
+// Prune removes the candidates from the ibctl directory.
+func Prune(dirPath string, candidates []*Candidate) error {
+	for _, candidate := range candidates {
+		if err := os.RemoveAll(filepath.Join(dirPath, candidate.Path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseAge parses an age as a number of days with a "d" suffix (e.g., "180d"),
+// or as a Go duration (e.g., "72h").
+func ParseAge(value string) (time.Duration, error) {
+	var age time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q, must be a number of days (e.g., 180d) or a duration (e.g., 72h)", value)
+		}
+		age = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		age, err = time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q, must be a number of days (e.g., 180d) or a duration (e.g., 72h)", value)
+		}
+	}
+	if age < 0 {
+		return 0, fmt.Errorf("invalid age %q, must not be negative", value)
+	}
+	return age, nil
+}
+
+// FormatSize formats a size in bytes with binary units (e.g., "1.5 MiB").
+func FormatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	divisor, exponent := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		divisor *= unit
+		exponent++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(divisor), "KMGTPE"[exponent])
+}
+
+// *** PRIVATE ***
+
+// scanner scans the directories of an ibctl directory for candidates.
+type scanner struct {
+	dirPath        string
+	accountAliases map[string]string
+	// currencies are the currencies still in use.
+	currencies map[string]struct{}
+	// symbols are the symbols still held.
+	symbols map[string]struct{}
+	cutoff  time.Time
+}
+
+// scanRaw returns the raw XML archives older than the cutoff, except the
+// newest archive of each account, and the archives of removed accounts.
+func (s *scanner) scanRaw(rawDirPath string) ([]*Candidate, error) {
+	var candidates []*Candidate
+	return candidates, s.forEachAccountDir(rawDirPath, &candidates, func(accountDirPath string) error {
+		files, err := readFiles(accountDirPath)
+		if err != nil {
+			return err
+		}
+		// Archive names are timestamps, so the last file is the newest.
+		for i, file := range files {
+			if i == len(files)-1 || !file.modTime.Before(s.cutoff) {
+				continue
+			}
+			candidate, err := s.newCandidate(file.path, "raw XML archive older than the cutoff")
+			if err != nil {
+				return err
+			}
+			candidates = append(candidates, candidate)
+		}
+		return nil
+	})
+}
+
+// scanOlderFiles returns the files in the directory older than the cutoff.
+func (s *scanner) scanOlderFiles(dirPath string, reason string) ([]*Candidate, error) {
+	files, err := readFiles(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []*Candidate
+	for _, file := range files {
+		if !file.modTime.Before(s.cutoff) {
+			continue
+		}
+		candidate, err := s.newCandidate(file.path, reason)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
+
+// scanActivityStatements returns the parsed Activity Statement CSV results
+// not in use by any current CSV file, and the results of removed accounts.
+func (s *scanner) scanActivityStatements(cacheActivityStatementsDirPath string) ([]*Candidate, error) {
+	var candidates []*Candidate
+	return candidates, s.forEachAccountDir(cacheActivityStatementsDirPath, &candidates, func(accountDirPath string) error {
+		cacheFilePaths, err := ibkractivitycsv.CacheFilePaths(
+			filepath.Join(ibctlpath.ActivityStatementsDirPath(s.dirPath), filepath.Base(accountDirPath)),
+			accountDirPath,
+		)
+		if err != nil {
+			return err
+		}
+		inUse := make(map[string]struct{}, len(cacheFilePaths))
+		for _, cacheFilePath := range cacheFilePaths {
+			inUse[cacheFilePath] = struct{}{}
+		}
+		files, err := readFiles(accountDirPath)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			if _, ok := inUse[file.path]; ok {
+				continue
+			}
+			candidate, err := s.newCandidate(file.path, "parsed result of a CSV since edited, replaced, or removed")
+			if err != nil {
+				return err
+			}
+			candidates = append(candidates, candidate)
+		}
+		return nil
+	})
+}
+
+// scanFX returns the FX rate pairs with a currency no longer in use that
+// have not been updated since the cutoff.
+func (s *scanner) scanFX(fxDirPath string) ([]*Candidate, error) {
+	entries, err := os.ReadDir(fxDirPath)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []*Candidate
+	for _, entry := range entries {
+		base, quote, ok := strings.Cut(entry.Name(), ".")
+		if !entry.IsDir() || !ok {
+			continue
+		}
+		_, baseInUse := s.currencies[base]
+		_, quoteInUse := s.currencies[quote]
+		if baseInUse && quoteInUse {
+			continue
+		}
+		pairDirPath := filepath.Join(fxDirPath, entry.Name())
+		files, err := readFiles(pairDirPath)
+		if err != nil {
+			return nil, err
+		}
+		if updatedSince(files, s.cutoff) {
+			continue
+		}
+		candidate, err := s.newCandidate(pairDirPath, "FX rates of a currency no longer in the data")
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
+
+// scanPrices returns the closing prices of symbols no longer held that have
+// not been updated since the cutoff.
+func (s *scanner) scanPrices(pricesDirPath string) ([]*Candidate, error) {
+	files, err := readFiles(pricesDirPath)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []*Candidate
+	for _, file := range files {
+		// Price files are named by the path-escaped symbol.
+		symbol, err := url.PathUnescape(strings.TrimSuffix(filepath.Base(file.path), ".json"))
+		if err != nil {
+			continue
+		}
+		if _, ok := s.symbols[symbol]; ok || !file.modTime.Before(s.cutoff) {
+			continue
+		}
+		candidate, err := s.newCandidate(file.path, "closing prices of a symbol no longer held")
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
+
+// scanAccountDirs returns the directories of removed accounts.
+func (s *scanner) scanAccountDirs(dirPath string) ([]*Candidate, error) {
+	var candidates []*Candidate
+	return candidates, s.forEachAccountDir(dirPath, &candidates, func(string) error { return nil })
+}
+
+// scanSnapshots returns the position snapshots older than the cutoff, except
+// the newest snapshot.
+func (s *scanner) scanSnapshots(snapshotsDirPath string) ([]*Candidate, error) {
+	entries, err := os.ReadDir(snapshotsDirPath)
+	if err != nil {
+		return nil, err
+	}
+	var dates []string
+	for _, entry := range entries {
+		if _, err := time.Parse(time.DateOnly, entry.Name()); entry.IsDir() && err == nil {
+			dates = append(dates, entry.Name())
+		}
+	}
+	sort.Strings(dates)
+	cutoffDate := s.cutoff.Format(time.DateOnly)
+	var candidates []*Candidate
+	for i, date := range dates {
+		if i == len(dates)-1 || date >= cutoffDate {
+			continue
+		}
+		candidate, err := s.newCandidate(filepath.Join(snapshotsDirPath, date), "position snapshot older than the cutoff")
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
+
+// forEachAccountDir calls f with each account directory in the directory,
+// and adds the directories of accounts no longer configured to candidates.
+func (s *scanner) forEachAccountDir(dirPath string, candidates *[]*Candidate, f func(accountDirPath string) error) error {
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		accountDirPath := filepath.Join(dirPath, entry.Name())
+		if _, ok := s.accountAliases[entry.Name()]; !ok {
+			candidate, err := s.newCandidate(accountDirPath, "account no longer in "+ibctlpath.ConfigFileName)
+			if err != nil {
+				return err
+			}
+			*candidates = append(*candidates, candidate)
+			continue
+		}
+		if err := f(accountDirPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newCandidate returns the candidate for a file or directory.
+func (s *scanner) newCandidate(path string, reason string) (*Candidate, error) {
+	relPath, err := filepath.Rel(s.dirPath, path)
+	if err != nil {
+		return nil, err
+	}
+	files, bytes, err := diskUsage(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Candidate{
+		Path:   relPath,
+		Files:  files,
+		Bytes:  bytes,
+		Reason: reason,
+	}, nil
+}
+
+// newUsage returns the usage of a directory or file with the candidates found in it.
+func (s *scanner) newUsage(path string, rule string, candidates []*Candidate) (*Usage, error) {
+	relPath, err := filepath.Rel(s.dirPath, path)
+	if err != nil {
+		return nil, err
+	}
+	files, bytes, err := diskUsage(path)
+	if err != nil {
+		return nil, err
+	}
+	usage := &Usage{
+		Directory: relPath,
+		Files:     files,
+		Bytes:     bytes,
+		Rule:      rule,
+	}
+	for _, candidate := range candidates {
+		usage.PrunableFiles += candidate.Files
+		usage.PrunableBytes += candidate.Bytes
+	}
+	return usage, nil
+}
+
+// file is a regular file.
+type file struct {
+	path    string
+	modTime time.Time
+}
+
+// readFiles returns the regular files directly in the directory, sorted by name.
+func readFiles(dirPath string) ([]file, error) {
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	var files []file
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file{path: filepath.Join(dirPath, entry.Name()), modTime: info.ModTime()})
+	}
+	return files, nil
+}
+
+// updatedSince returns true if any of the files was modified at or after the cutoff.
+func updatedSince(files []file, cutoff time.Time) bool {
+	for _, file := range files {
+		if !file.modTime.Before(cutoff) {
+			return true
+		}
+	}
+	return false
+}
+
+// diskUsage returns the number and total size of the regular files at the
+// path, recursively if it is a directory.
+func diskUsage(path string) (int, int64, error) {
+	var files int
+	var bytes int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files++
+		bytes += info.Size()
+		return nil
+	})
+	return files, bytes, err
+}
+
+// currencies returns the currencies in use: those of the trades, positions,
+// and cash records, the currencies of FX conversion pairs, the tax base
+// currency, and USD and CAD, which FX rates are fetched against.
+func currencies(mergedData *ibctlmerge.MergedData, baseCurrency string) map[string]struct{} {
+	result := map[string]struct{}{"USD": {}, "CAD": {}}
+	add := func(currencyCode string) {
+		if currencyCode != "" {
+			result[currencyCode] = struct{}{}
+		}
+	}
+	add(baseCurrency)
+	for _, trade := range mergedData.Trades {
+		add(trade.GetCurrencyCode())
+		if base, quote, ok := strings.Cut(trade.GetSymbol(), "."); ok && trade.GetAssetCategory() == "CASH" {
+			add(base)
+			add(quote)
+		}
+	}
+	for _, position := range mergedData.Positions {
+		add(position.GetCurrencyCode())
+	}
+	for _, cashPosition := range mergedData.CashPositions {
+		add(cashPosition.GetBalance().GetCurrencyCode())
+	}
+	for _, cashTransaction := range mergedData.CashTransactions {
+		add(cashTransaction.GetCurrencyCode())
+	}
+	return result
+}
@@ -0,0 +1,151 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctlprune
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/pkg/ibkractivitycsv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScan(t *testing.T) {
+	t.Parallel()
+	dirPath := t.TempDir()
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := now.AddDate(-1, 0, 0)
+	cutoff := now.AddDate(0, 0, -180)
+	writeFile := func(path string, modTime time.Time) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte("data"), 0o644))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	// Raw archives: the old one is pruned, the newest is kept even though it
+	// is old, and the archives of a removed account are pruned.
+	rawDirPath := ibctlpath.CacheRawAccountDirPath(dirPath, "individual")
+	writeFile(filepath.Join(rawDirPath, "20250101T000000Z.xml"), old)
+	writeFile(filepath.Join(rawDirPath, "20250102T000000Z.xml"), old)
+	writeFile(filepath.Join(ibctlpath.CacheRawAccountDirPath(dirPath, "closed"), "20250101T000000Z.xml"), now)
+	// Debug traces: only the old one is pruned.
+	writeFile(filepath.Join(ibctlpath.CacheDebugDirPath(dirPath), "http-20250101T000000Z.log"), old)
+	writeFile(filepath.Join(ibctlpath.CacheDebugDirPath(dirPath), "http-20260601T000000Z.log"), now)
+	// Activity Statements: the result of the current CSV is kept, the stale
+	// result is pruned regardless of age.
+	csvDirPath := filepath.Join(ibctlpath.ActivityStatementsDirPath(dirPath), "individual")
+	writeFile(filepath.Join(csvDirPath, "2024.csv"), old)
+	cacheActivityStatementsDirPath := filepath.Join(ibctlpath.CacheActivityStatementsDirPath(dirPath), "individual")
+	cacheFilePaths, err := ibkractivitycsv.CacheFilePaths(csvDirPath, cacheActivityStatementsDirPath)
+	require.NoError(t, err)
+	require.Len(t, cacheFilePaths, 1)
+	writeFile(cacheFilePaths[0], old)
+	writeFile(filepath.Join(cacheActivityStatementsDirPath, "stale.json"), now)
+	// FX: pairs of currencies in use are kept, old pairs of currencies no
+	// longer in use are pruned, and recently updated pairs are kept.
+	writeFile(filepath.Join(ibctlpath.CacheFXDirPath(dirPath), "EUR.USD", "rates.json"), old)
+	writeFile(filepath.Join(ibctlpath.CacheFXDirPath(dirPath), "GBP.USD", "rates.json"), old)
+	writeFile(filepath.Join(ibctlpath.CacheFXDirPath(dirPath), "JPY.USD", "rates.json"), now)
+	// Prices: held symbols are kept, old prices of symbols no longer held are pruned.
+	writeFile(filepath.Join(ibctlpath.CachePricesDirPath(dirPath), "AAPL.json"), old)
+	writeFile(filepath.Join(ibctlpath.CachePricesDirPath(dirPath), "BRK%20B.json"), old)
+	writeFile(filepath.Join(ibctlpath.CachePricesDirPath(dirPath), "MSFT.json"), old)
+	// The merged data is never pruned.
+	writeFile(ibctlpath.CacheMergedDataFilePath(dirPath), old)
+	// Snapshots: the old one is pruned, the newest is kept.
+	snapshotsDirPath := ibctlpath.DataAccountSnapshotsDirPath(dirPath, "individual")
+	writeFile(filepath.Join(snapshotsDirPath, "2025-01-01", "positions.json"), old)
+	writeFile(filepath.Join(snapshotsDirPath, "2025-01-02", "positions.json"), old)
+
+	mergedData := &ibctlmerge.MergedData{
+		Trades: []*datav1.Trade{
+			{Symbol: "SAP", CurrencyCode: "EUR"},
+		},
+		Positions: []*datav1.Position{
+			{Symbol: "AAPL", CurrencyCode: "USD"},
+			{Symbol: "BRK B", CurrencyCode: "USD"},
+		},
+	}
+	accountAliases := map[string]string{"individual": "U1111111"}
+
+	result, err := Scan(dirPath, accountAliases, mergedData, "USD", cutoff, false)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		[]string{
+			"cache/activity_statements/individual/stale.json",
+			"cache/debug/http-20250101T000000Z.log",
+			"cache/fx/GBP.USD",
+			"cache/prices/MSFT.json",
+			"cache/raw/closed",
+			"cache/raw/individual/20250101T000000Z.xml",
+		},
+		candidatePaths(result.Candidates),
+	)
+	usages := make(map[string]*Usage)
+	for _, usage := range result.Usages {
+		usages[usage.Directory] = usage
+	}
+	require.Len(t, usages, 6)
+	require.Equal(t, 3, usages["cache/fx"].Files)
+	require.Equal(t, int64(12), usages["cache/fx"].Bytes)
+	require.Equal(t, 1, usages["cache/fx"].PrunableFiles)
+	require.Equal(t, int64(4), usages["cache/fx"].PrunableBytes)
+	require.Equal(t, 0, usages["cache/merged_data.json"].PrunableFiles)
+
+	result, err = Scan(dirPath, accountAliases, mergedData, "USD", cutoff, true)
+	require.NoError(t, err)
+	require.Contains(t, candidatePaths(result.Candidates), "data/accounts/individual/snapshots/2025-01-01")
+	require.NotContains(t, candidatePaths(result.Candidates), "data/accounts/individual/snapshots/2025-01-02")
+
+	require.NoError(t, Prune(dirPath, result.Candidates))
+	result, err = Scan(dirPath, accountAliases, mergedData, "USD", cutoff, true)
+	require.NoError(t, err)
+	require.Empty(t, result.Candidates)
+	require.FileExists(t, cacheFilePaths[0])
+	require.FileExists(t, filepath.Join(ibctlpath.CacheFXDirPath(dirPath), "EUR.USD", "rates.json"))
+}
+
+func TestScanEmpty(t *testing.T) {
+	t.Parallel()
+	result, err := Scan(t.TempDir(), nil, &ibctlmerge.MergedData{}, "USD", time.Now(), true)
+	require.NoError(t, err)
+	require.Empty(t, result.Usages)
+	require.Empty(t, result.Candidates)
+}
+
+func TestParseAge(t *testing.T) {
+	t.Parallel()
+	age, err := ParseAge("180d")
+	require.NoError(t, err)
+	require.Equal(t, 180*24*time.Hour, age)
+	age, err = ParseAge("72h")
+	require.NoError(t, err)
+	require.Equal(t, 72*time.Hour, age)
+	_, err = ParseAge("six months")
+	require.Error(t, err)
+	_, err = ParseAge("-1d")
+	require.Error(t, err)
+}
+
+func TestFormatSize(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "512 B", FormatSize(512))
+	require.Equal(t, "1.5 KiB", FormatSize(1536))
+	require.Equal(t, "2.0 MiB", FormatSize(2*1024*1024))
+	require.Equal(t, "3.0 GiB", FormatSize(3*1024*1024*1024))
+}
+
+func candidatePaths(candidates []*Candidate) []string {
+	paths := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		paths = append(paths, filepath.ToSlash(candidate.Path))
+	}
+	return paths
+}
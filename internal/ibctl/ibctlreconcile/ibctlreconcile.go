@@ -0,0 +1,840 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctlreconcile provides statement-of-changes reconciliation between
+// dated position snapshots.
+//
+// For each account and symbol, the reconciliation checks that:
+//
+//	previous position + trades + transfers + corporate actions = current position
+//
+// where trades, transfers, and corporate actions are those dated after the
+// previous snapshot and on or before the current snapshot. Any remaining
+// difference is an unexplained quantity change, which usually means a window
+// of data is missing (e.g., a gap between downloads longer than the Flex
+// Query period) — something FIFO alone silently absorbs.
+//
+// Lot reconciliation separately compares the lots IBKR matched against each
+// closing trade (the Flex Query closed lots) with the lots ibctl's FIFO
+// computation closed, and lists every closing date where they diverge.
+//
+// Mark-to-market reconciliation compares the Mark-to-Market Performance
+// Summary of each Activity Statement with the quantities and P/L ibctl
+// computes from its merged data for the same period.
+//
+// Realized P/L reconciliation compares the realized gains ibctl computes for
+// each closing trade with the FIFO realized P/L IBKR reports for the trade.
+//
+// Trade code checking lists the Activity Statement trades with IBKR trade
+// codes ibctl does not know, whose meaning for the merge was not reviewed.
+package ibctlreconcile
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctltaxlot"
+	"github.com/bufdev/ibctl/internal/pkg/ibkractivitycsv"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/bufdev/ibctl/internal/pkg/protoio"
+)
+
+// PositionSnapshot is a set of IBKR-reported positions for one account as of a date.
+type PositionSnapshot struct {
+	// AccountAlias is the account alias the snapshot belongs to.
+	AccountAlias string
+	// Date is the snapshot date (YYYY-MM-DD).
+	Date string
+	// Positions is the list of positions reported as of Date.
+	Positions []*datav1.Position
+}
+
+// Discrepancy is an unexplained quantity change between two snapshots for a
+// single account and symbol.
+type Discrepancy struct {
+	// Account is the account alias.
+	Account string `json:"account"`
+	// Symbol is the ticker symbol.
+	Symbol string `json:"symbol"`
+	// FromDate is the date of the previous snapshot (YYYY-MM-DD).
+	FromDate string `json:"from_date"`
+	// ToDate is the date of the current snapshot (YYYY-MM-DD).
+	ToDate string `json:"to_date"`
+	// PreviousQuantity is the quantity in the previous snapshot.
+	PreviousQuantity string `json:"previous_quantity"`
+	// TradeQuantity is the net signed quantity of trades between the snapshots.
+	TradeQuantity string `json:"trade_quantity"`
+	// TransferQuantity is the net signed quantity of transfers between the snapshots.
+	TransferQuantity string `json:"transfer_quantity"`
+	// CorporateActionQuantity is the net signed quantity of corporate actions between the snapshots.
+	CorporateActionQuantity string `json:"corporate_action_quantity"`
+	// ExpectedQuantity is previous + trades + transfers + corporate actions.
+	ExpectedQuantity string `json:"expected_quantity"`
+	// ActualQuantity is the quantity in the current snapshot.
+	ActualQuantity string `json:"actual_quantity"`
+	// UnexplainedQuantity is actual - expected.
+	UnexplainedQuantity string `json:"unexplained_quantity"`
+}
+
+// DiscrepancyHeaders returns the column headers for discrepancy table/CSV output.
+func DiscrepancyHeaders() []string {
+	return []string{"ACCOUNT", "SYMBOL", "FROM", "TO", "PREVIOUS", "TRADES", "TRANSFERS", "CORP ACTIONS", "EXPECTED", "ACTUAL", "UNEXPLAINED"}
+}
+
+// DiscrepancyToRow converts a Discrepancy to a string slice for table/CSV output.
+func DiscrepancyToRow(d *Discrepancy) []string {
+	return []string{
+		d.Account,
+		d.Symbol,
+		d.FromDate,
+		d.ToDate,
+		d.PreviousQuantity,
+		d.TradeQuantity,
+		d.TransferQuantity,
+		d.CorporateActionQuantity,
+		d.ExpectedQuantity,
+		d.ActualQuantity,
+		d.UnexplainedQuantity,
+	}
+}
+
+// LotDivergence is a difference between the quantity IBKR and ibctl closed
+// from lots opened on one date, by closing trades on another date.
+type LotDivergence struct {
+	// Account is the account alias.
+	Account string `json:"account"`
+	// Symbol is the ticker symbol.
+	Symbol string `json:"symbol"`
+	// CloseDate is the date of the closing trades (YYYY-MM-DD).
+	CloseDate string `json:"close_date"`
+	// OpenDate is the date the closed lots were opened (YYYY-MM-DD).
+	OpenDate string `json:"open_date"`
+	// IBKRQuantity is the quantity IBKR closed from lots opened on OpenDate.
+	IBKRQuantity string `json:"ibkr_quantity"`
+	// IbctlQuantity is the quantity ibctl's FIFO closed from lots opened on OpenDate.
+	IbctlQuantity string `json:"ibctl_quantity"`
+}
+
+// LotDivergenceHeaders returns the column headers for lot divergence table/CSV output.
+func LotDivergenceHeaders() []string {
+	return []string{"ACCOUNT", "SYMBOL", "CLOSED", "OPENED", "IBKR QUANTITY", "IBCTL QUANTITY"}
+}
+
+// LotDivergenceToRow converts a LotDivergence to a string slice for table/CSV output.
+func LotDivergenceToRow(d *LotDivergence) []string {
+	return []string{
+		d.Account,
+		d.Symbol,
+		d.CloseDate,
+		d.OpenDate,
+		d.IBKRQuantity,
+		d.IbctlQuantity,
+	}
+}
+
+// RealizedDivergence is a difference between the realized P/L IBKR and ibctl
+// computed for a closing trade.
+type RealizedDivergence struct {
+	// Account is the account alias.
+	Account string `json:"account"`
+	// Symbol is the ticker symbol.
+	Symbol string `json:"symbol"`
+	// TradeID is the IBKR trade ID of the closing trade.
+	TradeID string `json:"trade_id"`
+	// Date is the trade date of the closing trade (YYYY-MM-DD).
+	Date string `json:"date"`
+	// Currency is the trade currency.
+	Currency string `json:"currency"`
+	// IBKRRealized is IBKR's FIFO realized P/L for the trade.
+	IBKRRealized string `json:"ibkr_realized"`
+	// IbctlRealized is the sum of ibctl's realized gains for the trade.
+	IbctlRealized string `json:"ibctl_realized"`
+	// Difference is IbctlRealized - IBKRRealized.
+	Difference string `json:"difference"`
+}
+
+// RealizedDivergenceHeaders returns the column headers for realized divergence table/CSV output.
+func RealizedDivergenceHeaders() []string {
+	return []string{"ACCOUNT", "SYMBOL", "TRADE ID", "DATE", "CURRENCY", "IBKR REALIZED", "IBCTL REALIZED", "DIFFERENCE"}
+}
+
+// RealizedDivergenceToRow converts a RealizedDivergence to a string slice for table/CSV output.
+func RealizedDivergenceToRow(d *RealizedDivergence) []string {
+	return []string{
+		d.Account,
+		d.Symbol,
+		d.TradeID,
+		d.Date,
+		d.Currency,
+		d.IBKRRealized,
+		d.IbctlRealized,
+		d.Difference,
+	}
+}
+
+// MTMDivergence is a difference between the Mark-to-Market Performance Summary
+// of an Activity Statement and ibctl's merged data, for a single account,
+// symbol, and statement period.
+type MTMDivergence struct {
+	// Account is the account alias.
+	Account string `json:"account"`
+	// Symbol is the ticker symbol.
+	Symbol string `json:"symbol"`
+	// FromDate is the first date of the statement period (YYYY-MM-DD).
+	FromDate string `json:"from_date"`
+	// ToDate is the last date of the statement period (YYYY-MM-DD).
+	ToDate string `json:"to_date"`
+	// IBKRPriorQuantity is the quantity IBKR reports at the start of the period.
+	IBKRPriorQuantity string `json:"ibkr_prior_quantity"`
+	// IbctlPriorQuantity is the quantity ibctl computes at the start of the period.
+	IbctlPriorQuantity string `json:"ibctl_prior_quantity"`
+	// IBKRCurrentQuantity is the quantity IBKR reports at the end of the period.
+	IBKRCurrentQuantity string `json:"ibkr_current_quantity"`
+	// IbctlCurrentQuantity is the quantity ibctl computes at the end of the period.
+	IbctlCurrentQuantity string `json:"ibctl_current_quantity"`
+	// IBKRPL is IBKR's position, transaction, and commissions P/L for the
+	// period, or empty if P/L is not compared for the symbol.
+	IBKRPL string `json:"ibkr_pl,omitempty"`
+	// IbctlPL is ibctl's P/L for the period, or empty if P/L is not compared for the symbol.
+	IbctlPL string `json:"ibctl_pl,omitempty"`
+}
+
+// MTMDivergenceHeaders returns the column headers for MTM divergence table/CSV output.
+func MTMDivergenceHeaders() []string {
+	return []string{"ACCOUNT", "SYMBOL", "FROM", "TO", "IBKR PRIOR", "IBCTL PRIOR", "IBKR CURRENT", "IBCTL CURRENT", "IBKR P/L", "IBCTL P/L"}
+}
+
+// MTMDivergenceToRow converts an MTMDivergence to a string slice for table/CSV output.
+func MTMDivergenceToRow(d *MTMDivergence) []string {
+	return []string{
+		d.Account,
+		d.Symbol,
+		d.FromDate,
+		d.ToDate,
+		d.IBKRPriorQuantity,
+		d.IbctlPriorQuantity,
+		d.IBKRCurrentQuantity,
+		d.IbctlCurrentQuantity,
+		d.IBKRPL,
+		d.IbctlPL,
+	}
+}
+
+// UnknownTradeCode is an Activity Statement CSV trade with a trade code that
+// is not in the IBKR codes legend ibctl knows.
+type UnknownTradeCode struct {
+	// Account is the account alias.
+	Account string `json:"account"`
+	// Symbol is the ticker symbol.
+	Symbol string `json:"symbol"`
+	// Date is the trade date (YYYY-MM-DD).
+	Date string `json:"date"`
+	// Quantity is the signed trade quantity.
+	Quantity string `json:"quantity"`
+	// Code is the unknown trade code.
+	Code string `json:"code"`
+	// Codes is the full semicolon-separated list of trade codes of the trade.
+	Codes string `json:"codes"`
+}
+
+// UnknownTradeCodeHeaders returns the column headers for unknown trade code table/CSV output.
+func UnknownTradeCodeHeaders() []string {
+	return []string{"ACCOUNT", "SYMBOL", "DATE", "QUANTITY", "CODE", "CODES"}
+}
+
+// UnknownTradeCodeToRow converts an UnknownTradeCode to a string slice for table/CSV output.
+func UnknownTradeCodeToRow(u *UnknownTradeCode) []string {
+	return []string{
+		u.Account,
+		u.Symbol,
+		u.Date,
+		u.Quantity,
+		u.Code,
+		u.Codes,
+	}
+}
+
+// FindUnknownTradeCodes returns the trade codes of the Activity Statement CSV
+// trades of an account that are not in the IBKR codes legend, sorted by date
+// and symbol. A trade listed in overlapping statements is only reported once.
+func FindUnknownTradeCodes(accountAlias string, statements []*ibkractivitycsv.ActivityStatement) []*UnknownTradeCode {
+	type unknownTradeCodeKey struct {
+		symbol   string
+		dateTime time.Time
+		quantity string
+		code     string
+	}
+	seen := make(map[unknownTradeCodeKey]struct{})
+	var unknownTradeCodes []*UnknownTradeCode
+	for _, statement := range statements {
+		for i := range statement.Trades {
+			csvTrade := &statement.Trades[i]
+			for _, code := range csvTrade.Codes() {
+				if ibkractivitycsv.IsKnownTradeCode(code) {
+					continue
+				}
+				key := unknownTradeCodeKey{symbol: csvTrade.Symbol, dateTime: csvTrade.DateTime, quantity: csvTrade.Quantity, code: code}
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				seen[key] = struct{}{}
+				unknownTradeCodes = append(unknownTradeCodes, &UnknownTradeCode{
+					Account:  accountAlias,
+					Symbol:   csvTrade.Symbol,
+					Date:     csvTrade.DateTime.Format(time.DateOnly),
+					Quantity: csvTrade.Quantity,
+					Code:     code,
+					Codes:    csvTrade.Code,
+				})
+			}
+		}
+	}
+	sort.SliceStable(unknownTradeCodes, func(i, j int) bool {
+		a, b := unknownTradeCodes[i], unknownTradeCodes[j]
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		return a.Symbol < b.Symbol
+	})
+	return unknownTradeCodes
+}
+
+// ReadClosedLots reads the IBKR closed lots persisted for an account from
+// closed_lots.json in the account data directory. Returns an empty slice if
+// the file does not exist.
+func ReadClosedLots(dataAccountDirPath string) ([]*datav1.ClosedLot, error) {
+	closedLots, err := protoio.ReadMessagesJSON(filepath.Join(dataAccountDirPath, "closed_lots.json"), func() *datav1.ClosedLot { return &datav1.ClosedLot{} })
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading closed lots: %w", err)
+	}
+	return closedLots, nil
+}
+
+// ReconcileLots compares IBKR's closed lots with the realized gains from
+// ibctl's FIFO computation, and returns every (account, symbol, close date,
+// open date) whose closed quantity differs.
+//
+// Only closing dates that IBKR reported closed lots for are compared, since
+// closed lots are only available for the downloaded Flex Query windows.
+// Quantities are compared unsigned, as realized gains do not record whether
+// the closed lot was long or short.
+func ReconcileLots(closedLots []*datav1.ClosedLot, realizedGains []ibctltaxlot.RealizedGain) []*LotDivergence {
+	type closeKey struct {
+		account   string
+		symbol    string
+		closeDate string
+	}
+	type lotKey struct {
+		closeKey
+		openDate string
+	}
+	type quantities struct {
+		ibkrMicros  int64
+		ibctlMicros int64
+	}
+	reportedCloseKeys := make(map[closeKey]struct{})
+	lotQuantities := make(map[lotKey]*quantities)
+	get := func(key lotKey) *quantities {
+		q, ok := lotQuantities[key]
+		if !ok {
+			q = &quantities{}
+			lotQuantities[key] = q
+		}
+		return q
+	}
+	for _, closedLot := range closedLots {
+		key := lotKey{
+			closeKey: closeKey{
+				account:   closedLot.GetAccountId(),
+				symbol:    closedLot.GetSymbol(),
+				closeDate: protoDateString(closedLot.GetCloseDate()),
+			},
+			openDate: protoDateString(closedLot.GetOpenDate()),
+		}
+		reportedCloseKeys[key.closeKey] = struct{}{}
+		get(key).ibkrMicros += absMicros(mathpb.ToMicros(closedLot.GetQuantity()))
+	}
+	for _, realizedGain := range realizedGains {
+		key := lotKey{
+			closeKey: closeKey{
+				account:   realizedGain.AccountAlias,
+				symbol:    realizedGain.Symbol,
+				closeDate: realizedGain.CloseDate.String(),
+			},
+			openDate: realizedGain.OpenDate.String(),
+		}
+		if _, ok := reportedCloseKeys[key.closeKey]; !ok {
+			continue
+		}
+		get(key).ibctlMicros += realizedGain.QuantityMicros
+	}
+	var lotDivergences []*LotDivergence
+	for key, q := range lotQuantities {
+		if q.ibkrMicros == q.ibctlMicros {
+			continue
+		}
+		lotDivergences = append(lotDivergences, &LotDivergence{
+			Account:       key.account,
+			Symbol:        key.symbol,
+			CloseDate:     key.closeDate,
+			OpenDate:      key.openDate,
+			IBKRQuantity:  microsToString(q.ibkrMicros),
+			IbctlQuantity: microsToString(q.ibctlMicros),
+		})
+	}
+	// Sort by account, close date, symbol, then open date for deterministic output.
+	sort.Slice(lotDivergences, func(i, j int) bool {
+		a, b := lotDivergences[i], lotDivergences[j]
+		if a.Account != b.Account {
+			return a.Account < b.Account
+		}
+		if a.CloseDate != b.CloseDate {
+			return a.CloseDate < b.CloseDate
+		}
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		return a.OpenDate < b.OpenDate
+	})
+	return lotDivergences
+}
+
+// ReconcileRealized compares IBKR's FIFO realized P/L of each trade with the
+// sum of the realized gains ibctl's FIFO computation attributes to the trade,
+// and returns every trade where they differ by more than one cent.
+//
+// Only trades with an IBKR realized P/L are compared, which are the trades
+// downloaded through the Flex Query. Cash and FX trades are skipped, since
+// they close no lots.
+func ReconcileRealized(trades []*datav1.Trade, realizedGains []ibctltaxlot.RealizedGain) []*RealizedDivergence {
+	type tradeKey struct {
+		account string
+		tradeID string
+	}
+	ibctlGainMicros := make(map[tradeKey]int64)
+	for _, realizedGain := range realizedGains {
+		ibctlGainMicros[tradeKey{account: realizedGain.AccountAlias, tradeID: realizedGain.TradeID}] += realizedGain.GainMicros
+	}
+	var realizedDivergences []*RealizedDivergence
+	for _, trade := range trades {
+		if trade.GetFifoPnlRealized() == nil || trade.GetAssetCategory() == assetCategoryCash {
+			continue
+		}
+		ibkrMicros := moneypb.MoneyToMicros(trade.GetFifoPnlRealized())
+		ibctlMicros := ibctlGainMicros[tradeKey{account: trade.GetAccountId(), tradeID: trade.GetTradeId()}]
+		if absMicros(ibctlMicros-ibkrMicros) <= realizedToleranceMicros {
+			continue
+		}
+		realizedDivergences = append(realizedDivergences, &RealizedDivergence{
+			Account:       trade.GetAccountId(),
+			Symbol:        trade.GetSymbol(),
+			TradeID:       trade.GetTradeId(),
+			Date:          protoDateString(trade.GetTradeDate()),
+			Currency:      trade.GetCurrencyCode(),
+			IBKRRealized:  microsToString(ibkrMicros),
+			IbctlRealized: microsToString(ibctlMicros),
+			Difference:    microsToString(ibctlMicros - ibkrMicros),
+		})
+	}
+	// Sort by account, date, symbol, then trade ID for deterministic output.
+	sort.Slice(realizedDivergences, func(i, j int) bool {
+		a, b := realizedDivergences[i], realizedDivergences[j]
+		if a.Account != b.Account {
+			return a.Account < b.Account
+		}
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		return a.TradeID < b.TradeID
+	})
+	return realizedDivergences
+}
+
+// ReconcileMTM compares the Mark-to-Market Performance Summary of each
+// Activity Statement of an account with ibctl's merged data, and returns
+// every symbol and statement period that diverges.
+//
+// ibctl's quantities at the start and end of the period are computed from the
+// trades, transfers, and corporate actions before and during the period.
+// ibctl's P/L uses IBKR's prior and current prices, and is the same
+// decomposition IBKR uses, without the "Other" P/L:
+//
+//	prior quantity * (current price - prior price)
+//	+ sum over trades of (quantity * current price + proceeds)
+//	+ commissions
+//
+// P/L is only compared for stocks in the account base currency, since IBKR
+// reports P/L in the base currency and prices in the instrument currency, and
+// derivative prices exclude the contract multiplier. A P/L difference of up to
+// one currency unit is ignored to absorb rounding. Forex rows, which are cash
+// balances rather than positions, are not compared, and statements without a
+// period or a Mark-to-Market Performance Summary are skipped.
+func ReconcileMTM(
+	accountAlias string,
+	statements []*ibkractivitycsv.ActivityStatement,
+	trades []*datav1.Trade,
+	transfers []*datav1.Transfer,
+	corporateActions []*datav1.CorporateAction,
+) []*MTMDivergence {
+	var mtmDivergences []*MTMDivergence
+	for _, statement := range statements {
+		if statement.PeriodStart.IsZero() || len(statement.MTMPerformanceSummaries) == 0 {
+			continue
+		}
+		mtmDivergences = append(mtmDivergences, reconcileMTMStatement(accountAlias, statement, trades, transfers, corporateActions)...)
+	}
+	// Sort by period then symbol for deterministic output.
+	sort.SliceStable(mtmDivergences, func(i, j int) bool {
+		a, b := mtmDivergences[i], mtmDivergences[j]
+		if a.FromDate != b.FromDate {
+			return a.FromDate < b.FromDate
+		}
+		if a.ToDate != b.ToDate {
+			return a.ToDate < b.ToDate
+		}
+		return a.Symbol < b.Symbol
+	})
+	return mtmDivergences
+}
+
+// ReadPositionSnapshots reads all dated position snapshots for an account from
+// the snapshots directory, sorted by date ascending. Returns an empty slice if
+// the directory does not exist.
+func ReadPositionSnapshots(snapshotsDirPath string, accountAlias string) ([]*PositionSnapshot, error) {
+	entries, err := os.ReadDir(snapshotsDirPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading snapshots directory: %w", err)
+	}
+	var snapshots []*PositionSnapshot
+	for _, entry := range entries {
+		// Snapshot directories are named by date; skip anything else.
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", entry.Name()); err != nil {
+			continue
+		}
+		positionsPath := filepath.Join(snapshotsDirPath, entry.Name(), "positions.json")
+		positions, err := protoio.ReadMessagesJSON(positionsPath, func() *datav1.Position { return &datav1.Position{} })
+		if err != nil {
+			return nil, fmt.Errorf("reading position snapshot %s: %w", entry.Name(), err)
+		}
+		snapshots = append(snapshots, &PositionSnapshot{
+			AccountAlias: accountAlias,
+			Date:         entry.Name(),
+			Positions:    positions,
+		})
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Date < snapshots[j].Date
+	})
+	return snapshots, nil
+}
+
+// Reconcile checks each consecutive pair of snapshots against the trades,
+// transfers, and corporate actions dated between them, and returns every
+// (account, symbol, window) whose quantity change is not fully explained.
+//
+// Snapshots must all belong to the same account and be sorted by date.
+// Trades, transfers, and corporate actions may span all accounts; only
+// those for the snapshot account are considered.
+func Reconcile(
+	snapshots []*PositionSnapshot,
+	trades []*datav1.Trade,
+	transfers []*datav1.Transfer,
+	corporateActions []*datav1.CorporateAction,
+) []*Discrepancy {
+	var discrepancies []*Discrepancy
+	for i := 1; i < len(snapshots); i++ {
+		discrepancies = append(discrepancies, reconcilePair(snapshots[i-1], snapshots[i], trades, transfers, corporateActions)...)
+	}
+	return discrepancies
+}
+
+// *** PRIVATE ***
+
+// symbolChanges accumulates quantity changes in micros for a single symbol.
+type symbolChanges struct {
+	previousMicros        int64
+	tradeMicros           int64
+	transferMicros        int64
+	corporateActionMicros int64
+	actualMicros          int64
+}
+
+// reconcilePair reconciles a single previous/current snapshot pair.
+func reconcilePair(
+	previous *PositionSnapshot,
+	current *PositionSnapshot,
+	trades []*datav1.Trade,
+	transfers []*datav1.Transfer,
+	corporateActions []*datav1.CorporateAction,
+) []*Discrepancy {
+	accountAlias := current.AccountAlias
+	changes := make(map[string]*symbolChanges)
+	get := func(symbol string) *symbolChanges {
+		c, ok := changes[symbol]
+		if !ok {
+			c = &symbolChanges{}
+			changes[symbol] = c
+		}
+		return c
+	}
+	// inWindow returns true if the date is after the previous snapshot and on or before the current one.
+	inWindow := func(dateStr string) bool {
+		return dateStr > previous.Date && dateStr <= current.Date
+	}
+	for _, position := range previous.Positions {
+		get(position.GetSymbol()).previousMicros += mathpb.ToMicros(position.GetQuantity())
+	}
+	for _, position := range current.Positions {
+		get(position.GetSymbol()).actualMicros += mathpb.ToMicros(position.GetQuantity())
+	}
+	// Trade quantities are already signed (positive for buys, negative for sells).
+	for _, trade := range trades {
+		if trade.GetAccountId() != accountAlias || !inWindow(protoDateString(trade.GetTradeDate())) {
+			continue
+		}
+		get(trade.GetSymbol()).tradeMicros += mathpb.ToMicros(trade.GetQuantity())
+	}
+	// Transfer quantities are signed by direction.
+	for _, transfer := range transfers {
+		if transfer.GetAccountId() != accountAlias || !inWindow(protoDateString(transfer.GetDate())) {
+			continue
+		}
+		quantityMicros := absMicros(mathpb.ToMicros(transfer.GetQuantity()))
+		switch transfer.GetDirection() {
+		case datav1.TransferDirection_TRANSFER_DIRECTION_IN:
+			get(transfer.GetSymbol()).transferMicros += quantityMicros
+		case datav1.TransferDirection_TRANSFER_DIRECTION_OUT:
+			get(transfer.GetSymbol()).transferMicros -= quantityMicros
+		}
+	}
+	// Corporate action quantities are signed (positive for additions, negative for reductions).
+	for _, action := range corporateActions {
+		if action.GetAccountId() != accountAlias || !inWindow(protoDateString(action.GetDate())) {
+			continue
+		}
+		get(action.GetSymbol()).corporateActionMicros += mathpb.ToMicros(action.GetQuantity())
+	}
+	var discrepancies []*Discrepancy
+	for symbol, c := range changes {
+		expectedMicros := c.previousMicros + c.tradeMicros + c.transferMicros + c.corporateActionMicros
+		if expectedMicros == c.actualMicros {
+			continue
+		}
+		discrepancies = append(discrepancies, &Discrepancy{
+			Account:                 accountAlias,
+			Symbol:                  symbol,
+			FromDate:                previous.Date,
+			ToDate:                  current.Date,
+			PreviousQuantity:        microsToString(c.previousMicros),
+			TradeQuantity:           microsToString(c.tradeMicros),
+			TransferQuantity:        microsToString(c.transferMicros),
+			CorporateActionQuantity: microsToString(c.corporateActionMicros),
+			ExpectedQuantity:        microsToString(expectedMicros),
+			ActualQuantity:          microsToString(c.actualMicros),
+			UnexplainedQuantity:     microsToString(c.actualMicros - expectedMicros),
+		})
+	}
+	// Sort by symbol for deterministic output.
+	sort.Slice(discrepancies, func(i, j int) bool {
+		return discrepancies[i].Symbol < discrepancies[j].Symbol
+	})
+	return discrepancies
+}
+
+// mtmPLToleranceMicros is the P/L difference ignored as rounding (one currency unit).
+const mtmPLToleranceMicros = 1_000_000
+
+// realizedToleranceMicros is the realized P/L difference ignored as rounding (one cent).
+const realizedToleranceMicros = 10_000
+
+// assetCategoryCash is the IBKR asset category for FX conversion trades.
+const assetCategoryCash = "CASH"
+
+// symbolPeriod accumulates ibctl's quantities and trade cash flows in micros
+// for a single symbol over a statement period.
+type symbolPeriod struct {
+	priorMicros   int64
+	currentMicros int64
+	// tradeMicros is the net signed quantity of trades in the period.
+	tradeMicros int64
+	// cashMicros is the net proceeds and commissions of trades in the period.
+	cashMicros int64
+	// currencyCodes is the set of trade currencies of the symbol.
+	currencyCodes map[string]struct{}
+}
+
+// reconcileMTMStatement reconciles the Mark-to-Market Performance Summary of a single statement.
+func reconcileMTMStatement(
+	accountAlias string,
+	statement *ibkractivitycsv.ActivityStatement,
+	trades []*datav1.Trade,
+	transfers []*datav1.Transfer,
+	corporateActions []*datav1.CorporateAction,
+) []*MTMDivergence {
+	fromDate := statement.PeriodStart.Format("2006-01-02")
+	toDate := statement.PeriodEnd.Format("2006-01-02")
+	periods := make(map[string]*symbolPeriod)
+	get := func(symbol string) *symbolPeriod {
+		p, ok := periods[symbol]
+		if !ok {
+			p = &symbolPeriod{currencyCodes: make(map[string]struct{})}
+			periods[symbol] = p
+		}
+		return p
+	}
+	// addQuantity adds a quantity change on dateStr to the prior and current quantities.
+	addQuantity := func(symbol string, dateStr string, quantityMicros int64) {
+		if dateStr > toDate {
+			return
+		}
+		p := get(symbol)
+		if dateStr < fromDate {
+			p.priorMicros += quantityMicros
+		}
+		p.currentMicros += quantityMicros
+	}
+	for _, trade := range trades {
+		if trade.GetAccountId() != accountAlias || trade.GetAssetCategory() == assetCategoryCash {
+			continue
+		}
+		dateStr := protoDateString(trade.GetTradeDate())
+		quantityMicros := mathpb.ToMicros(trade.GetQuantity())
+		addQuantity(trade.GetSymbol(), dateStr, quantityMicros)
+		p := get(trade.GetSymbol())
+		p.currencyCodes[trade.GetCurrencyCode()] = struct{}{}
+		if dateStr >= fromDate && dateStr <= toDate {
+			p.tradeMicros += quantityMicros
+			p.cashMicros += moneypb.MoneyToMicros(trade.GetProceeds()) + moneypb.MoneyToMicros(trade.GetCommission())
+		}
+	}
+	for _, transfer := range transfers {
+		if transfer.GetAccountId() != accountAlias {
+			continue
+		}
+		quantityMicros := absMicros(mathpb.ToMicros(transfer.GetQuantity()))
+		switch transfer.GetDirection() {
+		case datav1.TransferDirection_TRANSFER_DIRECTION_IN:
+			addQuantity(transfer.GetSymbol(), protoDateString(transfer.GetDate()), quantityMicros)
+		case datav1.TransferDirection_TRANSFER_DIRECTION_OUT:
+			addQuantity(transfer.GetSymbol(), protoDateString(transfer.GetDate()), -quantityMicros)
+		}
+	}
+	for _, action := range corporateActions {
+		if action.GetAccountId() != accountAlias {
+			continue
+		}
+		addQuantity(action.GetSymbol(), protoDateString(action.GetDate()), mathpb.ToMicros(action.GetQuantity()))
+	}
+	var mtmDivergences []*MTMDivergence
+	reportedSymbols := make(map[string]struct{})
+	for _, summary := range statement.MTMPerformanceSummaries {
+		if summary.AssetCategory == "Forex" {
+			continue
+		}
+		reportedSymbols[summary.Symbol] = struct{}{}
+		p := get(summary.Symbol)
+		ibkrPriorMicros := mathpb.ParseMicros(summary.PriorQuantity)
+		ibkrCurrentMicros := mathpb.ParseMicros(summary.CurrentQuantity)
+		diverges := ibkrPriorMicros != p.priorMicros || ibkrCurrentMicros != p.currentMicros
+		var ibkrPL, ibctlPL string
+		if _, ok := p.currencyCodes[statement.BaseCurrency]; ok && len(p.currencyCodes) == 1 && summary.AssetCategory == "Stocks" {
+			priorPriceMicros := mathpb.ParseMicros(summary.PriorPrice)
+			currentPriceMicros := mathpb.ParseMicros(summary.CurrentPrice)
+			ibkrPLMicros := mathpb.ParseMicros(summary.PositionPL) + mathpb.ParseMicros(summary.TransactionPL) + mathpb.ParseMicros(summary.CommissionsPL)
+			ibctlPLMicros := multiplyMicros(p.priorMicros, currentPriceMicros-priorPriceMicros) +
+				multiplyMicros(p.tradeMicros, currentPriceMicros) +
+				p.cashMicros
+			ibkrPL = microsToString(ibkrPLMicros)
+			ibctlPL = microsToString(ibctlPLMicros)
+			if absMicros(ibkrPLMicros-ibctlPLMicros) > mtmPLToleranceMicros {
+				diverges = true
+			}
+		}
+		if !diverges {
+			continue
+		}
+		mtmDivergences = append(mtmDivergences, &MTMDivergence{
+			Account:              accountAlias,
+			Symbol:               summary.Symbol,
+			FromDate:             fromDate,
+			ToDate:               toDate,
+			IBKRPriorQuantity:    microsToString(ibkrPriorMicros),
+			IbctlPriorQuantity:   microsToString(p.priorMicros),
+			IBKRCurrentQuantity:  microsToString(ibkrCurrentMicros),
+			IbctlCurrentQuantity: microsToString(p.currentMicros),
+			IBKRPL:               ibkrPL,
+			IbctlPL:              ibctlPL,
+		})
+	}
+	// Symbols ibctl holds or trades in the period but IBKR does not report.
+	for symbol, p := range periods {
+		if _, ok := reportedSymbols[symbol]; ok {
+			continue
+		}
+		if p.priorMicros == 0 && p.currentMicros == 0 && p.tradeMicros == 0 {
+			continue
+		}
+		mtmDivergences = append(mtmDivergences, &MTMDivergence{
+			Account:              accountAlias,
+			Symbol:               symbol,
+			FromDate:             fromDate,
+			ToDate:               toDate,
+			IBKRPriorQuantity:    "0",
+			IbctlPriorQuantity:   microsToString(p.priorMicros),
+			IBKRCurrentQuantity:  "0",
+			IbctlCurrentQuantity: microsToString(p.currentMicros),
+		})
+	}
+	return mtmDivergences
+}
+
+// multiplyMicros returns quantity * price in micros, splitting the quantity
+// into whole units and a remainder to avoid overflow.
+func multiplyMicros(quantityMicros int64, priceMicros int64) int64 {
+	return priceMicros*(quantityMicros/1_000_000) + priceMicros*(quantityMicros%1_000_000)/1_000_000
+}
+
+// microsToString formats a micros quantity as a decimal string.
+func microsToString(micros int64) string {
+	return mathpb.ToString(mathpb.FromMicros(micros))
+}
+
+// absMicros returns the absolute value of a micros quantity.
+func absMicros(micros int64) int64 {
+	if micros < 0 {
+		return -micros
+	}
+	return micros
+}
+
+// protoDateString returns a sortable YYYY-MM-DD string from a proto Date.
+func protoDateString(d interface {
+	GetYear() uint32
+	GetMonth() uint32
+	GetDay() uint32
+}) string {
+	if d == nil {
+		return ""
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", d.GetYear(), d.GetMonth(), d.GetDay())
+}
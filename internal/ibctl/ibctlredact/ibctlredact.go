@@ -0,0 +1,162 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctlredact replaces account identifiers in exported data, so
+// outputs and archives can be shared without revealing account aliases or
+// IBKR account IDs.
+//
+// Each configured account alias is replaced by a stable pseudonym
+// ("account-1", "account-2", ...) assigned in sorted alias order, so the same
+// config always produces the same pseudonyms. The IBKR account ID of an alias
+// is replaced by the same pseudonym, and IBKR account IDs that are not in the
+// config (e.g., the other side of a transfer) are replaced by "redacted".
+package ibctlredact
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	// unknownAccountReplacement replaces IBKR account IDs that are not in the config.
+	unknownAccountReplacement = "redacted"
+	// accountIDFieldName is the proto field name of account aliases in data messages.
+	accountIDFieldName = "account_id"
+	// descriptionFieldName is the proto field name of free-form descriptions in data messages.
+	descriptionFieldName = "description"
+)
+
+var (
+	// tokenRegexp matches the tokens that are compared against aliases and
+	// account IDs. Aliases and IDs only match whole tokens, so an alias is
+	// never replaced inside a longer word.
+	tokenRegexp = regexp.MustCompile(`[A-Za-z0-9_-]+`)
+	// ibkrAccountIDRegexp matches IBKR account IDs (individual, advisor, and paper accounts).
+	ibkrAccountIDRegexp = regexp.MustCompile(`^(?:DU|DF|U|F)[0-9]{5,9}$`)
+)
+
+// Redactor replaces account aliases and IBKR account IDs with pseudonyms.
+type Redactor struct {
+	// pseudonyms maps account aliases to pseudonyms.
+	pseudonyms map[string]string
+	// replacements maps account aliases and IBKR account IDs to pseudonyms.
+	replacements map[string]string
+}
+
+// NewRedactor returns a new Redactor for the account aliases in the config,
+// which map aliases to IBKR account IDs.
+func NewRedactor(accountAliases map[string]string) *Redactor {
+	aliases := make([]string, 0, len(accountAliases))
+	for alias := range accountAliases {
+		aliases = append(aliases, alias)
+	}
+	slices.Sort(aliases)
+	pseudonyms := make(map[string]string, len(aliases))
+	replacements := make(map[string]string, 2*len(aliases))
+	for i, alias := range aliases {
+		pseudonym := fmt.Sprintf("account-%d", i+1)
+		pseudonyms[alias] = pseudonym
+		replacements[alias] = pseudonym
+		replacements[accountAliases[alias]] = pseudonym
+	}
+	return &Redactor{
+		pseudonyms:   pseudonyms,
+		replacements: replacements,
+	}
+}
+
+// Pseudonym returns the pseudonym of an account alias. Aliases that are not
+// in the config are redacted like any other text.
+func (r *Redactor) Pseudonym(alias string) string {
+	if pseudonym, ok := r.pseudonyms[alias]; ok {
+		return pseudonym
+	}
+	return r.Text(alias)
+}
+
+// Text replaces every account alias and IBKR account ID in the text.
+func (r *Redactor) Text(text string) string {
+	return tokenRegexp.ReplaceAllStringFunc(text, func(token string) string {
+		if replacement, ok := r.replacements[token]; ok {
+			return replacement
+		}
+		if ibkrAccountIDRegexp.MatchString(token) {
+			return unknownAccountReplacement
+		}
+		return token
+	})
+}
+
+// Identifies returns true if the text contains an account alias or IBKR account ID.
+func (r *Redactor) Identifies(text string) bool {
+	return r.Text(text) != text
+}
+
+// MergedData returns a redacted copy of the merged data. Account aliases are
+// replaced by pseudonyms, descriptions that identify an account are removed,
+// and account IDs in all other text are replaced. The input is not modified.
+func (r *Redactor) MergedData(mergedData *ibctlmerge.MergedData) *ibctlmerge.MergedData {
+	duplicateMatches := make([]*ibctlmerge.DuplicateMatch, len(mergedData.DuplicateMatches))
+	for i, duplicateMatch := range mergedData.DuplicateMatches {
+		redacted := *duplicateMatch
+		redacted.Account = r.Pseudonym(duplicateMatch.Account)
+		duplicateMatches[i] = &redacted
+	}
+	return &ibctlmerge.MergedData{
+		Trades:           redactMessages(r, mergedData.Trades),
+		Positions:        redactMessages(r, mergedData.Positions),
+		Transfers:        redactMessages(r, mergedData.Transfers),
+		TradeTransfers:   redactMessages(r, mergedData.TradeTransfers),
+		CorporateActions: redactMessages(r, mergedData.CorporateActions),
+		CashPositions:    redactMessages(r, mergedData.CashPositions),
+		CashTransactions: redactMessages(r, mergedData.CashTransactions),
+		DuplicateMatches: duplicateMatches,
+	}
+}
+
+// *** PRIVATE ***
+
+// redactMessages returns redacted clones of the messages.
+func redactMessages[M proto.Message](redactor *Redactor, messages []M) []M {
+	redacted := make([]M, len(messages))
+	for i, message := range messages {
+		clone := proto.Clone(message).(M)
+		redactor.redactMessage(clone.ProtoReflect())
+		redacted[i] = clone
+	}
+	return redacted
+}
+
+// redactMessage redacts the string fields of the message and its nested
+// messages in place.
+func (r *Redactor) redactMessage(message protoreflect.Message) {
+	message.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		switch {
+		case field.IsList() || field.IsMap():
+			// Data messages have no repeated or map fields with account identifiers.
+		case field.Kind() == protoreflect.MessageKind:
+			r.redactMessage(value.Message())
+		case field.Kind() == protoreflect.StringKind:
+			text := value.String()
+			switch field.Name() {
+			case accountIDFieldName:
+				message.Set(field, protoreflect.ValueOfString(r.Pseudonym(text)))
+			case descriptionFieldName:
+				if r.Identifies(text) {
+					message.Clear(field)
+				}
+			default:
+				if redacted := r.Text(text); redacted != text {
+					message.Set(field, protoreflect.ValueOfString(redacted))
+				}
+			}
+		}
+		return true
+	})
+}
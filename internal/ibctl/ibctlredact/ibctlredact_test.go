@@ -0,0 +1,54 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctlredact
+
+import (
+	"testing"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/stretchr/testify/require"
+)
+
+func TestText(t *testing.T) {
+	t.Parallel()
+	redactor := NewRedactor(map[string]string{"rrsp": "U2222222", "individual": "U1111111"})
+	for _, testCase := range []struct {
+		text     string
+		expected string
+	}{
+		{text: "individual", expected: "account-1"},
+		{text: "rrsp", expected: "account-2"},
+		{text: "data/accounts/rrsp/trades.json", expected: "data/accounts/account-2/trades.json"},
+		{text: `<FlexStatement accountId="U1111111">`, expected: `<FlexStatement accountId="account-1">`},
+		{text: "Transfer from U9876543", expected: "Transfer from redacted"},
+		{text: "rrsp-old individually", expected: "rrsp-old individually"},
+		{text: "AAPL Cash Dividend", expected: "AAPL Cash Dividend"},
+	} {
+		require.Equal(t, testCase.expected, redactor.Text(testCase.text), testCase.text)
+	}
+}
+
+func TestMergedData(t *testing.T) {
+	t.Parallel()
+	redactor := NewRedactor(map[string]string{"individual": "U1111111"})
+	mergedData := &ibctlmerge.MergedData{
+		CashTransactions: []*datav1.CashTransaction{
+			{AccountId: "individual", Description: "AAPL Cash Dividend"},
+			{AccountId: "individual", Description: "Transfer to U1111111"},
+		},
+		DuplicateMatches: []*ibctlmerge.DuplicateMatch{{Account: "individual"}},
+	}
+	redacted := redactor.MergedData(mergedData)
+	require.Equal(t, "account-1", redacted.CashTransactions[0].GetAccountId())
+	require.Equal(t, "AAPL Cash Dividend", redacted.CashTransactions[0].GetDescription())
+	require.Equal(t, "account-1", redacted.CashTransactions[1].GetAccountId())
+	require.Empty(t, redacted.CashTransactions[1].GetDescription())
+	require.Equal(t, "account-1", redacted.DuplicateMatches[0].Account)
+	// The input is not modified.
+	require.Equal(t, "individual", mergedData.CashTransactions[1].GetAccountId())
+	require.Equal(t, "Transfer to U1111111", mergedData.CashTransactions[1].GetDescription())
+	require.Equal(t, "individual", mergedData.DuplicateMatches[0].Account)
+}
@@ -0,0 +1,217 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctlremote uploads encrypted archives of the ibctl directory to
+// remote backup targets and enforces a retention policy.
+//
+// Archives are named ibctl-<YYYYMMDDTHHMMSSZ>.zip.enc so they sort by time.
+// Retention only considers objects matching that pattern, so other files
+// sharing the target are never deleted.
+package ibctlremote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/pkg/s3"
+)
+
+const (
+	// archivePrefix is the name prefix of all backup archives.
+	archivePrefix = "ibctl-"
+	// archiveSuffix is the name suffix of all backup archives.
+	archiveSuffix = ".zip.enc"
+	// archiveTimeLayout is the time layout embedded in archive names.
+	archiveTimeLayout = "20060102T150405Z"
+	// gcsEndpoint is the Google Cloud Storage S3-compatible XML API endpoint.
+	gcsEndpoint = "https://storage.googleapis.com"
+)
+
+// Target is a remote location that stores backup archives.
+type Target interface {
+	// Name returns the configured target name.
+	Name() string
+	// Put stores the archive data under the archive name.
+	Put(ctx context.Context, archiveName string, data []byte) error
+	// List returns the names of all backup archives in the target, oldest first.
+	List(ctx context.Context) ([]string, error)
+	// Delete removes the archive with the name.
+	Delete(ctx context.Context, archiveName string) error
+}
+
+// NewTarget creates a Target from a validated target configuration.
+// Credentials for s3 and gcs targets are read with getenv, and requests are
+// sent with httpClient.
+func NewTarget(config ibctlconfig.BackupTargetConfig, httpClient *http.Client, getenv func(string) string) (Target, error) {
+	switch config.Type {
+	case ibctlconfig.BackupTargetTypeS3:
+		credentials, err := readCredentials(getenv, "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY")
+		if err != nil {
+			return nil, fmt.Errorf("backup target %q: %w", config.Name, err)
+		}
+		credentials.SessionToken = getenv("AWS_SESSION_TOKEN")
+		endpoint := config.Endpoint
+		if endpoint == "" {
+			endpoint = "https://s3." + config.Region + ".amazonaws.com"
+		}
+		return newBucketTarget(config, s3.NewClient(httpClient, endpoint, config.Region, credentials)), nil
+	case ibctlconfig.BackupTargetTypeGCS:
+		credentials, err := readCredentials(getenv, "GCS_HMAC_ACCESS_KEY_ID", "GCS_HMAC_SECRET")
+		if err != nil {
+			return nil, fmt.Errorf("backup target %q: %w", config.Name, err)
+		}
+		return newBucketTarget(config, s3.NewClient(httpClient, gcsEndpoint, "auto", credentials)), nil
+	case ibctlconfig.BackupTargetTypeLocal:
+		return &localTarget{name: config.Name, dirPath: config.Path}, nil
+	default:
+		return nil, fmt.Errorf("backup target %q: unknown type %q", config.Name, config.Type)
+	}
+}
+
+// NewArchiveName returns the archive name for a backup taken at the time.
+func NewArchiveName(t time.Time) string {
+	return archivePrefix + t.UTC().Format(archiveTimeLayout) + archiveSuffix
+}
+
+// Upload stores the archive in the target, then deletes all but the newest
+// retention archives. Returns the names of the deleted archives.
+func Upload(ctx context.Context, target Target, archiveName string, data []byte, retention int) ([]string, error) {
+	if err := target.Put(ctx, archiveName, data); err != nil {
+		return nil, fmt.Errorf("uploading to %s: %w", target.Name(), err)
+	}
+	archiveNames, err := target.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing %s: %w", target.Name(), err)
+	}
+	if retention < 1 || len(archiveNames) <= retention {
+		return nil, nil
+	}
+	deleted := archiveNames[:len(archiveNames)-retention]
+	for _, name := range deleted {
+		if err := target.Delete(ctx, name); err != nil {
+			return nil, fmt.Errorf("deleting %s from %s: %w", name, target.Name(), err)
+		}
+	}
+	return deleted, nil
+}
+
+// *** PRIVATE ***
+
+// bucketTarget stores archives in an S3-compatible bucket.
+type bucketTarget struct {
+	name   string
+	bucket string
+	prefix string
+	client s3.Client
+}
+
+func newBucketTarget(config ibctlconfig.BackupTargetConfig, client s3.Client) *bucketTarget {
+	return &bucketTarget{
+		name:   config.Name,
+		bucket: config.Bucket,
+		prefix: config.Prefix,
+		client: client,
+	}
+}
+
+func (t *bucketTarget) Name() string {
+	return t.name
+}
+
+func (t *bucketTarget) Put(ctx context.Context, archiveName string, data []byte) error {
+	return t.client.PutObject(ctx, t.bucket, t.prefix+archiveName, data)
+}
+
+func (t *bucketTarget) List(ctx context.Context) ([]string, error) {
+	keys, err := t.client.ListObjects(ctx, t.bucket, t.prefix+archivePrefix)
+	if err != nil {
+		return nil, err
+	}
+	var archiveNames []string
+	for _, key := range keys {
+		if name := strings.TrimPrefix(key, t.prefix); isArchiveName(name) {
+			archiveNames = append(archiveNames, name)
+		}
+	}
+	sort.Strings(archiveNames)
+	return archiveNames, nil
+}
+
+func (t *bucketTarget) Delete(ctx context.Context, archiveName string) error {
+	return t.client.DeleteObject(ctx, t.bucket, t.prefix+archiveName)
+}
+
+// localTarget stores archives in a local directory.
+type localTarget struct {
+	name    string
+	dirPath string
+}
+
+func (t *localTarget) Name() string {
+	return t.name
+}
+
+func (t *localTarget) Put(_ context.Context, archiveName string, data []byte) error {
+	if err := os.MkdirAll(t.dirPath, 0o755); err != nil {
+		return err
+	}
+	// Write to a temporary file and rename so a partial archive is never left behind.
+	tempFilePath := filepath.Join(t.dirPath, "."+archiveName+".tmp")
+	if err := os.WriteFile(tempFilePath, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tempFilePath, filepath.Join(t.dirPath, archiveName))
+}
+
+func (t *localTarget) List(_ context.Context) ([]string, error) {
+	entries, err := os.ReadDir(t.dirPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var archiveNames []string
+	for _, entry := range entries {
+		if !entry.IsDir() && isArchiveName(entry.Name()) {
+			archiveNames = append(archiveNames, entry.Name())
+		}
+	}
+	sort.Strings(archiveNames)
+	return archiveNames, nil
+}
+
+func (t *localTarget) Delete(_ context.Context, archiveName string) error {
+	return os.Remove(filepath.Join(t.dirPath, archiveName))
+}
+
+// isArchiveName returns true if the name matches the backup archive pattern.
+func isArchiveName(name string) bool {
+	if !strings.HasPrefix(name, archivePrefix) || !strings.HasSuffix(name, archiveSuffix) {
+		return false
+	}
+	_, err := time.Parse(archiveTimeLayout, strings.TrimSuffix(strings.TrimPrefix(name, archivePrefix), archiveSuffix))
+	return err == nil
+}
+
+// readCredentials reads an access key pair from the environment.
+func readCredentials(getenv func(string) string, accessKeyIDEnvVar string, secretEnvVar string) (s3.Credentials, error) {
+	credentials := s3.Credentials{
+		AccessKeyID:     getenv(accessKeyIDEnvVar),
+		SecretAccessKey: getenv(secretEnvVar),
+	}
+	if credentials.AccessKeyID == "" || credentials.SecretAccessKey == "" {
+		return s3.Credentials{}, fmt.Errorf("%s and %s environment variables are required", accessKeyIDEnvVar, secretEnvVar)
+	}
+	return credentials, nil
+}
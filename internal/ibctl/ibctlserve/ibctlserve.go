@@ -0,0 +1,312 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctlserve implements the ibctl.service.v1.IbctlService API served
+// by "ibctl serve", and the Prometheus metrics served alongside it.
+package ibctlserve
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	servicev1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/service/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlholdings"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctltaxlot"
+	"github.com/bufdev/ibctl/internal/pkg/connecthttp"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/bufdev/ibctl/internal/pkg/promtext"
+	"github.com/bufdev/ibctl/internal/pkg/timepb"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+)
+
+const (
+	// ServiceName is the fully-qualified name of the served service.
+	ServiceName = "ibctl.service.v1.IbctlService"
+	// MetricsPath is the path of the Prometheus metrics endpoint.
+	MetricsPath = "/metrics"
+)
+
+// assetCategoryCash is the IBKR asset category for FX conversions, which are
+// not security trades.
+const assetCategoryCash = "CASH"
+
+// LoadFunc reads the config and merged data, restricted to the accounts in
+// the named account group if group is set. An unknown group should be
+// returned as a connecthttp.Error with CodeInvalidArgument.
+type LoadFunc func(ctx context.Context, group string) (*ibctlconfig.Config, *ibctlmerge.MergedData, error)
+
+// NewHandler returns a handler serving IbctlService procedures under
+// /ibctl.service.v1.IbctlService/ and Prometheus metrics at MetricsPath.
+//
+// download is called by the Download RPC. load is called by every other RPC
+// and every metrics scrape, so that each response reflects the data on disk
+// at the time of the request.
+func NewHandler(download func(context.Context) error, load LoadFunc) http.Handler {
+	service := &service{
+		download: download,
+		load:     load,
+		metrics:  newMetrics(),
+	}
+	mux := http.NewServeMux()
+	mux.Handle(
+		"/"+ServiceName+"/Download",
+		connecthttp.NewUnaryHandler(
+			func() *servicev1.DownloadRequest { return &servicev1.DownloadRequest{} },
+			instrument(service.metrics, "Download", service.Download),
+		),
+	)
+	mux.Handle(
+		"/"+ServiceName+"/GetHoldings",
+		connecthttp.NewUnaryHandler(
+			func() *servicev1.GetHoldingsRequest { return &servicev1.GetHoldingsRequest{} },
+			instrument(service.metrics, "GetHoldings", service.GetHoldings),
+		),
+	)
+	mux.Handle(
+		"/"+ServiceName+"/GetLots",
+		connecthttp.NewUnaryHandler(
+			func() *servicev1.GetLotsRequest { return &servicev1.GetLotsRequest{} },
+			instrument(service.metrics, "GetLots", service.GetLots),
+		),
+	)
+	mux.Handle(
+		"/"+ServiceName+"/GetRealizedGains",
+		connecthttp.NewUnaryHandler(
+			func() *servicev1.GetRealizedGainsRequest { return &servicev1.GetRealizedGainsRequest{} },
+			instrument(service.metrics, "GetRealizedGains", service.GetRealizedGains),
+		),
+	)
+	mux.HandleFunc("GET "+MetricsPath, service.serveMetrics)
+	return mux
+}
+
+// *** PRIVATE ***
+
+type service struct {
+	download func(context.Context) error
+	load     LoadFunc
+	metrics  *metrics
+}
+
+func (s *service) Download(ctx context.Context, _ *servicev1.DownloadRequest) (*servicev1.DownloadResponse, error) {
+	start := time.Now()
+	err := s.download(ctx)
+	s.metrics.downloadDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		s.metrics.downloads.Add(1, "error")
+		return nil, err
+	}
+	s.metrics.downloads.Add(1, "success")
+	return &servicev1.DownloadResponse{}, nil
+}
+
+func (s *service) GetHoldings(ctx context.Context, request *servicev1.GetHoldingsRequest) (*servicev1.GetHoldingsResponse, error) {
+	config, mergedData, err := s.load(ctx, request.GetGroup())
+	if err != nil {
+		return nil, err
+	}
+	fxStore := ibctlfxrates.NewStore(ibctlpath.CacheFXDirPath(config.DirPath))
+	result, err := ibctlholdings.GetHoldingsOverview(mergedData.Trades, mergedData.Positions, mergedData.CashPositions, config, fxStore)
+	s.metrics.observeFXStore(fxStore)
+	if err != nil {
+		return nil, err
+	}
+	response := &servicev1.GetHoldingsResponse{}
+	for _, h := range result.Holdings {
+		response.Holdings = append(response.Holdings, &servicev1.Holding{
+			Symbol:           h.Symbol,
+			CurrencyCode:     h.Currency,
+			LastPrice:        h.LastPrice,
+			AveragePrice:     h.AveragePrice,
+			LastPriceUsd:     h.LastPriceUSD,
+			AveragePriceUsd:  h.AveragePriceUSD,
+			MarketValueUsd:   h.MarketValueUSD,
+			UnrealizedPnlUsd: h.UnrealizedPnLUSD,
+			StcgUsd:          h.STCGUSD,
+			LtcgUsd:          h.LTCGUSD,
+			Position:         h.Position,
+			Category:         h.Category,
+			Type:             h.Type,
+			Sector:           h.Sector,
+			Geo:              h.Geo,
+		})
+	}
+	return response, nil
+}
+
+func (s *service) GetLots(ctx context.Context, request *servicev1.GetLotsRequest) (*servicev1.GetLotsResponse, error) {
+	config, mergedData, err := s.load(ctx, request.GetGroup())
+	if err != nil {
+		return nil, err
+	}
+	fxStore := ibctlfxrates.NewStore(ibctlpath.CacheFXDirPath(config.DirPath))
+	result, err := ibctlholdings.GetLotList(request.GetSymbol(), mergedData.Trades, mergedData.Positions, config, fxStore)
+	s.metrics.observeFXStore(fxStore)
+	if err != nil {
+		return nil, err
+	}
+	response := &servicev1.GetLotsResponse{}
+	for _, l := range result.Lots {
+		openDate, err := xtime.ParseDate(l.Date)
+		if err != nil {
+			return nil, fmt.Errorf("lot %s %s: %w", l.Account, l.Symbol, err)
+		}
+		protoOpenDate, err := timepb.DateToProto(openDate)
+		if err != nil {
+			return nil, err
+		}
+		response.Lots = append(response.Lots, &servicev1.Lot{
+			Symbol:          l.Symbol,
+			AccountId:       l.Account,
+			OpenDate:        protoOpenDate,
+			Quantity:        l.Quantity,
+			CurrencyCode:    l.Currency,
+			AveragePrice:    l.AveragePrice,
+			Pnl:             l.PnL,
+			Value:           l.Value,
+			AveragePriceUsd: l.AverageUSD,
+			PnlUsd:          l.PnLUSD,
+			ValueUsd:        l.ValueUSD,
+			StcgUsd:         l.STCGUSD,
+			LtcgUsd:         l.LTCGUSD,
+			Source:          l.Source,
+		})
+	}
+	return response, nil
+}
+
+func (s *service) GetRealizedGains(ctx context.Context, request *servicev1.GetRealizedGainsRequest) (*servicev1.GetRealizedGainsResponse, error) {
+	config, mergedData, err := s.load(ctx, request.GetGroup())
+	if err != nil {
+		return nil, err
+	}
+	var securityTrades []*datav1.Trade
+	for _, trade := range mergedData.Trades {
+		if trade.GetAssetCategory() == assetCategoryCash {
+			continue
+		}
+		securityTrades = append(securityTrades, trade)
+	}
+	taxLotResult, err := ibctltaxlot.ComputeTaxLotsCached(config.CacheTaxLotsDirPath(), securityTrades, config.AverageCostSymbols())
+	if err != nil {
+		return nil, err
+	}
+	response := &servicev1.GetRealizedGainsResponse{}
+	for _, realizedGain := range taxLotResult.RealizedGains {
+		openDate, err := timepb.DateToProto(realizedGain.OpenDate)
+		if err != nil {
+			return nil, err
+		}
+		closeDate, err := timepb.DateToProto(realizedGain.CloseDate)
+		if err != nil {
+			return nil, err
+		}
+		response.RealizedGains = append(response.RealizedGains, &servicev1.RealizedGain{
+			AccountId: realizedGain.AccountAlias,
+			Symbol:    realizedGain.Symbol,
+			OpenDate:  openDate,
+			CloseDate: closeDate,
+			Quantity:  mathpb.FromMicros(realizedGain.QuantityMicros),
+			Gain:      moneypb.MoneyFromMicros(realizedGain.CurrencyCode, realizedGain.GainMicros),
+			LongTerm:  config.TaxRules.IsLongTerm(realizedGain.OpenDate, realizedGain.CloseDate),
+		})
+	}
+	return response, nil
+}
+
+// serveMetrics updates the portfolio gauges from the data on disk and writes
+// all metrics in the Prometheus text format.
+func (s *service) serveMetrics(responseWriter http.ResponseWriter, request *http.Request) {
+	if err := s.updatePortfolioMetrics(request.Context()); err != nil {
+		http.Error(responseWriter, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.metrics.registry.ServeHTTP(responseWriter, request)
+}
+
+func (s *service) updatePortfolioMetrics(ctx context.Context) error {
+	config, mergedData, err := s.load(ctx, "")
+	if err != nil {
+		return err
+	}
+	fxStore := ibctlfxrates.NewStore(ibctlpath.CacheFXDirPath(config.DirPath))
+	result, err := ibctlholdings.GetHoldingsOverview(mergedData.Trades, mergedData.Positions, mergedData.CashPositions, config, fxStore)
+	s.metrics.observeFXStore(fxStore)
+	if err != nil {
+		return err
+	}
+	var marketValueMicros, unrealizedPnLMicros int64
+	for _, h := range result.Holdings {
+		marketValueMicros += mathpb.ParseMicros(h.MarketValueUSD)
+		unrealizedPnLMicros += mathpb.ParseMicros(h.UnrealizedPnLUSD)
+	}
+	s.metrics.marketValue.Set(float64(marketValueMicros) / 1e6)
+	s.metrics.unrealizedPnL.Set(float64(unrealizedPnLMicros) / 1e6)
+	accountToTrades := make(map[string]int)
+	for _, trade := range mergedData.Trades {
+		accountToTrades[trade.GetAccountId()]++
+	}
+	s.metrics.trades.Reset()
+	for account, trades := range accountToTrades {
+		s.metrics.trades.Set(float64(trades), account)
+	}
+	return nil
+}
+
+type metrics struct {
+	registry         *promtext.Registry
+	requests         *promtext.Counter
+	downloads        *promtext.Counter
+	downloadDuration *promtext.Summary
+	fxCacheLookups   *promtext.Counter
+	trades           *promtext.Gauge
+	marketValue      *promtext.Gauge
+	unrealizedPnL    *promtext.Gauge
+}
+
+func newMetrics() *metrics {
+	registry := promtext.NewRegistry()
+	return &metrics{
+		registry:         registry,
+		requests:         registry.NewCounter("ibctl_api_requests_total", "IbctlService requests by procedure and result code.", "procedure", "code"),
+		downloads:        registry.NewCounter("ibctl_downloads_total", "Downloads by result.", "result"),
+		downloadDuration: registry.NewSummary("ibctl_download_duration_seconds", "Duration of downloads."),
+		fxCacheLookups:   registry.NewCounter("ibctl_fx_cache_lookups_total", "FX rate pair lookups by whether the rates were cached in memory.", "result"),
+		trades:           registry.NewGauge("ibctl_trades", "Number of merged trades by account.", "account"),
+		marketValue:      registry.NewGauge("ibctl_portfolio_market_value_usd", "Total market value of all holdings in USD."),
+		unrealizedPnL:    registry.NewGauge("ibctl_portfolio_unrealized_pnl_usd", "Total unrealized P&L of all holdings in USD."),
+	}
+}
+
+// observeFXStore records the cache lookups of an FX store that is no longer used.
+func (m *metrics) observeFXStore(fxStore *ibctlfxrates.Store) {
+	hits, misses := fxStore.CacheStats()
+	m.fxCacheLookups.Add(float64(hits), "hit")
+	m.fxCacheLookups.Add(float64(misses), "miss")
+}
+
+// instrument wraps handle to count requests by result code.
+func instrument[Req any, Res any](
+	m *metrics,
+	procedure string,
+	handle func(context.Context, Req) (Res, error),
+) func(context.Context, Req) (Res, error) {
+	return func(ctx context.Context, request Req) (Res, error) {
+		response, err := handle(ctx, request)
+		code := "ok"
+		if err != nil {
+			code = connecthttp.CodeOf(err).String()
+		}
+		m.requests.Add(1, procedure, code)
+		return response, err
+	}
+}
@@ -0,0 +1,423 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctlstatement extracts trades from IBKR statements that are only
+// available as PDFs, best-effort, into seed data.
+//
+// Activity Statements for old years are sometimes only available as PDFs,
+// which have no structured data. The text of the Trades section is extracted
+// from the PDF, or read from a CSV conversion of it (e.g., by a PDF table
+// extraction tool), and each stock trade row becomes an ImportedTransaction
+// with low_confidence set. The trades of low-confidence transactions have the
+// "statement_extract" source, so that the resulting tax lots record that their
+// basis should be verified against the original statements.
+//
+// Extracted transactions are appended to seed/<alias>/transactions.json, and
+// are merged alongside the transactions imported from previous brokers.
+package ibctlstatement
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/big"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"buf.build/go/protovalidate"
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/bufdev/ibctl/internal/pkg/filemode"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/bufdev/ibctl/internal/pkg/pdftext"
+	"github.com/bufdev/ibctl/internal/pkg/protoio"
+	"github.com/bufdev/ibctl/internal/pkg/timepb"
+	"google.golang.org/protobuf/proto"
+)
+
+// FileName is the name of the seed transactions file within each seed account directory.
+const FileName = "transactions.json"
+
+// Source is the Trade source of low-confidence extracted transactions.
+const Source = "statement_extract"
+
+// transactionSource is the ImportedTransaction source of extracted transactions.
+const transactionSource = "ibkr_statement"
+
+// assetCategories maps the asset category headings of the Trades section to
+// whether their trades are extracted. Only stock trades are extracted, as
+// other asset categories need multipliers or FX handling that cannot be
+// reliably recovered from the text.
+var assetCategories = map[string]bool{
+	"Stocks":                   true,
+	"Equity and Index Options": false,
+	"Options":                  false,
+	"Futures":                  false,
+	"Options On Futures":       false,
+	"Forex":                    false,
+	"Bonds":                    false,
+	"Treasury Bills":           false,
+	"Warrants":                 false,
+	"CFDs":                     false,
+}
+
+var (
+	// dateRegexp matches a trade date, which is followed by a comma when the
+	// time is in the same cell.
+	dateRegexp = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}),?$`)
+	// timeRegexp matches a trade time.
+	timeRegexp = regexp.MustCompile(`^\d{1,2}:\d{2}(:\d{2})?$`)
+	// numberRegexp matches a number with optional thousands separators, and
+	// negative numbers in parentheses.
+	numberRegexp = regexp.MustCompile(`^\(?-?[\d,]*\.?\d+\)?$`)
+	// symbolRegexp matches a stock symbol, which may contain spaces (e.g., "BRK B").
+	symbolRegexp = regexp.MustCompile(`^[A-Z0-9][A-Z0-9. ]*$`)
+	// currencyRegexp matches a currency heading.
+	currencyRegexp = regexp.MustCompile(`^[A-Z]{3}$`)
+	// forexRegexp matches a currency pair symbol (e.g., "EUR.USD").
+	forexRegexp = regexp.MustCompile(`^[A-Z]{3}\.[A-Z]{3}$`)
+)
+
+// Result is the result of extracting trades from a statement.
+type Result struct {
+	// Transactions are the extracted transactions, all with low_confidence set.
+	Transactions []*datav1.ImportedTransaction
+	// Skipped are the trade rows that could not be extracted.
+	Skipped []*SkippedRow
+}
+
+// SkippedRow is a trade row that could not be extracted.
+type SkippedRow struct {
+	// Row is the text of the row.
+	Row string
+	// Reason is why the row was skipped.
+	Reason string
+}
+
+// ParsePDF extracts the stock trades of an Activity Statement PDF into
+// transactions for the account. currencyCode is the currency of trades
+// before the first currency heading.
+//
+// Returns an error if the data is not a PDF. Text that cannot be extracted,
+// such as text in fonts with custom encodings, is skipped, so a PDF without
+// any extracted trades should be converted to CSV with a PDF table extraction
+// tool and parsed with ParseCSV instead.
+func ParsePDF(data []byte, accountAlias string, currencyCode string) (*Result, error) {
+	lines, err := pdftext.ExtractLines(data)
+	if err != nil {
+		return nil, err
+	}
+	parser := newParser(accountAlias, currencyCode)
+	for _, line := range lines {
+		if err := parser.parseRow(lineToCells(line)); err != nil {
+			return nil, err
+		}
+	}
+	return parser.result, nil
+}
+
+// ParseCSV extracts the stock trades of a CSV conversion of an Activity
+// Statement into transactions for the account. currencyCode is the currency
+// of trades before the first currency heading.
+//
+// Rows are read positionally, as in the Trades section of the statement: the
+// symbol, the date and optional time, and then the quantity, the trade price,
+// and the proceeds and commission if present. Other rows are ignored.
+func ParseCSV(reader io.Reader, accountAlias string, currencyCode string) (*Result, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("reading statement CSV: %w", err)
+	}
+	parser := newParser(accountAlias, currencyCode)
+	for _, record := range records {
+		cells := make([]string, 0, len(record))
+		for _, cell := range record {
+			// Strip a UTF-8 byte order mark, which spreadsheet exports often add.
+			if cell = strings.TrimSpace(strings.TrimPrefix(cell, "\ufeff")); cell != "" {
+				cells = append(cells, cell)
+			}
+		}
+		if err := parser.parseRow(cells); err != nil {
+			return nil, err
+		}
+	}
+	return parser.result, nil
+}
+
+// WriteTransactions appends the transactions to the seed transactions of the
+// account, skipping transactions that are already in the seed data, so that
+// a statement can be imported again. Returns the number of transactions added.
+func WriteTransactions(seedDirPath string, accountAlias string, transactions []*datav1.ImportedTransaction) (int, error) {
+	accountDirPath := filepath.Join(seedDirPath, accountAlias)
+	filePath := filepath.Join(accountDirPath, FileName)
+	existing, err := protoio.ReadMessagesJSON(filePath, func() *datav1.ImportedTransaction { return &datav1.ImportedTransaction{} })
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return 0, err
+	}
+	// Only compare to the existing transactions, as a statement may have
+	// identical trades.
+	merged := existing
+	for _, transaction := range transactions {
+		if !containsTransaction(existing, transaction) {
+			merged = append(merged, transaction)
+		}
+	}
+	added := len(merged) - len(existing)
+	if added == 0 {
+		return 0, nil
+	}
+	if err := filemode.MkdirAll(accountDirPath); err != nil {
+		return 0, err
+	}
+	if err := protoio.WriteMessagesJSON(filePath, merged); err != nil {
+		return 0, err
+	}
+	return added, nil
+}
+
+// *** PRIVATE ***
+
+// parser extracts trades from the rows of a statement, tracking the asset
+// category and currency headings that precede them.
+type parser struct {
+	accountAlias string
+	// assetCategory is the current asset category heading, or empty before the first.
+	assetCategory string
+	// currencyCode is the current currency heading.
+	currencyCode string
+	result       *Result
+}
+
+func newParser(accountAlias string, currencyCode string) *parser {
+	return &parser{
+		accountAlias: accountAlias,
+		currencyCode: strings.ToUpper(currencyCode),
+		result:       &Result{},
+	}
+}
+
+// parseRow parses a row of cells.
+func (p *parser) parseRow(cells []string) error {
+	if len(cells) == 0 {
+		return nil
+	}
+	// Headings are rows with only the asset category or currency.
+	joined := strings.Join(cells, " ")
+	if _, ok := assetCategories[joined]; ok {
+		p.assetCategory = joined
+		return nil
+	}
+	if len(cells) == 1 && currencyRegexp.MatchString(joined) {
+		p.currencyCode = joined
+		return nil
+	}
+	dateIndex := -1
+	for i, cell := range cells {
+		if dateRegexp.MatchString(strings.Fields(cell)[0]) {
+			dateIndex = i
+			break
+		}
+	}
+	// Trade rows have the symbol before the date, and rows that start with
+	// the date, such as dividends and corporate actions, are not trades.
+	if dateIndex < 1 {
+		return nil
+	}
+	// Statement CSV exports have the section, asset category, and currency
+	// before the symbol in the same row.
+	symbolIndex := dateIndex - 1
+	assetCategory := p.assetCategory
+	currencyCode := p.currencyCode
+	for _, cell := range cells[:symbolIndex] {
+		if _, ok := assetCategories[cell]; ok {
+			assetCategory = cell
+		} else if currencyRegexp.MatchString(cell) {
+			currencyCode = cell
+		}
+	}
+	symbol := cells[symbolIndex]
+	if !symbolRegexp.MatchString(symbol) {
+		return nil
+	}
+	// The remaining values, with the date and time split if in one cell.
+	var values []string
+	for _, cell := range cells[dateIndex:] {
+		values = append(values, strings.Fields(cell)...)
+	}
+	date := dateRegexp.FindStringSubmatch(values[0])[1]
+	values = values[1:]
+	if len(values) > 0 && timeRegexp.MatchString(values[0]) {
+		values = values[1:]
+	}
+	var numbers []*big.Int
+	for _, value := range values {
+		if !numberRegexp.MatchString(value) {
+			break
+		}
+		number, err := parseBigMicros(value)
+		if err != nil {
+			break
+		}
+		numbers = append(numbers, number)
+	}
+	// Trades have at least a quantity and a trade price.
+	if len(numbers) < 2 {
+		return nil
+	}
+	if assetCategory != "" && !assetCategories[assetCategory] {
+		p.skip(joined, assetCategory+" trades are not extracted")
+		return nil
+	}
+	if forexRegexp.MatchString(symbol) {
+		p.skip(joined, "Forex trades are not extracted")
+		return nil
+	}
+	if currencyCode == "" {
+		p.skip(joined, "no currency heading before the row")
+		return nil
+	}
+	transaction, reason, err := p.newTransaction(joined, symbol, date, currencyCode, numbers)
+	if err != nil {
+		return err
+	}
+	if transaction == nil {
+		p.skip(joined, reason)
+		return nil
+	}
+	p.result.Transactions = append(p.result.Transactions, transaction)
+	return nil
+}
+
+// newTransaction returns the transaction for a trade row, or the reason the
+// row was skipped.
+func (p *parser) newTransaction(
+	row string,
+	symbol string,
+	date string,
+	currencyCode string,
+	numbers []*big.Int,
+) (*datav1.ImportedTransaction, string, error) {
+	quantity := numbers[0]
+	if quantity.Sign() == 0 {
+		return nil, "zero quantity", nil
+	}
+	units, remainder := new(big.Int).QuoRem(quantity, big.NewInt(1_000_000), new(big.Int))
+	if remainder.Sign() != 0 || !units.IsInt64() {
+		return nil, "fractional quantities are not supported in seed data", nil
+	}
+	price := new(big.Int).Abs(numbers[1])
+	if price.Sign() == 0 {
+		return nil, "zero trade price", nil
+	}
+	tradeDate, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return nil, "invalid date " + date, nil
+	}
+	protoDate, err := timepb.NewProtoDate(tradeDate.Year(), tradeDate.Month(), tradeDate.Day())
+	if err != nil {
+		return nil, "", err
+	}
+	protoPrice, err := moneypb.NewProtoMoney(currencyCode, mathpb.BigMicrosToString(price))
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", row, err)
+	}
+	transactionType := datav1.ImportedTransactionType_IMPORTED_TRANSACTION_TYPE_BUY
+	if quantity.Sign() < 0 {
+		transactionType = datav1.ImportedTransactionType_IMPORTED_TRANSACTION_TYPE_SELL
+	}
+	transaction := &datav1.ImportedTransaction{
+		AccountId:     p.accountAlias,
+		Date:          protoDate,
+		Type:          transactionType,
+		Symbol:        symbol,
+		IbkrSymbol:    symbol,
+		Description:   row,
+		Quantity:      units.Int64(),
+		Price:         protoPrice,
+		CurrencyCode:  currencyCode,
+		Source:        transactionSource,
+		OriginalType:  "Trades",
+		LowConfidence: true,
+	}
+	if amount := netAmount(quantity, price, numbers[2:]); amount != nil {
+		transaction.Amount, err = moneypb.NewProtoMoney(currencyCode, mathpb.BigMicrosToString(amount))
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", row, err)
+		}
+	}
+	if err := protovalidate.Validate(transaction); err != nil {
+		return nil, "", fmt.Errorf("%s: %w", row, err)
+	}
+	return transaction, "", nil
+}
+
+// skip records a skipped row.
+func (p *parser) skip(row string, reason string) {
+	p.result.Skipped = append(p.result.Skipped, &SkippedRow{Row: row, Reason: reason})
+}
+
+// lineToCells splits a PDF line into cells at whitespace, except that the
+// words before the first date are one cell, as symbols may contain spaces.
+func lineToCells(line string) []string {
+	words := strings.Fields(line)
+	for i, word := range words {
+		if i > 0 && dateRegexp.MatchString(word) {
+			return append([]string{strings.Join(words[:i], " ")}, words[i:]...)
+		}
+	}
+	return words
+}
+
+// netAmount returns the proceeds plus the commission of a trade, or nil if
+// the proceeds are not in the numbers after the quantity and trade price.
+//
+// The proceeds are the first number within 1% of the quantity times the
+// trade price with the opposite sign, which skips the closing price column if
+// present, and the commission is the number after the proceeds if it is not
+// positive.
+func netAmount(quantity *big.Int, price *big.Int, numbers []*big.Int) *big.Int {
+	// In micros: -quantity * price / 1_000_000.
+	expected := new(big.Int).Mul(quantity, price)
+	expected.Quo(expected, big.NewInt(-1_000_000))
+	tolerance := new(big.Int).Quo(new(big.Int).Abs(expected), big.NewInt(100))
+	for i, number := range numbers {
+		difference := new(big.Int).Sub(number, expected)
+		if difference.Abs(difference).Cmp(tolerance) > 0 {
+			continue
+		}
+		amount := new(big.Int).Set(number)
+		if i+1 < len(numbers) && numbers[i+1].Sign() <= 0 {
+			amount.Add(amount, numbers[i+1])
+		}
+		return amount
+	}
+	return nil
+}
+
+// parseBigMicros parses a number, ignoring thousands separators, with
+// negative numbers in parentheses.
+func parseBigMicros(value string) (*big.Int, error) {
+	value = strings.ReplaceAll(value, ",", "")
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		value = "-" + strings.TrimPrefix(strings.Trim(value, "()"), "-")
+	}
+	return mathpb.ParseBigMicros(value)
+}
+
+// containsTransaction returns true if the transactions contain the transaction.
+func containsTransaction(transactions []*datav1.ImportedTransaction, transaction *datav1.ImportedTransaction) bool {
+	for _, existing := range transactions {
+		if proto.Equal(existing, transaction) {
+			return true
+		}
+	}
+	return false
+}
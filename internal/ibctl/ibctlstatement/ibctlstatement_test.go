@@ -0,0 +1,116 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctlstatement
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCSV(t *testing.T) {
+	t.Parallel()
+	// A PDF table extraction of the Trades section, with a Total row, a
+	// fractional quantity, and a Forex subsection.
+	input := `Trades
+Symbol,Date/Time,Quantity,T. Price,C. Price,Proceeds,Comm/Fee,Basis,Realized P/L,MTM P/L,Code
+Stocks
+USD
+AAPL,"2019-03-04, 10:30:00",10,"175.50",176.00,"-1,755.00",-1.00,"1,756.00",0.00,5.00,O
+BRK B,"2019-03-05, 11:00:00",-5,200.00,201.00,"1,000.00",(1.05),-900.00,98.95,-5.00,C
+Total,,,,,"-755.00",-2.05,,,,
+CAD
+SHOP,"2019-03-06, 09:45:00",0.5,100.00,100.00,-50.00,-1.00,51.00,0.00,0.00,O
+Forex
+EUR.USD,"2019-03-07, 12:00:00","1,000",1.13,1.13,"-1,130.00",-2.00,,,0.00,
+`
+	result, err := ParseCSV(strings.NewReader(input), "individual", "")
+	require.NoError(t, err)
+	require.Len(t, result.Transactions, 2)
+
+	buy := result.Transactions[0]
+	require.Equal(t, "individual", buy.GetAccountId())
+	require.Equal(t, datav1.ImportedTransactionType_IMPORTED_TRANSACTION_TYPE_BUY, buy.GetType())
+	require.Equal(t, "AAPL", buy.GetIbkrSymbol())
+	require.Equal(t, uint32(2019), buy.GetDate().GetYear())
+	require.Equal(t, uint32(4), buy.GetDate().GetDay())
+	require.Equal(t, int64(10), buy.GetQuantity())
+	require.Equal(t, "175.5", moneypb.MoneyValueToString(buy.GetPrice()))
+	require.Equal(t, "-1756", moneypb.MoneyValueToString(buy.GetAmount()))
+	require.Equal(t, "USD", buy.GetCurrencyCode())
+	require.True(t, buy.GetLowConfidence())
+
+	sell := result.Transactions[1]
+	require.Equal(t, datav1.ImportedTransactionType_IMPORTED_TRANSACTION_TYPE_SELL, sell.GetType())
+	require.Equal(t, "BRK B", sell.GetIbkrSymbol())
+	require.Equal(t, int64(-5), sell.GetQuantity())
+	require.Equal(t, "200", moneypb.MoneyValueToString(sell.GetPrice()))
+	require.Equal(t, "998.95", moneypb.MoneyValueToString(sell.GetAmount()))
+
+	require.Len(t, result.Skipped, 2)
+	require.Equal(t, "fractional quantities are not supported in seed data", result.Skipped[0].Reason)
+	require.Equal(t, "Forex trades are not extracted", result.Skipped[1].Reason)
+}
+
+func TestParseCSVActivityStatementRows(t *testing.T) {
+	t.Parallel()
+	// Rows of an Activity Statement CSV have the asset category and currency
+	// before the symbol, and dividends are not trades.
+	input := `Trades,Data,Order,Stocks,EUR,SAP,"2019-03-04, 10:30:00",2,100,101,-200,-3,203,0,2,O
+Dividends,Data,USD,2019-03-05,AAPL Cash Dividend,7.30
+`
+	result, err := ParseCSV(strings.NewReader(input), "individual", "USD")
+	require.NoError(t, err)
+	require.Len(t, result.Transactions, 1)
+	require.Equal(t, "SAP", result.Transactions[0].GetIbkrSymbol())
+	require.Equal(t, "EUR", result.Transactions[0].GetCurrencyCode())
+	require.Equal(t, "EUR", result.Transactions[0].GetPrice().GetCurrencyCode())
+	require.Equal(t, "-203", moneypb.MoneyValueToString(result.Transactions[0].GetAmount()))
+	require.Empty(t, result.Skipped)
+}
+
+func TestParsePDF(t *testing.T) {
+	t.Parallel()
+	content := `BT 50 700 Td (Stocks) Tj ET
+BT 50 690 Td (USD) Tj ET
+BT 50 680 Td (BRK B) Tj 100 0 Td (2019-03-04,) Tj 60 0 Td (10:30:00) Tj 60 0 Td (3) Tj 40 0 Td (200.00) Tj ET`
+	var data strings.Builder
+	data.WriteString("%PDF-1.4\n")
+	fmt.Fprintf(&data, "1 0 obj\n<< /Length %d >>\nstream\n%s\nendstream\nendobj\n%%%%EOF\n", len(content), content)
+
+	result, err := ParsePDF([]byte(data.String()), "individual", "")
+	require.NoError(t, err)
+	require.Len(t, result.Transactions, 1)
+	require.Equal(t, "BRK B", result.Transactions[0].GetIbkrSymbol())
+	require.Equal(t, int64(3), result.Transactions[0].GetQuantity())
+	require.Nil(t, result.Transactions[0].GetAmount())
+
+	_, err = ParsePDF([]byte("Symbol,Date"), "individual", "")
+	require.Error(t, err)
+}
+
+func TestWriteTransactions(t *testing.T) {
+	t.Parallel()
+	input := `USD
+AAPL,2019-03-04,10,175.50
+AAPL,2019-03-04,10,175.50
+`
+	result, err := ParseCSV(strings.NewReader(input), "individual", "")
+	require.NoError(t, err)
+	require.Len(t, result.Transactions, 2)
+	seedDirPath := t.TempDir()
+	// Identical trades in a statement are both added.
+	added, err := WriteTransactions(seedDirPath, "individual", result.Transactions)
+	require.NoError(t, err)
+	require.Equal(t, 2, added)
+	// Importing the statement again adds nothing.
+	added, err = WriteTransactions(seedDirPath, "individual", result.Transactions)
+	require.NoError(t, err)
+	require.Equal(t, 0, added)
+}
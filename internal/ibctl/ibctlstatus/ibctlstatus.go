@@ -0,0 +1,430 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctlstatus reports the coverage, size, and health of an ibctl
+// directory in one table.
+//
+// The report has a row per:
+//
+//   - account: the trades and their date range, the size of the persistent
+//     data, and when the position snapshot was last downloaded.
+//   - statements: the months covered by the Activity Statement CSVs of an
+//     account, and the months missing between the first and last.
+//   - fx: the date range of an FX rate pair, and whether it starts before the
+//     first trade in its currency, or is missing or no longer used.
+//   - cache: the size of each entry of cache/, and what "ibctl data prune"
+//     would remove from it by default.
+package ibctlstatus
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	timev1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/time/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlprune"
+	"github.com/bufdev/ibctl/internal/pkg/ibkractivitycsv"
+	"github.com/bufdev/ibctl/internal/pkg/protoio"
+)
+
+const (
+	// SectionAccount is the section of the per-account rows.
+	SectionAccount = "account"
+	// SectionStatements is the section of the per-account Activity Statement coverage rows.
+	SectionStatements = "statements"
+	// SectionFX is the section of the per-pair FX rate rows.
+	SectionFX = "fx"
+	// SectionCache is the section of the cache directory rows.
+	SectionCache = "cache"
+)
+
+const (
+	// statusOK is the status of a row without problems.
+	statusOK = "ok"
+	// fxRateGapDays is the number of days an FX rate pair may start after the
+	// first trade in its currency, as rates are not published on weekends and
+	// holidays.
+	fxRateGapDays = 7
+)
+
+// Row is a row of the status report.
+type Row struct {
+	// Section is the section of the row (account, statements, fx, cache).
+	Section string `json:"section"`
+	// Name is the account alias, FX pair, or cache directory.
+	Name string `json:"name"`
+	// From is the first date (YYYY-MM-DD) or month (YYYY-MM) covered, if any.
+	From string `json:"from,omitempty"`
+	// To is the last date (YYYY-MM-DD) or month (YYYY-MM) covered, if any.
+	To string `json:"to,omitempty"`
+	// Count is the number of trades for accounts, months present for
+	// statements, rates for FX pairs, and files for cache directories.
+	Count int `json:"count"`
+	// Bytes is the size on disk.
+	Bytes int64 `json:"bytes"`
+	// Status is "ok" or a description of the problem.
+	Status string `json:"status"`
+}
+
+// Headers returns the column headers for table/CSV output.
+func Headers() []string {
+	return []string{"SECTION", "NAME", "FROM", "TO", "COUNT", "SIZE", "STATUS"}
+}
+
+// ToRow converts a Row to a string slice for CSV output, with the size in bytes.
+func ToRow(r *Row) []string {
+	return []string{r.Section, r.Name, r.From, r.To, strconv.Itoa(r.Count), strconv.FormatInt(r.Bytes, 10), r.Status}
+}
+
+// ToTableRow converts a Row to a string slice for table output, with a
+// human-readable size.
+func ToTableRow(r *Row) []string {
+	return []string{r.Section, r.Name, r.From, r.To, strconv.Itoa(r.Count), ibctlprune.FormatSize(r.Bytes), r.Status}
+}
+
+// GetStatus returns the status report of the ibctl directory of the config,
+// as of now. mergedData must have the trades, positions, and cash records.
+func GetStatus(config *ibctlconfig.Config, mergedData *ibctlmerge.MergedData, now time.Time) ([]*Row, error) {
+	var rows []*Row
+	for _, f := range []func(*ibctlconfig.Config, *ibctlmerge.MergedData, time.Time) ([]*Row, error){
+		accountRows,
+		statementRows,
+		fxRows,
+		cacheRows,
+	} {
+		sectionRows, err := f(config, mergedData, now)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, sectionRows...)
+	}
+	return rows, nil
+}
+
+// *** PRIVATE ***
+
+// accountRows returns the trades, data size, and last download of each account.
+func accountRows(config *ibctlconfig.Config, mergedData *ibctlmerge.MergedData, now time.Time) ([]*Row, error) {
+	rows := make(map[string]*Row, len(config.AccountAliases))
+	for alias := range config.AccountAliases {
+		rows[alias] = &Row{Section: SectionAccount, Name: alias}
+	}
+	for _, trade := range mergedData.Trades {
+		row, ok := rows[trade.GetAccountId()]
+		if !ok {
+			continue
+		}
+		row.Count++
+		row.From, row.To = extendRange(row.From, row.To, dateString(trade.GetTradeDate()))
+	}
+	var result []*Row
+	for _, alias := range slices.Sorted(maps.Keys(rows)) {
+		row := rows[alias]
+		bytes, err := diskUsage(ibctlpath.DataAccountDirPath(config.DirPath, alias))
+		if err != nil {
+			return nil, err
+		}
+		row.Bytes = bytes
+		row.Status, err = downloadStatus(config, alias, now)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	return result, nil
+}
+
+// downloadStatus returns when the position snapshot of the account was last
+// downloaded, flagged as stale if older than config.SnapshotMaxAge.
+func downloadStatus(config *ibctlconfig.Config, alias string, now time.Time) (string, error) {
+	metadata := &datav1.Metadata{}
+	if err := protoio.ReadMessageJSON(ibctlpath.CacheAccountMetadataFilePath(config.DirPath, alias), metadata); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "never downloaded", nil
+		}
+		return "", err
+	}
+	if metadata.GetDownloadTime() == nil {
+		return "never downloaded", nil
+	}
+	downloadTime := metadata.GetDownloadTime().AsTime()
+	age := now.Sub(downloadTime).Round(time.Minute)
+	status := fmt.Sprintf("downloaded %s, %s ago", downloadTime.Local().Format(time.DateTime), age)
+	if age > config.SnapshotMaxAge {
+		return "stale, " + status, nil
+	}
+	return status, nil
+}
+
+// statementRows returns the months covered by the Activity Statement CSVs of
+// each account that has any.
+func statementRows(config *ibctlconfig.Config, _ *ibctlmerge.MergedData, _ time.Time) ([]*Row, error) {
+	var rows []*Row
+	for _, alias := range slices.Sorted(maps.Keys(config.AccountAliases)) {
+		csvDirPath := filepath.Join(ibctlpath.ActivityStatementsDirPath(config.DirPath), alias)
+		statements, err := ibkractivitycsv.ParseDirectoryWithCache(
+			csvDirPath,
+			filepath.Join(ibctlpath.CacheActivityStatementsDirPath(config.DirPath), alias),
+		)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		if len(statements) == 0 {
+			continue
+		}
+		bytes, err := diskUsage(csvDirPath)
+		if err != nil {
+			return nil, err
+		}
+		row := &Row{Section: SectionStatements, Name: alias, Bytes: bytes}
+		months := make(map[string]struct{})
+		for _, statement := range statements {
+			if statement.PeriodStart.IsZero() {
+				continue
+			}
+			for month := firstOfMonth(statement.PeriodStart); !month.After(statement.PeriodEnd); month = month.AddDate(0, 1, 0) {
+				months[month.Format("2006-01")] = struct{}{}
+			}
+		}
+		row.Count = len(months)
+		if row.Count == 0 {
+			row.Status = "no statement periods"
+			rows = append(rows, row)
+			continue
+		}
+		sortedMonths := slices.Sorted(maps.Keys(months))
+		row.From, row.To = sortedMonths[0], sortedMonths[len(sortedMonths)-1]
+		row.Status = statusOK
+		if missing := missingMonths(months, row.From, row.To); len(missing) > 0 {
+			row.Status = "missing " + strings.Join(missing, ", ")
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// missingMonths returns the months between from and to (YYYY-MM) that are
+// not in months, with consecutive months collapsed into ranges
+// (e.g., "2021-03 to 2021-05").
+func missingMonths(months map[string]struct{}, from string, to string) []string {
+	fromMonth, err := time.Parse("2006-01", from)
+	if err != nil {
+		return nil
+	}
+	var missing []string
+	var rangeStart, rangeEnd string
+	flush := func() {
+		switch {
+		case rangeStart == "":
+		case rangeStart == rangeEnd:
+			missing = append(missing, rangeStart)
+		default:
+			missing = append(missing, rangeStart+" to "+rangeEnd)
+		}
+		rangeStart, rangeEnd = "", ""
+	}
+	for month := fromMonth; month.Format("2006-01") <= to; month = month.AddDate(0, 1, 0) {
+		monthString := month.Format("2006-01")
+		if _, ok := months[monthString]; ok {
+			flush()
+			continue
+		}
+		if rangeStart == "" {
+			rangeStart = monthString
+		}
+		rangeEnd = monthString
+	}
+	flush()
+	return missing
+}
+
+// fxRows returns the date range of each FX rate pair in the cache, and the
+// pairs "ibctl download" would fetch that are missing.
+func fxRows(config *ibctlconfig.Config, mergedData *ibctlmerge.MergedData, _ time.Time) ([]*Row, error) {
+	// The first trade date in each currency, for the pairs to cover.
+	firstTradeDates := make(map[string]string)
+	for _, trade := range mergedData.Trades {
+		currencyCode := trade.GetCurrencyCode()
+		date := dateString(trade.GetTradeDate())
+		if firstTradeDate, ok := firstTradeDates[currencyCode]; currencyCode != "" && (!ok || date < firstTradeDate) {
+			firstTradeDates[currencyCode] = date
+		}
+	}
+	expectedPairs := fxPairs(firstTradeDates)
+	fxDirPath := ibctlpath.CacheFXDirPath(config.DirPath)
+	entries, err := os.ReadDir(fxDirPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	rows := make(map[string]*Row)
+	for _, entry := range entries {
+		base, _, ok := strings.Cut(entry.Name(), ".")
+		if !entry.IsDir() || !ok {
+			continue
+		}
+		pairDirPath := filepath.Join(fxDirPath, entry.Name())
+		rates, err := protoio.ReadMessagesJSON(
+			filepath.Join(pairDirPath, "rates.json"),
+			func() *datav1.ExchangeRate { return &datav1.ExchangeRate{} },
+		)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		bytes, err := diskUsage(pairDirPath)
+		if err != nil {
+			return nil, err
+		}
+		row := &Row{Section: SectionFX, Name: entry.Name(), Count: len(rates), Bytes: bytes}
+		for _, rate := range rates {
+			row.From, row.To = extendRange(row.From, row.To, dateString(rate.GetDate()))
+		}
+		_, expected := expectedPairs[entry.Name()]
+		firstTradeDate := firstTradeDates[base]
+		switch {
+		case !expected:
+			row.Status = "not used by any trade"
+		case len(rates) == 0:
+			row.Status = "no rates, run \"ibctl download\""
+		case firstTradeDate != "" && row.From > addDays(firstTradeDate, fxRateGapDays):
+			row.Status = fmt.Sprintf("starts after the first %s trade on %s", base, firstTradeDate)
+		default:
+			row.Status = statusOK
+		}
+		rows[entry.Name()] = row
+	}
+	for pair := range expectedPairs {
+		if _, ok := rows[pair]; !ok {
+			rows[pair] = &Row{Section: SectionFX, Name: pair, Status: "missing, run \"ibctl download\""}
+		}
+	}
+	result := make([]*Row, 0, len(rows))
+	for _, pair := range slices.Sorted(maps.Keys(rows)) {
+		result = append(result, rows[pair])
+	}
+	return result, nil
+}
+
+// fxPairs returns the FX pairs "ibctl download" fetches for the currencies:
+// X.USD for each currency other than USD, X.CAD for each currency other than
+// CAD, and USD.CAD, or no pairs if all trades are in USD.
+func fxPairs(currencies map[string]string) map[string]struct{} {
+	pairs := make(map[string]struct{})
+	for currency := range currencies {
+		if currency == "USD" {
+			continue
+		}
+		pairs[currency+".USD"] = struct{}{}
+		if currency != "CAD" {
+			pairs[currency+".CAD"] = struct{}{}
+		}
+		pairs["USD.CAD"] = struct{}{}
+	}
+	return pairs
+}
+
+// cacheRows returns the size of each entry of cache/, and what "ibctl data
+// prune" would remove from it with the default age.
+func cacheRows(config *ibctlconfig.Config, mergedData *ibctlmerge.MergedData, now time.Time) ([]*Row, error) {
+	result, err := ibctlprune.Scan(
+		config.DirPath,
+		config.AccountAliases,
+		mergedData,
+		config.TaxBaseCurrency,
+		now.Add(-ibctlprune.DefaultAge),
+		false,
+	)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]*Row, 0, len(result.Usages))
+	for _, usage := range result.Usages {
+		row := &Row{
+			Section: SectionCache,
+			Name:    usage.Directory,
+			Count:   usage.Files,
+			Bytes:   usage.Bytes,
+			Status:  statusOK,
+		}
+		if usage.PrunableFiles > 0 {
+			row.Status = fmt.Sprintf("%d files (%s) prunable, run \"ibctl data prune\"", usage.PrunableFiles, ibctlprune.FormatSize(usage.PrunableBytes))
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// extendRange returns the range from from to to extended to include date.
+// Empty bounds are unset.
+func extendRange(from string, to string, date string) (string, string) {
+	if date == "" {
+		return from, to
+	}
+	if from == "" || date < from {
+		from = date
+	}
+	if to == "" || date > to {
+		to = date
+	}
+	return from, to
+}
+
+// addDays returns the date (YYYY-MM-DD) n days after date, or date if it is invalid.
+func addDays(date string, n int) string {
+	t, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return date
+	}
+	return t.AddDate(0, 0, n).Format(time.DateOnly)
+}
+
+// dateString formats a proto Date as YYYY-MM-DD, or returns "" if unset.
+func dateString(date *timev1.Date) string {
+	if date == nil {
+		return ""
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", date.GetYear(), date.GetMonth(), date.GetDay())
+}
+
+// firstOfMonth returns the first day of the month of t.
+func firstOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// diskUsage returns the total size of the regular files at the path,
+// recursively, or 0 if it does not exist.
+func diskUsage(path string) (int64, error) {
+	var bytes int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		bytes += info.Size()
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	return bytes, err
+}
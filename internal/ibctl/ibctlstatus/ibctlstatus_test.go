@@ -0,0 +1,123 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctlstatus
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	timev1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/time/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/pkg/protoio"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestGetStatus(t *testing.T) {
+	t.Parallel()
+	dirPath := t.TempDir()
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	config := &ibctlconfig.Config{
+		DirPath:         dirPath,
+		AccountAliases:  map[string]string{"individual": "U1111111", "rrsp": "U2222222"},
+		TaxBaseCurrency: "USD",
+		SnapshotMaxAge:  72 * time.Hour,
+	}
+	// The individual account was downloaded an hour ago, and the rrsp account never.
+	require.NoError(t, os.MkdirAll(ibctlpath.CacheAccountDirPath(dirPath, "individual"), 0o755))
+	require.NoError(t, protoio.WriteMessageJSON(
+		ibctlpath.CacheAccountMetadataFilePath(dirPath, "individual"),
+		&datav1.Metadata{DownloadTime: timestamppb.New(now.Add(-time.Hour))},
+	))
+	// Statements for Q1 2021 and May 2021, missing April.
+	csvDirPath := filepath.Join(ibctlpath.ActivityStatementsDirPath(dirPath), "individual")
+	require.NoError(t, os.MkdirAll(csvDirPath, 0o755))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(csvDirPath, "q1.csv"),
+		[]byte("Statement,Data,Period,\"January 1, 2021 - March 31, 2021\"\n"),
+		0o644,
+	))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(csvDirPath, "may.csv"),
+		[]byte("Statement,Data,Period,\"May 1, 2021 - May 31, 2021\"\n"),
+		0o644,
+	))
+	// EUR.USD starts after the first EUR trade, and GBP.USD is not used.
+	writeRates := func(pair string, dates ...*timev1.Date) {
+		rates := make([]*datav1.ExchangeRate, 0, len(dates))
+		for _, date := range dates {
+			rates = append(rates, &datav1.ExchangeRate{Date: date, Provider: "test"})
+		}
+		pairDirPath := filepath.Join(ibctlpath.CacheFXDirPath(dirPath), pair)
+		require.NoError(t, os.MkdirAll(pairDirPath, 0o755))
+		require.NoError(t, protoio.WriteMessagesJSON(filepath.Join(pairDirPath, "rates.json"), rates))
+	}
+	writeRates("EUR.USD", newDate(2021, 6, 1), newDate(2026, 5, 29))
+	writeRates("EUR.CAD", newDate(2021, 3, 1), newDate(2026, 5, 29))
+	writeRates("GBP.USD", newDate(2021, 3, 1))
+
+	mergedData := &ibctlmerge.MergedData{
+		Trades: []*datav1.Trade{
+			{AccountId: "individual", TradeDate: newDate(2021, 3, 5), CurrencyCode: "EUR"},
+			{AccountId: "individual", TradeDate: newDate(2021, 2, 1), CurrencyCode: "USD"},
+			{AccountId: "individual", TradeDate: newDate(2022, 7, 1), CurrencyCode: "USD"},
+		},
+	}
+	rows, err := GetStatus(config, mergedData, now)
+	require.NoError(t, err)
+	rowsByName := make(map[string]*Row)
+	for _, row := range rows {
+		rowsByName[row.Section+" "+row.Name] = row
+	}
+
+	individual := rowsByName["account individual"]
+	require.Equal(t, 3, individual.Count)
+	require.Equal(t, "2021-02-01", individual.From)
+	require.Equal(t, "2022-07-01", individual.To)
+	require.Contains(t, individual.Status, "1h0m0s ago")
+	require.NotContains(t, individual.Status, "stale")
+	require.Equal(t, "never downloaded", rowsByName["account rrsp"].Status)
+
+	statements := rowsByName["statements individual"]
+	require.Equal(t, 4, statements.Count)
+	require.Equal(t, "2021-01", statements.From)
+	require.Equal(t, "2021-05", statements.To)
+	require.Equal(t, "missing 2021-04", statements.Status)
+	require.NotContains(t, rowsByName, "statements rrsp")
+
+	require.Equal(t, "starts after the first EUR trade on 2021-03-05", rowsByName["fx EUR.USD"].Status)
+	require.Equal(t, 2, rowsByName["fx EUR.USD"].Count)
+	require.Equal(t, statusOK, rowsByName["fx EUR.CAD"].Status)
+	require.Equal(t, "not used by any trade", rowsByName["fx GBP.USD"].Status)
+	require.Equal(t, "missing, run \"ibctl download\"", rowsByName["fx USD.CAD"].Status)
+
+	require.Equal(t, 3, rowsByName["cache cache/fx"].Count)
+	require.Equal(t, statusOK, rowsByName["cache cache/activity_statements"].Status)
+}
+
+func TestMissingMonths(t *testing.T) {
+	t.Parallel()
+	months := map[string]struct{}{
+		"2020-11": {},
+		"2021-02": {},
+		"2021-04": {},
+		"2021-08": {},
+	}
+	require.Equal(
+		t,
+		[]string{"2020-12 to 2021-01", "2021-03", "2021-05 to 2021-07"},
+		missingMonths(months, "2020-11", "2021-08"),
+	)
+	require.Empty(t, missingMonths(map[string]struct{}{"2021-01": {}}, "2021-01", "2021-01"))
+}
+
+func newDate(year uint32, month uint32, day uint32) *timev1.Date {
+	return &timev1.Date{Year: year, Month: month, Day: day}
+}
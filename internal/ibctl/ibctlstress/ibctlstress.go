@@ -0,0 +1,352 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctlstress applies scenario shocks to holdings for stress testing.
+//
+// A shock is a percentage change keyed by a category (e.g., "EQUITY"), sector
+// (e.g., "TECH"), or trading currency (e.g., "CAD"), or by a currency pair
+// against USD (e.g., "USD.CAD"). A holding is shocked by every key that
+// matches it, and the shocks compound. Sector shocks are split by the symbol's
+// sector look-through weights, and currency pair shocks by the symbol's
+// currencies look-through, so a TECH shock hits 25% of an ETF that is 25% TECH.
+//
+// A currency pair shock is the change in the price of the first currency in
+// the second, so "USD.CAD:+10%" (USD strengthens against CAD) lowers the USD
+// value of CAD holdings and cash by 1/1.1, and "CAD.USD:+10%" raises it by 10%.
+package ibctlstress
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlholdings"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+)
+
+// cashCategory is the category ibctlholdings assigns to cash holdings.
+const cashCategory = "CASH"
+
+// Shock is a percentage change applied to the holdings matching a key.
+type Shock struct {
+	// Key is an upper-case category, sector, or currency code (e.g., "EQUITY",
+	// "TECH", "CAD"), or a currency pair against USD (e.g., "USD.CAD").
+	Key string `json:"key"`
+	// Percent is the change in percent (e.g., -20 for a 20% drop).
+	Percent float64 `json:"percent"`
+}
+
+// ParseShocks parses a comma-separated list of shocks of the form KEY:PERCENT
+// (e.g., "EQUITY:-20%,BOND:-5%,USD.CAD:+10%"). The "%" is optional.
+func ParseShocks(s string) ([]Shock, error) {
+	var shocks []Shock
+	seenKeys := make(map[string]struct{})
+	seenPairCurrencies := make(map[string]struct{})
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, ":")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		value = strings.TrimSuffix(strings.TrimSpace(value), "%")
+		if !ok || key == "" || value == "" {
+			return nil, fmt.Errorf("invalid shock %q, must be KEY:PERCENT (e.g., EQUITY:-20%%)", part)
+		}
+		percent, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(percent) || math.IsInf(percent, 0) {
+			return nil, fmt.Errorf("invalid shock %q, percent must be a number", part)
+		}
+		if percent <= -100 {
+			return nil, fmt.Errorf("invalid shock %q, percent must be greater than -100", part)
+		}
+		if _, ok := seenKeys[key]; ok {
+			return nil, fmt.Errorf("duplicate shock for %s", key)
+		}
+		seenKeys[key] = struct{}{}
+		if strings.Contains(key, ".") {
+			currency, _, err := parsePair(key)
+			if err != nil {
+				return nil, fmt.Errorf("invalid shock %q: %w", part, err)
+			}
+			if _, ok := seenPairCurrencies[currency]; ok {
+				return nil, fmt.Errorf("duplicate currency pair shock for %s", currency)
+			}
+			seenPairCurrencies[currency] = struct{}{}
+		}
+		shocks = append(shocks, Shock{Key: key, Percent: percent})
+	}
+	if len(shocks) == 0 {
+		return nil, errors.New("at least one shock is required")
+	}
+	return shocks, nil
+}
+
+// Result is the outcome of applying shocks to holdings.
+type Result struct {
+	// ValueUSD is the total market value before the shocks in USD.
+	ValueUSD string `json:"value_usd"`
+	// StressedValueUSD is the total market value after the shocks in USD.
+	StressedValueUSD string `json:"stressed_value_usd"`
+	// ChangeUSD is the change in total market value in USD, which is also the
+	// change in P&L, since cost basis is unaffected.
+	ChangeUSD string `json:"change_usd"`
+	// ChangePct is the change in total market value as a percentage (e.g., "-12.34%").
+	ChangePct string `json:"change_pct"`
+	// UnrealizedPnLUSD is the unrealized P&L of securities before the shocks in USD.
+	UnrealizedPnLUSD string `json:"unrealized_pnl_usd"`
+	// StressedUnrealizedPnLUSD is the unrealized P&L of securities after the shocks in USD.
+	StressedUnrealizedPnLUSD string `json:"stressed_unrealized_pnl_usd"`
+	// Allocations is the allocation before and after the shocks, sorted by name.
+	Allocations []*AllocationShift `json:"allocations"`
+	// UnmatchedKeys is the shock keys that matched no holding.
+	UnmatchedKeys []string `json:"unmatched_keys,omitempty"`
+}
+
+// AllocationShift is the value and allocation of one classification value
+// before and after the shocks.
+type AllocationShift struct {
+	// Name is the classification value (e.g., "EQUITY" or "TECH").
+	Name string `json:"name"`
+	// MarketValueUSD is the market value before the shocks in USD.
+	MarketValueUSD string `json:"market_value_usd"`
+	// StressedValueUSD is the market value after the shocks in USD.
+	StressedValueUSD string `json:"stressed_value_usd"`
+	// ChangeUSD is the change in market value in USD.
+	ChangeUSD string `json:"change_usd"`
+	// NetLiqPct is the percentage of net liq before the shocks (e.g., "45.23%").
+	NetLiqPct string `json:"net_liq_pct"`
+	// StressedNetLiqPct is the percentage of net liq after the shocks.
+	StressedNetLiqPct string `json:"stressed_net_liq_pct"`
+	// ShiftPct is the change in percentage of net liq in percentage points (e.g., "-2.10%").
+	ShiftPct string `json:"shift_pct"`
+}
+
+// AllocationShiftHeaders returns the column headers for stress output, naming
+// the first column after the classification.
+func AllocationShiftHeaders(classification ibctlholdings.Classification) []string {
+	return []string{strings.ToUpper(string(classification)), "MKT VAL USD", "STRESSED USD", "CHANGE USD", "NET LIQ %", "STRESSED %", "SHIFT"}
+}
+
+// AllocationShiftToRow converts an AllocationShift to a string slice for CSV output.
+func AllocationShiftToRow(a *AllocationShift) []string {
+	return []string{
+		a.Name,
+		a.MarketValueUSD,
+		a.StressedValueUSD,
+		a.ChangeUSD,
+		a.NetLiqPct,
+		a.StressedNetLiqPct,
+		a.ShiftPct,
+	}
+}
+
+// AllocationShiftToTableRow converts an AllocationShift to a string slice for table display.
+func AllocationShiftToTableRow(a *AllocationShift) []string {
+	return []string{
+		a.Name,
+		cliio.FormatUSD(a.MarketValueUSD),
+		cliio.FormatUSD(a.StressedValueUSD),
+		cliio.FormatUSD(a.ChangeUSD),
+		a.NetLiqPct,
+		a.StressedNetLiqPct,
+		a.ShiftPct,
+	}
+}
+
+// Apply applies the shocks to the holdings, and reports the allocation by the
+// classification before and after the shocks. Holdings without a USD market
+// value are skipped.
+func Apply(
+	holdings []*ibctlholdings.HoldingOverview,
+	shocks []Shock,
+	classification ibctlholdings.Classification,
+	config *ibctlconfig.Config,
+) (*Result, error) {
+	percents := make(map[string]float64, len(shocks))
+	// pairFactors maps a currency to the factor applied to its USD value.
+	pairFactors := make(map[string]float64)
+	for _, shock := range shocks {
+		if strings.Contains(shock.Key, ".") {
+			currency, inverse, err := parsePair(shock.Key)
+			if err != nil {
+				return nil, err
+			}
+			factor := 1 + shock.Percent/100
+			if inverse {
+				factor = 1 / factor
+			}
+			pairFactors[currency] = factor
+			continue
+		}
+		percents[shock.Key] = shock.Percent
+	}
+	matchedKeys := make(map[string]struct{})
+	type allocationData struct {
+		valueMicros         int64
+		stressedValueMicros int64
+	}
+	allocationMap := make(map[string]*allocationData)
+	var totalMicros, totalStressedMicros, pnlMicros, stressedPnLMicros int64
+	for _, h := range holdings {
+		if h.MarketValueUSD == "" {
+			continue
+		}
+		valueMicros := mathpb.ParseMicros(h.MarketValueUSD)
+		factor := 1.0
+		// Category and trading currency shocks apply to the whole holding.
+		for _, key := range []string{h.Category, h.Currency} {
+			if percent, ok := percents[strings.ToUpper(key)]; ok && key != "" {
+				factor *= 1 + percent/100
+				matchedKeys[strings.ToUpper(key)] = struct{}{}
+			}
+		}
+		// Sector shocks apply to the sector look-through weights, if any.
+		if h.Category != cashCategory {
+			var sectorPercent float64
+			var sectorMatched bool
+			for sector, weight := range ibctlholdings.ClassificationSector.Weights(h, config.Lookthroughs) {
+				if percent, ok := percents[strings.ToUpper(sector)]; ok {
+					sectorPercent += weight * percent
+					sectorMatched = true
+					matchedKeys[strings.ToUpper(sector)] = struct{}{}
+				}
+			}
+			if sectorMatched {
+				factor *= 1 + sectorPercent/100
+			}
+		}
+		// Currency pair shocks apply to the currencies look-through weights, if any.
+		if len(pairFactors) > 0 {
+			currencies := map[string]float64{h.Currency: 1}
+			if h.Category != cashCategory && len(config.SymbolConfigs[h.Symbol].Currencies) > 0 {
+				currencies = config.SymbolConfigs[h.Symbol].Currencies
+			}
+			var pairFactor float64
+			for currency, weight := range currencies {
+				currencyFactor, ok := pairFactors[currency]
+				if !ok {
+					currencyFactor = 1
+				}
+				pairFactor += weight * currencyFactor
+			}
+			factor *= pairFactor
+		}
+		stressedMicros := int64(math.Round(float64(valueMicros) * factor))
+		totalMicros += valueMicros
+		totalStressedMicros += stressedMicros
+		if h.Category != cashCategory {
+			pnlMicros += mathpb.ParseMicros(h.UnrealizedPnLUSD)
+			stressedPnLMicros += mathpb.ParseMicros(h.UnrealizedPnLUSD) + stressedMicros - valueMicros
+		}
+		for name, weight := range classification.Weights(h, config.Lookthroughs) {
+			data, ok := allocationMap[name]
+			if !ok {
+				data = &allocationData{}
+				allocationMap[name] = data
+			}
+			data.valueMicros += int64(math.Round(float64(valueMicros) * weight))
+			data.stressedValueMicros += int64(math.Round(float64(stressedMicros) * weight))
+		}
+	}
+	// Currency pair shocks match if any valued holding is exposed to the currency.
+	for _, h := range holdings {
+		if h.MarketValueUSD == "" {
+			continue
+		}
+		for currency := range config.SymbolConfigs[h.Symbol].Currencies {
+			markPairMatched(matchedKeys, shocks, currency)
+		}
+		markPairMatched(matchedKeys, shocks, h.Currency)
+	}
+	result := &Result{
+		ValueUSD:                 usdString(totalMicros),
+		StressedValueUSD:         usdString(totalStressedMicros),
+		ChangeUSD:                usdString(totalStressedMicros - totalMicros),
+		ChangePct:                percentString(totalStressedMicros-totalMicros, totalMicros),
+		UnrealizedPnLUSD:         usdString(pnlMicros),
+		StressedUnrealizedPnLUSD: usdString(stressedPnLMicros),
+	}
+	for name, data := range allocationMap {
+		netLiqPct := percentOf(data.valueMicros, totalMicros)
+		stressedNetLiqPct := percentOf(data.stressedValueMicros, totalStressedMicros)
+		result.Allocations = append(result.Allocations, &AllocationShift{
+			Name:              name,
+			MarketValueUSD:    usdString(data.valueMicros),
+			StressedValueUSD:  usdString(data.stressedValueMicros),
+			ChangeUSD:         usdString(data.stressedValueMicros - data.valueMicros),
+			NetLiqPct:         fmt.Sprintf("%.2f%%", netLiqPct),
+			StressedNetLiqPct: fmt.Sprintf("%.2f%%", stressedNetLiqPct),
+			ShiftPct:          fmt.Sprintf("%+.2f%%", stressedNetLiqPct-netLiqPct),
+		})
+	}
+	// Sort by classification value for deterministic output.
+	sort.Slice(result.Allocations, func(i, j int) bool {
+		return result.Allocations[i].Name < result.Allocations[j].Name
+	})
+	for _, shock := range shocks {
+		if _, ok := matchedKeys[shock.Key]; !ok {
+			result.UnmatchedKeys = append(result.UnmatchedKeys, shock.Key)
+		}
+	}
+	return result, nil
+}
+
+// parsePair parses a currency pair against USD (e.g., "USD.CAD"), returning
+// the non-USD currency, and true if USD is the first currency, in which case
+// a rise in the pair lowers the USD value of the currency.
+func parsePair(key string) (string, bool, error) {
+	first, second, _ := strings.Cut(key, ".")
+	if len(first) != 3 || len(second) != 3 {
+		return "", false, fmt.Errorf("currency pair %s must be two three-letter currency codes (e.g., USD.CAD)", key)
+	}
+	switch {
+	case first == "USD" && second != "USD":
+		return second, true, nil
+	case second == "USD" && first != "USD":
+		return first, false, nil
+	default:
+		return "", false, fmt.Errorf("currency pair %s must be against USD (e.g., USD.CAD)", key)
+	}
+}
+
+// markPairMatched marks the currency pair shock for the currency as matched, if any.
+func markPairMatched(matchedKeys map[string]struct{}, shocks []Shock, currency string) {
+	for _, shock := range shocks {
+		if !strings.Contains(shock.Key, ".") {
+			continue
+		}
+		if pairCurrency, _, err := parsePair(shock.Key); err == nil && pairCurrency == currency {
+			matchedKeys[shock.Key] = struct{}{}
+		}
+	}
+}
+
+// percentOf returns value as a percentage of total, or 0 if total is 0.
+func percentOf(valueMicros int64, totalMicros int64) float64 {
+	if totalMicros == 0 {
+		return 0
+	}
+	return float64(valueMicros) / float64(totalMicros) * 100
+}
+
+// percentString formats value as a signed percentage of total, or returns an
+// empty string if total is 0.
+func percentString(valueMicros int64, totalMicros int64) string {
+	if totalMicros == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%+.2f%%", percentOf(valueMicros, totalMicros))
+}
+
+// usdString formats micros as a USD value string.
+func usdString(micros int64) string {
+	return moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", micros))
+}
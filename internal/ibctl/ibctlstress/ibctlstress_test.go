@@ -0,0 +1,102 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctlstress
+
+import (
+	"testing"
+
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlholdings"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseShocks(t *testing.T) {
+	t.Parallel()
+	shocks, err := ParseShocks("equity:-20%, BOND:-5,USD.CAD:+10%")
+	require.NoError(t, err)
+	require.Equal(t, []Shock{{Key: "EQUITY", Percent: -20}, {Key: "BOND", Percent: -5}, {Key: "USD.CAD", Percent: 10}}, shocks)
+	for _, s := range []string{
+		"",
+		"EQUITY",
+		"EQUITY:abc",
+		"EQUITY:-100%",
+		"EQUITY:-10%,equity:-5%",
+		"EUR.CAD:+5%",
+		"USD.CAD:+5%,CAD.USD:-5%",
+	} {
+		_, err := ParseShocks(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+	holdings := []*ibctlholdings.HoldingOverview{
+		{Symbol: "NET", Currency: "USD", MarketValueUSD: "500", UnrealizedPnLUSD: "100", Category: "EQUITY", Sector: "TECH"},
+		{Symbol: "VT", Currency: "USD", MarketValueUSD: "400", UnrealizedPnLUSD: "50", Category: "EQUITY"},
+		{Symbol: "SHOP", Currency: "CAD", MarketValueUSD: "100", UnrealizedPnLUSD: "-10", Category: "EQUITY", Sector: "TECH"},
+		{Symbol: "CAD", Currency: "CAD", MarketValueUSD: "200", Category: "CASH"},
+	}
+	config := &ibctlconfig.Config{
+		Lookthroughs: map[string]ibctlconfig.Lookthrough{
+			"VT": {Sector: map[string]float64{"TECH": 0.25, "ENERGY": 0.75}},
+		},
+		SymbolConfigs: map[string]ibctlconfig.SymbolConfig{
+			"VT": {Currencies: map[string]float64{"USD": 0.5, "CAD": 0.5}},
+		},
+	}
+	shocks, err := ParseShocks("TECH:-20%,CAD.USD:-10%,BOND:-5%")
+	require.NoError(t, err)
+	result, err := Apply(holdings, shocks, ibctlholdings.ClassificationCategory, config)
+	require.NoError(t, err)
+	// NET: 500 * 0.8 = 400.
+	// VT: 400 * (1 - 0.25*0.2) * (0.5 + 0.5*0.9) = 400 * 0.95 * 0.95 = 361.
+	// SHOP: 100 * 0.8 * 0.9 = 72.
+	// CAD cash: 200 * 0.9 = 180.
+	require.Equal(t, &Result{
+		ValueUSD:                 "1200",
+		StressedValueUSD:         "1013",
+		ChangeUSD:                "-187",
+		ChangePct:                "-15.58%",
+		UnrealizedPnLUSD:         "140",
+		StressedUnrealizedPnLUSD: "-27",
+		Allocations: []*AllocationShift{
+			{
+				Name:              "CASH",
+				MarketValueUSD:    "200",
+				StressedValueUSD:  "180",
+				ChangeUSD:         "-20",
+				NetLiqPct:         "16.67%",
+				StressedNetLiqPct: "17.77%",
+				ShiftPct:          "+1.10%",
+			},
+			{
+				Name:              "EQUITY",
+				MarketValueUSD:    "1000",
+				StressedValueUSD:  "833",
+				ChangeUSD:         "-167",
+				NetLiqPct:         "83.33%",
+				StressedNetLiqPct: "82.23%",
+				ShiftPct:          "-1.10%",
+			},
+		},
+		UnmatchedKeys: []string{"BOND"},
+	}, result)
+}
+
+func TestApplyUSDPair(t *testing.T) {
+	t.Parallel()
+	holdings := []*ibctlholdings.HoldingOverview{
+		{Symbol: "SHOP", Currency: "CAD", MarketValueUSD: "110", Category: "EQUITY"},
+		{Symbol: "AAPL", Currency: "USD", MarketValueUSD: "100", Category: "EQUITY"},
+	}
+	shocks, err := ParseShocks("USD.CAD:+10%")
+	require.NoError(t, err)
+	result, err := Apply(holdings, shocks, ibctlholdings.ClassificationCategory, &ibctlconfig.Config{})
+	require.NoError(t, err)
+	// USD strengthening 10% against CAD lowers the USD value of CAD holdings by 1/1.1.
+	require.Equal(t, "200", result.StressedValueUSD)
+	require.Empty(t, result.UnmatchedKeys)
+}
@@ -0,0 +1,393 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctltax projects tax liability for a year from realized gains and
+// investment income.
+//
+// Realized gains come from FIFO lot matching over all trades, split into
+// short-term and long-term by the holding period of the configured tax rules.
+// Income comes from the Flex Query Cash Transactions section. Amounts are
+// converted to USD at the FX rate on their date: the cost of a realized gain
+// at the rate on the open date, its proceeds at the rate on the close date,
+// and income at the rate on the payment date.
+package ibctltax
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctltaxlot"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/bufdev/ibctl/internal/pkg/timepb"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+)
+
+// assetCategoryCash is the IBKR asset category for cash/FX trades, which are
+// currency conversions rather than security trades.
+const assetCategoryCash = "CASH"
+
+// Projection is the projected tax liability for a year.
+type Projection struct {
+	// Year is the tax year.
+	Year int `json:"year"`
+	// BaseCurrency is the currency tax is paid in.
+	BaseCurrency string `json:"base_currency"`
+	// AccountTypes is the projection per account type, in ibctlconfig.AccountTypes order.
+	// Only account types with configured accounts are included.
+	AccountTypes []*AccountTypeProjection `json:"account_types"`
+	// Total is the sum across all account types.
+	Total *AccountTypeProjection `json:"total"`
+	// UnconvertedCurrencies lists currencies with activity in the year that
+	// could not be converted to USD and were excluded.
+	UnconvertedCurrencies []string `json:"unconverted_currencies,omitempty"`
+}
+
+// AccountTypeProjection is the projected tax liability for one account type.
+type AccountTypeProjection struct {
+	// AccountType is the account type (e.g., "taxable"), or "TOTAL" for the total.
+	AccountType string `json:"account_type"`
+	// STCGUSD is the realized short-term capital gain in USD.
+	STCGUSD string `json:"stcg_usd"`
+	// LTCGUSD is the realized long-term capital gain in USD.
+	LTCGUSD string `json:"ltcg_usd"`
+	// DividendsUSD is dividends and payments in lieu in USD.
+	DividendsUSD string `json:"dividends_usd"`
+	// InterestUSD is interest received net of interest paid in USD.
+	InterestUSD string `json:"interest_usd"`
+	// WithholdingUSD is the tax withheld at source in USD (negative), credited against tax.
+	WithholdingUSD string `json:"withholding_usd"`
+	// TaxUSD is the projected tax in USD. Always zero for deferred and exempt accounts.
+	TaxUSD string `json:"tax_usd"`
+	// TaxBase is the projected tax in the base currency. Empty if no FX rate is available.
+	TaxBase string `json:"tax_base,omitempty"`
+}
+
+// ProjectionHeaders returns the column headers for tax projection table/CSV output.
+func ProjectionHeaders(baseCurrency string) []string {
+	return []string{"ACCOUNT TYPE", "STCG USD", "LTCG USD", "DIVIDENDS USD", "INTEREST USD", "WITHHOLDING USD", "TAX USD", "TAX " + baseCurrency}
+}
+
+// AccountTypeProjectionToRow converts an AccountTypeProjection to a string slice for CSV output.
+func AccountTypeProjectionToRow(p *AccountTypeProjection) []string {
+	return []string{
+		p.AccountType,
+		p.STCGUSD,
+		p.LTCGUSD,
+		p.DividendsUSD,
+		p.InterestUSD,
+		p.WithholdingUSD,
+		p.TaxUSD,
+		p.TaxBase,
+	}
+}
+
+// AccountTypeProjectionToTableRow converts an AccountTypeProjection to a string slice for table display.
+// USD columns are formatted with $ prefix, comma separators, rounded to cents.
+func AccountTypeProjectionToTableRow(p *AccountTypeProjection) []string {
+	return []string{
+		p.AccountType,
+		cliio.FormatUSD(p.STCGUSD),
+		cliio.FormatUSD(p.LTCGUSD),
+		cliio.FormatUSD(p.DividendsUSD),
+		cliio.FormatUSD(p.InterestUSD),
+		cliio.FormatUSD(p.WithholdingUSD),
+		cliio.FormatUSD(p.TaxUSD),
+		p.TaxBase,
+	}
+}
+
+// GetProjection projects the tax liability for the year from all trades and
+// cash transactions, using the tax rates and account types from the config.
+//
+// For taxable accounts, tax is STCG, LTCG, and income (dividends and interest)
+// at their configured rates, less withholding tax credits. Net losses reduce
+// the projected tax and can make it negative. Deferred and exempt accounts are
+// reported for reference but have no projected tax.
+func GetProjection(
+	year int,
+	trades []*datav1.Trade,
+	cashTransactions []*datav1.CashTransaction,
+	config *ibctlconfig.Config,
+	fxStore *ibctlfxrates.Store,
+) (*Projection, error) {
+	var securityTrades []*datav1.Trade
+	for _, trade := range trades {
+		if trade.GetAssetCategory() != assetCategoryCash {
+			securityTrades = append(securityTrades, trade)
+		}
+	}
+	taxLotResult, err := ibctltaxlot.ComputeTaxLotsCached(config.CacheTaxLotsDirPath(), securityTrades, config.AverageCostSymbols())
+	if err != nil {
+		return nil, err
+	}
+	amountsByType := make(map[string]*amounts)
+	unconvertedCurrencies := make(map[string]struct{})
+	// toUSD converts the native amount to USD at the rate on the date.
+	toUSD := func(currencyCode string, nativeMicros int64, date xtime.Date) (int64, bool) {
+		usdMoney, ok := fxStore.ConvertToUSDOnDate(moneypb.MoneyFromMicros(currencyCode, nativeMicros), date)
+		if !ok {
+			unconvertedCurrencies[currencyCode] = struct{}{}
+			return 0, false
+		}
+		return moneypb.MoneyToMicros(usdMoney), true
+	}
+	// add adds the USD amount to the account's type via field.
+	add := func(accountAlias string, usdMicros int64, field func(*amounts) *int64) {
+		accountType := config.AccountTypes[accountAlias]
+		if accountType == "" {
+			accountType = ibctlconfig.AccountTypeTaxable
+		}
+		a := amountsByType[accountType]
+		if a == nil {
+			a = &amounts{}
+			amountsByType[accountType] = a
+		}
+		*field(a) += usdMicros
+	}
+	for _, gain := range taxLotResult.RealizedGains {
+		if gain.CloseDate.Year != year {
+			continue
+		}
+		// The gain in USD includes the change in the FX rate over the holding period.
+		costUSDMicros, ok := toUSD(gain.CurrencyCode, gain.CostMicros, gain.OpenDate)
+		if !ok {
+			continue
+		}
+		proceedsUSDMicros, ok := toUSD(gain.CurrencyCode, gain.ProceedsMicros, gain.CloseDate)
+		if !ok {
+			continue
+		}
+		gainUSDMicros := proceedsUSDMicros - costUSDMicros
+		if gain.Short {
+			gainUSDMicros = -gainUSDMicros
+		}
+		if config.TaxRules.IsLongTerm(gain.OpenDate, gain.CloseDate) {
+			add(gain.AccountAlias, gainUSDMicros, func(a *amounts) *int64 { return &a.ltcgMicros })
+		} else {
+			add(gain.AccountAlias, gainUSDMicros, func(a *amounts) *int64 { return &a.stcgMicros })
+		}
+	}
+	for _, cashTransaction := range cashTransactions {
+		if int(cashTransaction.GetDate().GetYear()) != year {
+			continue
+		}
+		var field func(*amounts) *int64
+		switch cashTransaction.GetType() {
+		case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_DIVIDEND,
+			datav1.CashTransactionType_CASH_TRANSACTION_TYPE_PAYMENT_IN_LIEU:
+			field = func(a *amounts) *int64 { return &a.dividendsMicros }
+		case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_INTEREST_RECEIVED,
+			datav1.CashTransactionType_CASH_TRANSACTION_TYPE_INTEREST_PAID:
+			field = func(a *amounts) *int64 { return &a.interestMicros }
+		case datav1.CashTransactionType_CASH_TRANSACTION_TYPE_WITHHOLDING_TAX:
+			field = func(a *amounts) *int64 { return &a.withholdingMicros }
+		default:
+			continue
+		}
+		date, err := timepb.ProtoToDate(cashTransaction.GetDate())
+		if err != nil {
+			return nil, err
+		}
+		amount := cashTransaction.GetAmount()
+		if usdMicros, ok := toUSD(amount.GetCurrencyCode(), moneypb.MoneyToMicros(amount), date); ok {
+			add(cashTransaction.GetAccountId(), usdMicros, field)
+		}
+	}
+	projection := &Projection{
+		Year:                  year,
+		BaseCurrency:          config.TaxBaseCurrency,
+		UnconvertedCurrencies: sortedKeys(unconvertedCurrencies),
+	}
+	total := &amounts{}
+	for _, accountType := range ibctlconfig.AccountTypes {
+		a, ok := amountsByType[accountType]
+		if !ok {
+			if len(config.AccountAliasesForType(accountType)) == 0 {
+				continue
+			}
+			a = &amounts{}
+		}
+		if accountType == ibctlconfig.AccountTypeTaxable {
+			a.taxMicros = int64(math.Round(
+				float64(a.stcgMicros)*config.TaxRateSTCG+
+					float64(a.ltcgMicros)*config.TaxRateLTCG+
+					float64(a.dividendsMicros+a.interestMicros)*config.TaxRateIncome,
+			)) + a.withholdingMicros
+		}
+		total.stcgMicros += a.stcgMicros
+		total.ltcgMicros += a.ltcgMicros
+		total.dividendsMicros += a.dividendsMicros
+		total.interestMicros += a.interestMicros
+		total.withholdingMicros += a.withholdingMicros
+		total.taxMicros += a.taxMicros
+		projection.AccountTypes = append(projection.AccountTypes, a.toProjection(accountType, config.TaxBaseCurrency, fxStore))
+	}
+	projection.Total = total.toProjection("TOTAL", config.TaxBaseCurrency, fxStore)
+	return projection, nil
+}
+
+// currentYearSafeHarborPct is the percentage of the current-year tax that
+// satisfies the safe harbor for estimated payments.
+const currentYearSafeHarborPct = 90
+
+// EstimatedPayments is the schedule of quarterly estimated tax payments for a year.
+type EstimatedPayments struct {
+	// Year is the tax year.
+	Year int `json:"year"`
+	// BaseCurrency is the currency tax is paid in.
+	BaseCurrency string `json:"base_currency"`
+	// CurrentYearTaxUSD is the projected tax for the year in USD.
+	CurrentYearTaxUSD string `json:"current_year_tax_usd"`
+	// CurrentYearSafeHarborUSD is 90% of the projected tax for the year in USD.
+	CurrentYearSafeHarborUSD string `json:"current_year_safe_harbor_usd"`
+	// PriorYearSafeHarborUSD is the configured percentage of the prior-year tax
+	// in USD. Empty if no prior-year tax is configured.
+	PriorYearSafeHarborUSD string `json:"prior_year_safe_harbor_usd,omitempty"`
+	// RequiredUSD is the lower of the two safe harbors in USD, and never negative.
+	RequiredUSD string `json:"required_usd"`
+	// Payments is the list of quarterly payments.
+	Payments []*EstimatedPayment `json:"payments"`
+}
+
+// EstimatedPayment is a single quarterly estimated tax payment.
+type EstimatedPayment struct {
+	// Quarter is the quarter (e.g., "Q1").
+	Quarter string `json:"quarter"`
+	// DueDate is the due date (YYYY-MM-DD).
+	DueDate string `json:"due_date"`
+	// AmountUSD is the payment amount in USD.
+	AmountUSD string `json:"amount_usd"`
+	// AmountBase is the payment amount in the base currency. Empty if no FX rate is available.
+	AmountBase string `json:"amount_base,omitempty"`
+	// CumulativeUSD is the total of this and all earlier payments in USD.
+	CumulativeUSD string `json:"cumulative_usd"`
+}
+
+// EstimatedPaymentHeaders returns the column headers for estimated payment table/CSV output.
+func EstimatedPaymentHeaders(baseCurrency string) []string {
+	return []string{"QUARTER", "DUE DATE", "AMOUNT USD", "AMOUNT " + baseCurrency, "CUMULATIVE USD"}
+}
+
+// EstimatedPaymentToRow converts an EstimatedPayment to a string slice for CSV output.
+func EstimatedPaymentToRow(p *EstimatedPayment) []string {
+	return []string{p.Quarter, p.DueDate, p.AmountUSD, p.AmountBase, p.CumulativeUSD}
+}
+
+// EstimatedPaymentToTableRow converts an EstimatedPayment to a string slice for table display.
+func EstimatedPaymentToTableRow(p *EstimatedPayment) []string {
+	return []string{p.Quarter, p.DueDate, cliio.FormatUSD(p.AmountUSD), p.AmountBase, cliio.FormatUSD(p.CumulativeUSD)}
+}
+
+// GetEstimatedPayments computes safe-harbor quarterly estimated payments from
+// the projected tax for the year.
+//
+// The required annual amount is the lower of 90% of the projected tax and
+// priorYearPct percent of the prior-year tax. If priorYearTaxMicros is 0, only
+// the current-year safe harbor is used. The amount is split evenly across the
+// four US due dates (April 15, June 15, September 15, and January 15 of the
+// following year), with any rounding remainder in the last payment. Due dates
+// are not adjusted for weekends or holidays.
+func GetEstimatedPayments(
+	projection *Projection,
+	priorYearTaxMicros int64,
+	priorYearPct float64,
+	fxStore *ibctlfxrates.Store,
+) *EstimatedPayments {
+	currentYearTaxMicros := mathpb.ParseMicros(projection.Total.TaxUSD)
+	currentYearSafeHarborMicros := int64(math.Round(float64(currentYearTaxMicros) * currentYearSafeHarborPct / 100))
+	estimatedPayments := &EstimatedPayments{
+		Year:                     projection.Year,
+		BaseCurrency:             projection.BaseCurrency,
+		CurrentYearTaxUSD:        usdString(currentYearTaxMicros),
+		CurrentYearSafeHarborUSD: usdString(currentYearSafeHarborMicros),
+	}
+	requiredMicros := currentYearSafeHarborMicros
+	if priorYearTaxMicros > 0 {
+		priorYearSafeHarborMicros := int64(math.Round(float64(priorYearTaxMicros) * priorYearPct / 100))
+		estimatedPayments.PriorYearSafeHarborUSD = usdString(priorYearSafeHarborMicros)
+		requiredMicros = min(requiredMicros, priorYearSafeHarborMicros)
+	}
+	requiredMicros = max(requiredMicros, 0)
+	estimatedPayments.RequiredUSD = usdString(requiredMicros)
+	// Round quarterly payments down to cents, with the remainder in the last payment.
+	quarterMicros := requiredMicros / 4 / 10_000 * 10_000
+	dueDates := []string{
+		fmt.Sprintf("%04d-04-15", projection.Year),
+		fmt.Sprintf("%04d-06-15", projection.Year),
+		fmt.Sprintf("%04d-09-15", projection.Year),
+		fmt.Sprintf("%04d-01-15", projection.Year+1),
+	}
+	var cumulativeMicros int64
+	for i, dueDate := range dueDates {
+		amountMicros := quarterMicros
+		if i == len(dueDates)-1 {
+			amountMicros = requiredMicros - cumulativeMicros
+		}
+		cumulativeMicros += amountMicros
+		amountUSD := moneypb.MoneyFromMicros("USD", amountMicros)
+		payment := &EstimatedPayment{
+			Quarter:       fmt.Sprintf("Q%d", i+1),
+			DueDate:       dueDate,
+			AmountUSD:     moneypb.MoneyValueToString(amountUSD),
+			CumulativeUSD: usdString(cumulativeMicros),
+		}
+		if amountBase, ok := fxStore.Convert(amountUSD, projection.BaseCurrency); ok {
+			payment.AmountBase = moneypb.MoneyValueToString(amountBase)
+		}
+		estimatedPayments.Payments = append(estimatedPayments.Payments, payment)
+	}
+	return estimatedPayments
+}
+
+// *** PRIVATE ***
+
+// amounts accumulates USD micros for one account type.
+type amounts struct {
+	stcgMicros        int64
+	ltcgMicros        int64
+	dividendsMicros   int64
+	interestMicros    int64
+	withholdingMicros int64
+	taxMicros         int64
+}
+
+// toProjection converts the amounts to an AccountTypeProjection, converting
+// the tax to the base currency.
+func (a *amounts) toProjection(accountType string, baseCurrency string, fxStore *ibctlfxrates.Store) *AccountTypeProjection {
+	taxUSD := moneypb.MoneyFromMicros("USD", a.taxMicros)
+	p := &AccountTypeProjection{
+		AccountType:    accountType,
+		STCGUSD:        usdString(a.stcgMicros),
+		LTCGUSD:        usdString(a.ltcgMicros),
+		DividendsUSD:   usdString(a.dividendsMicros),
+		InterestUSD:    usdString(a.interestMicros),
+		WithholdingUSD: usdString(a.withholdingMicros),
+		TaxUSD:         moneypb.MoneyValueToString(taxUSD),
+	}
+	if taxBase, ok := fxStore.Convert(taxUSD, baseCurrency); ok {
+		p.TaxBase = moneypb.MoneyValueToString(taxBase)
+	}
+	return p
+}
+
+// usdString returns the USD micros as a decimal string.
+func usdString(micros int64) string {
+	return moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", micros))
+}
+
+// sortedKeys returns the sorted keys of the set.
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,207 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctltax
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	timev1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/time/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctltaxrules"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/bufdev/ibctl/internal/pkg/protoio"
+	"github.com/bufdev/ibctl/internal/pkg/timepb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetProjection(t *testing.T) {
+	t.Parallel()
+	trades := []*datav1.Trade{
+		newTrade(t, "individual", "NET", "2024-01-10", datav1.TradeSide_TRADE_SIDE_BUY, 10, 100),
+		newTrade(t, "individual", "NET", "2025-03-01", datav1.TradeSide_TRADE_SIDE_SELL, -4, 150),
+		newTrade(t, "individual", "AAPL", "2025-01-05", datav1.TradeSide_TRADE_SIDE_BUY, 5, 200),
+		newTrade(t, "individual", "AAPL", "2025-06-01", datav1.TradeSide_TRADE_SIDE_SELL, -5, 180),
+		// Realized in another year.
+		newTrade(t, "individual", "NET", "2026-02-01", datav1.TradeSide_TRADE_SIDE_SELL, -6, 120),
+		newTrade(t, "rrsp", "MSFT", "2025-01-05", datav1.TradeSide_TRADE_SIDE_BUY, 1, 300),
+		newTrade(t, "rrsp", "MSFT", "2025-02-05", datav1.TradeSide_TRADE_SIDE_SELL, -1, 400),
+	}
+	cashTransactions := []*datav1.CashTransaction{
+		newCashTransaction(t, "individual", "2025-04-01", datav1.CashTransactionType_CASH_TRANSACTION_TYPE_DIVIDEND, 50),
+		newCashTransaction(t, "individual", "2025-04-01", datav1.CashTransactionType_CASH_TRANSACTION_TYPE_WITHHOLDING_TAX, -7.5),
+		newCashTransaction(t, "individual", "2025-05-01", datav1.CashTransactionType_CASH_TRANSACTION_TYPE_DEPOSIT_WITHDRAWAL, 1000),
+		newCashTransaction(t, "rrsp", "2025-04-01", datav1.CashTransactionType_CASH_TRANSACTION_TYPE_DIVIDEND, 20),
+	}
+	taxRules, err := ibctltaxrules.NewRules(ibctltaxrules.JurisdictionUS, 0)
+	require.NoError(t, err)
+	config := &ibctlconfig.Config{
+		AccountTypes: map[string]string{
+			"individual": ibctlconfig.AccountTypeTaxable,
+			"rrsp":       ibctlconfig.AccountTypeDeferred,
+		},
+		TaxRateSTCG:     0.4,
+		TaxRateLTCG:     0.2,
+		TaxRateIncome:   0.4,
+		TaxBaseCurrency: "USD",
+		TaxRules:        taxRules,
+	}
+	projection, err := GetProjection(2025, trades, cashTransactions, config, ibctlfxrates.NewStore(t.TempDir()))
+	require.NoError(t, err)
+	require.Equal(t, []*AccountTypeProjection{
+		{
+			AccountType:    ibctlconfig.AccountTypeTaxable,
+			STCGUSD:        "-100",
+			LTCGUSD:        "200",
+			DividendsUSD:   "50",
+			InterestUSD:    "0",
+			WithholdingUSD: "-7.5",
+			// -100*0.4 + 200*0.2 + 50*0.4 - 7.5
+			TaxUSD:  "12.5",
+			TaxBase: "12.5",
+		},
+		{
+			AccountType:    ibctlconfig.AccountTypeDeferred,
+			STCGUSD:        "100",
+			LTCGUSD:        "0",
+			DividendsUSD:   "20",
+			InterestUSD:    "0",
+			WithholdingUSD: "0",
+			TaxUSD:         "0",
+			TaxBase:        "0",
+		},
+	}, projection.AccountTypes)
+	require.Equal(t, "12.5", projection.Total.TaxUSD)
+	require.Equal(t, "70", projection.Total.DividendsUSD)
+}
+
+func TestGetProjectionFXRatesOnDate(t *testing.T) {
+	t.Parallel()
+	fxDirPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(fxDirPath, "CAD.USD"), 0o755))
+	require.NoError(t, protoio.WriteMessagesJSON(filepath.Join(fxDirPath, "CAD.USD", "rates.json"), []*datav1.ExchangeRate{
+		newCADExchangeRate(t, "2025-01-10", "0.7"),
+		newCADExchangeRate(t, "2025-03-03", "0.72"),
+		newCADExchangeRate(t, "2025-06-02", "0.75"),
+	}))
+	trades := []*datav1.Trade{
+		newCADTrade(t, "SHOP", "2025-01-10", datav1.TradeSide_TRADE_SIDE_BUY, 10, 100),
+		newCADTrade(t, "SHOP", "2025-06-02", datav1.TradeSide_TRADE_SIDE_SELL, -10, 110),
+		// A short sale, closed at a lower price.
+		newCADTrade(t, "XIU", "2025-01-10", datav1.TradeSide_TRADE_SIDE_SELL, -10, 50),
+		newCADTrade(t, "XIU", "2025-06-02", datav1.TradeSide_TRADE_SIDE_BUY, 10, 40),
+	}
+	dividend := newCashTransaction(t, "individual", "2025-03-03", datav1.CashTransactionType_CASH_TRANSACTION_TYPE_DIVIDEND, 0)
+	dividend.Amount = moneypb.MoneyFromMicros("CAD", 100_000_000)
+	dividend.CurrencyCode = "CAD"
+	taxRules, err := ibctltaxrules.NewRules(ibctltaxrules.JurisdictionUS, 0)
+	require.NoError(t, err)
+	config := &ibctlconfig.Config{
+		AccountTypes:    map[string]string{"individual": ibctlconfig.AccountTypeTaxable},
+		TaxRateSTCG:     0.4,
+		TaxRateLTCG:     0.2,
+		TaxRateIncome:   0.4,
+		TaxBaseCurrency: "USD",
+		TaxRules:        taxRules,
+	}
+	projection, err := GetProjection(2025, trades, []*datav1.CashTransaction{dividend}, config, ibctlfxrates.NewStore(fxDirPath))
+	require.NoError(t, err)
+	require.Empty(t, projection.UnconvertedCurrencies)
+	// SHOP: 1100 CAD at 0.75 less 1000 CAD at 0.7, and XIU: 500 CAD at 0.7
+	// less 400 CAD at 0.75, rather than the 100 CAD gains of each at 0.75.
+	require.Equal(t, "175", projection.Total.STCGUSD)
+	// 100 CAD at 0.72, the rate on the payment date.
+	require.Equal(t, "72", projection.Total.DividendsUSD)
+}
+
+func newCADTrade(t *testing.T, symbol string, date string, side datav1.TradeSide, quantity int64, price int64) *datav1.Trade {
+	t.Helper()
+	trade := newTrade(t, "individual", symbol, date, side, quantity, price)
+	trade.TradePrice = moneypb.MoneyFromMicros("CAD", price*1_000_000)
+	trade.CurrencyCode = "CAD"
+	return trade
+}
+
+func newCADExchangeRate(t *testing.T, date string, value string) *datav1.ExchangeRate {
+	t.Helper()
+	rate, err := mathpb.NewDecimal(value)
+	require.NoError(t, err)
+	return &datav1.ExchangeRate{
+		Date:              newDate(t, date),
+		BaseCurrencyCode:  "CAD",
+		QuoteCurrencyCode: "USD",
+		Rate:              rate,
+		Provider:          "frankfurter",
+	}
+}
+
+func newTrade(t *testing.T, accountAlias string, symbol string, date string, side datav1.TradeSide, quantity int64, price int64) *datav1.Trade {
+	t.Helper()
+	return &datav1.Trade{
+		TradeId:       accountAlias + symbol + date,
+		TradeDate:     newDate(t, date),
+		Symbol:        symbol,
+		AssetCategory: "STK",
+		Side:          side,
+		Quantity:      mathpb.FromMicros(quantity * 1_000_000),
+		TradePrice:    moneypb.MoneyFromMicros("USD", price*1_000_000),
+		CurrencyCode:  "USD",
+		AccountId:     accountAlias,
+	}
+}
+
+func newCashTransaction(t *testing.T, accountAlias string, date string, cashTransactionType datav1.CashTransactionType, amount float64) *datav1.CashTransaction {
+	t.Helper()
+	return &datav1.CashTransaction{
+		AccountId:    accountAlias,
+		Type:         cashTransactionType,
+		Date:         newDate(t, date),
+		Amount:       moneypb.MoneyFromMicros("USD", int64(amount*1_000_000)),
+		CurrencyCode: "USD",
+	}
+}
+
+func newDate(t *testing.T, date string) *timev1.Date {
+	t.Helper()
+	parsed, err := time.Parse(time.DateOnly, date)
+	require.NoError(t, err)
+	protoDate, err := timepb.NewProtoDate(parsed.Year(), parsed.Month(), parsed.Day())
+	require.NoError(t, err)
+	return protoDate
+}
+
+func TestGetEstimatedPayments(t *testing.T) {
+	t.Parallel()
+	fxStore := ibctlfxrates.NewStore(t.TempDir())
+	projection := &Projection{
+		Year:         2025,
+		BaseCurrency: "USD",
+		Total:        &AccountTypeProjection{TaxUSD: "10000"},
+	}
+	// The prior-year safe harbor (110% of 8000 = 8800) is below 90% of 10000.
+	estimatedPayments := GetEstimatedPayments(projection, 8_000_000_000, 110, fxStore)
+	require.Equal(t, "9000", estimatedPayments.CurrentYearSafeHarborUSD)
+	require.Equal(t, "8800", estimatedPayments.PriorYearSafeHarborUSD)
+	require.Equal(t, "8800", estimatedPayments.RequiredUSD)
+	require.Equal(t, []*EstimatedPayment{
+		{Quarter: "Q1", DueDate: "2025-04-15", AmountUSD: "2200", AmountBase: "2200", CumulativeUSD: "2200"},
+		{Quarter: "Q2", DueDate: "2025-06-15", AmountUSD: "2200", AmountBase: "2200", CumulativeUSD: "4400"},
+		{Quarter: "Q3", DueDate: "2025-09-15", AmountUSD: "2200", AmountBase: "2200", CumulativeUSD: "6600"},
+		{Quarter: "Q4", DueDate: "2026-01-15", AmountUSD: "2200", AmountBase: "2200", CumulativeUSD: "8800"},
+	}, estimatedPayments.Payments)
+	// Without a prior-year tax, the current-year safe harbor is used, and the
+	// rounding remainder goes in the last payment.
+	projection.Total.TaxUSD = "100.01"
+	estimatedPayments = GetEstimatedPayments(projection, 0, 100, fxStore)
+	require.Equal(t, "", estimatedPayments.PriorYearSafeHarborUSD)
+	require.Equal(t, "90.009", estimatedPayments.RequiredUSD)
+	require.Equal(t, "22.5", estimatedPayments.Payments[0].AmountUSD)
+	require.Equal(t, "22.509", estimatedPayments.Payments[3].AmountUSD)
+}
@@ -6,23 +6,50 @@
 package ibctltaxlot
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"hash"
 	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
 	"sort"
 	"time"
 
 	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
 	mathv1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/math/v1"
+	moneyv1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/money/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctltaxrules"
+	"github.com/bufdev/ibctl/internal/pkg/filemode"
 	"github.com/bufdev/ibctl/internal/pkg/mathpb"
 	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/bufdev/ibctl/internal/pkg/protoio"
 	"github.com/bufdev/ibctl/internal/pkg/timepb"
+	"github.com/bufdev/ibctl/internal/pkg/timing"
 	"github.com/bufdev/ibctl/internal/standard/xtime"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 )
 
 // microsFactor is the number of micros per unit.
 const microsFactor = 1_000_000
 
+// assetCategoryBond is the IBKR asset category for bonds, priced as a percentage of par.
+const assetCategoryBond = "BOND"
+
+const (
+	// assetCategoryFuture is the IBKR asset category for futures, priced per
+	// unit of the contract multiplier.
+	assetCategoryFuture = "FUT"
+	// assetCategoryCFD is the IBKR asset category for contracts for difference.
+	assetCategoryCFD = "CFD"
+)
+
 // costBasisTolerancePct is the percentage threshold below which cost basis
 // discrepancies are suppressed. Small differences arise from rounding in
 // IBKR's consolidation of order executions vs our FIFO computation.
@@ -35,6 +62,42 @@ type TaxLotResult struct {
 	// UnmatchedSells records sells that could not be fully matched against
 	// existing buy lots (e.g., the buy occurred before the data window).
 	UnmatchedSells []UnmatchedSell
+	// RealizedGains records the gain or loss from every lot closed by a trade,
+	// sorted by close date.
+	RealizedGains []RealizedGain
+}
+
+// RealizedGain records the gain or loss from closing all or part of a tax lot.
+type RealizedGain struct {
+	// AccountAlias is the account alias of the lot.
+	AccountAlias string
+	// Symbol is the ticker symbol.
+	Symbol string
+	// TradeID is the trade ID of the closing trade.
+	TradeID string
+	// OpenDate is the date the lot was opened.
+	OpenDate xtime.Date
+	// CloseDate is the trade date of the closing trade.
+	CloseDate xtime.Date
+	// QuantityMicros is the closed quantity in micros, always positive.
+	QuantityMicros int64
+	// CurrencyCode is the trade currency.
+	CurrencyCode string
+	// GainMicros is the realized gain (negative for a loss) in micros of the
+	// trade currency, ProceedsMicros - CostMicros for long lots and
+	// CostMicros - ProceedsMicros for short lots.
+	GainMicros int64
+	// CostMicros is the cost basis of the closed quantity in micros of the
+	// trade currency, including the commissions of the opening trade. For
+	// short lots, this is the net proceeds of the short sale.
+	CostMicros int64
+	// ProceedsMicros is the closing trade amount allocated to the closed
+	// quantity in micros of the trade currency, including the commissions of
+	// the closing trade. For long lots, these are the net proceeds of the
+	// sale, and for short lots the cost of the buy to close.
+	ProceedsMicros int64
+	// Short is true if the lot was a short position.
+	Short bool
 }
 
 // UnmatchedSell records a sell trade where the corresponding buy lots
@@ -93,24 +156,57 @@ type taxLot struct {
 	openDate        xtime.Date
 	quantityMicros  int64
 	costBasisMicros int64
-	currencyCode    string
+	// basisMicros is the total basis of the remaining quantity, including
+	// commissions: the cost of a long lot, or the net proceeds of a short lot.
+	basisMicros  int64
+	currencyCode string
+	source       string
+	lotID        string
+	// multiplier is the contract multiplier of the opening trade, 1 for
+	// anything but futures and CFDs.
+	multiplier int64
+}
+
+// lotDateKey identifies the lots opened for an account and symbol on a date,
+// used to assign lot ID sequence numbers.
+type lotDateKey struct {
+	lotKey
+	openDate xtime.Date
 }
 
 // ComputeTaxLots computes open tax lots from trades using FIFO ordering.
 // Buys create new lots, sells consume the oldest lots first.
 // Trades are grouped by (account_id, symbol) so each account's FIFO is independent.
 //
+// Use ComputeTaxLotsCached to cache the result between commands.
+//
 // Transfer-in records are converted to synthetic buy trades before processing.
 // Corporate actions (stock splits, etc.) should be pre-processed by the caller
 // or handled as synthetic trades.
 //
+// Realized gains are computed from the proceeds and commissions of the
+// opening and closing trades rather than their prices, so they match the
+// amounts brokers report. A trade amount is allocated to lots in proportion
+// to quantity. Trades without proceeds, such as synthetic trades from
+// transfers, fall back to the trade price times the quantity.
+//
 // If a sell cannot be fully matched against existing lots (e.g., the buy
 // occurred before the data window), the unmatched quantity is recorded in
 // the result rather than failing.
-func ComputeTaxLots(trades []*datav1.Trade) (*TaxLotResult, error) {
+//
+// Symbols in averageCostSymbols use the average cost basis method: after each
+// buy, every open long lot of the account and symbol is assigned the average
+// cost of the position. Sells still consume the oldest lots first, so each
+// lot keeps its open date for the short-term and long-term split.
+func ComputeTaxLots(trades []*datav1.Trade, averageCostSymbols map[string]struct{}) (*TaxLotResult, error) {
+	defer timing.Start("fifo")()
 	// Group trades by (account_id, symbol), sorted by trade date.
 	keyTrades := make(map[lotKey][]*datav1.Trade)
 	for _, trade := range trades {
+		// Daily mark-to-market settlements of futures move cash, not contracts.
+		if IsMarkToMarketSettlement(trade) {
+			continue
+		}
 		key := lotKey{accountAlias: trade.GetAccountId(), symbol: trade.GetSymbol()}
 		keyTrades[key] = append(keyTrades[key], trade)
 	}
@@ -131,6 +227,15 @@ func ComputeTaxLots(trades []*datav1.Trade) (*TaxLotResult, error) {
 	// Process trades using FIFO within each (account, symbol) group.
 	groupLots := make(map[lotKey][]*taxLot)
 	var unmatchedSells []UnmatchedSell
+	var realizedGains []RealizedGain
+	// Lot ID sequence numbers are assigned as lots are opened, so a lot keeps
+	// its ID when earlier lots for the same date are closed.
+	lotSequences := make(map[lotDateKey]int)
+	newLotID := func(key lotKey, openDate xtime.Date) string {
+		dateKey := lotDateKey{lotKey: key, openDate: openDate}
+		lotSequences[dateKey]++
+		return FormatLotID(key.accountAlias, key.symbol, openDate, lotSequences[dateKey])
+	}
 	for key, trades := range keyTrades {
 		for _, trade := range trades {
 			switch trade.GetSide() {
@@ -143,11 +248,17 @@ func ComputeTaxLots(trades []*datav1.Trade) (*TaxLotResult, error) {
 					return nil, fmt.Errorf("parsing trade date for %s/%s: %w", key.accountAlias, key.symbol, err)
 				}
 				tradeQuantityMicros := mathpb.ToMicros(trade.GetQuantity())
+				tradeAmountMicros := netTradeAmountMicros(trade)
 				lots := groupLots[key]
 				// Check if there are short lots to close (buy-to-close after sell-to-open).
 				for len(lots) > 0 && lots[0].quantityMicros < 0 && tradeQuantityMicros > 0 {
 					shortLot := lots[0]
 					shortQty := -shortLot.quantityMicros // Positive amount to close.
+					closedMicros := min(shortQty, tradeQuantityMicros)
+					closingAmountMicros := proportion(tradeAmountMicros, closedMicros, tradeQuantityMicros)
+					tradeAmountMicros -= closingAmountMicros
+					// The buy trade date is the close date of the short lot.
+					realizedGains = append(realizedGains, closeLot(shortLot, trade, openDate, closedMicros, closingAmountMicros))
 					if shortQty <= tradeQuantityMicros {
 						// Fully close this short lot.
 						tradeQuantityMicros -= shortQty
@@ -167,16 +278,34 @@ func ComputeTaxLots(trades []*datav1.Trade) (*TaxLotResult, error) {
 						openDate:        openDate,
 						quantityMicros:  tradeQuantityMicros,
 						costBasisMicros: moneypb.MoneyToMicros(trade.GetTradePrice()),
+						basisMicros:     tradeAmountMicros,
 						currencyCode:    trade.GetCurrencyCode(),
+						source:          trade.GetSource(),
+						lotID:           newLotID(key, openDate),
+						multiplier:      TradeMultiplier(trade),
 					})
+					if _, ok := averageCostSymbols[key.symbol]; ok {
+						if err := applyAverageCost(groupLots[key]); err != nil {
+							return nil, fmt.Errorf("computing average cost for %s/%s: %w", key.accountAlias, key.symbol, err)
+						}
+					}
 				}
 			case datav1.TradeSide_TRADE_SIDE_SELL:
 				// Sells consume the oldest lots first (FIFO).
 				// Sell quantity is negative, so negate to get the positive amount to consume.
 				remainingMicros := -mathpb.ToMicros(trade.GetQuantity())
+				tradeAmountMicros := netTradeAmountMicros(trade)
+				closeDate, err := protoDateToXtimeDate(trade.GetTradeDate())
+				if err != nil {
+					return nil, fmt.Errorf("parsing trade date for %s/%s: %w", key.accountAlias, key.symbol, err)
+				}
 				lots := groupLots[key]
 				for len(lots) > 0 && lots[0].quantityMicros > 0 && remainingMicros > 0 {
 					lot := lots[0]
+					closedMicros := min(lot.quantityMicros, remainingMicros)
+					closingAmountMicros := proportion(tradeAmountMicros, closedMicros, remainingMicros)
+					tradeAmountMicros -= closingAmountMicros
+					realizedGains = append(realizedGains, closeLot(lot, trade, closeDate, closedMicros, closingAmountMicros))
 					if lot.quantityMicros <= remainingMicros {
 						// This lot is fully consumed.
 						remainingMicros -= lot.quantityMicros
@@ -191,17 +320,17 @@ func ComputeTaxLots(trades []*datav1.Trade) (*TaxLotResult, error) {
 				// If there's remaining sell quantity with no lots to consume,
 				// create a short lot (sell-to-open, e.g., writing options).
 				if remainingMicros > 0 {
-					openDate, err := protoDateToXtimeDate(trade.GetTradeDate())
-					if err != nil {
-						return nil, fmt.Errorf("parsing trade date for %s/%s: %w", key.accountAlias, key.symbol, err)
-					}
 					groupLots[key] = append(groupLots[key], &taxLot{
 						accountAlias:    key.accountAlias,
 						symbol:          key.symbol,
-						openDate:        openDate,
+						openDate:        closeDate,
 						quantityMicros:  -remainingMicros, // Negative = short position.
 						costBasisMicros: moneypb.MoneyToMicros(trade.GetTradePrice()),
+						basisMicros:     tradeAmountMicros,
 						currencyCode:    trade.GetCurrencyCode(),
+						source:          trade.GetSource(),
+						lotID:           newLotID(key, closeDate),
+						multiplier:      TradeMultiplier(trade),
 					})
 				}
 			}
@@ -227,11 +356,14 @@ func ComputeTaxLots(trades []*datav1.Trade) (*TaxLotResult, error) {
 				Quantity:       mathpb.FromMicros(lot.quantityMicros),
 				CostBasisPrice: moneypb.MoneyFromMicros(lot.currencyCode, lot.costBasisMicros),
 				CurrencyCode:   lot.currencyCode,
+				Source:         lot.source,
+				LotId:          lot.lotID,
 			})
 		}
 	}
 	// Sort by account, then symbol, then open date for deterministic output.
-	sort.Slice(result, func(i, j int) bool {
+	// The sort is stable so lots opened on the same date stay in FIFO order.
+	sort.SliceStable(result, func(i, j int) bool {
 		if result[i].GetAccountId() != result[j].GetAccountId() {
 			return result[i].GetAccountId() < result[j].GetAccountId()
 		}
@@ -240,39 +372,238 @@ func ComputeTaxLots(trades []*datav1.Trade) (*TaxLotResult, error) {
 		}
 		return taxLotDateString(result[i]) < taxLotDateString(result[j])
 	})
+	// Sort realized gains by close date, then account and symbol.
+	sort.SliceStable(realizedGains, func(i, j int) bool {
+		if realizedGains[i].CloseDate != realizedGains[j].CloseDate {
+			return realizedGains[i].CloseDate.Before(realizedGains[j].CloseDate)
+		}
+		if realizedGains[i].AccountAlias != realizedGains[j].AccountAlias {
+			return realizedGains[i].AccountAlias < realizedGains[j].AccountAlias
+		}
+		return realizedGains[i].Symbol < realizedGains[j].Symbol
+	})
 	return &TaxLotResult{
 		TaxLots:        result,
 		UnmatchedSells: unmatchedSells,
+		RealizedGains:  realizedGains,
 	}, nil
 }
 
+// taxLotCacheVersion is mixed into the tax lot cache key and is bumped
+// whenever ComputeTaxLots or TaxLotResult changes in a way that invalidates
+// previously cached results.
+const taxLotCacheVersion = 1
+
+// maxTaxLotCacheEntries is the number of tax lot results kept in the cache.
+// Commands compute tax lots from different subsets of the trades (by account,
+// as-of date, or ignored symbols), so more than one result is kept.
+const maxTaxLotCacheEntries = 16
+
+// ComputeTaxLotsCached is ComputeTaxLots with the result cached as JSON under
+// cacheDirPath. The cache key is a digest of the trades and the average cost
+// symbols, so a result is reused only for the same subset of the same merged
+// trades, and changing any input of the merge or any filter applied to its
+// trades computes the tax lots again. The least recently used results beyond
+// maxTaxLotCacheEntries are removed. If cacheDirPath is empty, the result is
+// not cached.
+func ComputeTaxLotsCached(cacheDirPath string, trades []*datav1.Trade, averageCostSymbols map[string]struct{}) (*TaxLotResult, error) {
+	if cacheDirPath == "" {
+		return ComputeTaxLots(trades, averageCostSymbols)
+	}
+	key, err := taxLotCacheKey(trades, averageCostSymbols)
+	if err != nil {
+		return nil, err
+	}
+	cacheFilePath := filepath.Join(cacheDirPath, key+".json")
+	if result, ok := readTaxLotCache(cacheFilePath); ok {
+		// Mark the result as recently used; a failure only means it is pruned sooner.
+		now := time.Now()
+		_ = os.Chtimes(cacheFilePath, now, now)
+		return result, nil
+	}
+	result, err := ComputeTaxLots(trades, averageCostSymbols)
+	if err != nil {
+		return nil, err
+	}
+	// Best-effort cache write; a failure only means recomputing next time.
+	if err := writeTaxLotCache(cacheFilePath, result); err == nil {
+		_ = pruneTaxLotCache(cacheDirPath)
+	}
+	return result, nil
+}
+
+// taxLotCacheKey returns a hex-encoded SHA-256 digest of the trades, in order,
+// and the sorted average cost symbols.
+func taxLotCacheKey(trades []*datav1.Trade, averageCostSymbols map[string]struct{}) (string, error) {
+	digest := sha256.New()
+	_, _ = fmt.Fprintf(digest, "version:%d\n", taxLotCacheVersion)
+	symbols := make([]string, 0, len(averageCostSymbols))
+	for symbol := range averageCostSymbols {
+		symbols = append(symbols, symbol)
+	}
+	slices.Sort(symbols)
+	for _, symbol := range symbols {
+		writeLengthPrefixed(digest, []byte(symbol))
+	}
+	marshalOptions := proto.MarshalOptions{Deterministic: true}
+	var data []byte
+	for _, trade := range trades {
+		var err error
+		data, err = marshalOptions.MarshalAppend(data[:0], trade)
+		if err != nil {
+			return "", fmt.Errorf("digesting trade %s: %w", trade.GetTradeId(), err)
+		}
+		writeLengthPrefixed(digest, data)
+	}
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
+
+// writeLengthPrefixed writes the length of data followed by data to the
+// digest, so that adjacent values cannot run together.
+func writeLengthPrefixed(digest hash.Hash, data []byte) {
+	_, _ = digest.Write(binary.AppendUvarint(nil, uint64(len(data))))
+	_, _ = digest.Write(data)
+}
+
+// taxLotCacheEntry is the JSON form of a cached TaxLotResult. Tax lots are
+// encoded with protojson.
+type taxLotCacheEntry struct {
+	TaxLots        []json.RawMessage         `json:"tax_lots"`
+	UnmatchedSells []unmatchedSellCacheEntry `json:"unmatched_sells"`
+	RealizedGains  []RealizedGain            `json:"realized_gains"`
+}
+
+// unmatchedSellCacheEntry is the JSON form of a cached UnmatchedSell.
+type unmatchedSellCacheEntry struct {
+	AccountAlias            string `json:"account_alias"`
+	Symbol                  string `json:"symbol"`
+	UnmatchedQuantityMicros int64  `json:"unmatched_quantity_micros"`
+}
+
+// readTaxLotCache reads a cached result, returning false if the cache file is
+// missing or unreadable.
+func readTaxLotCache(filePath string) (*TaxLotResult, bool) {
+	data, err := protoio.ReadFile(filePath)
+	if err != nil {
+		return nil, false
+	}
+	var entry taxLotCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+	result := &TaxLotResult{RealizedGains: entry.RealizedGains}
+	for _, taxLotData := range entry.TaxLots {
+		taxLot := &datav1.TaxLot{}
+		if err := protojson.Unmarshal(taxLotData, taxLot); err != nil {
+			return nil, false
+		}
+		result.TaxLots = append(result.TaxLots, taxLot)
+	}
+	for _, unmatchedSell := range entry.UnmatchedSells {
+		result.UnmatchedSells = append(result.UnmatchedSells, UnmatchedSell{
+			AccountAlias:      unmatchedSell.AccountAlias,
+			Symbol:            unmatchedSell.Symbol,
+			UnmatchedQuantity: mathpb.FromMicros(unmatchedSell.UnmatchedQuantityMicros),
+		})
+	}
+	return result, true
+}
+
+// writeTaxLotCache writes the result to the cache file.
+func writeTaxLotCache(filePath string, result *TaxLotResult) error {
+	entry := taxLotCacheEntry{RealizedGains: result.RealizedGains}
+	for _, taxLot := range result.TaxLots {
+		taxLotData, err := protojson.Marshal(taxLot)
+		if err != nil {
+			return err
+		}
+		entry.TaxLots = append(entry.TaxLots, taxLotData)
+	}
+	for _, unmatchedSell := range result.UnmatchedSells {
+		entry.UnmatchedSells = append(entry.UnmatchedSells, unmatchedSellCacheEntry{
+			AccountAlias:            unmatchedSell.AccountAlias,
+			Symbol:                  unmatchedSell.Symbol,
+			UnmatchedQuantityMicros: mathpb.ToMicros(unmatchedSell.UnmatchedQuantity),
+		})
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := filemode.MkdirAll(filepath.Dir(filePath)); err != nil {
+		return err
+	}
+	return protoio.WriteFile(filePath, data)
+}
+
+// pruneTaxLotCache removes the least recently used cache files beyond
+// maxTaxLotCacheEntries.
+func pruneTaxLotCache(cacheDirPath string) error {
+	dirEntries, err := os.ReadDir(cacheDirPath)
+	if err != nil {
+		return err
+	}
+	if len(dirEntries) <= maxTaxLotCacheEntries {
+		return nil
+	}
+	type cacheFile struct {
+		path    string
+		modTime time.Time
+	}
+	cacheFiles := make([]cacheFile, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		info, err := dirEntry.Info()
+		if err != nil {
+			return err
+		}
+		cacheFiles = append(cacheFiles, cacheFile{path: filepath.Join(cacheDirPath, dirEntry.Name()), modTime: info.ModTime()})
+	}
+	// Newest first, so the files past the limit are the oldest.
+	sort.Slice(cacheFiles, func(i, j int) bool {
+		return cacheFiles[i].modTime.After(cacheFiles[j].modTime)
+	})
+	var errs []error
+	for _, cacheFile := range cacheFiles[maxTaxLotCacheEntries:] {
+		if err := os.Remove(cacheFile.path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// FormatLotID returns the deterministic lot ID for the sequence-th lot opened
+// for the account and symbol on the open date, starting from 1.
+func FormatLotID(accountAlias string, symbol string, openDate xtime.Date, sequence int) string {
+	return fmt.Sprintf("%s/%s/%s/%d", accountAlias, symbol, openDate, sequence)
+}
+
 // ComputePositions aggregates tax lots into positions with weighted average cost basis.
 // Positions are grouped by (account_id, symbol).
-func ComputePositions(taxLots []*datav1.TaxLot) []*datav1.ComputedPosition {
+func ComputePositions(taxLots []*datav1.TaxLot) ([]*datav1.ComputedPosition, error) {
 	// Aggregate quantities (as total micros) and total cost per (account, symbol).
 	type symbolData struct {
-		quantityMicros  int64
-		totalCostMicros int64
-		currencyCode    string
+		quantityMicros int64
+		totalCost      *mathv1.Decimal
+		currencyCode   string
 	}
 	dataMap := make(map[lotKey]*symbolData)
 	for _, lot := range taxLots {
 		key := lotKey{accountAlias: lot.GetAccountId(), symbol: lot.GetSymbol()}
 		data, ok := dataMap[key]
 		if !ok {
-			data = &symbolData{currencyCode: lot.GetCurrencyCode()}
+			data = &symbolData{totalCost: &mathv1.Decimal{}, currencyCode: lot.GetCurrencyCode()}
 			dataMap[key] = data
 		}
-		lotQtyMicros := mathpb.ToMicros(lot.GetQuantity())
-		data.quantityMicros += lotQtyMicros
-		// Total cost = price * quantity. Both are in micros so we need to divide by microsFactor.
-		// To avoid int64 overflow with large quantities (e.g., bonds with 331000 face value),
-		// divide quantity by microsFactor first (converting back to units), then multiply by price.
-		lotQtyUnits := lotQtyMicros / microsFactor
-		lotQtyRemainder := lotQtyMicros % microsFactor
-		priceMicros := moneypb.MoneyToMicros(lot.GetCostBasisPrice())
-		// cost = price * (qtyUnits + qtyRemainder/microsFactor) = price*qtyUnits + price*qtyRemainder/microsFactor
-		data.totalCostMicros += priceMicros*lotQtyUnits + priceMicros*lotQtyRemainder/microsFactor
+		data.quantityMicros += mathpb.ToMicros(lot.GetQuantity())
+		// Total cost = price * quantity, which overflows int64 micros for large
+		// quantities (e.g., bonds with 331000 face value).
+		cost, err := mathpb.Multiply(lot.GetCostBasisPrice().GetAmount(), lot.GetQuantity())
+		if err != nil {
+			return nil, fmt.Errorf("computing cost of %s/%s: %w", key.accountAlias, key.symbol, err)
+		}
+		if data.totalCost, err = mathpb.Add(data.totalCost, cost); err != nil {
+			return nil, fmt.Errorf("computing cost of %s/%s: %w", key.accountAlias, key.symbol, err)
+		}
 	}
 	// Build computed positions.
 	var positions []*datav1.ComputedPosition
@@ -281,25 +612,19 @@ func ComputePositions(taxLots []*datav1.TaxLot) []*datav1.ComputedPosition {
 			continue
 		}
 		// Weighted average cost basis = total cost / total quantity.
-		// For large quantities (e.g., bonds with 331000 face value), the naive
-		// totalCostMicros * microsFactor overflows int64. Instead, compute
-		// avgCost = totalCost / (quantity / microsFactor) for large quantities,
-		// falling back to the precise formula for small quantities.
-		qtyUnits := data.quantityMicros / microsFactor
-		var avgCostMicros int64
-		if qtyUnits != 0 {
-			// Divide first to avoid overflow: totalCost / qtyUnits gives the result directly.
-			avgCostMicros = data.totalCostMicros / qtyUnits
-		} else {
-			// Small quantity (< 1 unit): use the precise formula.
-			avgCostMicros = data.totalCostMicros * microsFactor / data.quantityMicros
+		averageCost, err := mathpb.Divide(data.totalCost, mathpb.FromMicros(data.quantityMicros))
+		if err != nil {
+			return nil, fmt.Errorf("computing average cost of %s/%s: %w", key.accountAlias, key.symbol, err)
 		}
 		positions = append(positions, &datav1.ComputedPosition{
-			Symbol:                key.symbol,
-			AccountId:             key.accountAlias,
-			Quantity:              mathpb.FromMicros(data.quantityMicros),
-			AverageCostBasisPrice: moneypb.MoneyFromMicros(data.currencyCode, avgCostMicros),
-			CurrencyCode:          data.currencyCode,
+			Symbol:    key.symbol,
+			AccountId: key.accountAlias,
+			Quantity:  mathpb.FromMicros(data.quantityMicros),
+			AverageCostBasisPrice: &moneyv1.Money{
+				CurrencyCode: data.currencyCode,
+				Amount:       averageCost,
+			},
+			CurrencyCode: data.currencyCode,
 		})
 	}
 	// Sort by account then symbol for deterministic output.
@@ -309,16 +634,16 @@ func ComputePositions(taxLots []*datav1.TaxLot) []*datav1.ComputedPosition {
 		}
 		return positions[i].GetSymbol() < positions[j].GetSymbol()
 	})
-	return positions
+	return positions, nil
 }
 
-// IsLongTerm returns whether a tax lot is long-term (held >= 365 days) as of the given date.
-func IsLongTerm(lot *datav1.TaxLot, asOf xtime.Date) (bool, error) {
+// IsLongTerm returns whether a tax lot is long-term as of the given date under the tax rules.
+func IsLongTerm(lot *datav1.TaxLot, asOf xtime.Date, taxRules *ibctltaxrules.Rules) (bool, error) {
 	openDate, err := protoDateToXtimeDate(lot.GetOpenDate())
 	if err != nil {
 		return false, err
 	}
-	return asOf.DaysSince(openDate) >= 365, nil
+	return taxRules.IsLongTerm(openDate, asOf), nil
 }
 
 // VerifyPositions compares computed positions against IBKR-reported positions.
@@ -362,8 +687,10 @@ func VerifyPositions(computed []*datav1.ComputedPosition, reported []*datav1.Pos
 		}
 		// Compare cost basis prices numerically. Suppress discrepancies within
 		// 0.1% tolerance — small differences arise from rounding in IBKR's
-		// consolidation of order executions vs our FIFO computation.
-		computedCostMicros := moneypb.MoneyToMicros(comp.GetAverageCostBasisPrice())
+		// consolidation of order executions vs our FIFO computation. IBKR
+		// reports the cost basis price of futures and CFDs per contract, so
+		// the computed price per unit is multiplied by the contract multiplier.
+		computedCostMicros := moneypb.MoneyToMicros(comp.GetAverageCostBasisPrice()) * PositionMultiplier(rep)
 		reportedCostMicros := moneypb.MoneyToMicros(rep.GetCostBasisPrice())
 		if computedCostMicros != reportedCostMicros {
 			absDiff := math.Abs(float64(computedCostMicros - reportedCostMicros))
@@ -374,7 +701,7 @@ func VerifyPositions(computed []*datav1.ComputedPosition, reported []*datav1.Pos
 					AccountAlias:  key.accountAlias,
 					Symbol:        key.symbol,
 					Type:          DiscrepancyTypeCostBasis,
-					ComputedValue: moneypb.MoneyValueToString(comp.GetAverageCostBasisPrice()),
+					ComputedValue: moneypb.MoneyValueToString(moneypb.MoneyFromMicros(comp.GetCurrencyCode(), computedCostMicros)),
 					ReportedValue: moneypb.MoneyValueToString(rep.GetCostBasisPrice()),
 				})
 			}
@@ -494,6 +821,180 @@ func TradeTransfersToSyntheticTrades(tradeTransfers []*datav1.TradeTransfer) []*
 
 // *** PRIVATE ***
 
+// closeLot returns the realized gain from closing closedMicros of the lot
+// with the closing trade, and removes the closed share of the basis from the
+// lot. The caller reduces the lot quantity afterwards. closingAmountMicros is
+// the share of the closing trade amount allocated to the closed quantity.
+// Long lots gain when the closing amount is above the basis, short lots when
+// it is below.
+func closeLot(lot *taxLot, closingTrade *datav1.Trade, closeDate xtime.Date, closedMicros int64, closingAmountMicros int64) RealizedGain {
+	costMicros := proportion(lot.basisMicros, closedMicros, absInt64(lot.quantityMicros))
+	lot.basisMicros -= costMicros
+	gainMicros := closingAmountMicros - costMicros
+	if lot.quantityMicros < 0 {
+		gainMicros = -gainMicros
+	}
+	return RealizedGain{
+		AccountAlias:   lot.accountAlias,
+		Symbol:         lot.symbol,
+		TradeID:        closingTrade.GetTradeId(),
+		OpenDate:       lot.openDate,
+		CloseDate:      closeDate,
+		QuantityMicros: closedMicros,
+		CurrencyCode:   lot.currencyCode,
+		GainMicros:     gainMicros,
+		CostMicros:     costMicros,
+		ProceedsMicros: closingAmountMicros,
+		Short:          lot.quantityMicros < 0,
+	}
+}
+
+// netTradeAmountMicros returns the amount of a trade in micros of the trade
+// currency, including commissions: the cost of a buy, or the net proceeds of
+// a sell. Trades without proceeds fall back to the trade price times the
+// quantity. Bond prices are percentages of par, so bond amounts are divided
+// by 100.
+func netTradeAmountMicros(trade *datav1.Trade) int64 {
+	proceedsMicros := moneypb.MoneyToMicros(trade.GetProceeds())
+	if proceedsMicros == 0 {
+		quantityMicros := mathpb.ToMicros(trade.GetQuantity())
+		priceMicros := moneypb.MoneyToMicros(trade.GetTradePrice())
+		// Multiply units and remainder separately to avoid int64 overflow.
+		proceedsMicros = -(priceMicros*(quantityMicros/microsFactor) + priceMicros*(quantityMicros%microsFactor)/microsFactor)
+		if trade.GetAssetCategory() == assetCategoryBond {
+			proceedsMicros /= 100
+		}
+	}
+	// Proceeds are negative for buys and commissions are negative, so the net
+	// cash flow is negative for buys and positive for sells.
+	netMicros := proceedsMicros + moneypb.MoneyToMicros(trade.GetCommission())
+	if trade.GetSide() == datav1.TradeSide_TRADE_SIDE_BUY {
+		return -netMicros
+	}
+	return netMicros
+}
+
+// IsMultiplierCategory returns true if prices of the IBKR asset category are
+// per unit of a contract multiplier, so values and P&L must be multiplied by
+// it. Positions in these categories are margined, so their notional value is
+// not part of the account's market value.
+func IsMultiplierCategory(assetCategory string) bool {
+	return assetCategory == assetCategoryFuture || assetCategory == assetCategoryCFD
+}
+
+// IsMarkToMarketSettlement returns true if the trade is a daily mark-to-market
+// settlement of a futures or CFD position rather than a trade of contracts.
+// Settlements have no quantity, so they must not open or close lots.
+func IsMarkToMarketSettlement(trade *datav1.Trade) bool {
+	return IsMultiplierCategory(trade.GetAssetCategory()) && mathpb.ToMicros(trade.GetQuantity()) == 0
+}
+
+// TradeMultiplier returns the contract multiplier of a futures or CFD trade,
+// or 1 for other trades. IBKR reports the trade price per unit and the
+// proceeds per contract, so the multiplier is derived as
+// |proceeds| / (|quantity| * price), rounded to a whole number.
+func TradeMultiplier(trade *datav1.Trade) int64 {
+	if !IsMultiplierCategory(trade.GetAssetCategory()) {
+		return 1
+	}
+	return deriveMultiplier(
+		moneypb.MoneyToMicros(trade.GetProceeds()),
+		mathpb.ToMicros(trade.GetQuantity()),
+		moneypb.MoneyToMicros(trade.GetTradePrice()),
+	)
+}
+
+// PositionMultiplier returns the contract multiplier of a futures or CFD
+// position, or 1 for other positions. IBKR reports the market price per unit
+// and the market value with the multiplier applied, so the multiplier is
+// derived as |market value| / (|quantity| * market price), rounded to a
+// whole number.
+func PositionMultiplier(position *datav1.Position) int64 {
+	if !IsMultiplierCategory(position.GetAssetCategory()) {
+		return 1
+	}
+	return deriveMultiplier(
+		moneypb.MoneyToMicros(position.GetMarketValue()),
+		mathpb.ToMicros(position.GetQuantity()),
+		moneypb.MoneyToMicros(position.GetMarketPrice()),
+	)
+}
+
+// deriveMultiplier returns |valueMicros| / (|quantityMicros| * |priceMicros|),
+// rounded to a whole number, or 1 if it cannot be derived.
+func deriveMultiplier(valueMicros int64, quantityMicros int64, priceMicros int64) int64 {
+	if valueMicros == 0 || quantityMicros == 0 || priceMicros == 0 {
+		return 1
+	}
+	// Computed in floating point, since quantity * price overflows int64 micros.
+	unitValue := math.Abs(float64(quantityMicros)) / microsFactor * math.Abs(float64(priceMicros))
+	multiplier := int64(math.Round(math.Abs(float64(valueMicros)) / unitValue))
+	if multiplier < 1 {
+		return 1
+	}
+	return multiplier
+}
+
+// applyAverageCost sets the cost basis of every long lot to the weighted
+// average cost basis of the long lots.
+func applyAverageCost(lots []*taxLot) error {
+	var quantityMicros, totalBasisMicros int64
+	totalCost := &mathv1.Decimal{}
+	for _, lot := range lots {
+		if lot.quantityMicros <= 0 {
+			continue
+		}
+		quantityMicros += lot.quantityMicros
+		totalBasisMicros += lot.basisMicros
+		cost, err := mathpb.Multiply(mathpb.FromMicros(lot.costBasisMicros), mathpb.FromMicros(lot.quantityMicros))
+		if err != nil {
+			return err
+		}
+		if totalCost, err = mathpb.Add(totalCost, cost); err != nil {
+			return err
+		}
+	}
+	if quantityMicros == 0 {
+		return nil
+	}
+	// Mutual fund positions are usually fractional, so divide exactly rather
+	// than by whole units.
+	averageCost, err := mathpb.Divide(totalCost, mathpb.FromMicros(quantityMicros))
+	if err != nil {
+		return err
+	}
+	averageCostMicros := mathpb.ToMicros(averageCost)
+	// The total basis is redistributed in proportion to quantity, with the
+	// rounding remainder going to the last lot so the total is unchanged.
+	remainingQuantityMicros := quantityMicros
+	for _, lot := range lots {
+		if lot.quantityMicros > 0 {
+			lot.costBasisMicros = averageCostMicros
+			lot.basisMicros = proportion(totalBasisMicros, lot.quantityMicros, remainingQuantityMicros)
+			totalBasisMicros -= lot.basisMicros
+			remainingQuantityMicros -= lot.quantityMicros
+		}
+	}
+	return nil
+}
+
+// proportion returns value * numerator / denominator without overflow.
+func proportion(value int64, numerator int64, denominator int64) int64 {
+	if numerator == denominator {
+		return value
+	}
+	result := new(big.Int).Mul(big.NewInt(value), big.NewInt(numerator))
+	return result.Quo(result, big.NewInt(denominator)).Int64()
+}
+
+// absInt64 returns the absolute value of an int64.
+func absInt64(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
 // tradeDateString returns a sortable date string from a trade's trade_date.
 func tradeDateString(trade *datav1.Trade) string {
 	return protoDateStr(trade.GetTradeDate())
@@ -0,0 +1,270 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctltaxlot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	timev1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/time/v1"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeTaxLotsLotIDs(t *testing.T) {
+	t.Parallel()
+	trades := []*datav1.Trade{
+		newTestTrade("t1", datav1.TradeSide_TRADE_SIDE_BUY, 3, 10),
+		newTestTrade("t2", datav1.TradeSide_TRADE_SIDE_BUY, 3, 20),
+		newTestTrade("t3", datav1.TradeSide_TRADE_SIDE_BUY, 4, 30),
+	}
+	result, err := ComputeTaxLots(trades, nil)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		[]string{"individual/AAPL/2025-03-03/1", "individual/AAPL/2025-03-03/2", "individual/AAPL/2025-03-04/1"},
+		lotIDs(result.TaxLots),
+	)
+	// Selling the first lot leaves the IDs of the remaining lots unchanged.
+	trades = append(trades, newTestTrade("t4", datav1.TradeSide_TRADE_SIDE_SELL, 5, -10))
+	result, err = ComputeTaxLots(trades, nil)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		[]string{"individual/AAPL/2025-03-03/2", "individual/AAPL/2025-03-04/1"},
+		lotIDs(result.TaxLots),
+	)
+}
+
+func TestComputeTaxLotsAverageCost(t *testing.T) {
+	t.Parallel()
+	trades := []*datav1.Trade{
+		newTestTrade("t1", datav1.TradeSide_TRADE_SIDE_BUY, 3, 10),
+		newTestTrade("t2", datav1.TradeSide_TRADE_SIDE_BUY, 4, 30),
+		newTestTrade("t3", datav1.TradeSide_TRADE_SIDE_SELL, 5, -20),
+	}
+	trades[1].TradePrice = moneypb.MoneyFromMicros("USD", 170_000_000)
+	trades[2].TradePrice = moneypb.MoneyFromMicros("USD", 180_000_000)
+	result, err := ComputeTaxLots(trades, map[string]struct{}{"AAPL": {}})
+	require.NoError(t, err)
+	// Both lots have the average cost of (10*150 + 30*170) / 40 = 165, and the
+	// sell consumes the oldest lot first, keeping its open date.
+	require.Len(t, result.RealizedGains, 2)
+	require.Equal(t, xtime.Date{Year: 2025, Month: time.March, Day: 3}, result.RealizedGains[0].OpenDate)
+	require.Equal(t, int64(150_000_000), result.RealizedGains[0].GainMicros)
+	require.Equal(t, xtime.Date{Year: 2025, Month: time.March, Day: 4}, result.RealizedGains[1].OpenDate)
+	require.Equal(t, int64(150_000_000), result.RealizedGains[1].GainMicros)
+	require.Len(t, result.TaxLots, 1)
+	require.Equal(t, "individual/AAPL/2025-03-04/1", result.TaxLots[0].GetLotId())
+	require.Equal(t, "165", moneypb.MoneyValueToString(result.TaxLots[0].GetCostBasisPrice()))
+}
+
+func TestComputePositions(t *testing.T) {
+	t.Parallel()
+	taxLots := []*datav1.TaxLot{
+		// Fractional crypto lots average exactly: (1*100 + 0.5*130) / 1.5 = 110.
+		newTestTaxLot(t, "BTC", "1", "100"),
+		newTestTaxLot(t, "BTC", "0.5", "130"),
+		// The total cost of a 200 billion face value bond position overflows
+		// int64 micros.
+		newTestTaxLot(t, "T", "200000000000", "100.5"),
+		newTestTaxLot(t, "T", "100000000000", "99"),
+	}
+	positions, err := ComputePositions(taxLots)
+	require.NoError(t, err)
+	require.Len(t, positions, 2)
+	require.Equal(t, "BTC", positions[0].GetSymbol())
+	require.Equal(t, "1.5", mathpb.ToString(positions[0].GetQuantity()))
+	require.Equal(t, "110", moneypb.MoneyValueToString(positions[0].GetAverageCostBasisPrice()))
+	require.Equal(t, "T", positions[1].GetSymbol())
+	require.Equal(t, "300000000000", mathpb.ToString(positions[1].GetQuantity()))
+	require.Equal(t, "100", moneypb.MoneyValueToString(positions[1].GetAverageCostBasisPrice()))
+}
+
+func TestComputeTaxLotsAverageCostLarge(t *testing.T) {
+	t.Parallel()
+	// Buys of 100 billion at 100 and 50 billion at 103.
+	trades := []*datav1.Trade{
+		newTestTrade("t1", datav1.TradeSide_TRADE_SIDE_BUY, 3, 100_000_000_000),
+		newTestTrade("t2", datav1.TradeSide_TRADE_SIDE_BUY, 4, 50_000_000_000),
+	}
+	trades[0].TradePrice = moneypb.MoneyFromMicros("USD", 100_000_000)
+	trades[1].TradePrice = moneypb.MoneyFromMicros("USD", 103_000_000)
+	result, err := ComputeTaxLots(trades, map[string]struct{}{"AAPL": {}})
+	require.NoError(t, err)
+	require.Len(t, result.TaxLots, 2)
+	for _, taxLot := range result.TaxLots {
+		require.Equal(t, "101", moneypb.MoneyValueToString(taxLot.GetCostBasisPrice()))
+	}
+}
+
+func TestComputeTaxLotsFutures(t *testing.T) {
+	t.Parallel()
+	// Two ES contracts with a multiplier of 50, bought at 5000 and sold at 5010.
+	trades := []*datav1.Trade{
+		newTestTrade("t1", datav1.TradeSide_TRADE_SIDE_BUY, 3, 2),
+		newTestTrade("t2", datav1.TradeSide_TRADE_SIDE_BUY, 4, 0),
+		newTestTrade("t3", datav1.TradeSide_TRADE_SIDE_SELL, 5, -2),
+	}
+	for _, trade := range trades {
+		trade.Symbol = "ES"
+		trade.AssetCategory = "FUT"
+		trade.TradePrice = moneypb.MoneyFromMicros("USD", 5_000_000_000)
+		trade.Proceeds = moneypb.MoneyFromMicros("USD", -mathpb.ToMicros(trade.GetQuantity())*5_000*50)
+	}
+	trades[2].TradePrice = moneypb.MoneyFromMicros("USD", 5_010_000_000)
+	trades[2].Proceeds = moneypb.MoneyFromMicros("USD", 2*5_010*50_000_000)
+	require.Equal(t, int64(50), TradeMultiplier(trades[0]))
+	require.Equal(t, int64(50), TradeMultiplier(trades[2]))
+	// The zero-quantity trade is a mark-to-market settlement and opens no lot.
+	require.True(t, IsMarkToMarketSettlement(trades[1]))
+	result, err := ComputeTaxLots(trades, nil)
+	require.NoError(t, err)
+	require.Empty(t, result.TaxLots)
+	require.Len(t, result.RealizedGains, 1)
+	require.Equal(t, int64(2*10*50_000_000), result.RealizedGains[0].GainMicros)
+	require.Equal(t, int64(2*5_000*50_000_000), result.RealizedGains[0].CostMicros)
+}
+
+func TestComputeTaxLotsProceeds(t *testing.T) {
+	t.Parallel()
+	// Two buys of 10 for 1500 and 1600 plus a commission of 1 each, and a sell
+	// of 15 for 2400 less a commission of 1.50.
+	trades := []*datav1.Trade{
+		newTestTrade("t1", datav1.TradeSide_TRADE_SIDE_BUY, 3, 10),
+		newTestTrade("t2", datav1.TradeSide_TRADE_SIDE_BUY, 4, 10),
+		newTestTrade("t3", datav1.TradeSide_TRADE_SIDE_SELL, 5, -15),
+	}
+	trades[0].Proceeds = moneypb.MoneyFromMicros("USD", -1_500_000_000)
+	trades[0].Commission = moneypb.MoneyFromMicros("USD", -1_000_000)
+	trades[1].Proceeds = moneypb.MoneyFromMicros("USD", -1_600_000_000)
+	trades[1].Commission = moneypb.MoneyFromMicros("USD", -1_000_000)
+	trades[2].Proceeds = moneypb.MoneyFromMicros("USD", 2_400_000_000)
+	trades[2].Commission = moneypb.MoneyFromMicros("USD", -1_500_000)
+	result, err := ComputeTaxLots(trades, nil)
+	require.NoError(t, err)
+	require.Len(t, result.RealizedGains, 2)
+	// The net proceeds of 2398.50 are split 10:5 between the two lots.
+	require.Equal(t, "t3", result.RealizedGains[0].TradeID)
+	require.Equal(t, int64(1_501_000_000), result.RealizedGains[0].CostMicros)
+	require.Equal(t, int64(1_599_000_000), result.RealizedGains[0].ProceedsMicros)
+	require.Equal(t, int64(98_000_000), result.RealizedGains[0].GainMicros)
+	require.Equal(t, int64(800_500_000), result.RealizedGains[1].CostMicros)
+	require.Equal(t, int64(799_500_000), result.RealizedGains[1].ProceedsMicros)
+	require.Equal(t, int64(-1_000_000), result.RealizedGains[1].GainMicros)
+	// Closing the rest of the second lot uses the remaining basis.
+	trades = append(trades, newTestTrade("t4", datav1.TradeSide_TRADE_SIDE_SELL, 6, -5))
+	trades[3].Proceeds = moneypb.MoneyFromMicros("USD", 800_000_000)
+	result, err = ComputeTaxLots(trades, nil)
+	require.NoError(t, err)
+	require.Len(t, result.RealizedGains, 3)
+	require.Equal(t, int64(800_500_000), result.RealizedGains[2].CostMicros)
+	require.Equal(t, int64(-500_000), result.RealizedGains[2].GainMicros)
+}
+
+func TestComputeTaxLotsCached(t *testing.T) {
+	t.Parallel()
+	cacheDirPath := t.TempDir()
+	trades := []*datav1.Trade{
+		newTestTrade("t1", datav1.TradeSide_TRADE_SIDE_BUY, 3, 10),
+		newTestTrade("t2", datav1.TradeSide_TRADE_SIDE_BUY, 4, 10),
+		newTestTrade("t3", datav1.TradeSide_TRADE_SIDE_SELL, 5, -15),
+	}
+	expected, err := ComputeTaxLots(trades, nil)
+	require.NoError(t, err)
+	// The first call computes and caches the result, and the second reads it
+	// back from the cache.
+	for range 2 {
+		result, err := ComputeTaxLotsCached(cacheDirPath, trades, nil)
+		require.NoError(t, err)
+		require.Equal(t, lotIDs(expected.TaxLots), lotIDs(result.TaxLots))
+		require.Equal(t, "150", moneypb.MoneyValueToString(result.TaxLots[0].GetCostBasisPrice()))
+		require.Equal(t, expected.RealizedGains, result.RealizedGains)
+	}
+	dirEntries, err := os.ReadDir(cacheDirPath)
+	require.NoError(t, err)
+	require.Len(t, dirEntries, 1)
+	// A cached result is returned without recomputing.
+	key, err := taxLotCacheKey(trades, nil)
+	require.NoError(t, err)
+	require.NoError(t, writeTaxLotCache(filepath.Join(cacheDirPath, key+".json"), &TaxLotResult{}))
+	result, err := ComputeTaxLotsCached(cacheDirPath, trades, nil)
+	require.NoError(t, err)
+	require.Empty(t, result.TaxLots)
+	// Changing a trade, the subset of trades, or the average cost symbols
+	// changes the key.
+	changedTrades := []*datav1.Trade{trades[0], trades[1], newTestTrade("t3", datav1.TradeSide_TRADE_SIDE_SELL, 5, -16)}
+	for _, changedKey := range []func() (string, error){
+		func() (string, error) { return taxLotCacheKey(changedTrades, nil) },
+		func() (string, error) { return taxLotCacheKey(trades[:2], nil) },
+		func() (string, error) { return taxLotCacheKey(trades, map[string]struct{}{"AAPL": {}}) },
+	} {
+		otherKey, err := changedKey()
+		require.NoError(t, err)
+		require.NotEqual(t, key, otherKey)
+	}
+	// Only the most recently used results are kept.
+	for i := range maxTaxLotCacheEntries + 2 {
+		_, err := ComputeTaxLotsCached(cacheDirPath, []*datav1.Trade{newTestTrade("t1", datav1.TradeSide_TRADE_SIDE_BUY, uint32(i+1), 10)}, nil)
+		require.NoError(t, err)
+	}
+	dirEntries, err = os.ReadDir(cacheDirPath)
+	require.NoError(t, err)
+	require.Len(t, dirEntries, maxTaxLotCacheEntries)
+}
+
+func TestPositionMultiplier(t *testing.T) {
+	t.Parallel()
+	position := &datav1.Position{
+		AssetCategory: "FUT",
+		Quantity:      mathpb.FromMicros(-3_000_000),
+		MarketPrice:   moneypb.MoneyFromMicros("USD", 20_100_250_000),
+		MarketValue:   moneypb.MoneyFromMicros("USD", -3*20_100_250_000*20),
+	}
+	require.Equal(t, int64(20), PositionMultiplier(position))
+	position.AssetCategory = "STK"
+	require.Equal(t, int64(1), PositionMultiplier(position))
+}
+
+func newTestTrade(tradeID string, side datav1.TradeSide, day uint32, quantity int64) *datav1.Trade {
+	return &datav1.Trade{
+		TradeId:      tradeID,
+		AccountId:    "individual",
+		TradeDate:    &timev1.Date{Year: 2025, Month: 3, Day: day},
+		Symbol:       "AAPL",
+		Side:         side,
+		Quantity:     mathpb.FromMicros(quantity * 1_000_000),
+		TradePrice:   moneypb.MoneyFromMicros("USD", 150_000_000),
+		CurrencyCode: "USD",
+	}
+}
+
+func newTestTaxLot(t *testing.T, symbol string, quantity string, costBasisPrice string) *datav1.TaxLot {
+	t.Helper()
+	quantityDecimal, err := mathpb.NewDecimal(quantity)
+	require.NoError(t, err)
+	costBasisPriceMoney, err := moneypb.NewProtoMoney("USD", costBasisPrice)
+	require.NoError(t, err)
+	return &datav1.TaxLot{
+		AccountId:      "individual",
+		Symbol:         symbol,
+		Quantity:       quantityDecimal,
+		CostBasisPrice: costBasisPriceMoney,
+		CurrencyCode:   "USD",
+	}
+}
+
+func lotIDs(taxLots []*datav1.TaxLot) []string {
+	ids := make([]string, 0, len(taxLots))
+	for _, taxLot := range taxLots {
+		ids = append(ids, taxLot.GetLotId())
+	}
+	return ids
+}
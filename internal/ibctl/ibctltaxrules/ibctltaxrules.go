@@ -0,0 +1,138 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctltaxrules provides per-jurisdiction tax rules for classifying
+// capital gains as short-term or long-term and for default long-term rates.
+package ibctltaxrules
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+)
+
+// Jurisdiction is a tax jurisdiction with its own holding-period and rate rules.
+type Jurisdiction string
+
+const (
+	// JurisdictionUS is the United States. Gains are long-term if the lot
+	// was held for more than one year, so a lot becomes long-term the day
+	// after the one-year anniversary of its open date.
+	JurisdictionUS Jurisdiction = "us"
+	// JurisdictionCA is Canada. There is no holding-period distinction, and
+	// all capital gains are taxed at the short-term rate, which should be
+	// the marginal rate times the inclusion rate.
+	JurisdictionCA Jurisdiction = "ca"
+	// JurisdictionAU is Australia. Gains are eligible for the CGT discount
+	// if the lot was held for at least twelve months, excluding the open and
+	// close dates, which is the same holding period as the US. The
+	// long-term rate defaults to half the short-term rate.
+	JurisdictionAU Jurisdiction = "au"
+)
+
+// DefaultJurisdiction is the jurisdiction used if none is configured.
+const DefaultJurisdiction = JurisdictionUS
+
+// Jurisdictions is the list of all valid jurisdictions, in display order.
+var Jurisdictions = []Jurisdiction{
+	JurisdictionUS,
+	JurisdictionCA,
+	JurisdictionAU,
+}
+
+// Rules holds the validated tax rules for a jurisdiction.
+type Rules struct {
+	jurisdiction Jurisdiction
+	longTermDays int
+}
+
+// NewRules returns the rules for the jurisdiction.
+//
+// If longTermDays is positive, it overrides the jurisdiction's holding period,
+// and lots become long-term once held that many days.
+func NewRules(jurisdiction Jurisdiction, longTermDays int) (*Rules, error) {
+	if jurisdiction == "" {
+		jurisdiction = DefaultJurisdiction
+	}
+	if !slices.Contains(Jurisdictions, jurisdiction) {
+		return nil, fmt.Errorf("invalid jurisdiction %q, must be one of: %s", jurisdiction, jurisdictionsString())
+	}
+	if longTermDays < 0 {
+		return nil, fmt.Errorf("long-term days must not be negative, got %d", longTermDays)
+	}
+	if longTermDays > 0 && jurisdiction == JurisdictionCA {
+		return nil, fmt.Errorf("jurisdiction %q has no long-term capital gains, long-term days cannot be set", jurisdiction)
+	}
+	return &Rules{
+		jurisdiction: jurisdiction,
+		longTermDays: longTermDays,
+	}, nil
+}
+
+// Jurisdiction returns the jurisdiction of the rules.
+func (r *Rules) Jurisdiction() Jurisdiction {
+	return r.jurisdiction
+}
+
+// HasLongTerm returns whether the jurisdiction distinguishes long-term from
+// short-term capital gains.
+func (r *Rules) HasLongTerm() bool {
+	return r.jurisdiction != JurisdictionCA
+}
+
+// LongTermDate returns the first date on which a lot opened on openDate is
+// long-term, or false if the jurisdiction has no long-term capital gains.
+func (r *Rules) LongTermDate(openDate xtime.Date) (xtime.Date, bool) {
+	if !r.HasLongTerm() {
+		return xtime.Date{}, false
+	}
+	if r.longTermDays > 0 {
+		return openDate.AddDays(r.longTermDays), true
+	}
+	return oneYearAnniversary(openDate).AddDays(1), true
+}
+
+// IsLongTerm returns whether a lot opened on openDate is long-term as of the
+// given date, which is the close date for a closed lot.
+func (r *Rules) IsLongTerm(openDate xtime.Date, asOf xtime.Date) bool {
+	longTermDate, ok := r.LongTermDate(openDate)
+	return ok && asOf.EqualOrAfter(longTermDate)
+}
+
+// DefaultLTCGRate returns the long-term capital gains rate to use if none is
+// configured, given the short-term rate.
+func (r *Rules) DefaultLTCGRate(stcgRate float64) float64 {
+	switch r.jurisdiction {
+	case JurisdictionCA:
+		return stcgRate
+	case JurisdictionAU:
+		// The CGT discount halves the taxable gain.
+		return stcgRate / 2
+	default:
+		return 0
+	}
+}
+
+// *** PRIVATE ***
+
+// oneYearAnniversary returns the same date one year later. February 29 maps
+// to February 28 in a non-leap year.
+func oneYearAnniversary(date xtime.Date) xtime.Date {
+	anniversary := xtime.Date{Year: date.Year + 1, Month: date.Month, Day: date.Day}
+	if date.Month == time.February && date.Day == 29 && !anniversary.IsValid() {
+		anniversary.Day = 28
+	}
+	return anniversary
+}
+
+func jurisdictionsString() string {
+	strs := make([]string, len(Jurisdictions))
+	for i, jurisdiction := range Jurisdictions {
+		strs[i] = string(jurisdiction)
+	}
+	return strings.Join(strs, ", ")
+}
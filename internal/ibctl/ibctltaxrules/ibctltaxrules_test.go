@@ -0,0 +1,44 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctltaxrules
+
+import (
+	"testing"
+
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsLongTerm(t *testing.T) {
+	t.Parallel()
+	for _, testCase := range []struct {
+		jurisdiction Jurisdiction
+		longTermDays int
+		openDate     string
+		asOf         string
+		expected     bool
+	}{
+		{JurisdictionUS, 0, "2025-01-10", "2026-01-10", false},
+		{JurisdictionUS, 0, "2025-01-10", "2026-01-11", true},
+		// 365 days is not more than one year across a leap day.
+		{JurisdictionUS, 0, "2023-03-01", "2024-02-29", false},
+		{JurisdictionUS, 0, "2023-03-01", "2024-03-01", false},
+		{JurisdictionUS, 0, "2023-03-01", "2024-03-02", true},
+		{JurisdictionUS, 0, "2024-02-29", "2025-02-28", false},
+		{JurisdictionUS, 0, "2024-02-29", "2025-03-01", true},
+		{JurisdictionAU, 0, "2025-01-10", "2026-01-11", true},
+		{JurisdictionCA, 0, "2015-01-10", "2026-01-11", false},
+		{JurisdictionUS, 365, "2025-01-10", "2026-01-09", false},
+		{JurisdictionUS, 365, "2025-01-10", "2026-01-10", true},
+	} {
+		rules, err := NewRules(testCase.jurisdiction, testCase.longTermDays)
+		require.NoError(t, err)
+		openDate, err := xtime.ParseDate(testCase.openDate)
+		require.NoError(t, err)
+		asOf, err := xtime.ParseDate(testCase.asOf)
+		require.NoError(t, err)
+		require.Equal(t, testCase.expected, rules.IsLongTerm(openDate, asOf), "%s %d %s %s", testCase.jurisdiction, testCase.longTermDays, testCase.openDate, testCase.asOf)
+	}
+}
@@ -0,0 +1,456 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctltesting provides an end-to-end test harness for ibctl.
+//
+// A fixture directory contains an ibctl.yaml, the Flex Query XML response to
+// serve (flex_query.xml), optionally the FX rates to serve (fx_rates.json, a
+// map from pair such as "EUR.USD" to a map from date to rate), and optionally
+// the prices to serve (prices.json, a map from Yahoo Finance symbol to its
+// Prices). RunPipeline serves the fixture from fake Flex Web Service,
+// frankfurter.dev, Bank of Canada, and Yahoo Finance HTTP servers, runs the
+// download, merge, and holdings pipeline into a temporary directory, and
+// returns the outputs. RunFixture compares the outputs
+// against the golden files in the golden subdirectory of the fixture.
+//
+// Set IBCTL_UPDATE_GOLDEN=1 to rewrite the golden files from the current
+// outputs, for example after an intended change to the conversion code.
+package ibctltesting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctldownload"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlholdings"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/pkg/bankofcanada"
+	"github.com/bufdev/ibctl/internal/pkg/frankfurter"
+	"github.com/bufdev/ibctl/internal/pkg/ibkrflexquery"
+	"github.com/bufdev/ibctl/internal/pkg/yahoofinance"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// UpdateGoldenEnvVar is the environment variable that, when set to "1",
+	// rewrites golden files instead of comparing against them.
+	UpdateGoldenEnvVar = "IBCTL_UPDATE_GOLDEN"
+	// FlexQueryFileName is the name of the fixture Flex Query XML response.
+	FlexQueryFileName = "flex_query.xml"
+	// FXRatesFileName is the name of the fixture FX rates.
+	FXRatesFileName = "fx_rates.json"
+	// PricesFileName is the name of the fixture prices.
+	PricesFileName = "prices.json"
+	// GoldenDirName is the name of the golden file subdirectory of a fixture.
+	GoldenDirName = "golden"
+	// testToken is the Flex Web Service token passed to the fake server.
+	testToken = "test-token"
+	// testReferenceCode is the reference code returned by the fake Flex Web Service.
+	testReferenceCode = "1234567890"
+)
+
+// Prices is the prices of a Yahoo Finance symbol served by the fake Yahoo
+// Finance server.
+type Prices struct {
+	// Currency is the currency code of the prices.
+	Currency string `json:"currency"`
+	// Closes maps dates in YYYY-MM-DD format to closing prices.
+	Closes map[string]string `json:"closes"`
+}
+
+// Result is the output of running the pipeline on a fixture.
+type Result struct {
+	// DirPath is the temporary ibctl directory the pipeline ran in.
+	DirPath string
+	// Summary is the download summary.
+	Summary *ibctldownload.Summary
+	// MergedData is the merged data from all sources.
+	MergedData *ibctlmerge.MergedData
+	// Holdings is the holdings overview.
+	Holdings *ibctlholdings.HoldingsResult
+}
+
+// RunFixture runs the pipeline on the fixture directory and compares the
+// outputs against its golden files.
+func RunFixture(t testing.TB, fixtureDirPath string) {
+	t.Helper()
+	result := RunPipeline(t, fixtureDirPath)
+	CompareGolden(t, filepath.Join(fixtureDirPath, GoldenDirName), Outputs(t, result))
+}
+
+// RunPipeline copies the fixture config into a temporary directory, serves the
+// fixture Flex Query response and FX rates from fake HTTP servers, and runs the
+// download, merge, and holdings pipeline.
+func RunPipeline(t testing.TB, fixtureDirPath string) *Result {
+	t.Helper()
+	dirPath := t.TempDir()
+	configData, err := os.ReadFile(ibctlpath.ConfigFilePath(fixtureDirPath))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(ibctlpath.ConfigFilePath(dirPath), configData, 0o644))
+	config, err := ibctlconfig.ReadConfig(dirPath)
+	require.NoError(t, err)
+	xmlData, err := os.ReadFile(filepath.Join(fixtureDirPath, FlexQueryFileName))
+	require.NoError(t, err)
+	pairToDateToRate := make(map[string]map[string]string)
+	fxRatesData, err := os.ReadFile(filepath.Join(fixtureDirPath, FXRatesFileName))
+	if err == nil {
+		require.NoError(t, json.Unmarshal(fxRatesData, &pairToDateToRate))
+	} else {
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	}
+	symbolToPrices := make(map[string]*Prices)
+	pricesData, err := os.ReadFile(filepath.Join(fixtureDirPath, PricesFileName))
+	if err == nil {
+		require.NoError(t, json.Unmarshal(pricesData, &symbolToPrices))
+	} else {
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	}
+	logger := slog.New(slog.DiscardHandler)
+	downloader := ibctldownload.NewDownloader(
+		logger,
+		[]ibctldownload.Login{{Token: testToken, FlexQueryID: config.IBKRFlexQueryID}},
+		config,
+		ibkrflexquery.NewClientForBaseURL(logger, http.DefaultClient, NewFlexQueryServer(t, config.IBKRFlexQueryID, xmlData).URL),
+		frankfurter.NewClientForBaseURL(http.DefaultClient, NewFrankfurterServer(t, pairToDateToRate).URL),
+		bankofcanada.NewClientForBaseURL(http.DefaultClient, NewBankOfCanadaServer(t, pairToDateToRate).URL),
+		yahoofinance.NewClientForBaseURL(http.DefaultClient, NewYahooFinanceServer(t, symbolToPrices).URL),
+	)
+	summary, err := downloader.DownloadWithSummary(context.Background())
+	require.NoError(t, err)
+	mergedData, err := ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(dirPath),
+		ibctlpath.CacheAccountsDirPath(dirPath),
+		ibctlpath.ActivityStatementsDirPath(dirPath),
+		ibctlpath.CacheActivityStatementsDirPath(dirPath),
+		ibctlpath.TradeConfirmationsDirPath(dirPath),
+		// Do not cache the merged data, so that every run exercises the merge.
+		"",
+		ibctlpath.SeedDirPath(dirPath),
+		ibctlpath.DataManualDirPath(dirPath),
+		config.AccountAliases,
+	)
+	require.NoError(t, err)
+	fxStore := ibctlfxrates.NewStore(ibctlpath.CacheFXDirPath(dirPath))
+	holdingsResult, err := ibctlholdings.GetHoldingsOverview(mergedData.Trades, mergedData.Positions, mergedData.CashPositions, config, fxStore)
+	require.NoError(t, err)
+	return &Result{
+		DirPath:    dirPath,
+		Summary:    summary,
+		MergedData: mergedData,
+		Holdings:   holdingsResult,
+	}
+}
+
+// Outputs returns the outputs of the pipeline keyed by golden file path
+// relative to the golden directory: every file the download wrote under
+// data/accounts, cache/accounts, and cache/fx except the download metadata,
+// plus summary.json with the download summary and holdings.json with the
+// holdings overview.
+//
+// Files of JSON lines are normalized by compacting each line, since the
+// protojson output is deliberately unstable in its whitespace.
+func Outputs(t testing.TB, result *Result) map[string][]byte {
+	t.Helper()
+	outputs := make(map[string][]byte)
+	for _, dirPath := range []string{
+		ibctlpath.DataAccountsDirPath(result.DirPath),
+		ibctlpath.CacheAccountsDirPath(result.DirPath),
+		ibctlpath.CacheFXDirPath(result.DirPath),
+	} {
+		err := filepath.WalkDir(dirPath, func(filePath string, dirEntry fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if dirEntry.IsDir() {
+				return nil
+			}
+			// The download metadata records the time of the download, so it
+			// differs between runs.
+			if filepath.Base(filePath) == "metadata.json" {
+				return nil
+			}
+			data, err := os.ReadFile(filePath)
+			if err != nil {
+				return err
+			}
+			relPath, err := filepath.Rel(result.DirPath, filePath)
+			if err != nil {
+				return err
+			}
+			if filepath.Ext(filePath) == ".json" {
+				data, err = normalizeJSONLines(data)
+				if err != nil {
+					return err
+				}
+			}
+			outputs[filepath.ToSlash(relPath)] = data
+			return nil
+		})
+		require.NoError(t, err)
+	}
+	summaryData, err := json.MarshalIndent(result.Summary, "", "  ")
+	require.NoError(t, err)
+	outputs["summary.json"] = append(summaryData, '\n')
+	holdingsData, err := json.MarshalIndent(result.Holdings.Holdings, "", "  ")
+	require.NoError(t, err)
+	outputs["holdings.json"] = append(holdingsData, '\n')
+	return outputs
+}
+
+// CompareGolden compares the outputs against the golden files in
+// goldenDirPath, failing on any missing, extra, or differing file.
+//
+// If IBCTL_UPDATE_GOLDEN=1, the golden directory is replaced with the outputs.
+func CompareGolden(t testing.TB, goldenDirPath string, outputs map[string][]byte) {
+	t.Helper()
+	if os.Getenv(UpdateGoldenEnvVar) == "1" {
+		require.NoError(t, os.RemoveAll(goldenDirPath))
+		for relPath, data := range outputs {
+			filePath := filepath.Join(goldenDirPath, filepath.FromSlash(relPath))
+			require.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0o755))
+			require.NoError(t, os.WriteFile(filePath, data, 0o644))
+		}
+		return
+	}
+	var goldenRelPaths []string
+	err := filepath.WalkDir(goldenDirPath, func(filePath string, dirEntry fs.DirEntry, err error) error {
+		if err != nil || dirEntry.IsDir() {
+			return err
+		}
+		relPath, err := filepath.Rel(goldenDirPath, filePath)
+		if err != nil {
+			return err
+		}
+		goldenRelPaths = append(goldenRelPaths, filepath.ToSlash(relPath))
+		return nil
+	})
+	require.NoError(t, err, "reading golden files, set %s=1 to create them", UpdateGoldenEnvVar)
+	outputRelPaths := make([]string, 0, len(outputs))
+	for relPath := range outputs {
+		outputRelPaths = append(outputRelPaths, relPath)
+	}
+	slices.Sort(outputRelPaths)
+	require.Equal(t, goldenRelPaths, outputRelPaths, "output files differ from golden files, set %s=1 to update them", UpdateGoldenEnvVar)
+	for _, relPath := range goldenRelPaths {
+		goldenData, err := os.ReadFile(filepath.Join(goldenDirPath, filepath.FromSlash(relPath)))
+		require.NoError(t, err)
+		require.Equal(t, string(goldenData), string(outputs[relPath]), "%s differs from golden file, set %s=1 to update it", relPath, UpdateGoldenEnvVar)
+	}
+}
+
+// NewFlexQueryServer returns a fake Flex Web Service that serves xmlData for
+// queryID. The server is closed when the test completes.
+func NewFlexQueryServer(t testing.TB, queryID string, xmlData []byte) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/SendRequest", func(responseWriter http.ResponseWriter, request *http.Request) {
+		if request.URL.Query().Get("t") != testToken || request.URL.Query().Get("q") != queryID {
+			writeFlexError(responseWriter, "1020", "Invalid request or unable to validate request.")
+			return
+		}
+		_, _ = responseWriter.Write([]byte(
+			"<FlexStatementResponse><Status>Success</Status><ReferenceCode>" + testReferenceCode + "</ReferenceCode></FlexStatementResponse>",
+		))
+	})
+	mux.HandleFunc("/GetStatement", func(responseWriter http.ResponseWriter, request *http.Request) {
+		if request.URL.Query().Get("t") != testToken || request.URL.Query().Get("q") != testReferenceCode {
+			writeFlexError(responseWriter, "1020", "Invalid request or unable to validate request.")
+			return
+		}
+		_, _ = responseWriter.Write(xmlData)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// NewFrankfurterServer returns a fake frankfurter.dev API that serves the rates
+// of pairToDateToRate within the requested date range. The server is closed
+// when the test completes.
+func NewFrankfurterServer(t testing.TB, pairToDateToRate map[string]map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		// The path is /{startDate}..{endDate}.
+		startDate, endDate, ok := strings.Cut(strings.TrimPrefix(request.URL.Path, "/"), "..")
+		if !ok {
+			http.NotFound(responseWriter, request)
+			return
+		}
+		quote := request.URL.Query().Get("symbols")
+		dateToRate := pairToDateToRate[request.URL.Query().Get("base")+"."+quote]
+		rates := make(map[string]map[string]json.Number)
+		for date, rate := range dateToRate {
+			if date >= startDate && date <= endDate {
+				rates[date] = map[string]json.Number{quote: json.Number(rate)}
+			}
+		}
+		writeJSON(responseWriter, map[string]any{"rates": rates})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// NewBankOfCanadaServer returns a fake Bank of Canada valet API that serves the
+// X.CAD rates of pairToDateToRate within the requested date range. The server
+// is closed when the test completes.
+func NewBankOfCanadaServer(t testing.TB, pairToDateToRate map[string]map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		// The path is /FX{BASE}CAD/json.
+		seriesName, ok := strings.CutSuffix(strings.TrimPrefix(request.URL.Path, "/"), "/json")
+		if !ok || !strings.HasPrefix(seriesName, "FX") || !strings.HasSuffix(seriesName, "CAD") {
+			http.NotFound(responseWriter, request)
+			return
+		}
+		base := strings.TrimSuffix(strings.TrimPrefix(seriesName, "FX"), "CAD")
+		startDate := request.URL.Query().Get("start_date")
+		endDate := request.URL.Query().Get("end_date")
+		dateToRate := pairToDateToRate[base+".CAD"]
+		dates := make([]string, 0, len(dateToRate))
+		for date := range dateToRate {
+			if date >= startDate && date <= endDate {
+				dates = append(dates, date)
+			}
+		}
+		slices.Sort(dates)
+		observations := make([]map[string]any, 0, len(dates))
+		for _, date := range dates {
+			observations = append(observations, map[string]any{
+				"d":        date,
+				seriesName: map[string]string{"v": dateToRate[date]},
+			})
+		}
+		writeJSON(responseWriter, map[string]any{"observations": observations})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// NewYahooFinanceServer returns a fake Yahoo Finance chart API that serves
+// the daily closes of symbolToPrices within the requested period, at 14:30
+// UTC on each date, and quotes of the latest close. Unknown symbols are not
+// found. The server is closed when the test completes.
+func NewYahooFinanceServer(t testing.TB, symbolToPrices map[string]*Prices) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		// The path is /v8/finance/chart/{symbol}.
+		prices, ok := symbolToPrices[strings.TrimPrefix(request.URL.Path, "/v8/finance/chart/")]
+		if !ok {
+			responseWriter.WriteHeader(http.StatusNotFound)
+			writeJSON(responseWriter, map[string]any{
+				"chart": map[string]any{
+					"error": map[string]string{"code": "Not Found", "description": "No data found, symbol may be delisted"},
+				},
+			})
+			return
+		}
+		dates := slices.Sorted(maps.Keys(prices.Closes))
+		meta := map[string]any{
+			"currency":             prices.Currency,
+			"exchangeTimezoneName": "UTC",
+			"gmtoffset":            0,
+		}
+		if request.URL.Query().Get("range") != "" {
+			if len(dates) > 0 {
+				meta["regularMarketPrice"] = json.Number(prices.Closes[dates[len(dates)-1]])
+			}
+			writeJSON(responseWriter, map[string]any{"chart": map[string]any{"result": []any{map[string]any{"meta": meta}}}})
+			return
+		}
+		period1, err := strconv.ParseInt(request.URL.Query().Get("period1"), 10, 64)
+		if err != nil {
+			http.Error(responseWriter, err.Error(), http.StatusBadRequest)
+			return
+		}
+		period2, err := strconv.ParseInt(request.URL.Query().Get("period2"), 10, 64)
+		if err != nil {
+			http.Error(responseWriter, err.Error(), http.StatusBadRequest)
+			return
+		}
+		timestamps := []int64{}
+		closes := []json.Number{}
+		for _, date := range dates {
+			dateTime, err := time.Parse("2006-01-02", date)
+			if err != nil {
+				http.Error(responseWriter, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			timestamp := dateTime.Add(14*time.Hour + 30*time.Minute).Unix()
+			if timestamp < period1 || timestamp >= period2 {
+				continue
+			}
+			timestamps = append(timestamps, timestamp)
+			closes = append(closes, json.Number(prices.Closes[date]))
+		}
+		writeJSON(responseWriter, map[string]any{
+			"chart": map[string]any{
+				"result": []any{
+					map[string]any{
+						"meta":       meta,
+						"timestamp":  timestamps,
+						"indicators": map[string]any{"quote": []any{map[string]any{"close": closes}}},
+					},
+				},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// *** PRIVATE ***
+
+// writeFlexError writes a Flex Web Service error response.
+func writeFlexError(responseWriter http.ResponseWriter, code string, message string) {
+	_, _ = responseWriter.Write([]byte(
+		"<FlexStatementResponse><Status>Fail</Status><ErrorCode>" + code + "</ErrorCode><ErrorMessage>" + message + "</ErrorMessage></FlexStatementResponse>",
+	))
+}
+
+// writeJSON writes value as a JSON response.
+func writeJSON(responseWriter http.ResponseWriter, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		http.Error(responseWriter, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	responseWriter.Header().Set("Content-Type", "application/json")
+	_, _ = responseWriter.Write(data)
+}
+
+// normalizeJSONLines compacts each non-empty line of JSON lines data.
+func normalizeJSONLines(data []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	for line := range bytes.Lines(data) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if err := json.Compact(&buffer, line); err != nil {
+			return nil, err
+		}
+		buffer.WriteByte('\n')
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctltesting
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlprices"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixtures(t *testing.T) {
+	t.Parallel()
+	dirEntries, err := os.ReadDir("testdata")
+	require.NoError(t, err)
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() {
+			continue
+		}
+		t.Run(dirEntry.Name(), func(t *testing.T) {
+			t.Parallel()
+			RunFixture(t, filepath.Join("testdata", dirEntry.Name()))
+		})
+	}
+}
+
+func TestPrices(t *testing.T) {
+	t.Parallel()
+	result := RunPipeline(t, filepath.Join("testdata", "basic"))
+	config, err := ibctlconfig.ReadConfig(result.DirPath)
+	require.NoError(t, err)
+	// The download cached the closes of every traded symbol, fetched with its quote symbol.
+	priceStore := ibctlprices.NewStore(config, nil, nil)
+	for symbol, expectedClose := range map[string]string{
+		"AAPL": "201.08",
+		"ASML": "681.4",
+		"SHOP": "150.2",
+	} {
+		price, ok, err := priceStore.Close(symbol, xtime.Date{Year: 2025, Month: time.June, Day: 29})
+		require.NoError(t, err)
+		require.True(t, ok, symbol)
+		require.Equal(t, expectedClose, price.Close, symbol)
+	}
+}
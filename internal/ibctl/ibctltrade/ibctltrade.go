@@ -0,0 +1,462 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctltrade provides trade blotter reporting for ibctl.
+//
+// Trades are listed with their IBKR order ID so that the legs of multi-leg
+// and combo orders (e.g., option spreads), which IBKR reports as separate
+// trades, can be displayed and filtered together, or collapsed into a single
+// row per order.
+//
+// Realized statistics per symbol (win rate, holding period, and returns) are
+// computed from the lots closed by FIFO matching of the trades.
+package ibctltrade
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlnotes"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctltaxlot"
+	"github.com/bufdev/ibctl/internal/pkg/cliio"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+)
+
+// TradeOverview represents a single trade for display.
+type TradeOverview struct {
+	// Date is the trade date (YYYY-MM-DD).
+	Date string `json:"date"`
+	// Account is the account alias.
+	Account string `json:"account"`
+	// OrderID is the IBKR order ID, shared by all legs of an order.
+	OrderID string `json:"order_id,omitempty"`
+	// Symbol is the ticker symbol.
+	Symbol string `json:"symbol"`
+	// Side is "BUY" or "SELL".
+	Side string `json:"side"`
+	// Quantity is the signed quantity.
+	Quantity string `json:"quantity"`
+	// Price is the trade price in native currency.
+	Price string `json:"price"`
+	// Proceeds is the signed proceeds in native currency.
+	Proceeds string `json:"proceeds"`
+	// Commission is the commission in native currency.
+	Commission string `json:"commission"`
+	// Currency is the native currency code.
+	Currency string `json:"currency"`
+	// TradeID is the trade ID.
+	TradeID string `json:"trade_id"`
+	// Tags is the sorted list of tags from the account's notes.
+	Tags []string `json:"tags,omitempty"`
+	// Note is the free-text note from the account's notes.
+	Note string `json:"note,omitempty"`
+}
+
+// TradeListHeaders returns the column headers for trade list table/CSV output.
+func TradeListHeaders() []string {
+	return []string{"DATE", "ACCOUNT", "ORDER", "SYMBOL", "SIDE", "QUANTITY", "PRICE", "PROCEEDS", "COMMISSION", "CURRENCY", "TRADE ID", "TAGS", "NOTE"}
+}
+
+// TradeOverviewToRow converts a TradeOverview to a string slice for table/CSV output.
+func TradeOverviewToRow(t *TradeOverview) []string {
+	return []string{
+		t.Date,
+		t.Account,
+		t.OrderID,
+		t.Symbol,
+		t.Side,
+		t.Quantity,
+		t.Price,
+		t.Proceeds,
+		t.Commission,
+		t.Currency,
+		t.TradeID,
+		strings.Join(t.Tags, ","),
+		t.Note,
+	}
+}
+
+// AnnotateTrades sets the tags and note of each trade from notes.
+func AnnotateTrades(tradeOverviews []*TradeOverview, notes *ibctlnotes.Notes) {
+	for _, t := range tradeOverviews {
+		annotation := notes.TradeAnnotation(t.Account, t.Symbol, t.TradeID)
+		t.Tags = annotation.Tags
+		t.Note = annotation.Note
+	}
+}
+
+// OrderOverview represents all legs of a single order for display.
+type OrderOverview struct {
+	// Date is the trade date of the first leg (YYYY-MM-DD).
+	Date string `json:"date"`
+	// Account is the account alias.
+	Account string `json:"account"`
+	// OrderID is the IBKR order ID. Trades without an order ID are their own order
+	// and use the trade ID instead.
+	OrderID string `json:"order_id"`
+	// Legs is the number of trades in the order.
+	Legs int `json:"legs"`
+	// Symbols is the distinct leg symbols, comma-separated.
+	Symbols string `json:"symbols"`
+	// Proceeds is the net proceeds of all legs in native currency.
+	Proceeds string `json:"proceeds"`
+	// Commission is the total commission of all legs in native currency.
+	Commission string `json:"commission"`
+	// Currency is the native currency code.
+	Currency string `json:"currency"`
+}
+
+// OrderListHeaders returns the column headers for order list table/CSV output.
+func OrderListHeaders() []string {
+	return []string{"DATE", "ACCOUNT", "ORDER", "LEGS", "SYMBOLS", "PROCEEDS", "COMMISSION", "CURRENCY"}
+}
+
+// OrderOverviewToRow converts an OrderOverview to a string slice for table/CSV output.
+func OrderOverviewToRow(o *OrderOverview) []string {
+	return []string{
+		o.Date,
+		o.Account,
+		o.OrderID,
+		fmt.Sprintf("%d", o.Legs),
+		o.Symbols,
+		o.Proceeds,
+		o.Commission,
+		o.Currency,
+	}
+}
+
+// SymbolStats contains the realized statistics for a symbol, computed from
+// its closed lots.
+type SymbolStats struct {
+	// Symbol is the ticker symbol, or "TOTAL" for the totals across all symbols.
+	Symbol string `json:"symbol"`
+	// ClosedLots is the number of closed lots. A lot closed by several trades
+	// counts once per closing trade.
+	ClosedLots int `json:"closed_lots"`
+	// Wins is the number of closed lots with a positive gain.
+	Wins int `json:"wins"`
+	// WinRate is the percentage of closed lots with a positive gain.
+	WinRate string `json:"win_rate"`
+	// AvgHoldingDays is the average number of days the closed lots were held.
+	AvgHoldingDays string `json:"avg_holding_days"`
+	// AvgReturn is the average return of the closed lots on their cost basis.
+	AvgReturn string `json:"avg_return"`
+	// BestUSD is the largest gain of a closed lot in USD.
+	BestUSD string `json:"best_usd"`
+	// BestDate is the close date of the best closed lot (YYYY-MM-DD).
+	BestDate string `json:"best_date"`
+	// WorstUSD is the smallest gain (largest loss) of a closed lot in USD.
+	WorstUSD string `json:"worst_usd"`
+	// WorstDate is the close date of the worst closed lot (YYYY-MM-DD).
+	WorstDate string `json:"worst_date"`
+	// RealizedPnLUSD is the total realized gain of the closed lots in USD.
+	RealizedPnLUSD string `json:"realized_pnl_usd"`
+}
+
+// SymbolStatsHeaders returns the column headers for symbol stats table/CSV output.
+func SymbolStatsHeaders() []string {
+	return []string{"SYMBOL", "CLOSED", "WINS", "WIN RATE", "AVG DAYS", "AVG RETURN", "BEST", "BEST DATE", "WORST", "WORST DATE", "REALIZED P&L"}
+}
+
+// SymbolStatsToRow converts a SymbolStats to a string slice for CSV output.
+func SymbolStatsToRow(s *SymbolStats) []string {
+	return []string{
+		s.Symbol,
+		fmt.Sprintf("%d", s.ClosedLots),
+		fmt.Sprintf("%d", s.Wins),
+		s.WinRate,
+		s.AvgHoldingDays,
+		s.AvgReturn,
+		s.BestUSD,
+		s.BestDate,
+		s.WorstUSD,
+		s.WorstDate,
+		s.RealizedPnLUSD,
+	}
+}
+
+// SymbolStatsToTableRow converts a SymbolStats to a string slice for table
+// output, with USD values formatted.
+func SymbolStatsToTableRow(s *SymbolStats) []string {
+	row := SymbolStatsToRow(s)
+	row[6] = cliio.FormatUSD(s.BestUSD)
+	row[8] = cliio.FormatUSD(s.WorstUSD)
+	row[10] = cliio.FormatUSD(s.RealizedPnLUSD)
+	return row
+}
+
+// GetSymbolStats computes the realized statistics of each symbol from the lots
+// closed by FIFO matching of the trades, sorted by symbol, followed by the
+// totals across all symbols.
+//
+// If year is non-zero, only lots closed in that year are included. If symbol
+// is set, only that symbol is included. Returns are on the cost basis of each
+// lot in its trade currency, and gains are converted to USD at the latest
+// rate. Gains that cannot be converted count as zero in the USD columns.
+// Symbols in averageCostSymbols use the average cost basis method. Tax lots
+// are cached under cacheTaxLotsDirPath if it is non-empty.
+func GetSymbolStats(
+	trades []*datav1.Trade,
+	year int,
+	symbol string,
+	averageCostSymbols map[string]struct{},
+	cacheTaxLotsDirPath string,
+	fxStore *ibctlfxrates.Store,
+) ([]*SymbolStats, error) {
+	// Filter out CASH asset category trades (FX conversions like USD.CAD).
+	var securityTrades []*datav1.Trade
+	for _, trade := range trades {
+		if trade.GetAssetCategory() != assetCategoryCash {
+			securityTrades = append(securityTrades, trade)
+		}
+	}
+	taxLotResult, err := ibctltaxlot.ComputeTaxLotsCached(cacheTaxLotsDirPath, securityTrades, averageCostSymbols)
+	if err != nil {
+		return nil, err
+	}
+	symbolToAccumulator := make(map[string]*statsAccumulator)
+	total := &statsAccumulator{}
+	for _, gain := range taxLotResult.RealizedGains {
+		if year != 0 && gain.CloseDate.Year != year {
+			continue
+		}
+		if symbol != "" && gain.Symbol != symbol {
+			continue
+		}
+		accumulator, ok := symbolToAccumulator[gain.Symbol]
+		if !ok {
+			accumulator = &statsAccumulator{}
+			symbolToAccumulator[gain.Symbol] = accumulator
+		}
+		var gainUSDMicros int64
+		if gainUSD, ok := fxStore.ConvertToUSD(moneypb.MoneyFromMicros(gain.CurrencyCode, gain.GainMicros)); ok {
+			gainUSDMicros = moneypb.MoneyToMicros(gainUSD)
+		}
+		accumulator.add(gain, gainUSDMicros)
+		total.add(gain, gainUSDMicros)
+	}
+	symbols := make([]string, 0, len(symbolToAccumulator))
+	for s := range symbolToAccumulator {
+		symbols = append(symbols, s)
+	}
+	sort.Strings(symbols)
+	stats := make([]*SymbolStats, 0, len(symbols)+1)
+	for _, s := range symbols {
+		stats = append(stats, symbolToAccumulator[s].stats(s))
+	}
+	return append(stats, total.stats("TOTAL")), nil
+}
+
+// GetTradeList returns the trades for display, sorted by date, account, and
+// order so that the legs of each order are adjacent.
+//
+// If orderID is set, only the legs of that order are returned. If symbol is
+// set, all legs of every order with a leg in that symbol are returned, so
+// that spreads are never shown partially.
+func GetTradeList(trades []*datav1.Trade, orderID string, symbol string) []*TradeOverview {
+	trades = filterTrades(trades, orderID, symbol)
+	tradeOverviews := make([]*TradeOverview, 0, len(trades))
+	for _, trade := range trades {
+		tradeOverviews = append(tradeOverviews, &TradeOverview{
+			Date:       protoDateString(trade.GetTradeDate()),
+			Account:    trade.GetAccountId(),
+			OrderID:    trade.GetOrderId(),
+			Symbol:     trade.GetSymbol(),
+			Side:       strings.TrimPrefix(trade.GetSide().String(), "TRADE_SIDE_"),
+			Quantity:   mathpb.ToString(trade.GetQuantity()),
+			Price:      moneypb.MoneyValueToString(trade.GetTradePrice()),
+			Proceeds:   moneypb.MoneyValueToString(trade.GetProceeds()),
+			Commission: moneypb.MoneyValueToString(trade.GetCommission()),
+			Currency:   trade.GetCurrencyCode(),
+			TradeID:    trade.GetTradeId(),
+		})
+	}
+	return tradeOverviews
+}
+
+// GetOrderList returns one row per order, combining the legs of each order.
+// Filtering is the same as for GetTradeList.
+func GetOrderList(trades []*datav1.Trade, orderID string, symbol string) []*OrderOverview {
+	trades = filterTrades(trades, orderID, symbol)
+	var orderOverviews []*OrderOverview
+	type orderTotals struct {
+		overview         *OrderOverview
+		symbols          []string
+		proceedsMicros   int64
+		commissionMicros int64
+	}
+	keyToOrderTotals := make(map[string]*orderTotals)
+	var keys []string
+	for _, trade := range trades {
+		key := orderKey(trade)
+		totals, ok := keyToOrderTotals[key]
+		if !ok {
+			id := trade.GetOrderId()
+			if id == "" {
+				id = trade.GetTradeId()
+			}
+			totals = &orderTotals{
+				overview: &OrderOverview{
+					Date:     protoDateString(trade.GetTradeDate()),
+					Account:  trade.GetAccountId(),
+					OrderID:  id,
+					Currency: trade.GetCurrencyCode(),
+				},
+			}
+			keyToOrderTotals[key] = totals
+			keys = append(keys, key)
+		}
+		totals.overview.Legs++
+		if !slices.Contains(totals.symbols, trade.GetSymbol()) {
+			totals.symbols = append(totals.symbols, trade.GetSymbol())
+		}
+		totals.proceedsMicros += moneypb.MoneyToMicros(trade.GetProceeds())
+		totals.commissionMicros += moneypb.MoneyToMicros(trade.GetCommission())
+	}
+	// Keys are in trade order, which is already sorted.
+	for _, key := range keys {
+		totals := keyToOrderTotals[key]
+		totals.overview.Symbols = strings.Join(totals.symbols, ",")
+		totals.overview.Proceeds = moneypb.MoneyValueToString(moneypb.MoneyFromMicros(totals.overview.Currency, totals.proceedsMicros))
+		totals.overview.Commission = moneypb.MoneyValueToString(moneypb.MoneyFromMicros(totals.overview.Currency, totals.commissionMicros))
+		orderOverviews = append(orderOverviews, totals.overview)
+	}
+	return orderOverviews
+}
+
+// *** PRIVATE ***
+
+// assetCategoryCash is the asset category for FX conversion trades.
+const assetCategoryCash = "CASH"
+
+// statsAccumulator accumulates the closed lots of a symbol for SymbolStats.
+type statsAccumulator struct {
+	closedLots int
+	wins       int
+	// holdingDays is the total days held across closed lots.
+	holdingDays int
+	// returnSum is the sum of the per-lot returns, as fractions.
+	returnSum float64
+	// returnLots is the number of closed lots with a cost basis.
+	returnLots     int
+	best           *ibctltaxlot.RealizedGain
+	bestUSDMicros  int64
+	worst          *ibctltaxlot.RealizedGain
+	worstUSDMicros int64
+	pnlUSDMicros   int64
+}
+
+func (a *statsAccumulator) add(gain ibctltaxlot.RealizedGain, gainUSDMicros int64) {
+	a.closedLots++
+	if gain.GainMicros > 0 {
+		a.wins++
+	}
+	a.holdingDays += gain.CloseDate.DaysSince(gain.OpenDate)
+	if gain.CostMicros != 0 {
+		a.returnSum += float64(gain.GainMicros) / float64(gain.CostMicros)
+		a.returnLots++
+	}
+	if a.best == nil || gainUSDMicros > a.bestUSDMicros {
+		a.best = &gain
+		a.bestUSDMicros = gainUSDMicros
+	}
+	if a.worst == nil || gainUSDMicros < a.worstUSDMicros {
+		a.worst = &gain
+		a.worstUSDMicros = gainUSDMicros
+	}
+	a.pnlUSDMicros += gainUSDMicros
+}
+
+func (a *statsAccumulator) stats(symbol string) *SymbolStats {
+	stats := &SymbolStats{
+		Symbol:         symbol,
+		ClosedLots:     a.closedLots,
+		Wins:           a.wins,
+		RealizedPnLUSD: usdString(a.pnlUSDMicros),
+	}
+	if a.closedLots == 0 {
+		return stats
+	}
+	stats.WinRate = fmt.Sprintf("%.2f%%", float64(a.wins)/float64(a.closedLots)*100)
+	stats.AvgHoldingDays = fmt.Sprintf("%.0f", float64(a.holdingDays)/float64(a.closedLots))
+	if a.returnLots > 0 {
+		stats.AvgReturn = fmt.Sprintf("%+.2f%%", a.returnSum/float64(a.returnLots)*100)
+	}
+	stats.BestUSD = usdString(a.bestUSDMicros)
+	stats.BestDate = a.best.CloseDate.String()
+	stats.WorstUSD = usdString(a.worstUSDMicros)
+	stats.WorstDate = a.worst.CloseDate.String()
+	return stats
+}
+
+// usdString formats micros as a USD value string.
+func usdString(micros int64) string {
+	return moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", micros))
+}
+
+// filterTrades returns the sorted trades matching orderID and symbol. See GetTradeList.
+func filterTrades(trades []*datav1.Trade, orderID string, symbol string) []*datav1.Trade {
+	// Select the orders with a leg in the symbol, so all their legs are kept.
+	symbolOrderKeys := make(map[string]struct{})
+	if symbol != "" {
+		for _, trade := range trades {
+			if trade.GetSymbol() == symbol {
+				symbolOrderKeys[orderKey(trade)] = struct{}{}
+			}
+		}
+	}
+	var filtered []*datav1.Trade
+	for _, trade := range trades {
+		if orderID != "" && trade.GetOrderId() != orderID {
+			continue
+		}
+		if symbol != "" {
+			if _, ok := symbolOrderKeys[orderKey(trade)]; !ok {
+				continue
+			}
+		}
+		filtered = append(filtered, trade)
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		dateI := protoDateString(filtered[i].GetTradeDate())
+		dateJ := protoDateString(filtered[j].GetTradeDate())
+		if dateI != dateJ {
+			return dateI < dateJ
+		}
+		if filtered[i].GetAccountId() != filtered[j].GetAccountId() {
+			return filtered[i].GetAccountId() < filtered[j].GetAccountId()
+		}
+		if orderKey(filtered[i]) != orderKey(filtered[j]) {
+			return orderKey(filtered[i]) < orderKey(filtered[j])
+		}
+		return filtered[i].GetSymbol() < filtered[j].GetSymbol()
+	})
+	return filtered
+}
+
+// orderKey returns the key grouping the legs of an order. Trades without an
+// order ID are their own order.
+func orderKey(trade *datav1.Trade) string {
+	if orderID := trade.GetOrderId(); orderID != "" {
+		return trade.GetAccountId() + "/order/" + orderID
+	}
+	return trade.GetAccountId() + "/trade/" + trade.GetTradeId()
+}
+
+// protoDateString returns a sortable YYYY-MM-DD string from a proto Date.
+func protoDateString(d interface {
+	GetYear() uint32
+	GetMonth() uint32
+	GetDay() uint32
+}) string {
+	if d == nil {
+		return ""
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", d.GetYear(), d.GetMonth(), d.GetDay())
+}
@@ -0,0 +1,132 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctltrade
+
+import (
+	"fmt"
+	"testing"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	timev1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/time/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetOrderList(t *testing.T) {
+	t.Parallel()
+	trades := []*datav1.Trade{
+		newTrade("1", "100", "SPY 250620C00600000", 1, -500),
+		newTrade("2", "100", "SPY 250620C00610000", -1, 300),
+		newTrade("3", "", "AAPL", 10, -2000),
+		newTrade("4", "200", "SPY", 5, -3000),
+	}
+	orderOverviews := GetOrderList(trades, "", "")
+	require.Len(t, orderOverviews, 3)
+	require.Equal(t, "100", orderOverviews[0].OrderID)
+	require.Equal(t, 2, orderOverviews[0].Legs)
+	require.Equal(t, "SPY 250620C00600000,SPY 250620C00610000", orderOverviews[0].Symbols)
+	require.Equal(t, "-200", orderOverviews[0].Proceeds)
+	// A trade without an order ID is its own order.
+	require.Equal(t, "3", orderOverviews[2].OrderID)
+	// Filtering by one leg's symbol keeps every leg of the order.
+	tradeOverviews := GetTradeList(trades, "", "SPY 250620C00610000")
+	require.Len(t, tradeOverviews, 2)
+	require.Equal(t, "1", tradeOverviews[0].TradeID)
+	require.Equal(t, "2", tradeOverviews[1].TradeID)
+	require.Len(t, GetTradeList(trades, "200", ""), 1)
+}
+
+func TestGetSymbolStats(t *testing.T) {
+	t.Parallel()
+	trades := []*datav1.Trade{
+		// Two AAPL lots closed by one sell: a 50% win held 30 days and a 25% loss held 10 days.
+		newPricedTrade("AAPL", datav1.TradeSide_TRADE_SIDE_BUY, 2025, 1, 1, 10, 100),
+		newPricedTrade("AAPL", datav1.TradeSide_TRADE_SIDE_BUY, 2025, 1, 21, 10, 200),
+		newPricedTrade("AAPL", datav1.TradeSide_TRADE_SIDE_SELL, 2025, 1, 31, -20, 150),
+		// A short MSFT lot bought back at a profit of 20%.
+		newPricedTrade("MSFT", datav1.TradeSide_TRADE_SIDE_SELL, 2025, 2, 1, -5, 50),
+		newPricedTrade("MSFT", datav1.TradeSide_TRADE_SIDE_BUY, 2025, 2, 5, 5, 40),
+		// A lot closed in another year.
+		newPricedTrade("NET", datav1.TradeSide_TRADE_SIDE_BUY, 2024, 1, 1, 1, 10),
+		newPricedTrade("NET", datav1.TradeSide_TRADE_SIDE_SELL, 2024, 6, 1, -1, 20),
+	}
+	stats, err := GetSymbolStats(trades, 2025, "", nil, "", ibctlfxrates.NewStore(t.TempDir()))
+	require.NoError(t, err)
+	require.Equal(t, []*SymbolStats{
+		{
+			Symbol:         "AAPL",
+			ClosedLots:     2,
+			Wins:           1,
+			WinRate:        "50.00%",
+			AvgHoldingDays: "20",
+			AvgReturn:      "+12.50%",
+			BestUSD:        "500",
+			BestDate:       "2025-01-31",
+			WorstUSD:       "-500",
+			WorstDate:      "2025-01-31",
+			RealizedPnLUSD: "0",
+		},
+		{
+			Symbol:         "MSFT",
+			ClosedLots:     1,
+			Wins:           1,
+			WinRate:        "100.00%",
+			AvgHoldingDays: "4",
+			AvgReturn:      "+20.00%",
+			BestUSD:        "50",
+			BestDate:       "2025-02-05",
+			WorstUSD:       "50",
+			WorstDate:      "2025-02-05",
+			RealizedPnLUSD: "50",
+		},
+		{
+			Symbol:         "TOTAL",
+			ClosedLots:     3,
+			Wins:           2,
+			WinRate:        "66.67%",
+			AvgHoldingDays: "15",
+			AvgReturn:      "+15.00%",
+			BestUSD:        "500",
+			BestDate:       "2025-01-31",
+			WorstUSD:       "-500",
+			WorstDate:      "2025-01-31",
+			RealizedPnLUSD: "50",
+		},
+	}, stats)
+	stats, err = GetSymbolStats(trades, 0, "NET", nil, "", ibctlfxrates.NewStore(t.TempDir()))
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	require.Equal(t, "NET", stats[0].Symbol)
+	require.Equal(t, "+100.00%", stats[0].AvgReturn)
+}
+
+func newPricedTrade(symbol string, side datav1.TradeSide, year uint32, month uint32, day uint32, quantity int64, price int64) *datav1.Trade {
+	return &datav1.Trade{
+		TradeId:      fmt.Sprintf("%s-%d-%d-%d", symbol, year, month, day),
+		TradeDate:    &timev1.Date{Year: year, Month: month, Day: day},
+		Symbol:       symbol,
+		Side:         side,
+		Quantity:     mathpb.FromMicros(quantity * 1_000_000),
+		TradePrice:   moneypb.MoneyFromMicros("USD", price*1_000_000),
+		CurrencyCode: "USD",
+		AccountId:    "individual",
+	}
+}
+
+func newTrade(tradeID string, orderID string, symbol string, quantity int64, proceeds int64) *datav1.Trade {
+	return &datav1.Trade{
+		TradeId:      tradeID,
+		OrderId:      orderID,
+		TradeDate:    &timev1.Date{Year: 2025, Month: 3, Day: 1},
+		Symbol:       symbol,
+		Quantity:     mathpb.FromMicros(quantity * 1_000_000),
+		Proceeds:     moneypb.MoneyFromMicros("USD", proceeds*1_000_000),
+		Commission:   moneypb.MoneyFromMicros("USD", -1_000_000),
+		CurrencyCode: "USD",
+		AccountId:    "individual",
+	}
+}
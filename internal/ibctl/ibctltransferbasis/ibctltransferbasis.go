@@ -0,0 +1,317 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctltransferbasis imports per-lot cost basis for positions
+// transferred into IBKR, from CSV exports of the IBKR Position Transfer Basis tool.
+//
+// FOP transfers carry no transfer price, so FIFO has no lots for the
+// transferred positions. Each row of the export becomes a synthetic buy trade
+// on the acquisition date at the lot's cost basis, with the "transfer_basis"
+// source so that the resulting tax lots record where their basis came from.
+//
+// Imported trades are stored per account in data/manual/<alias>/transfer_basis.json
+// and are merged alongside manually entered trades.
+//
+// For transfers out of IBKR, WriteCSV writes tax lots in the same format, for
+// the cost basis upload tools of receiving brokers.
+package ibctltransferbasis
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"buf.build/go/protovalidate"
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlholdings"
+	"github.com/bufdev/ibctl/internal/pkg/filemode"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/bufdev/ibctl/internal/pkg/protoio"
+	"github.com/bufdev/ibctl/internal/pkg/timepb"
+)
+
+// FileName is the name of the transfer basis file within each manual account directory.
+const FileName = "transfer_basis.json"
+
+// Source is the Trade source of imported transfer basis lots.
+const Source = "transfer_basis"
+
+// Column names accepted in the CSV header, lowercased. The first matching
+// name in each list is used.
+var (
+	symbolColumnNames    = []string{"symbol"}
+	quantityColumnNames  = []string{"quantity", "qty", "shares"}
+	dateColumnNames      = []string{"acquisition date", "date acquired", "open date", "trade date"}
+	unitCostColumnNames  = []string{"unit cost", "cost basis price", "cost price", "price"}
+	totalCostColumnNames = []string{"cost basis", "total cost", "cost"}
+	currencyColumnNames  = []string{"currency"}
+	assetCategoryNames   = []string{"asset category", "asset class"}
+)
+
+// dateLayouts are the accepted acquisition date formats.
+var dateLayouts = []string{time.DateOnly, "20060102", "01/02/2006", "1/2/2006"}
+
+// exportDateLayout is the acquisition date format written by WriteCSV, which
+// receiving brokers' cost basis upload tools expect.
+const exportDateLayout = "01/02/2006"
+
+// Lot is a single tax lot as written by WriteCSV.
+type Lot struct {
+	// Account is the account alias.
+	Account string `json:"account"`
+	// Symbol is the ticker symbol.
+	Symbol string `json:"symbol"`
+	// Quantity is the remaining quantity in the lot.
+	Quantity string `json:"quantity"`
+	// AcquisitionDate is the lot open date (MM/DD/YYYY).
+	AcquisitionDate string `json:"acquisition_date"`
+	// UnitCost is the cost basis price per share in the native currency.
+	UnitCost string `json:"unit_cost"`
+	// CostBasis is the total cost basis of the lot in the native currency.
+	CostBasis string `json:"cost_basis"`
+	// Currency is the native currency code.
+	Currency string `json:"currency"`
+}
+
+// NewLots converts lots from a lot list to Lots.
+func NewLots(lots []*ibctlholdings.LotOverview) ([]*Lot, error) {
+	transferLots := make([]*Lot, 0, len(lots))
+	for _, l := range lots {
+		openDate, err := time.Parse(time.DateOnly, l.Date)
+		if err != nil {
+			return nil, fmt.Errorf("lot %s: invalid open date %q", l.LotID, l.Date)
+		}
+		quantityMicros := mathpb.ToMicros(l.Quantity)
+		unitCostMicros := mathpb.ParseMicros(l.AveragePrice)
+		// Scale by whole units and the remainder separately, as ParseCSV does, to avoid overflowing int64.
+		costBasisMicros := unitCostMicros*(quantityMicros/1_000_000) + unitCostMicros*(quantityMicros%1_000_000)/1_000_000
+		transferLots = append(transferLots, &Lot{
+			Account:         l.Account,
+			Symbol:          l.Symbol,
+			Quantity:        mathpb.ToString(l.Quantity),
+			AcquisitionDate: openDate.Format(exportDateLayout),
+			UnitCost:        l.AveragePrice,
+			CostBasis:       moneypb.MoneyValueToString(moneypb.MoneyFromMicros(l.Currency, costBasisMicros)),
+			Currency:        l.Currency,
+		})
+	}
+	return transferLots, nil
+}
+
+// Headers returns the CSV column headers written by WriteCSV, which ParseCSV
+// also accepts.
+func Headers() []string {
+	return []string{"Account", "Symbol", "Quantity", "Acquisition Date", "Unit Cost", "Cost Basis", "Currency"}
+}
+
+// LotToRow converts a Lot to a CSV row aligned with Headers.
+func LotToRow(l *Lot) []string {
+	return []string{
+		l.Account,
+		l.Symbol,
+		l.Quantity,
+		l.AcquisitionDate,
+		l.UnitCost,
+		l.CostBasis,
+		l.Currency,
+	}
+}
+
+// WriteCSV writes the lots as CSV with a header row.
+func WriteCSV(writer io.Writer, lots []*Lot) error {
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.Write(Headers()); err != nil {
+		return err
+	}
+	for _, l := range lots {
+		if err := csvWriter.Write(LotToRow(l)); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// ParseCSV parses a Position Transfer Basis CSV export into synthetic buy
+// trades for the account.
+//
+// The header must have symbol, quantity, and acquisition date columns, and
+// either a unit cost or a total cost column. Currency defaults to USD and
+// asset category to STK. Rows without a symbol or quantity (e.g., totals) are
+// skipped.
+func ParseCSV(reader io.Reader, accountAlias string) ([]*datav1.Trade, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("reading transfer basis CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("transfer basis CSV is empty")
+	}
+	header := make(map[string]int, len(records[0]))
+	for i, name := range records[0] {
+		// Strip a UTF-8 byte order mark, which spreadsheet exports often add.
+		header[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	symbolIndex := columnIndex(header, symbolColumnNames)
+	quantityIndex := columnIndex(header, quantityColumnNames)
+	dateIndex := columnIndex(header, dateColumnNames)
+	unitCostIndex := columnIndex(header, unitCostColumnNames)
+	totalCostIndex := columnIndex(header, totalCostColumnNames)
+	currencyIndex := columnIndex(header, currencyColumnNames)
+	assetCategoryIndex := columnIndex(header, assetCategoryNames)
+	if symbolIndex < 0 || quantityIndex < 0 || dateIndex < 0 {
+		return nil, errors.New("transfer basis CSV must have symbol, quantity, and acquisition date columns")
+	}
+	if unitCostIndex < 0 && totalCostIndex < 0 {
+		return nil, errors.New("transfer basis CSV must have a unit cost or total cost column")
+	}
+	var trades []*datav1.Trade
+	for i, record := range records[1:] {
+		// Line numbers are 1-based and include the header.
+		line := i + 2
+		field := func(index int) string {
+			if index < 0 || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+		symbol := field(symbolIndex)
+		if symbol == "" || field(quantityIndex) == "" {
+			continue
+		}
+		trade, err := newTrade(
+			accountAlias,
+			symbol,
+			field(quantityIndex),
+			field(dateIndex),
+			field(unitCostIndex),
+			field(totalCostIndex),
+			field(currencyIndex),
+			field(assetCategoryIndex),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		trades = append(trades, trade)
+	}
+	if len(trades) == 0 {
+		return nil, errors.New("transfer basis CSV has no lots")
+	}
+	return trades, nil
+}
+
+// WriteTrades replaces the imported transfer basis trades of the account
+// under dataManualDirPath.
+func WriteTrades(dataManualDirPath string, accountAlias string, trades []*datav1.Trade) error {
+	accountDirPath := filepath.Join(dataManualDirPath, accountAlias)
+	if err := filemode.MkdirAll(accountDirPath); err != nil {
+		return err
+	}
+	return protoio.WriteMessagesJSON(filepath.Join(accountDirPath, FileName), trades)
+}
+
+// *** PRIVATE ***
+
+// columnIndex returns the index of the first of names in the header, or -1.
+func columnIndex(header map[string]int, names []string) int {
+	for _, name := range names {
+		if index, ok := header[name]; ok {
+			return index
+		}
+	}
+	return -1
+}
+
+// newTrade returns the synthetic buy trade for a single lot.
+func newTrade(
+	accountAlias string,
+	symbol string,
+	quantity string,
+	date string,
+	unitCost string,
+	totalCost string,
+	currencyCode string,
+	assetCategory string,
+) (*datav1.Trade, error) {
+	quantityMicros := parseMicros(quantity)
+	if quantityMicros <= 0 {
+		return nil, fmt.Errorf("%s: quantity must be positive, got %q", symbol, quantity)
+	}
+	acquisitionDate, err := parseDate(date)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", symbol, err)
+	}
+	protoDate, err := timepb.NewProtoDate(acquisitionDate.Year(), acquisitionDate.Month(), acquisitionDate.Day())
+	if err != nil {
+		return nil, err
+	}
+	// Prefer the unit cost, and derive it from the total cost otherwise.
+	var unitCostMicros, totalCostMicros int64
+	if unitCost != "" {
+		unitCostMicros = parseMicros(unitCost)
+		totalCostMicros = unitCostMicros*(quantityMicros/1_000_000) + unitCostMicros*(quantityMicros%1_000_000)/1_000_000
+	} else {
+		totalCostMicros = parseMicros(totalCost)
+		// Divide in floating point to avoid overflowing int64 when scaling the total cost.
+		unitCostMicros = int64(float64(totalCostMicros) / float64(quantityMicros) * 1_000_000)
+	}
+	if unitCostMicros < 0 || totalCostMicros < 0 {
+		return nil, fmt.Errorf("%s: cost must not be negative", symbol)
+	}
+	if currencyCode == "" {
+		currencyCode = "USD"
+	}
+	currencyCode = strings.ToUpper(currencyCode)
+	if assetCategory == "" {
+		assetCategory = "STK"
+	}
+	dateString := acquisitionDate.Format(time.DateOnly)
+	hash := sha256.Sum256(fmt.Appendf(nil, "%s|%s|%s|%d|%d", accountAlias, symbol, dateString, quantityMicros, unitCostMicros))
+	trade := &datav1.Trade{
+		TradeId:       fmt.Sprintf("transfer-basis-%x", hash[:8]),
+		TradeDate:     protoDate,
+		SettleDate:    protoDate,
+		Symbol:        symbol,
+		Description:   "Transfer basis lot acquired " + dateString,
+		AssetCategory: assetCategory,
+		Side:          datav1.TradeSide_TRADE_SIDE_BUY,
+		Quantity:      mathpb.FromMicros(quantityMicros),
+		TradePrice:    moneypb.MoneyFromMicros(currencyCode, unitCostMicros),
+		Proceeds:      moneypb.MoneyFromMicros(currencyCode, -totalCostMicros),
+		Commission:    moneypb.MoneyFromMicros(currencyCode, 0),
+		CurrencyCode:  currencyCode,
+		AccountId:     accountAlias,
+		Source:        Source,
+	}
+	if err := protovalidate.Validate(trade); err != nil {
+		return nil, fmt.Errorf("%s: %w", symbol, err)
+	}
+	return trade, nil
+}
+
+// parseMicros parses a decimal value, ignoring thousands separators and a
+// leading dollar sign. Returns 0 for empty or invalid input.
+func parseMicros(value string) int64 {
+	value = strings.TrimPrefix(strings.ReplaceAll(value, ",", ""), "$")
+	return mathpb.ParseMicros(value)
+}
+
+// parseDate parses an acquisition date in any of the accepted layouts.
+func parseDate(value string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid acquisition date %q", value)
+}
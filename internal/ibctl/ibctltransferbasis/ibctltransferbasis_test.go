@@ -0,0 +1,71 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctltransferbasis
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlholdings"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCSV(t *testing.T) {
+	t.Parallel()
+	trades, err := ParseCSV(strings.NewReader(`Symbol,Quantity,Date Acquired,Cost Basis,Currency
+AAPL,10,03/15/2019,"1,500.00",USD
+SHOP,4,2020-06-01,2000,CAD
+Total,,,,
+`), "individual")
+	require.NoError(t, err)
+	require.Len(t, trades, 2)
+	require.Equal(t, "AAPL", trades[0].GetSymbol())
+	require.Equal(t, "10", mathpb.ToString(trades[0].GetQuantity()))
+	require.Equal(t, int64(150_000_000), moneypb.MoneyToMicros(trades[0].GetTradePrice()))
+	require.Equal(t, uint32(2019), trades[0].GetTradeDate().GetYear())
+	require.Equal(t, Source, trades[0].GetSource())
+	require.Equal(t, "CAD", trades[1].GetCurrencyCode())
+	require.Equal(t, int64(500_000_000), moneypb.MoneyToMicros(trades[1].GetTradePrice()))
+
+	_, err = ParseCSV(strings.NewReader("Symbol,Quantity\nAAPL,10\n"), "individual")
+	require.Error(t, err)
+	_, err = ParseCSV(strings.NewReader("Symbol,Quantity,Acquisition Date,Unit Cost\nAAPL,10,not-a-date,1\n"), "individual")
+	require.Error(t, err)
+}
+
+func TestWriteCSV(t *testing.T) {
+	t.Parallel()
+	quantity, err := mathpb.NewDecimal("2.5")
+	require.NoError(t, err)
+	lots, err := NewLots([]*ibctlholdings.LotOverview{
+		{
+			Symbol:       "SHOP",
+			Account:      "individual",
+			Date:         "2020-06-01",
+			Quantity:     quantity,
+			Currency:     "CAD",
+			AveragePrice: "500.5",
+			LotID:        "individual/SHOP/2020-06-01/1",
+		},
+	})
+	require.NoError(t, err)
+	var buffer bytes.Buffer
+	require.NoError(t, WriteCSV(&buffer, lots))
+	require.Equal(t, `Account,Symbol,Quantity,Acquisition Date,Unit Cost,Cost Basis,Currency
+individual,SHOP,2.5,06/01/2020,500.5,1251.25,CAD
+`, buffer.String())
+
+	// The written CSV can be imported back.
+	trades, err := ParseCSV(&buffer, "individual")
+	require.NoError(t, err)
+	require.Len(t, trades, 1)
+	require.Equal(t, "2.5", mathpb.ToString(trades[0].GetQuantity()))
+	require.Equal(t, int64(500_500_000), moneypb.MoneyToMicros(trades[0].GetTradePrice()))
+	require.Equal(t, int64(-1_251_250_000), moneypb.MoneyToMicros(trades[0].GetProceeds()))
+	require.Equal(t, uint32(2020), trades[0].GetTradeDate().GetYear())
+}
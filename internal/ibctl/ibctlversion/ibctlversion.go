@@ -0,0 +1,130 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctlversion provides the build metadata of the ibctl binary.
+//
+// Release builds set the metadata with -ldflags, for example:
+//
+//	go build -ldflags "-X github.com/bufdev/ibctl/internal/ibctl/ibctlversion.version=v1.2.3 \
+//	  -X github.com/bufdev/ibctl/internal/ibctl/ibctlversion.commit=$(git rev-parse HEAD) \
+//	  -X github.com/bufdev/ibctl/internal/ibctl/ibctlversion.date=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/ibctl
+//
+// Other builds fall back to the module version and VCS information that the Go
+// toolchain embeds, if any.
+package ibctlversion
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// DevVersion is the version of builds without version information.
+const DevVersion = "dev"
+
+// Set at build time with -ldflags -X.
+var (
+	version string
+	commit  string
+	date    string
+)
+
+// Info is the build metadata of the binary.
+type Info struct {
+	// Version is the release version (e.g., "v1.2.3"), or DevVersion.
+	Version string `json:"version"`
+	// Commit is the git commit the binary was built from, with a "-dirty"
+	// suffix for uncommitted changes, or empty if unknown.
+	Commit string `json:"commit,omitempty"`
+	// Date is the build or commit date in RFC 3339 format, or empty if unknown.
+	Date string `json:"date,omitempty"`
+	// GoVersion is the Go version the binary was built with.
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build metadata of the binary.
+func Get() Info {
+	info := Info{
+		Version:   version,
+		Commit:    commit,
+		Date:      date,
+		GoVersion: runtime.Version(),
+	}
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && buildInfo.Main.Version != "(devel)" {
+			info.Version = buildInfo.Main.Version
+		}
+		var revision, revisionTime string
+		var modified bool
+		for _, setting := range buildInfo.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				revision = setting.Value
+			case "vcs.time":
+				revisionTime = setting.Value
+			case "vcs.modified":
+				modified = setting.Value == "true"
+			}
+		}
+		if info.Commit == "" && revision != "" {
+			info.Commit = revision
+			if modified {
+				info.Commit += "-dirty"
+			}
+		}
+		if info.Date == "" {
+			info.Date = revisionTime
+		}
+	}
+	if info.Version == "" {
+		info.Version = DevVersion
+	}
+	return info
+}
+
+// IsReleaseVersion returns true if the version is a release version (vMAJOR.MINOR.PATCH).
+func IsReleaseVersion(version string) bool {
+	_, ok := parseVersion(version)
+	return ok
+}
+
+// CompareVersions compares two release versions (vMAJOR.MINOR.PATCH),
+// returning -1, 0, or +1. Returns false if either is not a release version.
+func CompareVersions(a string, b string) (int, bool) {
+	aParts, ok := parseVersion(a)
+	if !ok {
+		return 0, false
+	}
+	bParts, ok := parseVersion(b)
+	if !ok {
+		return 0, false
+	}
+	for i := range aParts {
+		if aParts[i] < bParts[i] {
+			return -1, true
+		}
+		if aParts[i] > bParts[i] {
+			return +1, true
+		}
+	}
+	return 0, true
+}
+
+// parseVersion parses a vMAJOR.MINOR.PATCH version.
+func parseVersion(s string) ([3]int, bool) {
+	var parts [3]int
+	fields := strings.Split(strings.TrimPrefix(s, "v"), ".")
+	if !strings.HasPrefix(s, "v") || len(fields) != 3 {
+		return parts, false
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
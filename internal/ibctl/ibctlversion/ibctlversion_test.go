@@ -0,0 +1,32 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctlversion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareVersions(t *testing.T) {
+	t.Parallel()
+	for _, testCase := range []struct {
+		a        string
+		b        string
+		expected int
+		ok       bool
+	}{
+		{"v1.2.3", "v1.2.3", 0, true},
+		{"v1.2.3", "v1.10.0", -1, true},
+		{"v2.0.0", "v1.9.9", 1, true},
+		{"dev", "v1.0.0", 0, false},
+		{"v0.0.0-20261016022637-544865c38f7b", "v1.0.0", 0, false},
+		{"1.2.3", "v1.2.3", 0, false},
+	} {
+		comparison, ok := CompareVersions(testCase.a, testCase.b)
+		require.Equal(t, testCase.ok, ok, "%s %s", testCase.a, testCase.b)
+		require.Equal(t, testCase.expected, comparison, "%s %s", testCase.a, testCase.b)
+	}
+}
@@ -18,13 +18,14 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	mathv1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/math/v1"
 	"github.com/bufdev/ibctl/internal/pkg/mathpb"
 )
 
-// baseURL is the Bank of Canada valet API base URL.
-const baseURL = "https://www.bankofcanada.ca/valet/observations"
+// DefaultBaseURL is the Bank of Canada valet API observations base URL.
+const DefaultBaseURL = "https://www.bankofcanada.ca/valet/observations"
 
 // DailyRate is a single daily exchange rate returned by the API.
 type DailyRate struct {
@@ -43,20 +44,28 @@ type Client interface {
 }
 
 // NewClient creates a new Bank of Canada API client.
-func NewClient() Client {
+func NewClient(httpClient *http.Client) Client {
+	return NewClientForBaseURL(httpClient, DefaultBaseURL)
+}
+
+// NewClientForBaseURL creates a new Bank of Canada API client for an API at
+// baseURL instead of DefaultBaseURL, such as a fake server in tests.
+func NewClientForBaseURL(httpClient *http.Client, baseURL string) Client {
 	return &client{
-		httpClient: http.DefaultClient,
+		httpClient: httpClient,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
 	}
 }
 
 type client struct {
 	httpClient *http.Client
+	baseURL    string
 }
 
 func (c *client) GetRates(ctx context.Context, baseCurrency string, startDate string, endDate string) ([]DailyRate, error) {
 	// Build the series name (e.g., FXUSDCAD) and request URL.
 	seriesName := fmt.Sprintf("FX%sCAD", baseCurrency)
-	reqURL := fmt.Sprintf("%s/%s/json?start_date=%s&end_date=%s", baseURL, seriesName, startDate, endDate)
+	reqURL := fmt.Sprintf("%s/%s/json?start_date=%s&end_date=%s", c.baseURL, seriesName, startDate, endDate)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
 		return nil, err
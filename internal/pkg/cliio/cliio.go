@@ -2,7 +2,7 @@
 //
 // All rights reserved.
 
-// Package cliio provides output formatting for CLI commands (table, CSV, JSON).
+// Package cliio provides output formatting for CLI commands (table, CSV, JSON, chart).
 package cliio
 
 import (
@@ -10,11 +10,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
+	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"unicode/utf8"
 
 	"github.com/bufdev/ibctl/internal/pkg/mathpb"
 	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/bufdev/ibctl/internal/pkg/timing"
 )
 
 // Format represents the output format for CLI commands.
@@ -27,8 +33,71 @@ const (
 	FormatCSV Format = "csv"
 	// FormatJSON is the JSON output format.
 	FormatJSON Format = "json"
+	// FormatChart is the terminal bar chart output format, only supported by
+	// commands that show allocations.
+	FormatChart Format = "chart"
 )
 
+// ColorMode is when to color table output.
+type ColorMode string
+
+const (
+	// ColorModeAuto colors output written to a terminal, unless NO_COLOR is
+	// set or TERM is "dumb".
+	ColorModeAuto ColorMode = "auto"
+	// ColorModeAlways always colors output.
+	ColorModeAlways ColorMode = "always"
+	// ColorModeNever never colors output.
+	ColorModeNever ColorMode = "never"
+)
+
+// Color is an ANSI terminal color.
+type Color string
+
+const (
+	// ColorNone leaves text uncolored.
+	ColorNone Color = ""
+	// ColorGreen is the ANSI green foreground color, for gains.
+	ColorGreen Color = "\x1b[32m"
+	// ColorRed is the ANSI red foreground color, for losses.
+	ColorRed Color = "\x1b[31m"
+	// ColorYellow is the ANSI yellow foreground color, for warnings.
+	ColorYellow Color = "\x1b[33m"
+	// ColorCyan is the ANSI cyan foreground color, for totals.
+	ColorCyan Color = "\x1b[36m"
+)
+
+// ClearScreen is the ANSI sequence that clears the terminal and moves the
+// cursor to the top left, for re-rendering output in place.
+const ClearScreen = "\x1b[H\x1b[2J"
+
+// ansiReset is the ANSI sequence that resets colors.
+const ansiReset = "\x1b[0m"
+
+// tablePadding is the number of spaces between table columns.
+const tablePadding = 2
+
+// defaultChartWidth is the width in columns of the longest bar in a bar chart.
+const defaultChartWidth = 40
+
+// chartBlocks are the unicode partial blocks used to draw bars with eighth-column
+// precision, from one eighth to a full block.
+var chartBlocks = []rune{'▏', '▎', '▍', '▌', '▋', '▊', '▉', '█'}
+
+// heatmapShades are the characters of heatmap cells, from a zero value to the
+// largest value.
+var heatmapShades = []rune{'·', '░', '▒', '▓', '█'}
+
+// BarChartEntry is a single bar in a bar chart.
+type BarChartEntry struct {
+	// Label is the bar label.
+	Label string
+	// Value is the bar value. Bars are scaled relative to the largest absolute value.
+	Value float64
+	// Annotation is the text printed after the bar (e.g., "45.23%  $12,345.67").
+	Annotation string
+}
+
 // ParseFormat parses a string into a Format, returning an error for unknown formats.
 func ParseFormat(s string) (Format, error) {
 	switch strings.ToLower(s) {
@@ -43,9 +112,107 @@ func ParseFormat(s string) (Format, error) {
 	}
 }
 
+// ParseChartableFormat parses a string into a Format like ParseFormat, but also
+// accepts the chart format.
+func ParseChartableFormat(s string) (Format, error) {
+	if strings.ToLower(s) == string(FormatChart) {
+		return FormatChart, nil
+	}
+	format, err := ParseFormat(s)
+	if err != nil {
+		return "", fmt.Errorf("unknown format %q, must be one of: table, csv, json, chart", s)
+	}
+	return format, nil
+}
+
+// WriteBarChart writes a horizontal unicode bar chart to the writer, one line per
+// entry in the given order. Labels are left-aligned, and negative values are drawn
+// with a leading "-" marker.
+func WriteBarChart(writer io.Writer, entries []BarChartEntry) error {
+	defer timing.Start("render")()
+	var labelWidth int
+	var maxAbsValue float64
+	for _, entry := range entries {
+		labelWidth = max(labelWidth, utf8.RuneCountInString(entry.Label))
+		maxAbsValue = max(maxAbsValue, math.Abs(entry.Value))
+	}
+	for _, entry := range entries {
+		var bar string
+		if maxAbsValue > 0 {
+			bar = renderBar(math.Abs(entry.Value) / maxAbsValue * defaultChartWidth)
+		}
+		sign := " "
+		if entry.Value < 0 {
+			sign = "-"
+		}
+		padding := strings.Repeat(" ", labelWidth-utf8.RuneCountInString(entry.Label))
+		barPadding := strings.Repeat(" ", defaultChartWidth-utf8.RuneCountInString(bar))
+		if _, err := fmt.Fprintf(writer, "%s%s  %s%s%s  %s\n", entry.Label, padding, sign, bar, barPadding, entry.Annotation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HeatmapRow is a single row of a heatmap.
+type HeatmapRow struct {
+	// Label is the row label.
+	Label string
+	// Cells are the cell values. Negative values are cells that do not exist
+	// (e.g., February 30), which are drawn blank.
+	Cells []float64
+	// Annotation is the text printed after the cells (e.g., "12 trades").
+	Annotation string
+}
+
+// WriteHeatmap writes a unicode heatmap to the writer, one line per row, with
+// one column per cell. Cells are shaded relative to the largest value across
+// all rows. The header is printed above the cells, so that its characters line
+// up with cell columns, and a legend is printed below the rows.
+func WriteHeatmap(writer io.Writer, header string, rows []HeatmapRow) error {
+	defer timing.Start("render")()
+	var labelWidth int
+	var cellsWidth int
+	var maxValue float64
+	for _, row := range rows {
+		labelWidth = max(labelWidth, utf8.RuneCountInString(row.Label))
+		cellsWidth = max(cellsWidth, len(row.Cells))
+		for _, value := range row.Cells {
+			maxValue = max(maxValue, value)
+		}
+	}
+	labelPadding := strings.Repeat(" ", labelWidth+tablePadding)
+	if header != "" {
+		if _, err := fmt.Fprintf(writer, "%s%s\n", labelPadding, strings.TrimRight(header, " ")); err != nil {
+			return err
+		}
+	}
+	for _, row := range rows {
+		var cells strings.Builder
+		for _, value := range row.Cells {
+			cells.WriteRune(heatmapShade(value, maxValue))
+		}
+		cells.WriteString(strings.Repeat(" ", cellsWidth-len(row.Cells)))
+		line := row.Label + strings.Repeat(" ", labelWidth-utf8.RuneCountInString(row.Label)+tablePadding) + cells.String()
+		if row.Annotation != "" {
+			line += strings.Repeat(" ", tablePadding) + row.Annotation
+		}
+		if _, err := fmt.Fprintln(writer, strings.TrimRight(line, " ")); err != nil {
+			return err
+		}
+	}
+	legend := make([]string, 0, len(heatmapShades))
+	for _, shade := range heatmapShades {
+		legend = append(legend, string(shade))
+	}
+	_, err := fmt.Fprintf(writer, "\n%sless %s more\n", labelPadding, strings.Join(legend, " "))
+	return err
+}
+
 // WriteTable writes tabular data to the writer using tabwriter for aligned columns.
 func WriteTable(writer io.Writer, headers []string, rows [][]string) error {
-	tw := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	defer timing.Start("render")()
+	tw := tabwriter.NewWriter(writer, 0, 0, tablePadding, ' ', 0)
 	// Write header row.
 	if _, err := fmt.Fprintln(tw, strings.Join(headers, "\t")); err != nil {
 		return err
@@ -62,7 +229,8 @@ func WriteTable(writer io.Writer, headers []string, rows [][]string) error {
 // WriteTableWithTotals writes a table followed by a blank line and a totals row,
 // all through the same tabwriter so columns align between data and totals.
 func WriteTableWithTotals(writer io.Writer, headers []string, rows [][]string, totalsRow []string) error {
-	tw := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	defer timing.Start("render")()
+	tw := tabwriter.NewWriter(writer, 0, 0, tablePadding, ' ', 0)
 	// Write header row.
 	if _, err := fmt.Fprintln(tw, strings.Join(headers, "\t")); err != nil {
 		return err
@@ -85,8 +253,193 @@ func WriteTableWithTotals(writer io.Writer, headers []string, rows [][]string, t
 	return tw.Flush()
 }
 
+// CellColorFunc returns the color of the cell at the row and column index of
+// the data rows of a table.
+type CellColorFunc func(rowIndex int, columnIndex int) Color
+
+// WriteColoredTableWithTotals writes a table with totals like
+// WriteTableWithTotals, coloring each data cell with cellColor and every cell
+// of the totals row with totalsColor. Columns are aligned on the uncolored
+// text, so the layout matches WriteTableWithTotals.
+func WriteColoredTableWithTotals(
+	writer io.Writer,
+	headers []string,
+	rows [][]string,
+	totalsRow []string,
+	cellColor CellColorFunc,
+	totalsColor Color,
+) error {
+	defer timing.Start("render")()
+	lines := make([][]string, 0, len(rows)+3)
+	lines = append(lines, headers)
+	lines = append(lines, rows...)
+	lines = append(lines, make([]string, len(headers)), totalsRow)
+	widths := columnWidths(lines)
+	for i, line := range lines {
+		var builder strings.Builder
+		for j, cell := range line {
+			color := ColorNone
+			switch {
+			case i == len(lines)-1:
+				color = totalsColor
+			case i > 0 && i <= len(rows):
+				color = cellColor(i-1, j)
+			}
+			// Empty cells are left uncolored so blank rows stay blank.
+			if cell != "" {
+				builder.WriteString(Colorize(cell, color))
+			}
+			if j < len(line)-1 {
+				builder.WriteString(strings.Repeat(" ", widths[j]-utf8.RuneCountInString(cell)+tablePadding))
+			}
+		}
+		builder.WriteString("\n")
+		if _, err := io.WriteString(writer, builder.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FitTable drops columns of a table, in dropOrder, until it fits within width
+// columns, and returns the remaining headers, rows, and totals row along with
+// the original index of each remaining column. The table is returned unchanged
+// if width is not positive. If the table is still too wide after all of
+// dropOrder is dropped, the remaining columns are returned as is.
+func FitTable(width int, headers []string, rows [][]string, totalsRow []string, dropOrder []int) ([]string, [][]string, []string, []int) {
+	columnIndexes := make([]int, len(headers))
+	for i := range columnIndexes {
+		columnIndexes[i] = i
+	}
+	if width <= 0 {
+		return headers, rows, totalsRow, columnIndexes
+	}
+	lines := make([][]string, 0, len(rows)+2)
+	lines = append(lines, headers)
+	lines = append(lines, rows...)
+	lines = append(lines, totalsRow)
+	widths := columnWidths(lines)
+	// The last column is not padded, so its width is its widest cell.
+	lastWidth := 0
+	for _, line := range lines {
+		if len(line) > 0 {
+			lastWidth = max(lastWidth, utf8.RuneCountInString(line[len(line)-1]))
+		}
+	}
+	widths = append(widths, lastWidth)
+	dropped := make(map[int]struct{})
+	tableWidth := func() int {
+		total := 0
+		for i, columnWidth := range widths {
+			if _, ok := dropped[i]; !ok {
+				total += columnWidth + tablePadding
+			}
+		}
+		return total - tablePadding
+	}
+	for _, columnIndex := range dropOrder {
+		if tableWidth() <= width {
+			break
+		}
+		dropped[columnIndex] = struct{}{}
+	}
+	if len(dropped) == 0 {
+		return headers, rows, totalsRow, columnIndexes
+	}
+	columnIndexes = slices.DeleteFunc(columnIndexes, func(i int) bool {
+		_, ok := dropped[i]
+		return ok
+	})
+	selectColumns := func(line []string) []string {
+		selected := make([]string, len(columnIndexes))
+		for i, columnIndex := range columnIndexes {
+			if columnIndex < len(line) {
+				selected[i] = line[columnIndex]
+			}
+		}
+		return selected
+	}
+	fitRows := make([][]string, len(rows))
+	for i, row := range rows {
+		fitRows[i] = selectColumns(row)
+	}
+	return selectColumns(headers), fitRows, selectColumns(totalsRow), columnIndexes
+}
+
+// Truncate shortens the text to at most maxWidth columns, ending it with an
+// ellipsis if it was shortened.
+func Truncate(text string, maxWidth int) string {
+	if utf8.RuneCountInString(text) <= maxWidth || maxWidth <= 0 {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:maxWidth-1]) + "…"
+}
+
+// TerminalWidth returns the width in columns of the terminal the file is
+// attached to, or 0 if it is not a terminal. The COLUMNS environment
+// variable overrides the detected width.
+func TerminalWidth(file *os.File) int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return ioctlTerminalWidth(file)
+}
+
+// ParseColorMode parses a string into a ColorMode, returning an error for unknown modes.
+func ParseColorMode(s string) (ColorMode, error) {
+	switch colorMode := ColorMode(strings.ToLower(s)); colorMode {
+	case ColorModeAuto, ColorModeAlways, ColorModeNever:
+		return colorMode, nil
+	default:
+		return "", fmt.Errorf("unknown color mode %q, must be one of: auto, always, never", s)
+	}
+}
+
+// ColorEnabled returns whether output written to the file should be colored
+// in the color mode.
+func ColorEnabled(colorMode ColorMode, file *os.File) bool {
+	switch colorMode {
+	case ColorModeAlways:
+		return true
+	case ColorModeNever:
+		return false
+	}
+	// See https://no-color.org.
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return fileInfo.Mode()&os.ModeCharDevice != 0
+}
+
+// SignColor returns ColorGreen for a positive decimal value, ColorRed for a
+// negative one, and ColorNone for zero, empty, or invalid values.
+func SignColor(value string) Color {
+	switch micros := mathpb.ParseMicros(value); {
+	case micros > 0:
+		return ColorGreen
+	case micros < 0:
+		return ColorRed
+	default:
+		return ColorNone
+	}
+}
+
+// Colorize wraps the text in the color. Returns the text unchanged for ColorNone.
+func Colorize(text string, color Color) string {
+	if color == ColorNone {
+		return text
+	}
+	return string(color) + text + ansiReset
+}
+
 // WriteCSVRecords writes CSV records to the writer.
 func WriteCSVRecords(writer io.Writer, records [][]string) error {
+	defer timing.Start("render")()
 	csvWriter := csv.NewWriter(writer)
 	if err := csvWriter.WriteAll(records); err != nil {
 		return err
@@ -119,8 +472,51 @@ func FormatUSDMicros(micros int64) string {
 	return FormatUSD(moneypb.MoneyValueToString(moneypb.MoneyFromMicros("USD", micros)))
 }
 
+// columnWidths returns the width of each column of the lines, in runes. As
+// with tabwriter, the last cell of a line is not part of a column.
+func columnWidths(lines [][]string) []int {
+	var widths []int
+	for _, line := range lines {
+		for j := 0; j < len(line)-1; j++ {
+			if j == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[j] = max(widths[j], utf8.RuneCountInString(line[j]))
+		}
+	}
+	return widths
+}
+
+// heatmapShade returns the character of a heatmap cell with the value, where
+// maxValue is the largest value of the heatmap.
+func heatmapShade(value float64, maxValue float64) rune {
+	switch {
+	case value < 0:
+		return ' '
+	case value == 0 || maxValue <= 0:
+		return heatmapShades[0]
+	}
+	level := int(math.Ceil(value / maxValue * float64(len(heatmapShades)-1)))
+	return heatmapShades[min(max(level, 1), len(heatmapShades)-1)]
+}
+
+// renderBar returns a bar of the given width in columns, using partial blocks
+// for the fractional part. Any non-zero width renders at least a sliver.
+func renderBar(width float64) string {
+	eighths := int(math.Round(width * 8))
+	if eighths == 0 && width > 0 {
+		eighths = 1
+	}
+	bar := strings.Repeat(string(chartBlocks[len(chartBlocks)-1]), eighths/8)
+	if remainder := eighths % 8; remainder > 0 {
+		bar += string(chartBlocks[remainder-1])
+	}
+	return bar
+}
+
 // WriteJSON writes objects as JSON with newlines between each object.
 func WriteJSON[O any](writer io.Writer, objects ...O) error {
+	defer timing.Start("render")()
 	for _, object := range objects {
 		data, err := json.Marshal(object)
 		if err != nil {
@@ -135,3 +531,36 @@ func WriteJSON[O any](writer io.Writer, objects ...O) error {
 	}
 	return nil
 }
+
+// WriteJSONWithSummary writes objects as with WriteJSON, followed by a
+// summary object with the as-of date (YYYY-MM-DD), the number of objects, and
+// their totals:
+//
+//	{"summary":{"as_of":"2026-01-02","count":3,"totals":{...}}}
+//
+// The summary is wrapped in a "summary" key so it can be told apart from the objects.
+func WriteJSONWithSummary[O any](writer io.Writer, asOf string, totals any, objects ...O) error {
+	defer timing.Start("render")()
+	if err := WriteJSON(writer, objects...); err != nil {
+		return err
+	}
+	return WriteJSON(writer, &jsonSummary{
+		Summary: &jsonSummaryValue{
+			AsOf:   asOf,
+			Count:  len(objects),
+			Totals: totals,
+		},
+	})
+}
+
+// jsonSummary is the summary object written by WriteJSONWithSummary.
+type jsonSummary struct {
+	Summary *jsonSummaryValue `json:"summary"`
+}
+
+// jsonSummaryValue is the value of the "summary" key of a jsonSummary.
+type jsonSummaryValue struct {
+	AsOf   string `json:"as_of"`
+	Count  int    `json:"count"`
+	Totals any    `json:"totals"`
+}
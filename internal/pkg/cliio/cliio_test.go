@@ -0,0 +1,93 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package cliio
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteColoredTableWithTotals(t *testing.T) {
+	t.Parallel()
+	headers := []string{"SYMBOL", "P&L USD", "NOTE"}
+	rows := [][]string{
+		{"AAPL", "$1,234.56", "résumé"},
+		{"", "", ""},
+		{"CAD", "-$7.00", ""},
+	}
+	totalsRow := []string{"TOTAL", "$1,227.56", ""}
+	var plain bytes.Buffer
+	require.NoError(t, WriteTableWithTotals(&plain, headers, rows, totalsRow))
+	var colored bytes.Buffer
+	require.NoError(t, WriteColoredTableWithTotals(&colored, headers, rows, totalsRow, func(rowIndex int, columnIndex int) Color {
+		if columnIndex == 1 {
+			return SignColor(map[int]string{0: "1234.56", 2: "-7"}[rowIndex])
+		}
+		return ColorNone
+	}, ColorCyan))
+	require.Contains(t, colored.String(), Colorize("-$7.00", ColorRed))
+	require.Contains(t, colored.String(), Colorize("TOTAL", ColorCyan))
+	// Colors do not change the layout.
+	require.Equal(t, plain.String(), regexp.MustCompile("\x1b\\[[0-9]+m").ReplaceAllString(colored.String(), ""))
+}
+
+func TestFitTable(t *testing.T) {
+	t.Parallel()
+	headers := []string{"SYMBOL", "VALUE", "SECTOR", "GEO"}
+	rows := [][]string{{"AAPL", "$1,400.00", "TECH", "US"}}
+	totalsRow := []string{"TOTAL", "$1,400.00", "", ""}
+	// "SYMBOL  VALUE      SECTOR  GEO" is 30 columns wide.
+	fitHeaders, fitRows, fitTotalsRow, columnIndexes := FitTable(30, headers, rows, totalsRow, []int{3, 2})
+	require.Equal(t, headers, fitHeaders)
+	require.Equal(t, rows, fitRows)
+	require.Equal(t, totalsRow, fitTotalsRow)
+	require.Equal(t, []int{0, 1, 2, 3}, columnIndexes)
+	fitHeaders, fitRows, fitTotalsRow, columnIndexes = FitTable(29, headers, rows, totalsRow, []int{3, 2})
+	require.Equal(t, []string{"SYMBOL", "VALUE", "SECTOR"}, fitHeaders)
+	require.Equal(t, [][]string{{"AAPL", "$1,400.00", "TECH"}}, fitRows)
+	require.Equal(t, []string{"TOTAL", "$1,400.00", ""}, fitTotalsRow)
+	require.Equal(t, []int{0, 1, 2}, columnIndexes)
+	// Columns outside dropOrder are kept even if the table does not fit.
+	fitHeaders, _, _, columnIndexes = FitTable(5, headers, rows, totalsRow, []int{3, 2})
+	require.Equal(t, []string{"SYMBOL", "VALUE"}, fitHeaders)
+	require.Equal(t, []int{0, 1}, columnIndexes)
+	require.Equal(t, "Datacenter…", Truncate("Datacenter capex cycle", 11))
+	require.Equal(t, "RSU vest", Truncate("RSU vest", 11))
+}
+
+func TestWriteJSONWithSummary(t *testing.T) {
+	t.Parallel()
+	type row struct {
+		Symbol   string `json:"symbol"`
+		ValueUSD string `json:"value_usd"`
+	}
+	var buffer bytes.Buffer
+	require.NoError(t, WriteJSONWithSummary(&buffer, "2026-01-02", map[string]string{"value_usd": "30"}, &row{Symbol: "AAPL", ValueUSD: "10"}, &row{Symbol: "MSFT", ValueUSD: "20"}))
+	require.Equal(t, `{"symbol":"AAPL","value_usd":"10"}
+{"symbol":"MSFT","value_usd":"20"}
+{"summary":{"as_of":"2026-01-02","count":2,"totals":{"value_usd":"30"}}}
+`, buffer.String())
+}
+
+func TestWriteHeatmap(t *testing.T) {
+	t.Parallel()
+	var buffer bytes.Buffer
+	require.NoError(t, WriteHeatmap(&buffer, "1 3", []HeatmapRow{
+		{Label: "Jan", Cells: []float64{0, 1, 8}, Annotation: "9 trades"},
+		{Label: "Feb", Cells: []float64{4, -1}},
+	}))
+	require.Equal(
+		t,
+		"     1 3\n"+
+			"Jan  ·░█  9 trades\n"+
+			"Feb  ▒\n"+
+			"\n"+
+			"     less · ░ ▒ ▓ █ more\n",
+		buffer.String(),
+	)
+}
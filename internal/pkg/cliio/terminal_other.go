@@ -0,0 +1,15 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+//go:build !darwin && !linux
+
+package cliio
+
+import "os"
+
+// ioctlTerminalWidth returns 0, since terminal width detection is only
+// supported on darwin and linux. COLUMNS can be set instead.
+func ioctlTerminalWidth(*os.File) int {
+	return 0
+}
@@ -0,0 +1,28 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+//go:build darwin || linux
+
+package cliio
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// ioctlTerminalWidth returns the width in columns of the terminal the file
+// is attached to, or 0 if it is not a terminal.
+func ioctlTerminalWidth(file *os.File) int {
+	var winsize struct {
+		rows    uint16
+		columns uint16
+		xPixels uint16
+		yPixels uint16
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&winsize))); errno != 0 {
+		return 0
+	}
+	return int(winsize.columns)
+}
@@ -0,0 +1,330 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package connecthttp serves unary protobuf RPCs over net/http.
+//
+// Handlers speak the unary Connect protocol (https://connectrpc.com/docs/protocol)
+// with the proto and JSON codecs, and the gRPC protocol with the proto codec.
+// gRPC requires HTTP/2, so servers that accept gRPC clients must enable
+// unencrypted HTTP/2 (see http.Protocols) when not serving TLS.
+//
+// Streaming, compression, and Connect GET requests are not supported.
+package connecthttp
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// CodeCanceled indicates the RPC was canceled by the caller.
+	CodeCanceled Code = 1
+	// CodeUnknown indicates an error without a more specific code.
+	CodeUnknown Code = 2
+	// CodeInvalidArgument indicates the request was invalid.
+	CodeInvalidArgument Code = 3
+	// CodeDeadlineExceeded indicates the RPC did not complete before its deadline.
+	CodeDeadlineExceeded Code = 4
+	// CodeUnimplemented indicates the request used an unsupported feature.
+	CodeUnimplemented Code = 12
+	// CodeInternal indicates a server-side error.
+	CodeInternal Code = 13
+)
+
+// maxMessageSize is the maximum size of a request message in bytes.
+const maxMessageSize = 4 << 20
+
+// Code is an RPC error code, shared by the Connect and gRPC protocols.
+type Code int
+
+// String returns the Connect name of the code (e.g., "invalid_argument").
+func (c Code) String() string {
+	switch c {
+	case CodeCanceled:
+		return "canceled"
+	case CodeUnknown:
+		return "unknown"
+	case CodeInvalidArgument:
+		return "invalid_argument"
+	case CodeDeadlineExceeded:
+		return "deadline_exceeded"
+	case CodeUnimplemented:
+		return "unimplemented"
+	case CodeInternal:
+		return "internal"
+	default:
+		return "code_" + strconv.Itoa(int(c))
+	}
+}
+
+// Error is an error with an RPC code.
+type Error struct {
+	code Code
+	err  error
+}
+
+// NewError returns a new Error with the code.
+func NewError(code Code, err error) *Error {
+	return &Error{code: code, err: err}
+}
+
+// Code returns the RPC code.
+func (e *Error) Code() Code {
+	return e.code
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	return e.code.String() + ": " + e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// NewUnaryHandler returns a handler for a unary RPC.
+//
+// newRequest returns an empty request message to unmarshal into. Errors
+// returned by handle are sent with their code if they are an *Error, and
+// with CodeUnknown otherwise.
+func NewUnaryHandler[Req proto.Message, Res proto.Message](
+	newRequest func() Req,
+	handle func(context.Context, Req) (Res, error),
+) http.Handler {
+	return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodPost {
+			responseWriter.Header().Set("Allow", http.MethodPost)
+			http.Error(responseWriter, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type"))
+		switch mediaType {
+		case "application/proto", "application/json":
+			serveConnect(responseWriter, request, mediaType, newRequest, handle)
+		case "application/grpc", "application/grpc+proto":
+			serveGRPC(responseWriter, request, newRequest, handle)
+		default:
+			responseWriter.Header().Set("Accept-Post", "application/proto, application/json, application/grpc")
+			http.Error(responseWriter, "unsupported content type", http.StatusUnsupportedMediaType)
+		}
+	})
+}
+
+// CodeOf returns the RPC code that err is sent with: the code of an *Error,
+// CodeCanceled or CodeDeadlineExceeded for context errors, and CodeUnknown
+// otherwise.
+func CodeOf(err error) Code {
+	var rpcError *Error
+	switch {
+	case errors.As(err, &rpcError):
+		return rpcError.Code()
+	case errors.Is(err, context.Canceled):
+		return CodeCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return CodeDeadlineExceeded
+	default:
+		return CodeUnknown
+	}
+}
+
+// *** PRIVATE ***
+
+// serveConnect serves a unary Connect protocol request.
+func serveConnect[Req proto.Message, Res proto.Message](
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+	mediaType string,
+	newRequest func() Req,
+	handle func(context.Context, Req) (Res, error),
+) {
+	if request.Header.Get("Content-Encoding") != "" && request.Header.Get("Content-Encoding") != "identity" {
+		writeConnectError(responseWriter, NewError(CodeUnimplemented, errors.New("compression is not supported")))
+		return
+	}
+	data, err := readAll(request.Body)
+	if err != nil {
+		writeConnectError(responseWriter, err)
+		return
+	}
+	requestMessage := newRequest()
+	if mediaType == "application/json" {
+		err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, requestMessage)
+	} else {
+		err = proto.Unmarshal(data, requestMessage)
+	}
+	if err != nil {
+		writeConnectError(responseWriter, NewError(CodeInvalidArgument, fmt.Errorf("unmarshal request: %w", err)))
+		return
+	}
+	responseMessage, err := handle(request.Context(), requestMessage)
+	if err != nil {
+		writeConnectError(responseWriter, err)
+		return
+	}
+	if mediaType == "application/json" {
+		data, err = protojson.Marshal(responseMessage)
+	} else {
+		data, err = proto.Marshal(responseMessage)
+	}
+	if err != nil {
+		writeConnectError(responseWriter, NewError(CodeInternal, fmt.Errorf("marshal response: %w", err)))
+		return
+	}
+	responseWriter.Header().Set("Content-Type", mediaType)
+	responseWriter.WriteHeader(http.StatusOK)
+	_, _ = responseWriter.Write(data)
+}
+
+// serveGRPC serves a unary gRPC protocol request.
+func serveGRPC[Req proto.Message, Res proto.Message](
+	responseWriter http.ResponseWriter,
+	request *http.Request,
+	newRequest func() Req,
+	handle func(context.Context, Req) (Res, error),
+) {
+	if request.ProtoMajor != 2 {
+		http.Error(responseWriter, "gRPC requires HTTP/2", http.StatusHTTPVersionNotSupported)
+		return
+	}
+	responseWriter.Header().Set("Content-Type", "application/grpc")
+	responseWriter.Header().Add("Trailer", "Grpc-Status")
+	responseWriter.Header().Add("Trailer", "Grpc-Message")
+	data, err := readGRPCMessage(request.Body)
+	if err != nil {
+		writeGRPCStatus(responseWriter, err)
+		return
+	}
+	requestMessage := newRequest()
+	if err := proto.Unmarshal(data, requestMessage); err != nil {
+		writeGRPCStatus(responseWriter, NewError(CodeInvalidArgument, fmt.Errorf("unmarshal request: %w", err)))
+		return
+	}
+	responseMessage, err := handle(request.Context(), requestMessage)
+	if err != nil {
+		writeGRPCStatus(responseWriter, err)
+		return
+	}
+	data, err = proto.Marshal(responseMessage)
+	if err != nil {
+		writeGRPCStatus(responseWriter, NewError(CodeInternal, fmt.Errorf("marshal response: %w", err)))
+		return
+	}
+	// Each message is prefixed by an uncompressed flag byte and a big-endian length.
+	prefix := make([]byte, 5)
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(data)))
+	responseWriter.WriteHeader(http.StatusOK)
+	_, _ = responseWriter.Write(prefix)
+	_, _ = responseWriter.Write(data)
+	writeGRPCStatus(responseWriter, nil)
+}
+
+// readGRPCMessage reads the single length-prefixed message of a unary gRPC request.
+func readGRPCMessage(reader io.Reader) ([]byte, error) {
+	prefix := make([]byte, 5)
+	if _, err := io.ReadFull(reader, prefix); err != nil {
+		return nil, NewError(CodeInvalidArgument, fmt.Errorf("read message prefix: %w", err))
+	}
+	if prefix[0] != 0 {
+		return nil, NewError(CodeUnimplemented, errors.New("compression is not supported"))
+	}
+	size := binary.BigEndian.Uint32(prefix[1:])
+	if size > maxMessageSize {
+		return nil, NewError(CodeInvalidArgument, fmt.Errorf("message of %d bytes exceeds maximum of %d bytes", size, maxMessageSize))
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return nil, NewError(CodeInvalidArgument, fmt.Errorf("read message: %w", err))
+	}
+	return data, nil
+}
+
+// readAll reads a Connect request body, enforcing maxMessageSize.
+func readAll(reader io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(reader, maxMessageSize+1))
+	if err != nil {
+		return nil, NewError(CodeInvalidArgument, fmt.Errorf("read request: %w", err))
+	}
+	if len(data) > maxMessageSize {
+		return nil, NewError(CodeInvalidArgument, fmt.Errorf("request exceeds maximum of %d bytes", maxMessageSize))
+	}
+	return data, nil
+}
+
+// writeConnectError writes a Connect protocol unary error response.
+func writeConnectError(responseWriter http.ResponseWriter, err error) {
+	code := CodeOf(err)
+	data, _ := json.Marshal(struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}{
+		Code:    code.String(),
+		Message: errorMessage(err),
+	})
+	responseWriter.Header().Set("Content-Type", "application/json")
+	responseWriter.WriteHeader(httpStatus(code))
+	_, _ = responseWriter.Write(data)
+}
+
+// writeGRPCStatus writes the gRPC status trailers for err, or OK if err is nil.
+func writeGRPCStatus(responseWriter http.ResponseWriter, err error) {
+	if err == nil {
+		responseWriter.Header().Set("Grpc-Status", "0")
+		return
+	}
+	responseWriter.Header().Set("Grpc-Status", strconv.Itoa(int(CodeOf(err))))
+	responseWriter.Header().Set("Grpc-Message", percentEncode(errorMessage(err)))
+}
+
+// errorMessage returns the message for err, without the code prefix of an *Error.
+func errorMessage(err error) string {
+	var rpcError *Error
+	if errors.As(err, &rpcError) {
+		return rpcError.err.Error()
+	}
+	return err.Error()
+}
+
+// httpStatus returns the HTTP status for a Connect unary error with the code.
+func httpStatus(code Code) int {
+	switch code {
+	case CodeCanceled:
+		// 499 Client Closed Request, per the Connect protocol.
+		return 499
+	case CodeInvalidArgument:
+		return http.StatusBadRequest
+	case CodeDeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case CodeUnimplemented:
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// percentEncode encodes a grpc-message value, escaping '%' and all bytes
+// outside printable ASCII.
+func percentEncode(s string) string {
+	var builder strings.Builder
+	for i := range len(s) {
+		c := s[i]
+		if c < 0x20 || c > 0x7e || c == '%' {
+			fmt.Fprintf(&builder, "%%%02X", c)
+			continue
+		}
+		builder.WriteByte(c)
+	}
+	return builder.String()
+}
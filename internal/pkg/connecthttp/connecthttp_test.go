@@ -0,0 +1,86 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package connecthttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestNewUnaryHandler(t *testing.T) {
+	t.Parallel()
+	handler := NewUnaryHandler(
+		func() *wrapperspb.StringValue { return &wrapperspb.StringValue{} },
+		func(_ context.Context, request *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+			if request.GetValue() == "" {
+				return nil, NewError(CodeInvalidArgument, errors.New("value is required"))
+			}
+			return wrapperspb.String("hello " + request.GetValue()), nil
+		},
+	)
+	server := httptest.NewUnstartedServer(handler)
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+	client := server.Client()
+
+	// Connect with the JSON codec.
+	response, err := client.Post(server.URL, "application/json", strings.NewReader(`"world"`))
+	require.NoError(t, err)
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.NoError(t, response.Body.Close())
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, `"hello world"`, string(body))
+
+	// Connect error.
+	response, err = client.Post(server.URL, "application/json", strings.NewReader(`""`))
+	require.NoError(t, err)
+	body, err = io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.NoError(t, response.Body.Close())
+	require.Equal(t, http.StatusBadRequest, response.StatusCode)
+	require.Equal(t, `{"code":"invalid_argument","message":"value is required"}`, string(body))
+
+	// gRPC.
+	response, err = client.Post(server.URL, "application/grpc", bytes.NewReader(grpcFrame(t, wrapperspb.String("world"))))
+	require.NoError(t, err)
+	body, err = io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.NoError(t, response.Body.Close())
+	require.Equal(t, "0", response.Trailer.Get("Grpc-Status"))
+	require.Greater(t, len(body), 5)
+	responseMessage := &wrapperspb.StringValue{}
+	require.NoError(t, proto.Unmarshal(body[5:], responseMessage))
+	require.Equal(t, "hello world", responseMessage.GetValue())
+
+	// gRPC error.
+	response, err = client.Post(server.URL, "application/grpc", bytes.NewReader(grpcFrame(t, wrapperspb.String(""))))
+	require.NoError(t, err)
+	_, err = io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.NoError(t, response.Body.Close())
+	require.Equal(t, "3", response.Trailer.Get("Grpc-Status"))
+	require.Equal(t, "value is required", response.Trailer.Get("Grpc-Message"))
+}
+
+func grpcFrame(t *testing.T, message proto.Message) []byte {
+	data, err := proto.Marshal(message)
+	require.NoError(t, err)
+	frame := make([]byte, 5, 5+len(data))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(data)))
+	return append(frame, data...)
+}
@@ -0,0 +1,120 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package cryptobox provides authenticated symmetric encryption for files.
+//
+// Data is sealed with AES-256-GCM using a 32-byte key. The sealed format is:
+//
+//	magic ("IBCTLBOX") | version (1 byte) | nonce (12 bytes) | ciphertext + tag
+//
+// The magic prefix lets readers detect sealed data and fail clearly when no
+// key is available.
+package cryptobox
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeySize is the required key size in bytes.
+const KeySize = 32
+
+// PrefixSize is the number of leading bytes IsSealed needs to detect sealed data.
+const PrefixSize = 8
+
+// magic is the prefix of all sealed data.
+var magic = []byte("IBCTLBOX")
+
+// version is the current sealed format version.
+const version byte = 1
+
+// ParseKey decodes a base64-encoded 32-byte key, as generated by
+// "openssl rand -base64 32".
+func ParseKey(encodedKey string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encodedKey))
+	if err != nil {
+		return nil, fmt.Errorf("decoding key as base64: %w", err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", KeySize, len(key))
+	}
+	return key, nil
+}
+
+// NewKey returns a new random key, base64-encoded.
+func NewKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// IsSealed returns true if the data starts with the sealed format prefix.
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// Seal encrypts and authenticates the plaintext with the key.
+func Seal(key []byte, plaintext []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	header := make([]byte, 0, len(magic)+1+len(nonce))
+	header = append(header, magic...)
+	header = append(header, version)
+	header = append(header, nonce...)
+	// The header is authenticated as additional data so it cannot be altered.
+	return aead.Seal(header, nonce, plaintext, header), nil
+}
+
+// Open decrypts and verifies sealed data with the key.
+func Open(key []byte, sealed []byte) ([]byte, error) {
+	if !IsSealed(sealed) {
+		return nil, errors.New("data is not sealed")
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	headerSize := len(magic) + 1 + aead.NonceSize()
+	if len(sealed) < headerSize {
+		return nil, errors.New("sealed data is truncated")
+	}
+	if sealedVersion := sealed[len(magic)]; sealedVersion != version {
+		return nil, fmt.Errorf("unsupported sealed data version %d", sealedVersion)
+	}
+	header := sealed[:headerSize]
+	nonce := header[len(magic)+1:]
+	plaintext, err := aead.Open(nil, nonce, sealed[headerSize:], header)
+	if err != nil {
+		return nil, errors.New("decrypting sealed data: wrong key or corrupted data")
+	}
+	return plaintext, nil
+}
+
+// *** PRIVATE ***
+
+// newAEAD returns an AES-256-GCM AEAD for the key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
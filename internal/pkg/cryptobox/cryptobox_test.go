@@ -0,0 +1,46 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package cryptobox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealOpen(t *testing.T) {
+	t.Parallel()
+	encodedKey, err := NewKey()
+	require.NoError(t, err)
+	key, err := ParseKey(encodedKey)
+	require.NoError(t, err)
+	plaintext := []byte("trade data")
+	sealed, err := Seal(key, plaintext)
+	require.NoError(t, err)
+	require.True(t, IsSealed(sealed))
+	require.False(t, IsSealed(plaintext))
+	opened, err := Open(key, sealed)
+	require.NoError(t, err)
+	require.Equal(t, plaintext, opened)
+	// A different key must fail to open.
+	otherEncodedKey, err := NewKey()
+	require.NoError(t, err)
+	otherKey, err := ParseKey(otherEncodedKey)
+	require.NoError(t, err)
+	_, err = Open(otherKey, sealed)
+	require.Error(t, err)
+	// Tampering with the header must fail to open.
+	sealed[len(magic)+1] ^= 0xff
+	_, err = Open(key, sealed)
+	require.Error(t, err)
+}
+
+func TestParseKey(t *testing.T) {
+	t.Parallel()
+	_, err := ParseKey("not base64!")
+	require.Error(t, err)
+	_, err = ParseKey("c2hvcnQ=")
+	require.Error(t, err)
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package filelock provides advisory, exclusive locks on files, used to keep
+// processes from mutating the same files at the same time.
+//
+// Locks are reentrant within a process: acquiring a lock the process already
+// holds succeeds immediately, and the lock is released when every acquisition
+// has been released.
+package filelock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pollInterval is the interval between attempts to acquire a held lock when waiting.
+const pollInterval = 200 * time.Millisecond
+
+// ErrLocked is returned when the lock is held by another process.
+var ErrLocked = errors.New("locked by another process")
+
+var (
+	mu sync.Mutex
+	// held maps the absolute paths of the locks this process holds to the locks.
+	held = make(map[string]*lock)
+)
+
+// Acquire acquires the lock on the file, creating the file if needed, and
+// records holder in the file so that other processes can report who holds
+// the lock. If the lock is held by another process, Acquire returns an error
+// wrapping ErrLocked, unless wait is set, in which case it waits until the
+// lock is released or the context is done.
+//
+// The returned function releases the lock.
+func Acquire(ctx context.Context, filePath string, holder string, wait bool) (func() error, error) {
+	filePath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		release, err := tryAcquire(filePath, holder)
+		if err == nil {
+			return release, nil
+		}
+		if !wait || !errors.Is(err, ErrLocked) {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// *** PRIVATE ***
+
+// lock is a lock held by this process.
+type lock struct {
+	file *os.File
+	// count is the number of acquisitions not yet released.
+	count int
+}
+
+// tryAcquire acquires the lock without waiting.
+func tryAcquire(filePath string, holder string) (func() error, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if l, ok := held[filePath]; ok {
+		l.count++
+		return newRelease(filePath), nil
+	}
+	file, err := lockFile(filePath)
+	if err != nil {
+		if errors.Is(err, ErrLocked) {
+			return nil, fmt.Errorf("%s %w (%s)", filePath, ErrLocked, readHolder(filePath))
+		}
+		return nil, err
+	}
+	if err := writeHolder(file, holder); err != nil {
+		return nil, errors.Join(err, unlockFile(file))
+	}
+	held[filePath] = &lock{file: file, count: 1}
+	return newRelease(filePath), nil
+}
+
+// newRelease returns a function that releases one acquisition of the lock.
+// Calling it more than once has no further effect.
+func newRelease(filePath string) func() error {
+	var once sync.Once
+	return func() error {
+		var err error
+		once.Do(func() {
+			mu.Lock()
+			defer mu.Unlock()
+			l := held[filePath]
+			l.count--
+			if l.count == 0 {
+				delete(held, filePath)
+				err = unlockFile(l.file)
+			}
+		})
+		return err
+	}
+}
+
+// writeHolder replaces the contents of the locked file with the holder.
+func writeHolder(file *os.File, holder string) error {
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+	_, err := file.WriteAt([]byte(holder+"\n"), 0)
+	return err
+}
+
+// readHolder returns the holder recorded in the lock file, or a placeholder if
+// it cannot be read.
+func readHolder(filePath string) string {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "unknown holder"
+	}
+	if holder := strings.TrimSpace(string(data)); holder != "" {
+		return holder
+	}
+	return "unknown holder"
+}
@@ -0,0 +1,32 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+//go:build !darwin && !linux
+
+package filelock
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// lockFile creates the file exclusively, so the lock is held while the file
+// exists. A process that crashes leaves the file in place, and it must be
+// removed by hand.
+func lockFile(filePath string) (*os.File, error) {
+	file, err := os.OpenFile(filePath, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return nil, ErrLocked
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+// unlockFile releases the lock by removing the file.
+func unlockFile(file *os.File) error {
+	return errors.Join(file.Close(), os.Remove(file.Name()))
+}
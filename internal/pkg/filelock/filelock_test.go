@@ -0,0 +1,63 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package filelock
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcquire(t *testing.T) {
+	t.Parallel()
+	filePath := filepath.Join(t.TempDir(), "test.lock")
+	release, err := Acquire(context.Background(), filePath, "pid 1: first", false)
+	require.NoError(t, err)
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	require.Equal(t, "pid 1: first\n", string(data))
+	// Acquiring again in the same process is reentrant.
+	releaseAgain, err := Acquire(context.Background(), filePath, "pid 1: second", false)
+	require.NoError(t, err)
+	require.NoError(t, releaseAgain())
+	// The lock is still held after releasing one of the two acquisitions.
+	_, err = lockFile(filePath)
+	require.ErrorIs(t, err, ErrLocked)
+	require.NoError(t, release())
+	// Releasing twice has no further effect.
+	require.NoError(t, release())
+	file, err := lockFile(filePath)
+	require.NoError(t, err)
+	require.NoError(t, unlockFile(file))
+}
+
+func TestAcquireHeld(t *testing.T) {
+	t.Parallel()
+	filePath := filepath.Join(t.TempDir(), "test.lock")
+	// Simulate another process by locking the file directly.
+	file, err := lockFile(filePath)
+	require.NoError(t, err)
+	require.NoError(t, writeHolder(file, "pid 2: other"))
+	_, err = Acquire(context.Background(), filePath, "pid 1", false)
+	require.ErrorIs(t, err, ErrLocked)
+	require.ErrorContains(t, err, "pid 2: other")
+	// Waiting stops when the context is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = Acquire(ctx, filePath, "pid 1", true)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	// Waiting succeeds once the other process releases the lock.
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = unlockFile(file)
+	}()
+	release, err := Acquire(context.Background(), filePath, "pid 1", true)
+	require.NoError(t, err)
+	require.NoError(t, release())
+}
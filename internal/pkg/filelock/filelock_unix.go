@@ -0,0 +1,42 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+//go:build darwin || linux
+
+package filelock
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockFile opens the file and takes an exclusive flock on it. The lock is
+// released by the kernel if the process exits, so a crashed process never
+// leaves the lock held.
+func lockFile(filePath string) (*os.File, error) {
+	file, err := os.OpenFile(filePath, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		closeErr := file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, ErrLocked
+		}
+		return nil, errors.Join(err, closeErr)
+	}
+	return file, nil
+}
+
+// unlockFile clears the holder and releases the lock. The file is left in
+// place, since removing it would let another process lock a new file while
+// a third still waits on the old one.
+func unlockFile(file *os.File) error {
+	return errors.Join(
+		file.Truncate(0),
+		syscall.Flock(int(file.Fd()), syscall.LOCK_UN),
+		file.Close(),
+	)
+}
@@ -0,0 +1,150 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package filemode holds the permissions of the files and directories that
+// ibctl writes, and writes them with those permissions.
+//
+// The modes default to DefaultFileMode and DefaultDirMode, since the files
+// hold financial data, and can be changed for the process with Set. Modes are
+// applied with an explicit chmod, so they do not depend on the umask.
+package filemode
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// DefaultFileMode is the default permissions of written files.
+	DefaultFileMode fs.FileMode = 0o600
+	// DefaultDirMode is the default permissions of created directories.
+	DefaultDirMode fs.FileMode = 0o700
+)
+
+// ErrReadOnly is the error for writes under a read-only directory.
+var ErrReadOnly = errors.New("read-only directory")
+
+var (
+	fileMode atomic.Uint32
+	dirMode  atomic.Uint32
+
+	readOnlyLock     sync.RWMutex
+	readOnlyDirPaths []string
+)
+
+func init() {
+	Set(DefaultFileMode, DefaultDirMode)
+}
+
+// Set sets the permissions of the files and directories written for the rest
+// of the process.
+func Set(file fs.FileMode, dir fs.FileMode) {
+	fileMode.Store(uint32(file.Perm()))
+	dirMode.Store(uint32(dir.Perm()))
+}
+
+// SetReadOnly makes the directory read-only for the rest of the process:
+// creating, writing, or replacing anything under it fails with ErrReadOnly.
+func SetReadOnly(dirPath string) error {
+	absDirPath, err := filepath.Abs(dirPath)
+	if err != nil {
+		return err
+	}
+	readOnlyLock.Lock()
+	defer readOnlyLock.Unlock()
+	readOnlyDirPaths = append(readOnlyDirPaths, absDirPath)
+	return nil
+}
+
+// CheckWritable returns an error wrapping ErrReadOnly if the path is under a
+// directory made read-only with SetReadOnly.
+func CheckWritable(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	readOnlyLock.RLock()
+	defer readOnlyLock.RUnlock()
+	for _, readOnlyDirPath := range readOnlyDirPaths {
+		if absPath == readOnlyDirPath || strings.HasPrefix(absPath, readOnlyDirPath+string(filepath.Separator)) {
+			return fmt.Errorf("cannot write %s: %w %s", path, ErrReadOnly, readOnlyDirPath)
+		}
+	}
+	return nil
+}
+
+// File returns the permissions of written files.
+func File() fs.FileMode {
+	return fs.FileMode(fileMode.Load())
+}
+
+// Dir returns the permissions of created directories.
+func Dir() fs.FileMode {
+	return fs.FileMode(dirMode.Load())
+}
+
+// MkdirAll creates the directory and any missing parents with Dir
+// permissions. Existing directories are left alone.
+func MkdirAll(dirPath string) error {
+	info, err := os.Stat(dirPath)
+	if err == nil {
+		if !info.IsDir() {
+			return &fs.PathError{Op: "mkdir", Path: dirPath, Err: fs.ErrExist}
+		}
+		return nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := CheckWritable(dirPath); err != nil {
+		return err
+	}
+	if parentDirPath := filepath.Dir(dirPath); parentDirPath != dirPath {
+		if err := MkdirAll(parentDirPath); err != nil {
+			return err
+		}
+	}
+	if err := os.Mkdir(dirPath, Dir()); err != nil {
+		// Another process may have created the directory in the meantime.
+		if errors.Is(err, fs.ErrExist) {
+			return nil
+		}
+		return err
+	}
+	return os.Chmod(dirPath, Dir())
+}
+
+// WriteFile writes the data to the file with File permissions, creating the
+// file if needed. The permissions of an existing file are updated too.
+func WriteFile(filePath string, data []byte) error {
+	if err := CheckWritable(filePath); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filePath, data, File()); err != nil {
+		return err
+	}
+	return os.Chmod(filePath, File())
+}
+
+// OpenFile opens the file with the flags, creating it if needed, with File
+// permissions. The permissions of an existing file are updated too.
+func OpenFile(filePath string, flag int) (*os.File, error) {
+	if err := CheckWritable(filePath); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(filePath, flag|os.O_CREATE, File())
+	if err != nil {
+		return nil, err
+	}
+	if err := file.Chmod(File()); err != nil {
+		return nil, errors.Join(err, file.Close())
+	}
+	return file, nil
+}
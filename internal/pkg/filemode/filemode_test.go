@@ -0,0 +1,71 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+//go:build darwin || linux
+
+package filemode
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestModes(t *testing.T) {
+	// Not parallel: modes and the umask are process state.
+	defer Set(DefaultFileMode, DefaultDirMode)
+	oldUmask := syscall.Umask(0o077)
+	defer syscall.Umask(oldUmask)
+	dirPath := t.TempDir()
+
+	// Modes apply regardless of the umask.
+	Set(0o640, 0o750)
+	nestedDirPath := filepath.Join(dirPath, "a", "b")
+	require.NoError(t, MkdirAll(nestedDirPath))
+	requireMode(t, filepath.Join(dirPath, "a"), 0o750)
+	requireMode(t, nestedDirPath, 0o750)
+	filePath := filepath.Join(nestedDirPath, "file")
+	require.NoError(t, WriteFile(filePath, []byte("data")))
+	requireMode(t, filePath, 0o640)
+
+	// Existing files are updated, existing directories are left alone.
+	Set(DefaultFileMode, DefaultDirMode)
+	require.NoError(t, MkdirAll(nestedDirPath))
+	requireMode(t, nestedDirPath, 0o750)
+	require.NoError(t, WriteFile(filePath, []byte("data")))
+	requireMode(t, filePath, 0o600)
+	file, err := OpenFile(filepath.Join(nestedDirPath, "other"), os.O_WRONLY)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	requireMode(t, filepath.Join(nestedDirPath, "other"), 0o600)
+}
+
+func requireMode(t *testing.T, path string, expected os.FileMode) {
+	t.Helper()
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, expected, info.Mode().Perm(), path)
+}
+
+func TestReadOnly(t *testing.T) {
+	t.Parallel()
+	dirPath := t.TempDir()
+	readOnlyDirPath := filepath.Join(dirPath, "readonly")
+	require.NoError(t, MkdirAll(readOnlyDirPath))
+	require.NoError(t, SetReadOnly(readOnlyDirPath))
+
+	// Nothing can be created or written under the directory.
+	require.ErrorIs(t, WriteFile(filepath.Join(readOnlyDirPath, "file"), []byte("data")), ErrReadOnly)
+	require.ErrorIs(t, MkdirAll(filepath.Join(readOnlyDirPath, "a")), ErrReadOnly)
+	_, err := OpenFile(filepath.Join(readOnlyDirPath, "file"), os.O_WRONLY)
+	require.ErrorIs(t, err, ErrReadOnly)
+	// The existing directory is left alone.
+	require.NoError(t, MkdirAll(readOnlyDirPath))
+	// Siblings with the same prefix are writable.
+	require.NoError(t, MkdirAll(readOnlyDirPath+"2"))
+	require.NoError(t, WriteFile(filepath.Join(readOnlyDirPath+"2", "file"), []byte("data")))
+}
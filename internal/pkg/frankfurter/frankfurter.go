@@ -14,13 +14,14 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	mathv1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/math/v1"
 	"github.com/bufdev/ibctl/internal/pkg/mathpb"
 )
 
-// baseURL is the frankfurter.dev API base URL.
-const baseURL = "https://api.frankfurter.dev/v1"
+// DefaultBaseURL is the frankfurter.dev API base URL.
+const DefaultBaseURL = "https://api.frankfurter.dev/v1"
 
 // DailyRate is a single daily exchange rate returned by the API.
 type DailyRate struct {
@@ -38,19 +39,27 @@ type Client interface {
 }
 
 // NewClient creates a new exchange rate client.
-func NewClient() Client {
+func NewClient(httpClient *http.Client) Client {
+	return NewClientForBaseURL(httpClient, DefaultBaseURL)
+}
+
+// NewClientForBaseURL creates a new exchange rate client for an API at baseURL
+// instead of DefaultBaseURL, such as a fake server in tests.
+func NewClientForBaseURL(httpClient *http.Client, baseURL string) Client {
 	return &client{
-		httpClient: http.DefaultClient,
+		httpClient: httpClient,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
 	}
 }
 
 type client struct {
 	httpClient *http.Client
+	baseURL    string
 }
 
 func (c *client) GetRates(ctx context.Context, baseCurrency string, quoteCurrency string, startDate string, endDate string) ([]DailyRate, error) {
 	// Build the request URL for the time series endpoint.
-	reqURL := fmt.Sprintf("%s/%s..%s?base=%s&symbols=%s", baseURL, startDate, endDate, baseCurrency, quoteCurrency)
+	reqURL := fmt.Sprintf("%s/%s..%s?base=%s&symbols=%s", c.baseURL, startDate, endDate, baseCurrency, quoteCurrency)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
 		return nil, err
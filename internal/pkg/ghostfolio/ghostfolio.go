@@ -0,0 +1,143 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ghostfolio provides a client for importing activities into a
+// Ghostfolio (https://ghostfol.io) instance.
+//
+// The client authenticates with a user's security token, which is exchanged
+// for a session token with the anonymous auth endpoint, as the Ghostfolio web
+// client does.
+package ghostfolio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Activity types.
+const (
+	// ActivityTypeBuy is a purchase of a security.
+	ActivityTypeBuy = "BUY"
+	// ActivityTypeSell is a sale of a security.
+	ActivityTypeSell = "SELL"
+	// ActivityTypeDividend is a dividend payment.
+	ActivityTypeDividend = "DIVIDEND"
+	// ActivityTypeInterest is an interest payment.
+	ActivityTypeInterest = "INTEREST"
+	// ActivityTypeFee is a fee or tax.
+	ActivityTypeFee = "FEE"
+)
+
+// Data sources.
+const (
+	// DataSourceYahoo prices the symbol with Yahoo Finance.
+	DataSourceYahoo = "YAHOO"
+	// DataSourceManual is for activities without a market-priced symbol, such as fees and interest.
+	DataSourceManual = "MANUAL"
+)
+
+// Activity is a single activity in the Ghostfolio import format.
+type Activity struct {
+	// AccountID is the Ghostfolio account ID, or empty for the default account.
+	AccountID string `json:"accountId,omitempty"`
+	// Comment is a free-form comment.
+	Comment string `json:"comment,omitempty"`
+	// Currency is the currency code of unitPrice and fee.
+	Currency string `json:"currency"`
+	// DataSource is the source of market data for the symbol (e.g., "YAHOO" or "MANUAL").
+	DataSource string `json:"dataSource"`
+	// Date is the activity date in ISO 8601 format.
+	Date string `json:"date"`
+	// Fee is the fee paid, always non-negative.
+	Fee float64 `json:"fee"`
+	// Quantity is the quantity, always non-negative.
+	Quantity float64 `json:"quantity"`
+	// Symbol is the symbol, or a name for manual activities.
+	Symbol string `json:"symbol"`
+	// Type is the activity type (one of the ActivityType constants).
+	Type string `json:"type"`
+	// UnitPrice is the price per unit, always non-negative.
+	UnitPrice float64 `json:"unitPrice"`
+}
+
+// Client is the interface for a Ghostfolio instance.
+type Client interface {
+	// Import imports activities. Ghostfolio rejects the whole import if any
+	// activity already exists.
+	Import(ctx context.Context, activities []Activity) error
+}
+
+// NewClient creates a new client for the Ghostfolio instance at baseURL
+// (e.g., "https://ghostfol.io"), authenticating with the security token.
+func NewClient(httpClient *http.Client, baseURL string, accessToken string) Client {
+	return &client{
+		httpClient:  httpClient,
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		accessToken: accessToken,
+	}
+}
+
+// *** PRIVATE ***
+
+type client struct {
+	httpClient  *http.Client
+	baseURL     string
+	accessToken string
+}
+
+func (c *client) Import(ctx context.Context, activities []Activity) error {
+	var authResponse struct {
+		AuthToken string `json:"authToken"`
+	}
+	if err := c.post(ctx, "/api/v1/auth/anonymous", "", map[string]string{"accessToken": c.accessToken}, &authResponse); err != nil {
+		return fmt.Errorf("authenticating: %w", err)
+	}
+	if authResponse.AuthToken == "" {
+		return fmt.Errorf("authenticating: no auth token in response")
+	}
+	if err := c.post(ctx, "/api/v1/import", authResponse.AuthToken, map[string][]Activity{"activities": activities}, nil); err != nil {
+		return fmt.Errorf("importing: %w", err)
+	}
+	return nil
+}
+
+// post posts the JSON request to the path and decodes the JSON response into response if non-nil.
+func (c *client) post(ctx context.Context, path string, authToken string, request any, response any) error {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+authToken)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+	if response == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, response); err != nil {
+		return fmt.Errorf("parsing response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package githubrelease provides a client for fetching GitHub releases and
+// verifying their assets against a sha256sum-format checksums file.
+//
+// Requests are unauthenticated, so they are subject to GitHub's rate limit for
+// anonymous API requests.
+package githubrelease
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// apiBaseURL is the GitHub REST API base URL.
+const apiBaseURL = "https://api.github.com"
+
+// Release is a GitHub release.
+type Release struct {
+	// TagName is the release tag (e.g., "v1.2.3").
+	TagName string `json:"tag_name"`
+	// Assets is the list of files attached to the release.
+	Assets []*Asset `json:"assets"`
+}
+
+// Asset returns the asset with the name, or nil if the release has none.
+func (r *Release) Asset(name string) *Asset {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset
+		}
+	}
+	return nil
+}
+
+// Asset is a file attached to a GitHub release.
+type Asset struct {
+	// Name is the file name.
+	Name string `json:"name"`
+	// DownloadURL is the URL to download the file from.
+	DownloadURL string `json:"browser_download_url"`
+}
+
+// Client is the interface for fetching GitHub releases.
+type Client interface {
+	// GetLatestRelease returns the latest non-prerelease release of the repository.
+	GetLatestRelease(ctx context.Context, owner string, repo string) (*Release, error)
+	// GetRelease returns the release of the repository with the tag.
+	GetRelease(ctx context.Context, owner string, repo string, tag string) (*Release, error)
+	// DownloadAsset returns the contents of the asset.
+	DownloadAsset(ctx context.Context, asset *Asset) ([]byte, error)
+}
+
+// NewClient creates a new GitHub release client.
+func NewClient(httpClient *http.Client) Client {
+	return &client{
+		httpClient: httpClient,
+	}
+}
+
+// ParseChecksums parses a checksums file in sha256sum format ("<hex digest>  <file name>"
+// per line) into a map from file name to lowercase hex digest.
+func ParseChecksums(data []byte) (map[string]string, error) {
+	checksums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		digest, name, ok := strings.Cut(line, " ")
+		// sha256sum marks binary mode with a "*" before the file name.
+		name = strings.TrimPrefix(strings.TrimSpace(name), "*")
+		if !ok || name == "" || len(digest) != sha256.Size*2 {
+			return nil, fmt.Errorf("invalid checksum line %q", line)
+		}
+		checksums[name] = strings.ToLower(digest)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return checksums, nil
+}
+
+// VerifyChecksum verifies that the SHA-256 digest of data matches the checksum
+// for the file name.
+func VerifyChecksum(checksums map[string]string, name string, data []byte) error {
+	expected, ok := checksums[name]
+	if !ok {
+		return fmt.Errorf("no checksum for %s", name)
+	}
+	digest := sha256.Sum256(data)
+	if actual := hex.EncodeToString(digest[:]); actual != expected {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", name, expected, actual)
+	}
+	return nil
+}
+
+type client struct {
+	httpClient *http.Client
+}
+
+func (c *client) GetLatestRelease(ctx context.Context, owner string, repo string) (*Release, error) {
+	return c.getRelease(ctx, fmt.Sprintf("%s/repos/%s/%s/releases/latest", apiBaseURL, owner, repo))
+}
+
+func (c *client) GetRelease(ctx context.Context, owner string, repo string, tag string) (*Release, error) {
+	return c.getRelease(ctx, fmt.Sprintf("%s/repos/%s/%s/releases/tags/%s", apiBaseURL, owner, repo, tag))
+}
+
+func (c *client) DownloadAsset(ctx context.Context, asset *Asset) ([]byte, error) {
+	if asset == nil || asset.DownloadURL == "" {
+		return nil, errors.New("asset has no download URL")
+	}
+	return c.get(ctx, asset.DownloadURL, "application/octet-stream")
+}
+
+func (c *client) getRelease(ctx context.Context, reqURL string) (*Release, error) {
+	body, err := c.get(ctx, reqURL, "application/vnd.github+json")
+	if err != nil {
+		return nil, err
+	}
+	release := &Release{}
+	if err := json.Unmarshal(body, release); err != nil {
+		return nil, fmt.Errorf("parsing release: %w", err)
+	}
+	return release, nil
+}
+
+func (c *client) get(ctx context.Context, reqURL string, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from %s: %s", resp.StatusCode, reqURL, string(body))
+	}
+	return body, nil
+}
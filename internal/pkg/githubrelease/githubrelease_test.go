@@ -0,0 +1,28 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package githubrelease
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyChecksum(t *testing.T) {
+	t.Parallel()
+	data := []byte("ibctl binary")
+	digest := sha256.Sum256(data)
+	checksums, err := ParseChecksums([]byte(hex.EncodeToString(digest[:]) + "  ibctl-linux-amd64\n" +
+		hex.EncodeToString(make([]byte, sha256.Size)) + " *ibctl-darwin-arm64\n"))
+	require.NoError(t, err)
+	require.Len(t, checksums, 2)
+	require.NoError(t, VerifyChecksum(checksums, "ibctl-linux-amd64", data))
+	require.ErrorContains(t, VerifyChecksum(checksums, "ibctl-darwin-arm64", data), "checksum mismatch")
+	require.ErrorContains(t, VerifyChecksum(checksums, "ibctl-windows-amd64.exe", data), "no checksum")
+	_, err = ParseChecksums([]byte("abc ibctl-linux-amd64\n"))
+	require.Error(t, err)
+}
@@ -0,0 +1,188 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package httpclient constructs the HTTP client shared by all API clients, so
+// that proxies, custom certificate authorities, and request tracing apply to
+// every request.
+package httpclient
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redactedQueryParameters are the query parameters whose values are replaced
+// in traces, since they carry credentials (e.g., the Flex Web Service token).
+var redactedQueryParameters = []string{"t", "token", "access_token", "accessToken", "key"}
+
+// Config configures the HTTP client. The zero value uses http.DefaultClient.
+type Config struct {
+	// ProxyURL is the URL of the proxy for all requests (e.g., "http://proxy.corp:3128"),
+	// or empty to use the HTTPS_PROXY, HTTP_PROXY, and NO_PROXY environment variables.
+	ProxyURL string
+	// CABundlePath is the path of a PEM file of CA certificates to trust in
+	// addition to the system roots, or empty to only trust the system roots.
+	CABundlePath string
+	// InsecureSkipVerify disables TLS certificate verification. For debugging only.
+	InsecureSkipVerify bool
+	// Trace is the writer every request is traced to, or nil to not trace requests.
+	// Each trace line has the method, the URL with credentials redacted, and the
+	// response status and timing.
+	Trace io.Writer
+	// TraceBodyBytes is the number of response body bytes included in traces,
+	// or 0 to not include response bodies.
+	TraceBodyBytes int
+}
+
+// New returns an HTTP client for the config.
+func New(config Config) (*http.Client, error) {
+	if config.ProxyURL == "" && config.CABundlePath == "" && !config.InsecureSkipVerify && config.Trace == nil {
+		return http.DefaultClient, nil
+	}
+	defaultTransport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return nil, errors.New("http.DefaultTransport is not an *http.Transport")
+	}
+	transport := defaultTransport.Clone()
+	if config.ProxyURL != "" {
+		proxyURL, err := ParseProxyURL(config.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if config.CABundlePath != "" || config.InsecureSkipVerify {
+		tlsConfig := &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: config.InsecureSkipVerify,
+		}
+		if config.CABundlePath != "" {
+			rootCAs, err := readCABundle(config.CABundlePath)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.RootCAs = rootCAs
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	if config.Trace != nil {
+		return &http.Client{
+			Transport: &tracingTransport{
+				next:      transport,
+				writer:    config.Trace,
+				bodyBytes: config.TraceBodyBytes,
+			},
+		}, nil
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// RedactURL returns the URL with the values of credential query parameters
+// (e.g., the Flex Web Service token "t") replaced.
+func RedactURL(requestURL *url.URL) string {
+	query := requestURL.Query()
+	redacted := false
+	for _, name := range redactedQueryParameters {
+		if query.Has(name) {
+			query.Set(name, "REDACTED")
+			redacted = true
+		}
+	}
+	if !redacted && requestURL.User == nil {
+		return requestURL.String()
+	}
+	clone := *requestURL
+	clone.User = nil
+	clone.RawQuery = query.Encode()
+	return clone.String()
+}
+
+// ParseProxyURL parses and validates a proxy URL. The scheme must be http,
+// https, or socks5.
+func ParseProxyURL(value string) (*url.URL, error) {
+	proxyURL, err := url.Parse(value)
+	if err != nil || proxyURL.Host == "" {
+		return nil, fmt.Errorf("proxy URL %q is invalid, must be an http, https, or socks5 URL", value)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+		return proxyURL, nil
+	default:
+		return nil, fmt.Errorf("proxy URL %q is invalid, must be an http, https, or socks5 URL", value)
+	}
+}
+
+// *** PRIVATE ***
+
+// tracingTransport writes a trace line for every request it sends.
+type tracingTransport struct {
+	next      http.RoundTripper
+	writer    io.Writer
+	bodyBytes int
+	// lock serializes writes from concurrent requests.
+	lock sync.Mutex
+}
+
+func (t *tracingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	start := time.Now()
+	response, err := t.next.RoundTrip(request)
+	duration := time.Since(start).Round(time.Millisecond)
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s %s %s", start.UTC().Format(time.RFC3339), request.Method, RedactURL(request.URL))
+	if err != nil {
+		fmt.Fprintf(&builder, " error=%q duration=%s\n", err.Error(), duration)
+	} else {
+		fmt.Fprintf(&builder, " status=%q duration=%s\n", response.Status, duration)
+		if t.bodyBytes > 0 {
+			// Read the start of the body for the trace, and put it back for the caller.
+			snippet, readErr := io.ReadAll(io.LimitReader(response.Body, int64(t.bodyBytes)))
+			response.Body = &readCloser{
+				Reader: io.MultiReader(bytes.NewReader(snippet), response.Body),
+				Closer: response.Body,
+			}
+			if readErr != nil {
+				fmt.Fprintf(&builder, "  body error: %v\n", readErr)
+			} else {
+				fmt.Fprintf(&builder, "  body: %q\n", snippet)
+			}
+		}
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	// Tracing is best-effort, so write errors do not fail the request.
+	_, _ = io.WriteString(t.writer, builder.String())
+	return response, err
+}
+
+// readCloser combines a Reader with the Closer of the original body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// readCABundle returns the system roots with the certificates of the PEM file added.
+func readCABundle(filePath string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA bundle: %w", err)
+	}
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil {
+		rootCAs = x509.NewCertPool()
+	}
+	if !rootCAs.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("CA bundle %s contains no PEM certificates", filePath)
+	}
+	return rootCAs, nil
+}
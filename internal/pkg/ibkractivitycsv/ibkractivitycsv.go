@@ -6,25 +6,48 @@
 //
 // Activity Statement CSVs are multi-section files where each row starts with
 // a section name and row type (Header, Data, SubTotal, Total). Different sections
-// have different column layouts. This parser extracts trades, positions, dividends,
-// interest, withholding tax, and financial instrument information.
+// have different column layouts. This parser extracts the statement period,
+// trades, positions, dividends, interest, withholding tax, deposits and
+// withdrawals, fees, the Mark-to-Market Performance Summary, and financial
+// instrument information.
 //
 // Account Information sections are intentionally skipped to avoid reading
-// identifying information like account numbers.
+// identifying information like account numbers, except for the base currency.
 package ibkractivitycsv
 
 import (
+	"crypto/sha256"
 	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/bufdev/ibctl/internal/pkg/filemode"
+	"github.com/bufdev/ibctl/internal/pkg/protoio"
+	"github.com/bufdev/ibctl/internal/pkg/timing"
 )
 
 // ActivityStatement contains all parsed sections from a single Activity Statement CSV file.
 type ActivityStatement struct {
+	// PeriodStart is the first date of the statement period, or zero if the
+	// statement has no period.
+	PeriodStart time.Time
+	// PeriodEnd is the last date of the statement period, or zero if the
+	// statement has no period.
+	PeriodEnd time.Time
+	// BaseCurrency is the account base currency, in which the Mark-to-Market
+	// Performance Summary P/L is reported.
+	BaseCurrency string
 	// Trades contains stock/equity trade executions.
 	Trades []Trade
 	// ForexTrades contains foreign exchange conversion trades.
@@ -37,8 +60,15 @@ type ActivityStatement struct {
 	WithholdingTaxes []WithholdingTax
 	// InterestItems contains interest income and expenses.
 	InterestItems []Interest
+	// DepositWithdrawals contains cash deposits and withdrawals.
+	DepositWithdrawals []DepositWithdrawal
+	// Fees contains other fees, such as market data subscriptions.
+	Fees []Fee
 	// InstrumentInfos contains financial instrument metadata.
 	InstrumentInfos []InstrumentInfo
+	// MTMPerformanceSummaries contains the per-symbol rows of the
+	// Mark-to-Market Performance Summary for the statement period.
+	MTMPerformanceSummaries []MTMPerformanceSummary
 }
 
 // Trade represents a stock/equity trade execution.
@@ -52,7 +82,52 @@ type Trade struct {
 	Proceeds   string
 	Commission string
 	RealizedPL string
-	Code       string
+	// Code is the semicolon-separated list of trade codes (e.g., "O;P").
+	Code string
+}
+
+// Trade codes that change how a trade is merged.
+const (
+	// TradeCodeCancelled marks a cancelled trade.
+	TradeCodeCancelled = "Ca"
+	// TradeCodeCorrected marks a trade that corrects an earlier trade.
+	TradeCodeCorrected = "Co"
+)
+
+// knownTradeCodes are the trade codes of the Activity Statement codes legend.
+// Codes other than TradeCodeCancelled and TradeCodeCorrected are informational
+// (e.g., "O" for opening and "C" for closing) and do not change the trade.
+var knownTradeCodes = map[string]struct{}{
+	"A": {}, "ADR": {}, "AEx": {}, "AFx": {}, "Adj": {}, "Al": {}, "Aw": {},
+	"B": {}, "Bo": {}, "C": {}, "CD": {}, "CP": {}, "Ca": {}, "Co": {}, "Cx": {},
+	"ETF": {}, "Ep": {}, "Ex": {}, "G": {}, "HC": {}, "HFI": {}, "HFR": {},
+	"I": {}, "IA": {}, "IM": {}, "INV": {}, "L": {}, "LD": {}, "LI": {},
+	"LT": {}, "Lo": {}, "M": {}, "MEx": {}, "ML": {}, "MLG": {}, "MLL": {},
+	"MSG": {}, "MSL": {}, "O": {}, "P": {}, "PI": {}, "Po": {}, "Pr": {},
+	"R": {}, "RED": {}, "RP": {}, "Re": {}, "Ri": {}, "SI": {}, "SL": {},
+	"SO": {}, "SS": {}, "ST": {}, "SY": {}, "T": {},
+}
+
+// Codes returns the trade codes of the trade.
+func (t *Trade) Codes() []string {
+	var codes []string
+	for code := range strings.SplitSeq(t.Code, ";") {
+		if code = strings.TrimSpace(code); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// HasCode returns true if the trade has the trade code.
+func (t *Trade) HasCode(code string) bool {
+	return slices.Contains(t.Codes(), code)
+}
+
+// IsKnownTradeCode returns true if the code is in the Activity Statement codes legend.
+func IsKnownTradeCode(code string) bool {
+	_, ok := knownTradeCodes[code]
+	return ok
 }
 
 // ForexTrade represents a foreign exchange conversion trade.
@@ -104,6 +179,49 @@ type Interest struct {
 	Amount       string
 }
 
+// DepositWithdrawal represents a cash deposit or withdrawal.
+type DepositWithdrawal struct {
+	CurrencyCode string
+	// Date is the settle date.
+	Date        time.Time
+	Description string
+	// Amount is positive for deposits, negative for withdrawals.
+	Amount string
+}
+
+// Fee represents a fee, such as a market data subscription.
+type Fee struct {
+	// Subtitle is the fee category (e.g., "Other Fees").
+	Subtitle     string
+	CurrencyCode string
+	Date         time.Time
+	Description  string
+	// Amount is negative for fees charged, positive for refunds.
+	Amount string
+}
+
+// MTMPerformanceSummary is the mark-to-market P/L of a symbol over the statement
+// period. Quantities and prices are in the instrument currency, and P/L is in
+// the account base currency.
+type MTMPerformanceSummary struct {
+	AssetCategory   string
+	Symbol          string
+	PriorQuantity   string
+	CurrentQuantity string
+	PriorPrice      string
+	CurrentPrice    string
+	// PositionPL is the P/L from price changes of the position held at the start of the period.
+	PositionPL string
+	// TransactionPL is the P/L of trades in the period, from trade price to period-end price.
+	TransactionPL string
+	// CommissionsPL is the commissions paid in the period, negative.
+	CommissionsPL string
+	// OtherPL is other P/L, such as from corporate actions and accruals.
+	OtherPL string
+	// TotalPL is the sum of the other P/L fields.
+	TotalPL string
+}
+
 // InstrumentInfo contains financial instrument metadata.
 type InstrumentInfo struct {
 	AssetCategory   string
@@ -120,8 +238,129 @@ type InstrumentInfo struct {
 }
 
 // ParseDirectory reads all *.csv files recursively from the directory and parses them.
+// Files are parsed concurrently; statements are returned in file path order.
 func ParseDirectory(dirPath string) ([]*ActivityStatement, error) {
-	var statements []*ActivityStatement
+	return parseDirectory(dirPath, "")
+}
+
+// ParseDirectoryWithCache is like ParseDirectory, but caches each parsed file as
+// JSON under cacheDirPath. Cache entries are keyed on the file's absolute path,
+// size, and modification time, so edited or replaced files are re-parsed
+// automatically. Cache read and write failures fall back to parsing. Cache
+// files are read and written with protoio, so they are encrypted at rest when
+// protoio encryption is enabled.
+func ParseDirectoryWithCache(dirPath string, cacheDirPath string) ([]*ActivityStatement, error) {
+	return parseDirectory(dirPath, cacheDirPath)
+}
+
+// CacheFilePaths returns the paths of the cache files under cacheDirPath that
+// ParseDirectoryWithCache uses for the CSV files currently in dirPath. Other
+// files under cacheDirPath are stale: the results of files that were since
+// edited, replaced, or removed, or of an older cache version.
+//
+// Returns an empty slice if dirPath does not exist.
+func CacheFilePaths(dirPath string, cacheDirPath string) ([]string, error) {
+	paths, err := csvFilePaths(dirPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	cacheFilePaths := make([]string, 0, len(paths))
+	for _, path := range paths {
+		cacheFilePath, err := cacheFilePathFor(path, cacheDirPath)
+		if err != nil {
+			return nil, err
+		}
+		cacheFilePaths = append(cacheFilePaths, cacheFilePath)
+	}
+	return cacheFilePaths, nil
+}
+
+// ParseFile parses a single IBKR Activity Statement CSV file.
+func ParseFile(filePath string) (*ActivityStatement, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parse(file)
+}
+
+// cacheVersion is bumped whenever ActivityStatement or the parser changes in a
+// way that invalidates previously cached results.
+const cacheVersion = 3
+
+// parseDirectory walks dirPath for CSV files and parses them concurrently,
+// using the cache under cacheDirPath if it is non-empty.
+func parseDirectory(dirPath string, cacheDirPath string) ([]*ActivityStatement, error) {
+	defer timing.Start("csv parse")()
+	paths, err := csvFilePaths(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	// Parse files concurrently with a bounded number of workers. Results are
+	// written by index so output order matches the walk order.
+	statements := make([]*ActivityStatement, len(paths))
+	errs := make([]error, len(paths))
+	semaphore := make(chan struct{}, runtime.GOMAXPROCS(0))
+	var waitGroup sync.WaitGroup
+	for i, path := range paths {
+		waitGroup.Add(1)
+		semaphore <- struct{}{}
+		go func() {
+			defer waitGroup.Done()
+			defer func() { <-semaphore }()
+			statement, err := parseFileWithCache(path, cacheDirPath)
+			if err != nil {
+				errs[i] = fmt.Errorf("parsing %s: %w", path, err)
+				return
+			}
+			statements[i] = statement
+		}()
+	}
+	waitGroup.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return statements, nil
+}
+
+// parseFileWithCache parses a single file, consulting and populating the cache
+// under cacheDirPath if it is non-empty.
+func parseFileWithCache(filePath string, cacheDirPath string) (*ActivityStatement, error) {
+	if cacheDirPath == "" {
+		return ParseFile(filePath)
+	}
+	cacheFilePath, err := cacheFilePathFor(filePath, cacheDirPath)
+	if err != nil {
+		return ParseFile(filePath)
+	}
+	// Use the cached result if present and readable.
+	if data, err := protoio.ReadFile(cacheFilePath); err == nil {
+		statement := &ActivityStatement{}
+		if err := json.Unmarshal(data, statement); err == nil {
+			return statement, nil
+		}
+	}
+	statement, err := ParseFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	// Best-effort cache write; a failure only means re-parsing next time.
+	if data, err := json.Marshal(statement); err == nil {
+		if err := filemode.MkdirAll(cacheDirPath); err == nil {
+			_ = protoio.WriteFile(cacheFilePath, data)
+		}
+	}
+	return statement, nil
+}
+
+// csvFilePaths returns the paths of all *.csv files under dirPath, recursively,
+// in walk order.
+func csvFilePaths(dirPath string) ([]string, error) {
+	var paths []string
 	err := filepath.WalkDir(dirPath, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -132,27 +371,29 @@ func ParseDirectory(dirPath string) ([]*ActivityStatement, error) {
 		if !strings.HasSuffix(strings.ToLower(d.Name()), ".csv") {
 			return nil
 		}
-		statement, err := ParseFile(path)
-		if err != nil {
-			return fmt.Errorf("parsing %s: %w", path, err)
-		}
-		statements = append(statements, statement)
+		paths = append(paths, path)
 		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
-	return statements, nil
+	return paths, nil
 }
 
-// ParseFile parses a single IBKR Activity Statement CSV file.
-func ParseFile(filePath string) (*ActivityStatement, error) {
-	file, err := os.Open(filePath)
+// cacheFilePathFor returns the cache file path for a CSV file, derived from
+// its absolute path, size, modification time, and the cache version.
+func cacheFilePathFor(filePath string, cacheDirPath string) (string, error) {
+	absFilePath, err := filepath.Abs(filePath)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	defer file.Close()
-	return parse(file)
+	info, err := os.Stat(absFilePath)
+	if err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("%d|%s|%d|%d", cacheVersion, absFilePath, info.Size(), info.ModTime().UnixNano())
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(cacheDirPath, hex.EncodeToString(hash[:16])+".json"), nil
 }
 
 func parse(reader io.Reader) (*ActivityStatement, error) {
@@ -180,8 +421,11 @@ func parse(reader io.Reader) (*ActivityStatement, error) {
 		sectionName := record[0]
 		rowType := record[1]
 
-		// Skip Account Information entirely — contains identifying info.
+		// Skip Account Information except the base currency — it contains identifying info.
 		if sectionName == "Account Information" {
+			if rowType == "Data" && len(record) >= 4 && record[2] == "Base Currency" {
+				statement.BaseCurrency = record[3]
+			}
 			continue
 		}
 
@@ -197,6 +441,12 @@ func parse(reader io.Reader) (*ActivityStatement, error) {
 		}
 
 		switch sectionName {
+		case "Statement":
+			if err := parseStatementField(record, statement); err != nil {
+				return nil, fmt.Errorf("parsing statement: %w", err)
+			}
+		case "Mark-to-Market Performance Summary":
+			parseMTMPerformanceSummary(record, sectionHeaders[sectionName], statement)
 		case "Trades":
 			if err := parseTrade(record, sectionHeaders[sectionName], statement); err != nil {
 				return nil, fmt.Errorf("parsing trade: %w", err)
@@ -217,6 +467,14 @@ func parse(reader io.Reader) (*ActivityStatement, error) {
 			if err := parseInterest(record, statement); err != nil {
 				return nil, fmt.Errorf("parsing interest: %w", err)
 			}
+		case "Deposits & Withdrawals":
+			if err := parseDepositWithdrawal(record, statement); err != nil {
+				return nil, fmt.Errorf("parsing deposit or withdrawal: %w", err)
+			}
+		case "Fees":
+			if err := parseFee(record, statement); err != nil {
+				return nil, fmt.Errorf("parsing fee: %w", err)
+			}
 		case "Financial Instrument Information":
 			if err := parseInstrumentInfo(record, sectionHeaders[sectionName], statement); err != nil {
 				return nil, fmt.Errorf("parsing instrument info: %w", err)
@@ -284,6 +542,62 @@ func parseTrade(record []string, header []string, statement *ActivityStatement)
 	return nil
 }
 
+// parseStatementField parses a Statement,Data row. Only the Period field is
+// used, which is either a date range ("January 1, 2026 - January 31, 2026")
+// or a single date ("January 5, 2026").
+func parseStatementField(record []string, statement *ActivityStatement) error {
+	if len(record) < 4 || record[2] != "Period" {
+		return nil
+	}
+	startString, endString, ok := strings.Cut(record[3], " - ")
+	if !ok {
+		endString = startString
+	}
+	periodStart, err := parsePeriodDate(startString)
+	if err != nil {
+		return fmt.Errorf("parsing period %q: %w", record[3], err)
+	}
+	periodEnd, err := parsePeriodDate(endString)
+	if err != nil {
+		return fmt.Errorf("parsing period %q: %w", record[3], err)
+	}
+	statement.PeriodStart = periodStart
+	statement.PeriodEnd = periodEnd
+	return nil
+}
+
+// parseMTMPerformanceSummary parses a Mark-to-Market Performance Summary,Data
+// row. Columns are looked up by header name. Total and subtotal rows, which
+// have no symbol, are skipped.
+func parseMTMPerformanceSummary(record []string, header []string, statement *ActivityStatement) {
+	column := func(name string) string {
+		for i, headerName := range header {
+			if headerName == name && i < len(record) {
+				return cleanNumber(record[i])
+			}
+		}
+		return ""
+	}
+	assetCategory := column("Asset Category")
+	symbol := column("Symbol")
+	if symbol == "" || strings.HasPrefix(assetCategory, "Total") {
+		return
+	}
+	statement.MTMPerformanceSummaries = append(statement.MTMPerformanceSummaries, MTMPerformanceSummary{
+		AssetCategory:   assetCategory,
+		Symbol:          symbol,
+		PriorQuantity:   column("Prior Quantity"),
+		CurrentQuantity: column("Current Quantity"),
+		PriorPrice:      column("Prior Price"),
+		CurrentPrice:    column("Current Price"),
+		PositionPL:      column("Mark-to-Market P/L Position"),
+		TransactionPL:   column("Mark-to-Market P/L Transaction"),
+		CommissionsPL:   column("Mark-to-Market P/L Commissions"),
+		OtherPL:         column("Mark-to-Market P/L Other"),
+		TotalPL:         column("Mark-to-Market P/L Total"),
+	})
+}
+
 // parsePosition parses an Open Positions,Data row. Only processes Summary rows.
 func parsePosition(record []string, header []string, statement *ActivityStatement) error {
 	if len(record) < 3 {
@@ -384,6 +698,53 @@ func parseInterest(record []string, statement *ActivityStatement) error {
 	return nil
 }
 
+// parseDepositWithdrawal parses a Deposits & Withdrawals,Data row. Skips Total rows.
+func parseDepositWithdrawal(record []string, statement *ActivityStatement) error {
+	if len(record) < 6 {
+		return nil
+	}
+	currencyCode := record[2]
+	// Skip Total/summary rows.
+	if strings.HasPrefix(currencyCode, "Total") {
+		return nil
+	}
+	date, err := parseDate(record[3])
+	if err != nil {
+		return fmt.Errorf("parsing deposit or withdrawal date %q: %w", record[3], err)
+	}
+	statement.DepositWithdrawals = append(statement.DepositWithdrawals, DepositWithdrawal{
+		CurrencyCode: currencyCode,
+		Date:         date,
+		Description:  record[4],
+		Amount:       cleanNumber(record[5]),
+	})
+	return nil
+}
+
+// parseFee parses a Fees,Data row. Skips Total rows.
+// Fees rows have a leading Subtitle column: Subtitle,Currency,Date,Description,Amount.
+func parseFee(record []string, statement *ActivityStatement) error {
+	if len(record) < 7 {
+		return nil
+	}
+	// Skip Total/summary rows, which have Total in the Subtitle or Currency column.
+	if strings.HasPrefix(record[2], "Total") || strings.HasPrefix(record[3], "Total") {
+		return nil
+	}
+	date, err := parseDate(record[4])
+	if err != nil {
+		return fmt.Errorf("parsing fee date %q: %w", record[4], err)
+	}
+	statement.Fees = append(statement.Fees, Fee{
+		Subtitle:     record[2],
+		CurrencyCode: record[3],
+		Date:         date,
+		Description:  record[5],
+		Amount:       cleanNumber(record[6]),
+	})
+	return nil
+}
+
 // parseInstrumentInfo parses a Financial Instrument Information,Data row.
 // Handles two header variants: Stocks (without Issuer/Maturity) and Bonds (with Issuer/Maturity).
 func parseInstrumentInfo(record []string, header []string, statement *ActivityStatement) error {
@@ -421,6 +782,11 @@ func parseDate(s string) (time.Time, error) {
 	return time.Parse("2006-01-02", s)
 }
 
+// parsePeriodDate parses a statement period date in "January 2, 2006" format.
+func parsePeriodDate(s string) (time.Time, error) {
+	return time.Parse("January 2, 2006", strings.TrimSpace(s))
+}
+
 // cleanNumber strips commas from numeric strings (e.g., "-2,290" → "-2290").
 func cleanNumber(s string) string {
 	return strings.ReplaceAll(s, ",", "")
@@ -5,6 +5,8 @@
 package ibkractivitycsv
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -16,6 +18,27 @@ func TestParseFile(t *testing.T) {
 	statement, err := ParseFile("testdata/sample.csv")
 	require.NoError(t, err)
 
+	// Verify the statement period and base currency were parsed.
+	require.Equal(t, "2026-01-01", statement.PeriodStart.Format("2006-01-02"))
+	require.Equal(t, "2026-01-31", statement.PeriodEnd.Format("2006-01-02"))
+	require.Equal(t, "USD", statement.BaseCurrency)
+
+	// Verify the Mark-to-Market Performance Summary was parsed (excluding Total rows).
+	require.Len(t, statement.MTMPerformanceSummaries, 2, "expected 2 MTM performance summaries")
+	require.Equal(t, MTMPerformanceSummary{
+		AssetCategory:   "Stocks",
+		Symbol:          "AAPL",
+		PriorQuantity:   "0",
+		CurrentQuantity: "100",
+		PriorPrice:      "0",
+		CurrentPrice:    "175.25",
+		PositionPL:      "0",
+		TransactionPL:   "2475",
+		CommissionsPL:   "-1",
+		OtherPL:         "0",
+		TotalPL:         "2474",
+	}, statement.MTMPerformanceSummaries[0])
+
 	// Verify stock trades were parsed (only Order rows).
 	require.Len(t, statement.Trades, 4, "expected 4 stock trades")
 	// First stock trade should be AAPL buy.
@@ -50,6 +73,18 @@ func TestParseFile(t *testing.T) {
 	// Verify interest items were parsed.
 	require.Len(t, statement.InterestItems, 2, "expected 2 interest items")
 
+	// Verify deposits and withdrawals were parsed (excluding Total rows).
+	require.Len(t, statement.DepositWithdrawals, 2, "expected 2 deposits and withdrawals")
+	require.Equal(t, "50000", statement.DepositWithdrawals[0].Amount)
+	require.Equal(t, "CAD", statement.DepositWithdrawals[1].CurrencyCode)
+	require.Equal(t, "-500", statement.DepositWithdrawals[1].Amount)
+
+	// Verify fees were parsed (excluding Total rows).
+	require.Len(t, statement.Fees, 1, "expected 1 fee")
+	require.Equal(t, "Other Fees", statement.Fees[0].Subtitle)
+	require.Equal(t, "2026-01-03", statement.Fees[0].Date.Format("2006-01-02"))
+	require.Equal(t, "-10", statement.Fees[0].Amount)
+
 	// Verify instrument info was parsed (both stocks and bonds).
 	require.Len(t, statement.InstrumentInfos, 5, "expected 5 instrument infos (4 stocks + 1 bond)")
 	// Check stock instrument info.
@@ -74,3 +109,50 @@ func TestParseFile(t *testing.T) {
 	require.True(t, foundStock, "expected AAPL stock instrument info")
 	require.True(t, foundBond, "expected TEST bond instrument info")
 }
+
+func TestParseDirectoryWithCache(t *testing.T) {
+	t.Parallel()
+	expected, err := ParseDirectory("testdata")
+	require.NoError(t, err)
+	require.NotEmpty(t, expected)
+	cacheDirPath := t.TempDir()
+	// The first pass parses and populates the cache.
+	statements, err := ParseDirectoryWithCache("testdata", cacheDirPath)
+	require.NoError(t, err)
+	require.Equal(t, len(expected), len(statements))
+	entries, err := os.ReadDir(cacheDirPath)
+	require.NoError(t, err)
+	require.Len(t, entries, len(expected))
+	// The second pass reads from the cache and must match the parsed result.
+	cachedStatements, err := ParseDirectoryWithCache("testdata", cacheDirPath)
+	require.NoError(t, err)
+	require.Equal(t, len(expected), len(cachedStatements))
+	for i := range expected {
+		require.Equal(t, expected[i].Trades[0].Symbol, cachedStatements[i].Trades[0].Symbol)
+		require.True(t, expected[i].Trades[0].DateTime.Equal(cachedStatements[i].Trades[0].DateTime))
+		require.Equal(t, len(expected[i].Trades), len(cachedStatements[i].Trades))
+		require.Equal(t, expected[i].Positions, cachedStatements[i].Positions)
+		require.Equal(t, expected[i].InstrumentInfos, cachedStatements[i].InstrumentInfos)
+	}
+	// Every cache file is in use by a CSV file in the directory.
+	cacheFilePaths, err := CacheFilePaths("testdata", cacheDirPath)
+	require.NoError(t, err)
+	require.Len(t, cacheFilePaths, len(entries))
+	for _, entry := range entries {
+		require.Contains(t, cacheFilePaths, filepath.Join(cacheDirPath, entry.Name()))
+	}
+	cacheFilePaths, err = CacheFilePaths("nonexistent", cacheDirPath)
+	require.NoError(t, err)
+	require.Empty(t, cacheFilePaths)
+}
+
+func TestTradeCodes(t *testing.T) {
+	t.Parallel()
+	trade := &Trade{Code: "O; Ca;P"}
+	require.Equal(t, []string{"O", "Ca", "P"}, trade.Codes())
+	require.True(t, trade.HasCode(TradeCodeCancelled))
+	require.False(t, trade.HasCode(TradeCodeCorrected))
+	require.Nil(t, (&Trade{}).Codes())
+	require.True(t, IsKnownTradeCode("Co"))
+	require.False(t, IsKnownTradeCode("Zz"))
+}
@@ -16,9 +16,13 @@
 // The response contains one FlexStatement per IBKR account. Each statement
 // includes Trades, OpenPositions, CashTransactions, Transfers, TradeTransfers,
 // and CorporateActions sections, parsed from the IBKR XML attribute-based format.
+//
+// A replay client returns a previously saved XML response instead of calling
+// the API, for testing and for debugging parsing issues.
 package ibkrflexquery
 
 import (
+	"bytes"
 	"context"
 	"encoding/xml"
 	"errors"
@@ -26,6 +30,7 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"reflect"
 	"strings"
 	"time"
 
@@ -34,10 +39,9 @@ import (
 )
 
 const (
-	// sendRequestURL is the IBKR Flex Web Service endpoint for initiating a query.
-	sendRequestURL = "https://ndcdyn.interactivebrokers.com/AccountManagement/FlexWebService/SendRequest"
-	// getStatementURL is the IBKR Flex Web Service endpoint for retrieving a statement.
-	getStatementURL = "https://ndcdyn.interactivebrokers.com/AccountManagement/FlexWebService/GetStatement"
+	// DefaultBaseURL is the IBKR Flex Web Service base URL. The SendRequest and
+	// GetStatement endpoints are relative to it.
+	DefaultBaseURL = "https://ndcdyn.interactivebrokers.com/AccountManagement/FlexWebService"
 	// userAgent is the required User-Agent header for IBKR (IBKR expects "Java").
 	userAgent = "Java"
 	// maxAttempts is the maximum number of attempts for each API call.
@@ -48,38 +52,186 @@ const (
 	maxRetryDelay = 30 * time.Second
 )
 
+var (
+	// ErrTokenInvalid is returned when IBKR rejects the Flex Web Service token
+	// as invalid or expired.
+	ErrTokenInvalid = errors.New("flex web service token is invalid or expired")
+	// ErrRateLimited is returned when IBKR rejects a request because too many
+	// requests were made with the token.
+	ErrRateLimited = errors.New("flex web service rate limit exceeded")
+	// ErrQueryMissingSection indicates that the Flex Query does not include a
+	// section that is required. See MissingSections.
+	ErrQueryMissingSection = errors.New("flex query is missing a required section")
+)
+
 // Client is the interface for downloading Flex Query data from IBKR.
 type Client interface {
 	// Download fetches and parses a Flex Query statement.
 	//
 	// The token is the Flex Web Service token generated in the IBKR portal.
 	// The queryID identifies which Flex Query to execute.
-	// The fromDate and toDate optionally override the query's configured period.
-	// Pass zero-value dates to use the query's default period.
-	// If one is set, both must be set. Each request is limited to 365 days.
+	// The period optionally overrides the query's configured period with a
+	// preset, and the fromDate and toDate optionally override it with a date
+	// range. Pass an empty period and zero-value dates to use the query's
+	// configured period. If one date is set, both must be set, and a period
+	// cannot be combined with dates. Each request is limited to 365 days.
 	//
 	// The method performs the two-step API flow (SendRequest → GetStatement),
 	// parses the XML response, and returns one FlexStatement per IBKR account.
-	Download(ctx context.Context, token string, queryID string, fromDate xtime.Date, toDate xtime.Date) ([]FlexStatement, error)
+	Download(ctx context.Context, token string, queryID string, period Period, fromDate xtime.Date, toDate xtime.Date) ([]FlexStatement, error)
+	// DownloadRaw is like Download, but returns the raw statement XML without parsing it.
+	// Use ParseResponse to parse the result.
+	DownloadRaw(ctx context.Context, token string, queryID string, period Period, fromDate xtime.Date, toDate xtime.Date) ([]byte, error)
+}
+
+// Period is a Flex Query period preset, which overrides the period configured
+// in the Flex Query.
+type Period string
+
+// Period presets accepted by the Flex Web Service.
+const (
+	PeriodLastBusinessDay     Period = "LastBusinessDay"
+	PeriodLastBusinessWeek    Period = "LastBusinessWeek"
+	PeriodLastMonth           Period = "LastMonth"
+	PeriodLast30CalendarDays  Period = "Last30CalendarDays"
+	PeriodMonthToDate         Period = "MonthToDate"
+	PeriodYearToDate          Period = "YearToDate"
+	PeriodLastQuarter         Period = "LastQuarter"
+	PeriodLastYear            Period = "LastYear"
+	PeriodLast365CalendarDays Period = "Last365CalendarDays"
+)
+
+// Periods are all period presets, from shortest to longest.
+var Periods = []Period{
+	PeriodLastBusinessDay,
+	PeriodLastBusinessWeek,
+	PeriodLast30CalendarDays,
+	PeriodMonthToDate,
+	PeriodLastMonth,
+	PeriodLastQuarter,
+	PeriodYearToDate,
+	PeriodLastYear,
+	PeriodLast365CalendarDays,
+}
+
+// ParsePeriod parses a period preset, ignoring case. Returns the empty Period if value is empty.
+func ParsePeriod(value string) (Period, error) {
+	if value == "" {
+		return "", nil
+	}
+	for _, period := range Periods {
+		if strings.EqualFold(string(period), value) {
+			return period, nil
+		}
+	}
+	names := make([]string, len(Periods))
+	for i, period := range Periods {
+		names[i] = string(period)
+	}
+	return "", fmt.Errorf("unknown period %q, must be one of %s", value, strings.Join(names, ", "))
 }
 
 // NewClient creates a new Flex Query API client. The logger is required.
-func NewClient(logger *slog.Logger) Client {
+func NewClient(logger *slog.Logger, httpClient *http.Client) Client {
+	return NewClientForBaseURL(logger, httpClient, DefaultBaseURL)
+}
+
+// NewClientForBaseURL creates a new Flex Query API client for a Flex Web
+// Service at baseURL instead of DefaultBaseURL, such as a fake server in tests.
+func NewClientForBaseURL(logger *slog.Logger, httpClient *http.Client, baseURL string) Client {
+	baseURL = strings.TrimSuffix(baseURL, "/")
 	return &client{
-		httpClient: http.DefaultClient,
-		logger:     logger,
+		httpClient:      httpClient,
+		logger:          logger,
+		sendRequestURL:  baseURL + "/SendRequest",
+		getStatementURL: baseURL + "/GetStatement",
+	}
+}
+
+// NewReplayClient creates a Client that returns the saved statement XML instead
+// of calling the API. The token, query ID, period, and dates are ignored.
+func NewReplayClient(logger *slog.Logger, xmlData []byte) Client {
+	return &replayClient{
+		logger:  logger,
+		xmlData: xmlData,
+	}
+}
+
+// ParseResponse parses raw statement XML as returned by DownloadRaw into one
+// FlexStatement per IBKR account.
+func ParseResponse(data []byte) ([]FlexStatement, error) {
+	response, err := parseFlexQueryResponse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing flex query response: %w", err)
+	}
+	return response.FlexStatements.Statements, nil
+}
+
+// SplitResponse splits raw statement XML as returned by DownloadRaw into one
+// standalone response per IBKR account, keyed by account ID.
+//
+// Each FlexStatement element is copied byte-for-byte, including attributes and
+// sections not parsed by this package, and wrapped so the result can itself be
+// parsed with ParseResponse or replayed with NewReplayClient.
+func SplitResponse(data []byte) (map[string][]byte, error) {
+	accountIDToData := make(map[string][]byte)
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var depth int
+	// statementDepth is the depth of the current FlexStatement element, or 0 if outside one.
+	var statementDepth int
+	var startOffset int64
+	var accountID string
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.RawToken()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("splitting flex query response: %w", err)
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			depth++
+			if token.Name.Local == "FlexStatement" && statementDepth == 0 {
+				accountID = xmlAttr(token, "accountId")
+				if accountID == "" {
+					return nil, errors.New("splitting flex query response: FlexStatement without accountId")
+				}
+				statementDepth = depth
+				startOffset = offset
+			}
+		case xml.EndElement:
+			if depth == statementDepth {
+				var buffer bytes.Buffer
+				buffer.WriteString("<FlexQueryResponse>\n<FlexStatements count=\"1\">\n")
+				buffer.Write(data[startOffset:decoder.InputOffset()])
+				buffer.WriteString("\n</FlexStatements>\n</FlexQueryResponse>\n")
+				accountIDToData[accountID] = buffer.Bytes()
+				statementDepth = 0
+			}
+			depth--
+		}
 	}
+	return accountIDToData, nil
 }
 
 // FlexStatement contains the data returned by a Flex Query for a single IBKR account.
 type FlexStatement struct {
 	// AccountId is the IBKR account identifier (e.g., "U1234567").
 	AccountId string `xml:"accountId,attr"`
+	// FromDate is the first date covered by the statement (YYYYMMDD).
+	FromDate string `xml:"fromDate,attr"`
+	// ToDate is the last date covered by the statement (YYYYMMDD). Open positions are as of this date.
+	ToDate string `xml:"toDate,attr"`
 	// Trades is the list of trade executions.
 	Trades []XMLTrade `xml:"Trades>Trade"`
+	// Lots is the list of lots closed by trades, as matched by IBKR. Only present
+	// if the Flex Query Trades section includes the "Closed Lots" level of detail.
+	Lots []XMLLot `xml:"Trades>Lot"`
 	// OpenPositions is the list of currently open positions.
 	OpenPositions []XMLPosition `xml:"OpenPositions>OpenPosition"`
-	// CashTransactions is the list of cash transactions (used for FX rate extraction).
+	// CashTransactions is the list of cash transactions (dividends, withholding tax, interest, fees, deposits).
 	CashTransactions []XMLCashTransaction `xml:"CashTransactions>CashTransaction"`
 	// Transfers is the list of position transfers (ACATS, ATON, FOP, internal).
 	Transfers []XMLTransfer `xml:"Transfers>Transfer"`
@@ -89,6 +241,202 @@ type FlexStatement struct {
 	CorporateActions []XMLCorporateAction `xml:"CorporateActions>CorporateAction"`
 	// CashReport is the cash balance report by currency.
 	CashReport []XMLCashReportCurrency `xml:"CashReport>CashReportCurrency"`
+	// DividendAccruals is the list of changes in dividend accruals, which
+	// post dividends on the ex-date and reverse them on the pay date.
+	DividendAccruals []XMLDividendAccrual `xml:"ChangeInDividendAccruals>ChangeInDividendAccrual"`
+}
+
+// Section names of the FlexStatement elements parsed by this package.
+const (
+	SectionTrades           = "Trades"
+	SectionOpenPositions    = "OpenPositions"
+	SectionCashTransactions = "CashTransactions"
+	SectionTransfers        = "Transfers"
+	SectionTradeTransfers   = "TradeTransfers"
+	SectionCorporateActions = "CorporateActions"
+	SectionCashReport       = "CashReport"
+	// SectionChangeInDividendAccruals is the section of dividends declared but not yet paid.
+	SectionChangeInDividendAccruals = "ChangeInDividendAccruals"
+)
+
+// Sections are all FlexStatement sections parsed by this package, in display order.
+// A Flex Query should include all of them.
+var Sections = []string{
+	SectionTrades,
+	SectionOpenPositions,
+	SectionCashTransactions,
+	SectionTransfers,
+	SectionTradeTransfers,
+	SectionCorporateActions,
+	SectionCashReport,
+	SectionChangeInDividendAccruals,
+}
+
+// GetSections returns the names of the sections present in each FlexStatement
+// of the raw statement XML, keyed by account ID.
+//
+// A section that the Flex Query includes is present even if it has no rows,
+// so this distinguishes an empty section from one the query does not include.
+func GetSections(data []byte) (map[string]map[string]struct{}, error) {
+	accountIDToSections := make(map[string]map[string]struct{})
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var depth int
+	// statementDepth is the depth of the current FlexStatement element, or 0 if outside one.
+	var statementDepth int
+	var sections map[string]struct{}
+	for {
+		token, err := decoder.RawToken()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("reading flex query sections: %w", err)
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			depth++
+			switch {
+			case token.Name.Local == "FlexStatement" && statementDepth == 0:
+				statementDepth = depth
+				sections = make(map[string]struct{})
+				accountIDToSections[xmlAttr(token, "accountId")] = sections
+			case statementDepth != 0 && depth == statementDepth+1:
+				sections[token.Name.Local] = struct{}{}
+			}
+		case xml.EndElement:
+			if depth == statementDepth {
+				statementDepth = 0
+			}
+			depth--
+		}
+	}
+	return accountIDToSections, nil
+}
+
+// MissingSections returns the sections of Sections that at least one
+// FlexStatement of the raw statement XML does not include, in Sections order.
+func MissingSections(data []byte) ([]string, error) {
+	accountIDToSections, err := GetSections(data)
+	if err != nil {
+		return nil, err
+	}
+	var missingSections []string
+	for _, section := range Sections {
+		for _, sections := range accountIDToSections {
+			if _, ok := sections[section]; !ok {
+				missingSections = append(missingSections, section)
+				break
+			}
+		}
+	}
+	return missingSections, nil
+}
+
+// SectionSpec describes a Flex Query section: where to find it in the IBKR
+// portal and the fields of its rows that this package reads.
+type SectionSpec struct {
+	// Section is the section name (one of the Section constants).
+	Section string
+	// PortalName is the name of the section in the IBKR portal Flex Query editor.
+	PortalName string
+	// Element is the XML element name of the section's rows (e.g., "Trade").
+	Element string
+	// Fields are the XML attribute names of the rows read by this package, in declaration order.
+	Fields []string
+}
+
+// SectionSpecs are the specs of all sections in Sections, in the same order.
+var SectionSpecs = []SectionSpec{
+	{Section: SectionTrades, PortalName: "Trades", Element: "Trade", Fields: xmlAttrNames(XMLTrade{})},
+	{Section: SectionOpenPositions, PortalName: "Open Positions", Element: "OpenPosition", Fields: xmlAttrNames(XMLPosition{})},
+	{Section: SectionCashTransactions, PortalName: "Cash Transactions", Element: "CashTransaction", Fields: xmlAttrNames(XMLCashTransaction{})},
+	{Section: SectionTransfers, PortalName: "Transfers", Element: "Transfer", Fields: xmlAttrNames(XMLTransfer{})},
+	{Section: SectionTradeTransfers, PortalName: "Incoming/Outgoing Trade Transfers", Element: "TradeTransfer", Fields: xmlAttrNames(XMLTradeTransfer{})},
+	{Section: SectionCorporateActions, PortalName: "Corporate Actions", Element: "CorporateAction", Fields: xmlAttrNames(XMLCorporateAction{})},
+	{Section: SectionCashReport, PortalName: "Cash Report", Element: "CashReportCurrency", Fields: xmlAttrNames(XMLCashReportCurrency{})},
+	{Section: SectionChangeInDividendAccruals, PortalName: "Change in Dividend Accruals", Element: "ChangeInDividendAccrual", Fields: xmlAttrNames(XMLDividendAccrual{})},
+}
+
+// Setting is a Flex Query setting in the IBKR portal Flex Query editor.
+type Setting struct {
+	// Group is the settings group (e.g., "Delivery Configuration").
+	Group string
+	// Name is the setting name.
+	Name string
+	// Value is the value that this package expects.
+	Value string
+}
+
+// Settings are the Flex Query settings that this package expects, in portal order.
+var Settings = []Setting{
+	{Group: "Delivery Configuration", Name: "Format", Value: "XML"},
+	{Group: "Delivery Configuration", Name: "Period", Value: "Last 365 Calendar Days"},
+	{Group: "General Configuration", Name: "Date Format", Value: "yyyyMMdd"},
+	{Group: "General Configuration", Name: "Time Format", Value: "HHmmss"},
+	{Group: "General Configuration", Name: "Date/Time Separator", Value: "; (semi-colon)"},
+	{Group: "General Configuration", Name: "Include Canceled Trades?", Value: "No"},
+	{Group: "General Configuration", Name: "Include Currency Rates?", Value: "Yes"},
+	{Group: "General Configuration", Name: "Include Audit Trail Fields?", Value: "No"},
+	{Group: "General Configuration", Name: "Breakout by Day?", Value: "No"},
+}
+
+// MissingFields returns the fields of SectionSpecs that the rows of the raw
+// statement XML do not include, keyed by section. Fields missing from the
+// first row of a section are missing, since IBKR includes every field
+// selected in the Flex Query on every row. Sections without rows are not
+// checked, so a section is only in the result if it has missing fields.
+func MissingFields(data []byte) (map[string][]string, error) {
+	elementToSpec := make(map[string]SectionSpec, len(SectionSpecs))
+	for _, sectionSpec := range SectionSpecs {
+		elementToSpec[sectionSpec.Element] = sectionSpec
+	}
+	sectionToMissingFields := make(map[string][]string)
+	checked := make(map[string]struct{})
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var depth int
+	// statementDepth is the depth of the current FlexStatement element, or 0 if outside one.
+	var statementDepth int
+	for {
+		token, err := decoder.RawToken()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("reading flex query fields: %w", err)
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			depth++
+			switch {
+			case token.Name.Local == "FlexStatement" && statementDepth == 0:
+				statementDepth = depth
+			case statementDepth != 0 && depth == statementDepth+2:
+				sectionSpec, ok := elementToSpec[token.Name.Local]
+				if !ok {
+					continue
+				}
+				if _, ok := checked[sectionSpec.Section]; ok {
+					continue
+				}
+				checked[sectionSpec.Section] = struct{}{}
+				attrNames := make(map[string]struct{}, len(token.Attr))
+				for _, attr := range token.Attr {
+					attrNames[attr.Name.Local] = struct{}{}
+				}
+				for _, field := range sectionSpec.Fields {
+					if _, ok := attrNames[field]; !ok {
+						sectionToMissingFields[sectionSpec.Section] = append(sectionToMissingFields[sectionSpec.Section], field)
+					}
+				}
+			}
+		case xml.EndElement:
+			if depth == statementDepth {
+				statementDepth = 0
+			}
+			depth--
+		}
+	}
+	return sectionToMissingFields, nil
 }
 
 // XMLTrade represents a trade in the IBKR Flex Query XML format.
@@ -107,6 +455,26 @@ type XMLTrade struct {
 	IBCommission     string `xml:"ibCommission,attr"`
 	Currency         string `xml:"currency,attr"`
 	FifoPnlRealized  string `xml:"fifoPnlRealized,attr"`
+	// IBOrderID is the IBKR order ID. Legs of a combo order share an order ID.
+	IBOrderID string `xml:"ibOrderID,attr"`
+}
+
+// XMLLot represents a lot closed by a trade in the IBKR Flex Query XML format.
+// Lot rows follow the closing Trade row within the Trades section.
+// All fields are XML attributes.
+type XMLLot struct {
+	TradeID       string `xml:"tradeID,attr"`
+	Symbol        string `xml:"symbol,attr"`
+	AssetCategory string `xml:"assetCategory,attr"`
+	BuySell       string `xml:"buySell,attr"`
+	// TradeDate is the date of the closing trade (YYYYMMDD).
+	TradeDate string `xml:"tradeDate,attr"`
+	// OpenDateTime is the date the lot was opened (YYYYMMDD or YYYYMMDD;HHMMSS).
+	OpenDateTime    string `xml:"openDateTime,attr"`
+	Quantity        string `xml:"quantity,attr"`
+	Cost            string `xml:"cost,attr"`
+	FifoPnlRealized string `xml:"fifoPnlRealized,attr"`
+	Currency        string `xml:"currency,attr"`
 }
 
 // XMLPosition represents an open position in the IBKR Flex Query XML format.
@@ -125,14 +493,16 @@ type XMLPosition struct {
 }
 
 // XMLCashTransaction represents a cash transaction in the IBKR Flex Query XML format.
-// Used primarily for extracting FX rates.
+// Covers dividends, payments in lieu, withholding tax, interest, fees, deposits, and withdrawals.
 type XMLCashTransaction struct {
-	DateTime     string `xml:"dateTime,attr"`
-	Currency     string `xml:"currency,attr"`
-	FxRateToBase string `xml:"fxRateToBase,attr"`
-	Type         string `xml:"type,attr"`
-	Amount       string `xml:"amount,attr"`
-	Description  string `xml:"description,attr"`
+	DateTime      string `xml:"dateTime,attr"`
+	Currency      string `xml:"currency,attr"`
+	FxRateToBase  string `xml:"fxRateToBase,attr"`
+	Type          string `xml:"type,attr"`
+	Amount        string `xml:"amount,attr"`
+	Description   string `xml:"description,attr"`
+	Symbol        string `xml:"symbol,attr"`
+	TransactionID string `xml:"transactionID,attr"`
 }
 
 // XMLTransfer represents a position transfer in the IBKR Flex Query XML format.
@@ -188,11 +558,40 @@ type XMLCashReportCurrency struct {
 	EndingSettledCash string `xml:"endingSettledCash,attr"`
 }
 
+// XMLDividendAccrual represents a change in a dividend accrual from the IBKR
+// Flex Query Change in Dividend Accruals section. IBKR posts an accrual (code
+// "Po") on the ex-date and reverses it (code "Re") when the dividend is paid,
+// so the open accruals are those whose net amounts do not sum to zero.
+type XMLDividendAccrual struct {
+	// Date is the date of the change (format: YYYYMMDD).
+	Date string `xml:"date,attr"`
+	// Symbol is the ticker symbol of the dividend payer.
+	Symbol string `xml:"symbol,attr"`
+	// Currency is the ISO currency code of the dividend.
+	Currency string `xml:"currency,attr"`
+	// ExDate is the ex-dividend date (format: YYYYMMDD).
+	ExDate string `xml:"exDate,attr"`
+	// PayDate is the payment date (format: YYYYMMDD).
+	PayDate string `xml:"payDate,attr"`
+	// Quantity is the number of shares the dividend accrues on.
+	Quantity string `xml:"quantity,attr"`
+	// NetAmount is the signed change in the accrual net of withholding tax.
+	NetAmount string `xml:"netAmount,attr"`
+	// Code is "Po" for a posted accrual or "Re" for a reversal.
+	Code string `xml:"code,attr"`
+	// ActionID is the IBKR identifier of the dividend action.
+	ActionID string `xml:"actionID,attr"`
+}
+
 // *** PRIVATE ***
 
 type client struct {
 	httpClient *http.Client
 	logger     *slog.Logger
+	// sendRequestURL is the endpoint for initiating a query.
+	sendRequestURL string
+	// getStatementURL is the endpoint for retrieving a statement.
+	getStatementURL string
 }
 
 // flexQueryResponse is the top-level XML structure of a Flex Query statement.
@@ -217,13 +616,28 @@ type sendResponse struct {
 	ErrorMessage  string   `xml:"ErrorMessage"`
 }
 
+// errorCodeToErr maps IBKR error codes to the errors they are wrapped with.
+var errorCodeToErr = map[string]error{
+	"1012": ErrTokenInvalid, // Token has expired.
+	"1015": ErrTokenInvalid, // Token is invalid.
+	"1018": ErrRateLimited,  // Too many requests have been made from this token.
+}
+
 // retryableErrorCodes are IBKR error codes that indicate a transient failure.
 var retryableErrorCodes = map[string]bool{
 	"1001": true, // Statement could not be generated at this time.
 	"1019": true, // Statement is being generated, please try again shortly.
 }
 
-func (c *client) Download(ctx context.Context, token string, queryID string, fromDate xtime.Date, toDate xtime.Date) ([]FlexStatement, error) {
+func (c *client) Download(ctx context.Context, token string, queryID string, period Period, fromDate xtime.Date, toDate xtime.Date) ([]FlexStatement, error) {
+	xmlData, err := c.DownloadRaw(ctx, token, queryID, period, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+	return ParseResponse(xmlData)
+}
+
+func (c *client) DownloadRaw(ctx context.Context, token string, queryID string, period Period, fromDate xtime.Date, toDate xtime.Date) ([]byte, error) {
 	// Validate required parameters.
 	if token == "" {
 		return nil, errors.New("token is required")
@@ -235,8 +649,11 @@ func (c *client) Download(ctx context.Context, token string, queryID string, fro
 	if fromDate.IsZero() != toDate.IsZero() {
 		return nil, errors.New("fromDate and toDate must both be set or both be zero")
 	}
+	if period != "" && !fromDate.IsZero() {
+		return nil, errors.New("period and dates cannot both be set")
+	}
 	// Step 1: Send the request to get a reference code, with backoff on transient errors.
-	referenceCode, err := c.sendRequest(ctx, token, queryID, fromDate, toDate)
+	referenceCode, err := c.sendRequest(ctx, token, queryID, period, fromDate, toDate)
 	if err != nil {
 		return nil, fmt.Errorf("sending flex query request: %w", err)
 	}
@@ -246,21 +663,19 @@ func (c *client) Download(ctx context.Context, token string, queryID string, fro
 	if err != nil {
 		return nil, fmt.Errorf("getting flex query statement: %w", err)
 	}
-	// Step 3: Parse the XML response into structured data.
-	response, err := parseFlexQueryResponse(xmlData)
-	if err != nil {
-		return nil, fmt.Errorf("parsing flex query response: %w", err)
-	}
-	// Return all per-account statements.
-	return response.FlexStatements.Statements, nil
+	return xmlData, nil
 }
 
 // sendRequest initiates a Flex Query and returns the reference code.
 // Retries on transient IBKR errors with exponential backoff.
-func (c *client) sendRequest(ctx context.Context, token string, queryID string, fromDate xtime.Date, toDate xtime.Date) (string, error) {
+func (c *client) sendRequest(ctx context.Context, token string, queryID string, period Period, fromDate xtime.Date, toDate xtime.Date) (string, error) {
 	// Build the request URL with query parameters.
-	// Parameter order matches IBKR docs: t, q, [fd, td], v.
-	reqURL := fmt.Sprintf("%s?t=%s&q=%s", sendRequestURL, token, queryID)
+	// Parameter order matches IBKR docs: t, q, [p | fd, td], v.
+	reqURL := fmt.Sprintf("%s?t=%s&q=%s", c.sendRequestURL, token, queryID)
+	// Optionally append the period preset override parameter.
+	if period != "" {
+		reqURL += "&p=" + string(period)
+	}
 	// Optionally append date range override parameters (IBKR expects YYYYMMDD format).
 	if !fromDate.IsZero() && !toDate.IsZero() {
 		reqURL += fmt.Sprintf("&fd=%04d%02d%02d&td=%04d%02d%02d", fromDate.Year, fromDate.Month, fromDate.Day, toDate.Year, toDate.Month, toDate.Day)
@@ -271,7 +686,7 @@ func (c *client) sendRequest(ctx context.Context, token string, queryID string,
 			if attempt > 0 {
 				c.logger.Info("retrying send request", "attempt", attempt+1)
 			}
-			c.logger.Debug("send request", "query_id", queryID, "has_dates", !fromDate.IsZero())
+			c.logger.Debug("send request", "query_id", queryID, "period", string(period), "has_dates", !fromDate.IsZero())
 			req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 			if err != nil {
 				return "", false, err
@@ -287,6 +702,9 @@ func (c *client) sendRequest(ctx context.Context, token string, queryID string,
 			if err != nil {
 				return "", false, err
 			}
+			if resp.StatusCode == http.StatusTooManyRequests {
+				return "", false, fmt.Errorf("%w: unexpected status %d: %s", ErrRateLimited, resp.StatusCode, string(body))
+			}
 			if resp.StatusCode != http.StatusOK {
 				return "", false, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
 			}
@@ -299,7 +717,7 @@ func (c *client) sendRequest(ctx context.Context, token string, queryID string,
 				if retryable {
 					c.logger.Warn("transient IBKR error, will retry", "code", sendResp.ErrorCode, "message", sendResp.ErrorMessage)
 				}
-				return "", retryable, fmt.Errorf("%s (code: %s)", sendResp.ErrorMessage, sendResp.ErrorCode)
+				return "", retryable, newResponseError(&sendResp)
 			}
 			return sendResp.ReferenceCode, false, nil
 		},
@@ -316,7 +734,7 @@ func (c *client) getStatement(ctx context.Context, token string, referenceCode s
 			}
 			// Build the request URL with query parameters.
 			// Parameter order matches IBKR docs: t, q, v.
-			reqURL := fmt.Sprintf("%s?t=%s&q=%s&v=3", getStatementURL, token, referenceCode)
+			reqURL := fmt.Sprintf("%s?t=%s&q=%s&v=3", c.getStatementURL, token, referenceCode)
 			req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 			if err != nil {
 				return nil, false, err
@@ -332,6 +750,9 @@ func (c *client) getStatement(ctx context.Context, token string, referenceCode s
 			if err != nil {
 				return nil, false, err
 			}
+			if resp.StatusCode == http.StatusTooManyRequests {
+				return nil, false, fmt.Errorf("%w: unexpected status %d: %s", ErrRateLimited, resp.StatusCode, string(body))
+			}
 			if resp.StatusCode != http.StatusOK {
 				return nil, false, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
 			}
@@ -346,7 +767,7 @@ func (c *client) getStatement(ctx context.Context, token string, referenceCode s
 				if retryable {
 					c.logger.Warn("transient IBKR error, will retry", "code", getResp.ErrorCode, "message", getResp.ErrorMessage)
 				}
-				return nil, retryable, fmt.Errorf("%s (code: %s)", getResp.ErrorMessage, getResp.ErrorCode)
+				return nil, retryable, newResponseError(&getResp)
 			}
 			// If it's not an error response, it's the actual statement XML.
 			return body, false, nil
@@ -354,6 +775,63 @@ func (c *client) getStatement(ctx context.Context, token string, referenceCode s
 	)
 }
 
+// replayClient is a Client that returns saved statement XML.
+type replayClient struct {
+	logger  *slog.Logger
+	xmlData []byte
+}
+
+func (c *replayClient) Download(ctx context.Context, token string, queryID string, period Period, fromDate xtime.Date, toDate xtime.Date) ([]FlexStatement, error) {
+	xmlData, err := c.DownloadRaw(ctx, token, queryID, period, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+	return ParseResponse(xmlData)
+}
+
+func (c *replayClient) DownloadRaw(_ context.Context, _ string, _ string, period Period, fromDate xtime.Date, _ xtime.Date) ([]byte, error) {
+	if !fromDate.IsZero() {
+		c.logger.Warn("date range is ignored when replaying a flex query response")
+	}
+	if period != "" {
+		c.logger.Warn("period is ignored when replaying a flex query response")
+	}
+	return c.xmlData, nil
+}
+
+// newResponseError returns the error for a failed Flex Web Service response,
+// wrapping the error for its code if there is one.
+func newResponseError(response *sendResponse) error {
+	if err, ok := errorCodeToErr[response.ErrorCode]; ok {
+		return fmt.Errorf("%w: %s (code: %s)", err, response.ErrorMessage, response.ErrorCode)
+	}
+	return fmt.Errorf("%s (code: %s)", response.ErrorMessage, response.ErrorCode)
+}
+
+// xmlAttr returns the value of the attribute with the local name, or an empty string.
+func xmlAttr(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// xmlAttrNames returns the names of the XML attributes of a struct, from its
+// xml struct tags, in declaration order.
+func xmlAttrNames(value any) []string {
+	valueType := reflect.TypeOf(value)
+	var names []string
+	for i := range valueType.NumField() {
+		name, options, _ := strings.Cut(valueType.Field(i).Tag.Get("xml"), ",")
+		if options == "attr" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 // parseFlexQueryResponse parses the raw XML data into a flexQueryResponse.
 func parseFlexQueryResponse(data []byte) (*flexQueryResponse, error) {
 	var response flexQueryResponse
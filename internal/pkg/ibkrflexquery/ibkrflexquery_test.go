@@ -0,0 +1,160 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibkrflexquery
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+	"github.com/stretchr/testify/require"
+)
+
+const testResponse = `<FlexQueryResponse queryName="test" type="AF">
+<FlexStatements count="1">
+<FlexStatement accountId="U1234567" fromDate="20250101" toDate="20251231">
+<Trades>
+<Trade tradeID="1" tradeDate="20250102" symbol="AAPL" buySell="BUY" quantity="10" tradePrice="150" currency="USD" />
+<Trade tradeID="3" tradeDate="20250103" symbol="MSFT" buySell="SELL" quantity="-5" tradePrice="400" currency="USD" />
+<Lot tradeID="3" tradeDate="20250103" openDateTime="20240105;093000" symbol="MSFT" buySell="SELL" quantity="5" cost="1500" currency="USD" />
+</Trades>
+<OpenPositions>
+<OpenPosition symbol="AAPL" position="10" markPrice="200" currency="USD" />
+</OpenPositions>
+</FlexStatement>
+</FlexStatements>
+</FlexQueryResponse>`
+
+func TestReplayClient(t *testing.T) {
+	t.Parallel()
+	client := NewReplayClient(slog.New(slog.NewTextHandler(io.Discard, nil)), []byte(testResponse))
+	statements, err := client.Download(t.Context(), "", "", "", xtime.Date{}, xtime.Date{})
+	require.NoError(t, err)
+	require.Len(t, statements, 1)
+	require.Equal(t, "U1234567", statements[0].AccountId)
+	require.Len(t, statements[0].Trades, 2)
+	require.Equal(t, "AAPL", statements[0].Trades[0].Symbol)
+	require.Equal(t, "150", statements[0].Trades[0].TradePrice)
+	// Closed lots are parsed from the Lot rows of the Trades section.
+	require.Len(t, statements[0].Lots, 1)
+	require.Equal(t, "20240105;093000", statements[0].Lots[0].OpenDateTime)
+	require.Len(t, statements[0].OpenPositions, 1)
+	require.Equal(t, "10", statements[0].OpenPositions[0].Position)
+	// The raw response is returned unchanged.
+	xmlData, err := client.DownloadRaw(t.Context(), "", "", "", xtime.Date{}, xtime.Date{})
+	require.NoError(t, err)
+	require.Equal(t, testResponse, string(xmlData))
+}
+
+func TestSplitResponse(t *testing.T) {
+	t.Parallel()
+	// Add a second account with an element this package does not parse.
+	second := `<FlexStatement accountId="U7654321" fromDate="20250101" toDate="20251231"><Unparsed foo="bar" /><Trades><Trade tradeID="2" symbol="MSFT" /></Trades></FlexStatement>`
+	data := strings.Replace(testResponse, "</FlexStatements>", second+"\n</FlexStatements>", 1)
+	accountIDToData, err := SplitResponse([]byte(data))
+	require.NoError(t, err)
+	require.Len(t, accountIDToData, 2)
+	require.Contains(t, string(accountIDToData["U7654321"]), `<Unparsed foo="bar" />`)
+	// Each split response parses on its own.
+	for accountID, splitData := range accountIDToData {
+		statements, err := ParseResponse(splitData)
+		require.NoError(t, err)
+		require.Len(t, statements, 1)
+		require.Equal(t, accountID, statements[0].AccountId)
+		require.NotEmpty(t, statements[0].Trades)
+	}
+}
+
+func TestGetSections(t *testing.T) {
+	t.Parallel()
+	data := strings.Replace(testResponse, "</OpenPositions>", "</OpenPositions>\n<CashReport />", 1)
+	accountIDToSections, err := GetSections([]byte(data))
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		map[string]map[string]struct{}{
+			"U1234567": {SectionTrades: {}, SectionOpenPositions: {}, SectionCashReport: {}},
+		},
+		accountIDToSections,
+	)
+}
+
+func TestMissingSections(t *testing.T) {
+	t.Parallel()
+	missingSections, err := MissingSections([]byte(testResponse))
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		[]string{SectionCashTransactions, SectionTransfers, SectionTradeTransfers, SectionCorporateActions, SectionCashReport, SectionChangeInDividendAccruals},
+		missingSections,
+	)
+}
+
+func TestMissingFields(t *testing.T) {
+	t.Parallel()
+	sectionToMissingFields, err := MissingFields([]byte(testResponse))
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		map[string][]string{
+			SectionTrades:        {"settleDateTarget", "description", "assetCategory", "proceeds", "ibCommission", "fifoPnlRealized", "ibOrderID"},
+			SectionOpenPositions: {"description", "assetCategory", "costBasisPrice", "positionValue", "fifoPnlUnrealized"},
+		},
+		sectionToMissingFields,
+	)
+	require.Len(t, SectionSpecs, len(Sections))
+	for i, sectionSpec := range SectionSpecs {
+		require.Equal(t, Sections[i], sectionSpec.Section)
+	}
+}
+
+func TestClientPeriod(t *testing.T) {
+	t.Parallel()
+	period, err := ParsePeriod("last30calendardays")
+	require.NoError(t, err)
+	require.Equal(t, PeriodLast30CalendarDays, period)
+	_, err = ParsePeriod("LastWeek")
+	require.ErrorContains(t, err, "unknown period")
+	var sendRequestQuery url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		if strings.HasSuffix(request.URL.Path, "/SendRequest") {
+			sendRequestQuery = request.URL.Query()
+			_, _ = responseWriter.Write([]byte("<FlexStatementResponse><Status>Success</Status><ReferenceCode>1</ReferenceCode></FlexStatementResponse>"))
+			return
+		}
+		_, _ = responseWriter.Write([]byte(testResponse))
+	}))
+	defer server.Close()
+	client := NewClientForBaseURL(slog.New(slog.NewTextHandler(io.Discard, nil)), http.DefaultClient, server.URL)
+	_, err = client.DownloadRaw(t.Context(), "token", "1", PeriodYearToDate, xtime.Date{}, xtime.Date{})
+	require.NoError(t, err)
+	require.Equal(t, "YearToDate", sendRequestQuery.Get("p"))
+	require.False(t, sendRequestQuery.Has("fd"))
+	_, err = client.DownloadRaw(t.Context(), "token", "1", PeriodYearToDate, xtime.Date{Year: 2025, Month: 1, Day: 1}, xtime.Date{Year: 2025, Month: 2, Day: 1})
+	require.ErrorContains(t, err, "period and dates cannot both be set")
+}
+
+func TestClientErrors(t *testing.T) {
+	t.Parallel()
+	for code, expectedErr := range map[string]error{
+		"1012": ErrTokenInvalid,
+		"1015": ErrTokenInvalid,
+		"1018": ErrRateLimited,
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(responseWriter http.ResponseWriter, _ *http.Request) {
+			_, _ = responseWriter.Write([]byte("<FlexStatementResponse><Status>Fail</Status><ErrorCode>" + code + "</ErrorCode><ErrorMessage>Failed.</ErrorMessage></FlexStatementResponse>"))
+		}))
+		client := NewClientForBaseURL(slog.New(slog.NewTextHandler(io.Discard, nil)), http.DefaultClient, server.URL)
+		_, err := client.DownloadRaw(t.Context(), "token", "1", "", xtime.Date{}, xtime.Date{})
+		server.Close()
+		require.ErrorIs(t, err, expectedErr, code)
+		require.ErrorContains(t, err, "(code: "+code+")")
+	}
+}
@@ -0,0 +1,309 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibkrtradeconfirm parses IBKR Trade Confirmation Flex reports.
+//
+// Trade Confirmation Flex Queries are configured separately from Activity
+// Flex Queries in the IBKR portal and report one row per execution. Reports
+// can be run as XML, where each execution is a TradeConfirm element, or as
+// CSV, where a header row names the columns. The CSV may include the header
+// and trailer records IBKR adds when "Include header and trailer records" is
+// set (BOF, BOA, HEADER, DATA, EOA, EOF).
+//
+// Columns are matched by name, case-insensitively and ignoring punctuation, so
+// both the XML attribute names (e.g., "tradeID", "buySell") and the CSV column
+// names (e.g., "TradeID", "Buy/Sell") are recognized. Columns that are not
+// needed, including account identifiers other than the account ID, are ignored.
+package ibkrtradeconfirm
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// TradeConfirm is a single execution from a Trade Confirmation Flex report.
+//
+// Numeric fields are decimal strings as reported by IBKR, with commas removed.
+type TradeConfirm struct {
+	// AccountID is the IBKR account ID (e.g., "U1234567"), if reported.
+	AccountID string
+	// TradeID is the IBKR trade ID, shared with Activity Flex Query trades.
+	TradeID string
+	// OrderID is the IBKR order ID, if reported.
+	OrderID       string
+	TradeDate     time.Time
+	SettleDate    time.Time
+	Symbol        string
+	Description   string
+	AssetCategory string
+	CurrencyCode  string
+	// Quantity is positive for buys, negative for sells.
+	Quantity string
+	Price    string
+	// Proceeds is negative for buys, positive for sells.
+	Proceeds string
+	// Commission is negative for commissions paid.
+	Commission string
+}
+
+// ParseDirectory reads all *.csv and *.xml files recursively from the directory
+// and parses them. Trade confirmations are returned in file path order.
+func ParseDirectory(dirPath string) ([]*TradeConfirm, error) {
+	filePaths, err := ListFilePaths(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	var tradeConfirms []*TradeConfirm
+	for _, filePath := range filePaths {
+		fileTradeConfirms, err := ParseFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", filePath, err)
+		}
+		tradeConfirms = append(tradeConfirms, fileTradeConfirms...)
+	}
+	return tradeConfirms, nil
+}
+
+// ListFilePaths returns all *.csv and *.xml files under the directory in walk order.
+// Returns nil if the directory does not exist.
+func ListFilePaths(dirPath string) ([]string, error) {
+	var filePaths []string
+	err := filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(d.Name())) {
+		case ".csv", ".xml":
+			filePaths = append(filePaths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return filePaths, nil
+}
+
+// ParseFile parses a single Trade Confirmation Flex report. Files ending in
+// .xml are parsed as XML and all others as CSV.
+func ParseFile(filePath string) ([]*TradeConfirm, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToLower(filepath.Ext(filePath)) == ".xml" {
+		return ParseXML(data)
+	}
+	return ParseCSV(data)
+}
+
+// ParseXML parses the TradeConfirm elements of a Trade Confirmation Flex report in XML.
+func ParseXML(data []byte) ([]*TradeConfirm, error) {
+	var tradeConfirms []*TradeConfirm
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.RawToken()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("reading XML: %w", err)
+		}
+		element, ok := token.(xml.StartElement)
+		if !ok || element.Name.Local != "TradeConfirm" {
+			continue
+		}
+		fields := make(map[string]string, len(element.Attr))
+		for _, attr := range element.Attr {
+			fields[normalizeName(attr.Name.Local)] = attr.Value
+		}
+		tradeConfirm, err := newTradeConfirm(fields)
+		if err != nil {
+			return nil, fmt.Errorf("parsing trade %q: %w", fields["tradeid"], err)
+		}
+		if tradeConfirm != nil {
+			tradeConfirms = append(tradeConfirms, tradeConfirm)
+		}
+	}
+	return tradeConfirms, nil
+}
+
+// ParseCSV parses a Trade Confirmation Flex report in CSV.
+func ParseCSV(data []byte) ([]*TradeConfirm, error) {
+	csvReader := csv.NewReader(bytes.NewReader(data))
+	// Header and trailer records have different column counts than data rows.
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+	var tradeConfirms []*TradeConfirm
+	var header []string
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading CSV: %w", err)
+		}
+		if len(record) == 0 {
+			continue
+		}
+		// With header and trailer records, rows are prefixed with the record
+		// type and the section code (e.g., "HEADER","TCNF",...).
+		switch strings.ToUpper(record[0]) {
+		case "BOF", "EOF", "BOA", "EOA", "BOS", "EOS", "MSG":
+			continue
+		case "HEADER":
+			header = normalizeNames(record[min(2, len(record)):])
+			continue
+		case "DATA":
+			record = record[min(2, len(record)):]
+		default:
+			// Without header and trailer records, the first row is the header.
+			if header == nil {
+				header = normalizeNames(record)
+				continue
+			}
+		}
+		if header == nil {
+			return nil, errors.New("data row before header row")
+		}
+		fields := make(map[string]string, len(header))
+		for i, name := range header {
+			if i < len(record) {
+				fields[name] = record[i]
+			}
+		}
+		tradeConfirm, err := newTradeConfirm(fields)
+		if err != nil {
+			return nil, fmt.Errorf("parsing trade %q: %w", fields["tradeid"], err)
+		}
+		if tradeConfirm != nil {
+			tradeConfirms = append(tradeConfirms, tradeConfirm)
+		}
+	}
+	return tradeConfirms, nil
+}
+
+// *** PRIVATE ***
+
+// newTradeConfirm builds a TradeConfirm from fields keyed by normalized column
+// name. Returns nil for rows that are not executions, such as order and
+// symbol summary rows.
+func newTradeConfirm(fields map[string]string) (*TradeConfirm, error) {
+	// Reports with a level of detail column also have summary rows, which
+	// would double count the executions they summarize.
+	if levelOfDetail := field(fields, "levelofdetail"); levelOfDetail != "" && !strings.EqualFold(levelOfDetail, "EXECUTION") {
+		return nil, nil
+	}
+	symbol := field(fields, "symbol")
+	if symbol == "" {
+		return nil, errors.New("missing symbol")
+	}
+	tradeDateString := field(fields, "tradedate")
+	if tradeDateString == "" {
+		tradeDateString = field(fields, "datetime")
+	}
+	tradeDate, err := parseDate(tradeDateString)
+	if err != nil {
+		return nil, fmt.Errorf("parsing trade date %q: %w", tradeDateString, err)
+	}
+	settleDate := tradeDate
+	if settleDateString := field(fields, "settledate", "settledatetarget"); settleDateString != "" {
+		settleDate, err = parseDate(settleDateString)
+		if err != nil {
+			return nil, fmt.Errorf("parsing settle date %q: %w", settleDateString, err)
+		}
+	}
+	quantity := cleanNumber(field(fields, "quantity"))
+	if quantity == "" {
+		return nil, errors.New("missing quantity")
+	}
+	// Some report formats have unsigned quantities; the sign comes from the side.
+	if strings.HasPrefix(strings.ToUpper(field(fields, "buysell")), "SELL") && !strings.HasPrefix(quantity, "-") {
+		quantity = "-" + quantity
+	}
+	// Commission-free executions may have an empty commission.
+	commission := cleanNumber(field(fields, "commission", "ibcommission"))
+	if commission == "" {
+		commission = "0"
+	}
+	return &TradeConfirm{
+		AccountID:     field(fields, "accountid", "clientaccountid"),
+		TradeID:       field(fields, "tradeid"),
+		OrderID:       field(fields, "orderid", "iborderid"),
+		TradeDate:     tradeDate,
+		SettleDate:    settleDate,
+		Symbol:        symbol,
+		Description:   field(fields, "description"),
+		AssetCategory: field(fields, "assetcategory", "assetclass"),
+		CurrencyCode:  field(fields, "currency", "currencyprimary"),
+		Quantity:      quantity,
+		Price:         cleanNumber(field(fields, "price", "tradeprice")),
+		Proceeds:      cleanNumber(field(fields, "proceeds")),
+		Commission:    commission,
+	}, nil
+}
+
+// field returns the first non-empty value of the normalized column names.
+func field(fields map[string]string, names ...string) string {
+	for _, name := range names {
+		if value := strings.TrimSpace(fields[name]); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// normalizeNames returns the normalized form of each column name.
+func normalizeNames(names []string) []string {
+	normalized := make([]string, len(names))
+	for i, name := range names {
+		normalized[i] = normalizeName(name)
+	}
+	return normalized
+}
+
+// normalizeName lowercases a column name and removes everything but letters
+// and digits, so "Buy/Sell", "buySell", and "BuySell" are the same name.
+func normalizeName(name string) string {
+	var builder strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+// parseDate parses an IBKR date in YYYYMMDD or YYYY-MM-DD format, ignoring
+// any time that follows (e.g., "20250304;093000" or "2025-03-04, 09:30:00").
+func parseDate(s string) (time.Time, error) {
+	if i := strings.IndexAny(s, ";, "); i >= 0 {
+		s = s[:i]
+	}
+	if strings.Contains(s, "-") {
+		return time.Parse("2006-01-02", s)
+	}
+	return time.Parse("20060102", s)
+}
+
+// cleanNumber strips commas from numeric strings (e.g., "-2,290" → "-2290").
+func cleanNumber(s string) string {
+	return strings.ReplaceAll(s, ",", "")
+}
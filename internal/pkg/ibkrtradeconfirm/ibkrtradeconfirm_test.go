@@ -0,0 +1,59 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibkrtradeconfirm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDirectory(t *testing.T) {
+	t.Parallel()
+	// Files are parsed in walk order: sample.csv, then sample.xml.
+	tradeConfirms, err := ParseDirectory("testdata")
+	require.NoError(t, err)
+	// The ORDER summary row in the XML is skipped.
+	require.Len(t, tradeConfirms, 3)
+
+	// CSV with header and trailer records; the side gives the sign of the quantity.
+	msft := tradeConfirms[0]
+	require.Equal(t, "U0000000", msft.AccountID)
+	require.Equal(t, "1003", msft.TradeID)
+	require.Equal(t, "5003", msft.OrderID)
+	require.Equal(t, "MSFT", msft.Symbol)
+	require.Equal(t, "STK", msft.AssetCategory)
+	require.Equal(t, "USD", msft.CurrencyCode)
+	require.Equal(t, "2024-01-15", msft.TradeDate.Format("2006-01-02"))
+	require.Equal(t, "2024-01-17", msft.SettleDate.Format("2006-01-02"))
+	require.Equal(t, "-3", msft.Quantity)
+	require.Equal(t, "390.25", msft.Price)
+	require.Equal(t, "1170.75", msft.Proceeds)
+	require.Equal(t, "-1.5", msft.Commission)
+
+	// XML.
+	aapl := tradeConfirms[1]
+	require.Equal(t, "1001", aapl.TradeID)
+	require.Equal(t, "AAPL", aapl.Symbol)
+	require.Equal(t, "2024-01-05", aapl.TradeDate.Format("2006-01-02"))
+	require.Equal(t, "10", aapl.Quantity)
+	require.Equal(t, "-1855", aapl.Proceeds)
+	shop := tradeConfirms[2]
+	require.Equal(t, "-5", shop.Quantity)
+	require.Equal(t, "1050", shop.Price)
+	require.Equal(t, "0", shop.Commission)
+}
+
+func TestParseCSVWithoutHeaderRecords(t *testing.T) {
+	t.Parallel()
+	tradeConfirms, err := ParseCSV([]byte(`"Symbol","TradeID","TradeDate","Quantity","Price","Proceeds","CurrencyPrimary"
+"VTI","1004","20240120","2","230","-460","USD"
+`))
+	require.NoError(t, err)
+	require.Len(t, tradeConfirms, 1)
+	require.Equal(t, "VTI", tradeConfirms[0].Symbol)
+	require.Equal(t, "2024-01-20", tradeConfirms[0].SettleDate.Format("2006-01-02"))
+	require.Equal(t, "0", tradeConfirms[0].Commission)
+}
@@ -3,10 +3,21 @@
 // All rights reserved.
 
 // Package mathpb provides helper functions for working with proto math messages (e.g., Decimal).
+//
+// Values are usually handled as int64 total micros, which is fast but
+// overflows above about 9.2 trillion units, such as for bond face values or
+// some crypto quantities multiplied by a price. Decimal itself holds up to
+// int64 units, and the Big functions, Add, Multiply, and Divide handle values
+// of any size with a math/big fallback when the micros fast path would overflow.
+// Values that do not fit in a Decimal at all are represented as decimal
+// strings, with the Strings functions as their arithmetic.
 package mathpb
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"math/big"
 	"strconv"
 	"strings"
 
@@ -16,6 +27,16 @@ import (
 // microsFactor is the number of micros per unit.
 const microsFactor = 1_000_000
 
+var (
+	// ErrOverflow is returned when a value does not fit in a Decimal.
+	ErrOverflow = errors.New("decimal overflow")
+	// ErrDivisionByZero is returned when dividing by zero.
+	ErrDivisionByZero = errors.New("decimal division by zero")
+)
+
+// bigMicrosFactor is microsFactor as a big.Int.
+var bigMicrosFactor = big.NewInt(microsFactor)
+
 // NewDecimal creates a Decimal proto from a decimal string value (e.g., "123.456789").
 func NewDecimal(value string) (*mathv1.Decimal, error) {
 	units, micros, err := ParseToUnitsMicros(value)
@@ -29,6 +50,8 @@ func NewDecimal(value string) (*mathv1.Decimal, error) {
 }
 
 // ToMicros converts a Decimal proto to its total micros representation.
+// Overflows if the absolute value is above about 9.2 trillion; use
+// ToMicrosChecked or ToBigMicros for values that may be that large.
 func ToMicros(d *mathv1.Decimal) int64 {
 	if d == nil {
 		return 0
@@ -36,6 +59,172 @@ func ToMicros(d *mathv1.Decimal) int64 {
 	return d.GetUnits()*microsFactor + d.GetMicros()
 }
 
+// ToMicrosChecked converts a Decimal proto to its total micros representation,
+// returning false if it overflows int64.
+func ToMicrosChecked(d *mathv1.Decimal) (int64, bool) {
+	if d == nil {
+		return 0, true
+	}
+	units := d.GetUnits()
+	if units > (math.MaxInt64-microsFactor)/microsFactor || units < (math.MinInt64+microsFactor)/microsFactor {
+		return 0, false
+	}
+	return units*microsFactor + d.GetMicros(), true
+}
+
+// ToBigMicros converts a Decimal proto to its total micros as a big.Int, which
+// never overflows.
+func ToBigMicros(d *mathv1.Decimal) *big.Int {
+	if d == nil {
+		return new(big.Int)
+	}
+	totalMicros := new(big.Int).Mul(big.NewInt(d.GetUnits()), bigMicrosFactor)
+	return totalMicros.Add(totalMicros, big.NewInt(d.GetMicros()))
+}
+
+// FromBigMicros creates a Decimal proto from total micros as a big.Int.
+// Returns ErrOverflow if the units do not fit in int64.
+func FromBigMicros(totalMicros *big.Int) (*mathv1.Decimal, error) {
+	// QuoRem truncates toward zero, so units and micros have the same sign.
+	units, micros := new(big.Int).QuoRem(totalMicros, bigMicrosFactor, new(big.Int))
+	if !units.IsInt64() {
+		return nil, ErrOverflow
+	}
+	return &mathv1.Decimal{
+		Units:  units.Int64(),
+		Micros: micros.Int64(),
+	}, nil
+}
+
+// ParseBigMicros parses a decimal string of any size to total micros as a
+// big.Int. Digits beyond 6 decimal places are truncated, as with
+// ParseToUnitsMicros.
+func ParseBigMicros(value string) (*big.Int, error) {
+	negative := strings.HasPrefix(value, "-")
+	integerPart, fractionalPart, _ := strings.Cut(strings.TrimPrefix(value, "-"), ".")
+	if integerPart == "" && fractionalPart == "" {
+		return nil, fmt.Errorf("invalid decimal value %q", value)
+	}
+	if len(fractionalPart) > 6 {
+		fractionalPart = fractionalPart[:6]
+	}
+	digits := integerPart + fractionalPart + strings.Repeat("0", 6-len(fractionalPart))
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return nil, fmt.Errorf("invalid decimal value %q", value)
+		}
+	}
+	totalMicros, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal value %q", value)
+	}
+	if negative {
+		totalMicros.Neg(totalMicros)
+	}
+	return totalMicros, nil
+}
+
+// BigMicrosToString converts total micros as a big.Int to a decimal string
+// representation, formatted as ToString.
+func BigMicrosToString(totalMicros *big.Int) string {
+	sign := ""
+	if totalMicros.Sign() < 0 {
+		sign = "-"
+	}
+	units, micros := new(big.Int).QuoRem(new(big.Int).Abs(totalMicros), bigMicrosFactor, new(big.Int))
+	return sign + formatUnitsMicros(units.String(), micros.Int64())
+}
+
+// Add returns a + b. The micros fast path is used unless it would overflow.
+// Returns ErrOverflow if the units of the sum do not fit in int64.
+func Add(a *mathv1.Decimal, b *mathv1.Decimal) (*mathv1.Decimal, error) {
+	aMicros, aOK := ToMicrosChecked(a)
+	bMicros, bOK := ToMicrosChecked(b)
+	if aOK && bOK {
+		sum := aMicros + bMicros
+		// Signed addition overflows only if both operands have the same sign and the sum does not.
+		if (aMicros >= 0) != (bMicros >= 0) || (sum >= 0) == (aMicros >= 0) {
+			return FromMicros(sum), nil
+		}
+	}
+	return FromBigMicros(new(big.Int).Add(ToBigMicros(a), ToBigMicros(b)))
+}
+
+// Multiply returns a * b, truncated to 6 decimal places. The micros fast path
+// is used unless it would overflow. Returns ErrOverflow if the units of the
+// product do not fit in int64.
+func Multiply(a *mathv1.Decimal, b *mathv1.Decimal) (*mathv1.Decimal, error) {
+	aMicros, aOK := ToMicrosChecked(a)
+	bMicros, bOK := ToMicrosChecked(b)
+	if aOK && bOK {
+		// Split b into units and micros, as a*b = a*bUnits + a*bMicros/1e6, so
+		// that only values that overflow as a result take the slow path.
+		bUnits := bMicros / microsFactor
+		bRemainder := bMicros % microsFactor
+		if product, ok := multiplyInt64(aMicros, bUnits); ok {
+			if remainderProduct, ok := multiplyInt64(aMicros, bRemainder); ok {
+				return Add(FromMicros(product), FromMicros(remainderProduct/microsFactor))
+			}
+		}
+	}
+	product := new(big.Int).Mul(ToBigMicros(a), ToBigMicros(b))
+	return FromBigMicros(product.Quo(product, bigMicrosFactor))
+}
+
+// Divide returns a / b, truncated to 6 decimal places. The micros fast path is
+// used unless it would overflow. Returns ErrDivisionByZero if b is zero, and
+// ErrOverflow if the units of the quotient do not fit in int64.
+func Divide(a *mathv1.Decimal, b *mathv1.Decimal) (*mathv1.Decimal, error) {
+	aMicros, aOK := ToMicrosChecked(a)
+	bMicros, bOK := ToMicrosChecked(b)
+	if aOK && bOK {
+		if bMicros == 0 {
+			return nil, ErrDivisionByZero
+		}
+		// a/b in micros is a*1e6/b, which only overflows for large a.
+		if scaled, ok := multiplyInt64(aMicros, microsFactor); ok {
+			return FromMicros(scaled / bMicros), nil
+		}
+	}
+	divisor := ToBigMicros(b)
+	if divisor.Sign() == 0 {
+		return nil, ErrDivisionByZero
+	}
+	quotient := new(big.Int).Mul(ToBigMicros(a), bigMicrosFactor)
+	return FromBigMicros(quotient.Quo(quotient, divisor))
+}
+
+// AddStrings returns a + b for decimal strings of any size. Empty strings are
+// 0. The Decimal fast path is used unless a value does not fit in a Decimal.
+func AddStrings(a string, b string) (string, error) {
+	return applyStrings(a, b, Add, func(aMicros *big.Int, bMicros *big.Int) (*big.Int, error) {
+		return aMicros.Add(aMicros, bMicros), nil
+	})
+}
+
+// MultiplyStrings returns a * b for decimal strings of any size, truncated to
+// 6 decimal places. Empty strings are 0. The Decimal fast path is used unless
+// a value does not fit in a Decimal.
+func MultiplyStrings(a string, b string) (string, error) {
+	return applyStrings(a, b, Multiply, func(aMicros *big.Int, bMicros *big.Int) (*big.Int, error) {
+		product := aMicros.Mul(aMicros, bMicros)
+		return product.Quo(product, bigMicrosFactor), nil
+	})
+}
+
+// DivideStrings returns a / b for decimal strings of any size, truncated to 6
+// decimal places. Empty strings are 0. The Decimal fast path is used unless a
+// value does not fit in a Decimal. Returns ErrDivisionByZero if b is zero.
+func DivideStrings(a string, b string) (string, error) {
+	return applyStrings(a, b, Divide, func(aMicros *big.Int, bMicros *big.Int) (*big.Int, error) {
+		if bMicros.Sign() == 0 {
+			return nil, ErrDivisionByZero
+		}
+		quotient := aMicros.Mul(aMicros, bigMicrosFactor)
+		return quotient.Quo(quotient, bMicros), nil
+	})
+}
+
 // FromMicros creates a Decimal proto from total micros.
 func FromMicros(totalMicros int64) *mathv1.Decimal {
 	return &mathv1.Decimal{
@@ -49,22 +238,17 @@ func ToString(d *mathv1.Decimal) string {
 	if d == nil {
 		return "0"
 	}
-	totalMicros := ToMicros(d)
+	totalMicros, ok := ToMicrosChecked(d)
+	if !ok {
+		return BigMicrosToString(ToBigMicros(d))
+	}
 	// Handle sign separately for correct formatting.
 	sign := ""
 	if totalMicros < 0 {
 		sign = "-"
 		totalMicros = -totalMicros
 	}
-	units := totalMicros / microsFactor
-	micros := totalMicros % microsFactor
-	if micros == 0 {
-		return sign + strconv.FormatInt(units, 10)
-	}
-	// Format with up to 6 decimal places, trim trailing zeros.
-	decimalStr := fmt.Sprintf("%06d", micros)
-	decimalStr = strings.TrimRight(decimalStr, "0")
-	return fmt.Sprintf("%s%d.%s", sign, units, decimalStr)
+	return sign + formatUnitsMicros(strconv.FormatInt(totalMicros/microsFactor, 10), totalMicros%microsFactor)
 }
 
 // Format formats a decimal value rounded to the given precision (number of
@@ -78,7 +262,10 @@ func Format(d *mathv1.Decimal, precision int) string {
 		}
 		return "0"
 	}
-	totalMicros := ToMicros(d)
+	totalMicros, ok := ToMicrosChecked(d)
+	if !ok {
+		return formatBig(ToBigMicros(d), precision)
+	}
 	negative := totalMicros < 0
 	if negative {
 		totalMicros = -totalMicros
@@ -109,9 +296,98 @@ func Format(d *mathv1.Decimal, precision int) string {
 	return fmt.Sprintf("%s%s.%0*d", sign, addCommas(intPart), precision, fracPart)
 }
 
+// formatBig formats total micros as a big.Int like Format, for values whose
+// total micros overflow int64.
+func formatBig(totalMicros *big.Int, precision int) string {
+	sign := ""
+	if totalMicros.Sign() < 0 {
+		sign = "-"
+	}
+	absMicros := new(big.Int).Abs(totalMicros)
+	// Round half up at the requested precision, as Format does.
+	divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(6-precision)), nil)
+	absMicros.Add(absMicros, new(big.Int).Quo(divisor, big.NewInt(2)))
+	absMicros.Quo(absMicros, divisor)
+	fracDivisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(precision)), nil)
+	intPart, fracPart := new(big.Int).QuoRem(absMicros, fracDivisor, new(big.Int))
+	if precision == 0 {
+		return sign + addCommasString(intPart.String())
+	}
+	return fmt.Sprintf("%s%s.%0*d", sign, addCommasString(intPart.String()), precision, fracPart.Int64())
+}
+
+// formatUnitsMicros formats non-negative units and micros with up to 6
+// decimal places, trimming trailing zeros.
+func formatUnitsMicros(units string, micros int64) string {
+	if micros == 0 {
+		return units
+	}
+	return units + "." + strings.TrimRight(fmt.Sprintf("%06d", micros), "0")
+}
+
+// applyStrings applies decimalFunc to the decimal strings a and b, falling
+// back to bigFunc on their total micros if a, b, or the result does not fit in
+// a Decimal.
+func applyStrings(
+	a string,
+	b string,
+	decimalFunc func(*mathv1.Decimal, *mathv1.Decimal) (*mathv1.Decimal, error),
+	bigFunc func(*big.Int, *big.Int) (*big.Int, error),
+) (string, error) {
+	aDecimal, aErr := NewDecimal(a)
+	bDecimal, bErr := NewDecimal(b)
+	if aErr == nil && bErr == nil {
+		result, err := decimalFunc(aDecimal, bDecimal)
+		if err == nil {
+			return ToString(result), nil
+		}
+		if !errors.Is(err, ErrOverflow) {
+			return "", err
+		}
+	}
+	aMicros, err := parseStringMicros(a)
+	if err != nil {
+		return "", err
+	}
+	bMicros, err := parseStringMicros(b)
+	if err != nil {
+		return "", err
+	}
+	result, err := bigFunc(aMicros, bMicros)
+	if err != nil {
+		return "", err
+	}
+	return BigMicrosToString(result), nil
+}
+
+// parseStringMicros parses a decimal string of any size to total micros, with
+// an empty string as 0.
+func parseStringMicros(value string) (*big.Int, error) {
+	if value == "" {
+		return new(big.Int), nil
+	}
+	return ParseBigMicros(value)
+}
+
+// multiplyInt64 returns a * b, and false if it overflows int64.
+func multiplyInt64(a int64, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	product := a * b
+	if product/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	return product, true
+}
+
 // addCommas inserts comma separators into a non-negative integer (e.g., 1234567 → "1,234,567").
 func addCommas(n int64) string {
-	s := strconv.FormatInt(n, 10)
+	return addCommasString(strconv.FormatInt(n, 10))
+}
+
+// addCommasString inserts comma separators into the digits of a non-negative integer.
+func addCommasString(s string) string {
 	if len(s) <= 3 {
 		return s
 	}
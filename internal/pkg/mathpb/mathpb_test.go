@@ -0,0 +1,138 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package mathpb
+
+import (
+	"testing"
+
+	mathv1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/math/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToStringLargeUnits(t *testing.T) {
+	t.Parallel()
+	// 9.3 trillion units overflows total micros, but not units.
+	d, err := NewDecimal("9300000000000.5")
+	require.NoError(t, err)
+	_, ok := ToMicrosChecked(d)
+	require.False(t, ok)
+	require.Equal(t, "9300000000000.5", ToString(d))
+	require.Equal(t, "9,300,000,000,000.50", Format(d, 2))
+	require.Equal(t, "9,300,000,000,001", Format(d, 0))
+	d, err = NewDecimal("-9300000000000.000001")
+	require.NoError(t, err)
+	require.Equal(t, "-9300000000000.000001", ToString(d))
+	require.Equal(t, "-9,300,000,000,000.00", Format(d, 2))
+}
+
+func TestParseBigMicros(t *testing.T) {
+	t.Parallel()
+	for _, value := range []string{"0", "1.5", "-0.000001", "123456789012345678901234.123456"} {
+		totalMicros, err := ParseBigMicros(value)
+		require.NoError(t, err)
+		require.Equal(t, value, BigMicrosToString(totalMicros))
+	}
+	totalMicros, err := ParseBigMicros("1.12345678")
+	require.NoError(t, err)
+	require.Equal(t, "1.123456", BigMicrosToString(totalMicros))
+	_, err = ParseBigMicros("1.2.3")
+	require.Error(t, err)
+	_, err = ParseBigMicros("-")
+	require.Error(t, err)
+}
+
+func TestAdd(t *testing.T) {
+	t.Parallel()
+	requireAdd(t, "3.75", "1.25", "2.5")
+	requireAdd(t, "-0.5", "1.5", "-2")
+	requireAdd(t, "18000000000000", "9000000000000", "9000000000000")
+	a := &mathv1.Decimal{Units: 9_000_000_000_000_000_000}
+	_, err := Add(a, a)
+	require.ErrorIs(t, err, ErrOverflow)
+}
+
+func TestMultiply(t *testing.T) {
+	t.Parallel()
+	requireMultiply(t, "3.75", "1.5", "2.5")
+	requireMultiply(t, "-0.333333", "1", "-0.333333")
+	requireMultiply(t, "0.000001", "0.001", "0.001")
+	// A $10M bond face value at a price of 1,000,000 overflows total micros.
+	requireMultiply(t, "10000000000000", "10000000", "1000000")
+	requireMultiply(t, "-12345678901234.56789", "-123456.789", "100000000.01")
+	a := &mathv1.Decimal{Units: 9_000_000_000_000_000_000}
+	_, err := Multiply(a, &mathv1.Decimal{Units: 2})
+	require.ErrorIs(t, err, ErrOverflow)
+}
+
+func TestDivide(t *testing.T) {
+	t.Parallel()
+	requireDivide(t, "110", "165", "1.5")
+	requireDivide(t, "-0.333333", "-1", "3")
+	requireDivide(t, "2000", "1", "0.0005")
+	// A $10M total cost in micros overflows when scaled by 1e6.
+	requireDivide(t, "6666666.666666", "10000000", "1.5")
+	requireDivide(t, "12345678901234.56789", "12345678901234567.89", "1000")
+	_, err := Divide(mustNewDecimal(t, "1"), mustNewDecimal(t, "0"))
+	require.ErrorIs(t, err, ErrDivisionByZero)
+	_, err = Divide(&mathv1.Decimal{Units: 9_000_000_000_000_000_000}, mustNewDecimal(t, "0.5"))
+	require.ErrorIs(t, err, ErrOverflow)
+}
+
+func TestStrings(t *testing.T) {
+	t.Parallel()
+	// Values that fit in a Decimal take the fast path.
+	requireStrings(t, AddStrings, "3.75", "1.25", "2.5")
+	requireStrings(t, AddStrings, "1.5", "", "1.5")
+	requireStrings(t, MultiplyStrings, "15000000000000", "10000000000", "1500")
+	requireStrings(t, DivideStrings, "0.333333", "1", "3")
+	// Values beyond int64 units fall back to arbitrary precision.
+	requireStrings(t, AddStrings, "18000000000000000000.5", "9000000000000000000", "9000000000000000000.5")
+	requireStrings(t, AddStrings, "-1", "-9000000000000000000000", "8999999999999999999999")
+	requireStrings(t, MultiplyStrings, "90000000000000000000", "9000000000000000000", "10")
+	requireStrings(t, MultiplyStrings, "-15000000000000000000000.000001", "-10000000000000000000000.000001", "1.5")
+	requireStrings(t, DivideStrings, "9000000000000000000", "90000000000000000000", "10")
+	requireStrings(t, DivideStrings, "0.5", "9000000000000000000", "18000000000000000000")
+	_, err := DivideStrings("90000000000000000000", "")
+	require.ErrorIs(t, err, ErrDivisionByZero)
+	_, err = DivideStrings("1", "0")
+	require.ErrorIs(t, err, ErrDivisionByZero)
+	_, err = AddStrings("1.2.3", "1")
+	require.Error(t, err)
+}
+
+func requireStrings(t *testing.T, f func(string, string) (string, error), expected string, a string, b string) {
+	t.Helper()
+	result, err := f(a, b)
+	require.NoError(t, err)
+	require.Equal(t, expected, result)
+}
+
+func requireAdd(t *testing.T, expected string, a string, b string) {
+	t.Helper()
+	sum, err := Add(mustNewDecimal(t, a), mustNewDecimal(t, b))
+	require.NoError(t, err)
+	require.Equal(t, expected, ToString(sum))
+}
+
+func requireMultiply(t *testing.T, expected string, a string, b string) {
+	t.Helper()
+	product, err := Multiply(mustNewDecimal(t, a), mustNewDecimal(t, b))
+	require.NoError(t, err)
+	require.Equal(t, expected, ToString(product))
+}
+
+func requireDivide(t *testing.T, expected string, a string, b string) {
+	t.Helper()
+	quotient, err := Divide(mustNewDecimal(t, a), mustNewDecimal(t, b))
+	require.NoError(t, err)
+	require.Equal(t, expected, ToString(quotient))
+}
+
+func mustNewDecimal(t *testing.T, value string) *mathv1.Decimal {
+	t.Helper()
+	d, err := NewDecimal(value)
+	require.NoError(t, err)
+	return d
+}
@@ -50,9 +50,9 @@ func MoneyValueToString(money *moneyv1.Money) string {
 }
 
 // MoneyTimes multiplies a Money value by a given factor and returns a new Money.
-func MoneyTimes(money *moneyv1.Money, factor int64) *moneyv1.Money {
-	totalMicros := MoneyToMicros(money) * factor
-	return MoneyFromMicros(money.GetCurrencyCode(), totalMicros)
+// Returns mathpb.ErrOverflow if the result does not fit in a Money.
+func MoneyTimes(money *moneyv1.Money, factor int64) (*moneyv1.Money, error) {
+	return MoneyMultiply(money, &mathv1.Decimal{Units: factor})
 }
 
 // MoneyAdd adds two Money values with the same currency and returns a new Money.
@@ -61,6 +61,21 @@ func MoneyAdd(a, b *moneyv1.Money) *moneyv1.Money {
 	return MoneyFromMicros(a.GetCurrencyCode(), totalMicros)
 }
 
+// MoneyMultiply multiplies a Money value by a decimal quantity and returns a new Money,
+// truncated to 6 decimal places. This does not overflow for large values such
+// as bond face values, and returns mathpb.ErrOverflow if the result does not
+// fit in a Money.
+func MoneyMultiply(money *moneyv1.Money, quantity *mathv1.Decimal) (*moneyv1.Money, error) {
+	amount, err := mathpb.Multiply(money.GetAmount(), quantity)
+	if err != nil {
+		return nil, err
+	}
+	return &moneyv1.Money{
+		CurrencyCode: money.GetCurrencyCode(),
+		Amount:       amount,
+	}, nil
+}
+
 // MoneyDivide divides a Money value by a divisor and returns a new Money.
 // Rounds to nearest rather than truncating.
 func MoneyDivide(money *moneyv1.Money, divisor int64) *moneyv1.Money {
@@ -0,0 +1,475 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package pdftext extracts lines of text from PDF files, best-effort.
+//
+// Only what ibctl needs to read tables from statements is supported: the
+// content streams of the file are decoded (uncompressed or FlateDecode), and
+// the strings shown by their text operators are grouped into lines by their
+// vertical position, ordered left to right. Fonts are not interpreted, so
+// text in fonts with custom encodings, such as embedded subset fonts that
+// show glyph IDs, cannot be extracted and is skipped.
+package pdftext
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// spaceKerning is the TJ kerning adjustment, in thousandths of a text space
+// unit, beyond which a space is inserted between strings.
+const spaceKerning = -200
+
+// ExtractLines returns the lines of text in the PDF data, in order of
+// appearance of each content stream, with the text on each line separated
+// by single spaces.
+//
+// Returns an error if the data is not a PDF.
+func ExtractLines(data []byte) ([]string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\n\f\r "), []byte("%PDF-")) {
+		return nil, errors.New("not a PDF file")
+	}
+	var lines []string
+	for _, stream := range streams(data) {
+		lines = append(lines, contentLines(stream)...)
+	}
+	return lines, nil
+}
+
+// nonContentStreamKeys are stream dictionary keys and values of streams that
+// are not page content streams, such as images, fonts, and cross-reference
+// streams, which are skipped.
+var nonContentStreamKeys = [][]byte{
+	[]byte("/Image"),
+	[]byte("/XRef"),
+	[]byte("/ObjStm"),
+	[]byte("/Length1"),
+	[]byte("/Length2"),
+	[]byte("/Metadata"),
+	[]byte("/EmbeddedFile"),
+}
+
+// streams returns the decoded contents of every content stream in the data
+// that is uncompressed or FlateDecode-compressed. Streams that fail to decode
+// are skipped.
+func streams(data []byte) [][]byte {
+	var result [][]byte
+	for offset := 0; ; {
+		start := bytes.Index(data[offset:], []byte("stream"))
+		if start < 0 {
+			return result
+		}
+		start += offset
+		offset = start + len("stream")
+		// "endstream" also contains "stream".
+		if start >= 3 && string(data[start-3:start]) == "end" {
+			continue
+		}
+		// The stream dictionary is between the object header and the keyword.
+		dictStart := bytes.LastIndex(data[:start], []byte("obj"))
+		if dictStart < 0 {
+			continue
+		}
+		dict := data[dictStart:start]
+		contentStart := offset
+		if contentStart < len(data) && data[contentStart] == '\r' {
+			contentStart++
+		}
+		if contentStart < len(data) && data[contentStart] == '\n' {
+			contentStart++
+		}
+		end := bytes.Index(data[contentStart:], []byte("endstream"))
+		if end < 0 {
+			return result
+		}
+		content := bytes.TrimRight(data[contentStart:contentStart+end], "\r\n")
+		offset = contentStart + end + len("endstream")
+		if slices.ContainsFunc(nonContentStreamKeys, func(key []byte) bool { return bytes.Contains(dict, key) }) {
+			continue
+		}
+		switch {
+		case bytes.Contains(dict, []byte("/FlateDecode")):
+			reader, err := zlib.NewReader(bytes.NewReader(content))
+			if err != nil {
+				continue
+			}
+			// Truncated streams still yield the data before the error.
+			decoded, _ := io.ReadAll(reader)
+			result = append(result, decoded)
+		case !bytes.Contains(dict, []byte("/Filter")):
+			result = append(result, content)
+		}
+	}
+}
+
+// textItem is a string shown at a position on the page.
+type textItem struct {
+	x    float64
+	y    float64
+	text string
+}
+
+// contentLines returns the lines of text shown by the text operators in a
+// content stream. Streams without text operators, such as images and fonts,
+// have no lines.
+func contentLines(content []byte) []string {
+	var items []textItem
+	var operands []any
+	// The text line matrix position, and the position of the next string.
+	var lineX, lineY, x, y float64
+	show := func(text string) {
+		if text = strings.TrimSpace(text); text != "" {
+			items = append(items, textItem{x: x, y: y, text: text})
+		}
+		// The width of the string is unknown without the font, so
+		// strings shown after it sort after it.
+		x += 0.001
+	}
+	nextLine := func(tx float64, ty float64) {
+		lineX += tx
+		lineY += ty
+		x, y = lineX, lineY
+	}
+	tokenizer := &tokenizer{data: content}
+	for {
+		token, ok := tokenizer.next()
+		if !ok {
+			break
+		}
+		operator, isOperator := token.(operatorToken)
+		if !isOperator {
+			operands = append(operands, token)
+			continue
+		}
+		switch operator {
+		case "BT":
+			lineX, lineY, x, y = 0, 0, 0, 0
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				nextLine(number(operands[len(operands)-2]), number(operands[len(operands)-1]))
+			}
+		case "Tm":
+			if len(operands) >= 6 {
+				lineX, lineY = number(operands[len(operands)-2]), number(operands[len(operands)-1])
+				x, y = lineX, lineY
+			}
+		case "T*":
+			// The leading is unknown without TL, so move down one unit.
+			nextLine(0, -1)
+		case "Tj":
+			if len(operands) >= 1 {
+				show(text(operands[len(operands)-1]))
+			}
+		case "'", "\"":
+			nextLine(0, -1)
+			if len(operands) >= 1 {
+				show(text(operands[len(operands)-1]))
+			}
+		case "TJ":
+			if len(operands) >= 1 {
+				array, _ := operands[len(operands)-1].([]any)
+				var builder strings.Builder
+				for _, element := range array {
+					if kerning, ok := element.(float64); ok {
+						if kerning < spaceKerning {
+							builder.WriteByte(' ')
+						}
+						continue
+					}
+					builder.WriteString(text(element))
+				}
+				show(builder.String())
+			}
+		}
+		operands = operands[:0]
+	}
+	return itemsToLines(items)
+}
+
+// itemsToLines groups the items into lines by their rounded vertical
+// position, in order of the first item of each line, and joins the items of
+// each line left to right.
+func itemsToLines(items []textItem) []string {
+	var lineKeys []int64
+	keyToItems := make(map[int64][]textItem)
+	for _, item := range items {
+		key := int64(math.Round(item.y))
+		if _, ok := keyToItems[key]; !ok {
+			lineKeys = append(lineKeys, key)
+		}
+		keyToItems[key] = append(keyToItems[key], item)
+	}
+	lines := make([]string, 0, len(lineKeys))
+	for _, key := range lineKeys {
+		lineItems := keyToItems[key]
+		sort.SliceStable(lineItems, func(i, j int) bool {
+			return lineItems[i].x < lineItems[j].x
+		})
+		texts := make([]string, 0, len(lineItems))
+		for _, item := range lineItems {
+			texts = append(texts, item.text)
+		}
+		lines = append(lines, strings.Join(strings.Fields(strings.Join(texts, " ")), " "))
+	}
+	return lines
+}
+
+// number returns the operand as a number, or 0 if it is not a number.
+func number(operand any) float64 {
+	value, _ := operand.(float64)
+	return value
+}
+
+// text returns the operand as text, or empty if it is not a string or
+// contains control characters, which means the font does not use a standard
+// encoding.
+func text(operand any) string {
+	value, ok := operand.(stringToken)
+	if !ok {
+		return ""
+	}
+	for _, b := range []byte(value) {
+		if b < ' ' && b != '\t' {
+			return ""
+		}
+	}
+	// PDFDocEncoding and WinAnsiEncoding match Latin-1 for the characters
+	// statements use.
+	runes := make([]rune, 0, len(value))
+	for _, b := range []byte(value) {
+		runes = append(runes, rune(b))
+	}
+	return string(runes)
+}
+
+// operatorToken is a content stream operator, such as Tj.
+type operatorToken string
+
+// stringToken is a literal or hexadecimal string, decoded.
+type stringToken string
+
+// endArray is the token for the end of an array.
+const endArray = operatorToken("]")
+
+// tokenizer splits a content stream into operands and operators. Numbers
+// are float64, strings are stringToken, arrays are []any, operators are
+// operatorToken, and names and dictionaries are skipped.
+type tokenizer struct {
+	data   []byte
+	offset int
+}
+
+// next returns the next token, or false at the end of the data.
+func (t *tokenizer) next() (any, bool) {
+	for t.offset < len(t.data) {
+		c := t.data[t.offset]
+		switch {
+		case isWhitespace(c):
+			t.offset++
+		case c == '%':
+			// Comments run to the end of the line.
+			for t.offset < len(t.data) && t.data[t.offset] != '\n' && t.data[t.offset] != '\r' {
+				t.offset++
+			}
+		case c == '(':
+			t.offset++
+			return t.literalString(), true
+		case c == '<' && t.offset+1 < len(t.data) && t.data[t.offset+1] == '<':
+			t.offset += 2
+			t.skipDictionary()
+		case c == '<':
+			t.offset++
+			return t.hexString(), true
+		case c == '[':
+			t.offset++
+			var array []any
+			for {
+				token, ok := t.next()
+				if !ok || token == endArray {
+					return array, true
+				}
+				array = append(array, token)
+			}
+		case c == ']':
+			t.offset++
+			return endArray, true
+		case c == '/':
+			t.offset++
+			t.regular()
+		case c == '>' || c == ')' || c == '{' || c == '}':
+			t.offset++
+		default:
+			word := t.regular()
+			if value, err := strconv.ParseFloat(word, 64); err == nil {
+				return value, true
+			}
+			if word == "BI" {
+				t.skipInlineImage()
+				continue
+			}
+			return operatorToken(word), true
+		}
+	}
+	return nil, false
+}
+
+// regular returns the run of regular characters at the offset.
+func (t *tokenizer) regular() string {
+	start := t.offset
+	for t.offset < len(t.data) && !isWhitespace(t.data[t.offset]) && !isDelimiter(t.data[t.offset]) {
+		t.offset++
+	}
+	if t.offset == start {
+		// A lone delimiter, skip it.
+		t.offset++
+	}
+	return string(t.data[start:t.offset])
+}
+
+// literalString decodes a literal string after its opening parenthesis.
+func (t *tokenizer) literalString() stringToken {
+	var builder strings.Builder
+	depth := 1
+	for t.offset < len(t.data) {
+		c := t.data[t.offset]
+		t.offset++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return stringToken(builder.String())
+			}
+		case '\\':
+			if t.offset >= len(t.data) {
+				continue
+			}
+			escaped := t.data[t.offset]
+			t.offset++
+			switch escaped {
+			case 'n':
+				builder.WriteByte('\n')
+			case 'r':
+				builder.WriteByte('\r')
+			case 't':
+				builder.WriteByte('\t')
+			case 'b':
+				builder.WriteByte('\b')
+			case 'f':
+				builder.WriteByte('\f')
+			case '\r', '\n':
+				// A line continuation.
+				if escaped == '\r' && t.offset < len(t.data) && t.data[t.offset] == '\n' {
+					t.offset++
+				}
+			default:
+				if escaped >= '0' && escaped <= '7' {
+					// Up to three octal digits.
+					value := int(escaped - '0')
+					for i := 0; i < 2 && t.offset < len(t.data) && t.data[t.offset] >= '0' && t.data[t.offset] <= '7'; i++ {
+						value = value*8 + int(t.data[t.offset]-'0')
+						t.offset++
+					}
+					builder.WriteByte(byte(value))
+					continue
+				}
+				builder.WriteByte(escaped)
+			}
+			continue
+		}
+		builder.WriteByte(c)
+	}
+	return stringToken(builder.String())
+}
+
+// hexString decodes a hexadecimal string after its opening angle bracket.
+func (t *tokenizer) hexString() stringToken {
+	var digits []byte
+	for t.offset < len(t.data) && t.data[t.offset] != '>' {
+		if c := t.data[t.offset]; !isWhitespace(c) {
+			digits = append(digits, c)
+		}
+		t.offset++
+	}
+	t.offset++
+	// A final odd digit is followed by an implicit 0.
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	decoded := make([]byte, 0, len(digits)/2)
+	for i := 0; i < len(digits); i += 2 {
+		value, err := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+		if err != nil {
+			return ""
+		}
+		decoded = append(decoded, byte(value))
+	}
+	return stringToken(decoded)
+}
+
+// skipDictionary skips a dictionary after its opening angle brackets,
+// including nested dictionaries.
+func (t *tokenizer) skipDictionary() {
+	depth := 1
+	for t.offset < len(t.data) && depth > 0 {
+		switch {
+		case bytes.HasPrefix(t.data[t.offset:], []byte("<<")):
+			depth++
+			t.offset += 2
+		case bytes.HasPrefix(t.data[t.offset:], []byte(">>")):
+			depth--
+			t.offset += 2
+		case t.data[t.offset] == '(':
+			t.offset++
+			t.literalString()
+		default:
+			t.offset++
+		}
+	}
+}
+
+// skipInlineImage skips the data of an inline image through its EI operator.
+func (t *tokenizer) skipInlineImage() {
+	end := bytes.Index(t.data[t.offset:], []byte("EI"))
+	for end >= 0 {
+		endOffset := t.offset + end
+		// EI must be a separate token.
+		if (endOffset == 0 || isWhitespace(t.data[endOffset-1])) &&
+			(endOffset+2 >= len(t.data) || isWhitespace(t.data[endOffset+2])) {
+			t.offset = endOffset + 2
+			return
+		}
+		next := bytes.Index(t.data[endOffset+2:], []byte("EI"))
+		if next < 0 {
+			break
+		}
+		end += 2 + next
+	}
+	t.offset = len(t.data)
+}
+
+// isWhitespace returns true for PDF whitespace characters.
+func isWhitespace(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+// isDelimiter returns true for PDF delimiter characters.
+func isDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package pdftext
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractLines(t *testing.T) {
+	t.Parallel()
+	// A table with one row positioned by Td and one by Tm, with the cells of
+	// the second row shown out of order, and a TJ array with kerning.
+	content := `BT /F1 10 Tf 50 700 Td (Symbol) Tj 100 0 Td (Quantity) Tj ET
+BT 1 0 0 1 150 680 Tm (10) Tj 1 0 0 1 50 680 Tm (AAPL) Tj ET
+BT 50 660 Td [(Tot) 20 (al) -400 (\(USD\))] TJ ET`
+	var compressed bytes.Buffer
+	zlibWriter := zlib.NewWriter(&compressed)
+	_, err := zlibWriter.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, zlibWriter.Close())
+	var data bytes.Buffer
+	data.WriteString("%PDF-1.4\n")
+	data.WriteString("1 0 obj\n<< /Type /XObject /Subtype /Image /Length 4 >>\nstream\n(no)\nendstream\nendobj\n")
+	fmt.Fprintf(&data, "2 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", compressed.Len())
+	data.Write(compressed.Bytes())
+	data.WriteString("\nendstream\nendobj\n%%EOF\n")
+
+	lines, err := ExtractLines(data.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, []string{"Symbol Quantity", "AAPL 10", "Total (USD)"}, lines)
+}
+
+func TestExtractLinesNotPDF(t *testing.T) {
+	t.Parallel()
+	_, err := ExtractLines([]byte("Symbol,Quantity\n"))
+	require.Error(t, err)
+}
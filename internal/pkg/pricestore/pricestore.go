@@ -0,0 +1,275 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package pricestore downloads and caches daily closing prices per symbol.
+//
+// Prices are cached in one JSON file per symbol (<dir>/<symbol>.json) along
+// with the date ranges that have been fetched from the Provider. Update only
+// fetches the parts of a requested range that have not been fetched before, so
+// repeated updates are incremental, and gaps left by earlier partial updates
+// are filled in. Fetched ranges are recorded rather than inferred from the
+// cached prices, so weekends, holidays, and trading halts are not refetched.
+//
+// A range is only recorded as fetched through yesterday, since today's close
+// may not be available yet.
+//
+// Files are read and written with protoio, so they are encrypted at rest if
+// encryption is configured.
+package pricestore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bufdev/ibctl/internal/pkg/filemode"
+	"github.com/bufdev/ibctl/internal/pkg/protoio"
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+)
+
+// Price is a daily closing price.
+type Price struct {
+	// Date is the trading date.
+	Date xtime.Date `json:"date"`
+	// Close is the closing price as a decimal string in the symbol's trading currency.
+	Close string `json:"close"`
+}
+
+// Provider fetches daily closing prices from a quote provider.
+type Provider interface {
+	// GetDailyCloses returns the daily closing prices for the symbol between
+	// start and end inclusive. Dates without a close (weekends, holidays) are omitted.
+	GetDailyCloses(ctx context.Context, symbol string, start xtime.Date, end xtime.Date) ([]Price, error)
+}
+
+// Store is a cache of daily closing prices backed by a Provider.
+type Store struct {
+	dirPath  string
+	provider Provider
+	now      func() time.Time
+	// mu guards the per-symbol cache files within this process.
+	mu sync.Mutex
+}
+
+// NewStore returns a new Store that caches prices in the directory.
+//
+// The provider may be nil if the Store is only used to read cached prices.
+func NewStore(dirPath string, provider Provider) *Store {
+	return &Store{
+		dirPath:  dirPath,
+		provider: provider,
+		now:      time.Now,
+	}
+}
+
+// HasProvider returns true if the Store has a provider to fetch prices with.
+func (s *Store) HasProvider() bool {
+	return s.provider != nil
+}
+
+// Update fetches the prices for the symbol between start and end inclusive that
+// are not already cached. Returns true if the provider was called.
+func (s *Store) Update(ctx context.Context, symbol string, start xtime.Date, end xtime.Date) (bool, error) {
+	if s.provider == nil {
+		return false, errors.New("pricestore: no provider configured")
+	}
+	if end.Before(start) {
+		return false, fmt.Errorf("pricestore: end date %s is before start date %s", end, start)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := s.readFile(symbol)
+	if err != nil {
+		return false, err
+	}
+	gaps := missingRanges(file.Fetched, dateRange{Start: start, End: end})
+	if len(gaps) == 0 {
+		return false, nil
+	}
+	// Today's close may not be final, so ranges are only recorded through yesterday.
+	yesterday := xtime.TimeToDate(s.now()).AddDays(-1)
+	priceMap := make(map[xtime.Date]string, len(file.Prices))
+	for _, price := range file.Prices {
+		priceMap[price.Date] = price.Close
+	}
+	// Prices fetched before a failure are still saved, so the next update
+	// only fetches the remaining gaps.
+	var fetchErr error
+	for _, gap := range gaps {
+		prices, err := s.provider.GetDailyCloses(ctx, symbol, gap.Start, gap.End)
+		if err != nil {
+			fetchErr = fmt.Errorf("fetching %s prices for %s..%s: %w", symbol, gap.Start, gap.End, err)
+			break
+		}
+		for _, price := range prices {
+			if price.Date.Before(gap.Start) || price.Date.After(gap.End) {
+				continue
+			}
+			priceMap[price.Date] = price.Close
+		}
+		if gap.End.After(yesterday) {
+			gap.End = yesterday
+		}
+		if !gap.End.Before(gap.Start) {
+			file.Fetched = append(file.Fetched, gap)
+		}
+	}
+	file.Fetched = mergeRanges(file.Fetched)
+	file.Prices = make([]Price, 0, len(priceMap))
+	for date, closePrice := range priceMap {
+		file.Prices = append(file.Prices, Price{Date: date, Close: closePrice})
+	}
+	sort.Slice(file.Prices, func(i, j int) bool {
+		return file.Prices[i].Date.Before(file.Prices[j].Date)
+	})
+	if err := s.writeFile(symbol, file); err != nil {
+		return true, err
+	}
+	return true, fetchErr
+}
+
+// Prices returns the cached prices for the symbol between start and end
+// inclusive, sorted by date. Returns no prices if the symbol is not cached.
+func (s *Store) Prices(symbol string, start xtime.Date, end xtime.Date) ([]Price, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := s.readFile(symbol)
+	if err != nil {
+		return nil, err
+	}
+	var prices []Price
+	for _, price := range file.Prices {
+		if price.Date.Before(start) || price.Date.After(end) {
+			continue
+		}
+		prices = append(prices, price)
+	}
+	return prices, nil
+}
+
+// Close returns the most recent cached price for the symbol on or before the
+// date, for point-in-time valuation. Returns false if there is none.
+func (s *Store) Close(symbol string, date xtime.Date) (Price, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := s.readFile(symbol)
+	if err != nil {
+		return Price{}, false, err
+	}
+	// Prices are sorted by date, so find the first price after the date.
+	i := sort.Search(len(file.Prices), func(i int) bool {
+		return file.Prices[i].Date.After(date)
+	})
+	if i == 0 {
+		return Price{}, false, nil
+	}
+	return file.Prices[i-1], true, nil
+}
+
+// symbolFile is the cache file contents for a single symbol.
+type symbolFile struct {
+	// Symbol is the ticker symbol.
+	Symbol string `json:"symbol"`
+	// Fetched is the sorted, non-overlapping date ranges fetched from the provider.
+	Fetched []dateRange `json:"fetched"`
+	// Prices is the cached prices sorted by date.
+	Prices []Price `json:"prices"`
+}
+
+// dateRange is an inclusive range of dates.
+type dateRange struct {
+	Start xtime.Date `json:"start"`
+	End   xtime.Date `json:"end"`
+}
+
+func (s *Store) readFile(symbol string) (*symbolFile, error) {
+	filePath, err := s.filePath(symbol)
+	if err != nil {
+		return nil, err
+	}
+	data, err := protoio.ReadFile(filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &symbolFile{Symbol: symbol}, nil
+		}
+		return nil, err
+	}
+	file := &symbolFile{}
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", filePath, err)
+	}
+	return file, nil
+}
+
+func (s *Store) writeFile(symbol string, file *symbolFile) error {
+	filePath, err := s.filePath(symbol)
+	if err != nil {
+		return err
+	}
+	if err := filemode.MkdirAll(s.dirPath); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return protoio.WriteFile(filePath, append(data, '\n'))
+}
+
+// filePath returns the cache file path for the symbol. Symbols are escaped so
+// that symbols with spaces or slashes (e.g., "BRK B") map to a single file.
+func (s *Store) filePath(symbol string) (string, error) {
+	if symbol == "" {
+		return "", errors.New("pricestore: symbol is required")
+	}
+	return filepath.Join(s.dirPath, url.PathEscape(symbol)+".json"), nil
+}
+
+// missingRanges returns the parts of the requested range not covered by the
+// fetched ranges, which must be sorted and non-overlapping.
+func missingRanges(fetched []dateRange, requested dateRange) []dateRange {
+	var missing []dateRange
+	next := requested.Start
+	for _, r := range fetched {
+		if r.End.Before(next) {
+			continue
+		}
+		if r.Start.After(requested.End) {
+			break
+		}
+		if next.Before(r.Start) {
+			missing = append(missing, dateRange{Start: next, End: r.Start.AddDays(-1)})
+		}
+		next = r.End.AddDays(1)
+		if next.After(requested.End) {
+			return missing
+		}
+	}
+	return append(missing, dateRange{Start: next, End: requested.End})
+}
+
+// mergeRanges sorts the ranges and merges overlapping and adjacent ranges.
+func mergeRanges(ranges []dateRange) []dateRange {
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Start.Before(ranges[j].Start)
+	})
+	var merged []dateRange
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && !merged[n-1].End.AddDays(1).Before(r.Start) {
+			if r.End.After(merged[n-1].End) {
+				merged[n-1].End = r.End
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
@@ -0,0 +1,101 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package pricestore
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	provider := &testProvider{}
+	store := NewStore(t.TempDir(), provider)
+	store.now = func() time.Time { return time.Date(2025, 3, 20, 12, 0, 0, 0, time.UTC) }
+	// The initial update fetches the whole range.
+	called, err := store.Update(ctx, "AAPL", newTestDate(3, 3), newTestDate(3, 7))
+	require.NoError(t, err)
+	require.True(t, called)
+	require.Equal(t, []dateRange{{newTestDate(3, 3), newTestDate(3, 7)}}, provider.requests)
+	// A range inside the fetched range is served from the cache.
+	called, err = store.Update(ctx, "AAPL", newTestDate(3, 4), newTestDate(3, 6))
+	require.NoError(t, err)
+	require.False(t, called)
+	// A wider range only fetches the missing ends.
+	provider.requests = nil
+	_, err = store.Update(ctx, "AAPL", newTestDate(3, 1), newTestDate(3, 20))
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		[]dateRange{{newTestDate(3, 1), newTestDate(3, 2)}, {newTestDate(3, 8), newTestDate(3, 20)}},
+		provider.requests,
+	)
+	// Today is not recorded as fetched, since its close may not be available yet.
+	provider.requests = nil
+	_, err = store.Update(ctx, "AAPL", newTestDate(3, 1), newTestDate(3, 20))
+	require.NoError(t, err)
+	require.Equal(t, []dateRange{{newTestDate(3, 20), newTestDate(3, 20)}}, provider.requests)
+	prices, err := store.Prices("AAPL", newTestDate(3, 7), newTestDate(3, 10))
+	require.NoError(t, err)
+	require.Equal(t, []Price{{newTestDate(3, 7), "107"}, {newTestDate(3, 10), "110"}}, prices)
+	// The close on a weekend is the prior Friday's close.
+	price, ok, err := store.Close("AAPL", newTestDate(3, 9))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "107", price.Close)
+	_, ok, err = store.Close("AAPL", newTestDate(2, 1))
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestMissingRanges(t *testing.T) {
+	t.Parallel()
+	fetched := []dateRange{
+		{newTestDate(3, 5), newTestDate(3, 10)},
+		{newTestDate(3, 15), newTestDate(3, 20)},
+	}
+	require.Equal(
+		t,
+		[]dateRange{
+			{newTestDate(3, 1), newTestDate(3, 4)},
+			{newTestDate(3, 11), newTestDate(3, 14)},
+			{newTestDate(3, 21), newTestDate(3, 25)},
+		},
+		missingRanges(fetched, dateRange{newTestDate(3, 1), newTestDate(3, 25)}),
+	)
+	require.Empty(t, missingRanges(fetched, dateRange{newTestDate(3, 6), newTestDate(3, 9)}))
+	require.Equal(
+		t,
+		[]dateRange{{newTestDate(3, 11), newTestDate(3, 14)}},
+		missingRanges(fetched, dateRange{newTestDate(3, 8), newTestDate(3, 16)}),
+	)
+}
+
+// testProvider returns a close of 100 plus the day of the month for every weekday.
+type testProvider struct {
+	requests []dateRange
+}
+
+func (p *testProvider) GetDailyCloses(_ context.Context, _ string, start xtime.Date, end xtime.Date) ([]Price, error) {
+	p.requests = append(p.requests, dateRange{start, end})
+	var prices []Price
+	for date := start; !date.After(end); date = date.AddDays(1) {
+		if weekday := date.In(time.UTC).Weekday(); weekday == time.Saturday || weekday == time.Sunday {
+			continue
+		}
+		prices = append(prices, Price{Date: date, Close: strconv.Itoa(100 + date.Day)})
+	}
+	return prices, nil
+}
+
+func newTestDate(month time.Month, day int) xtime.Date {
+	return xtime.Date{Year: 2025, Month: month, Day: day}
+}
@@ -0,0 +1,208 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package promtext provides counters, gauges, and summaries exposed in the
+// Prometheus text exposition format.
+//
+// Only what ibctl needs is supported: summaries record a sum and a count,
+// without quantiles.
+package promtext
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry is a set of metric families.
+type Registry struct {
+	mu       sync.Mutex
+	families []*family
+}
+
+// NewRegistry returns a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// NewCounter registers and returns a new counter family.
+func (r *Registry) NewCounter(name string, help string, labelNames ...string) *Counter {
+	return &Counter{family: r.register(name, help, "counter", labelNames)}
+}
+
+// NewGauge registers and returns a new gauge family.
+func (r *Registry) NewGauge(name string, help string, labelNames ...string) *Gauge {
+	return &Gauge{family: r.register(name, help, "gauge", labelNames)}
+}
+
+// NewSummary registers and returns a new summary family.
+func (r *Registry) NewSummary(name string, help string, labelNames ...string) *Summary {
+	return &Summary{family: r.register(name, help, "summary", labelNames)}
+}
+
+// WriteText writes all metric families in the Prometheus text format.
+func (r *Registry) WriteText(writer io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var builder strings.Builder
+	for _, family := range r.families {
+		family.writeText(&builder)
+	}
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
+
+// ServeHTTP implements http.Handler by writing all metric families.
+func (r *Registry) ServeHTTP(responseWriter http.ResponseWriter, _ *http.Request) {
+	responseWriter.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = r.WriteText(responseWriter)
+}
+
+// Counter is a family of monotonically increasing values.
+type Counter struct {
+	family *family
+}
+
+// Add adds value, which must not be negative, to the series with the label values.
+func (c *Counter) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		panic("promtext: counter cannot decrease")
+	}
+	c.family.update(labelValues, func(s *series) { s.value += value })
+}
+
+// Gauge is a family of values that can go up and down.
+type Gauge struct {
+	family *family
+}
+
+// Set sets the series with the label values to value.
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.family.update(labelValues, func(s *series) { s.value = value })
+}
+
+// Reset removes all series, e.g. before setting the gauges for a new set of labels.
+func (g *Gauge) Reset() {
+	g.family.registry.mu.Lock()
+	defer g.family.registry.mu.Unlock()
+	g.family.keyToSeries = make(map[string]*series)
+}
+
+// Summary is a family of observed distributions, recorded as a sum and a count.
+type Summary struct {
+	family *family
+}
+
+// Observe records value in the series with the label values.
+func (s *Summary) Observe(value float64, labelValues ...string) {
+	s.family.update(labelValues, func(s *series) {
+		s.value += value
+		s.count++
+	})
+}
+
+// *** PRIVATE ***
+
+type family struct {
+	registry    *Registry
+	name        string
+	help        string
+	metricType  string
+	labelNames  []string
+	keyToSeries map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	// value is the value, or the sum for a summary.
+	value float64
+	// count is the number of observations for a summary.
+	count uint64
+}
+
+func (r *Registry) register(name string, help string, metricType string, labelNames []string) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.families {
+		if existing.name == name {
+			panic(fmt.Sprintf("promtext: duplicate metric %q", name))
+		}
+	}
+	family := &family{
+		registry:    r,
+		name:        name,
+		help:        help,
+		metricType:  metricType,
+		labelNames:  labelNames,
+		keyToSeries: make(map[string]*series),
+	}
+	r.families = append(r.families, family)
+	return family
+}
+
+func (f *family) update(labelValues []string, updateFunc func(*series)) {
+	if len(labelValues) != len(f.labelNames) {
+		panic(fmt.Sprintf("promtext: metric %q has %d labels, got %d values", f.name, len(f.labelNames), len(labelValues)))
+	}
+	f.registry.mu.Lock()
+	defer f.registry.mu.Unlock()
+	key := strings.Join(labelValues, "\x00")
+	s, ok := f.keyToSeries[key]
+	if !ok {
+		s = &series{labelValues: slices.Clone(labelValues)}
+		f.keyToSeries[key] = s
+	}
+	updateFunc(s)
+}
+
+func (f *family) writeText(builder *strings.Builder) {
+	fmt.Fprintf(builder, "# HELP %s %s\n", f.name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(f.help))
+	fmt.Fprintf(builder, "# TYPE %s %s\n", f.name, f.metricType)
+	keys := make([]string, 0, len(f.keyToSeries))
+	for key := range f.keyToSeries {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		s := f.keyToSeries[key]
+		labels := f.labelsString(s.labelValues)
+		if f.metricType == "summary" {
+			fmt.Fprintf(builder, "%s_sum%s %s\n", f.name, labels, formatFloat(s.value))
+			fmt.Fprintf(builder, "%s_count%s %d\n", f.name, labels, s.count)
+			continue
+		}
+		fmt.Fprintf(builder, "%s%s %s\n", f.name, labels, formatFloat(s.value))
+	}
+}
+
+// labelsString returns the {name="value",...} label set, or an empty string if there are no labels.
+func (f *family) labelsString(labelValues []string) string {
+	if len(labelValues) == 0 {
+		return ""
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	pairs := make([]string, len(labelValues))
+	for i, labelValue := range labelValues {
+		pairs[i] = f.labelNames[i] + `="` + replacer.Replace(labelValue) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package promtext
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteText(t *testing.T) {
+	t.Parallel()
+	registry := NewRegistry()
+	counter := registry.NewCounter("requests_total", "Requests served.", "procedure", "code")
+	gauge := registry.NewGauge("value", "The value.")
+	summary := registry.NewSummary("duration_seconds", "The duration.")
+	counter.Add(1, "b", "ok")
+	counter.Add(2, "a", `say "hi"`)
+	counter.Add(1, "b", "ok")
+	gauge.Set(1.5)
+	summary.Observe(2)
+	summary.Observe(0.5)
+	var builder strings.Builder
+	require.NoError(t, registry.WriteText(&builder))
+	require.Equal(t, `# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{procedure="a",code="say \"hi\""} 2
+requests_total{procedure="b",code="ok"} 2
+# HELP value The value.
+# TYPE value gauge
+value 1.5
+# HELP duration_seconds The duration.
+# TYPE duration_seconds summary
+duration_seconds_sum 2.5
+duration_seconds_count 2
+`, builder.String())
+}
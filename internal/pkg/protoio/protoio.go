@@ -3,16 +3,104 @@
 // All rights reserved.
 
 // Package protoio provides functions for reading and writing proto messages as JSON files.
+//
+// Writes are atomic: data is written to a temporary file in the same directory
+// and renamed over the destination, so a crash mid-write never leaves a
+// partially written file behind.
+//
+// IterMessagesJSON decodes a file of newline-separated messages one line at a
+// time, so memory use is bounded by the largest single message rather than the
+// file size. ReadMessagesJSON collects the messages of IterMessagesJSON, so
+// the raw file is never held in memory alongside the decoded messages.
+// ReadMessageJSON reads a single message, such as the merged data cache, which
+// cannot be decoded incrementally.
+//
+// Messages are written as compact JSON with fields in declaration order and
+// map keys sorted. protojson deliberately varies its whitespace between builds,
+// so the output is compacted to keep files byte-for-byte stable across ibctl
+// versions, which keeps diffs quiet for users who track their data in git.
+//
+// Files can optionally be encrypted at rest. SetEncryption configures a
+// process-wide key: sealed files are transparently decrypted on read, and
+// writes are sealed if write encryption is enabled. Sealed files cannot be
+// streamed, so IterMessagesJSON decrypts them in memory first.
+//
+// SetSkipUnchangedWrites makes writes skip files whose content would not
+// change, so their modification times are left alone and sealed files are not
+// re-encrypted with a new nonce.
 package protoio
 
 import (
+	"bufio"
 	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
 	"os"
+	"path/filepath"
+	"sync/atomic"
 
+	"github.com/bufdev/ibctl/internal/pkg/cryptobox"
+	"github.com/bufdev/ibctl/internal/pkg/filemode"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 )
 
+// encryption holds the process-wide encryption settings. See SetEncryption.
+var encryption atomic.Pointer[encryptionConfig]
+
+// skipUnchangedWrites is the process-wide setting of SetSkipUnchangedWrites.
+var skipUnchangedWrites atomic.Bool
+
+// SetEncryption configures at-rest encryption for all reads and writes.
+//
+// Sealed files are decrypted with the key on read. If encryptWrites is true,
+// all writes are sealed with the key. A nil key disables both, in which case
+// reading a sealed file returns an error.
+func SetEncryption(key []byte, encryptWrites bool) {
+	if key == nil {
+		encryption.Store(nil)
+		return
+	}
+	encryption.Store(&encryptionConfig{key: key, encryptWrites: encryptWrites})
+}
+
+// SetSkipUnchangedWrites configures whether writes skip files that already
+// have the same content. A file is only skipped if its plaintext has the same
+// SHA-256 hash as the data and it is sealed if and only if writes are sealed.
+func SetSkipUnchangedWrites(skip bool) {
+	skipUnchangedWrites.Store(skip)
+}
+
+// ReadFile reads a file, decrypting it if it is sealed.
+func ReadFile(filePath string) ([]byte, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return openIfSealed(filePath, data)
+}
+
+// WriteFile atomically writes data to a file, sealing it if write encryption is enabled.
+func WriteFile(filePath string, data []byte) error {
+	config := encryption.Load()
+	sealWrites := config != nil && config.encryptWrites
+	if skipUnchangedWrites.Load() && isUnchanged(filePath, data, sealWrites) {
+		return nil
+	}
+	if sealWrites {
+		sealed, err := cryptobox.Seal(config.key, data)
+		if err != nil {
+			return err
+		}
+		data = sealed
+	}
+	return writeFileAtomic(filePath, data)
+}
+
 // WriteMessageJSON writes a single proto message as JSON to a file.
 func WriteMessageJSON(filePath string, message proto.Message) error {
 	data, err := protojsonMarshal(message)
@@ -21,12 +109,12 @@ func WriteMessageJSON(filePath string, message proto.Message) error {
 	}
 	// Append a trailing newline for clean file formatting.
 	data = append(data, '\n')
-	return os.WriteFile(filePath, data, 0o644)
+	return WriteFile(filePath, data)
 }
 
 // ReadMessageJSON reads a single proto message from a JSON file.
 func ReadMessageJSON(filePath string, message proto.Message) error {
-	data, err := os.ReadFile(filePath)
+	data, err := ReadFile(filePath)
 	if err != nil {
 		return err
 	}
@@ -44,22 +132,15 @@ func WriteMessagesJSON[M proto.Message](filePath string, messages []M) error {
 		buf.Write(data)
 		buf.WriteByte('\n')
 	}
-	return os.WriteFile(filePath, buf.Bytes(), 0o644)
+	return WriteFile(filePath, buf.Bytes())
 }
 
 // ReadMessagesJSON reads newline-separated JSON proto messages from a file.
+// The file is decoded incrementally with IterMessagesJSON.
 func ReadMessagesJSON[M proto.Message](filePath string, newMessage func() M) ([]M, error) {
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, err
-	}
 	var messages []M
-	for line := range bytes.SplitSeq(data, []byte("\n")) {
-		if len(line) == 0 {
-			continue
-		}
-		message := newMessage()
-		if err := protojsonUnmarshal(line, message); err != nil {
+	for message, err := range IterMessagesJSON(filePath, newMessage) {
+		if err != nil {
 			return nil, err
 		}
 		messages = append(messages, message)
@@ -67,9 +148,163 @@ func ReadMessagesJSON[M proto.Message](filePath string, newMessage func() M) ([]
 	return messages, nil
 }
 
-// protojsonMarshal marshals a proto message to JSON using proto field names.
+// IterMessagesJSON returns an iterator over newline-separated JSON proto messages
+// in a file. The file is read incrementally and is closed when iteration ends.
+//
+// Iteration stops after the first error, which is yielded with a zero message.
+func IterMessagesJSON[M proto.Message](filePath string, newMessage func() M) iter.Seq2[M, error] {
+	return func(yield func(M, error) bool) {
+		file, err := os.Open(filePath)
+		if err != nil {
+			var zero M
+			yield(zero, err)
+			return
+		}
+		defer file.Close()
+		bufferedReader := bufio.NewReader(file)
+		var reader io.Reader = bufferedReader
+		// Sealed files must be decrypted as a whole before decoding.
+		if prefix, _ := bufferedReader.Peek(cryptobox.PrefixSize); cryptobox.IsSealed(prefix) {
+			sealed, err := io.ReadAll(bufferedReader)
+			if err != nil {
+				var zero M
+				yield(zero, err)
+				return
+			}
+			data, err := openIfSealed(filePath, sealed)
+			if err != nil {
+				var zero M
+				yield(zero, err)
+				return
+			}
+			reader = bytes.NewReader(data)
+		}
+		for message, err := range DecodeMessagesJSON(reader, newMessage) {
+			if !yield(message, err) {
+				return
+			}
+		}
+	}
+}
+
+// DecodeMessagesJSON returns an iterator over newline-separated JSON proto
+// messages read from the reader. Empty lines are skipped.
+//
+// Iteration stops after the first error, which is yielded with a zero message.
+func DecodeMessagesJSON[M proto.Message](reader io.Reader, newMessage func() M) iter.Seq2[M, error] {
+	return func(yield func(M, error) bool) {
+		var zero M
+		bufferedReader := bufio.NewReader(reader)
+		for {
+			// ReadBytes has no line length limit, unlike bufio.Scanner.
+			line, readErr := bufferedReader.ReadBytes('\n')
+			if line = bytes.TrimSuffix(line, []byte("\n")); len(line) > 0 {
+				message := newMessage()
+				if err := protojsonUnmarshal(line, message); err != nil {
+					yield(zero, err)
+					return
+				}
+				if !yield(message, nil) {
+					return
+				}
+			}
+			if readErr != nil {
+				if !errors.Is(readErr, io.EOF) {
+					yield(zero, readErr)
+				}
+				return
+			}
+		}
+	}
+}
+
+// encryptionConfig is the key and write mode configured by SetEncryption.
+type encryptionConfig struct {
+	key           []byte
+	encryptWrites bool
+}
+
+// openIfSealed decrypts the data if it is sealed, and returns it unchanged otherwise.
+func openIfSealed(filePath string, data []byte) ([]byte, error) {
+	if !cryptobox.IsSealed(data) {
+		return data, nil
+	}
+	config := encryption.Load()
+	if config == nil {
+		return nil, fmt.Errorf("%s is encrypted but no encryption key is configured", filePath)
+	}
+	plaintext, err := cryptobox.Open(config.key, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	return plaintext, nil
+}
+
+// isUnchanged returns true if the file exists, is sealed if and only if
+// sealed is true, and has plaintext with the same SHA-256 hash as data.
+func isUnchanged(filePath string, data []byte, sealed bool) bool {
+	existing, err := os.ReadFile(filePath)
+	if err != nil {
+		return false
+	}
+	if cryptobox.IsSealed(existing) != sealed {
+		return false
+	}
+	plaintext, err := openIfSealed(filePath, existing)
+	if err != nil {
+		return false
+	}
+	return sha256.Sum256(plaintext) == sha256.Sum256(data)
+}
+
+// writeFileAtomic writes data to a temporary file in the destination directory,
+// syncs it, and renames it over filePath.
+func writeFileAtomic(filePath string, data []byte) (retErr error) {
+	if err := filemode.CheckWritable(filePath); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tempFilePath := file.Name()
+	defer func() {
+		// Clean up the temporary file if anything failed before the rename.
+		if retErr != nil {
+			_ = os.Remove(tempFilePath)
+		}
+	}()
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	// CreateTemp uses 0600; apply the configured permissions of data files.
+	if err := os.Chmod(tempFilePath, filemode.File()); err != nil {
+		return err
+	}
+	return os.Rename(tempFilePath, filePath)
+}
+
+// protojsonMarshal marshals a proto message to compact JSON using proto field
+// names. The output of protojson is compacted to remove the whitespace it
+// varies between builds.
 func protojsonMarshal(message proto.Message) ([]byte, error) {
-	return (protojson.MarshalOptions{UseProtoNames: true}).Marshal(message)
+	data, err := (protojson.MarshalOptions{UseProtoNames: true}).Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	var buffer bytes.Buffer
+	if err := json.Compact(&buffer, data); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
 }
 
 // protojsonUnmarshal unmarshals JSON data into a proto message.
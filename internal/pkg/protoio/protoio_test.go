@@ -0,0 +1,181 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package protoio
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bufdev/ibctl/internal/pkg/cryptobox"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestIterMessagesJSON(t *testing.T) {
+	t.Parallel()
+	filePath := writeTestMessages(t, t.TempDir(), 10)
+	var tradeIDs []string
+	for message, err := range IterMessagesJSON(filePath, newStruct) {
+		require.NoError(t, err)
+		tradeIDs = append(tradeIDs, message.GetFields()["trade_id"].GetStringValue())
+		// Breaking out of the loop stops reading the file.
+		if len(tradeIDs) == 3 {
+			break
+		}
+	}
+	require.Equal(t, []string{"1000000", "1000001", "1000002"}, tradeIDs)
+	messages, err := ReadMessagesJSON(filePath, newStruct)
+	require.NoError(t, err)
+	require.Len(t, messages, 10)
+	require.Equal(t, "1000009", messages[9].GetFields()["trade_id"].GetStringValue())
+	// A missing file is reported as not existing.
+	_, err = ReadMessagesJSON(filepath.Join(t.TempDir(), "missing.json"), newStruct)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestDecodeMessagesJSON(t *testing.T) {
+	t.Parallel()
+	// Empty lines are skipped and a missing trailing newline is tolerated.
+	var count int
+	for message, err := range DecodeMessagesJSON(strings.NewReader("{\"a\":1}\n\n{\"a\":2}"), newStruct) {
+		require.NoError(t, err)
+		count++
+		require.Equal(t, float64(count), message.GetFields()["a"].GetNumberValue())
+	}
+	require.Equal(t, 2, count)
+	// Invalid JSON yields an error and stops iteration.
+	var errs []error
+	for _, err := range DecodeMessagesJSON(strings.NewReader("{\"a\":1}\nnot json\n{\"a\":3}\n"), newStruct) {
+		errs = append(errs, err)
+	}
+	require.Len(t, errs, 2)
+	require.NoError(t, errs[0])
+	require.Error(t, errs[1])
+}
+
+func TestEncryption(t *testing.T) {
+	// Not parallel: encryption is process-wide.
+	encodedKey, err := cryptobox.NewKey()
+	require.NoError(t, err)
+	key, err := cryptobox.ParseKey(encodedKey)
+	require.NoError(t, err)
+	SetEncryption(key, true)
+	t.Cleanup(func() { SetEncryption(nil, false) })
+	filePath := writeTestMessages(t, t.TempDir(), 3)
+	raw, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	require.True(t, cryptobox.IsSealed(raw))
+	messages, err := ReadMessagesJSON(filePath, newStruct)
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	var count int
+	for _, err := range IterMessagesJSON(filePath, newStruct) {
+		require.NoError(t, err)
+		count++
+	}
+	require.Equal(t, 3, count)
+	// Without a key, sealed files cannot be read.
+	SetEncryption(nil, false)
+	_, err = ReadMessagesJSON(filePath, newStruct)
+	require.Error(t, err)
+}
+
+func TestWriteMessagesJSONStable(t *testing.T) {
+	t.Parallel()
+	message, err := structpb.NewStruct(map[string]any{"symbol": "AAPL", "account_id": "rrsp", "quantity": float64(10)})
+	require.NoError(t, err)
+	filePath := filepath.Join(t.TempDir(), "messages.json")
+	require.NoError(t, WriteMessagesJSON(filePath, []*structpb.Struct{message, message}))
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	// Compact, with map keys sorted.
+	require.Equal(t, "{\"account_id\":\"rrsp\",\"quantity\":10,\"symbol\":\"AAPL\"}\n{\"account_id\":\"rrsp\",\"quantity\":10,\"symbol\":\"AAPL\"}\n", string(data))
+}
+
+func TestSkipUnchangedWrites(t *testing.T) {
+	// Not parallel: skipping unchanged writes is process-wide.
+	SetSkipUnchangedWrites(true)
+	t.Cleanup(func() { SetSkipUnchangedWrites(false) })
+	dirPath := t.TempDir()
+	filePath := writeTestMessages(t, dirPath, 3)
+	oldModTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(filePath, oldModTime, oldModTime))
+	// Rewriting the same messages leaves the file alone.
+	writeTestMessages(t, dirPath, 3)
+	fileInfo, err := os.Stat(filePath)
+	require.NoError(t, err)
+	require.True(t, fileInfo.ModTime().Equal(oldModTime))
+	// Different messages are written.
+	writeTestMessages(t, dirPath, 4)
+	fileInfo, err = os.Stat(filePath)
+	require.NoError(t, err)
+	require.False(t, fileInfo.ModTime().Equal(oldModTime))
+	messages, err := ReadMessagesJSON(filePath, newStruct)
+	require.NoError(t, err)
+	require.Len(t, messages, 4)
+}
+
+func BenchmarkReadMessagesJSON(b *testing.B) {
+	filePath := writeTestMessages(b, b.TempDir(), 10000)
+	b.ReportAllocs()
+	for b.Loop() {
+		messages, err := ReadMessagesJSON(filePath, newStruct)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(messages) != 10000 {
+			b.Fatalf("expected 10000 messages, got %d", len(messages))
+		}
+	}
+}
+
+func BenchmarkIterMessagesJSON(b *testing.B) {
+	filePath := writeTestMessages(b, b.TempDir(), 10000)
+	b.ReportAllocs()
+	for b.Loop() {
+		var count int
+		for _, err := range IterMessagesJSON(filePath, newStruct) {
+			if err != nil {
+				b.Fatal(err)
+			}
+			count++
+		}
+		if count != 10000 {
+			b.Fatalf("expected 10000 messages, got %d", count)
+		}
+	}
+}
+
+// newStruct returns a new empty Struct for decoding.
+func newStruct() *structpb.Struct {
+	return &structpb.Struct{}
+}
+
+// writeTestMessages writes count trade-like messages to a file in dirPath and returns its path.
+func writeTestMessages(tb testing.TB, dirPath string, count int) string {
+	tb.Helper()
+	messages := make([]*structpb.Struct, 0, count)
+	for i := range count {
+		message, err := structpb.NewStruct(map[string]any{
+			"trade_id":      fmt.Sprintf("%d", 1000000+i),
+			"account_id":    "rrsp",
+			"symbol":        "AAPL",
+			"side":          "TRADE_SIDE_BUY",
+			"quantity":      map[string]any{"units": float64(i)},
+			"trade_price":   map[string]any{"currency_code": "USD", "units": "150", "micros": "500000"},
+			"currency_code": "USD",
+		})
+		require.NoError(tb, err)
+		messages = append(messages, message)
+	}
+	filePath := filepath.Join(dirPath, "messages.json")
+	require.NoError(tb, WriteMessagesJSON(filePath, messages))
+	return filePath
+}
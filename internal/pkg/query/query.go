@@ -0,0 +1,803 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package query evaluates a small pipeline expression language over records.
+//
+// A query is a source name followed by stages separated by "|":
+//
+//	lots | where sector == TECH and account_type == taxable | sum value_usd
+//	holdings | where market_value_usd > 10000 | sort market_value_usd desc | select symbol, market_value_usd
+//	trades | where date >= 2025-01-01 | group symbol | sum proceeds, count
+//
+// The source name is interpreted by the caller, which supplies the records. The stages are:
+//
+//	where <field> <op> <value> [and|or ...]  keep records matching the conditions ("and" binds tighter than "or")
+//	select <field>, ...                      keep only the fields, in order
+//	sort <field> [asc|desc]                  sort records by the field
+//	limit <n>                                keep the first n records
+//	group <field>                            group records by the field for the following aggregation stage
+//	<agg> [<field>], ...                     aggregate with sum, avg, min, max, or count into a single record, or one per group
+//
+// Operators are ==, !=, <, <=, >, >=, and ~ (case-insensitive substring). Values
+// that contain spaces or operator characters can be quoted with single or
+// double quotes. Comparisons are numeric if both sides are decimal numbers,
+// and otherwise compare strings, case-insensitively for == and !=. Missing
+// fields compare as the empty string. Sums and averages are computed exactly
+// in micros (six decimal places).
+package query
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+)
+
+// Record is a flat record of named string fields that preserves field order.
+type Record struct {
+	names  []string
+	values map[string]string
+}
+
+// NewRecord returns a new empty Record.
+func NewRecord() *Record {
+	return &Record{values: make(map[string]string)}
+}
+
+// NewRecordFromJSON returns a new Record from a JSON object, preserving field
+// order. Strings, numbers, and booleans become their text; null becomes the
+// empty string; nested objects and arrays are kept as compact JSON text.
+func NewRecordFromJSON(data []byte) (*Record, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return nil, errors.New("record must be a JSON object")
+	}
+	record := NewRecord()
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		name, ok := token.(string)
+		if !ok {
+			return nil, errors.New("record field name must be a string")
+		}
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, err
+		}
+		value, err := rawToString(raw)
+		if err != nil {
+			return nil, err
+		}
+		record.Set(name, value)
+	}
+	return record, nil
+}
+
+// NewRecordFromStruct returns a new Record from a struct or pointer to a
+// struct via its JSON encoding. Fields are ordered by their JSON tags, and
+// fields omitted by "omitempty" are included as the empty string, so every
+// record of the same type has the same fields.
+func NewRecordFromStruct(value any) (*Record, error) {
+	structType := reflect.TypeOf(value)
+	if structType != nil && structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+	if structType == nil || structType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("record value must be a struct, got %T", value)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := NewRecordFromJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	record := NewRecord()
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+		fieldValue, _ := decoded.Get(name)
+		record.Set(name, fieldValue)
+	}
+	return record, nil
+}
+
+// Get returns the value of the field, and false if the record has no such field.
+func (r *Record) Get(name string) (string, bool) {
+	value, ok := r.values[name]
+	return value, ok
+}
+
+// Set sets the value of the field, appending the field if it is new.
+func (r *Record) Set(name string, value string) {
+	if _, ok := r.values[name]; !ok {
+		r.names = append(r.names, name)
+	}
+	r.values[name] = value
+}
+
+// Names returns the field names in order.
+func (r *Record) Names() []string {
+	return r.names
+}
+
+// Values returns the field values in the order of Names.
+func (r *Record) Values() []string {
+	values := make([]string, len(r.names))
+	for i, name := range r.names {
+		values[i] = r.values[name]
+	}
+	return values
+}
+
+// MarshalJSON implements json.Marshaler, writing the fields in order.
+func (r *Record) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	for i, name := range r.names {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		nameData, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		valueData, err := json.Marshal(r.values[name])
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(nameData)
+		buffer.WriteByte(':')
+		buffer.Write(valueData)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
+
+// Query is a parsed query.
+type Query struct {
+	source string
+	stages []stage
+}
+
+// Parse parses a query expression.
+func Parse(expr string) (*Query, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	var stageTokens [][]token
+	var current []token
+	for _, t := range tokens {
+		if t.kind == tokenKindSymbol && t.text == "|" {
+			stageTokens = append(stageTokens, current)
+			current = nil
+			continue
+		}
+		current = append(current, t)
+	}
+	stageTokens = append(stageTokens, current)
+	if len(stageTokens[0]) != 1 || stageTokens[0][0].kind != tokenKindWord {
+		return nil, errors.New("query must start with a source name")
+	}
+	query := &Query{source: stageTokens[0][0].text}
+	for i, tokens := range stageTokens[1:] {
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("stage %d is empty", i+1)
+		}
+		stage, err := parseStage(tokens)
+		if err != nil {
+			return nil, fmt.Errorf("stage %d: %w", i+1, err)
+		}
+		query.stages = append(query.stages, stage)
+	}
+	// A group stage must be followed by an aggregation.
+	for i, s := range query.stages {
+		if _, ok := s.(*groupStage); ok {
+			if i+1 >= len(query.stages) {
+				return nil, errors.New("group must be followed by an aggregation")
+			}
+			if _, ok := query.stages[i+1].(*aggregateStage); !ok {
+				return nil, errors.New("group must be followed by an aggregation")
+			}
+		}
+	}
+	return query, nil
+}
+
+// Source returns the source name of the query.
+func (q *Query) Source() string {
+	return q.source
+}
+
+// Eval evaluates the query stages over the records of the source.
+func (q *Query) Eval(records []*Record) ([]*Record, error) {
+	knownNames := make(map[string]struct{})
+	for _, record := range records {
+		for _, name := range record.Names() {
+			knownNames[name] = struct{}{}
+		}
+	}
+	var groupBy string
+	for _, s := range q.stages {
+		// Fields are validated against the names known at each stage, so
+		// typos fail instead of silently matching nothing.
+		if len(records) > 0 {
+			for _, name := range s.fieldNames() {
+				if _, ok := knownNames[name]; !ok {
+					return nil, fmt.Errorf("unknown field %q", name)
+				}
+			}
+		}
+		if g, ok := s.(*groupStage); ok {
+			groupBy = g.field
+			continue
+		}
+		var err error
+		if a, ok := s.(*aggregateStage); ok {
+			records, err = a.aggregate(records, groupBy)
+			groupBy = ""
+		} else {
+			records, err = s.apply(records)
+		}
+		if err != nil {
+			return nil, err
+		}
+		knownNames = make(map[string]struct{})
+		for _, record := range records {
+			for _, name := range record.Names() {
+				knownNames[name] = struct{}{}
+			}
+		}
+	}
+	return records, nil
+}
+
+type stage interface {
+	// fieldNames returns the field names the stage reads.
+	fieldNames() []string
+	// apply applies the stage to the records.
+	apply(records []*Record) ([]*Record, error)
+}
+
+func parseStage(tokens []token) (stage, error) {
+	keyword := strings.ToLower(tokens[0].text)
+	args := tokens[1:]
+	if tokens[0].kind != tokenKindWord {
+		return nil, fmt.Errorf("unexpected %q", tokens[0].text)
+	}
+	switch keyword {
+	case "where":
+		return parseWhereStage(args)
+	case "select":
+		fields, err := parseFieldList(args)
+		if err != nil {
+			return nil, err
+		}
+		return &selectStage{fields: fields}, nil
+	case "sort":
+		if len(args) < 1 || len(args) > 2 || args[0].kind != tokenKindWord {
+			return nil, errors.New("sort takes a field and an optional asc or desc")
+		}
+		s := &sortStage{field: args[0].text}
+		if len(args) == 2 {
+			switch strings.ToLower(args[1].text) {
+			case "asc":
+			case "desc":
+				s.desc = true
+			default:
+				return nil, fmt.Errorf("sort order must be asc or desc, got %q", args[1].text)
+			}
+		}
+		return s, nil
+	case "limit":
+		if len(args) != 1 {
+			return nil, errors.New("limit takes a number")
+		}
+		n, err := strconv.Atoi(args[0].text)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("limit must be a non-negative integer, got %q", args[0].text)
+		}
+		return &limitStage{n: n}, nil
+	case "group":
+		if len(args) != 1 || args[0].kind != tokenKindWord {
+			return nil, errors.New("group takes a field")
+		}
+		return &groupStage{field: args[0].text}, nil
+	case aggregateSum, aggregateAvg, aggregateMin, aggregateMax, aggregateCount:
+		return parseAggregateStage(tokens)
+	default:
+		return nil, fmt.Errorf("unknown stage %q, must be one of: where, select, sort, limit, group, sum, avg, min, max, count", tokens[0].text)
+	}
+}
+
+// parseFieldList parses a comma-separated list of field names.
+func parseFieldList(tokens []token) ([]string, error) {
+	var fields []string
+	for i, t := range tokens {
+		if i%2 == 1 {
+			if t.kind != tokenKindSymbol || t.text != "," {
+				return nil, fmt.Errorf("expected \",\" but got %q", t.text)
+			}
+			continue
+		}
+		if t.kind != tokenKindWord {
+			return nil, fmt.Errorf("expected a field name but got %q", t.text)
+		}
+		fields = append(fields, t.text)
+	}
+	if len(fields) == 0 || len(tokens)%2 == 0 {
+		return nil, errors.New("expected a comma-separated list of fields")
+	}
+	return fields, nil
+}
+
+// Comparison operators.
+const (
+	opEqual        = "=="
+	opNotEqual     = "!="
+	opLess         = "<"
+	opLessEqual    = "<="
+	opGreater      = ">"
+	opGreaterEqual = ">="
+	opContains     = "~"
+)
+
+type condition struct {
+	field string
+	op    string
+	value string
+}
+
+func (c condition) matches(record *Record) bool {
+	value, _ := record.Get(c.field)
+	if c.op == opContains {
+		return strings.Contains(strings.ToLower(value), strings.ToLower(c.value))
+	}
+	comparison := compareValues(value, c.value)
+	switch c.op {
+	case opEqual:
+		return comparison == 0
+	case opNotEqual:
+		return comparison != 0
+	case opLess:
+		return comparison < 0
+	case opLessEqual:
+		return comparison <= 0
+	case opGreater:
+		return comparison > 0
+	case opGreaterEqual:
+		return comparison >= 0
+	default:
+		return false
+	}
+}
+
+// whereStage keeps records matching any of the groups of conditions, where
+// all conditions of a group must match.
+type whereStage struct {
+	orGroups [][]condition
+}
+
+func parseWhereStage(tokens []token) (*whereStage, error) {
+	s := &whereStage{}
+	var andGroup []condition
+	for len(tokens) > 0 {
+		if len(tokens) < 3 {
+			return nil, errors.New("where condition must be <field> <op> <value>")
+		}
+		if tokens[0].kind != tokenKindWord {
+			return nil, fmt.Errorf("expected a field name but got %q", tokens[0].text)
+		}
+		if tokens[1].kind != tokenKindSymbol || !isComparisonOp(tokens[1].text) {
+			return nil, fmt.Errorf("expected an operator (==, !=, <, <=, >, >=, ~) but got %q", tokens[1].text)
+		}
+		if tokens[2].kind == tokenKindSymbol {
+			return nil, fmt.Errorf("expected a value but got %q", tokens[2].text)
+		}
+		andGroup = append(andGroup, condition{field: tokens[0].text, op: tokens[1].text, value: tokens[2].text})
+		tokens = tokens[3:]
+		if len(tokens) == 0 {
+			break
+		}
+		switch strings.ToLower(tokens[0].text) {
+		case "and":
+		case "or":
+			s.orGroups = append(s.orGroups, andGroup)
+			andGroup = nil
+		default:
+			return nil, fmt.Errorf("expected and or or but got %q", tokens[0].text)
+		}
+		tokens = tokens[1:]
+		if len(tokens) == 0 {
+			return nil, errors.New("where ends with a dangling and or or")
+		}
+	}
+	if len(andGroup) == 0 {
+		return nil, errors.New("where requires a condition")
+	}
+	s.orGroups = append(s.orGroups, andGroup)
+	return s, nil
+}
+
+func (s *whereStage) fieldNames() []string {
+	var names []string
+	for _, andGroup := range s.orGroups {
+		for _, c := range andGroup {
+			names = append(names, c.field)
+		}
+	}
+	return names
+}
+
+func (s *whereStage) apply(records []*Record) ([]*Record, error) {
+	var result []*Record
+	for _, record := range records {
+		for _, andGroup := range s.orGroups {
+			matches := true
+			for _, c := range andGroup {
+				if !c.matches(record) {
+					matches = false
+					break
+				}
+			}
+			if matches {
+				result = append(result, record)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+type selectStage struct {
+	fields []string
+}
+
+func (s *selectStage) fieldNames() []string {
+	return s.fields
+}
+
+func (s *selectStage) apply(records []*Record) ([]*Record, error) {
+	result := make([]*Record, 0, len(records))
+	for _, record := range records {
+		selected := NewRecord()
+		for _, field := range s.fields {
+			value, _ := record.Get(field)
+			selected.Set(field, value)
+		}
+		result = append(result, selected)
+	}
+	return result, nil
+}
+
+type sortStage struct {
+	field string
+	desc  bool
+}
+
+func (s *sortStage) fieldNames() []string {
+	return []string{s.field}
+}
+
+func (s *sortStage) apply(records []*Record) ([]*Record, error) {
+	result := make([]*Record, len(records))
+	copy(result, records)
+	sort.SliceStable(result, func(i, j int) bool {
+		valueI, _ := result[i].Get(s.field)
+		valueJ, _ := result[j].Get(s.field)
+		if s.desc {
+			return compareValues(valueI, valueJ) > 0
+		}
+		return compareValues(valueI, valueJ) < 0
+	})
+	return result, nil
+}
+
+type limitStage struct {
+	n int
+}
+
+func (s *limitStage) fieldNames() []string {
+	return nil
+}
+
+func (s *limitStage) apply(records []*Record) ([]*Record, error) {
+	if len(records) > s.n {
+		return records[:s.n], nil
+	}
+	return records, nil
+}
+
+// groupStage sets the grouping field for the following aggregateStage, and is
+// not applied on its own.
+type groupStage struct {
+	field string
+}
+
+func (s *groupStage) fieldNames() []string {
+	return []string{s.field}
+}
+
+func (s *groupStage) apply(records []*Record) ([]*Record, error) {
+	return nil, errors.New("group must be followed by an aggregation")
+}
+
+// Aggregation functions.
+const (
+	aggregateSum   = "sum"
+	aggregateAvg   = "avg"
+	aggregateMin   = "min"
+	aggregateMax   = "max"
+	aggregateCount = "count"
+)
+
+type aggregation struct {
+	function string
+	field    string
+}
+
+// name returns the name of the result field (e.g., "sum_value_usd" or "count").
+func (a aggregation) name() string {
+	if a.function == aggregateCount {
+		return aggregateCount
+	}
+	return a.function + "_" + a.field
+}
+
+func (a aggregation) apply(records []*Record) string {
+	if a.function == aggregateCount {
+		return strconv.Itoa(len(records))
+	}
+	var totalMicros int64
+	var count int
+	var extreme string
+	for _, record := range records {
+		value, _ := record.Get(a.field)
+		if value == "" {
+			continue
+		}
+		count++
+		totalMicros += mathpb.ParseMicros(value)
+		if count == 1 ||
+			(a.function == aggregateMin && compareValues(value, extreme) < 0) ||
+			(a.function == aggregateMax && compareValues(value, extreme) > 0) {
+			extreme = value
+		}
+	}
+	switch a.function {
+	case aggregateSum:
+		return mathpb.ToString(mathpb.FromMicros(totalMicros))
+	case aggregateAvg:
+		if count == 0 {
+			return ""
+		}
+		return mathpb.ToString(mathpb.FromMicros(totalMicros / int64(count)))
+	default:
+		return extreme
+	}
+}
+
+type aggregateStage struct {
+	aggregations []aggregation
+}
+
+func parseAggregateStage(tokens []token) (*aggregateStage, error) {
+	s := &aggregateStage{}
+	for len(tokens) > 0 {
+		function := strings.ToLower(tokens[0].text)
+		switch function {
+		case aggregateCount:
+			s.aggregations = append(s.aggregations, aggregation{function: function})
+			tokens = tokens[1:]
+		case aggregateSum, aggregateAvg, aggregateMin, aggregateMax:
+			if len(tokens) < 2 || tokens[1].kind != tokenKindWord {
+				return nil, fmt.Errorf("%s takes a field", function)
+			}
+			s.aggregations = append(s.aggregations, aggregation{function: function, field: tokens[1].text})
+			tokens = tokens[2:]
+		default:
+			return nil, fmt.Errorf("unknown aggregation %q, must be one of: sum, avg, min, max, count", tokens[0].text)
+		}
+		if len(tokens) == 0 {
+			break
+		}
+		if tokens[0].kind != tokenKindSymbol || tokens[0].text != "," || len(tokens) == 1 {
+			return nil, fmt.Errorf("expected \",\" and another aggregation but got %q", tokens[0].text)
+		}
+		tokens = tokens[1:]
+	}
+	return s, nil
+}
+
+func (s *aggregateStage) fieldNames() []string {
+	var names []string
+	for _, a := range s.aggregations {
+		if a.field != "" {
+			names = append(names, a.field)
+		}
+	}
+	return names
+}
+
+func (s *aggregateStage) apply(records []*Record) ([]*Record, error) {
+	return s.aggregate(records, "")
+}
+
+// aggregate returns a single record with the aggregations over all records,
+// or if groupBy is set, one record per distinct value of the groupBy field
+// sorted by that value.
+func (s *aggregateStage) aggregate(records []*Record, groupBy string) ([]*Record, error) {
+	if groupBy == "" {
+		result := NewRecord()
+		for _, a := range s.aggregations {
+			result.Set(a.name(), a.apply(records))
+		}
+		return []*Record{result}, nil
+	}
+	groups := make(map[string][]*Record)
+	var keys []string
+	for _, record := range records {
+		key, _ := record.Get(groupBy)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], record)
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return compareValues(keys[i], keys[j]) < 0
+	})
+	result := make([]*Record, 0, len(keys))
+	for _, key := range keys {
+		groupRecord := NewRecord()
+		groupRecord.Set(groupBy, key)
+		for _, a := range s.aggregations {
+			groupRecord.Set(a.name(), a.apply(groups[key]))
+		}
+		result = append(result, groupRecord)
+	}
+	return result, nil
+}
+
+// compareValues compares two values numerically if both are decimal numbers,
+// and otherwise as case-insensitive strings.
+func compareValues(a string, b string) int {
+	if isNumber(a) && isNumber(b) {
+		aMicros, bMicros := mathpb.ParseMicros(a), mathpb.ParseMicros(b)
+		switch {
+		case aMicros < bMicros:
+			return -1
+		case aMicros > bMicros:
+			return +1
+		default:
+			return 0
+		}
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// isNumber returns true if the value is a decimal number (e.g., "-12.5").
+func isNumber(value string) bool {
+	if value == "" {
+		return false
+	}
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil && !strings.ContainsAny(value, "eExXnN")
+}
+
+func isComparisonOp(text string) bool {
+	switch text {
+	case opEqual, opNotEqual, opLess, opLessEqual, opGreater, opGreaterEqual, opContains:
+		return true
+	default:
+		return false
+	}
+}
+
+type tokenKind int
+
+const (
+	// tokenKindWord is a bare word, such as a field name or unquoted value.
+	tokenKindWord tokenKind = iota + 1
+	// tokenKindString is a quoted string.
+	tokenKindString
+	// tokenKindSymbol is "|", ",", or a comparison operator.
+	tokenKindSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// tokenize splits an expression into words, quoted strings, and symbols.
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '\'' || r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated string starting at position %d", i+1)
+			}
+			tokens = append(tokens, token{kind: tokenKindString, text: string(runes[i+1 : end])})
+			i = end + 1
+		case r == '|' || r == ',' || r == '~':
+			tokens = append(tokens, token{kind: tokenKindSymbol, text: string(r)})
+			i++
+		case r == '=' || r == '!' || r == '<' || r == '>':
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				tokens = append(tokens, token{kind: tokenKindSymbol, text: string(runes[i : i+2])})
+				i += 2
+				continue
+			}
+			if r == '=' || r == '!' {
+				return nil, fmt.Errorf("unexpected %q at position %d, did you mean %q", r, i+1, string(r)+"=")
+			}
+			tokens = append(tokens, token{kind: tokenKindSymbol, text: string(r)})
+			i++
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune("|,~=!<>'\"", runes[end]) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenKindWord, text: string(runes[i:end])})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+// rawToString converts a raw JSON value to its record string.
+func rawToString(raw json.RawMessage) (string, error) {
+	trimmed := bytes.TrimSpace(raw)
+	switch {
+	case bytes.Equal(trimmed, []byte("null")):
+		return "", nil
+	case len(trimmed) > 0 && trimmed[0] == '"':
+		var s string
+		if err := json.Unmarshal(trimmed, &s); err != nil {
+			return "", err
+		}
+		return s, nil
+	case len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '['):
+		var buffer bytes.Buffer
+		if err := json.Compact(&buffer, trimmed); err != nil {
+			return "", err
+		}
+		return buffer.String(), nil
+	default:
+		return string(trimmed), nil
+	}
+}
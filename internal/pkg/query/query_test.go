@@ -0,0 +1,115 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEval(t *testing.T) {
+	t.Parallel()
+	records := newTestRecords(
+		t,
+		`{"symbol":"AAPL","account":"brokerage","sector":"TECH","value_usd":"1000.50"}`,
+		`{"symbol":"MSFT","account":"rrsp","sector":"TECH","value_usd":"2000"}`,
+		`{"symbol":"XOM","account":"brokerage","sector":"ENERGY","value_usd":"300.25"}`,
+		`{"symbol":"NVDA","account":"brokerage","sector":"TECH","value_usd":"99"}`,
+	)
+	testEval(t, records, "lots | where sector == tech and account == brokerage | sum value_usd", [][]string{{"1099.5"}})
+	testEval(t, records, "lots | where symbol == XOM or value_usd >= 2000 | select symbol", [][]string{{"MSFT"}, {"XOM"}})
+	// Numeric comparison, not string comparison, since "99" > "300.25" as strings.
+	testEval(t, records, "lots | where value_usd < 300.25 | select symbol", [][]string{{"NVDA"}})
+	testEval(t, records, "lots | sort value_usd desc | limit 2 | select symbol, value_usd", [][]string{{"MSFT", "2000"}, {"AAPL", "1000.50"}})
+	testEval(t, records, "lots | where symbol ~ 'a' | count", [][]string{{"2"}})
+	testEval(
+		t,
+		records,
+		"lots | group sector | sum value_usd, count, max value_usd",
+		[][]string{{"ENERGY", "300.25", "1", "300.25"}, {"TECH", "3099.5", "3", "2000"}},
+	)
+	testEval(t, records, "lots | avg value_usd, min value_usd", [][]string{{"849.9375", "99"}})
+}
+
+func TestEvalUnknownField(t *testing.T) {
+	t.Parallel()
+	records := newTestRecords(t, `{"symbol":"AAPL"}`)
+	query, err := Parse("lots | where sector == TECH")
+	require.NoError(t, err)
+	_, err = query.Eval(records)
+	require.ErrorContains(t, err, `unknown field "sector"`)
+	// Fields removed by select are no longer known.
+	query, err = Parse("lots | select symbol | sum value_usd")
+	require.NoError(t, err)
+	_, err = query.Eval(records)
+	require.ErrorContains(t, err, `unknown field "value_usd"`)
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+	for _, expr := range []string{
+		"",
+		"lots |",
+		"lots | where",
+		"lots | where sector = TECH",
+		"lots | where sector == TECH and",
+		"lots | where sector == 'TECH",
+		"lots | limit -1",
+		"lots | sort symbol sideways",
+		"lots | group sector",
+		"lots | group sector | select symbol",
+		"lots | sum",
+		"lots | frobnicate",
+	} {
+		_, err := Parse(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestNewRecordFromStruct(t *testing.T) {
+	t.Parallel()
+	type testStruct struct {
+		Symbol   string            `json:"symbol"`
+		Sector   string            `json:"sector,omitempty"`
+		Quantity int               `json:"quantity"`
+		Tags     map[string]string `json:"tags"`
+		Ignored  string            `json:"-"`
+	}
+	record, err := NewRecordFromStruct(&testStruct{Symbol: "AAPL", Quantity: 10, Tags: map[string]string{"a": "b"}, Ignored: "x"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"symbol", "sector", "quantity", "tags"}, record.Names())
+	require.Equal(t, []string{"AAPL", "", "10", `{"a":"b"}`}, record.Values())
+	data, err := record.MarshalJSON()
+	require.NoError(t, err)
+	require.Equal(t, `{"symbol":"AAPL","sector":"","quantity":"10","tags":"{\"a\":\"b\"}"}`, string(data))
+	_, err = NewRecordFromStruct("AAPL")
+	require.Error(t, err)
+}
+
+func testEval(t *testing.T, records []*Record, expr string, expected [][]string) {
+	t.Helper()
+	query, err := Parse(expr)
+	require.NoError(t, err, expr)
+	require.Equal(t, "lots", query.Source())
+	result, err := query.Eval(records)
+	require.NoError(t, err, expr)
+	actual := make([][]string, 0, len(result))
+	for _, record := range result {
+		actual = append(actual, record.Values())
+	}
+	require.Equal(t, expected, actual, expr)
+}
+
+func newTestRecords(t *testing.T, objects ...string) []*Record {
+	t.Helper()
+	records := make([]*Record, 0, len(objects))
+	for _, object := range objects {
+		record, err := NewRecordFromJSON([]byte(object))
+		require.NoError(t, err)
+		records = append(records, record)
+	}
+	return records
+}
@@ -0,0 +1,259 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package s3 provides a minimal client for S3-compatible object storage.
+//
+// Requests are signed with AWS Signature Version 4 and use path-style URLs
+// (<endpoint>/<bucket>/<key>). Only the operations needed for backups are
+// implemented: put, list, and delete.
+//
+// Google Cloud Storage is supported through its S3-compatible XML API with
+// HMAC keys, using the endpoint https://storage.googleapis.com and region "auto".
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Client is the interface for S3-compatible object storage.
+type Client interface {
+	// PutObject uploads the data to the bucket under the key.
+	PutObject(ctx context.Context, bucket string, key string, data []byte) error
+	// ListObjects returns all object keys in the bucket with the prefix, sorted.
+	ListObjects(ctx context.Context, bucket string, prefix string) ([]string, error)
+	// DeleteObject deletes the object with the key from the bucket.
+	DeleteObject(ctx context.Context, bucket string, key string) error
+}
+
+// Credentials are the access keys used to sign requests.
+type Credentials struct {
+	// AccessKeyID is the access key ID.
+	AccessKeyID string
+	// SecretAccessKey is the secret access key.
+	SecretAccessKey string
+	// SessionToken is the optional session token for temporary credentials.
+	SessionToken string
+}
+
+// NewClient creates a new client for the endpoint (e.g., "https://s3.us-east-1.amazonaws.com")
+// and signing region (e.g., "us-east-1").
+func NewClient(httpClient *http.Client, endpoint string, region string, credentials Credentials) Client {
+	return &client{
+		httpClient:  httpClient,
+		endpoint:    strings.TrimSuffix(endpoint, "/"),
+		region:      region,
+		credentials: credentials,
+		now:         time.Now,
+	}
+}
+
+type client struct {
+	httpClient  *http.Client
+	endpoint    string
+	region      string
+	credentials Credentials
+	now         func() time.Time
+}
+
+func (c *client) PutObject(ctx context.Context, bucket string, key string, data []byte) error {
+	_, err := c.do(ctx, http.MethodPut, bucket, key, nil, data)
+	return err
+}
+
+func (c *client) ListObjects(ctx context.Context, bucket string, prefix string) ([]string, error) {
+	var keys []string
+	marker := ""
+	// Page through results with ListObjects (v1), which S3 and GCS both support.
+	for {
+		query := url.Values{"prefix": {prefix}}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		body, err := c.do(ctx, http.MethodGet, bucket, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		var result listBucketResult
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("parsing list response: %w", err)
+		}
+		for _, contents := range result.Contents {
+			keys = append(keys, contents.Key)
+		}
+		if !result.IsTruncated || len(result.Contents) == 0 {
+			break
+		}
+		marker = result.Contents[len(result.Contents)-1].Key
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (c *client) DeleteObject(ctx context.Context, bucket string, key string) error {
+	_, err := c.do(ctx, http.MethodDelete, bucket, key, nil, nil)
+	return err
+}
+
+// *** PRIVATE ***
+
+// listBucketResult is the XML response from ListObjects.
+type listBucketResult struct {
+	IsTruncated bool `xml:"IsTruncated"`
+	Contents    []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+}
+
+// do sends a signed request and returns the response body.
+// Any non-2xx status is returned as an error including the response body.
+func (c *client) do(ctx context.Context, method string, bucket string, key string, query url.Values, payload []byte) ([]byte, error) {
+	canonicalURI := "/" + uriEncode(bucket, false)
+	if key != "" {
+		canonicalURI += "/" + uriEncode(key, false)
+	}
+	canonicalQuery := canonicalQueryString(query)
+	reqURL := c.endpoint + canonicalURI
+	if canonicalQuery != "" {
+		reqURL += "?" + canonicalQuery
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(payload))
+	c.sign(req, canonicalURI, canonicalQuery, payload)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s %s: unexpected status %d: %s", method, canonicalURI, resp.StatusCode, string(body))
+	}
+	return body, nil
+}
+
+// sign adds AWS Signature Version 4 headers to the request.
+func (c *client) sign(req *http.Request, canonicalURI string, canonicalQuery string, payload []byte) {
+	now := c.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	if c.credentials.SessionToken != "" {
+		headers["x-amz-security-token"] = c.credentials.SessionToken
+	}
+	headerNames := make([]string, 0, len(headers))
+	for name := range headers {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+	var canonicalHeaders strings.Builder
+	for _, name := range headerNames {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+		if name != "host" {
+			req.Header.Set(name, headers[name])
+		}
+	}
+	signedHeaders := strings.Join(headerNames, ";")
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		canonicalQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := dateStamp + "/" + c.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+	signingKey := hmacSHA256([]byte("AWS4"+c.credentials.SecretAccessKey), dateStamp)
+	signingKey = hmacSHA256(signingKey, c.region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+	req.Header.Set(
+		"Authorization",
+		fmt.Sprintf(
+			"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+			c.credentials.AccessKeyID, scope, signedHeaders, signature,
+		),
+	)
+}
+
+// canonicalQueryString returns the query parameters sorted and URI-encoded per SigV4.
+func canonicalQueryString(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var pairs []string
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes everything except RFC 3986 unreserved characters.
+// Slashes are preserved unless encodeSlash is true.
+func uriEncode(s string, encodeSlash bool) string {
+	var builder strings.Builder
+	for _, b := range []byte(s) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9',
+			b == '-', b == '.', b == '_', b == '~':
+			builder.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			builder.WriteByte(b)
+		default:
+			fmt.Fprintf(&builder, "%%%02X", b)
+		}
+	}
+	return builder.String()
+}
+
+// sha256Hex returns the hex-encoded SHA-256 hash of the data.
+func sha256Hex(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of the data with the key.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
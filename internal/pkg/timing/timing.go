@@ -0,0 +1,92 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package timing records the time spent in named phases of a process.
+//
+// Recording is off until Enable is called, so instrumented code pays only for
+// an atomic load when timing is not requested.
+package timing
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	enabled atomic.Bool
+
+	mu sync.Mutex
+	// phases are the recorded phases in the order they were first started.
+	phases []*Phase
+	// phaseIndex maps phase names to their index in phases.
+	phaseIndex = make(map[string]int)
+	// depths maps phase names to the number of calls of the phase in progress.
+	depths = make(map[string]int)
+	// starts maps phase names to the start time of the outermost call in progress.
+	starts = make(map[string]time.Time)
+)
+
+// Phase is the time spent in a named phase.
+type Phase struct {
+	// Name is the name of the phase (e.g., "merge").
+	Name string
+	// Calls is the number of times the phase was started.
+	Calls int
+	// Duration is the total wall time spent in the phase.
+	Duration time.Duration
+}
+
+// Enable starts recording phases.
+func Enable() {
+	enabled.Store(true)
+}
+
+// Start starts timing the named phase and returns a function that stops it.
+// It is meant to be used as:
+//
+//	defer timing.Start("merge")()
+//
+// Nested calls of the same phase, such as a writer that calls another
+// writer, count as calls but only the outermost call counts towards the
+// duration. Different phases can overlap, so their durations can add up to
+// more than the total time.
+func Start(name string) func() {
+	if !enabled.Load() {
+		return func() {}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	index, ok := phaseIndex[name]
+	if !ok {
+		index = len(phases)
+		phaseIndex[name] = index
+		phases = append(phases, &Phase{Name: name})
+	}
+	phases[index].Calls++
+	if depths[name] == 0 {
+		starts[name] = time.Now()
+	}
+	depths[name]++
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		depths[name]--
+		if depths[name] == 0 {
+			phases[index].Duration += time.Since(starts[name])
+		}
+	}
+}
+
+// Phases returns a copy of the recorded phases in the order they were first started.
+func Phases() []*Phase {
+	mu.Lock()
+	defer mu.Unlock()
+	result := make([]*Phase, 0, len(phases))
+	for _, phase := range phases {
+		phaseCopy := *phase
+		result = append(result, &phaseCopy)
+	}
+	return result
+}
@@ -0,0 +1,37 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package timing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStart(t *testing.T) {
+	// Not parallel: phases are recorded in package state.
+	stop := Start("disabled")
+	stop()
+	require.Empty(t, Phases())
+
+	Enable()
+	stopOuter := Start("render")
+	stopInner := Start("render")
+	time.Sleep(time.Millisecond)
+	stopInner()
+	stopMerge := Start("merge")
+	stopMerge()
+	stopOuter()
+
+	phases := Phases()
+	require.Len(t, phases, 2)
+	require.Equal(t, "render", phases[0].Name)
+	require.Equal(t, 2, phases[0].Calls)
+	require.GreaterOrEqual(t, phases[0].Duration, time.Millisecond)
+	require.Equal(t, "merge", phases[1].Name)
+	require.Equal(t, 1, phases[1].Calls)
+	require.Less(t, phases[1].Duration, phases[0].Duration)
+}
@@ -0,0 +1,233 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package yahoofinance provides a client for fetching stock and fund prices
+// from the Yahoo Finance chart API.
+//
+// The chart API is free and does not require an API key or authentication,
+// but is unofficial and rate limited. Symbols are Yahoo symbols, so non-US
+// listings need their exchange suffix (e.g., SHOP.TO for the TSX listing).
+package yahoofinance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+)
+
+// DefaultBaseURL is the Yahoo Finance API base URL.
+const DefaultBaseURL = "https://query1.finance.yahoo.com"
+
+// userAgent is the User-Agent header of requests, since the API rejects
+// requests with the default Go User-Agent.
+const userAgent = "Mozilla/5.0 (compatible; ibctl)"
+
+// DailyClose is a single daily closing price returned by the API.
+type DailyClose struct {
+	// Date is the trading date in the exchange's time zone.
+	Date xtime.Date
+	// Close is the closing price as a decimal string.
+	Close string
+}
+
+// History is the daily closing prices of a symbol.
+type History struct {
+	// Currency is the currency code of the prices (e.g., "USD"). Prices of
+	// some exchanges are in minor units, such as "GBp" for pence on the LSE.
+	Currency string
+	// Closes is the daily closing prices sorted by date. Dates without a
+	// close (weekends, holidays) are omitted.
+	Closes []DailyClose
+}
+
+// Quote is the latest price of a symbol.
+type Quote struct {
+	// Currency is the currency code of the price, as in History.
+	Currency string
+	// Price is the latest price as a decimal string, delayed by up to the
+	// exchange's delay for free quotes.
+	Price string
+	// Time is the time of the price.
+	Time time.Time
+}
+
+// Client is the interface for fetching prices.
+type Client interface {
+	// GetHistory fetches the daily closing prices of the symbol between start
+	// and end inclusive.
+	GetHistory(ctx context.Context, symbol string, start xtime.Date, end xtime.Date) (*History, error)
+	// GetQuote fetches the latest price of the symbol.
+	GetQuote(ctx context.Context, symbol string) (*Quote, error)
+}
+
+// NewClient creates a new price client.
+func NewClient(httpClient *http.Client) Client {
+	return NewClientForBaseURL(httpClient, DefaultBaseURL)
+}
+
+// NewClientForBaseURL creates a new price client for an API at baseURL
+// instead of DefaultBaseURL, such as a fake server in tests.
+func NewClientForBaseURL(httpClient *http.Client, baseURL string) Client {
+	return &client{
+		httpClient: httpClient,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+type client struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+func (c *client) GetHistory(ctx context.Context, symbol string, start xtime.Date, end xtime.Date) (*History, error) {
+	// The range is in Unix seconds, from the start of the start date to the
+	// start of the day after the end date.
+	query := url.Values{
+		"interval": {"1d"},
+		"period1":  {strconv.FormatInt(start.In(time.UTC).Unix(), 10)},
+		"period2":  {strconv.FormatInt(end.AddDays(1).In(time.UTC).Unix(), 10)},
+	}
+	result, err := c.getChart(ctx, symbol, query)
+	if err != nil {
+		return nil, err
+	}
+	// Timestamps are the start of each trading day, so they are converted to
+	// dates in the exchange's time zone.
+	location := time.FixedZone(result.Meta.ExchangeTimezoneName, result.Meta.GMTOffset)
+	var closes []float64
+	if len(result.Indicators.Quote) > 0 {
+		closes = result.Indicators.Quote[0].Close
+	}
+	history := &History{
+		Currency: result.Meta.Currency,
+	}
+	for i, timestamp := range result.Timestamp {
+		// Closes are null for days without trading.
+		if i >= len(closes) || closes[i] == 0 {
+			continue
+		}
+		date := xtime.TimeToDate(time.Unix(timestamp, 0).In(location))
+		if date.Before(start) || date.After(end) {
+			continue
+		}
+		// The last timestamp may be the current trading day, which repeats
+		// the date of an earlier timestamp with the latest price.
+		if n := len(history.Closes); n > 0 && history.Closes[n-1].Date == date {
+			history.Closes[n-1].Close = formatPrice(closes[i])
+			continue
+		}
+		history.Closes = append(history.Closes, DailyClose{
+			Date:  date,
+			Close: formatPrice(closes[i]),
+		})
+	}
+	return history, nil
+}
+
+func (c *client) GetQuote(ctx context.Context, symbol string) (*Quote, error) {
+	result, err := c.getChart(ctx, symbol, url.Values{
+		"interval": {"1d"},
+		"range":    {"1d"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Meta.RegularMarketPrice == 0 {
+		return nil, fmt.Errorf("no price for %s", symbol)
+	}
+	return &Quote{
+		Currency: result.Meta.Currency,
+		Price:    formatPrice(result.Meta.RegularMarketPrice),
+		Time:     time.Unix(result.Meta.RegularMarketTime, 0),
+	}, nil
+}
+
+// *** PRIVATE ***
+
+// chartResponse is the JSON response from the chart API.
+type chartResponse struct {
+	Chart struct {
+		Result []*chartResult `json:"result"`
+		Error  *chartError    `json:"error"`
+	} `json:"chart"`
+}
+
+// chartResult is the chart of a single symbol.
+type chartResult struct {
+	Meta struct {
+		Currency             string  `json:"currency"`
+		ExchangeTimezoneName string  `json:"exchangeTimezoneName"`
+		GMTOffset            int     `json:"gmtoffset"`
+		RegularMarketPrice   float64 `json:"regularMarketPrice"`
+		RegularMarketTime    int64   `json:"regularMarketTime"`
+	} `json:"meta"`
+	Timestamp  []int64 `json:"timestamp"`
+	Indicators struct {
+		Quote []struct {
+			// Close is null for days without trading, which unmarshals to 0.
+			Close []float64 `json:"close"`
+		} `json:"quote"`
+	} `json:"indicators"`
+}
+
+// chartError is the error of a chart API response.
+type chartError struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+// getChart fetches the chart of the symbol with the query parameters.
+func (c *client) getChart(ctx context.Context, symbol string, query url.Values) (*chartResult, error) {
+	reqURL := c.baseURL + "/v8/finance/chart/" + url.PathEscape(symbol) + "?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	// Errors such as unknown symbols have a non-200 status and an error in
+	// the JSON body, which is more useful than the status.
+	var response chartResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+		}
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+	if chartErr := response.Chart.Error; chartErr != nil {
+		return nil, fmt.Errorf("%s: %s", chartErr.Code, chartErr.Description)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+	if len(response.Chart.Result) == 0 {
+		return nil, errors.New("no chart in response")
+	}
+	return response.Chart.Result[0], nil
+}
+
+// formatPrice formats a price with at most four decimal places, since the API
+// returns prices as floats with artifacts such as 220.91000366210938.
+func formatPrice(price float64) string {
+	s := strconv.FormatFloat(price, 'f', 4, 64)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
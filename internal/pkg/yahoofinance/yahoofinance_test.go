@@ -0,0 +1,107 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package yahoofinance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bufdev/ibctl/internal/standard/xtime"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetHistory(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	newYork := time.FixedZone("America/New_York", -4*60*60)
+	// Trading days start at 09:30 in New York, and the Monday has no close.
+	thursday := time.Date(2025, 9, 11, 9, 30, 0, 0, newYork).Unix()
+	friday := time.Date(2025, 9, 12, 9, 30, 0, 0, newYork).Unix()
+	monday := time.Date(2025, 9, 15, 9, 30, 0, 0, newYork).Unix()
+	server, requests := newTestServer(t, func(responseWriter http.ResponseWriter, _ *http.Request) {
+		_, _ = responseWriter.Write([]byte(`{"chart":{"result":[{` +
+			`"meta":{"currency":"USD","exchangeTimezoneName":"America/New_York","gmtoffset":-14400},` +
+			`"timestamp":[` + strconv.FormatInt(thursday, 10) + `,` + strconv.FormatInt(friday, 10) + `,` + strconv.FormatInt(monday, 10) + `],` +
+			`"indicators":{"quote":[{"close":[491.2300109863281,493.5,null]}]}` +
+			`}],"error":null}}`))
+	})
+	history, err := NewClientForBaseURL(http.DefaultClient, server.URL).GetHistory(
+		ctx,
+		"BRK-B",
+		xtime.Date{Year: 2025, Month: time.September, Day: 11},
+		xtime.Date{Year: 2025, Month: time.September, Day: 15},
+	)
+	require.NoError(t, err)
+	require.Len(t, *requests, 1)
+	request := (*requests)[0]
+	require.Equal(t, "/v8/finance/chart/BRK-B", request.URL.Path)
+	require.NotEmpty(t, request.Header.Get("User-Agent"))
+	require.Equal(t, "1d", request.URL.Query().Get("interval"))
+	require.Equal(t, strconv.FormatInt(time.Date(2025, 9, 11, 0, 0, 0, 0, time.UTC).Unix(), 10), request.URL.Query().Get("period1"))
+	require.Equal(t, strconv.FormatInt(time.Date(2025, 9, 16, 0, 0, 0, 0, time.UTC).Unix(), 10), request.URL.Query().Get("period2"))
+	require.Equal(
+		t,
+		&History{
+			Currency: "USD",
+			Closes: []DailyClose{
+				{Date: xtime.Date{Year: 2025, Month: time.September, Day: 11}, Close: "491.23"},
+				{Date: xtime.Date{Year: 2025, Month: time.September, Day: 12}, Close: "493.5"},
+			},
+		},
+		history,
+	)
+}
+
+func TestGetQuote(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	server, requests := newTestServer(t, func(responseWriter http.ResponseWriter, _ *http.Request) {
+		_, _ = responseWriter.Write([]byte(`{"chart":{"result":[{` +
+			`"meta":{"currency":"CAD","regularMarketPrice":212.05,"regularMarketTime":1757707200}` +
+			`}],"error":null}}`))
+	})
+	quote, err := NewClientForBaseURL(http.DefaultClient, server.URL).GetQuote(ctx, "SHOP.TO")
+	require.NoError(t, err)
+	require.Len(t, *requests, 1)
+	require.Equal(t, "/v8/finance/chart/SHOP.TO", (*requests)[0].URL.Path)
+	require.Equal(t, "1d", (*requests)[0].URL.Query().Get("range"))
+	require.Equal(t, "CAD", quote.Currency)
+	require.Equal(t, "212.05", quote.Price)
+	require.Equal(t, int64(1757707200), quote.Time.Unix())
+}
+
+func TestGetQuoteError(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	server, _ := newTestServer(t, func(responseWriter http.ResponseWriter, _ *http.Request) {
+		responseWriter.WriteHeader(http.StatusNotFound)
+		_, _ = responseWriter.Write([]byte(`{"chart":{"result":null,"error":{"code":"Not Found","description":"No data found, symbol may be delisted"}}}`))
+	})
+	_, err := NewClientForBaseURL(http.DefaultClient, server.URL).GetQuote(ctx, "XYZ")
+	require.EqualError(t, err, "Not Found: No data found, symbol may be delisted")
+}
+
+func TestFormatPrice(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "220.91", formatPrice(220.91000366210938))
+	require.Equal(t, "100", formatPrice(100))
+	require.Equal(t, "0.1235", formatPrice(0.12345678))
+}
+
+// newTestServer returns a fake chart API that responds with handlerFunc,
+// along with the requests it received.
+func newTestServer(t *testing.T, handlerFunc http.HandlerFunc) (*httptest.Server, *[]*http.Request) {
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		requests = append(requests, request)
+		handlerFunc(responseWriter, request)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
@@ -0,0 +1,220 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+// Package ibctl is the supported Go API for embedding ibctl computation in
+// other programs without shelling out to the CLI.
+//
+// A typical caller reads the configuration, merges the downloaded data, and
+// computes holdings:
+//
+//	config, err := ibctl.ReadConfig(dirPath)
+//	mergedData, err := ibctl.Merge(config)
+//	holdingsResult, err := ibctl.GetHoldingsOverview(mergedData, config, ibctl.NewFXStore(config))
+//
+// Downloading is left to the CLI ("ibctl download"); this package only reads
+// data already on disk. The types are aliases of the types used by the CLI,
+// so values can be passed between this package and the CLI's output formats
+// unchanged. Every type reachable from the exported types is exported here
+// too, along with constructors for the decimal, money, and date values of
+// trades. Anything not exported here is not part of the supported API and may
+// change without notice.
+package ibctl
+
+import (
+	"time"
+
+	datav1 "github.com/bufdev/ibctl/internal/gen/proto/go/ibctl/data/v1"
+	mathv1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/math/v1"
+	moneyv1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/money/v1"
+	timev1 "github.com/bufdev/ibctl/internal/gen/proto/go/standard/time/v1"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlconfig"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlfxrates"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlholdings"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmerge"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlmigrate"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctlpath"
+	"github.com/bufdev/ibctl/internal/ibctl/ibctltaxlot"
+	"github.com/bufdev/ibctl/internal/pkg/mathpb"
+	"github.com/bufdev/ibctl/internal/pkg/moneypb"
+	"github.com/bufdev/ibctl/internal/pkg/protoio"
+	"github.com/bufdev/ibctl/internal/pkg/timepb"
+)
+
+// Config is the validated ibctl.yaml configuration.
+type Config = ibctlconfig.Config
+
+// SymbolConfig is the configuration of a single symbol in Config.SymbolConfigs.
+type SymbolConfig = ibctlconfig.SymbolConfig
+
+// Cost basis methods of SymbolConfig.CostBasis.
+const (
+	// CostBasisFIFO assigns each lot the price it was bought at. This is the default.
+	CostBasisFIFO = ibctlconfig.CostBasisFIFO
+	// CostBasisAverage assigns every open lot the average cost of the position.
+	CostBasisAverage = ibctlconfig.CostBasisAverage
+)
+
+// MergedData contains all trades, positions, and cash data merged across
+// accounts and data sources.
+type MergedData = ibctlmerge.MergedData
+
+// TaxLotResult contains the FIFO tax lots, realized gains, and unmatched sells
+// computed from trades.
+type TaxLotResult = ibctltaxlot.TaxLotResult
+
+// RealizedGain records the gain or loss from closing all or part of a tax lot.
+type RealizedGain = ibctltaxlot.RealizedGain
+
+// UnmatchedSell records a sell that could not be fully matched against open lots.
+type UnmatchedSell = ibctltaxlot.UnmatchedSell
+
+// PositionDiscrepancy records a mismatch between a computed position and
+// the position reported by IBKR.
+type PositionDiscrepancy = ibctltaxlot.PositionDiscrepancy
+
+// HoldingsResult contains the holdings overview and any unmatched sells or
+// position discrepancies found while computing it.
+type HoldingsResult = ibctlholdings.HoldingsResult
+
+// HoldingOverview is a single holding in a HoldingsResult.
+type HoldingOverview = ibctlholdings.HoldingOverview
+
+// FXStore provides FX rate lookups from the downloaded FX rate files.
+type FXStore = ibctlfxrates.Store
+
+// FXRate is the exchange rate used to convert a holding to USD.
+type FXRate = ibctlfxrates.Rate
+
+// Trade is a single trade.
+type Trade = datav1.Trade
+
+// TradeSide is the side of a Trade.
+type TradeSide = datav1.TradeSide
+
+// Trade sides.
+const (
+	// TradeSideBuy is a buy, with a positive quantity.
+	TradeSideBuy = datav1.TradeSide_TRADE_SIDE_BUY
+	// TradeSideSell is a sell, with a negative quantity.
+	TradeSideSell = datav1.TradeSide_TRADE_SIDE_SELL
+)
+
+// Position is an IBKR-reported open position.
+type Position = datav1.Position
+
+// CashPosition is a cash balance in a single currency.
+type CashPosition = datav1.CashPosition
+
+// TaxLot is a single open FIFO tax lot.
+type TaxLot = datav1.TaxLot
+
+// Decimal is a decimal value, such as a quantity, as units and micros.
+type Decimal = mathv1.Decimal
+
+// Money is a decimal amount in a currency, as units and micros.
+type Money = moneyv1.Money
+
+// Date is a calendar date.
+type Date = timev1.Date
+
+// NewDecimal parses a decimal string such as "-12.5" into a Decimal with up
+// to 6 decimal places.
+func NewDecimal(value string) (*Decimal, error) {
+	return mathpb.NewDecimal(value)
+}
+
+// DecimalToString returns the decimal string of a Decimal, or "0" if it is nil.
+func DecimalToString(decimal *Decimal) string {
+	return mathpb.ToString(decimal)
+}
+
+// NewMoney parses a decimal string such as "150.25" into Money in the currency.
+func NewMoney(currencyCode string, value string) (*Money, error) {
+	return moneypb.NewProtoMoney(currencyCode, value)
+}
+
+// MoneyValueToString returns the decimal string of the amount of Money,
+// without its currency, or "0" if it is nil.
+func MoneyValueToString(money *Money) string {
+	return moneypb.MoneyValueToString(money)
+}
+
+// NewDate returns a new validated Date.
+func NewDate(year int, month time.Month, day int) (*Date, error) {
+	return timepb.NewProtoDate(year, month, day)
+}
+
+// ReadConfig reads and validates the ibctl.yaml configuration in dirPath.
+//
+// An error is returned if the data in dirPath needs to be migrated with
+// "ibctl data migrate" or was written by a newer version of ibctl.
+func ReadConfig(dirPath string) (*Config, error) {
+	config, err := ibctlconfig.ReadConfig(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := ibctlmigrate.Check(dirPath); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// SetEncryptionKey sets the key used to read data encrypted at rest. The key
+// is the decoded value of IBCTL_ENCRYPTION_KEY. This is process-wide.
+//
+// If a key is set, the merged data cache written by Merge is also sealed
+// with it, so that decrypted data is never written to disk.
+func SetEncryptionKey(key []byte) {
+	protoio.SetEncryption(key, key != nil)
+}
+
+// Merge merges the data in the ibctl directory of config across all
+// configured accounts, using and refreshing the merged data cache.
+func Merge(config *Config) (*MergedData, error) {
+	return ibctlmerge.Merge(
+		ibctlpath.DataAccountsDirPath(config.DirPath),
+		ibctlpath.CacheAccountsDirPath(config.DirPath),
+		ibctlpath.ActivityStatementsDirPath(config.DirPath),
+		ibctlpath.CacheActivityStatementsDirPath(config.DirPath),
+		ibctlpath.TradeConfirmationsDirPath(config.DirPath),
+		ibctlpath.CacheMergedDataFilePath(config.DirPath),
+		ibctlpath.SeedDirPath(config.DirPath),
+		ibctlpath.DataManualDirPath(config.DirPath),
+		config.AccountAliases,
+	)
+}
+
+// FilterAccounts returns a copy of mergedData restricted to the given account aliases.
+func FilterAccounts(mergedData *MergedData, accountAliases []string) *MergedData {
+	return ibctlmerge.FilterAccounts(mergedData, accountAliases)
+}
+
+// ComputeTaxLots computes FIFO tax lots and realized gains from trades, using
+// the average cost basis method for symbols configured with it in config.
+// A nil config is the default configuration, with no average cost symbols.
+func ComputeTaxLots(trades []*Trade, config *Config) (*TaxLotResult, error) {
+	var averageCostSymbols map[string]struct{}
+	if config != nil {
+		averageCostSymbols = config.AverageCostSymbols()
+	}
+	return ibctltaxlot.ComputeTaxLots(trades, averageCostSymbols)
+}
+
+// NewFXStore returns a new FXStore reading the FX rates downloaded to the
+// ibctl directory of config.
+func NewFXStore(config *Config) *FXStore {
+	return ibctlfxrates.NewStore(ibctlpath.CacheFXDirPath(config.DirPath))
+}
+
+// GetHoldingsOverview computes the holdings overview from mergedData,
+// aggregated across all accounts in mergedData.
+func GetHoldingsOverview(mergedData *MergedData, config *Config, fxStore *FXStore) (*HoldingsResult, error) {
+	return ibctlholdings.GetHoldingsOverview(
+		mergedData.Trades,
+		mergedData.Positions,
+		mergedData.CashPositions,
+		config,
+		fxStore,
+	)
+}
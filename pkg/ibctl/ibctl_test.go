@@ -0,0 +1,78 @@
+// Copyright 2026 Peter Edge
+//
+// All rights reserved.
+
+package ibctl_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bufdev/ibctl/pkg/ibctl"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeTaxLots(t *testing.T) {
+	t.Parallel()
+	trades := []*ibctl.Trade{
+		newTestTrade(t, "t1", 3, ibctl.TradeSideBuy, "10", "150"),
+		newTestTrade(t, "t2", 4, ibctl.TradeSideBuy, "30", "170"),
+	}
+	// A nil config has no average cost symbols, so the lots keep their own cost.
+	result, err := ibctl.ComputeTaxLots(trades, nil)
+	require.NoError(t, err)
+	require.Len(t, result.TaxLots, 2)
+	require.Equal(t, "150", ibctl.MoneyValueToString(result.TaxLots[0].GetCostBasisPrice()))
+	require.Equal(t, "170", ibctl.MoneyValueToString(result.TaxLots[1].GetCostBasisPrice()))
+	config := &ibctl.Config{
+		SymbolConfigs: map[string]ibctl.SymbolConfig{
+			"VFIAX": {CostBasis: ibctl.CostBasisAverage},
+		},
+	}
+	result, err = ibctl.ComputeTaxLots(trades, config)
+	require.NoError(t, err)
+	require.Len(t, result.TaxLots, 2)
+	require.Equal(t, "165", ibctl.MoneyValueToString(result.TaxLots[0].GetCostBasisPrice()))
+	require.Equal(t, "165", ibctl.MoneyValueToString(result.TaxLots[1].GetCostBasisPrice()))
+	// Selling 15 closes the first lot and 5 of the second.
+	trades = append(trades, newTestTrade(t, "t3", 5, ibctl.TradeSideSell, "-15", "180"))
+	result, err = ibctl.ComputeTaxLots(trades, nil)
+	require.NoError(t, err)
+	require.Len(t, result.TaxLots, 1)
+	require.Equal(t, "25", ibctl.DecimalToString(result.TaxLots[0].GetQuantity()))
+	require.Len(t, result.RealizedGains, 2)
+	realizedGain := result.RealizedGains[0]
+	require.Equal(t, "t3", realizedGain.TradeID)
+	require.Equal(t, int64(300_000_000), realizedGain.GainMicros)
+}
+
+func TestNewDate(t *testing.T) {
+	t.Parallel()
+	date, err := ibctl.NewDate(2025, time.March, 3)
+	require.NoError(t, err)
+	require.Equal(t, uint32(2025), date.GetYear())
+	_, err = ibctl.NewDate(2025, 13, 1)
+	require.Error(t, err)
+	_, err = ibctl.NewDecimal("not a number")
+	require.Error(t, err)
+}
+
+func newTestTrade(t *testing.T, tradeID string, day int, side ibctl.TradeSide, quantity string, price string) *ibctl.Trade {
+	t.Helper()
+	tradeDate, err := ibctl.NewDate(2025, time.March, day)
+	require.NoError(t, err)
+	quantityDecimal, err := ibctl.NewDecimal(quantity)
+	require.NoError(t, err)
+	tradePrice, err := ibctl.NewMoney("USD", price)
+	require.NoError(t, err)
+	return &ibctl.Trade{
+		TradeId:      tradeID,
+		AccountId:    "individual",
+		TradeDate:    tradeDate,
+		Symbol:       "VFIAX",
+		Side:         side,
+		Quantity:     quantityDecimal,
+		TradePrice:   tradePrice,
+		CurrencyCode: "USD",
+	}
+}